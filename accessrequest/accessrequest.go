@@ -0,0 +1,98 @@
+package accessrequest
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/webhook"
+)
+
+//go:generate mockgen -destination mocks/mock_accessrequest.go -package mock_accessrequest github.com/ystv/web-auth/accessrequest Repo
+
+type (
+	Repo interface {
+		GetRequest(context.Context, Request) (Request, error)
+		GetRequestsForUser(context.Context, int) ([]Request, error)
+		GetPendingRequests(context.Context, []int) ([]Request, error)
+		GetDecidedRequests(context.Context, []int, int) ([]Request, error)
+		AddRequest(context.Context, Request) (Request, error)
+		DecideRequest(context.Context, Request) (Request, error)
+	}
+
+	// Store stores the dependencies
+	Store struct {
+		db      *sqlx.DB
+		webhook webhook.Repo
+	}
+
+	// Request is a user asking to be added to a requestable role.Role, it is kept after it is decided as the history
+	Request struct {
+		RequestID   int         `db:"request_id" json:"requestID"`
+		RoleID      int         `db:"role_id" json:"roleID"`
+		UserID      int         `db:"user_id" json:"userID"`
+		Reason      string      `db:"reason" json:"reason"`
+		Status      Status      `db:"status" json:"status"`
+		RequestedAt time.Time   `db:"requested_at" json:"requestedAt"`
+		DecidedBy   null.Int    `db:"decided_by" json:"decidedBy"`
+		DecidedAt   null.Time   `db:"decided_at" json:"decidedAt"`
+		Comment     string      `db:"comment" json:"comment"`
+		EndsAt      null.Time   `db:"ends_at" json:"endsAt"`
+		RoleName    string      `db:"role_name" json:"roleName"`
+		UserName    string      `db:"user_name" json:"userName"`
+		DeciderName null.String `db:"decider_name" json:"deciderName"`
+	}
+
+	// Status is the state of a Request
+	Status string
+)
+
+const (
+	Pending   Status = "pending"
+	Approved  Status = "approved"
+	Denied    Status = "denied"
+	Cancelled Status = "cancelled"
+)
+
+var _ Repo = &Store{}
+
+// NewAccessRequestRepo stores our dependency
+func NewAccessRequestRepo(db *sqlx.DB, wh webhook.Repo) *Store {
+	return &Store{
+		db:      db,
+		webhook: wh,
+	}
+}
+
+// GetRequest returns a request
+func (s *Store) GetRequest(ctx context.Context, r Request) (Request, error) {
+	return s.getRequest(ctx, r)
+}
+
+// GetRequestsForUser returns every request a user has made, newest first
+func (s *Store) GetRequestsForUser(ctx context.Context, userID int) ([]Request, error) {
+	return s.getRequests(ctx, userID, nil, []Status{Pending, Approved, Denied, Cancelled}, 0)
+}
+
+// GetPendingRequests returns the requests waiting for a decision for the role ids, nil is every role
+func (s *Store) GetPendingRequests(ctx context.Context, roleIDs []int) ([]Request, error) {
+	return s.getRequests(ctx, 0, roleIDs, []Status{Pending}, 0)
+}
+
+// GetDecidedRequests returns the latest decided requests for the role ids, nil is every role
+func (s *Store) GetDecidedRequests(ctx context.Context, roleIDs []int, limit int) ([]Request, error) {
+	return s.getRequests(ctx, 0, roleIDs, []Status{Approved, Denied, Cancelled}, uint64(limit))
+}
+
+// AddRequest adds a pending request, a user can only have one pending request for a role
+func (s *Store) AddRequest(ctx context.Context, r Request) (Request, error) {
+	return s.addRequest(ctx, r)
+}
+
+// DecideRequest sets the outcome of a pending request, it fails if the request has already been decided. An approval
+// gives the user the role in the same transaction so a request can't be approved without the membership
+func (s *Store) DecideRequest(ctx context.Context, r Request) (Request, error) {
+	return s.decideRequest(ctx, r)
+}
@@ -0,0 +1,236 @@
+package accessrequest
+
+import (
+	"context"
+	dbSQL "database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/utils"
+	"github.com/ystv/web-auth/webhook"
+)
+
+// requestBuilder selects requests with the names needed to show them
+func requestBuilder() sq.SelectBuilder {
+	return utils.PSQL().Select("ar.*", "r.name AS role_name",
+		"CONCAT(u.first_name, ' ', u.last_name) AS user_name",
+		"CASE WHEN d.user_id IS NULL THEN NULL ELSE CONCAT(d.first_name, ' ', d.last_name) END AS decider_name").
+		From("people.access_requests ar").
+		InnerJoin("people.roles r ON r.role_id = ar.role_id").
+		InnerJoin("people.users u ON u.user_id = ar.user_id").
+		LeftJoin("people.users d ON d.user_id = ar.decided_by")
+}
+
+func (s *Store) getRequest(ctx context.Context, r1 Request) (Request, error) {
+	var r Request
+
+	builder := requestBuilder().
+		Where(sq.Eq{"ar.request_id": r1.RequestID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getRequest: %w", err))
+	}
+
+	err = s.db.GetContext(ctx, &r, sql, args...)
+	if err != nil {
+		return Request{}, fmt.Errorf("failed to get access request: %w", err)
+	}
+
+	return r, nil
+}
+
+// getRequests returns the requests with the statuses, userID of 0 and nil roleIDs aren't filtered on and a limit
+// of 0 returns everything
+func (s *Store) getRequests(ctx context.Context, userID int, roleIDs []int, statuses []Status,
+	limit uint64) ([]Request, error) {
+	var r []Request
+
+	builder := requestBuilder().
+		Where(sq.Eq{"ar.status": statuses}).
+		OrderBy("COALESCE(ar.decided_at, ar.requested_at) DESC")
+
+	if userID != 0 {
+		builder = builder.Where(sq.Eq{"ar.user_id": userID})
+	}
+
+	if roleIDs != nil {
+		builder = builder.Where(sq.Eq{"ar.role_id": roleIDs})
+	}
+
+	if limit > 0 {
+		builder = builder.Limit(limit)
+	}
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getRequests: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &r, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access requests: %w", err)
+	}
+
+	return r, nil
+}
+
+func (s *Store) addRequest(ctx context.Context, r Request) (Request, error) {
+	builder := utils.PSQL().Insert("people.access_requests").
+		Columns("role_id", "user_id", "reason").
+		Values(r.RoleID, r.UserID, r.Reason).
+		Suffix("RETURNING request_id")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for addRequest: %w", err))
+	}
+
+	err = s.db.QueryRowxContext(ctx, sql, args...).Scan(&r.RequestID)
+	if err != nil {
+		return Request{}, fmt.Errorf("failed to add access request: %w", err)
+	}
+
+	return s.getRequest(ctx, r)
+}
+
+func (s *Store) decideRequest(ctx context.Context, r Request) (Request, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Request{}, fmt.Errorf("failed to begin decide access request transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	builder := utils.PSQL().Update("people.access_requests").
+		SetMap(map[string]interface{}{
+			"status":     r.Status,
+			"decided_by": r.DecidedBy,
+			"decided_at": time.Now(),
+			"comment":    r.Comment,
+			"ends_at":    r.EndsAt,
+		}).
+		Where(sq.And{
+			sq.Eq{"request_id": r.RequestID},
+			sq.Eq{"status": Pending},
+		}).
+		Suffix("RETURNING role_id, user_id, reason")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for decideRequest: %w", err))
+	}
+
+	err = tx.QueryRowxContext(ctx, sql, args...).Scan(&r.RoleID, &r.UserID, &r.Reason)
+	if err != nil {
+		if errors.Is(err, dbSQL.ErrNoRows) {
+			return Request{}, fmt.Errorf("failed to decide access request: request %d is not pending", r.RequestID)
+		}
+
+		return Request{}, fmt.Errorf("failed to decide access request: %w", err)
+	}
+
+	if r.Status == Approved {
+		err = s.grantRole(ctx, tx, r)
+		if err != nil {
+			return Request{}, fmt.Errorf("failed to grant role for access request: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Request{}, fmt.Errorf("failed to commit decide access request: %w", err)
+	}
+
+	return s.getRequest(ctx, r)
+}
+
+// grantRole gives the requester the role in the decision's transaction. When they already hold it the membership
+// becomes the approved one, it starts now, keeps the later of the two ends (a permanent membership stays
+// permanent) and is no longer owned by a sync so the officership and membership syncs won't revoke it
+func (s *Store) grantRole(ctx context.Context, tx *sqlx.Tx, r Request) error {
+	var ru user.RoleUser
+
+	reason := fmt.Sprintf("Access request %d: %s", r.RequestID, r.Reason)
+
+	builder := utils.PSQL().Select("*").
+		From("people.role_members").
+		Where(sq.And{
+			sq.Eq{"role_id": r.RoleID},
+			sq.Eq{"user_id": r.UserID},
+		}).
+		Suffix("FOR UPDATE")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for grantRole: %w", err))
+	}
+
+	err = tx.GetContext(ctx, &ru, sql, args...)
+
+	switch {
+	case errors.Is(err, dbSQL.ErrNoRows):
+		sql, args, err = utils.PSQL().Insert("people.role_members").
+			Columns("role_id", "user_id", "ends_at", "granted_by", "reason").
+			Values(r.RoleID, r.UserID, r.EndsAt, r.DecidedBy, reason).
+			Suffix("RETURNING *").
+			ToSql()
+	case err != nil:
+		return fmt.Errorf("failed to get existing role user: %w", err)
+	default:
+		sql, args, err = utils.PSQL().Update("people.role_members").
+			SetMap(map[string]interface{}{
+				"starts_at":          nil,
+				"ends_at":            laterEnd(ru.EndsAt, r.EndsAt),
+				"granted_by":         r.DecidedBy,
+				"granted_at":         time.Now(),
+				"reason":             reason,
+				"officership_sync":   false,
+				"membership_sync":    false,
+				"expiry_notified_at": nil,
+			}).
+			Where(sq.And{
+				sq.Eq{"role_id": r.RoleID},
+				sq.Eq{"user_id": r.UserID},
+			}).
+			Suffix("RETURNING *").
+			ToSql()
+	}
+
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for grantRole: %w", err))
+	}
+
+	err = tx.GetContext(ctx, &ru, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to grant role user: %w", err)
+	}
+
+	err = s.webhook.Emit(ctx, tx, webhook.RoleUserAdded, ru)
+	if err != nil {
+		return fmt.Errorf("failed to emit role user added: %w", err)
+	}
+
+	return nil
+}
+
+// laterEnd returns the later of two membership ends, no end is later than any time
+func laterEnd(a, b null.Time) null.Time {
+	if !a.Valid || !b.Valid {
+		return null.Time{}
+	}
+
+	if a.Time.After(b.Time) {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,20 @@
+package accessrequest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/guregu/null.v4"
+)
+
+func TestLaterEnd(t *testing.T) {
+	soon := null.TimeFrom(time.Now().AddDate(0, 0, 7))
+	later := null.TimeFrom(time.Now().AddDate(0, 1, 0))
+
+	// approving a temporary request mustn't cut short a permanent membership the user already has
+	assert.False(t, laterEnd(null.Time{}, soon).Valid)
+	assert.False(t, laterEnd(soon, null.Time{}).Valid)
+	assert.Equal(t, later, laterEnd(soon, later))
+	assert.Equal(t, later, laterEnd(later, soon))
+}
@@ -0,0 +1,141 @@
+package accessreview
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+)
+
+//go:generate mockgen -destination mocks/mock_accessreview.go -package mock_accessreview github.com/ystv/web-auth/accessreview Repo
+
+type (
+	Repo interface {
+		GetReviews(context.Context) ([]Review, error)
+		GetReview(context.Context, Review) (Review, error)
+		AddReview(context.Context, Review, []int) (Review, error)
+		GetItems(context.Context, Review) ([]Item, error)
+		GetItem(context.Context, Item) (Item, error)
+		GetPendingItems(context.Context, []int) ([]Item, error)
+		GetOverdueItems(context.Context) ([]Item, error)
+		GetItemsForUser(context.Context, int) ([]Item, error)
+		DecideItem(context.Context, Item) (Item, error)
+		CompleteReviews(context.Context) ([]Review, error)
+		SetReviewReminded(context.Context, Review) error
+	}
+
+	// Store stores the dependencies
+	Store struct {
+		db *sqlx.DB
+	}
+
+	// Review is a campaign to check the members of some roles should still have them
+	Review struct {
+		ReviewID    int       `db:"review_id" json:"reviewID"`
+		Name        string    `db:"name" json:"name"`
+		Deadline    time.Time `db:"deadline" json:"deadline"`
+		AutoRevoke  bool      `db:"auto_revoke" json:"autoRevoke"`
+		CreatedBy   null.Int  `db:"created_by" json:"createdBy"`
+		CreatedAt   time.Time `db:"created_at" json:"createdAt"`
+		CompletedAt null.Time `db:"completed_at" json:"completedAt"`
+		RemindedAt  null.Time `db:"reminded_at" json:"remindedAt"`
+		Items       int       `db:"items" json:"items"`
+		Pending     int       `db:"pending" json:"pending"`
+		Revoked     int       `db:"revoked" json:"revoked"`
+	}
+
+	// Item is a membership of a role to be kept or revoked in a Review
+	Item struct {
+		ItemID      int       `db:"item_id" json:"itemID"`
+		ReviewID    int       `db:"review_id" json:"reviewID"`
+		RoleID      int       `db:"role_id" json:"roleID"`
+		UserID      int       `db:"user_id" json:"userID"`
+		Decision    Decision  `db:"decision" json:"decision"`
+		DecidedBy   null.Int  `db:"decided_by" json:"decidedBy"`
+		DecidedAt   null.Time `db:"decided_at" json:"decidedAt"`
+		Comment     string    `db:"comment" json:"comment"`
+		AutoRevoked bool      `db:"auto_revoked" json:"autoRevoked"`
+		ReviewName  string    `db:"review_name" json:"reviewName"`
+		Deadline    time.Time `db:"deadline" json:"deadline"`
+		// ReviewCompletedAt is set once the review is over, its items can't be decided after that
+		ReviewCompletedAt null.Time   `db:"review_completed_at" json:"reviewCompletedAt"`
+		RoleName          string      `db:"role_name" json:"roleName"`
+		UserName          string      `db:"user_name" json:"userName"`
+		DeciderName       null.String `db:"decider_name" json:"deciderName"`
+	}
+
+	// Decision is the outcome of an Item
+	Decision string
+)
+
+const (
+	Pending Decision = "pending"
+	Keep    Decision = "keep"
+	Revoke  Decision = "revoke"
+)
+
+var _ Repo = &Store{}
+
+// NewAccessReviewRepo stores our dependency
+func NewAccessReviewRepo(db *sqlx.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+// GetReviews returns every review with how far through it is, newest first
+func (s *Store) GetReviews(ctx context.Context) ([]Review, error) {
+	return s.getReviews(ctx)
+}
+
+// GetReview returns a review
+func (s *Store) GetReview(ctx context.Context, r Review) (Review, error) {
+	return s.getReview(ctx, r)
+}
+
+// AddReview starts a review of the current members of the roles
+func (s *Store) AddReview(ctx context.Context, r Review, roleIDs []int) (Review, error) {
+	return s.addReview(ctx, r, roleIDs)
+}
+
+// GetItems returns every item of a review
+func (s *Store) GetItems(ctx context.Context, r Review) ([]Item, error) {
+	return s.getItems(ctx, r)
+}
+
+// GetItem returns an item
+func (s *Store) GetItem(ctx context.Context, i Item) (Item, error) {
+	return s.getItem(ctx, i)
+}
+
+// GetPendingItems returns the items of the open reviews still to be decided for the role ids, nil is every role
+func (s *Store) GetPendingItems(ctx context.Context, roleIDs []int) ([]Item, error) {
+	return s.getPendingItems(ctx, roleIDs)
+}
+
+// GetOverdueItems returns the pending items of the open reviews past their deadline that auto revoke
+func (s *Store) GetOverdueItems(ctx context.Context) ([]Item, error) {
+	return s.getOverdueItems(ctx)
+}
+
+// GetItemsForUser returns every item about a user across all reviews, newest review first
+func (s *Store) GetItemsForUser(ctx context.Context, userID int) ([]Item, error) {
+	return s.getItemsForUser(ctx, userID)
+}
+
+// DecideItem keeps or revokes a pending item, it fails if the item has already been decided
+func (s *Store) DecideItem(ctx context.Context, i Item) (Item, error) {
+	return s.decideItem(ctx, i)
+}
+
+// CompleteReviews completes the open reviews that are past their deadline or have nothing left to decide and
+// returns them
+func (s *Store) CompleteReviews(ctx context.Context) ([]Review, error) {
+	return s.completeReviews(ctx)
+}
+
+// SetReviewReminded records that the reviewers have been reminded
+func (s *Store) SetReviewReminded(ctx context.Context, r Review) error {
+	return s.setReviewReminded(ctx, r)
+}
@@ -0,0 +1,335 @@
+package accessreview
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/ystv/web-auth/utils"
+)
+
+// reviewBuilder selects reviews with counts of their items
+func reviewBuilder() sq.SelectBuilder {
+	return utils.PSQL().Select("ar.*", "COUNT(ari.item_id) AS items",
+		"COUNT(ari.item_id) FILTER (WHERE ari.decision = 'pending') AS pending",
+		"COUNT(ari.item_id) FILTER (WHERE ari.decision = 'revoke') AS revoked").
+		From("people.access_reviews ar").
+		LeftJoin("people.access_review_items ari ON ari.review_id = ar.review_id").
+		GroupBy("ar.review_id")
+}
+
+// itemBuilder selects items with the names needed to show them
+func itemBuilder() sq.SelectBuilder {
+	return utils.PSQL().Select("ari.*", "ar.name AS review_name", "ar.deadline",
+		"ar.completed_at AS review_completed_at", "r.name AS role_name",
+		"CONCAT(u.first_name, ' ', u.last_name) AS user_name",
+		"CASE WHEN d.user_id IS NULL THEN NULL ELSE CONCAT(d.first_name, ' ', d.last_name) END AS decider_name").
+		From("people.access_review_items ari").
+		InnerJoin("people.access_reviews ar ON ar.review_id = ari.review_id").
+		InnerJoin("people.roles r ON r.role_id = ari.role_id").
+		InnerJoin("people.users u ON u.user_id = ari.user_id").
+		LeftJoin("people.users d ON d.user_id = ari.decided_by")
+}
+
+func (s *Store) getReviews(ctx context.Context) ([]Review, error) {
+	var r []Review
+
+	builder := reviewBuilder().
+		OrderBy("ar.created_at DESC")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getReviews: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &r, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access reviews: %w", err)
+	}
+
+	return r, nil
+}
+
+func (s *Store) getReview(ctx context.Context, r1 Review) (Review, error) {
+	var r Review
+
+	builder := reviewBuilder().
+		Where(sq.Eq{"ar.review_id": r1.ReviewID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getReview: %w", err))
+	}
+
+	err = s.db.GetContext(ctx, &r, sql, args...)
+	if err != nil {
+		return Review{}, fmt.Errorf("failed to get access review: %w", err)
+	}
+
+	return r, nil
+}
+
+// addReview adds the review and copies the current direct members of the roles into it in one transaction
+func (s *Store) addReview(ctx context.Context, r Review, roleIDs []int) (Review, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Review{}, fmt.Errorf("failed to begin access review transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	builder := utils.PSQL().Insert("people.access_reviews").
+		Columns("name", "deadline", "auto_revoke", "created_by").
+		Values(r.Name, r.Deadline, r.AutoRevoke, r.CreatedBy).
+		Suffix("RETURNING review_id")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for addReview review: %w", err))
+	}
+
+	err = tx.QueryRowxContext(ctx, sql, args...).Scan(&r.ReviewID)
+	if err != nil {
+		return Review{}, fmt.Errorf("failed to add access review: %w", err)
+	}
+
+	members := utils.PSQL().Select().
+		Column(sq.Expr("?::int", r.ReviewID)).
+		Columns("rm.role_id", "rm.user_id").
+		From("people.role_members rm").
+		Where(sq.And{
+			sq.Eq{"rm.role_id": roleIDs},
+			sq.Expr("(rm.starts_at IS NULL OR rm.starts_at <= NOW())"),
+			sq.Expr("(rm.ends_at IS NULL OR rm.ends_at > NOW())"),
+		})
+
+	insert := utils.PSQL().Insert("people.access_review_items").
+		Columns("review_id", "role_id", "user_id").
+		Select(members)
+
+	sql, args, err = insert.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for addReview items: %w", err))
+	}
+
+	_, err = tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return Review{}, fmt.Errorf("failed to add access review items: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Review{}, fmt.Errorf("failed to commit access review: %w", err)
+	}
+
+	return s.getReview(ctx, r)
+}
+
+func (s *Store) getItems(ctx context.Context, r Review) ([]Item, error) {
+	var i []Item
+
+	builder := itemBuilder().
+		Where(sq.Eq{"ari.review_id": r.ReviewID}).
+		OrderBy("r.name", "user_name")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getItems: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &i, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access review items: %w", err)
+	}
+
+	return i, nil
+}
+
+func (s *Store) getItem(ctx context.Context, i1 Item) (Item, error) {
+	var i Item
+
+	builder := itemBuilder().
+		Where(sq.Eq{"ari.item_id": i1.ItemID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getItem: %w", err))
+	}
+
+	err = s.db.GetContext(ctx, &i, sql, args...)
+	if err != nil {
+		return Item{}, fmt.Errorf("failed to get access review item: %w", err)
+	}
+
+	return i, nil
+}
+
+func (s *Store) getPendingItems(ctx context.Context, roleIDs []int) ([]Item, error) {
+	var i []Item
+
+	builder := itemBuilder().
+		Where(sq.And{
+			sq.Eq{"ari.decision": Pending},
+			sq.Eq{"ar.completed_at": nil},
+		}).
+		OrderBy("ar.deadline", "r.name", "user_name")
+
+	if roleIDs != nil {
+		builder = builder.Where(sq.Eq{"ari.role_id": roleIDs})
+	}
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getPendingItems: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &i, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending access review items: %w", err)
+	}
+
+	return i, nil
+}
+
+func (s *Store) getOverdueItems(ctx context.Context) ([]Item, error) {
+	var i []Item
+
+	builder := itemBuilder().
+		Where(sq.And{
+			sq.Eq{"ari.decision": Pending},
+			sq.Eq{"ar.completed_at": nil},
+			sq.Eq{"ar.auto_revoke": true},
+			sq.LtOrEq{"ar.deadline": time.Now()},
+		})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getOverdueItems: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &i, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get overdue access review items: %w", err)
+	}
+
+	return i, nil
+}
+
+func (s *Store) getItemsForUser(ctx context.Context, userID int) ([]Item, error) {
+	var i []Item
+
+	builder := itemBuilder().
+		Where(sq.Eq{"ari.user_id": userID}).
+		OrderBy("ar.deadline DESC", "r.name")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getItemsForUser: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &i, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access review items for user: %w", err)
+	}
+
+	return i, nil
+}
+
+func (s *Store) decideItem(ctx context.Context, i Item) (Item, error) {
+	sql, args, err := decideItemBuilder(i).ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for decideItem: %w", err))
+	}
+
+	res, err := s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return Item{}, fmt.Errorf("failed to decide access review item: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return Item{}, fmt.Errorf("failed to decide access review item: %w", err)
+	}
+
+	if rows < 1 {
+		return Item{}, fmt.Errorf("failed to decide access review item: item %d is not pending or its review has "+
+			"been completed", i.ItemID)
+	}
+
+	return s.getItem(ctx, i)
+}
+
+// decideItemBuilder only decides a pending item of a review that is still open, a review is completed once its
+// deadline passes so the items left pending stay that way
+func decideItemBuilder(i Item) sq.UpdateBuilder {
+	openReviews := utils.PSQL().Select("ar.review_id").
+		From("people.access_reviews ar").
+		Where(sq.Eq{"ar.completed_at": nil})
+
+	return utils.PSQL().Update("people.access_review_items").
+		SetMap(map[string]interface{}{
+			"decision":     i.Decision,
+			"decided_by":   i.DecidedBy,
+			"decided_at":   time.Now(),
+			"comment":      i.Comment,
+			"auto_revoked": i.AutoRevoked,
+		}).
+		Where(sq.And{
+			sq.Eq{"item_id": i.ItemID},
+			sq.Eq{"decision": Pending},
+			sq.Expr("review_id IN (?)", openReviews),
+		})
+}
+
+func (s *Store) completeReviews(ctx context.Context) ([]Review, error) {
+	var r []Review
+
+	subQuery := utils.PSQL().Select("ari.review_id").
+		From("people.access_review_items ari").
+		Where(sq.Eq{"ari.decision": Pending})
+
+	builder := utils.PSQL().Update("people.access_reviews").
+		Set("completed_at", time.Now()).
+		Where(sq.And{
+			sq.Eq{"completed_at": nil},
+			sq.Or{
+				sq.LtOrEq{"deadline": time.Now()},
+				utils.NotIn("review_id", subQuery),
+			},
+		}).
+		Suffix("RETURNING *")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for completeReviews: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &r, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete access reviews: %w", err)
+	}
+
+	return r, nil
+}
+
+func (s *Store) setReviewReminded(ctx context.Context, r Review) error {
+	builder := utils.PSQL().Update("people.access_reviews").
+		Set("reminded_at", time.Now()).
+		Where(sq.Eq{"review_id": r.ReviewID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for setReviewReminded: %w", err))
+	}
+
+	_, err = s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to set access review reminded: %w", err)
+	}
+
+	return nil
+}
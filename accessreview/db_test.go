@@ -0,0 +1,19 @@
+package accessreview
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v4"
+)
+
+func TestDecideItemSQL(t *testing.T) {
+	sql, args, err := decideItemBuilder(Item{ItemID: 5, Decision: Keep, DecidedBy: null.IntFrom(2)}).ToSql()
+	require.NoError(t, err)
+
+	// an item left pending when its review completed can't be decided afterwards
+	assert.Contains(t, sql, "WHERE (item_id = $6 AND decision = $7 AND review_id IN "+
+		"(SELECT ar.review_id FROM people.access_reviews ar WHERE ar.completed_at IS NULL))")
+	assert.Equal(t, []interface{}{5, Pending}, args[5:])
+}
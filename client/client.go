@@ -0,0 +1,194 @@
+// Package client is a small helper for other YSTV services to ask web-auth whether a user has a permission,
+// web-auth applies the permission hierarchy and SuperUser so the services don't have to
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type (
+	// Client calls the web-auth API using an API token made on the manage API page
+	Client struct {
+		baseURL    string
+		token      string
+		httpClient *http.Client
+	}
+
+	// Check is a single question for the authorize endpoint, either UserID or Token is set to pick the user,
+	// leaving both empty checks the user of the API token
+	Check struct {
+		UserID     int    `json:"userID,omitempty"`
+		Token      string `json:"token,omitempty"`
+		Permission string `json:"permission"`
+	}
+
+	// AuthorizeRequest is the body of the authorize endpoint
+	AuthorizeRequest struct {
+		Checks []Check `json:"checks"`
+	}
+
+	// Result is the answer to a Check, they are returned in the same order as the checks
+	Result struct {
+		UserID     int    `json:"userID"`
+		Permission string `json:"permission"`
+		Allowed    bool   `json:"allowed"`
+		Error      string `json:"error,omitempty"`
+	}
+
+	// AuthorizeResponse is the response of the authorize endpoint
+	AuthorizeResponse struct {
+		Results []Result `json:"results"`
+	}
+
+	// EffectivePermissions is every permission a user has, including the ones implied by others
+	EffectivePermissions struct {
+		UserID      int          `json:"userID"`
+		SuperUser   bool         `json:"superUser"`
+		Permissions []Permission `json:"permissions"`
+	}
+
+	// UserInfo is who a user is, Emails are all their verified addresses with the primary one first
+	UserInfo struct {
+		UserID    int      `json:"userID"`
+		Username  string   `json:"username"`
+		Firstname string   `json:"firstname"`
+		Nickname  string   `json:"nickname"`
+		Lastname  string   `json:"lastname"`
+		Email     string   `json:"email"`
+		Emails    []string `json:"emails"`
+		// Status is one of member, alumni, honorary or suspended
+		Status string `json:"status"`
+	}
+
+	// Permission is a permission a user has
+	Permission struct {
+		PermissionID int    `json:"permissionID"`
+		Name         string `json:"name"`
+		Description  string `json:"description"`
+	}
+)
+
+// New makes a Client for the web-auth at baseURL, i.e. https://auth.ystv.co.uk
+func New(baseURL, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Authorize answers a batch of checks in one request
+func (c *Client) Authorize(ctx context.Context, checks ...Check) ([]Result, error) {
+	var res AuthorizeResponse
+
+	err := c.do(ctx, http.MethodPost, "/api/v1/authorize", AuthorizeRequest{Checks: checks}, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Results) != len(checks) {
+		return nil, fmt.Errorf("failed to authorize: expected %d results, got %d", len(checks), len(res.Results))
+	}
+
+	return res.Results, nil
+}
+
+// HasPermission returns if a user has a permission
+func (c *Client) HasPermission(ctx context.Context, userID int, permission string) (bool, error) {
+	return c.check(ctx, Check{UserID: userID, Permission: permission})
+}
+
+// TokenHasPermission returns if the user of a JWT issued by web-auth has a permission, the permissions are
+// looked up again rather than trusting the perms claim in the token
+func (c *Client) TokenHasPermission(ctx context.Context, token, permission string) (bool, error) {
+	return c.check(ctx, Check{Token: token, Permission: permission})
+}
+
+// EffectivePermissions returns every permission a user has
+func (c *Client) EffectivePermissions(ctx context.Context, userID int) (EffectivePermissions, error) {
+	var res EffectivePermissions
+
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/users/%d/effective-permissions", userID), nil, &res)
+	if err != nil {
+		return EffectivePermissions{}, err
+	}
+
+	return res, nil
+}
+
+// UserInfo returns who a user is and their email addresses
+func (c *Client) UserInfo(ctx context.Context, userID int) (UserInfo, error) {
+	var res UserInfo
+
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/users/%d/userinfo", userID), nil, &res)
+	if err != nil {
+		return UserInfo{}, err
+	}
+
+	return res, nil
+}
+
+func (c *Client) check(ctx context.Context, check Check) (bool, error) {
+	res, err := c.Authorize(ctx, check)
+	if err != nil {
+		return false, err
+	}
+
+	if res[0].Error != "" {
+		return false, errors.New(res[0].Error)
+	}
+
+	return res[0].Allowed, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var buf bytes.Buffer
+
+	if body != nil {
+		err := json.NewEncoder(&buf).Encode(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, &buf)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call web-auth: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+
+		_ = json.NewDecoder(resp.Body).Decode(&e)
+
+		return fmt.Errorf("web-auth returned %d: %s", resp.StatusCode, e.Error)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(out)
+	if err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer service-token", r.Header.Get("Authorization"))
+
+		switch r.URL.Path {
+		case "/api/v1/authorize":
+			var req AuthorizeRequest
+
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+			res := AuthorizeResponse{}
+			for _, c := range req.Checks {
+				res.Results = append(res.Results, Result{
+					UserID:     c.UserID,
+					Permission: c.Permission,
+					Allowed:    c.Permission == "ManageMembers.Groups",
+				})
+			}
+
+			_ = json.NewEncoder(w).Encode(res)
+		case "/api/v1/users/5/effective-permissions":
+			_ = json.NewEncoder(w).Encode(EffectivePermissions{
+				UserID:      5,
+				Permissions: []Permission{{PermissionID: 1, Name: "ManageMembers.Groups"}},
+			})
+		case "/api/v1/users/5/userinfo":
+			_ = json.NewEncoder(w).Encode(UserInfo{
+				UserID: 5,
+				Email:  "primary@example.com",
+				Emails: []string{"primary@example.com", "other@york.ac.uk"},
+			})
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":"you are not authorised for accessing this"}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL+"/", "service-token")
+
+	allowed, err := c.HasPermission(context.Background(), 5, "ManageMembers.Groups")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	res, err := c.Authorize(context.Background(), Check{UserID: 5, Permission: "ManageMembers.Groups"},
+		Check{UserID: 5, Permission: "SuperUser"})
+	require.NoError(t, err)
+	assert.True(t, res[0].Allowed)
+	assert.False(t, res[1].Allowed)
+
+	perms, err := c.EffectivePermissions(context.Background(), 5)
+	require.NoError(t, err)
+	assert.Equal(t, "ManageMembers.Groups", perms.Permissions[0].Name)
+
+	info, err := c.UserInfo(context.Background(), 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"primary@example.com", "other@york.ac.uk"}, info.Emails)
+
+	_, err = c.EffectivePermissions(context.Background(), 6)
+	assert.EqualError(t, err, "web-auth returned 403: you are not authorised for accessing this")
+}
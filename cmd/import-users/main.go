@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/joho/godotenv"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zlog "github.com/rs/zerolog/log"
+	"github.com/rs/zerolog/pkgerrors"
+
+	"github.com/ystv/web-auth/emailchange"
+	"github.com/ystv/web-auth/emailtemplate"
+	"github.com/ystv/web-auth/infrastructure/db"
+	"github.com/ystv/web-auth/infrastructure/mail"
+	"github.com/ystv/web-auth/mailqueue"
+	"github.com/ystv/web-auth/role"
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/userimport"
+	"github.com/ystv/web-auth/utils"
+	"github.com/ystv/web-auth/webhook"
+)
+
+func main() {
+	//nolint:reassign
+	zerolog.ErrorStackMarshaler = pkgerrors.MarshalStack
+
+	logger := utils.NewLogger(zlog.With().
+		Str("service", "web-auth").
+		Logger(), utils.DefaultSkipper)
+
+	// Load environment
+	err := godotenv.Load(".env")
+	if err != nil {
+		logger.Warn(nil, "failed to load global env file")
+	} // Load .env file for production
+	err = godotenv.Overload(".env.local") // Load .env.local for developing
+	if err != nil {
+		logger.Warn(nil, "failed to load env file, using global env")
+	}
+
+	file := flag.String("file", "", "the csv of users to import")
+	columns := flag.String("columns", "", "comma separated field=header pairs for columns that aren't found "+
+		"automatically, e.g. \"email=Email Address,first_name=Forename\"")
+	roles := flag.String("roles", "", "comma separated names of the roles every imported user is added to")
+	importedBy := flag.Int("imported_by", 0, "the user id the import is made by, required with -run")
+	sendEmail := flag.Bool("send_email", false, "email the created users their username and password")
+	run := flag.Bool("run", false, "make the changes, without this the import is only previewed")
+	flag.Parse()
+
+	if *file == "" {
+		logger.Fatal(nil, errors.New("file not set"))
+	}
+
+	if *run && *importedBy == 0 {
+		logger.Fatal(nil, errors.New("imported_by must be set to run the import"))
+	}
+
+	mapping := make(userimport.Mapping)
+
+	for _, c := range strings.Split(*columns, ",") {
+		if strings.TrimSpace(c) == "" {
+			continue
+		}
+
+		field, header, ok := strings.Cut(c, "=")
+		if !ok {
+			logger.Fatal(nil, errors.Errorf("invalid column \"%s\", must be field=header", c))
+		}
+
+		mapping[userimport.Field(strings.TrimSpace(field))] = header
+	}
+
+	host := os.Getenv("WAUTH_DB_HOST")
+
+	if host == "" {
+		logger.Fatal(nil, errors.New("database host not set"))
+	}
+	dbConnectionString := fmt.Sprintf(
+		"host=%s port=%s user=%s dbname=%s sslmode=%s password=%s",
+		host,
+		os.Getenv("WAUTH_DB_PORT"),
+		os.Getenv("WAUTH_DB_USER"),
+		os.Getenv("WAUTH_DB_NAME"),
+		os.Getenv("WAUTH_DB_SSLMODE"),
+		os.Getenv("WAUTH_DB_PASS"),
+	)
+	database := db.NewStore(dbConnectionString, host, logger)
+
+	ctx := context.Background()
+
+	hooks := webhook.NewWebhookRepo(database)
+	users := user.NewUserRepo(database, os.Getenv("WAUTH_CDN_ENDPOINT"), hooks)
+	roleRepo := role.NewRoleRepo(database, hooks)
+
+	var roleIDs []int
+
+	for _, name := range strings.Split(*roles, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+
+		r, err := roleRepo.GetRole(ctx, role.Role{Name: strings.TrimSpace(name)})
+		if err != nil {
+			logger.Fatal(nil, errors.Errorf("failed to get role \"%s\": %v", name, err))
+		}
+
+		roleIDs = append(roleIDs, r.RoleID)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		logger.Fatal(nil, errors.Errorf("failed to open file: %v", err))
+	}
+
+	defer f.Close()
+
+	rows, err := userimport.Parse(f, mapping)
+	if err != nil {
+		logger.Fatal(nil, errors.Errorf("failed to parse csv: %v", err))
+	}
+
+	rows, err = userimport.Plan(ctx, users, rows)
+	if err != nil {
+		logger.Fatal(nil, errors.Errorf("failed to plan import: %v", err))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "LINE\tACTION\tUSERNAME\tEMAIL\tDETAILS")
+
+	for _, row := range rows {
+		details := strings.Join(row.Problems, "; ")
+		if row.Action == userimport.Update {
+			details = "existing user id " + strconv.Itoa(row.ExistingUserID)
+			for _, c := range row.Changes {
+				details += ", " + string(c)
+			}
+		}
+
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", row.Line, row.Action, row.User.Username, row.User.Email,
+			details)
+	}
+
+	_ = w.Flush()
+
+	if !*run {
+		logger.Info(nil, "previewed %d rows, use -run to import them", len(rows))
+		return
+	}
+
+	job := userimport.NewJob(rows, userimport.Options{
+		RoleIDs:    roleIDs,
+		SendEmail:  *sendEmail,
+		ImportedBy: *importedBy,
+	})
+
+	mailPort, _ := strconv.Atoi(os.Getenv("WAUTH_MAIL_PORT"))
+
+	queue := mailqueue.NewMailQueueRepo(database, mail.NewMailer(mail.Config{
+		Host:       os.Getenv("WAUTH_MAIL_HOST"),
+		Port:       mailPort,
+		Username:   os.Getenv("WAUTH_MAIL_USER"),
+		Password:   os.Getenv("WAUTH_MAIL_PASS"),
+		DomainName: os.Getenv("WAUTH_DOMAIN_NAME"),
+	}))
+
+	welcome := userimport.NewWelcome(queue, emailtemplate.NewEmailTemplateRepo(database))
+
+	changeEmail := userimport.NewChangeEmail(emailchange.NewEmailChangeRepo(database, hooks))
+
+	if err = job.Run(ctx, users, changeEmail, welcome); err != nil {
+		logger.Fatal(nil, errors.Errorf("failed to run import: %v", err))
+	}
+
+	// the emails are queued, the first of them are sent now and the rest by web-auth's mail queue
+	if *sendEmail {
+		if err = queue.SendPending(ctx); err != nil {
+			logger.Warn(nil, "failed to send queued emails, web-auth will retry them: %v", err)
+		}
+	}
+
+	p := job.Progress()
+
+	for _, e := range p.Errors {
+		logger.Warn(nil, "%s", e)
+	}
+
+	logger.Info(nil, "import ran successfully: %d created, %d updated, %d skipped, %d failed, %d emailed",
+		p.Created, p.Updated, p.Skipped, p.Failed, p.Emailed)
+}
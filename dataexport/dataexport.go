@@ -0,0 +1,87 @@
+package dataexport
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/accessrequest"
+	"github.com/ystv/web-auth/accessreview"
+	"github.com/ystv/web-auth/api"
+	"github.com/ystv/web-auth/emailchange"
+	"github.com/ystv/web-auth/keylist"
+	"github.com/ystv/web-auth/mailqueue"
+	"github.com/ystv/web-auth/membership"
+	"github.com/ystv/web-auth/officership"
+	"github.com/ystv/web-auth/permission"
+	"github.com/ystv/web-auth/role"
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/useremail"
+	"github.com/ystv/web-auth/userstatus"
+	"github.com/ystv/web-auth/webhook"
+)
+
+//go:generate mockgen -destination mocks/mock_dataexport.go -package mock_dataexport github.com/ystv/web-auth/dataexport Repo
+
+type (
+	Repo interface {
+		AddExport(context.Context, Export) (Export, error)
+		GetExportsForUser(context.Context, user.User) ([]Export, error)
+	}
+
+	// Store stores the dependencies
+	Store struct {
+		db *sqlx.DB
+	}
+
+	// Export is a record of a user's data being downloaded
+	Export struct {
+		ExportID   int         `db:"export_id" json:"exportID"`
+		UserID     int         `db:"user_id" json:"userID"`
+		ExportedAt time.Time   `db:"exported_at" json:"exportedAt"`
+		ExportedBy null.Int    `db:"exported_by" json:"exportedBy"`
+		ByName     null.String `db:"by_name" json:"byName"`
+	}
+
+	// Data is everything held about a user, each list is written to its own file in the export
+	Data struct {
+		User              user.User
+		Emails            []useremail.Email
+		EmailChanges      []emailchange.Change
+		StatusChanges     []userstatus.Change
+		Roles             []role.Role
+		RoleMemberships   []user.RoleUser
+		Permissions       []permission.Permission
+		Officerships      []officership.OfficershipMember
+		APITokens         []api.Token
+		AccessRequests    []accessrequest.Request
+		AccessReviews     []accessreview.Item
+		Memberships       []membership.Membership
+		Keys              []keylist.Grant
+		Mail              []mailqueue.Message
+		WebhookDeliveries []webhook.Delivery
+		Exports           []Export
+		ExportedAt        time.Time
+	}
+)
+
+var _ Repo = &Store{}
+
+// NewDataExportRepo stores our dependency
+func NewDataExportRepo(db *sqlx.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+// AddExport records that a user's data has been downloaded
+func (s *Store) AddExport(ctx context.Context, e Export) (Export, error) {
+	return s.addExport(ctx, e)
+}
+
+// GetExportsForUser returns when a user's data has been downloaded, newest first
+func (s *Store) GetExportsForUser(ctx context.Context, u user.User) ([]Export, error) {
+	return s.getExportsForUser(ctx, u)
+}
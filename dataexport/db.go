@@ -0,0 +1,52 @@
+package dataexport
+
+import (
+	"context"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/utils"
+)
+
+func (s *Store) addExport(ctx context.Context, e Export) (Export, error) {
+	builder := utils.PSQL().Insert("people.data_exports").
+		Columns("user_id", "exported_by").
+		Values(e.UserID, e.ExportedBy).
+		Suffix("RETURNING export_id, exported_at")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for addExport: %w", err))
+	}
+
+	err = s.db.QueryRowxContext(ctx, sql, args...).Scan(&e.ExportID, &e.ExportedAt)
+	if err != nil {
+		return Export{}, fmt.Errorf("failed to add export: %w", err)
+	}
+
+	return e, nil
+}
+
+func (s *Store) getExportsForUser(ctx context.Context, u user.User) ([]Export, error) {
+	var e []Export
+
+	builder := utils.PSQL().Select("e.*", "NULLIF(CONCAT(b.first_name, ' ', b.last_name), ' ') AS by_name").
+		From("people.data_exports e").
+		LeftJoin("people.users b ON b.user_id = e.exported_by").
+		Where(sq.Eq{"e.user_id": u.UserID}).
+		OrderBy("e.exported_at DESC")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getExportsForUser: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &e, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exports for user: %w", err)
+	}
+
+	return e, nil
+}
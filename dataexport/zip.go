@@ -0,0 +1,85 @@
+package dataexport
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// readme is the first file in an export so the user knows what they are looking at
+const readme = `This is all the data YSTV's web-auth holds about you, exported on %s.
+
+user.json                 your account, your password is never included
+emails.json               the email addresses on your account
+email_changes.json        every change of your email address
+status_changes.json       every change of whether you are a member, alumni, honorary or suspended
+roles.json                the roles you have, including the ones you get through another role
+role_memberships.json     the roles you have been given, when, by who and until when
+permissions.json          what your roles let you do
+officerships.json         the officerships you hold and have held
+api_tokens.json           the API tokens you have made, the tokens themselves aren't stored
+access_requests.json      the roles you have asked for and what was decided
+access_reviews.json       the reviews of whether you should keep your roles
+memberships.json          your memberships and payments
+keys.json                 the keys and card access you hold and have held
+mail.json                 the emails sent to you in the last 30 days, without what they said as they can have working links in
+webhook_deliveries.json   the changes to your account sent to other YSTV services
+exports.json              every time your data has been exported, including this one
+
+Please contact computing@ystv.co.uk if you have any questions about your data.
+`
+
+// WriteZip writes the data as a ZIP of JSON files
+func WriteZip(w io.Writer, d Data) error {
+	z := zip.NewWriter(w)
+
+	files := []struct {
+		name string
+		v    interface{}
+	}{
+		{"user.json", d.User},
+		{"emails.json", d.Emails},
+		{"email_changes.json", d.EmailChanges},
+		{"status_changes.json", d.StatusChanges},
+		{"roles.json", d.Roles},
+		{"role_memberships.json", d.RoleMemberships},
+		{"permissions.json", d.Permissions},
+		{"officerships.json", d.Officerships},
+		{"api_tokens.json", d.APITokens},
+		{"access_requests.json", d.AccessRequests},
+		{"access_reviews.json", d.AccessReviews},
+		{"memberships.json", d.Memberships},
+		{"keys.json", d.Keys},
+		{"mail.json", d.Mail},
+		{"webhook_deliveries.json", d.WebhookDeliveries},
+		{"exports.json", d.Exports},
+	}
+
+	f, err := z.CreateHeader(&zip.FileHeader{Name: "README.txt", Method: zip.Deflate, Modified: d.ExportedAt})
+	if err != nil {
+		return fmt.Errorf("failed to create README.txt: %w", err)
+	}
+
+	_, err = fmt.Fprintf(f, readme, d.ExportedAt.Format("02/01/2006 15:04"))
+	if err != nil {
+		return fmt.Errorf("failed to write README.txt: %w", err)
+	}
+
+	for _, file := range files {
+		f, err = z.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: d.ExportedAt})
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", file.name, err)
+		}
+
+		e := json.NewEncoder(f)
+		e.SetIndent("", "  ")
+
+		err = e.Encode(file.v)
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+
+	return z.Close()
+}
@@ -0,0 +1,62 @@
+package dataexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/user"
+)
+
+func TestWriteZip(t *testing.T) {
+	var b bytes.Buffer
+
+	err := WriteZip(&b, Data{
+		User: user.User{
+			UserID:   1,
+			Username: "test",
+			Password: null.StringFrom("secret"),
+			Salt:     null.StringFrom("salt"),
+		},
+		Exports:    []Export{{ExportID: 1, UserID: 1}},
+		ExportedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	z, err := zip.NewReader(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	require.NoError(t, err)
+
+	files := make(map[string][]byte)
+
+	for _, f := range z.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+
+		files[f.Name], err = io.ReadAll(r)
+		require.NoError(t, err)
+
+		_ = r.Close()
+	}
+
+	assert.Len(t, files, 17)
+	assert.Contains(t, files, "README.txt")
+	assert.NotContains(t, string(files["user.json"]), "secret")
+	assert.JSONEq(t, "null", string(files["keys.json"]))
+
+	var u user.User
+
+	require.NoError(t, json.Unmarshal(files["user.json"], &u))
+	assert.Equal(t, "test", u.Username)
+
+	var e []Export
+
+	require.NoError(t, json.Unmarshal(files["exports.json"], &e))
+	assert.Len(t, e, 1)
+}
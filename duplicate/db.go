@@ -0,0 +1,149 @@
+package duplicate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/utils"
+)
+
+func (s *Store) getUsers(ctx context.Context) ([]user.User, error) {
+	var u []user.User
+
+	builder := utils.PSQL().Select("user_id", "username", "university_username", "ldap_username", "email",
+		"first_name", "nickname", "last_name", "enabled", "created_at", "last_login").
+		From("people.users").
+		Where(sq.Eq{"deleted_at": nil}).
+		OrderBy("user_id")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getUsers: %w", err))
+	}
+
+	//nolint:musttag
+	err = s.db.SelectContext(ctx, &u, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	return u, nil
+}
+
+func (s *Store) getEmails(ctx context.Context) (map[int][]string, error) {
+	var rows []struct {
+		UserID int    `db:"user_id"`
+		Email  string `db:"email"`
+	}
+
+	builder := utils.PSQL().Select("e.user_id", "e.email").
+		From("people.user_emails e").
+		Join("people.users u ON u.user_id = e.user_id").
+		Where(sq.Eq{"u.deleted_at": nil}).
+		OrderBy("e.user_id", "e.email_id")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getEmails: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &rows, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get emails: %w", err)
+	}
+
+	emails := make(map[int][]string)
+
+	for _, r := range rows {
+		emails[r.UserID] = append(emails[r.UserID], r.Email)
+	}
+
+	return emails, nil
+}
+
+func (s *Store) getDismissals(ctx context.Context) ([]Dismissal, error) {
+	var d []Dismissal
+
+	builder := utils.PSQL().Select("d.*",
+		"CONCAT(a.first_name, ' ', a.last_name) AS name_a",
+		"CONCAT(b.first_name, ' ', b.last_name) AS name_b",
+		"NULLIF(CONCAT(u.first_name, ' ', u.last_name), ' ') AS by_name").
+		From("people.duplicate_dismissals d").
+		Join("people.users a ON a.user_id = d.user_id_a").
+		Join("people.users b ON b.user_id = d.user_id_b").
+		LeftJoin("people.users u ON u.user_id = d.dismissed_by").
+		OrderBy("d.dismissed_at DESC")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getDismissals: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &d, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dismissals: %w", err)
+	}
+
+	return d, nil
+}
+
+func (s *Store) addDismissals(ctx context.Context, d []Dismissal) error {
+	if len(d) == 0 {
+		return nil
+	}
+
+	builder := utils.PSQL().Insert("people.duplicate_dismissals").
+		Columns("user_id_a", "user_id_b", "dismissed_by").
+		Suffix("ON CONFLICT DO NOTHING")
+
+	for _, dismissal := range d {
+		if dismissal.UserIDA >= dismissal.UserIDB {
+			return fmt.Errorf("failed to add dismissals: user ids %d and %d are out of order",
+				dismissal.UserIDA, dismissal.UserIDB)
+		}
+
+		builder = builder.Values(dismissal.UserIDA, dismissal.UserIDB, dismissal.DismissedBy)
+	}
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for addDismissals: %w", err))
+	}
+
+	_, err = s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to add dismissals: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) removeDismissal(ctx context.Context, d Dismissal) error {
+	builder := utils.PSQL().Delete("people.duplicate_dismissals").
+		Where(sq.Eq{"user_id_a": d.UserIDA, "user_id_b": d.UserIDB})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for removeDismissal: %w", err))
+	}
+
+	res, err := s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to remove dismissal: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for removeDismissal: %w", err)
+	}
+
+	if rows < 1 {
+		return errors.New("failed to remove dismissal: dismissal doesn't exist")
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package duplicate
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/user"
+)
+
+//go:generate mockgen -destination mocks/mock_duplicate.go -package mock_duplicate github.com/ystv/web-auth/duplicate Repo
+
+type (
+	Repo interface {
+		GetUsers(context.Context) ([]user.User, error)
+		GetEmails(context.Context) (map[int][]string, error)
+		GetDismissals(context.Context) ([]Dismissal, error)
+		AddDismissals(context.Context, []Dismissal) error
+		RemoveDismissal(context.Context, Dismissal) error
+	}
+
+	// Store stores the dependencies
+	Store struct {
+		db *sqlx.DB
+	}
+
+	// Dismissal is a pair of users an admin has said aren't the same person, UserIDA is always the lower id
+	Dismissal struct {
+		UserIDA     int         `db:"user_id_a" json:"userIDA"`
+		UserIDB     int         `db:"user_id_b" json:"userIDB"`
+		NameA       string      `db:"name_a" json:"nameA"`
+		NameB       string      `db:"name_b" json:"nameB"`
+		DismissedAt time.Time   `db:"dismissed_at" json:"dismissedAt"`
+		DismissedBy null.Int    `db:"dismissed_by" json:"dismissedBy"`
+		ByName      null.String `db:"by_name" json:"byName"`
+	}
+)
+
+var _ Repo = &Store{}
+
+// NewDuplicateRepo stores our dependency
+func NewDuplicateRepo(db *sqlx.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+// GetUsers returns the users that aren't deleted with only the fields used to find duplicates
+func (s *Store) GetUsers(ctx context.Context) ([]user.User, error) {
+	return s.getUsers(ctx)
+}
+
+// GetEmails returns every address in people.user_emails of the users that aren't deleted by user id, verified or not
+func (s *Store) GetEmails(ctx context.Context) (map[int][]string, error) {
+	return s.getEmails(ctx)
+}
+
+// GetDismissals returns the pairs of users that have been dismissed, newest first
+func (s *Store) GetDismissals(ctx context.Context) ([]Dismissal, error) {
+	return s.getDismissals(ctx)
+}
+
+// AddDismissals stops the pairs of users being shown as duplicates, a pair that is already dismissed is ignored
+func (s *Store) AddDismissals(ctx context.Context, d []Dismissal) error {
+	return s.addDismissals(ctx, d)
+}
+
+// RemoveDismissal lets the pair of users be shown as duplicates again
+func (s *Store) RemoveDismissal(ctx context.Context, d Dismissal) error {
+	return s.removeDismissal(ctx, d)
+}
+
+// NewDismissal returns the Dismissal of two users with the lower id first
+func NewDismissal(userID1, userID2 int) Dismissal {
+	if userID1 > userID2 {
+		userID1, userID2 = userID2, userID1
+	}
+
+	return Dismissal{
+		UserIDA: userID1,
+		UserIDB: userID2,
+	}
+}
@@ -0,0 +1,381 @@
+package duplicate
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/ystv/web-auth/user"
+)
+
+type (
+	// Reason is why two users might be the same person
+	Reason string
+
+	// Pair is two users that might be the same person, UserA always has the lower id
+	Pair struct {
+		UserA      user.User
+		UserB      user.User
+		Reasons    []Reason
+		Confidence int
+	}
+
+	// Cluster is a group of users linked by pairs, the confidence is of its most likely pair
+	Cluster struct {
+		Users      []user.User
+		Pairs      []Pair
+		Confidence int
+	}
+
+	// key is the ids of a pair of users with the lower id first
+	key [2]int
+)
+
+const (
+	SameUniversityUsername Reason = "Same university username"
+	SameLDAPUsername       Reason = "Same LDAP username"
+	SameEmail              Reason = "Same email"
+	UniversityEmail        Reason = "University email matches university username"
+	SameName               Reason = "Same name"
+	SimilarName            Reason = "Similar name"
+)
+
+// universityDomain is the domain of university emails, the part before the @ is the university username
+const universityDomain = "york.ac.uk"
+
+// Confidence is how likely the reason alone means the users are the same person, out of 100
+func (r Reason) Confidence() int {
+	switch r {
+	case SameUniversityUsername, SameLDAPUsername:
+		return 95
+	case SameEmail, UniversityEmail:
+		return 90
+	case SameName:
+		return 50
+	case SimilarName:
+		return 30
+	default:
+		return 0
+	}
+}
+
+// Find clusters the users that might be the same person, emails is every address of each user by user id as well as
+// the primary one on the user. A dismissed pair is never returned whatever it matches on, but its users can still be
+// in the same cluster when they are both linked to someone else
+//
+//gocyclo:ignore
+func Find(users []user.User, emails map[int][]string, dismissals []Dismissal) []Cluster {
+	dismissed := make(map[key]bool, len(dismissals))
+
+	for _, d := range dismissals {
+		dismissed[newKey(d.UserIDA, d.UserIDB)] = true
+	}
+
+	reasons := make(map[key][]Reason)
+
+	add := func(i, j int, r Reason) {
+		k := newKey(users[i].UserID, users[j].UserID)
+		if dismissed[k] || slices.Contains(reasons[k], r) {
+			return
+		}
+
+		reasons[k] = append(reasons[k], r)
+	}
+
+	// exact matches are bucketed on the matching value so every user isn't compared with every other
+	exact := map[Reason]map[string][]int{
+		SameUniversityUsername: {},
+		SameLDAPUsername:       {},
+		SameEmail:              {},
+		SameName:               {},
+	}
+
+	universityUsernames := make(map[string][]int)
+	byLastname := make(map[string][]int)
+	byFirstname := make(map[string][]int)
+
+	for i, u := range users {
+		uni := strings.ToLower(strings.TrimSpace(u.UniversityUsername))
+		if uni != "" {
+			exact[SameUniversityUsername][uni] = append(exact[SameUniversityUsername][uni], i)
+			universityUsernames[uni] = append(universityUsernames[uni], i)
+		}
+
+		if ldap := strings.ToLower(strings.TrimSpace(u.LDAPUsername.String)); ldap != "" {
+			exact[SameLDAPUsername][ldap] = append(exact[SameLDAPUsername][ldap], i)
+		}
+
+		for _, email := range addresses(u, emails) {
+			if email = NormaliseEmail(email); email != "" {
+				exact[SameEmail][email] = append(exact[SameEmail][email], i)
+			}
+		}
+
+		last := normaliseName(u.Lastname)
+		if last == "" {
+			continue
+		}
+
+		for _, first := range firstnames(u) {
+			exact[SameName][first+" "+last] = append(exact[SameName][first+" "+last], i)
+			byFirstname[first] = append(byFirstname[first], i)
+		}
+
+		byLastname[last] = append(byLastname[last], i)
+	}
+
+	for r, buckets := range exact {
+		for _, bucket := range buckets {
+			for x := 0; x < len(bucket); x++ {
+				for y := x + 1; y < len(bucket); y++ {
+					if bucket[x] != bucket[y] {
+						add(bucket[x], bucket[y], r)
+					}
+				}
+			}
+		}
+	}
+
+	for i, u := range users {
+		for _, email := range addresses(u, emails) {
+			local, domain, ok := strings.Cut(strings.ToLower(strings.TrimSpace(email)), "@")
+			if !ok || domain != universityDomain {
+				continue
+			}
+
+			for _, j := range universityUsernames[local] {
+				if i != j {
+					add(i, j, UniversityEmail)
+				}
+			}
+		}
+	}
+
+	// similar names only compare users that share a first or last name
+	for _, bucket := range byLastname {
+		for x := 0; x < len(bucket); x++ {
+			for y := x + 1; y < len(bucket); y++ {
+				if similarNames(firstnames(users[bucket[x]]), firstnames(users[bucket[y]])) {
+					add(bucket[x], bucket[y], SimilarName)
+				}
+			}
+		}
+	}
+
+	for _, bucket := range byFirstname {
+		for x := 0; x < len(bucket); x++ {
+			for y := x + 1; y < len(bucket); y++ {
+				if bucket[x] != bucket[y] && similar(normaliseName(users[bucket[x]].Lastname),
+					normaliseName(users[bucket[y]].Lastname)) {
+					add(bucket[x], bucket[y], SimilarName)
+				}
+			}
+		}
+	}
+
+	return cluster(users, reasons)
+}
+
+// addresses is every address of a user, a user's own address can be listed twice but a pair is only added once
+func addresses(u user.User, emails map[int][]string) []string {
+	return append([]string{u.Email}, emails[u.UserID]...)
+}
+
+// cluster groups the pairs into clusters of users that are linked to each other
+func cluster(users []user.User, reasons map[key][]Reason) []Cluster {
+	byID := make(map[int]user.User, len(users))
+	parent := make(map[int]int, len(users))
+
+	for _, u := range users {
+		byID[u.UserID] = u
+		parent[u.UserID] = u.UserID
+	}
+
+	var find func(int) int
+	find = func(id int) int {
+		if parent[id] != id {
+			parent[id] = find(parent[id])
+		}
+
+		return parent[id]
+	}
+
+	pairs := make([]Pair, 0, len(reasons))
+
+	for k, r := range reasons {
+		slices.SortFunc(r, func(a, b Reason) int { return b.Confidence() - a.Confidence() })
+
+		pairs = append(pairs, Pair{
+			UserA:      byID[k[0]],
+			UserB:      byID[k[1]],
+			Reasons:    r,
+			Confidence: confidence(r),
+		})
+
+		parent[find(k[0])] = find(k[1])
+	}
+
+	slices.SortFunc(pairs, func(a, b Pair) int {
+		if a.Confidence != b.Confidence {
+			return b.Confidence - a.Confidence
+		}
+
+		if a.UserA.UserID != b.UserA.UserID {
+			return a.UserA.UserID - b.UserA.UserID
+		}
+
+		return a.UserB.UserID - b.UserB.UserID
+	})
+
+	clusters := make(map[int]*Cluster)
+
+	for _, p := range pairs {
+		root := find(p.UserA.UserID)
+
+		c, ok := clusters[root]
+		if !ok {
+			c = &Cluster{}
+			clusters[root] = c
+		}
+
+		for _, u := range []user.User{p.UserA, p.UserB} {
+			if !slices.ContainsFunc(c.Users, func(cu user.User) bool { return cu.UserID == u.UserID }) {
+				c.Users = append(c.Users, u)
+			}
+		}
+
+		c.Pairs = append(c.Pairs, p)
+		c.Confidence = max(c.Confidence, p.Confidence)
+	}
+
+	found := make([]Cluster, 0, len(clusters))
+
+	for _, c := range clusters {
+		slices.SortFunc(c.Users, func(a, b user.User) int { return a.UserID - b.UserID })
+		found = append(found, *c)
+	}
+
+	slices.SortFunc(found, func(a, b Cluster) int {
+		if a.Confidence != b.Confidence {
+			return b.Confidence - a.Confidence
+		}
+
+		return a.Users[0].UserID - b.Users[0].UserID
+	})
+
+	return found
+}
+
+// confidence is the most likely reason with a little more for each other reason
+func confidence(reasons []Reason) int {
+	c := 0
+
+	for _, r := range reasons {
+		c = max(c, r.Confidence())
+	}
+
+	return min(c+5*(len(reasons)-1), 99)
+}
+
+// NormaliseEmail lowercases an email and removes the parts that don't change where it is delivered, the +tag and
+// the dots in a Gmail address
+func NormaliseEmail(email string) string {
+	local, domain, ok := strings.Cut(strings.ToLower(strings.TrimSpace(email)), "@")
+	if !ok || local == "" || domain == "" {
+		return ""
+	}
+
+	local, _, _ = strings.Cut(local, "+")
+
+	if domain == "gmail.com" || domain == "googlemail.com" {
+		local = strings.ReplaceAll(local, ".", "")
+		domain = "gmail.com"
+	}
+
+	return local + "@" + domain
+}
+
+// normaliseName lowercases a name and removes everything that isn't a letter, so "O'Brien" and "obrien" match
+func normaliseName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) {
+			return unicode.ToLower(r)
+		}
+
+		return -1
+	}, name)
+}
+
+// firstnames are a user's first name and their nickname if it is different
+func firstnames(u user.User) []string {
+	names := make([]string, 0, 2)
+
+	for _, name := range []string{u.Firstname, u.Nickname} {
+		if n := normaliseName(name); n != "" && !slices.Contains(names, n) {
+			names = append(names, n)
+		}
+	}
+
+	return names
+}
+
+func similarNames(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if similar(x, y) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// similar is two different names a typo or two apart, short names have to be closer as two letters out of four
+// is a different name
+func similar(a, b string) bool {
+	if a == b || len(a) < 3 || len(b) < 3 {
+		return false
+	}
+
+	allowed := 1
+	if min(len([]rune(a)), len([]rune(b))) >= 6 {
+		allowed = 2
+	}
+
+	return levenshtein(a, b) <= allowed
+}
+
+// levenshtein is the number of single letter changes to turn one string into the other
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
+
+func newKey(userID1, userID2 int) key {
+	d := NewDismissal(userID1, userID2)
+
+	return key{d.UserIDA, d.UserIDB}
+}
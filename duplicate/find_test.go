@@ -0,0 +1,118 @@
+package duplicate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/user"
+)
+
+func TestNormaliseEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{email: " Someone@Example.com ", want: "someone@example.com"},
+		{email: "someone+ystv@example.com", want: "someone@example.com"},
+		{email: "some.one@googlemail.com", want: "someone@gmail.com"},
+		{email: "some.one@example.com", want: "some.one@example.com"},
+		{email: "not an email", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormaliseEmail(tt.email))
+		})
+	}
+}
+
+func TestSimilar(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "jonathan", b: "johnathan", want: true},
+		{a: "katherine", b: "catharine", want: true},
+		{a: "liam", b: "liim", want: true},
+		{a: "liam", b: "leon", want: false},
+		{a: "al", b: "ed", want: false},
+		{a: "same", b: "same", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"_"+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.want, similar(tt.a, tt.b))
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	users := []user.User{
+		{UserID: 1, Firstname: "Jane", Lastname: "Doe", Email: "jane.doe@gmail.com", UniversityUsername: "jd123"},
+		{UserID: 2, Firstname: "Jane", Lastname: "Doe", Email: "janedoe+ystv@gmail.com"},
+		{UserID: 3, Firstname: "J", Nickname: "Jayne", Lastname: "Doe", Email: "jd123@york.ac.uk"},
+		{UserID: 4, Firstname: "John", Lastname: "Smith", Email: "john@example.com",
+			LDAPUsername: null.StringFrom("jsmith")},
+		{UserID: 5, Firstname: "Johnny", Lastname: "Smith", Email: "johnny@example.com",
+			LDAPUsername: null.StringFrom("JSmith")},
+		{UserID: 6, Firstname: "Someone", Lastname: "Else", Email: "someone@example.com"},
+	}
+
+	t.Run("Clusters", func(t *testing.T) {
+		clusters := Find(users, nil, nil)
+		require.Len(t, clusters, 2)
+
+		assert.Equal(t, []int{1, 2, 3}, userIDs(clusters[0].Users))
+		assert.Equal(t, 95, clusters[0].Confidence)
+		require.Len(t, clusters[0].Pairs, 3)
+		assert.Equal(t, []Reason{SameEmail, SameName}, clusters[0].Pairs[0].Reasons)
+		assert.Equal(t, []Reason{UniversityEmail, SimilarName}, clusters[0].Pairs[1].Reasons)
+		assert.Equal(t, []Reason{SimilarName}, clusters[0].Pairs[2].Reasons)
+
+		assert.Equal(t, []int{4, 5}, userIDs(clusters[1].Users))
+		assert.Equal(t, []Reason{SameLDAPUsername}, clusters[1].Pairs[0].Reasons)
+	})
+
+	t.Run("Dismissed", func(t *testing.T) {
+		clusters := Find(users, nil, []Dismissal{NewDismissal(5, 4), NewDismissal(1, 2)})
+		require.Len(t, clusters, 1)
+		assert.Equal(t, []int{1, 2, 3}, userIDs(clusters[0].Users))
+		assert.Len(t, clusters[0].Pairs, 2)
+	})
+
+	t.Run("DismissedLinkedThroughAnother", func(t *testing.T) {
+		// 1 and 2 share an email and a name but were dismissed, they are only together through 3
+		clusters := Find(users, nil, []Dismissal{NewDismissal(2, 1)})
+		require.Len(t, clusters, 2)
+		assert.Equal(t, []int{1, 2, 3}, userIDs(clusters[0].Users))
+
+		for _, p := range clusters[0].Pairs {
+			assert.Equal(t, 3, p.UserB.UserID)
+		}
+	})
+
+	t.Run("OtherAddresses", func(t *testing.T) {
+		clusters := Find(users, map[int][]string{
+			4: {"john@example.com", "Someone@Example.com"},
+			6: {"someone@example.com", "se456@york.ac.uk"},
+		}, []Dismissal{NewDismissal(5, 4)})
+		require.Len(t, clusters, 2)
+
+		assert.Equal(t, []int{1, 2, 3}, userIDs(clusters[0].Users))
+		assert.Equal(t, []int{4, 6}, userIDs(clusters[1].Users))
+		assert.Equal(t, []Reason{SameEmail}, clusters[1].Pairs[0].Reasons)
+	})
+}
+
+func userIDs(users []user.User) []int {
+	ids := make([]int, 0, len(users))
+
+	for _, u := range users {
+		ids = append(ids, u.UserID)
+	}
+
+	return ids
+}
@@ -0,0 +1,382 @@
+package emailchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/utils"
+	"github.com/ystv/web-auth/webhook"
+)
+
+// changeBuilder selects changes with the name of who requested them
+func changeBuilder() sq.SelectBuilder {
+	return utils.PSQL().Select("c.*", "NULLIF(CONCAT(b.first_name, ' ', b.last_name), ' ') AS by_name").
+		From("people.email_changes c").
+		LeftJoin("people.users b ON b.user_id = c.requested_by")
+}
+
+// getChange returns the change with the token in the column, the column is never from the user
+func (s *Store) getChange(ctx context.Context, column, token string) (Change, error) {
+	var c Change
+
+	if token == "" {
+		return c, errors.New("failed to get email change: token must be set")
+	}
+
+	builder := changeBuilder().
+		Where(sq.Eq{"c." + column: token})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getChange: %w", err))
+	}
+
+	err = s.db.GetContext(ctx, &c, sql, args...)
+	if err != nil {
+		return c, fmt.Errorf("failed to get email change: %w", err)
+	}
+
+	return c, nil
+}
+
+func (s *Store) getPendingChange(ctx context.Context, u user.User) (Change, error) {
+	var c Change
+
+	builder := changeBuilder().
+		Where(sq.Eq{"c.user_id": u.UserID, "c.status": Pending})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getPendingChange: %w", err))
+	}
+
+	err = s.db.GetContext(ctx, &c, sql, args...)
+	if err != nil {
+		return c, fmt.Errorf("failed to get pending email change: %w", err)
+	}
+
+	return c, nil
+}
+
+func (s *Store) getChangesForUser(ctx context.Context, u user.User) ([]Change, error) {
+	var c []Change
+
+	builder := changeBuilder().
+		Where(sq.Eq{"c.user_id": u.UserID}).
+		OrderBy("c.requested_at DESC")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getChangesForUser: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &c, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email changes for user: %w", err)
+	}
+
+	return c, nil
+}
+
+func (s *Store) addChange(ctx context.Context, c Change) (Change, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to begin add email change transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// only the newest request can be verified
+	builder := utils.PSQL().Update("people.email_changes").
+		SetMap(map[string]interface{}{
+			"status":       Cancelled,
+			"verify_token": nil,
+		}).
+		Where(sq.Eq{"user_id": c.UserID, "status": Pending})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for addChange: %w", err))
+	}
+
+	_, err = tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to cancel pending email change: %w", err)
+	}
+
+	if c.Status == Changed {
+		c.ChangedAt.SetValid(time.Now())
+
+		err = s.setEmail(ctx, tx, c, c.OldEmail, c.NewEmail, c.RequestedBy.Int64)
+		if err != nil {
+			return Change{}, err
+		}
+	}
+
+	insert := utils.PSQL().Insert("people.email_changes").
+		Columns("user_id", "old_email", "new_email", "status", "verified", "verify_token", "revert_token",
+			"requested_by", "expires_at", "changed_at").
+		Values(c.UserID, c.OldEmail, c.NewEmail, c.Status, c.Verified, c.VerifyToken, c.RevertToken,
+			c.RequestedBy, c.ExpiresAt, c.ChangedAt).
+		Suffix("RETURNING change_id, requested_at")
+
+	sql, args, err = insert.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for addChange: %w", err))
+	}
+
+	err = tx.QueryRowxContext(ctx, sql, args...).Scan(&c.ChangeID, &c.RequestedAt)
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to add email change: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to commit add email change: %w", err)
+	}
+
+	return c, nil
+}
+
+func (s *Store) applyChange(ctx context.Context, c Change) (Change, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to begin apply email change transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	c, err = s.lockChange(ctx, tx, c, Pending)
+	if err != nil {
+		return Change{}, err
+	}
+
+	if time.Now().After(c.ExpiresAt) {
+		return Change{}, errors.New("failed to apply email change: the link has expired")
+	}
+
+	c.Verified = true
+
+	err = s.setEmail(ctx, tx, c, c.OldEmail, c.NewEmail, int64(c.UserID))
+	if err != nil {
+		return Change{}, err
+	}
+
+	c.Status = Changed
+	c.VerifyToken.Valid = false
+	c.ChangedAt.SetValid(time.Now())
+
+	err = s.updateChange(ctx, tx, c, map[string]interface{}{
+		"status":       c.Status,
+		"verified":     c.Verified,
+		"verify_token": nil,
+		"changed_at":   c.ChangedAt,
+	})
+	if err != nil {
+		return Change{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to commit apply email change: %w", err)
+	}
+
+	return c, nil
+}
+
+func (s *Store) revertChange(ctx context.Context, c Change) (Change, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to begin revert email change transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	c, err = s.lockChange(ctx, tx, c, Changed)
+	if err != nil {
+		return Change{}, err
+	}
+
+	if !c.CanRevert() {
+		return Change{}, errors.New("failed to revert email change: the link has expired")
+	}
+
+	err = s.setEmail(ctx, tx, c, c.NewEmail, c.OldEmail, int64(c.UserID))
+	if err != nil {
+		return Change{}, err
+	}
+
+	c.Status = Reverted
+	c.RevertToken.Valid = false
+	c.RevertedAt.SetValid(time.Now())
+
+	err = s.updateChange(ctx, tx, c, map[string]interface{}{
+		"status":       c.Status,
+		"revert_token": nil,
+		"reverted_at":  c.RevertedAt,
+	})
+	if err != nil {
+		return Change{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to commit revert email change: %w", err)
+	}
+
+	return c, nil
+}
+
+func (s *Store) cancelChange(ctx context.Context, c Change) error {
+	builder := utils.PSQL().Update("people.email_changes").
+		SetMap(map[string]interface{}{
+			"status":       Cancelled,
+			"verify_token": nil,
+		}).
+		Where(sq.Eq{"change_id": c.ChangeID, "status": Pending})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for cancelChange: %w", err))
+	}
+
+	res, err := s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to cancel email change: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for cancelChange: %w", err)
+	}
+
+	if rows < 1 {
+		return errors.New("failed to cancel email change: change isn't pending")
+	}
+
+	return nil
+}
+
+// lockChange reads the change again inside the transaction so the same link can't be used twice at once
+func (s *Store) lockChange(ctx context.Context, tx *sqlx.Tx, c Change, status Status) (Change, error) {
+	var locked Change
+
+	builder := utils.PSQL().Select("*").
+		From("people.email_changes").
+		Where(sq.Eq{"change_id": c.ChangeID}).
+		Suffix("FOR UPDATE")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for lockChange: %w", err))
+	}
+
+	err = tx.GetContext(ctx, &locked, sql, args...)
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to get email change: %w", err)
+	}
+
+	if locked.Status != status {
+		return Change{}, fmt.Errorf("failed to get email change: change is %s", locked.Status)
+	}
+
+	locked.ByName = c.ByName
+
+	return locked, nil
+}
+
+func (s *Store) updateChange(ctx context.Context, tx *sqlx.Tx, c Change, set map[string]interface{}) error {
+	builder := utils.PSQL().Update("people.email_changes").
+		SetMap(set).
+		Where(sq.Eq{"change_id": c.ChangeID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for updateChange: %w", err))
+	}
+
+	_, err = tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update email change: %w", err)
+	}
+
+	return nil
+}
+
+// setEmail changes the user's primary email only if it is still the one the change was made from, the address
+// changed from is kept as another of their addresses unless the change is being reverted, the user updated webhook
+// is queued in the same transaction
+func (s *Store) setEmail(ctx context.Context, tx *sqlx.Tx, c Change, from, to string, updatedBy int64) error {
+	var u user.User
+
+	builder := utils.PSQL().Update("people.users").
+		SetMap(map[string]interface{}{
+			"email":      to,
+			"updated_at": time.Now(),
+			"updated_by": updatedBy,
+		}).
+		Where(sq.Eq{"user_id": c.UserID, "email": from}).
+		Suffix("RETURNING *")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for setEmail: %w", err))
+	}
+
+	//nolint:musttag
+	err = tx.GetContext(ctx, &u, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to set email, it may have changed since or be used by someone else: %w",
+			err)
+	}
+
+	reverting := c.Status == Changed && from == c.NewEmail
+
+	builders := []sq.Sqlizer{
+		utils.PSQL().Update("people.user_emails").
+			Set("is_primary", false).
+			Where(sq.Eq{"user_id": c.UserID, "is_primary": true}),
+		utils.PSQL().Delete("people.user_emails").
+			Where(sq.And{sq.Eq{"user_id": c.UserID}, sq.Expr("lower(email) = lower(?)", to)}),
+		utils.PSQL().Insert("people.user_emails").
+			Columns("user_id", "email", "verified", "is_primary", "verified_at").
+			Values(c.UserID, to, c.Verified || reverting, true, time.Now()),
+	}
+
+	// whoever changed it shouldn't be left able to log in with the address it was reverted from
+	if reverting {
+		builders = append(builders, utils.PSQL().Delete("people.user_emails").
+			Where(sq.And{sq.Eq{"user_id": c.UserID}, sq.Expr("lower(email) = lower(?)", from)}))
+	}
+
+	for _, builder := range builders {
+		sql, args, err = builder.ToSql()
+		if err != nil {
+			panic(fmt.Errorf("failed to build sql for setEmail: %w", err))
+		}
+
+		_, err = tx.ExecContext(ctx, sql, args...)
+		if err != nil {
+			return fmt.Errorf("failed to set user emails, it may be used by someone else: %w", err)
+		}
+	}
+
+	err = s.webhook.Emit(ctx, tx, webhook.UserUpdated, u)
+	if err != nil {
+		return fmt.Errorf("failed to emit user updated: %w", err)
+	}
+
+	return nil
+}
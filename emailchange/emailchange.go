@@ -0,0 +1,124 @@
+package emailchange
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/webhook"
+)
+
+//go:generate mockgen -destination mocks/mock_emailchange.go -package mock_emailchange github.com/ystv/web-auth/emailchange Repo
+
+type (
+	Repo interface {
+		GetChangeByVerifyToken(context.Context, string) (Change, error)
+		GetChangeByRevertToken(context.Context, string) (Change, error)
+		GetPendingChange(context.Context, user.User) (Change, error)
+		GetChangesForUser(context.Context, user.User) ([]Change, error)
+		AddChange(context.Context, Change) (Change, error)
+		ApplyChange(context.Context, Change) (Change, error)
+		RevertChange(context.Context, Change) (Change, error)
+		CancelChange(context.Context, Change) error
+	}
+
+	// Store stores the dependencies
+	Store struct {
+		db      *sqlx.DB
+		webhook webhook.Repo
+	}
+
+	// Change is a user's email being changed, it is kept after it is done as the history
+	Change struct {
+		ChangeID    int         `db:"change_id" json:"changeID"`
+		UserID      int         `db:"user_id" json:"userID"`
+		OldEmail    string      `db:"old_email" json:"oldEmail"`
+		NewEmail    string      `db:"new_email" json:"newEmail"`
+		Status      Status      `db:"status" json:"status"`
+		Verified    bool        `db:"verified" json:"verified"`
+		VerifyToken null.String `db:"verify_token" json:"-"`
+		RevertToken null.String `db:"revert_token" json:"-"`
+		RequestedAt time.Time   `db:"requested_at" json:"requestedAt"`
+		RequestedBy null.Int    `db:"requested_by" json:"requestedBy"`
+		ExpiresAt   time.Time   `db:"expires_at" json:"expiresAt"`
+		ChangedAt   null.Time   `db:"changed_at" json:"changedAt"`
+		RevertedAt  null.Time   `db:"reverted_at" json:"revertedAt"`
+		ByName      null.String `db:"by_name" json:"byName"`
+	}
+
+	// Status is the state of a Change
+	Status string
+)
+
+const (
+	Pending   Status = "pending"
+	Changed   Status = "changed"
+	Reverted  Status = "reverted"
+	Cancelled Status = "cancelled"
+)
+
+const (
+	// VerifyFor is how long the link sent to the new address works for
+	VerifyFor = 24 * time.Hour
+	// RevertFor is how long the link sent to the old address can change it back for
+	RevertFor = 7 * 24 * time.Hour
+)
+
+var _ Repo = &Store{}
+
+// NewEmailChangeRepo stores our dependency
+func NewEmailChangeRepo(db *sqlx.DB, wh webhook.Repo) *Store {
+	return &Store{
+		db:      db,
+		webhook: wh,
+	}
+}
+
+// GetChangeByVerifyToken returns the change the link sent to the new address is for
+func (s *Store) GetChangeByVerifyToken(ctx context.Context, token string) (Change, error) {
+	return s.getChange(ctx, "verify_token", token)
+}
+
+// GetChangeByRevertToken returns the change the link sent to the old address is for
+func (s *Store) GetChangeByRevertToken(ctx context.Context, token string) (Change, error) {
+	return s.getChange(ctx, "revert_token", token)
+}
+
+// GetPendingChange returns the change waiting for the user to verify their new address
+func (s *Store) GetPendingChange(ctx context.Context, u user.User) (Change, error) {
+	return s.getPendingChange(ctx, u)
+}
+
+// GetChangesForUser returns every change of a user's email, newest first
+func (s *Store) GetChangesForUser(ctx context.Context, u user.User) ([]Change, error) {
+	return s.getChangesForUser(ctx, u)
+}
+
+// AddChange records a change, a pending one replaces any the user already has and one that isn't pending is
+// applied straight away
+func (s *Store) AddChange(ctx context.Context, c Change) (Change, error) {
+	return s.addChange(ctx, c)
+}
+
+// ApplyChange sets the user's email to the new address once it has been verified
+func (s *Store) ApplyChange(ctx context.Context, c Change) (Change, error) {
+	return s.applyChange(ctx, c)
+}
+
+// RevertChange sets the user's email back to the old address
+func (s *Store) RevertChange(ctx context.Context, c Change) (Change, error) {
+	return s.revertChange(ctx, c)
+}
+
+// CancelChange stops a pending change from being verified
+func (s *Store) CancelChange(ctx context.Context, c Change) error {
+	return s.cancelChange(ctx, c)
+}
+
+// CanRevert is if the old address can still change the email back
+func (c Change) CanRevert() bool {
+	return c.Status == Changed && c.ChangedAt.Valid && time.Since(c.ChangedAt.Time) < RevertFor
+}
@@ -0,0 +1,41 @@
+package emailchange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/guregu/null.v4"
+)
+
+func TestCanRevert(t *testing.T) {
+	tests := []struct {
+		name   string
+		change Change
+		want   bool
+	}{
+		{
+			name:   "changed recently",
+			change: Change{Status: Changed, ChangedAt: null.TimeFrom(time.Now().Add(-time.Hour))},
+			want:   true,
+		},
+		{
+			name:   "changed too long ago",
+			change: Change{Status: Changed, ChangedAt: null.TimeFrom(time.Now().Add(-RevertFor - time.Hour))},
+		},
+		{
+			name:   "pending",
+			change: Change{Status: Pending},
+		},
+		{
+			name:   "already reverted",
+			change: Change{Status: Reverted, ChangedAt: null.TimeFrom(time.Now().Add(-time.Hour))},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.change.CanRevert())
+		})
+	}
+}
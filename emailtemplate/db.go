@@ -0,0 +1,200 @@
+package emailtemplate
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/ystv/web-auth/utils"
+)
+
+// templateBuilder selects templates with the name of who last saved them
+func templateBuilder() sq.SelectBuilder {
+	return utils.PSQL().Select("t.*", "NULLIF(CONCAT(u.first_name, ' ', u.last_name), ' ') AS updated_by_name").
+		From("web_auth.email_templates t").
+		LeftJoin("people.users u ON u.user_id = t.updated_by")
+}
+
+func (s *Store) getTemplates(ctx context.Context) ([]Template, error) {
+	var t []Template
+
+	builder := templateBuilder().
+		OrderBy("t.name", "t.language")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getTemplates: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &t, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email templates: %w", err)
+	}
+
+	return t, nil
+}
+
+func (s *Store) getTemplatesForName(ctx context.Context, name Name) ([]Template, error) {
+	var t []Template
+
+	builder := templateBuilder().
+		Where(sq.Eq{"t.name": name}).
+		OrderBy("t.language")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getTemplatesForName: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &t, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email templates for name: %w", err)
+	}
+
+	return t, nil
+}
+
+// saveTemplate adds the template or bumps its version, along with a copy of it in the versions, in one transaction
+func (s *Store) saveTemplate(ctx context.Context, t Template, savedBy int) (Template, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Template{}, fmt.Errorf("failed to begin save email template transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	builder := utils.PSQL().Insert("web_auth.email_templates").
+		Columns("name", "language", "subject", "from_address", "text_body", "html_body", "updated_by").
+		Values(t.Name, t.Language, t.Subject, t.From, t.Text, t.HTML, savedBy).
+		Suffix(`ON CONFLICT (name, language) DO UPDATE SET
+			subject = EXCLUDED.subject,
+			from_address = EXCLUDED.from_address,
+			text_body = EXCLUDED.text_body,
+			html_body = EXCLUDED.html_body,
+			version = web_auth.email_templates.version + 1,
+			updated_at = NOW(),
+			updated_by = EXCLUDED.updated_by
+		RETURNING template_id, version, updated_at, updated_by`)
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for saveTemplate: %w", err))
+	}
+
+	err = tx.QueryRowxContext(ctx, sql, args...).Scan(&t.TemplateID, &t.Version, &t.UpdatedAt, &t.UpdatedBy)
+	if err != nil {
+		return Template{}, fmt.Errorf("failed to save email template: %w", err)
+	}
+
+	builder = utils.PSQL().Insert("web_auth.email_template_versions").
+		Columns("template_id", "version", "subject", "from_address", "text_body", "html_body", "created_by").
+		Values(t.TemplateID, t.Version, t.Subject, t.From, t.Text, t.HTML, savedBy)
+
+	sql, args, err = builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for saveTemplate: %w", err))
+	}
+
+	_, err = tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return Template{}, fmt.Errorf("failed to add email template version: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Template{}, fmt.Errorf("failed to commit save email template: %w", err)
+	}
+
+	return t, nil
+}
+
+func (s *Store) deleteTemplate(ctx context.Context, t Template) error {
+	builder := utils.PSQL().Delete("web_auth.email_templates").
+		Where(sq.Eq{"template_id": t.TemplateID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for deleteTemplate: %w", err))
+	}
+
+	_, err = s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete email template: %w", err)
+	}
+
+	return nil
+}
+
+// versionBuilder selects versions with the name of who saved them
+func versionBuilder() sq.SelectBuilder {
+	return utils.PSQL().Select("v.*", "NULLIF(CONCAT(u.first_name, ' ', u.last_name), ' ') AS created_by_name").
+		From("web_auth.email_template_versions v").
+		LeftJoin("people.users u ON u.user_id = v.created_by")
+}
+
+func (s *Store) getVersions(ctx context.Context, t Template) ([]Version, error) {
+	var v []Version
+
+	builder := versionBuilder().
+		Where(sq.Eq{"v.template_id": t.TemplateID}).
+		OrderBy("v.version DESC")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getVersions: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &v, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email template versions: %w", err)
+	}
+
+	return v, nil
+}
+
+func (s *Store) getVersion(ctx context.Context, v1 Version) (Version, error) {
+	var v Version
+
+	builder := versionBuilder().
+		Where(sq.Eq{"v.template_id": v1.TemplateID, "v.version": v1.Version})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getVersion: %w", err))
+	}
+
+	err = s.db.GetContext(ctx, &v, sql, args...)
+	if err != nil {
+		return Version{}, fmt.Errorf("failed to get email template version: %w", err)
+	}
+
+	return v, nil
+}
+
+func (s *Store) getLanguages(ctx context.Context) ([]string, error) {
+	var languages []string
+
+	builder := utils.PSQL().Select("DISTINCT language").
+		From("web_auth.email_templates").
+		OrderBy("language")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getLanguages: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &languages, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email template languages: %w", err)
+	}
+
+	if !slices.Contains(languages, DefaultLanguage) {
+		languages = append([]string{DefaultLanguage}, languages...)
+	}
+
+	return languages, nil
+}
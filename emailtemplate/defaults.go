@@ -0,0 +1,362 @@
+package emailtemplate
+
+import (
+	"embed"
+	"fmt"
+	"reflect"
+
+	"github.com/ystv/web-auth/templates"
+)
+
+type (
+	// Default is an email that is sent, it is used until a template for it is saved
+	Default struct {
+		Name        Name
+		Description string
+		Subject     string
+		From        string
+		HTML        templates.Template
+		// Sample is filled in like a real email would be for previews, it has to be the type sent with the email
+		Sample interface{}
+	}
+
+	// Name identifies an email
+	Name string
+
+	// ResetData is sent with Forgot and Reset
+	ResetData struct {
+		Email string
+		URL   string
+	}
+
+	// SignupData is sent with Signup
+	SignupData struct {
+		Name     string
+		Username string
+		Password string
+	}
+
+	// HandoverData is sent with OfficerHandover
+	HandoverData struct {
+		Name        string
+		Officership string
+		Incoming    bool
+		StartDate   string
+		EndDate     string
+	}
+
+	// RoleExpiryData is sent with RoleExpiry
+	RoleExpiryData struct {
+		Name   string
+		Role   string
+		EndsAt string
+		Reason string
+	}
+
+	// AccessRequestData is sent with AccessRequest
+	AccessRequestData struct {
+		Name      string
+		Requester string
+		Role      string
+		Reason    string
+		URL       string
+	}
+
+	// AccessDecisionData is sent with AccessDecision
+	AccessDecisionData struct {
+		Name    string
+		Role    string
+		Status  string
+		Comment string
+		EndsAt  string
+	}
+
+	// AccessReviewData is sent with AccessReview
+	AccessReviewData struct {
+		Name       string
+		Review     string
+		Count      int
+		Deadline   string
+		AutoRevoke bool
+		URL        string
+	}
+
+	// MembershipExpiryData is sent with MembershipExpiry
+	MembershipExpiryData struct {
+		Name         string
+		Type         string
+		AcademicYear string
+		PaidUntil    string
+	}
+
+	// EmailVerifyData is sent with EmailVerify and EmailAddress
+	EmailVerifyData struct {
+		Name  string
+		Email string
+		URL   string
+	}
+
+	// EmailChangedData is sent with EmailChanged
+	EmailChangedData struct {
+		Name     string
+		OldEmail string
+		NewEmail string
+		URL      string
+	}
+
+	// PasswordExpiryData is sent with PasswordExpiry
+	PasswordExpiryData struct {
+		Name       string
+		Role       string
+		MaxAgeDays int
+		ExpiresAt  string
+		LocksAt    string
+		URL        string
+	}
+)
+
+const (
+	Forgot           Name = "forgot"
+	Reset            Name = "reset"
+	Signup           Name = "signup"
+	OfficerHandover  Name = "officerHandover"
+	RoleExpiry       Name = "roleExpiry"
+	AccessRequest    Name = "accessRequest"
+	AccessDecision   Name = "accessDecision"
+	AccessReview     Name = "accessReview"
+	MembershipExpiry Name = "membershipExpiry"
+	EmailVerify      Name = "emailVerify"
+	EmailChanged     Name = "emailChanged"
+	EmailAddress     Name = "emailAddress"
+	PasswordExpiry   Name = "passwordExpiry"
+)
+
+const (
+	securityFrom = "YSTV Security <no-reply@ystv.co.uk>"
+	noReplyFrom  = "YSTV No-Reply <no-reply@ystv.co.uk>"
+)
+
+// text is the plain text part of the built-in templates, the html part is the compiled mjml in templates
+//
+//go:embed text/*.txt
+var text embed.FS
+
+// Defaults are every email that is sent
+var Defaults = []Default{
+	{
+		Name:        Forgot,
+		Description: "Sent when someone asks to reset their password",
+		Subject:     "YSTV Security - Reset Password",
+		From:        securityFrom,
+		HTML:        templates.ForgotEmailTemplate,
+		Sample: ResetData{
+			Email: "jane.doe@ystv.co.uk",
+			URL:   "https://auth.ystv.co.uk/reset/00000000-0000-0000-0000-000000000000",
+		},
+	},
+	{
+		Name:        Reset,
+		Description: "Sent when an admin resets a user's password",
+		Subject:     "YSTV Security - Reset Password",
+		From:        securityFrom,
+		HTML:        templates.ResetEmailTemplate,
+		Sample: ResetData{
+			Email: "jane.doe@ystv.co.uk",
+			URL:   "https://auth.ystv.co.uk/reset/00000000-0000-0000-0000-000000000000",
+		},
+	},
+	{
+		Name:        Signup,
+		Description: "Sent to a new user with their username and password",
+		Subject:     "Welcome to YSTV!",
+		From:        noReplyFrom,
+		HTML:        templates.SignupEmailTemplate,
+		Sample: SignupData{
+			Name:     "Jane",
+			Username: "jd123",
+			Password: "correct-horse-battery",
+		},
+	},
+	{
+		Name:        OfficerHandover,
+		Description: "Sent to the incoming and outgoing officers when a handover is applied",
+		Subject: "{{if .Incoming}}YSTV - Welcome, {{.Officership}}" +
+			"{{else}}YSTV - Your term as {{.Officership}} is ending{{end}}",
+		From: noReplyFrom,
+		HTML: templates.OfficerHandoverEmailTemplate,
+		Sample: HandoverData{
+			Name:        "Jane",
+			Officership: "Station Director",
+			Incoming:    true,
+			StartDate:   "01/06/2026",
+			EndDate:     "31/05/2027",
+		},
+	},
+	{
+		Name:        RoleExpiry,
+		Description: "Sent before a user's time limited role membership ends",
+		Subject:     "YSTV - Your {{.Role}} access is ending",
+		From:        noReplyFrom,
+		HTML:        templates.RoleExpiryEmailTemplate,
+		Sample: RoleExpiryData{
+			Name:   "Jane",
+			Role:   "Computing Team",
+			EndsAt: "01/06/2026 12:00",
+			Reason: "Helping with the website",
+		},
+	},
+	{
+		Name:        AccessRequest,
+		Description: "Sent to the approvers of a role when someone requests it",
+		Subject:     "YSTV - {{.Requester}} has requested {{.Role}}",
+		From:        noReplyFrom,
+		HTML:        templates.AccessRequestEmailTemplate,
+		Sample: AccessRequestData{
+			Name:      "Jane",
+			Requester: "John Smith",
+			Role:      "Computing Team",
+			Reason:    "Helping with the website",
+			URL:       "https://auth.ystv.co.uk/internal/access/requests",
+		},
+	},
+	{
+		Name:        AccessDecision,
+		Description: "Sent to the requester once their access request is approved or denied",
+		Subject:     "YSTV - Your request for {{.Role}} has been {{.Status}}",
+		From:        noReplyFrom,
+		HTML:        templates.AccessDecisionEmailTemplate,
+		Sample: AccessDecisionData{
+			Name:    "Jane",
+			Role:    "Computing Team",
+			Status:  "approved",
+			Comment: "Welcome to the team",
+			EndsAt:  "01/06/2026 12:00",
+		},
+	},
+	{
+		Name:        AccessReview,
+		Description: "Sent to the reviewers of an access review",
+		Subject:     "YSTV - Access review: {{.Review}}",
+		From:        noReplyFrom,
+		HTML:        templates.AccessReviewEmailTemplate,
+		Sample: AccessReviewData{
+			Name:       "Jane",
+			Review:     "Summer 2026",
+			Count:      3,
+			Deadline:   "01/06/2026 12:00",
+			AutoRevoke: true,
+			URL:        "https://auth.ystv.co.uk/internal/review/tasks",
+		},
+	},
+	{
+		Name:        MembershipExpiry,
+		Description: "Sent before a paid membership ends",
+		Subject:     "YSTV - Your membership is ending",
+		From:        noReplyFrom,
+		HTML:        templates.MembershipExpiryEmailTemplate,
+		Sample: MembershipExpiryData{
+			Name:         "Jane",
+			Type:         "Full",
+			AcademicYear: "2025/26",
+			PaidUntil:    "01/09/2026",
+		},
+	},
+	{
+		Name:        EmailVerify,
+		Description: "Sent to the new address when a user changes their email",
+		Subject:     "YSTV Security - Confirm your new email",
+		From:        securityFrom,
+		HTML:        templates.EmailVerifyEmailTemplate,
+		Sample: EmailVerifyData{
+			Name:  "Jane",
+			Email: "jane.doe@ystv.co.uk",
+			URL:   "https://auth.ystv.co.uk/email/verify/00000000-0000-0000-0000-000000000000",
+		},
+	},
+	{
+		Name:        EmailChanged,
+		Description: "Sent to the old address after a user's email is changed with a link to change it back",
+		Subject:     "YSTV Security - Your email has been changed",
+		From:        securityFrom,
+		HTML:        templates.EmailChangedEmailTemplate,
+		Sample: EmailChangedData{
+			Name:     "Jane",
+			OldEmail: "jane.doe@ystv.co.uk",
+			NewEmail: "jane@example.com",
+			URL:      "https://auth.ystv.co.uk/email/revert/00000000-0000-0000-0000-000000000000",
+		},
+	},
+	{
+		Name:        EmailAddress,
+		Description: "Sent to an address a user adds to their account",
+		Subject:     "YSTV Security - Confirm your email",
+		From:        securityFrom,
+		HTML:        templates.EmailAddressEmailTemplate,
+		Sample: EmailVerifyData{
+			Name:  "Jane",
+			Email: "jane@example.com",
+			URL:   "https://auth.ystv.co.uk/email/address/00000000-0000-0000-0000-000000000000",
+		},
+	},
+	{
+		Name:        PasswordExpiry,
+		Description: "Sent before a user's password reaches the maximum age of one of their roles",
+		Subject:     "YSTV Security - Your password is expiring",
+		From:        securityFrom,
+		HTML:        templates.PasswordExpiryEmailTemplate,
+		Sample: PasswordExpiryData{
+			Name:       "Jane",
+			Role:       "Admin",
+			MaxAgeDays: 365,
+			ExpiresAt:  "01/06/2026",
+			LocksAt:    "08/06/2026",
+			URL:        "https://auth.ystv.co.uk/internal/settings",
+		},
+	},
+}
+
+// GetDefault returns the email with the name
+func GetDefault(name Name) (Default, bool) {
+	for _, d := range Defaults {
+		if d.Name == name {
+			return d, true
+		}
+	}
+
+	return Default{}, false
+}
+
+// Template returns the built-in template in the default language
+func (d Default) Template() (Template, error) {
+	t, err := text.ReadFile("text/" + string(d.Name) + ".txt")
+	if err != nil {
+		return Template{}, fmt.Errorf("failed to read text for %s: %w", d.Name, err)
+	}
+
+	html, err := templates.GetEmailSource(d.HTML)
+	if err != nil {
+		return Template{}, fmt.Errorf("failed to read html for %s: %w", d.Name, err)
+	}
+
+	return Template{
+		Name:     d.Name,
+		Language: DefaultLanguage,
+		Subject:  d.Subject,
+		From:     d.From,
+		Text:     string(t),
+		HTML:     html,
+	}, nil
+}
+
+// Fields returns the names of the data the template can use, like {{.Name}}
+func (d Default) Fields() []string {
+	t := reflect.TypeOf(d.Sample)
+
+	fields := make([]string, 0, t.NumField())
+
+	for i := range t.NumField() {
+		fields = append(fields, t.Field(i).Name)
+	}
+
+	return fields
+}
@@ -0,0 +1,323 @@
+package emailtemplate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	netmail "net/mail"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/text/language"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/infrastructure/mail"
+)
+
+//go:generate mockgen -destination mocks/mock_emailtemplate.go -package mock_emailtemplate github.com/ystv/web-auth/emailtemplate Repo
+
+type (
+	Repo interface {
+		GetTemplates(context.Context) ([]Template, error)
+		GetTemplate(context.Context, Template) (Template, error)
+		SaveTemplate(context.Context, Template, int) (Template, error)
+		DeleteTemplate(context.Context, Template) error
+		GetVersions(context.Context, Template) ([]Version, error)
+		GetVersion(context.Context, Version) (Version, error)
+		GetLanguages(context.Context) ([]string, error)
+		Mail(context.Context, Name, string, string, interface{}) (mail.Mail, error)
+	}
+
+	// Store stores the dependencies
+	Store struct {
+		db *sqlx.DB
+	}
+
+	// Template is an email in one language, one that hasn't been saved has no TemplateID and is the default
+	Template struct {
+		TemplateID    int         `db:"template_id" json:"templateID"`
+		Name          Name        `db:"name" json:"name"`
+		Language      string      `db:"language" json:"language"`
+		Subject       string      `db:"subject" json:"subject"`
+		From          string      `db:"from_address" json:"from"`
+		Text          string      `db:"text_body" json:"text"`
+		HTML          string      `db:"html_body" json:"html"`
+		Version       int         `db:"version" json:"version"`
+		UpdatedAt     null.Time   `db:"updated_at" json:"updatedAt"`
+		UpdatedBy     null.Int    `db:"updated_by" json:"updatedBy"`
+		UpdatedByName null.String `db:"updated_by_name" json:"updatedByName"`
+	}
+
+	// Version is a Template as it was saved, every save is kept so it can be restored
+	Version struct {
+		VersionID     int         `db:"version_id" json:"versionID"`
+		TemplateID    int         `db:"template_id" json:"templateID"`
+		Version       int         `db:"version" json:"version"`
+		Subject       string      `db:"subject" json:"subject"`
+		From          string      `db:"from_address" json:"from"`
+		Text          string      `db:"text_body" json:"text"`
+		HTML          string      `db:"html_body" json:"html"`
+		CreatedAt     time.Time   `db:"created_at" json:"createdAt"`
+		CreatedBy     null.Int    `db:"created_by" json:"createdBy"`
+		CreatedByName null.String `db:"created_by_name" json:"createdByName"`
+	}
+
+	// Rendered is a Template filled in with data, ready to be sent
+	Rendered struct {
+		Subject string `json:"subject"`
+		From    string `json:"from"`
+		Text    string `json:"text"`
+		HTML    string `json:"html"`
+	}
+)
+
+// DefaultLanguage is what the built-in templates are written in and is used when a user hasn't chosen a language
+const DefaultLanguage = "en"
+
+// ErrNotFound is returned for a name that isn't one of the Defaults
+var ErrNotFound = errors.New("email template not found")
+
+var _ Repo = &Store{}
+
+// NewEmailTemplateRepo stores our dependency
+func NewEmailTemplateRepo(db *sqlx.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+// GetTemplates returns every template that has been saved, the ones that haven't are still the default
+func (s *Store) GetTemplates(ctx context.Context) ([]Template, error) {
+	return s.getTemplates(ctx)
+}
+
+// GetTemplate returns the template with the name in the language, if it hasn't been translated it is a copy of the
+// default language with no TemplateID which becomes the translation once saved
+func (s *Store) GetTemplate(ctx context.Context, t Template) (Template, error) {
+	d, ok := GetDefault(t.Name)
+	if !ok {
+		return Template{}, fmt.Errorf("failed to get email template %s: %w", t.Name, ErrNotFound)
+	}
+
+	lang, err := CanonicalLanguage(t.Language)
+	if err != nil {
+		return Template{}, err
+	}
+
+	saved, err := s.getTemplatesForName(ctx, d.Name)
+	if err != nil {
+		return Template{}, err
+	}
+
+	base, _, _ := strings.Cut(lang, "-")
+
+	for _, candidate := range []string{lang, base, DefaultLanguage} {
+		for _, t1 := range saved {
+			if t1.Language != candidate {
+				continue
+			}
+
+			if candidate == lang {
+				return t1, nil
+			}
+
+			return fallback(t1, lang), nil
+		}
+	}
+
+	t1, err := d.Template()
+	if err != nil {
+		return Template{}, err
+	}
+
+	return fallback(t1, lang), nil
+}
+
+// SaveTemplate checks the template renders with the sample data then saves it as a new version
+func (s *Store) SaveTemplate(ctx context.Context, t Template, savedBy int) (Template, error) {
+	lang, err := CanonicalLanguage(t.Language)
+	if err != nil {
+		return Template{}, err
+	}
+
+	t.Language = lang
+
+	_, err = t.Preview()
+	if err != nil {
+		return Template{}, err
+	}
+
+	return s.saveTemplate(ctx, t, savedBy)
+}
+
+// DeleteTemplate deletes a saved template and its versions, the default language goes back to the built-in one
+func (s *Store) DeleteTemplate(ctx context.Context, t Template) error {
+	return s.deleteTemplate(ctx, t)
+}
+
+// GetVersions returns every saved version of the template, newest first
+func (s *Store) GetVersions(ctx context.Context, t Template) ([]Version, error) {
+	return s.getVersions(ctx, t)
+}
+
+// GetVersion returns a version of a template
+func (s *Store) GetVersion(ctx context.Context, v Version) (Version, error) {
+	return s.getVersion(ctx, v)
+}
+
+// GetLanguages returns the default language and every language a template has been translated to
+func (s *Store) GetLanguages(ctx context.Context) ([]string, error) {
+	return s.getLanguages(ctx)
+}
+
+// Mail renders the template in the language, or the default language if it hasn't been translated, into an email
+// to the address
+func (s *Store) Mail(ctx context.Context, name Name, lang, to string, data interface{}) (mail.Mail, error) {
+	t, err := s.GetTemplate(ctx, Template{Name: name, Language: lang})
+	if err != nil {
+		// a language that isn't valid is the user's setting being out of date, it shouldn't stop the email
+		t, err = s.GetTemplate(ctx, Template{Name: name})
+		if err != nil {
+			return mail.Mail{}, err
+		}
+	}
+
+	r, err := t.Render(data)
+	if err != nil {
+		return mail.Mail{}, err
+	}
+
+	return r.Mail(to), nil
+}
+
+// Render fills in every part of the template with the data
+func (t Template) Render(data interface{}) (Rendered, error) {
+	r := Rendered{
+		From: t.From,
+	}
+
+	subject, err := executeText(string(t.Name)+" subject", t.Subject, data)
+	if err != nil {
+		return Rendered{}, err
+	}
+
+	// a header can't have a line break in it
+	r.Subject = strings.Join(strings.Fields(subject), " ")
+
+	r.Text, err = executeText(string(t.Name)+" text", t.Text, data)
+	if err != nil {
+		return Rendered{}, err
+	}
+
+	tmpl, err := htmltemplate.New(string(t.Name) + " html").Parse(t.HTML)
+	if err != nil {
+		return Rendered{}, fmt.Errorf("failed to parse html: %w", err)
+	}
+
+	var b bytes.Buffer
+
+	err = tmpl.Execute(&b, data)
+	if err != nil {
+		return Rendered{}, fmt.Errorf("failed to render html: %w", err)
+	}
+
+	r.HTML = b.String()
+
+	return r, nil
+}
+
+// Preview renders the template with its sample data, it is also how a template is checked before it is saved
+func (t Template) Preview() (Rendered, error) {
+	d, ok := GetDefault(t.Name)
+	if !ok {
+		return Rendered{}, fmt.Errorf("failed to preview email template %s: %w", t.Name, ErrNotFound)
+	}
+
+	if len(strings.TrimSpace(t.Subject)) == 0 {
+		return Rendered{}, errors.New("subject must be filled")
+	}
+
+	if len(strings.TrimSpace(t.Text)) == 0 && len(strings.TrimSpace(t.HTML)) == 0 {
+		return Rendered{}, errors.New("either the text or html must be filled")
+	}
+
+	_, err := netmail.ParseAddress(t.From)
+	if err != nil {
+		return Rendered{}, fmt.Errorf("from must be a valid address like \"YSTV <no-reply@ystv.co.uk>\": %w", err)
+	}
+
+	return t.Render(d.Sample)
+}
+
+// Mail returns the rendered template as an email to the address
+func (r Rendered) Mail(to string) mail.Mail {
+	return mail.Mail{
+		Subject: r.Subject,
+		To:      to,
+		From:    r.From,
+		Text:    r.Text,
+		HTML:    r.HTML,
+	}
+}
+
+// IsSaved returns if the template has been saved rather than being the default
+func (t Template) IsSaved() bool {
+	return t.TemplateID > 0
+}
+
+// Template returns the version as a template so it can be previewed or restored
+func (v Version) Template(t Template) Template {
+	t.Subject = v.Subject
+	t.From = v.From
+	t.Text = v.Text
+	t.HTML = v.HTML
+
+	return t
+}
+
+// CanonicalLanguage returns the language tag in its usual form, like en-GB, an empty language is the default
+func CanonicalLanguage(lang string) (string, error) {
+	lang = strings.TrimSpace(lang)
+	if len(lang) == 0 {
+		return DefaultLanguage, nil
+	}
+
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return "", fmt.Errorf("\"%s\" isn't a valid language, use a tag like \"cy\" or \"en-GB\"", lang)
+	}
+
+	return tag.String(), nil
+}
+
+// fallback turns a template into the unsaved translation of it
+func fallback(t Template, lang string) Template {
+	return Template{
+		Name:     t.Name,
+		Language: lang,
+		Subject:  t.Subject,
+		From:     t.From,
+		Text:     t.Text,
+		HTML:     t.HTML,
+	}
+}
+
+func executeText(name, text string, data interface{}) (string, error) {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+
+	var b bytes.Buffer
+
+	err = tmpl.Execute(&b, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to render %s: %w", name, err)
+	}
+
+	return b.String(), nil
+}
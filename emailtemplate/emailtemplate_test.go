@@ -0,0 +1,116 @@
+package emailtemplate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaults(t *testing.T) {
+	for _, d := range Defaults {
+		t.Run(string(d.Name), func(t *testing.T) {
+			t1, err := d.Template()
+			require.NoError(t, err)
+
+			r, err := t1.Preview()
+			require.NoError(t, err)
+
+			assert.NotEmpty(t, r.Subject)
+			assert.NotContains(t, r.Subject, "\n")
+			assert.NotEmpty(t, r.Text)
+			assert.NotEmpty(t, r.HTML)
+			assert.NotEmpty(t, d.Fields())
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	t1 := Template{
+		Name:    Signup,
+		Subject: "Welcome {{.Name}}",
+		From:    "YSTV <no-reply@ystv.co.uk>",
+		Text:    "Hi {{.Name}}",
+		HTML:    "<p>Hi {{.Name}}</p>",
+	}
+
+	r, err := t1.Render(SignupData{Name: "<Jane>"})
+	require.NoError(t, err)
+
+	// only the html part is escaped as the others aren't html
+	assert.Equal(t, "Welcome <Jane>", r.Subject)
+	assert.Equal(t, "Hi <Jane>", r.Text)
+	assert.Equal(t, "<p>Hi &lt;Jane&gt;</p>", r.HTML)
+	assert.Equal(t, t1.From, r.From)
+}
+
+func TestPreview(t *testing.T) {
+	valid := Template{
+		Name:    Forgot,
+		Subject: "Reset",
+		From:    "YSTV <no-reply@ystv.co.uk>",
+		Text:    "{{.URL}}",
+	}
+
+	_, err := valid.Preview()
+	assert.NoError(t, err)
+
+	t.Run("BadTemplate", func(t *testing.T) {
+		t1 := valid
+		t1.Text = "{{.URL"
+
+		_, err = t1.Preview()
+		assert.Error(t, err)
+	})
+
+	t.Run("UnknownField", func(t *testing.T) {
+		t1 := valid
+		t1.HTML = "{{.Password}}"
+
+		_, err = t1.Preview()
+		assert.Error(t, err)
+	})
+
+	t.Run("BadFrom", func(t *testing.T) {
+		t1 := valid
+		t1.From = "not an address"
+
+		_, err = t1.Preview()
+		assert.Error(t, err)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		t1 := valid
+		t1.Text = " "
+
+		_, err = t1.Preview()
+		assert.Error(t, err)
+	})
+
+	t.Run("UnknownName", func(t *testing.T) {
+		t1 := valid
+		t1.Name = "unknown"
+
+		_, err = t1.Preview()
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestCanonicalLanguage(t *testing.T) {
+	for lang, expected := range map[string]string{
+		"":       DefaultLanguage,
+		"en":     "en",
+		"en-gb":  "en-GB",
+		" cy ":   "cy",
+		"zh-hk":  "zh-HK",
+		"pt_BR":  "pt-BR",
+		"fr-CA ": "fr-CA",
+	} {
+		actual, err := CanonicalLanguage(lang)
+		require.NoError(t, err, lang)
+		assert.Equal(t, expected, actual, lang)
+	}
+
+	_, err := CanonicalLanguage("not a language")
+	assert.Error(t, err)
+}
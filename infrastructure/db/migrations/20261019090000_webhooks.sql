@@ -0,0 +1,46 @@
+-- +goose Up
+
+-- We will create the tables in the following order
+-- 1. web_auth.webhooks REFERENCES people.users
+-- 2. web_auth.webhook_deliveries REFERENCES web_auth.webhooks
+--
+-- web_auth.webhooks stores the subscriptions of external services to events happening in web-auth
+CREATE TABLE IF NOT EXISTS web_auth.webhooks(
+    webhook_id int GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name text NOT NULL,
+    url text NOT NULL,
+    secret text NOT NULL,
+    events text[] NOT NULL DEFAULT '{}',
+    active boolean NOT NULL DEFAULT true,
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    created_by int REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+COMMENT ON COLUMN web_auth.webhooks.secret IS 'Used to HMAC-SHA256 sign the payload so the receiver can verify it came from us';
+COMMENT ON COLUMN web_auth.webhooks.events IS 'The events the webhook is subscribed to, * subscribes to all events';
+--
+-- web_auth.webhook_deliveries is the persistent queue and log of every payload sent to a webhook
+CREATE TABLE IF NOT EXISTS web_auth.webhook_deliveries(
+    delivery_id int GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    webhook_id int NOT NULL REFERENCES web_auth.webhooks(webhook_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    event text NOT NULL,
+    payload text NOT NULL,
+    status text NOT NULL DEFAULT 'pending',
+    attempts int NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz NOT NULL DEFAULT NOW(),
+    last_attempt_at timestamptz,
+    response_code int,
+    response_body text,
+    error text,
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT statuschk CHECK (status IN ('pending', 'succeeded', 'failed'))
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx ON web_auth.webhook_deliveries(next_attempt_at)
+    WHERE status = 'pending';
+COMMENT ON COLUMN web_auth.webhook_deliveries.status IS
+    'pending - waiting to be sent or retried. succeeded - receiver responded with 2xx. failed - gave up after the maximum attempts';
+
+-- +goose Down
+
+DROP TABLE IF EXISTS web_auth.webhook_deliveries;
+DROP TABLE IF EXISTS web_auth.webhooks;
@@ -0,0 +1,10 @@
+-- +goose Up
+
+-- people.role_members.officership_sync marks the memberships granted by holding an officership,
+-- only these are removed again when the officer's term ends, so a role granted another way is kept
+ALTER TABLE people.role_members ADD COLUMN IF NOT EXISTS officership_sync boolean NOT NULL DEFAULT false;
+COMMENT ON COLUMN people.role_members.officership_sync IS 'Set when the membership was granted by the officership role sync';
+
+-- +goose Down
+
+ALTER TABLE people.role_members DROP COLUMN IF EXISTS officership_sync;
@@ -0,0 +1,9 @@
+-- +goose Up
+
+-- people.users.hide_from_public lets a user opt out of the public officer directory
+ALTER TABLE people.users ADD COLUMN IF NOT EXISTS hide_from_public boolean NOT NULL DEFAULT false;
+COMMENT ON COLUMN people.users.hide_from_public IS 'The user will not be shown in any public listings, i.e. the officer directory';
+
+-- +goose Down
+
+ALTER TABLE people.users DROP COLUMN IF EXISTS hide_from_public;
@@ -0,0 +1,52 @@
+-- +goose Up
+
+-- people.permission_implications stores the permission hierarchy, holding the parent permission also grants the child
+-- permission and everything the child implies
+CREATE TABLE IF NOT EXISTS people.permission_implications(
+    parent_permission_id int NOT NULL REFERENCES people.permissions(permission_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    child_permission_id int NOT NULL REFERENCES people.permissions(permission_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT permission_implications_pkey PRIMARY KEY (parent_permission_id, child_permission_id),
+    CONSTRAINT permission_implications_selfchk CHECK (parent_permission_id <> child_permission_id)
+);
+CREATE INDEX IF NOT EXISTS permission_implications_child_idx ON people.permission_implications(child_permission_id);
+--
+-- the hierarchy that used to be hard-coded, SuperUser is still sufficient for everything so isn't included
+INSERT INTO people.permission_implications (parent_permission_id, child_permission_id)
+SELECT parent.permission_id, child.permission_id
+FROM (VALUES
+    ('ManageMembers.Members.Admin', 'ManageMembers.Members.List'),
+    ('ManageMembers.Members.Admin', 'ManageMembers.Members.Add'),
+    ('ManageMembers.Admin', 'ManageMembers.Members.Admin'),
+    ('ManageMembers.Admin', 'ManageMembers.Permissions'),
+    ('ManageMembers.Admin', 'ManageMembers.Misc.KeyList'),
+    ('ManageMembers.Admin', 'ManageMembers.Misc.UnpaidList'),
+    ('ManageMembers.Admin', 'ManageMembers.Officers'),
+    ('ManageMembers.Admin', 'ManageMembers.Groups'),
+    ('Email.Everyone', 'Email.Access'),
+    ('Email.Everyone', 'Email.Alumni'),
+    ('Email.Everyone', 'Email.Officers'),
+    ('Calendar.Social.Admin', 'Calendar.Social.Creator'),
+    ('Calendar.Show.Admin', 'Calendar.Show.Creator'),
+    ('Calendar.Meeting.Admin', 'Calendar.Meeting.Creator'),
+    ('Calendar.Admin', 'Calendar.Social.Admin'),
+    ('Calendar.Admin', 'Calendar.Show.Admin'),
+    ('Calendar.Admin', 'Calendar.Meeting.Admin'),
+    ('CMS.News.Item.Admin', 'CMS.News.Item.Creator'),
+    ('CMS.News.Admin', 'CMS.News.Item.Admin'),
+    ('CMS.News.Admin', 'CMS.News.Creator'),
+    ('CMS.Page.Admin', 'CMS.Page.Creator'),
+    ('CMS.Slideshow.Admin', 'CMS.Slideshow.Creator'),
+    ('CMS.Admin', 'CMS.News.Admin'),
+    ('CMS.Admin', 'CMS.Page.Admin'),
+    ('CMS.Admin', 'CMS.Slideshow.Admin'),
+    ('CMS.Admin', 'CMS.EndboardAdmin'),
+    ('CMS.Admin', 'CMS.View'),
+    ('CMS.Admin', 'CMS.Permalink.Admin')
+) AS h(parent_name, child_name)
+INNER JOIN people.permissions parent ON parent.name = h.parent_name
+INNER JOIN people.permissions child ON child.name = h.child_name
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+
+DROP TABLE IF EXISTS people.permission_implications;
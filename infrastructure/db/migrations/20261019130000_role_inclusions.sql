@@ -0,0 +1,15 @@
+-- +goose Up
+
+-- people.role_inclusions lets a role include another, members of the role are also treated as members of the
+-- included role and everything it includes
+CREATE TABLE IF NOT EXISTS people.role_inclusions(
+    role_id int NOT NULL REFERENCES people.roles(role_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    included_role_id int NOT NULL REFERENCES people.roles(role_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT role_inclusions_pkey PRIMARY KEY (role_id, included_role_id),
+    CONSTRAINT role_inclusions_selfchk CHECK (role_id <> included_role_id)
+);
+CREATE INDEX IF NOT EXISTS role_inclusions_included_idx ON people.role_inclusions(included_role_id);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS people.role_inclusions;
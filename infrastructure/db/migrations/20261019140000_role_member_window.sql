@@ -0,0 +1,28 @@
+-- +goose Up
+
+-- people.role_members can now be temporary, a membership only counts between starts_at and ends_at and is removed
+-- by the expiry job once ends_at has passed
+ALTER TABLE people.role_members
+    ADD COLUMN IF NOT EXISTS starts_at timestamptz,
+    ADD COLUMN IF NOT EXISTS ends_at timestamptz,
+    ADD COLUMN IF NOT EXISTS granted_by int REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS granted_at timestamptz NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS reason text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS expiry_notified_at timestamptz,
+    ADD CONSTRAINT role_members_windowchk CHECK (starts_at IS NULL OR ends_at IS NULL OR starts_at < ends_at);
+CREATE INDEX IF NOT EXISTS role_members_ends_at_idx ON people.role_members(ends_at) WHERE ends_at IS NOT NULL;
+COMMENT ON COLUMN people.role_members.starts_at IS 'The membership is ignored before this, NULL means it has always counted';
+COMMENT ON COLUMN people.role_members.ends_at IS 'The membership is ignored from this and removed by the expiry job, NULL means it never expires';
+COMMENT ON COLUMN people.role_members.expiry_notified_at IS 'When the user was emailed that the membership is about to expire';
+
+-- +goose Down
+
+DROP INDEX IF EXISTS people.role_members_ends_at_idx;
+ALTER TABLE people.role_members
+    DROP CONSTRAINT IF EXISTS role_members_windowchk,
+    DROP COLUMN IF EXISTS expiry_notified_at,
+    DROP COLUMN IF EXISTS reason,
+    DROP COLUMN IF EXISTS granted_at,
+    DROP COLUMN IF EXISTS granted_by,
+    DROP COLUMN IF EXISTS ends_at,
+    DROP COLUMN IF EXISTS starts_at;
@@ -0,0 +1,34 @@
+-- +goose Up
+
+-- people.roles.requestable lets users ask for the role on the request access page and
+-- people.roles.approver_permission_id lets the holders of that permission approve the requests,
+-- anyone with ManageMembers.Groups can approve requests for any role
+ALTER TABLE people.roles
+    ADD COLUMN IF NOT EXISTS requestable boolean NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS approver_permission_id int REFERENCES people.permissions(permission_id) ON UPDATE CASCADE ON DELETE SET NULL;
+--
+-- people.access_requests stores every request for a role and its outcome
+CREATE TABLE IF NOT EXISTS people.access_requests(
+    request_id int GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    role_id int NOT NULL REFERENCES people.roles(role_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    user_id int NOT NULL REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    reason text NOT NULL DEFAULT '',
+    status text NOT NULL DEFAULT 'pending',
+    requested_at timestamptz NOT NULL DEFAULT NOW(),
+    decided_by int REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE SET NULL,
+    decided_at timestamptz,
+    comment text NOT NULL DEFAULT '',
+    ends_at timestamptz,
+
+    CONSTRAINT statuschk CHECK (status IN ('pending', 'approved', 'denied', 'cancelled'))
+);
+CREATE UNIQUE INDEX IF NOT EXISTS access_requests_pending_idx ON people.access_requests(role_id, user_id)
+    WHERE status = 'pending';
+COMMENT ON COLUMN people.access_requests.ends_at IS 'Set by the approver to make the granted membership temporary';
+
+-- +goose Down
+
+DROP TABLE IF EXISTS people.access_requests;
+ALTER TABLE people.roles
+    DROP COLUMN IF EXISTS approver_permission_id,
+    DROP COLUMN IF EXISTS requestable;
@@ -0,0 +1,38 @@
+-- +goose Up
+
+-- people.access_reviews is a campaign to check that the members of some roles should still have them
+CREATE TABLE IF NOT EXISTS people.access_reviews(
+    review_id int GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name text NOT NULL,
+    deadline timestamptz NOT NULL,
+    auto_revoke boolean NOT NULL DEFAULT false,
+    created_by int REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE SET NULL,
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    completed_at timestamptz,
+    reminded_at timestamptz
+);
+COMMENT ON COLUMN people.access_reviews.auto_revoke IS 'Memberships not reviewed by the deadline are removed';
+--
+-- people.access_review_items are the memberships in a review, they are copied when the review starts so later
+-- changes to the roles don't change the review
+CREATE TABLE IF NOT EXISTS people.access_review_items(
+    item_id int GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    review_id int NOT NULL REFERENCES people.access_reviews(review_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    role_id int NOT NULL REFERENCES people.roles(role_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    user_id int NOT NULL REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    decision text NOT NULL DEFAULT 'pending',
+    decided_by int REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE SET NULL,
+    decided_at timestamptz,
+    comment text NOT NULL DEFAULT '',
+    auto_revoked boolean NOT NULL DEFAULT false,
+
+    CONSTRAINT decisionchk CHECK (decision IN ('pending', 'keep', 'revoke')),
+    UNIQUE (review_id, role_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS access_review_items_pending_idx ON people.access_review_items(review_id)
+    WHERE decision = 'pending';
+
+-- +goose Down
+
+DROP TABLE IF EXISTS people.access_review_items;
+DROP TABLE IF EXISTS people.access_reviews;
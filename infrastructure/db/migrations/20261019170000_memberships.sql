@@ -0,0 +1,44 @@
+-- +goose Up
+
+-- people.membership_types are the kinds of membership that can be bought, paid members of a type are given its role
+CREATE TABLE IF NOT EXISTS people.membership_types(
+    type_id int GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name text NOT NULL UNIQUE,
+    description text NOT NULL DEFAULT '',
+    role_id int REFERENCES people.roles(role_id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+COMMENT ON COLUMN people.membership_types.role_id IS 'Role given to users while they have a paid membership of this type';
+--
+-- people.memberships is a user's membership for an academic year, it counts as paid once paid_at is set and until
+-- paid_until
+CREATE TABLE IF NOT EXISTS people.memberships(
+    membership_id int GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id int NOT NULL REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    type_id int NOT NULL REFERENCES people.membership_types(type_id) ON UPDATE CASCADE ON DELETE RESTRICT,
+    academic_year text NOT NULL,
+    paid_at timestamptz,
+    paid_until timestamptz NOT NULL,
+    amount_pence int NOT NULL DEFAULT 0,
+    source text NOT NULL DEFAULT 'manual',
+    reference text NOT NULL DEFAULT '',
+    created_by int REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE SET NULL,
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    reminded_at timestamptz,
+
+    CONSTRAINT sourcechk CHECK (source IN ('manual', 'su_import')),
+    CONSTRAINT amountchk CHECK (amount_pence >= 0),
+    UNIQUE (user_id, type_id, academic_year)
+);
+CREATE INDEX IF NOT EXISTS memberships_paid_until_idx ON people.memberships(paid_until);
+COMMENT ON COLUMN people.memberships.reference IS 'Order or card number from the source, used to match re-imports';
+--
+-- people.role_members.membership_sync marks the memberships granted for having a paid membership, only these are
+-- removed again when it runs out, so a role granted another way is kept
+ALTER TABLE people.role_members ADD COLUMN IF NOT EXISTS membership_sync boolean NOT NULL DEFAULT false;
+COMMENT ON COLUMN people.role_members.membership_sync IS 'Set when the membership was granted by the paid membership role sync';
+
+-- +goose Down
+
+ALTER TABLE people.role_members DROP COLUMN IF EXISTS membership_sync;
+DROP TABLE IF EXISTS people.memberships;
+DROP TABLE IF EXISTS people.membership_types;
@@ -0,0 +1,47 @@
+-- +goose Up
+
+-- people.keylist_keys are the physical keys and card access that can be given out, a key can have more than one copy
+CREATE TABLE IF NOT EXISTS people.keylist_keys(
+    key_id int GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name text NOT NULL UNIQUE,
+    kind text NOT NULL DEFAULT 'key',
+    description text NOT NULL DEFAULT '',
+    location text NOT NULL DEFAULT '',
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT kindchk CHECK (kind IN ('key', 'card'))
+);
+--
+-- people.keylist_grants is who holds a key, a grant is held until returned_at is set
+CREATE TABLE IF NOT EXISTS people.keylist_grants(
+    grant_id int GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    key_id int NOT NULL REFERENCES people.keylist_keys(key_id) ON UPDATE CASCADE ON DELETE RESTRICT,
+    user_id int NOT NULL REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    issued_at timestamptz NOT NULL DEFAULT NOW(),
+    issued_by int REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE SET NULL,
+    expires_at timestamptz,
+    returned_at timestamptz,
+    returned_to int REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE SET NULL,
+    note text NOT NULL DEFAULT ''
+);
+CREATE UNIQUE INDEX IF NOT EXISTS keylist_grants_held_idx ON people.keylist_grants(key_id, user_id) WHERE returned_at IS NULL;
+COMMENT ON COLUMN people.keylist_grants.expires_at IS 'When the key should be returned by, it is still held until it is';
+--
+-- people.keylist_events is the log of keys being issued, extended and returned for the porters
+CREATE TABLE IF NOT EXISTS people.keylist_events(
+    event_id int GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    grant_id int NOT NULL REFERENCES people.keylist_grants(grant_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    event text NOT NULL,
+    event_at timestamptz NOT NULL DEFAULT NOW(),
+    event_by int REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE SET NULL,
+    note text NOT NULL DEFAULT '',
+
+    CONSTRAINT eventchk CHECK (event IN ('issued', 'extended', 'returned'))
+);
+CREATE INDEX IF NOT EXISTS keylist_events_grant_id_idx ON people.keylist_events(grant_id);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS people.keylist_events;
+DROP TABLE IF EXISTS people.keylist_grants;
+DROP TABLE IF EXISTS people.keylist_keys;
@@ -0,0 +1,15 @@
+-- +goose Up
+
+-- people.data_exports records every time a user's data was downloaded, either by themselves or an admin answering a
+-- subject access request
+CREATE TABLE IF NOT EXISTS people.data_exports(
+    export_id int GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id int NOT NULL REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    exported_at timestamptz NOT NULL DEFAULT NOW(),
+    exported_by int REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS data_exports_user_id_idx ON people.data_exports(user_id);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS people.data_exports;
@@ -0,0 +1,12 @@
+-- +goose Up
+
+-- people.users.anonymised_at is set once a soft deleted user's personal details have been scrubbed after the
+-- retention period, the row is kept so the officership history still has someone to point at
+ALTER TABLE people.users ADD COLUMN IF NOT EXISTS anonymised_at timestamptz;
+COMMENT ON COLUMN people.users.anonymised_at IS 'When the personal details of this soft deleted user were scrubbed';
+CREATE INDEX IF NOT EXISTS users_deleted_at_idx ON people.users(deleted_at) WHERE deleted_at IS NOT NULL AND anonymised_at IS NULL;
+
+-- +goose Down
+
+DROP INDEX IF EXISTS people.users_deleted_at_idx;
+ALTER TABLE people.users DROP COLUMN IF EXISTS anonymised_at;
@@ -0,0 +1,17 @@
+-- +goose Up
+
+-- people.duplicate_dismissals are pairs of users an admin has said aren't the same person, so the duplicate report
+-- doesn't keep showing them, the lower user id is always first
+CREATE TABLE IF NOT EXISTS people.duplicate_dismissals(
+    user_id_a int NOT NULL REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    user_id_b int NOT NULL REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    dismissed_at timestamptz NOT NULL DEFAULT NOW(),
+    dismissed_by int REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE SET NULL,
+
+    CONSTRAINT duplicate_dismissals_pkey PRIMARY KEY (user_id_a, user_id_b),
+    CONSTRAINT orderchk CHECK (user_id_a < user_id_b)
+);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS people.duplicate_dismissals;
@@ -0,0 +1,29 @@
+-- +goose Up
+
+-- people.email_changes is every change of a user's email, a change is pending until the new address is verified,
+-- after which the old address can revert it for a while in case the account was taken over
+CREATE TABLE IF NOT EXISTS people.email_changes(
+    change_id int GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id int NOT NULL REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    old_email text NOT NULL,
+    new_email text NOT NULL,
+    status text NOT NULL DEFAULT 'pending',
+    verified bool NOT NULL DEFAULT false,
+    verify_token text UNIQUE,
+    revert_token text UNIQUE,
+    requested_at timestamptz NOT NULL DEFAULT NOW(),
+    requested_by int REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE SET NULL,
+    expires_at timestamptz NOT NULL,
+    changed_at timestamptz,
+    reverted_at timestamptz,
+
+    CONSTRAINT statuschk CHECK (status IN ('pending', 'changed', 'reverted', 'cancelled'))
+);
+CREATE INDEX IF NOT EXISTS email_changes_user_id_idx ON people.email_changes(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS email_changes_pending_idx ON people.email_changes(user_id) WHERE status = 'pending';
+COMMENT ON COLUMN people.email_changes.verified IS 'False when an admin changed the email without verifying it';
+COMMENT ON COLUMN people.email_changes.expires_at IS 'When the verify link stops working';
+
+-- +goose Down
+
+DROP TABLE IF EXISTS people.email_changes;
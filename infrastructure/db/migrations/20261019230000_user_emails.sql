@@ -0,0 +1,61 @@
+-- +goose Up
+
+-- people.user_emails is every email address of a user, any verified one can be used to log in and reset the
+-- password, the primary one is the one emailed and is kept in people.users.email as well
+CREATE TABLE IF NOT EXISTS people.user_emails(
+    email_id int GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id int NOT NULL REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    email text NOT NULL,
+    verified bool NOT NULL DEFAULT false,
+    is_primary bool NOT NULL DEFAULT false,
+    verify_token text UNIQUE,
+    verify_expires_at timestamptz,
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    verified_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS user_emails_user_email_idx ON people.user_emails(user_id, lower(email));
+CREATE UNIQUE INDEX IF NOT EXISTS user_emails_email_idx ON people.user_emails(lower(email)) WHERE verified OR is_primary;
+CREATE UNIQUE INDEX IF NOT EXISTS user_emails_primary_idx ON people.user_emails(user_id) WHERE is_primary;
+COMMENT ON COLUMN people.user_emails.verified IS 'Unverified addresses can''t be used to log in and don''t stop another user adding them';
+COMMENT ON COLUMN people.user_emails.verify_expires_at IS 'When the link sent to the address stops working';
+
+-- addresses that only differ by case can't both be kept, the accounts have to be merged or one of the addresses
+-- changed before this is run again, rather than one of them being left without an address to log in with
+-- +goose StatementBegin
+DO $$
+DECLARE
+    duplicates text;
+BEGIN
+    SELECT string_agg(format('%s (user ids %s)', lower(email), user_ids), ', ')
+    INTO duplicates
+    FROM (
+        SELECT lower(email) AS email, string_agg(user_id::text, ', ' ORDER BY user_id) AS user_ids
+        FROM people.users
+        WHERE email <> '' AND anonymised_at IS NULL
+        GROUP BY lower(email)
+        HAVING COUNT(*) > 1
+    ) d;
+
+    IF duplicates IS NOT NULL THEN
+        RAISE EXCEPTION 'users share an email when the case is ignored, merge them or change the email: %', duplicates;
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+
+-- the existing addresses are trusted as they have been used to log in until now
+INSERT INTO people.user_emails(user_id, email, verified, is_primary, created_at, verified_at)
+SELECT user_id, email, true, true, COALESCE(created_at, NOW()), COALESCE(created_at, NOW())
+FROM people.users
+WHERE email <> '' AND anonymised_at IS NULL;
+
+-- the addresses are unique across people.user_emails now
+ALTER TABLE people.users DROP CONSTRAINT IF EXISTS users_email_key;
+COMMENT ON COLUMN people.users.email IS 'The primary address in people.user_emails';
+
+-- +goose Down
+
+COMMENT ON COLUMN people.users.email IS 'Would be not null, but we have existing data';
+ALTER TABLE people.users ADD CONSTRAINT users_email_key UNIQUE (email);
+
+DROP TABLE IF EXISTS people.user_emails;
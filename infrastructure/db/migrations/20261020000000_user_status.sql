@@ -0,0 +1,37 @@
+-- +goose Up
+
+-- people.users.status is where a user is in their time with YSTV, alumni and honorary users keep a limited account
+-- rather than being disabled and suspended users can't log in
+ALTER TABLE people.users
+    ADD COLUMN IF NOT EXISTS status text NOT NULL DEFAULT 'member',
+    ADD CONSTRAINT users_statuschk CHECK (status IN ('member', 'alumni', 'honorary', 'suspended'));
+CREATE INDEX IF NOT EXISTS users_status_idx ON people.users(status);
+
+-- people.status_roles are the roles users with a status are given, moving to another status removes the roles of
+-- the old one that the new one doesn't have
+CREATE TABLE IF NOT EXISTS people.status_roles(
+    status text NOT NULL CHECK (status IN ('member', 'alumni', 'honorary', 'suspended')),
+    role_id int NOT NULL REFERENCES people.roles(role_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT status_roles_pkey PRIMARY KEY (status, role_id)
+);
+
+-- people.status_changes is the history of a user's status
+CREATE TABLE IF NOT EXISTS people.status_changes(
+    change_id int GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id int NOT NULL REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    from_status text NOT NULL,
+    to_status text NOT NULL,
+    reason text NOT NULL DEFAULT '',
+    changed_at timestamptz NOT NULL DEFAULT NOW(),
+    changed_by int REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS status_changes_user_id_idx ON people.status_changes(user_id);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS people.status_changes;
+DROP TABLE IF EXISTS people.status_roles;
+DROP INDEX IF EXISTS people.users_status_idx;
+ALTER TABLE people.users
+    DROP CONSTRAINT IF EXISTS users_statuschk,
+    DROP COLUMN IF EXISTS status;
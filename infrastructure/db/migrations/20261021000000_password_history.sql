@@ -0,0 +1,15 @@
+-- +goose Up
+
+-- people.password_history is the hashes of a user's old passwords, they are hashed with the user's salt so a new
+-- password can be checked against them without keeping anything readable
+CREATE TABLE IF NOT EXISTS people.password_history(
+    history_id int GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    user_id int NOT NULL REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    password text NOT NULL,
+    replaced_at timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS password_history_user_id_idx ON people.password_history(user_id, replaced_at DESC);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS people.password_history;
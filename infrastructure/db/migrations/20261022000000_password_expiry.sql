@@ -0,0 +1,23 @@
+-- +goose Up
+
+-- people.roles.password_max_age_days is how long the members of a role can keep a password, a user in more than one
+-- role with a maximum age has the shortest
+ALTER TABLE people.roles
+    ADD COLUMN IF NOT EXISTS password_max_age_days int,
+    ADD CONSTRAINT roles_password_max_age_dayschk CHECK (password_max_age_days > 0);
+
+-- people.users.password_changed_at is when the user's password was last set, existing users are counted from now so
+-- nobody's password expires as soon as a role is given a maximum age, password_expiry_warned_at is when they were
+-- emailed about it expiring and is cleared when it is changed
+ALTER TABLE people.users
+    ADD COLUMN IF NOT EXISTS password_changed_at timestamptz NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS password_expiry_warned_at timestamptz;
+
+-- +goose Down
+
+ALTER TABLE people.users
+    DROP COLUMN IF EXISTS password_expiry_warned_at,
+    DROP COLUMN IF EXISTS password_changed_at;
+ALTER TABLE people.roles
+    DROP CONSTRAINT IF EXISTS roles_password_max_age_dayschk,
+    DROP COLUMN IF EXISTS password_max_age_days;
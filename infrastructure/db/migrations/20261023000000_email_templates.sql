@@ -0,0 +1,50 @@
+-- +goose Up
+
+-- We will create the tables in the following order
+-- 1. web_auth.email_templates REFERENCES people.users
+-- 2. web_auth.email_template_versions REFERENCES web_auth.email_templates, people.users
+--
+-- web_auth.email_templates stores the emails that have been edited or translated, an email without a row here is
+-- sent with the built-in template
+CREATE TABLE IF NOT EXISTS web_auth.email_templates(
+    template_id int GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name text NOT NULL,
+    language text NOT NULL,
+    subject text NOT NULL,
+    from_address text NOT NULL,
+    text_body text NOT NULL,
+    html_body text NOT NULL,
+    version int NOT NULL DEFAULT 1,
+    updated_at timestamptz NOT NULL DEFAULT NOW(),
+    updated_by int REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE SET NULL,
+
+    CONSTRAINT email_templates_name_language_key UNIQUE (name, language)
+);
+COMMENT ON COLUMN web_auth.email_templates.name IS 'The email the template is for, like forgot';
+COMMENT ON COLUMN web_auth.email_templates.language IS 'A BCP 47 language tag, like en or en-GB';
+--
+-- web_auth.email_template_versions is every save of a template so an old one can be restored
+CREATE TABLE IF NOT EXISTS web_auth.email_template_versions(
+    version_id int GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    template_id int NOT NULL REFERENCES web_auth.email_templates(template_id) ON UPDATE CASCADE ON DELETE CASCADE,
+    version int NOT NULL,
+    subject text NOT NULL,
+    from_address text NOT NULL,
+    text_body text NOT NULL,
+    html_body text NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    created_by int REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE SET NULL,
+
+    CONSTRAINT email_template_versions_template_id_version_key UNIQUE (template_id, version)
+);
+
+-- people.users.language is the language a user's emails are sent in, NULL is the default
+ALTER TABLE people.users
+    ADD COLUMN IF NOT EXISTS language text;
+
+-- +goose Down
+
+ALTER TABLE people.users
+    DROP COLUMN IF EXISTS language;
+DROP TABLE IF EXISTS web_auth.email_template_versions;
+DROP TABLE IF EXISTS web_auth.email_templates;
@@ -0,0 +1,34 @@
+-- +goose Up
+
+-- web_auth.mail_queue is the persistent queue and log of every email sent, requests add to it and the worker sends
+-- them so a slow or broken mail server doesn't hang or lose them
+CREATE TABLE IF NOT EXISTS web_auth.mail_queue(
+    message_id int GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    subject text NOT NULL,
+    to_address text NOT NULL,
+    cc_addresses text[],
+    bcc_addresses text[],
+    from_address text NOT NULL,
+    text_body text NOT NULL,
+    html_body text NOT NULL,
+    status text NOT NULL DEFAULT 'queued',
+    attempts int NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz NOT NULL DEFAULT NOW(),
+    last_attempt_at timestamptz,
+    error text,
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    sent_at timestamptz,
+
+    CONSTRAINT statuschk CHECK (status IN ('queued', 'sent', 'failed'))
+);
+CREATE INDEX IF NOT EXISTS mail_queue_queued_idx ON web_auth.mail_queue(next_attempt_at)
+    WHERE status = 'queued';
+CREATE INDEX IF NOT EXISTS mail_queue_created_at_idx ON web_auth.mail_queue(created_at);
+COMMENT ON COLUMN web_auth.mail_queue.status IS
+    'queued - waiting to be sent or retried. sent - accepted by the mail server. failed - gave up after the maximum attempts';
+COMMENT ON COLUMN web_auth.mail_queue.next_attempt_at IS
+    'Moved forward when the worker claims a message so another worker does not send it too';
+
+-- +goose Down
+
+DROP TABLE IF EXISTS web_auth.mail_queue;
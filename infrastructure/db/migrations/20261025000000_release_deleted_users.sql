@@ -0,0 +1,22 @@
+-- +goose Up
+
+-- deleted users free their username and email straight away now, rather than when they are anonymised, so the
+-- users already waiting to be anonymised are released too. Their addresses are kept unverified so their mail can
+-- still be found and scrubbed when they are anonymised
+UPDATE people.users
+SET username      = 'deleted-' || user_id,
+    email         = 'noreply+' || user_id || '@ystv.co.uk',
+    ldap_username = NULL
+WHERE deleted_at IS NOT NULL AND anonymised_at IS NULL;
+
+UPDATE people.user_emails
+SET verified          = false,
+    is_primary        = false,
+    verify_token      = NULL,
+    verify_expires_at = NULL
+WHERE user_id IN (SELECT user_id FROM people.users WHERE deleted_at IS NOT NULL);
+
+-- +goose Down
+
+-- the released usernames and addresses can't be given back
+SELECT 1;
@@ -0,0 +1,20 @@
+-- +goose Up
+
+-- web_auth.user_imports keeps each previewed bulk import of users and its progress so neither is lost when the
+-- server restarts, they are deleted a day after they were uploaded
+CREATE TABLE IF NOT EXISTS web_auth.user_imports(
+    import_id text PRIMARY KEY,
+    rows jsonb NOT NULL,
+    options jsonb NOT NULL,
+    progress jsonb NOT NULL,
+    created_by int REFERENCES people.users(user_id) ON UPDATE CASCADE ON DELETE SET NULL,
+    created_at timestamptz NOT NULL DEFAULT NOW(),
+    updated_at timestamptz NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS user_imports_created_at_idx ON web_auth.user_imports(created_at);
+COMMENT ON COLUMN web_auth.user_imports.progress IS
+    'Saved after every row, an import that was running when the server stopped is marked as interrupted on startup';
+
+-- +goose Down
+
+DROP TABLE IF EXISTS web_auth.user_imports;
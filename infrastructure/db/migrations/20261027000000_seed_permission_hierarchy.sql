@@ -0,0 +1,88 @@
+-- +goose Up
+
+-- 20261019120000_permission_hierarchy only joined on the permissions that already existed, so a fresh database got
+-- none of the hierarchy. The permissions in it are added when they are missing and only the edges touching a
+-- permission added here are inserted, so an edge an admin has since removed isn't put back
+WITH added AS (
+    INSERT INTO people.permissions (name)
+    VALUES
+    ('ManageMembers.Members.Admin'),
+    ('ManageMembers.Members.List'),
+    ('ManageMembers.Members.Add'),
+    ('ManageMembers.Admin'),
+    ('ManageMembers.Permissions'),
+    ('ManageMembers.Misc.KeyList'),
+    ('ManageMembers.Misc.UnpaidList'),
+    ('ManageMembers.Officers'),
+    ('ManageMembers.Groups'),
+    ('Email.Everyone'),
+    ('Email.Access'),
+    ('Email.Alumni'),
+    ('Email.Officers'),
+    ('Calendar.Social.Admin'),
+    ('Calendar.Social.Creator'),
+    ('Calendar.Show.Admin'),
+    ('Calendar.Show.Creator'),
+    ('Calendar.Meeting.Admin'),
+    ('Calendar.Meeting.Creator'),
+    ('Calendar.Admin'),
+    ('CMS.News.Item.Admin'),
+    ('CMS.News.Item.Creator'),
+    ('CMS.News.Admin'),
+    ('CMS.News.Creator'),
+    ('CMS.Page.Admin'),
+    ('CMS.Page.Creator'),
+    ('CMS.Slideshow.Admin'),
+    ('CMS.Slideshow.Creator'),
+    ('CMS.Admin'),
+    ('CMS.EndboardAdmin'),
+    ('CMS.View'),
+    ('CMS.Permalink.Admin')
+    ON CONFLICT (name) DO NOTHING
+    RETURNING permission_id, name
+), permissions AS (
+    SELECT permission_id, name, true AS added FROM added
+    UNION ALL
+    SELECT permission_id, name, false AS added FROM people.permissions
+)
+INSERT INTO people.permission_implications (parent_permission_id, child_permission_id)
+SELECT parent.permission_id, child.permission_id
+FROM (VALUES
+    ('ManageMembers.Members.Admin', 'ManageMembers.Members.List'),
+    ('ManageMembers.Members.Admin', 'ManageMembers.Members.Add'),
+    ('ManageMembers.Admin', 'ManageMembers.Members.Admin'),
+    ('ManageMembers.Admin', 'ManageMembers.Permissions'),
+    ('ManageMembers.Admin', 'ManageMembers.Misc.KeyList'),
+    ('ManageMembers.Admin', 'ManageMembers.Misc.UnpaidList'),
+    ('ManageMembers.Admin', 'ManageMembers.Officers'),
+    ('ManageMembers.Admin', 'ManageMembers.Groups'),
+    ('Email.Everyone', 'Email.Access'),
+    ('Email.Everyone', 'Email.Alumni'),
+    ('Email.Everyone', 'Email.Officers'),
+    ('Calendar.Social.Admin', 'Calendar.Social.Creator'),
+    ('Calendar.Show.Admin', 'Calendar.Show.Creator'),
+    ('Calendar.Meeting.Admin', 'Calendar.Meeting.Creator'),
+    ('Calendar.Admin', 'Calendar.Social.Admin'),
+    ('Calendar.Admin', 'Calendar.Show.Admin'),
+    ('Calendar.Admin', 'Calendar.Meeting.Admin'),
+    ('CMS.News.Item.Admin', 'CMS.News.Item.Creator'),
+    ('CMS.News.Admin', 'CMS.News.Item.Admin'),
+    ('CMS.News.Admin', 'CMS.News.Creator'),
+    ('CMS.Page.Admin', 'CMS.Page.Creator'),
+    ('CMS.Slideshow.Admin', 'CMS.Slideshow.Creator'),
+    ('CMS.Admin', 'CMS.News.Admin'),
+    ('CMS.Admin', 'CMS.Page.Admin'),
+    ('CMS.Admin', 'CMS.Slideshow.Admin'),
+    ('CMS.Admin', 'CMS.EndboardAdmin'),
+    ('CMS.Admin', 'CMS.View'),
+    ('CMS.Admin', 'CMS.Permalink.Admin')
+) AS h(parent_name, child_name)
+INNER JOIN permissions parent ON parent.name = h.parent_name
+INNER JOIN permissions child ON child.name = h.child_name
+WHERE parent.added OR child.added
+ON CONFLICT DO NOTHING;
+
+-- +goose Down
+
+-- the permissions may be in use by now so they are kept
+SELECT 1;
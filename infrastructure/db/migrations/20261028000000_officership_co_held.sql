@@ -0,0 +1,11 @@
+-- +goose Up
+
+-- an officership that is held by more than one officer at once on purpose, overlapping terms of it aren't reported
+-- on the officer timeline
+ALTER TABLE people.officerships
+    ADD COLUMN IF NOT EXISTS co_held bool NOT NULL DEFAULT false;
+
+-- +goose Down
+
+ALTER TABLE people.officerships
+    DROP COLUMN IF EXISTS co_held;
@@ -1,11 +1,9 @@
 package mail
 
 import (
-	"bytes"
 	"crypto/tls"
 	"errors"
 	"fmt"
-	"html/template"
 	"log"
 	"time"
 
@@ -45,7 +43,8 @@ type (
 		Defaults   Defaults
 	}
 
-	// Mail represents an email to be sent
+	// Mail represents an email to be sent, the Text and HTML are already rendered, when both are set the HTML is
+	// sent as an alternative to the Text
 	Mail struct {
 		Subject string
 		To      string
@@ -53,8 +52,8 @@ type (
 		Bcc     []string
 		From    string
 		Error   error
-		Tpl     *template.Template
-		TplData interface{}
+		Text    string
+		HTML    string
 	}
 )
 
@@ -104,10 +103,14 @@ func (m *Mailer) CheckSendable(item Mail) error {
 		return errors.New("no To field is set")
 	}
 
+	if item.Text == "" && item.HTML == "" {
+		return errors.New("no Text or HTML is set")
+	}
+
 	return nil
 }
 
-// SendMail sends a template email
+// SendMail sends a rendered email
 func (m *Mailer) SendMail(item Mail) error {
 	err := m.CheckSendable(item)
 	if err != nil {
@@ -116,13 +119,6 @@ func (m *Mailer) SendMail(item Mail) error {
 
 	to, from, cc, bcc := m.setEmailHeader(item)
 
-	body := bytes.Buffer{}
-
-	err = item.Tpl.Execute(&body, item.TplData)
-	if err != nil {
-		return fmt.Errorf("failed to exec tpl: %w", err)
-	}
-
 	email := mail.NewMSG()
 	email.SetFrom(from).AddTo(to).SetSubject(item.Subject)
 
@@ -134,7 +130,15 @@ func (m *Mailer) SendMail(item Mail) error {
 		email.AddBcc(bcc...)
 	}
 
-	email.SetBody(mail.TextHTML, body.String())
+	if len(item.Text) > 0 {
+		email.SetBody(mail.TextPlain, item.Text)
+
+		if len(item.HTML) > 0 {
+			email.AddAlternative(mail.TextHTML, item.HTML)
+		}
+	} else {
+		email.SetBody(mail.TextHTML, item.HTML)
+	}
 
 	if email.Error != nil {
 		return fmt.Errorf("failed to set mail data: %w", email.Error)
@@ -1,97 +1,23 @@
 package permission
 
-import "github.com/ystv/web-auth/permission/permissions"
-
-// SufficientPermissionsFor takes a permission for a task and returns that permission
-// and higher permissions that would be acceptable
-//
-//gocyclo:ignore
-func SufficientPermissionsFor(p permissions.Permissions) map[string]bool {
-	m := make(map[string]bool)
-
-	m[p.String()] = true
-
-	switch p {
-	case permissions.MenuDisabled:
-		m[permissions.MenuDisabled.String()] = true
-
-		return m
-	case permissions.ManageMembersMembersList:
-		fallthrough
-	case permissions.ManageMembersMembersAdd:
-		m[permissions.ManageMembersMembersAdmin.String()] = true
-
-		fallthrough
-	case permissions.ManageMembersPermissions:
-		fallthrough
-	case permissions.ManageMembersMicsKeyList:
-		fallthrough
-	case permissions.ManageMembersMiscUnpaidList:
-		fallthrough
-	case permissions.ManageMembersOfficers:
-		fallthrough
-	case permissions.ManageMembersGroup:
-		fallthrough
-	case permissions.ManageMembersMembersAdmin:
-		m[permissions.ManageMembersAdmin.String()] = true
-	case permissions.EmailAccess:
-		fallthrough
-	case permissions.EmailAlumni:
-		fallthrough
-	case permissions.EmailOfficers:
-		m[permissions.EmailEveryone.String()] = true
-	case permissions.CalendarSocialCreator:
-		m[permissions.CalendarSocialAdmin.String()] = true
-
-		fallthrough
-	case permissions.CalendarSocialAdmin:
-		m[permissions.CalendarAdmin.String()] = true
-	case permissions.CalendarShowCreator:
-		m[permissions.CalendarShowAdmin.String()] = true
-
-		fallthrough
-	case permissions.CalendarShowAdmin:
-		m[permissions.CalendarAdmin.String()] = true
-	case permissions.CalendarMeetingCreator:
-		m[permissions.CalendarMeetingAdmin.String()] = true
-
-		fallthrough
-	case permissions.CalendarMeetingAdmin:
-		m[permissions.CalendarAdmin.String()] = true
-	case permissions.CMSNewsItemCreator:
-		m[permissions.CMSNewsItemAdmin.String()] = true
-
-		fallthrough
-	case permissions.CMSNewsItemAdmin:
-		m[permissions.CMSNewsAdmin.String()] = true
-
-		fallthrough
-	case permissions.CMSEndboardAdmin:
-		fallthrough
-	case permissions.CMSView:
-		fallthrough
-	case permissions.CMSPermalinkAdmin:
-		fallthrough
-	case permissions.CMSNewsAdmin:
-		m[permissions.CMSAdmin.String()] = true
-	case permissions.CMSNewsCreator:
-		m[permissions.CMSNewsAdmin.String()] = true
-		m[permissions.CMSAdmin.String()] = true
-	case permissions.CMSPageCreator:
-		m[permissions.CMSPageAdmin.String()] = true
-
-		fallthrough
-	case permissions.CMSPageAdmin:
-		m[permissions.CMSAdmin.String()] = true
-	case permissions.CMSSlideshowCreator:
-		m[permissions.CMSSlideshowAdmin.String()] = true
-
-		fallthrough
-	case permissions.CMSSlideshowAdmin:
-		m[permissions.CMSAdmin.String()] = true
+import (
+	"github.com/ystv/web-auth/permission"
+	"github.com/ystv/web-auth/permission/permissions"
+)
+
+// HasPermission takes the effective permissions of a user and returns if they are sufficient for a task,
+// the permissions implied by others are already included by user.GetPermissionsForUser so only SuperUser is
+// special and is sufficient for everything other than Menu.Disabled
+func HasPermission(perms []permission.Permission, p permissions.Permissions) bool {
+	for _, perm := range perms {
+		if perm.Name == p.String() {
+			return true
+		}
+
+		if perm.Name == permissions.SuperUser.String() && p != permissions.MenuDisabled {
+			return true
+		}
 	}
 
-	m[permissions.SuperUser.String()] = true
-
-	return m
+	return false
 }
@@ -0,0 +1,400 @@
+package keylist
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/ystv/web-auth/officership"
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/utils"
+)
+
+// grantBuilder selects grants with the names needed to show them on the keylist
+func grantBuilder() sq.SelectBuilder {
+	return utils.PSQL().Select("g.*", "k.name AS key_name", "k.kind AS key_kind",
+		"CONCAT(u.first_name, ' ', u.last_name) AS user_name", "u.university_username",
+		"NULLIF(CONCAT(i.first_name, ' ', i.last_name), ' ') AS issuer_name").
+		From("people.keylist_grants g").
+		InnerJoin("people.keylist_keys k ON k.key_id = g.key_id").
+		InnerJoin("people.users u ON u.user_id = g.user_id").
+		LeftJoin("people.users i ON i.user_id = g.issued_by")
+}
+
+func (s *Store) getKeys(ctx context.Context) ([]Key, error) {
+	var k []Key
+
+	builder := utils.PSQL().Select("k.*", "COUNT(g.grant_id) AS held").
+		From("people.keylist_keys k").
+		LeftJoin("people.keylist_grants g ON g.key_id = k.key_id AND g.returned_at IS NULL").
+		GroupBy("k.key_id").
+		OrderBy("k.name")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getKeys: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &k, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get keys: %w", err)
+	}
+
+	return k, nil
+}
+
+func (s *Store) getKey(ctx context.Context, k1 Key) (Key, error) {
+	var k Key
+
+	builder := utils.PSQL().Select("k.*", "COUNT(g.grant_id) AS held").
+		From("people.keylist_keys k").
+		LeftJoin("people.keylist_grants g ON g.key_id = k.key_id AND g.returned_at IS NULL").
+		Where(sq.Or{
+			sq.Eq{"k.key_id": k1.KeyID},
+			sq.And{sq.Eq{"LOWER(k.name)": strings.ToLower(k1.Name)}, sq.NotEq{"k.name": ""}},
+		}).
+		GroupBy("k.key_id").
+		Limit(1)
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getKey: %w", err))
+	}
+
+	err = s.db.GetContext(ctx, &k, sql, args...)
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to get key: %w", err)
+	}
+
+	return k, nil
+}
+
+func (s *Store) addKey(ctx context.Context, k Key) (Key, error) {
+	builder := utils.PSQL().Insert("people.keylist_keys").
+		Columns("name", "kind", "description", "location").
+		Values(k.Name, k.Kind, k.Description, k.Location).
+		Suffix("RETURNING key_id")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for addKey: %w", err))
+	}
+
+	err = s.db.QueryRowxContext(ctx, sql, args...).Scan(&k.KeyID)
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to add key: %w", err)
+	}
+
+	return s.getKey(ctx, Key{KeyID: k.KeyID})
+}
+
+func (s *Store) editKey(ctx context.Context, k Key) (Key, error) {
+	builder := utils.PSQL().Update("people.keylist_keys").
+		SetMap(map[string]interface{}{
+			"name":        k.Name,
+			"kind":        k.Kind,
+			"description": k.Description,
+			"location":    k.Location,
+		}).
+		Where(sq.Eq{"key_id": k.KeyID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for editKey: %w", err))
+	}
+
+	_, err = s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to edit key: %w", err)
+	}
+
+	return s.getKey(ctx, Key{KeyID: k.KeyID})
+}
+
+func (s *Store) deleteKey(ctx context.Context, k Key) error {
+	builder := utils.PSQL().Delete("people.keylist_keys").
+		Where(sq.Eq{"key_id": k.KeyID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for deleteKey: %w", err))
+	}
+
+	_, err = s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete key: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) getHeld(ctx context.Context) ([]Grant, error) {
+	var g []Grant
+
+	builder := grantBuilder().
+		Where(sq.Eq{"g.returned_at": nil}).
+		OrderBy("k.name", "user_name")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getHeld: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &g, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get held keys: %w", err)
+	}
+
+	return g, nil
+}
+
+func (s *Store) getGrantsForKey(ctx context.Context, k Key) ([]Grant, error) {
+	var g []Grant
+
+	builder := grantBuilder().
+		Where(sq.Eq{"g.key_id": k.KeyID}).
+		OrderBy("g.issued_at DESC")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getGrantsForKey: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &g, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get grants for key: %w", err)
+	}
+
+	return g, nil
+}
+
+func (s *Store) getHeldForUser(ctx context.Context, u user.User) ([]Grant, error) {
+	var g []Grant
+
+	builder := grantBuilder().
+		Where(sq.Eq{"g.user_id": u.UserID, "g.returned_at": nil}).
+		OrderBy("k.name")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getHeldForUser: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &g, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get held keys for user: %w", err)
+	}
+
+	return g, nil
+}
+
+func (s *Store) getGrantsForUser(ctx context.Context, u user.User) ([]Grant, error) {
+	var g []Grant
+
+	builder := grantBuilder().
+		Where(sq.Eq{"g.user_id": u.UserID}).
+		OrderBy("g.issued_at DESC")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getGrantsForUser: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &g, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get grants for user: %w", err)
+	}
+
+	return g, nil
+}
+
+func (s *Store) getGrant(ctx context.Context, g1 Grant) (Grant, error) {
+	var g Grant
+
+	builder := grantBuilder().
+		Where(sq.Eq{"g.grant_id": g1.GrantID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getGrant: %w", err))
+	}
+
+	err = s.db.GetContext(ctx, &g, sql, args...)
+	if err != nil {
+		return Grant{}, fmt.Errorf("failed to get grant: %w", err)
+	}
+
+	return g, nil
+}
+
+func (s *Store) issueGrant(ctx context.Context, g Grant) (Grant, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Grant{}, fmt.Errorf("failed to begin issue grant transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	builder := utils.PSQL().Insert("people.keylist_grants").
+		Columns("key_id", "user_id", "issued_by", "expires_at", "note").
+		Values(g.KeyID, g.UserID, g.IssuedBy, g.ExpiresAt, g.Note).
+		Suffix("RETURNING grant_id")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for issueGrant: %w", err))
+	}
+
+	err = tx.QueryRowxContext(ctx, sql, args...).Scan(&g.GrantID)
+	if err != nil {
+		return Grant{}, fmt.Errorf("failed to issue grant: %w", err)
+	}
+
+	sql, args, err = eventBuilder(Event{GrantID: g.GrantID, Event: Issued, EventBy: g.IssuedBy, Note: g.Note}).ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for issueGrant event: %w", err))
+	}
+
+	_, err = tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return Grant{}, fmt.Errorf("failed to add issue event: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Grant{}, fmt.Errorf("failed to commit issue grant: %w", err)
+	}
+
+	return s.getGrant(ctx, g)
+}
+
+// changeGrant extends or returns a grant and logs the event in one transaction
+func (s *Store) changeGrant(ctx context.Context, g Grant, e Event) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin %s grant transaction: %w", e.Event, err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	builder := utils.PSQL().Update("people.keylist_grants").
+		Where(sq.Eq{"grant_id": g.GrantID, "returned_at": nil})
+
+	switch e.Event {
+	case Extended:
+		builder = builder.Set("expires_at", g.ExpiresAt)
+	case Returned:
+		builder = builder.SetMap(map[string]interface{}{
+			"returned_at": time.Now(),
+			"returned_to": e.EventBy,
+		})
+	default:
+		return fmt.Errorf("failed to change grant: unknown event: %s", e.Event)
+	}
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for changeGrant: %w", err))
+	}
+
+	res, err := tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to change grant: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for changeGrant: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("failed to change grant: grant id %d has already been returned", g.GrantID)
+	}
+
+	e.GrantID = g.GrantID
+
+	sql, args, err = eventBuilder(e).ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for changeGrant event: %w", err))
+	}
+
+	_, err = tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to add %s event: %w", e.Event, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit %s grant: %w", e.Event, err)
+	}
+
+	return nil
+}
+
+func eventBuilder(e Event) sq.InsertBuilder {
+	return utils.PSQL().Insert("people.keylist_events").
+		Columns("grant_id", "event", "event_by", "note").
+		Values(e.GrantID, e.Event, e.EventBy, e.Note)
+}
+
+func (s *Store) getEvents(ctx context.Context, k Key) ([]Event, error) {
+	var e []Event
+
+	builder := utils.PSQL().Select("e.*", "CONCAT(u.first_name, ' ', u.last_name) AS user_name",
+		"NULLIF(CONCAT(b.first_name, ' ', b.last_name), ' ') AS by_name").
+		From("people.keylist_events e").
+		InnerJoin("people.keylist_grants g ON g.grant_id = e.grant_id").
+		InnerJoin("people.users u ON u.user_id = g.user_id").
+		LeftJoin("people.users b ON b.user_id = e.event_by").
+		Where(sq.Eq{"g.key_id": k.KeyID}).
+		OrderBy("e.event_at DESC", "e.event_id DESC")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getEvents: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &e, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get key events: %w", err)
+	}
+
+	return e, nil
+}
+
+func (s *Store) getLapsed(ctx context.Context) ([]Grant, error) {
+	var g []Grant
+
+	builder := grantBuilder().
+		Where(sq.Eq{"g.returned_at": nil}).
+		Where(sq.Or{
+			sq.Expr("u.deleted_at IS NOT NULL"),
+			sq.Expr("NOT u.enabled"),
+			sq.And{
+				sq.Expr(`NOT EXISTS (SELECT 1 FROM people.memberships m
+					WHERE m.user_id = g.user_id AND m.paid_at IS NOT NULL AND m.paid_until > NOW())`),
+				sq.Expr(`NOT EXISTS (SELECT 1 FROM people.officership_members om
+					WHERE om.user_id = g.user_id AND ` + officership.CurrentOfficerWhere + `)`),
+			},
+		}).
+		OrderBy("user_name", "k.name")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getLapsed: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &g, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lapsed keyholders: %w", err)
+	}
+
+	return g, nil
+}
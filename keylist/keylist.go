@@ -0,0 +1,184 @@
+package keylist
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/user"
+)
+
+//go:generate mockgen -destination mocks/mock_keylist.go -package mock_keylist github.com/ystv/web-auth/keylist Repo
+
+type (
+	Repo interface {
+		GetKeys(context.Context) ([]Key, error)
+		GetKey(context.Context, Key) (Key, error)
+		AddKey(context.Context, Key) (Key, error)
+		EditKey(context.Context, Key) (Key, error)
+		DeleteKey(context.Context, Key) error
+		GetHeld(context.Context) ([]Grant, error)
+		GetGrantsForKey(context.Context, Key) ([]Grant, error)
+		GetHeldForUser(context.Context, user.User) ([]Grant, error)
+		GetGrantsForUser(context.Context, user.User) ([]Grant, error)
+		GetGrant(context.Context, Grant) (Grant, error)
+		IssueGrant(context.Context, Grant) (Grant, error)
+		ExtendGrant(context.Context, Grant, Event) error
+		ReturnGrant(context.Context, Grant, Event) error
+		GetEvents(context.Context, Key) ([]Event, error)
+		GetLapsed(context.Context) ([]Grant, error)
+	}
+
+	// Store stores the dependencies
+	Store struct {
+		db *sqlx.DB
+	}
+
+	// Key is a physical key or card access that can be given to users, Held is how many are given out
+	Key struct {
+		KeyID       int       `db:"key_id" json:"keyID"`
+		Name        string    `db:"name" json:"name"`
+		Kind        Kind      `db:"kind" json:"kind"`
+		Description string    `db:"description" json:"description"`
+		Location    string    `db:"location" json:"location"`
+		CreatedAt   time.Time `db:"created_at" json:"createdAt"`
+		Held        int       `db:"held" json:"held"`
+	}
+
+	// Grant is a user holding a key, it is held until it is returned
+	Grant struct {
+		GrantID            int         `db:"grant_id" json:"grantID"`
+		KeyID              int         `db:"key_id" json:"keyID"`
+		UserID             int         `db:"user_id" json:"userID"`
+		IssuedAt           time.Time   `db:"issued_at" json:"issuedAt"`
+		IssuedBy           null.Int    `db:"issued_by" json:"issuedBy"`
+		ExpiresAt          null.Time   `db:"expires_at" json:"expiresAt"`
+		ReturnedAt         null.Time   `db:"returned_at" json:"returnedAt"`
+		ReturnedTo         null.Int    `db:"returned_to" json:"returnedTo"`
+		Note               string      `db:"note" json:"note"`
+		KeyName            string      `db:"key_name" json:"keyName"`
+		KeyKind            Kind        `db:"key_kind" json:"keyKind"`
+		UserName           string      `db:"user_name" json:"userName"`
+		UniversityUsername string      `db:"university_username" json:"universityUsername"`
+		IssuerName         null.String `db:"issuer_name" json:"issuerName"`
+	}
+
+	// Event is a key being issued, extended or returned
+	Event struct {
+		EventID  int         `db:"event_id" json:"eventID"`
+		GrantID  int         `db:"grant_id" json:"grantID"`
+		Event    EventType   `db:"event" json:"event"`
+		EventAt  time.Time   `db:"event_at" json:"eventAt"`
+		EventBy  null.Int    `db:"event_by" json:"eventBy"`
+		Note     string      `db:"note" json:"note"`
+		UserName string      `db:"user_name" json:"userName"`
+		ByName   null.String `db:"by_name" json:"byName"`
+	}
+
+	// Kind is whether a key is a physical key or card access
+	Kind string
+
+	// EventType is what happened to a grant
+	EventType string
+)
+
+const (
+	PhysicalKey Kind = "key"
+	CardAccess  Kind = "card"
+
+	Issued   EventType = "issued"
+	Extended EventType = "extended"
+	Returned EventType = "returned"
+)
+
+var _ Repo = &Store{}
+
+// NewKeylistRepo stores our dependency
+func NewKeylistRepo(db *sqlx.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+// GetKeys returns the keys with how many of each are held
+func (s *Store) GetKeys(ctx context.Context) ([]Key, error) {
+	return s.getKeys(ctx)
+}
+
+// GetKey returns a key by id or name
+func (s *Store) GetKey(ctx context.Context, k Key) (Key, error) {
+	return s.getKey(ctx, k)
+}
+
+// AddKey adds a key
+func (s *Store) AddKey(ctx context.Context, k Key) (Key, error) {
+	return s.addKey(ctx, k)
+}
+
+// EditKey edits a key
+func (s *Store) EditKey(ctx context.Context, k Key) (Key, error) {
+	return s.editKey(ctx, k)
+}
+
+// DeleteKey deletes a key, it fails once the key has been issued so the history is kept
+func (s *Store) DeleteKey(ctx context.Context, k Key) error {
+	return s.deleteKey(ctx, k)
+}
+
+// GetHeld returns the keylist, every grant that hasn't been returned in key then holder order
+func (s *Store) GetHeld(ctx context.Context) ([]Grant, error) {
+	return s.getHeld(ctx)
+}
+
+// GetGrantsForKey returns every grant of a key, newest first
+func (s *Store) GetGrantsForKey(ctx context.Context, k Key) ([]Grant, error) {
+	return s.getGrantsForKey(ctx, k)
+}
+
+// GetHeldForUser returns the keys a user holds
+func (s *Store) GetHeldForUser(ctx context.Context, u user.User) ([]Grant, error) {
+	return s.getHeldForUser(ctx, u)
+}
+
+// GetGrantsForUser returns every key a user has held, newest first
+func (s *Store) GetGrantsForUser(ctx context.Context, u user.User) ([]Grant, error) {
+	return s.getGrantsForUser(ctx, u)
+}
+
+// GetGrant returns a grant
+func (s *Store) GetGrant(ctx context.Context, g Grant) (Grant, error) {
+	return s.getGrant(ctx, g)
+}
+
+// IssueGrant gives a key to a user and logs it, a user can only hold one of each key at a time
+func (s *Store) IssueGrant(ctx context.Context, g Grant) (Grant, error) {
+	return s.issueGrant(ctx, g)
+}
+
+// ExtendGrant changes when a held key should be returned by and logs it
+func (s *Store) ExtendGrant(ctx context.Context, g Grant, e Event) error {
+	return s.changeGrant(ctx, g, e)
+}
+
+// ReturnGrant records a key being returned and logs it
+func (s *Store) ReturnGrant(ctx context.Context, g Grant, e Event) error {
+	return s.changeGrant(ctx, g, e)
+}
+
+// GetEvents returns the log of a key, newest first
+func (s *Store) GetEvents(ctx context.Context, k Key) ([]Event, error) {
+	return s.getEvents(ctx, k)
+}
+
+// GetLapsed returns the held keys whose holder is no longer a paid member or an officer, or whose account has been
+// disabled or deleted
+func (s *Store) GetLapsed(ctx context.Context) ([]Grant, error) {
+	return s.getLapsed(ctx)
+}
+
+// IsOverdue returns if the key is still held after it should have been returned
+func (g Grant) IsOverdue() bool {
+	return !g.ReturnedAt.Valid && g.ExpiresAt.Valid && g.ExpiresAt.Time.Before(time.Now())
+}
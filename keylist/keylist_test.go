@@ -0,0 +1,31 @@
+package keylist
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/guregu/null.v4"
+)
+
+func TestIsOverdue(t *testing.T) {
+	past := null.TimeFrom(time.Now().Add(-time.Hour))
+	future := null.TimeFrom(time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name  string
+		grant Grant
+		want  bool
+	}{
+		{name: "Overdue", grant: Grant{ExpiresAt: past}, want: true},
+		{name: "NotDue", grant: Grant{ExpiresAt: future}, want: false},
+		{name: "NoDate", grant: Grant{}, want: false},
+		{name: "Returned", grant: Grant{ExpiresAt: past, ReturnedAt: null.TimeFrom(time.Now())}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.grant.IsOverdue())
+		})
+	}
+}
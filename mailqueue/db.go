@@ -0,0 +1,226 @@
+package mailqueue
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+
+	"github.com/ystv/web-auth/utils"
+)
+
+// getMessages returns the most recent messages, all of them if the status isn't set
+func (s *Store) getMessages(ctx context.Context, status Status, limit int) ([]Message, error) {
+	var m []Message
+
+	builder := utils.PSQL().Select("*").
+		From("web_auth.mail_queue").
+		OrderBy("created_at DESC", "message_id DESC").
+		Limit(uint64(limit))
+
+	if len(status) > 0 {
+		builder = builder.Where(sq.Eq{"status": status})
+	}
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getMessages: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &m, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+
+	return m, nil
+}
+
+// getMessagesForAddresses returns the messages to, cc'd or bcc'd to any of the addresses, ignoring case
+func (s *Store) getMessagesForAddresses(ctx context.Context, addresses []string) ([]Message, error) {
+	var m []Message
+
+	lower := make(pq.StringArray, 0, len(addresses))
+	for _, address := range addresses {
+		lower = append(lower, strings.ToLower(address))
+	}
+
+	builder := utils.PSQL().Select("*").
+		From("web_auth.mail_queue").
+		Where(sq.Or{
+			sq.Expr("LOWER(to_address) = ANY(?)", lower),
+			sq.Expr("EXISTS (SELECT 1 FROM UNNEST(cc_addresses || bcc_addresses) a WHERE LOWER(a) = ANY(?))", lower),
+		}).
+		OrderBy("created_at DESC", "message_id DESC")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getMessagesForAddresses: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &m, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages for addresses: %w", err)
+	}
+
+	return m, nil
+}
+
+// getMessage returns a specific message
+func (s *Store) getMessage(ctx context.Context, m1 Message) (Message, error) {
+	var m Message
+
+	builder := utils.PSQL().Select("*").
+		From("web_auth.mail_queue").
+		Where(sq.Eq{"message_id": m1.MessageID}).
+		Limit(1)
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getMessage: %w", err))
+	}
+
+	err = s.db.GetContext(ctx, &m, sql, args...)
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	return m, nil
+}
+
+// getCounts returns the number of messages in each status
+func (s *Store) getCounts(ctx context.Context) (Counts, error) {
+	var c Counts
+
+	builder := utils.PSQL().Select(
+		"COUNT(*) FILTER (WHERE status = 'queued') AS queued",
+		"COUNT(*) FILTER (WHERE status = 'sent') AS sent",
+		"COUNT(*) FILTER (WHERE status = 'failed') AS failed").
+		From("web_auth.mail_queue")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getCounts: %w", err))
+	}
+
+	err = s.db.GetContext(ctx, &c, sql, args...)
+	if err != nil {
+		return Counts{}, fmt.Errorf("failed to get message counts: %w", err)
+	}
+
+	return c, nil
+}
+
+// addMessage adds a new message to the queue
+func (s *Store) addMessage(ctx context.Context, m Message) (Message, error) {
+	builder := utils.PSQL().Insert("web_auth.mail_queue").
+		Columns("subject", "to_address", "cc_addresses", "bcc_addresses", "from_address", "text_body",
+			"html_body").
+		Values(m.Subject, m.To, m.Cc, m.Bcc, m.From, m.Text, m.HTML).
+		Suffix("RETURNING message_id, status, attempts, next_attempt_at, created_at")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for addMessage: %w", err))
+	}
+
+	stmt, err := s.db.PrepareContext(ctx, sql)
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to add message: %w", err)
+	}
+
+	defer stmt.Close()
+
+	err = stmt.QueryRow(args...).Scan(&m.MessageID, &m.Status, &m.Attempts, &m.NextAttemptAt, &m.CreatedAt)
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to add message: %w", err)
+	}
+
+	return m, nil
+}
+
+// claimDueMessages returns the queued messages that are ready to be sent, moving their next attempt forward in the
+// same statement so a second worker, like the import-users command, skips them rather than sending them twice
+func (s *Store) claimDueMessages(ctx context.Context) ([]Message, error) {
+	var m []Message
+
+	builder := utils.PSQL().Update("web_auth.mail_queue").
+		Set("next_attempt_at", time.Now().Add(claimFor)).
+		Where(sq.Expr(`message_id IN (SELECT message_id FROM web_auth.mail_queue
+			WHERE status = ? AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED)`, Queued, batchSize)).
+		Suffix("RETURNING *")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for claimDueMessages: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &m, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due messages: %w", err)
+	}
+
+	return m, nil
+}
+
+// editMessage updates the result of a send attempt
+func (s *Store) editMessage(ctx context.Context, m Message) error {
+	builder := utils.PSQL().Update("web_auth.mail_queue").
+		SetMap(map[string]interface{}{
+			"status":          m.Status,
+			"attempts":        m.Attempts,
+			"next_attempt_at": m.NextAttemptAt,
+			"last_attempt_at": m.LastAttemptAt,
+			"error":           m.Error,
+			"sent_at":         m.SentAt,
+		}).
+		Where(sq.Eq{"message_id": m.MessageID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for editMessage: %w", err))
+	}
+
+	res, err := s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to edit message: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to edit message: %w", err)
+	}
+
+	if rows < 1 {
+		return fmt.Errorf("failed to edit message: invalid rows affected: %d, this message may not exist: %d",
+			rows, m.MessageID)
+	}
+
+	return nil
+}
+
+// deleteOldMessages deletes the sent and failed messages created before the time, queued messages are kept
+func (s *Store) deleteOldMessages(ctx context.Context, before time.Time) error {
+	builder := utils.PSQL().Delete("web_auth.mail_queue").
+		Where(sq.And{
+			sq.NotEq{"status": Queued},
+			sq.Lt{"created_at": before},
+		})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for deleteOldMessages: %w", err))
+	}
+
+	_, err = s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete old messages: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+package mailqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/infrastructure/mail"
+)
+
+//go:generate mockgen -destination mocks/mock_mailqueue.go -package mock_mailqueue github.com/ystv/web-auth/mailqueue Repo
+
+type (
+	Repo interface {
+		GetMessages(context.Context, Status, int) ([]Message, error)
+		GetMessage(context.Context, Message) (Message, error)
+		GetMessagesForAddresses(context.Context, []string) ([]Message, error)
+		GetCounts(context.Context) (Counts, error)
+		Queue(context.Context, mail.Mail) (Message, error)
+		Resend(context.Context, Message) (Message, error)
+		SendPending(context.Context) error
+		DeleteOldMessages(context.Context) error
+	}
+
+	// Store stores the dependencies
+	Store struct {
+		db     *sqlx.DB
+		mailer *mail.MailerInit
+	}
+
+	// Message is an email in the queue, it is kept after it is sent so it can be seen and resent
+	Message struct {
+		MessageID     int            `db:"message_id" json:"messageID"`
+		Subject       string         `db:"subject" json:"subject"`
+		To            string         `db:"to_address" json:"to"`
+		Cc            pq.StringArray `db:"cc_addresses" json:"cc"`
+		Bcc           pq.StringArray `db:"bcc_addresses" json:"bcc"`
+		From          string         `db:"from_address" json:"from"`
+		Text          string         `db:"text_body" json:"-"`
+		HTML          string         `db:"html_body" json:"-"`
+		Status        Status         `db:"status" json:"status"`
+		Attempts      int            `db:"attempts" json:"attempts"`
+		NextAttemptAt time.Time      `db:"next_attempt_at" json:"nextAttemptAt"`
+		LastAttemptAt null.Time      `db:"last_attempt_at" json:"lastAttemptAt"`
+		Error         null.String    `db:"error" json:"error"`
+		CreatedAt     time.Time      `db:"created_at" json:"createdAt"`
+		SentAt        null.Time      `db:"sent_at" json:"sentAt"`
+	}
+
+	// Counts is the number of messages in each Status
+	Counts struct {
+		Queued int `db:"queued" json:"queued"`
+		Sent   int `db:"sent" json:"sent"`
+		Failed int `db:"failed" json:"failed"`
+	}
+
+	// Status is the state of a Message in the queue
+	Status string
+)
+
+const (
+	Queued Status = "queued"
+	Sent   Status = "sent"
+	Failed Status = "failed"
+)
+
+const (
+	// maxAttempts is the number of attempts before a message is marked as failed, this is about a day of retrying
+	maxAttempts = 10
+	// batchSize is the number of messages claimed by each run of SendPending
+	batchSize = 50
+	// claimFor is how long a claimed message is left alone by other workers, long enough to send the whole batch
+	claimFor = 10 * time.Minute
+	// retention is how long a message is kept once it is sent or has failed, they contain reset links and passwords
+	retention = 30 * 24 * time.Hour
+)
+
+// Statuses is the list of statuses, used for the admin UI
+//
+//nolint:gochecknoglobals
+var Statuses = []Status{Queued, Failed, Sent}
+
+var _ Repo = &Store{}
+
+// NewMailQueueRepo stores our dependency
+func NewMailQueueRepo(db *sqlx.DB, mailer *mail.MailerInit) *Store {
+	return &Store{
+		db:     db,
+		mailer: mailer,
+	}
+}
+
+// String returns the string equivalent of Status
+func (s Status) String() string {
+	return string(s)
+}
+
+// GetMessages returns the most recent messages, with the status if it is set
+func (s *Store) GetMessages(ctx context.Context, status Status, limit int) ([]Message, error) {
+	return s.getMessages(ctx, status, limit)
+}
+
+// GetMessage returns a message
+func (s *Store) GetMessage(ctx context.Context, m Message) (Message, error) {
+	return s.getMessage(ctx, m)
+}
+
+// GetMessagesForAddresses returns every message sent to any of the addresses, newest first
+func (s *Store) GetMessagesForAddresses(ctx context.Context, addresses []string) ([]Message, error) {
+	return s.getMessagesForAddresses(ctx, addresses)
+}
+
+// GetCounts returns the number of messages in each status
+func (s *Store) GetCounts(ctx context.Context) (Counts, error) {
+	return s.getCounts(ctx)
+}
+
+// Queue adds an email to the queue, it is sent by SendPending
+func (s *Store) Queue(ctx context.Context, item mail.Mail) (Message, error) {
+	if len(item.To) == 0 {
+		return Message{}, errors.New("failed to queue email: no To field is set")
+	}
+
+	if len(item.Text) == 0 && len(item.HTML) == 0 {
+		return Message{}, errors.New("failed to queue email: no Text or HTML is set")
+	}
+
+	return s.addMessage(ctx, Message{
+		Subject: item.Subject,
+		To:      item.To,
+		Cc:      item.Cc,
+		Bcc:     item.Bcc,
+		From:    item.From,
+		Text:    item.Text,
+		HTML:    item.HTML,
+	})
+}
+
+// Resend queues a new message with the same email as an existing message
+func (s *Store) Resend(ctx context.Context, m Message) (Message, error) {
+	message, err := s.getMessage(ctx, m)
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to get message for resend: %w", err)
+	}
+
+	return s.Queue(ctx, message.Mail())
+}
+
+// DeleteOldMessages deletes the messages that were sent or failed longer ago than the retention
+func (s *Store) DeleteOldMessages(ctx context.Context) error {
+	return s.deleteOldMessages(ctx, time.Now().Add(-retention))
+}
+
+// Mail returns the message as an email to be sent
+func (m Message) Mail() mail.Mail {
+	return mail.Mail{
+		Subject: m.Subject,
+		To:      m.To,
+		Cc:      m.Cc,
+		Bcc:     m.Bcc,
+		From:    m.From,
+		Text:    m.Text,
+		HTML:    m.HTML,
+	}
+}
+
+// backOff returns the time to wait before the next attempt, doubling each attempt starting at a minute
+func backOff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	if attempts > maxAttempts {
+		attempts = maxAttempts
+	}
+
+	return time.Duration(1<<(attempts-1)) * time.Minute
+}
@@ -0,0 +1,77 @@
+package mailqueue
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ystv/web-auth/infrastructure/mail"
+)
+
+func TestBackOff(t *testing.T) {
+	assert.Equal(t, time.Minute, backOff(1))
+	assert.Equal(t, 2*time.Minute, backOff(2))
+	assert.Equal(t, 16*time.Minute, backOff(5))
+	assert.Equal(t, backOff(maxAttempts), backOff(maxAttempts+5))
+}
+
+func TestAttempt(t *testing.T) {
+	m := Message{
+		MessageID: 1,
+		Subject:   "Subject",
+		To:        "jane.doe@ystv.co.uk",
+		Text:      "Text",
+		Status:    Queued,
+	}
+
+	t.Run("Sent", func(t *testing.T) {
+		var sent mail.Mail
+
+		m1 := attempt(m, func(item mail.Mail) error {
+			sent = item
+
+			return nil
+		})
+
+		assert.Equal(t, Sent, m1.Status)
+		assert.Equal(t, 1, m1.Attempts)
+		assert.True(t, m1.SentAt.Valid)
+		assert.False(t, m1.Error.Valid)
+		assert.Equal(t, m.Mail(), sent)
+	})
+
+	failing := func(mail.Mail) error {
+		return errors.New("connection refused")
+	}
+
+	t.Run("Retried", func(t *testing.T) {
+		m1 := attempt(m, failing)
+
+		assert.Equal(t, Queued, m1.Status)
+		assert.Equal(t, "connection refused", m1.Error.String)
+		assert.False(t, m1.SentAt.Valid)
+		assert.WithinDuration(t, time.Now().Add(backOff(1)), m1.NextAttemptAt, time.Second)
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		m1 := m
+		m1.Attempts = maxAttempts - 1
+
+		m1 = attempt(m1, failing)
+
+		assert.Equal(t, Failed, m1.Status)
+		assert.Equal(t, maxAttempts, m1.Attempts)
+	})
+
+	t.Run("SentAfterFailing", func(t *testing.T) {
+		m1 := attempt(attempt(m, failing), func(mail.Mail) error {
+			return nil
+		})
+
+		assert.Equal(t, Sent, m1.Status)
+		assert.Equal(t, 2, m1.Attempts)
+		assert.False(t, m1.Error.Valid)
+	})
+}
@@ -0,0 +1,77 @@
+package mailqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/infrastructure/mail"
+)
+
+// SendPending sends every queued message that is due, rescheduling failures with back-off
+func (s *Store) SendPending(ctx context.Context) error {
+	messages, err := s.claimDueMessages(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to claim due messages for sendPending: %w", err)
+	}
+
+	if len(messages) == 0 {
+		return nil
+	}
+
+	// a mail server that can't be connected to fails every attempt so they are retried once it is back
+	send := func(mail.Mail) error {
+		return errors.New("failed to connect to the mail server")
+	}
+
+	mailer := s.mailer.ConnectMailer()
+	if mailer != nil {
+		defer func() {
+			_ = mailer.Close()
+		}()
+
+		send = mailer.SendMail
+	}
+
+	for _, m := range messages {
+		m = attempt(m, send)
+
+		err = s.editMessage(ctx, m)
+		if err != nil {
+			return fmt.Errorf("failed to edit message for sendPending: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// attempt sends the message and records the outcome on it
+func attempt(m Message, send func(mail.Mail) error) Message {
+	m.Attempts++
+	m.LastAttemptAt = null.TimeFrom(time.Now())
+	m.Error = null.String{}
+
+	err := send(m.Mail())
+	if err == nil {
+		m.Status = Sent
+		m.SentAt = m.LastAttemptAt
+
+		return m
+	}
+
+	m.Error = null.StringFrom(err.Error())
+
+	if m.Attempts >= maxAttempts {
+		m.Status = Failed
+
+		return m
+	}
+
+	m.Status = Queued
+	m.NextAttemptAt = time.Now().Add(backOff(m.Attempts))
+
+	return m
+}
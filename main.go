@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/pkg/errors"
@@ -12,12 +13,22 @@ import (
 	"github.com/rs/zerolog/pkgerrors"
 	_ "golang.org/x/crypto/x509roots/fallback" // CA bundle for FROM Scratch
 
+	"github.com/ystv/web-auth/passwordpolicy"
 	"github.com/ystv/web-auth/utils"
 	"github.com/ystv/web-auth/views"
 )
 
 //go:generate ./node_modules/.bin/mjml -r ./templates/mjml/forgotEmail.mjml -o ./templates/forgotEmail.tmpl
 //go:generate ./node_modules/.bin/mjml -r ./templates/mjml/resetEmail.mjml -o ./templates/resetEmail.tmpl
+//go:generate ./node_modules/.bin/mjml -r ./templates/mjml/officerHandoverEmail.mjml -o ./templates/officerHandoverEmail.tmpl
+//go:generate ./node_modules/.bin/mjml -r ./templates/mjml/roleExpiryEmail.mjml -o ./templates/roleExpiryEmail.tmpl
+//go:generate ./node_modules/.bin/mjml -r ./templates/mjml/accessRequestEmail.mjml -o ./templates/accessRequestEmail.tmpl
+//go:generate ./node_modules/.bin/mjml -r ./templates/mjml/accessDecisionEmail.mjml -o ./templates/accessDecisionEmail.tmpl
+//go:generate ./node_modules/.bin/mjml -r ./templates/mjml/accessReviewEmail.mjml -o ./templates/accessReviewEmail.tmpl
+//go:generate ./node_modules/.bin/mjml -r ./templates/mjml/membershipExpiryEmail.mjml -o ./templates/membershipExpiryEmail.tmpl
+//go:generate ./node_modules/.bin/mjml -r ./templates/mjml/emailVerifyEmail.mjml -o ./templates/emailVerifyEmail.tmpl
+//go:generate ./node_modules/.bin/mjml -r ./templates/mjml/emailChangedEmail.mjml -o ./templates/emailChangedEmail.tmpl
+//go:generate ./node_modules/.bin/mjml -r ./templates/mjml/emailAddressEmail.mjml -o ./templates/emailAddressEmail.tmpl
 
 var (
 	Version = "unknown"
@@ -117,19 +128,65 @@ func main() {
 	}
 	logger.Debug(nil, "connected to cdn: %s", cdnConfig.Endpoint)
 
+	// deleted users are anonymised after this many days
+	retentionDays, err := strconv.Atoi(os.Getenv("WAUTH_DELETED_USER_RETENTION_DAYS"))
+	if err != nil || retentionDays < 1 {
+		retentionDays = 90
+	}
+
+	// new passwords are checked against the policy, a length, score or history of 0 turns that check off
+	passwordMinLength, err := strconv.Atoi(os.Getenv("WAUTH_PASSWORD_MIN_LENGTH"))
+	if err != nil || passwordMinLength < 0 {
+		passwordMinLength = 10
+	}
+
+	passwordMinScore, err := strconv.Atoi(os.Getenv("WAUTH_PASSWORD_MIN_SCORE"))
+	if err != nil || passwordMinScore < 0 || passwordMinScore > 4 {
+		passwordMinScore = 3
+	}
+
+	passwordHistory, err := strconv.Atoi(os.Getenv("WAUTH_PASSWORD_HISTORY"))
+	if err != nil || passwordHistory < 0 {
+		passwordHistory = 5
+	}
+
+	passwordAllowPersonal, _ := strconv.ParseBool(os.Getenv("WAUTH_PASSWORD_ALLOW_PERSONAL"))
+
+	// passwords of roles with a maximum age are warned about this many days before they expire and can still be
+	// logged in with for this many days after
+	passwordExpiryWarningDays, err := strconv.Atoi(os.Getenv("WAUTH_PASSWORD_EXPIRY_WARNING_DAYS"))
+	if err != nil || passwordExpiryWarningDays < 0 {
+		passwordExpiryWarningDays = 14
+	}
+
+	passwordExpiryGraceDays, err := strconv.Atoi(os.Getenv("WAUTH_PASSWORD_EXPIRY_GRACE_DAYS"))
+	if err != nil || passwordExpiryGraceDays < 0 {
+		passwordExpiryGraceDays = 7
+	}
+
 	// Generate config
 	conf := &views.Config{
-		Version:           Version,
-		Commit:            Commit,
-		Debug:             debug,
-		Address:           address,
-		DatabaseURL:       dbConnectionString,
-		BaseDomainName:    os.Getenv("WAUTH_BASE_DOMAIN_NAME"),
-		DomainName:        domainName,
-		LogoutEndpoint:    os.Getenv("WAUTH_LOGOUT_ENDPOINT"),
-		JWTCookieName:     jwtCookieName,
-		SessionCookieName: sessionCookieName,
-		CDNEndpoint:       os.Getenv("WAUTH_CDN_ENDPOINT"),
+		Version:              Version,
+		Commit:               Commit,
+		Debug:                debug,
+		Address:              address,
+		DatabaseURL:          dbConnectionString,
+		BaseDomainName:       os.Getenv("WAUTH_BASE_DOMAIN_NAME"),
+		DomainName:           domainName,
+		LogoutEndpoint:       os.Getenv("WAUTH_LOGOUT_ENDPOINT"),
+		JWTCookieName:        jwtCookieName,
+		SessionCookieName:    sessionCookieName,
+		CDNEndpoint:          os.Getenv("WAUTH_CDN_ENDPOINT"),
+		DeletedUserRetention: time.Duration(retentionDays) * 24 * time.Hour,
+		PasswordPolicy: passwordpolicy.Policy{
+			MinLength:     passwordMinLength,
+			MinScore:      passwordMinScore,
+			AllowPersonal: passwordAllowPersonal,
+			History:       passwordHistory,
+			BreachedFile:  os.Getenv("WAUTH_PASSWORD_BREACHED_FILE"),
+			ExpiryWarning: time.Duration(passwordExpiryWarningDays) * 24 * time.Hour,
+			ExpiryGrace:   time.Duration(passwordExpiryGraceDays) * 24 * time.Hour,
+		},
 		Mail: views.SMTPConfig{
 			Host:       os.Getenv("WAUTH_MAIL_HOST"),
 			Username:   os.Getenv("WAUTH_MAIL_USER"),
@@ -0,0 +1,492 @@
+package membership
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/utils"
+	"github.com/ystv/web-auth/webhook"
+)
+
+// currentWhere matches the paid memberships of alias m that haven't run out
+const currentWhere = "(m.paid_at IS NOT NULL AND m.paid_until > NOW())"
+
+// membershipBuilder selects memberships with the names needed to show them
+func membershipBuilder() sq.SelectBuilder {
+	return utils.PSQL().Select("m.*", "t.name AS type_name",
+		"CONCAT(u.first_name, ' ', u.last_name) AS user_name", "u.first_name", "u.email", "u.language").
+		From("people.memberships m").
+		InnerJoin("people.membership_types t ON t.type_id = m.type_id").
+		InnerJoin("people.users u ON u.user_id = m.user_id")
+}
+
+func (s *Store) getTypes(ctx context.Context) ([]Type, error) {
+	var t []Type
+
+	builder := utils.PSQL().Select("t.*", "r.name AS role_name",
+		"COUNT(DISTINCT m.user_id) FILTER (WHERE "+currentWhere+") AS current_members").
+		From("people.membership_types t").
+		LeftJoin("people.roles r ON r.role_id = t.role_id").
+		LeftJoin("people.memberships m ON m.type_id = t.type_id").
+		GroupBy("t.type_id", "r.name").
+		OrderBy("t.name")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getTypes: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &t, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get membership types: %w", err)
+	}
+
+	return t, nil
+}
+
+func (s *Store) getType(ctx context.Context, t1 Type) (Type, error) {
+	var t Type
+
+	builder := utils.PSQL().Select("t.*", "r.name AS role_name").
+		From("people.membership_types t").
+		LeftJoin("people.roles r ON r.role_id = t.role_id").
+		Where(sq.Or{
+			sq.Eq{"t.type_id": t1.TypeID},
+			sq.And{sq.Eq{"LOWER(t.name)": strings.ToLower(t1.Name)}, sq.NotEq{"t.name": ""}},
+		}).
+		Limit(1)
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getType: %w", err))
+	}
+
+	err = s.db.GetContext(ctx, &t, sql, args...)
+	if err != nil {
+		return Type{}, fmt.Errorf("failed to get membership type: %w", err)
+	}
+
+	return t, nil
+}
+
+func (s *Store) addType(ctx context.Context, t Type) (Type, error) {
+	builder := utils.PSQL().Insert("people.membership_types").
+		Columns("name", "description", "role_id").
+		Values(t.Name, t.Description, t.RoleID).
+		Suffix("RETURNING type_id")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for addType: %w", err))
+	}
+
+	err = s.db.QueryRowxContext(ctx, sql, args...).Scan(&t.TypeID)
+	if err != nil {
+		return Type{}, fmt.Errorf("failed to add membership type: %w", err)
+	}
+
+	return s.getType(ctx, Type{TypeID: t.TypeID})
+}
+
+func (s *Store) editType(ctx context.Context, t Type) (Type, error) {
+	builder := utils.PSQL().Update("people.membership_types").
+		SetMap(map[string]interface{}{
+			"name":        t.Name,
+			"description": t.Description,
+			"role_id":     t.RoleID,
+		}).
+		Where(sq.Eq{"type_id": t.TypeID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for editType: %w", err))
+	}
+
+	_, err = s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return Type{}, fmt.Errorf("failed to edit membership type: %w", err)
+	}
+
+	return s.getType(ctx, Type{TypeID: t.TypeID})
+}
+
+func (s *Store) deleteType(ctx context.Context, t Type) error {
+	builder := utils.PSQL().Delete("people.membership_types").
+		Where(sq.Eq{"type_id": t.TypeID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for deleteType: %w", err))
+	}
+
+	_, err = s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete membership type: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) getAcademicYears(ctx context.Context) ([]string, error) {
+	var years []string
+
+	builder := utils.PSQL().Select("academic_year").
+		Distinct().
+		From("people.memberships").
+		OrderBy("academic_year DESC")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getAcademicYears: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &years, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get academic years: %w", err)
+	}
+
+	return years, nil
+}
+
+func (s *Store) getMemberships(ctx context.Context, academicYear string) ([]Membership, error) {
+	var m []Membership
+
+	builder := membershipBuilder().
+		Where(sq.Eq{"m.academic_year": academicYear}).
+		OrderBy("user_name", "t.name")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getMemberships: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &m, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get memberships: %w", err)
+	}
+
+	return m, nil
+}
+
+func (s *Store) getMembershipsForUser(ctx context.Context, u user.User) ([]Membership, error) {
+	var m []Membership
+
+	builder := membershipBuilder().
+		Where(sq.Eq{"m.user_id": u.UserID}).
+		OrderBy("m.paid_until DESC")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getMembershipsForUser: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &m, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get memberships for user: %w", err)
+	}
+
+	return m, nil
+}
+
+func (s *Store) getMembership(ctx context.Context, m1 Membership) (Membership, error) {
+	var m Membership
+
+	builder := membershipBuilder().
+		Where(sq.Eq{"m.membership_id": m1.MembershipID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getMembership: %w", err))
+	}
+
+	err = s.db.GetContext(ctx, &m, sql, args...)
+	if err != nil {
+		return Membership{}, fmt.Errorf("failed to get membership: %w", err)
+	}
+
+	return m, nil
+}
+
+func (s *Store) addMembership(ctx context.Context, m Membership) (Membership, error) {
+	builder := utils.PSQL().Insert("people.memberships").
+		Columns("user_id", "type_id", "academic_year", "paid_at", "paid_until", "amount_pence", "source",
+			"reference", "created_by").
+		Values(m.UserID, m.TypeID, m.AcademicYear, m.PaidAt, m.PaidUntil, m.AmountPence, m.Source, m.Reference,
+			m.CreatedBy).
+		Suffix(`ON CONFLICT (user_id, type_id, academic_year) DO UPDATE SET paid_at = EXCLUDED.paid_at,
+			paid_until = EXCLUDED.paid_until, amount_pence = EXCLUDED.amount_pence, source = EXCLUDED.source,
+			reference = EXCLUDED.reference
+			RETURNING membership_id`)
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for addMembership: %w", err))
+	}
+
+	err = s.db.QueryRowxContext(ctx, sql, args...).Scan(&m.MembershipID)
+	if err != nil {
+		return Membership{}, fmt.Errorf("failed to add membership: %w", err)
+	}
+
+	return s.getMembership(ctx, m)
+}
+
+func (s *Store) deleteMembership(ctx context.Context, m Membership) error {
+	builder := utils.PSQL().Delete("people.memberships").
+		Where(sq.Eq{"membership_id": m.MembershipID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for deleteMembership: %w", err))
+	}
+
+	_, err = s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete membership: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) getUnpaid(ctx context.Context) ([]Membership, error) {
+	var m []Membership
+
+	latest := membershipBuilder().
+		Options("DISTINCT ON (m.user_id)").
+		Where(sq.And{
+			sq.Eq{"u.deleted_at": nil},
+			sq.Expr(`NOT EXISTS (SELECT 1 FROM people.memberships c
+				WHERE c.user_id = m.user_id AND c.paid_at IS NOT NULL AND c.paid_until > NOW())`),
+		}).
+		OrderBy("m.user_id", "m.paid_until DESC")
+
+	builder := utils.PSQL().Select("*").
+		FromSelect(latest, "unpaid").
+		OrderBy("user_name")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getUnpaid: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &m, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unpaid memberships: %w", err)
+	}
+
+	return m, nil
+}
+
+func (s *Store) getExpiringBefore(ctx context.Context, t time.Time) ([]Membership, error) {
+	var m []Membership
+
+	builder := membershipBuilder().
+		Where(sq.And{
+			sq.Expr(currentWhere),
+			sq.Lt{"m.paid_until": t},
+			sq.Eq{"m.reminded_at": nil},
+			sq.Eq{"u.deleted_at": nil},
+			sq.Expr(`NOT EXISTS (SELECT 1 FROM people.memberships n
+				WHERE n.user_id = m.user_id AND n.paid_at IS NOT NULL AND n.paid_until > m.paid_until)`),
+		}).
+		OrderBy("m.paid_until")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getExpiringBefore: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &m, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expiring memberships: %w", err)
+	}
+
+	return m, nil
+}
+
+func (s *Store) setReminded(ctx context.Context, m Membership) error {
+	builder := utils.PSQL().Update("people.memberships").
+		Set("reminded_at", time.Now()).
+		Where(sq.Eq{"membership_id": m.MembershipID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for setReminded: %w", err))
+	}
+
+	_, err = s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to set membership reminded: %w", err)
+	}
+
+	return nil
+}
+
+// getRoleSyncActions works out which paid members are missing the role of their membership type and which roles
+// granted by the sync are no longer backed by a paid membership
+func (s *Store) getRoleSyncActions(ctx context.Context, q sqlx.QueryerContext) ([]RoleSyncAction, error) {
+	var grants, revokes []RoleSyncAction
+
+	grantBuilder := utils.PSQL().Select("'grant' AS action", "t.role_id", "r.name AS role_name",
+		"m.user_id", "CONCAT(u.first_name, ' ', u.last_name) AS user_name").
+		Distinct().
+		From("people.memberships m").
+		InnerJoin("people.membership_types t ON t.type_id = m.type_id").
+		InnerJoin("people.roles r ON r.role_id = t.role_id").
+		InnerJoin("people.users u ON u.user_id = m.user_id").
+		Where(currentWhere).
+		Where(sq.Eq{"u.deleted_at": nil}).
+		Where(`NOT EXISTS (SELECT 1 FROM people.role_members rm
+			WHERE rm.role_id = t.role_id AND rm.user_id = m.user_id AND `+roleSyncHeldWhere+`)`).
+		OrderBy("user_name", "role_name")
+
+	revokeBuilder := utils.PSQL().Select("'revoke' AS action", "rm.role_id", "r.name AS role_name",
+		"rm.user_id", "CONCAT(u.first_name, ' ', u.last_name) AS user_name").
+		From("people.role_members rm").
+		InnerJoin("people.roles r ON r.role_id = rm.role_id").
+		InnerJoin("people.users u ON u.user_id = rm.user_id").
+		Where(sq.Eq{"rm.membership_sync": true}).
+		Where(`NOT EXISTS (SELECT 1 FROM people.memberships m
+			INNER JOIN people.membership_types t ON t.type_id = m.type_id
+			WHERE t.role_id = rm.role_id AND m.user_id = rm.user_id AND `+currentWhere+`)`).
+		OrderBy("user_name", "role_name")
+
+	sql, args, err := grantBuilder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getRoleSyncActions grants: %w", err))
+	}
+
+	err = sqlx.SelectContext(ctx, q, &grants, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get membership role sync grants: %w", err)
+	}
+
+	sql, args, err = revokeBuilder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getRoleSyncActions revokes: %w", err))
+	}
+
+	err = sqlx.SelectContext(ctx, q, &revokes, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get membership role sync revokes: %w", err)
+	}
+
+	return append(grants, revokes...), nil
+}
+
+// syncRoles applies the role sync actions in one transaction with their webhook events, only memberships granted by
+// the sync are revoked
+func (s *Store) syncRoles(ctx context.Context) ([]RoleSyncAction, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin membership role sync transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	actions, err := s.getRoleSyncActions(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]RoleSyncAction, 0, len(actions))
+
+	for _, a := range actions {
+		var rows int64
+
+		switch a.Action {
+		case RoleSyncGrant:
+			rows, err = execRoleSync(ctx, tx, roleSyncGrantBuilder(a))
+		case RoleSyncRevoke:
+			rows, err = execRoleSync(ctx, tx, roleSyncRevokeBuilder(a))
+			if err == nil && rows < 1 {
+				// the officership sync still backs the role so it is only handed over to that sync
+				_, err = execRoleSync(ctx, tx, roleSyncReleaseBuilder(a))
+			}
+		default:
+			return nil, fmt.Errorf("failed to apply membership role sync: unknown action: %s", a.Action)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to %s role for membership role sync: %w", a.Action, err)
+		}
+
+		if rows < 1 {
+			continue
+		}
+
+		event := webhook.RoleUserAdded
+		if a.Action == RoleSyncRevoke {
+			event = webhook.RoleUserRemoved
+		}
+
+		err = s.webhook.Emit(ctx, tx, event, user.RoleUser{RoleID: a.RoleID, UserID: a.UserID, MembershipSync: true})
+		if err != nil {
+			return nil, fmt.Errorf("failed to emit %s for membership role sync: %w", event, err)
+		}
+
+		applied = append(applied, a)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit membership role sync: %w", err)
+	}
+
+	return applied, nil
+}
+
+// execRoleSync runs one of the role sync statements and returns the rows it changed
+func execRoleSync(ctx context.Context, e sqlx.ExecerContext, builder sq.Sqlizer) (int64, error) {
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for syncRoles: %w", err))
+	}
+
+	res, err := e.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// roleSyncGrantBuilder gives the role, a membership the user already has that isn't current or is only held
+// through the officership sync is taken on by this sync and made current
+func roleSyncGrantBuilder(a RoleSyncAction) sq.InsertBuilder {
+	return utils.PSQL().Insert("people.role_members").
+		Columns("role_id", "user_id", "membership_sync", "reason").
+		Values(a.RoleID, a.UserID, true, "Paid membership").
+		Suffix(`ON CONFLICT (role_id, user_id) DO UPDATE SET membership_sync = true, starts_at = NULL,
+			ends_at = NULL, expiry_notified_at = NULL`)
+}
+
+// roleSyncRevokeBuilder removes a membership only this sync is backing
+func roleSyncRevokeBuilder(a RoleSyncAction) sq.DeleteBuilder {
+	return utils.PSQL().Delete("people.role_members").
+		Where(sq.And{
+			sq.Eq{"role_id": a.RoleID},
+			sq.Eq{"user_id": a.UserID},
+			sq.Eq{"membership_sync": true},
+			sq.Eq{"officership_sync": false},
+		})
+}
+
+// roleSyncReleaseBuilder stops this sync backing a membership the officership sync is also backing
+func roleSyncReleaseBuilder(a RoleSyncAction) sq.UpdateBuilder {
+	return utils.PSQL().Update("people.role_members").
+		Set("membership_sync", false).
+		Where(sq.And{
+			sq.Eq{"role_id": a.RoleID},
+			sq.Eq{"user_id": a.UserID},
+			sq.Eq{"membership_sync": true},
+		})
+}
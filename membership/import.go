@@ -0,0 +1,304 @@
+package membership
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/user"
+)
+
+// ImportRow is a purchase from the Students' Union membership export and what the import did with it
+type ImportRow struct {
+	Line               int       `json:"line"`
+	Email              string    `json:"email"`
+	UniversityUsername string    `json:"universityUsername"`
+	Name               string    `json:"name"`
+	TypeName           string    `json:"typeName"`
+	PaidAt             time.Time `json:"paidAt"`
+	AmountPence        int       `json:"amountPence"`
+	Reference          string    `json:"reference"`
+	UserID             int       `json:"userID,omitempty"`
+	MembershipID       int       `json:"membershipID,omitempty"`
+	Problem            string    `json:"problem,omitempty"`
+}
+
+// importHeaders are the lower case headers each column of the export is found by, the export has changed its
+// headers over the years so all of them are matched
+var importHeaders = map[string][]string{
+	"email":               {"email", "email address", "e-mail"},
+	"university_username": {"university username", "university id", "student id", "username"},
+	"name":                {"name", "full name", "customer name", "purchaser"},
+	"first_name":          {"first name", "forename"},
+	"last_name":           {"last name", "surname"},
+	"type":                {"membership type", "membership", "product name", "product", "type"},
+	"paid_at":             {"purchase date", "date purchased", "order date", "date"},
+	"amount":              {"price", "amount", "total", "price paid"},
+	"reference":           {"order number", "order no", "card number", "reference"},
+}
+
+// importDateFormats are the date formats the export has been seen with
+var importDateFormats = []string{
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+	"02/01/2006",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02",
+	"02-01-2006",
+	"2 January 2006",
+}
+
+// ParseImport reads the Students' Union membership export, rows that can't be read are kept with a problem so
+// they are shown
+func ParseImport(r io.Reader) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+
+	for i, h := range header {
+		// a byte order mark is left on the first header by excel
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if _, ok := columns[h]; !ok {
+			columns[h] = i
+		}
+	}
+
+	fields := make(map[string]int)
+
+	for f, hs := range importHeaders {
+		for _, h := range hs {
+			if i, ok := columns[h]; ok {
+				fields[f] = i
+
+				break
+			}
+		}
+	}
+
+	_, hasEmail := fields["email"]
+	_, hasUniversityUsername := fields["university_username"]
+
+	if !hasEmail && !hasUniversityUsername {
+		return nil, errors.New("csv must have an email or university username column")
+	}
+
+	if _, ok := fields["paid_at"]; !ok {
+		return nil, errors.New("csv must have a purchase date column")
+	}
+
+	var rows []ImportRow
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read csv line %d: %w", line, err)
+		}
+
+		values := make(map[string]string)
+
+		for f, i := range fields {
+			if i < len(record) && strings.TrimSpace(record[i]) != "" {
+				values[f] = strings.TrimSpace(record[i])
+			}
+		}
+
+		if len(values) == 0 {
+			continue
+		}
+
+		rows = append(rows, newImportRow(line, values))
+	}
+
+	return rows, nil
+}
+
+// newImportRow fills an import row from the values of a line
+func newImportRow(line int, values map[string]string) ImportRow {
+	row := ImportRow{
+		Line:               line,
+		Email:              strings.ToLower(values["email"]),
+		UniversityUsername: strings.ToLower(values["university_username"]),
+		Name:               values["name"],
+		TypeName:           values["type"],
+		Reference:          values["reference"],
+	}
+
+	if row.Name == "" {
+		row.Name = strings.TrimSpace(values["first_name"] + " " + values["last_name"])
+	}
+
+	if row.UniversityUsername == "" && strings.HasSuffix(row.Email, "@york.ac.uk") {
+		row.UniversityUsername = strings.TrimSuffix(row.Email, "@york.ac.uk")
+	}
+
+	var err error
+
+	row.PaidAt, err = parseImportDate(values["paid_at"])
+	if err != nil {
+		row.Problem = err.Error()
+		return row
+	}
+
+	row.AmountPence, err = ParsePence(values["amount"])
+	if err != nil {
+		row.Problem = err.Error()
+		return row
+	}
+
+	if row.Email == "" && row.UniversityUsername == "" {
+		row.Problem = "email or university username must be set"
+	}
+
+	return row
+}
+
+// parseImportDate reads a purchase date in any of the formats the export has used
+func parseImportDate(s string) (time.Time, error) {
+	for _, f := range importDateFormats {
+		t, err := time.ParseInLocation(f, s, time.Local)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("purchase date \"%s\" isn't a date", s)
+}
+
+// ParsePence reads an amount like £5.00 or 5 in pence, a missing amount is free
+func ParsePence(s string) (int, error) {
+	s = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s), "£"))
+	if s == "" {
+		return 0, nil
+	}
+
+	pounds, pence, hasPence := strings.Cut(s, ".")
+
+	p, err := strconv.Atoi(pounds)
+	if err != nil || p < 0 {
+		return 0, fmt.Errorf("amount \"%s\" isn't an amount", s)
+	}
+
+	total := p * 100
+
+	if hasPence {
+		if len(pence) == 1 {
+			pence += "0"
+		}
+
+		pp, err := strconv.Atoi(pence)
+		if err != nil || len(pence) != 2 || pp < 0 {
+			return 0, fmt.Errorf("amount \"%s\" isn't an amount", s)
+		}
+
+		total += pp
+	}
+
+	return total, nil
+}
+
+// Import records a membership for each row that can be matched to a user, by email and then university username,
+// and to a type, by name and then the default type, rows that can't be are given a problem and skipped. Running
+// the same export again updates the memberships it made
+func Import(ctx context.Context, memberships Repo, users user.Repo, rows []ImportRow, defaultType Type,
+	importedBy int,
+) ([]ImportRow, error) {
+	types := make(map[string]Type)
+
+	for i := range rows {
+		row := &rows[i]
+		if row.Problem != "" {
+			continue
+		}
+
+		u, err := importUser(ctx, users, *row)
+		if err != nil {
+			row.Problem = err.Error()
+			continue
+		}
+
+		row.UserID = u.UserID
+
+		t, ok := types[strings.ToLower(row.TypeName)]
+		if !ok {
+			t = defaultType
+
+			if row.TypeName != "" {
+				named, err := memberships.GetType(ctx, Type{Name: row.TypeName})
+				if err == nil {
+					t = named
+				}
+			}
+
+			types[strings.ToLower(row.TypeName)] = t
+		}
+
+		if t.TypeID == 0 {
+			row.Problem = fmt.Sprintf("membership type \"%s\" doesn't exist", row.TypeName)
+			continue
+		}
+
+		academicYear := AcademicYear(row.PaidAt)
+
+		paidUntil, err := AcademicYearEnd(academicYear)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get end of academic year for line %d: %w", row.Line, err)
+		}
+
+		m, err := memberships.AddMembership(ctx, Membership{
+			UserID:       u.UserID,
+			TypeID:       t.TypeID,
+			AcademicYear: academicYear,
+			PaidAt:       null.TimeFrom(row.PaidAt),
+			PaidUntil:    paidUntil,
+			AmountPence:  row.AmountPence,
+			Source:       SUImport,
+			Reference:    row.Reference,
+			CreatedBy:    null.NewInt(int64(importedBy), importedBy != 0),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add membership for line %d: %w", row.Line, err)
+		}
+
+		row.MembershipID = m.MembershipID
+	}
+
+	return rows, nil
+}
+
+// importUser finds the user a row is for
+func importUser(ctx context.Context, users user.Repo, row ImportRow) (user.User, error) {
+	if row.Email != "" {
+		u, err := users.GetUser(ctx, user.User{Email: row.Email})
+		if err == nil && !u.DeletedAt.Valid {
+			return u, nil
+		}
+	}
+
+	if row.UniversityUsername != "" {
+		u, err := users.GetUserByUniversityUsername(ctx, user.User{UniversityUsername: row.UniversityUsername})
+		if err == nil && !u.DeletedAt.Valid {
+			return u, nil
+		}
+	}
+
+	return user.User{}, errors.New("no user has this email or university username")
+}
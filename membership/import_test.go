@@ -0,0 +1,132 @@
+package membership
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/ystv/web-auth/user"
+	mock_user "github.com/ystv/web-auth/user/mocks"
+)
+
+func TestAcademicYear(t *testing.T) {
+	assert.Equal(t, "2026/27", AcademicYear(time.Date(2026, time.September, 1, 0, 0, 0, 0, time.Local)))
+	assert.Equal(t, "2025/26", AcademicYear(time.Date(2026, time.August, 31, 23, 59, 0, 0, time.Local)))
+	assert.Equal(t, "2099/00", AcademicYear(time.Date(2099, time.October, 1, 0, 0, 0, 0, time.Local)))
+
+	end, err := AcademicYearEnd("2026/27")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2027, time.September, 1, 0, 0, 0, 0, time.Local), end)
+
+	_, err = AcademicYearEnd("2026/28")
+	assert.Error(t, err)
+
+	_, err = AcademicYearEnd("2026")
+	assert.Error(t, err)
+}
+
+func TestParseImport(t *testing.T) {
+	csv := "\ufeffOrder Number,Purchase Date,First Name,Surname,Email,Product Name,Price\n" +
+		"1001,01/10/2026 12:30,Alice,Smith,ABC123@york.ac.uk,Full Membership,£5.00\n" +
+		",,,,,,\n" +
+		"1002,2026-10-02,Bob,Jones,bob@example.com,,7.5\n" +
+		"1003,yesterday,Carol,Brown,carol@example.com,Full Membership,£5.00\n" +
+		"1004,02/10/2026,Dan,White,dan@example.com,Full Membership,five pounds\n"
+
+	rows, err := ParseImport(strings.NewReader(csv))
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+
+	assert.Equal(t, 2, rows[0].Line)
+	assert.Equal(t, "abc123@york.ac.uk", rows[0].Email)
+	assert.Equal(t, "abc123", rows[0].UniversityUsername)
+	assert.Equal(t, "Alice Smith", rows[0].Name)
+	assert.Equal(t, "Full Membership", rows[0].TypeName)
+	assert.Equal(t, time.Date(2026, time.October, 1, 12, 30, 0, 0, time.Local), rows[0].PaidAt)
+	assert.Equal(t, 500, rows[0].AmountPence)
+	assert.Equal(t, "1001", rows[0].Reference)
+	assert.Empty(t, rows[0].Problem)
+
+	assert.Equal(t, 4, rows[1].Line)
+	assert.Equal(t, 750, rows[1].AmountPence)
+	assert.Empty(t, rows[1].UniversityUsername)
+	assert.Empty(t, rows[1].Problem)
+
+	assert.Contains(t, rows[2].Problem, "isn't a date")
+	assert.Contains(t, rows[3].Problem, "isn't an amount")
+
+	_, err = ParseImport(strings.NewReader("Name,Price\nAlice,5\n"))
+	assert.Error(t, err)
+}
+
+func TestImport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	users := mock_user.NewMockRepo(ctrl)
+	memberships := &importRepo{types: map[string]Type{"Full": {TypeID: 10, Name: "Full"}}}
+
+	paidAt := time.Date(2026, time.October, 1, 12, 30, 0, 0, time.Local)
+
+	rows := []ImportRow{
+		{Line: 2, Email: "abc123@york.ac.uk", UniversityUsername: "abc123", TypeName: "Full", PaidAt: paidAt,
+			AmountPence: 500, Reference: "1001"},
+		{Line: 3, Email: "old@example.com", UniversityUsername: "def456", PaidAt: paidAt},
+		{Line: 4, Email: "nobody@example.com", PaidAt: paidAt},
+		{Line: 5, Problem: "purchase date \"\" isn't a date"},
+	}
+
+	users.EXPECT().GetUser(gomock.Any(), user.User{Email: "abc123@york.ac.uk"}).Return(user.User{UserID: 1}, nil)
+	users.EXPECT().GetUser(gomock.Any(), user.User{Email: "old@example.com"}).
+		Return(user.User{}, errors.New("not found"))
+	users.EXPECT().GetUserByUniversityUsername(gomock.Any(), user.User{UniversityUsername: "def456"}).
+		Return(user.User{UserID: 2}, nil)
+	users.EXPECT().GetUser(gomock.Any(), user.User{Email: "nobody@example.com"}).
+		Return(user.User{}, errors.New("not found"))
+
+	rows, err := Import(context.Background(), memberships, users, rows, Type{TypeID: 20}, 99)
+	require.NoError(t, err)
+
+	require.Len(t, memberships.added, 2)
+	assert.Equal(t, 10, memberships.added[0].TypeID)
+	assert.Equal(t, 20, memberships.added[1].TypeID)
+
+	for _, m := range memberships.added {
+		assert.Equal(t, "2026/27", m.AcademicYear)
+		assert.Equal(t, time.Date(2027, time.September, 1, 0, 0, 0, 0, time.Local), m.PaidUntil)
+		assert.Equal(t, SUImport, m.Source)
+		assert.Equal(t, int64(99), m.CreatedBy.Int64)
+	}
+
+	assert.Equal(t, 100, rows[0].MembershipID)
+	assert.Equal(t, 200, rows[1].MembershipID)
+	assert.Equal(t, 2, rows[1].UserID)
+	assert.NotEmpty(t, rows[2].Problem)
+	assert.Zero(t, rows[3].UserID)
+}
+
+// importRepo is the part of the Repo used by Import, the mocks can't be used in this package
+type importRepo struct {
+	Repo
+	types map[string]Type
+	added []Membership
+}
+
+func (r *importRepo) GetType(_ context.Context, t Type) (Type, error) {
+	if found, ok := r.types[t.Name]; ok {
+		return found, nil
+	}
+
+	return Type{}, errors.New("not found")
+}
+
+func (r *importRepo) AddMembership(_ context.Context, m Membership) (Membership, error) {
+	r.added = append(r.added, m)
+	m.MembershipID = m.UserID * 100
+
+	return m, nil
+}
@@ -0,0 +1,208 @@
+package membership
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/webhook"
+)
+
+//go:generate mockgen -destination mocks/mock_membership.go -package mock_membership github.com/ystv/web-auth/membership Repo
+
+type (
+	Repo interface {
+		GetTypes(context.Context) ([]Type, error)
+		GetType(context.Context, Type) (Type, error)
+		AddType(context.Context, Type) (Type, error)
+		EditType(context.Context, Type) (Type, error)
+		DeleteType(context.Context, Type) error
+		GetAcademicYears(context.Context) ([]string, error)
+		GetMemberships(context.Context, string) ([]Membership, error)
+		GetMembershipsForUser(context.Context, user.User) ([]Membership, error)
+		GetMembership(context.Context, Membership) (Membership, error)
+		AddMembership(context.Context, Membership) (Membership, error)
+		DeleteMembership(context.Context, Membership) error
+		GetUnpaid(context.Context) ([]Membership, error)
+		GetExpiringBefore(context.Context, time.Time) ([]Membership, error)
+		SetReminded(context.Context, Membership) error
+		GetRoleSyncActions(context.Context) ([]RoleSyncAction, error)
+		SyncRoles(context.Context) ([]RoleSyncAction, error)
+	}
+
+	// Store stores the dependencies
+	Store struct {
+		db      *sqlx.DB
+		webhook webhook.Repo
+	}
+
+	// Type is a kind of membership that can be bought, paid members of it are given its role
+	Type struct {
+		TypeID         int         `db:"type_id" json:"typeID"`
+		Name           string      `db:"name" json:"name"`
+		Description    string      `db:"description" json:"description"`
+		RoleID         null.Int    `db:"role_id" json:"roleID"`
+		RoleName       null.String `db:"role_name" json:"roleName"`
+		CurrentMembers int         `db:"current_members" json:"currentMembers"`
+	}
+
+	// Membership is a user's membership of a Type for an academic year, it is paid once PaidAt is set and until
+	// PaidUntil
+	Membership struct {
+		MembershipID int         `db:"membership_id" json:"membershipID"`
+		UserID       int         `db:"user_id" json:"userID"`
+		TypeID       int         `db:"type_id" json:"typeID"`
+		AcademicYear string      `db:"academic_year" json:"academicYear"`
+		PaidAt       null.Time   `db:"paid_at" json:"paidAt"`
+		PaidUntil    time.Time   `db:"paid_until" json:"paidUntil"`
+		AmountPence  int         `db:"amount_pence" json:"amountPence"`
+		Source       Source      `db:"source" json:"source"`
+		Reference    string      `db:"reference" json:"reference"`
+		CreatedBy    null.Int    `db:"created_by" json:"createdBy"`
+		CreatedAt    time.Time   `db:"created_at" json:"createdAt"`
+		RemindedAt   null.Time   `db:"reminded_at" json:"remindedAt"`
+		TypeName     string      `db:"type_name" json:"typeName"`
+		UserName     string      `db:"user_name" json:"userName"`
+		Firstname    string      `db:"first_name" json:"-"`
+		Email        string      `db:"email" json:"-"`
+		Language     null.String `db:"language" json:"-"`
+	}
+
+	// Source is where a Membership was recorded from
+	Source string
+)
+
+const (
+	Manual   Source = "manual"
+	SUImport Source = "su_import"
+)
+
+var _ Repo = &Store{}
+
+// NewMembershipRepo stores our dependency
+func NewMembershipRepo(db *sqlx.DB, wh webhook.Repo) *Store {
+	return &Store{
+		db:      db,
+		webhook: wh,
+	}
+}
+
+// GetTypes returns the membership types with how many paid members they have
+func (s *Store) GetTypes(ctx context.Context) ([]Type, error) {
+	return s.getTypes(ctx)
+}
+
+// GetType returns a membership type by id or name
+func (s *Store) GetType(ctx context.Context, t Type) (Type, error) {
+	return s.getType(ctx, t)
+}
+
+// AddType adds a membership type
+func (s *Store) AddType(ctx context.Context, t Type) (Type, error) {
+	return s.addType(ctx, t)
+}
+
+// EditType edits a membership type, the role sync picks up a change of role
+func (s *Store) EditType(ctx context.Context, t Type) (Type, error) {
+	return s.editType(ctx, t)
+}
+
+// DeleteType deletes a membership type, it fails while there are memberships of it
+func (s *Store) DeleteType(ctx context.Context, t Type) error {
+	return s.deleteType(ctx, t)
+}
+
+// GetAcademicYears returns the academic years with memberships, newest first
+func (s *Store) GetAcademicYears(ctx context.Context) ([]string, error) {
+	return s.getAcademicYears(ctx)
+}
+
+// GetMemberships returns the memberships of an academic year
+func (s *Store) GetMemberships(ctx context.Context, academicYear string) ([]Membership, error) {
+	return s.getMemberships(ctx, academicYear)
+}
+
+// GetMembershipsForUser returns every membership of a user, newest first
+func (s *Store) GetMembershipsForUser(ctx context.Context, u user.User) ([]Membership, error) {
+	return s.getMembershipsForUser(ctx, u)
+}
+
+// GetMembership returns a membership
+func (s *Store) GetMembership(ctx context.Context, m Membership) (Membership, error) {
+	return s.getMembership(ctx, m)
+}
+
+// AddMembership records a membership, a membership of the same type and year for the user is replaced so
+// imports can be run again
+func (s *Store) AddMembership(ctx context.Context, m Membership) (Membership, error) {
+	return s.addMembership(ctx, m)
+}
+
+// DeleteMembership deletes a membership
+func (s *Store) DeleteMembership(ctx context.Context, m Membership) error {
+	return s.deleteMembership(ctx, m)
+}
+
+// GetUnpaid returns the latest membership of every user who has had one but doesn't have a paid one now
+func (s *Store) GetUnpaid(ctx context.Context) ([]Membership, error) {
+	return s.getUnpaid(ctx)
+}
+
+// GetExpiringBefore returns the paid memberships ending before the time that haven't been reminded and aren't
+// followed by another paid membership
+func (s *Store) GetExpiringBefore(ctx context.Context, t time.Time) ([]Membership, error) {
+	return s.getExpiringBefore(ctx, t)
+}
+
+// SetReminded records that the user has been reminded their membership is ending
+func (s *Store) SetReminded(ctx context.Context, m Membership) error {
+	return s.setReminded(ctx, m)
+}
+
+// GetRoleSyncActions returns the role changes the sync would make without making them
+func (s *Store) GetRoleSyncActions(ctx context.Context) ([]RoleSyncAction, error) {
+	return s.getRoleSyncActions(ctx, s.db)
+}
+
+// SyncRoles grants the membership type roles to paid members and revokes the roles it granted from users whose
+// memberships have ended, returning the changes made
+func (s *Store) SyncRoles(ctx context.Context) ([]RoleSyncAction, error) {
+	return s.syncRoles(ctx)
+}
+
+// Amount returns the amount paid formatted in pounds
+func (m Membership) Amount() string {
+	return fmt.Sprintf("£%d.%02d", m.AmountPence/100, m.AmountPence%100)
+}
+
+// IsCurrent returns if the membership is paid and hasn't run out
+func (m Membership) IsCurrent() bool {
+	return m.PaidAt.Valid && m.PaidUntil.After(time.Now())
+}
+
+// AcademicYear returns the academic year the time is in, they start on the 1st of September and are written
+// like 2026/27
+func AcademicYear(t time.Time) string {
+	year := t.Year()
+	if t.Month() < time.September {
+		year--
+	}
+
+	return fmt.Sprintf("%d/%02d", year, (year+1)%100)
+}
+
+// AcademicYearEnd returns when memberships of an academic year run out by default, the start of the next one
+func AcademicYearEnd(academicYear string) (time.Time, error) {
+	var start, end int
+
+	_, err := fmt.Sscanf(academicYear, "%d/%d", &start, &end)
+	if err != nil || (start+1)%100 != end {
+		return time.Time{}, fmt.Errorf("academic year \"%s\" must be like 2026/27", academicYear)
+	}
+
+	return time.Date(start+1, time.September, 1, 0, 0, 0, 0, time.Local), nil
+}
@@ -0,0 +1,29 @@
+package membership
+
+import "github.com/ystv/web-auth/user"
+
+type (
+	// RoleSyncAction is a change to a user's roles needed to match their paid memberships
+	RoleSyncAction struct {
+		Action   RoleSyncActionType `db:"action" json:"action"`
+		RoleID   int                `db:"role_id" json:"roleID"`
+		RoleName string             `db:"role_name" json:"roleName"`
+		UserID   int                `db:"user_id" json:"userID"`
+		UserName string             `db:"user_name" json:"userName"`
+	}
+
+	// RoleSyncActionType is whether the role is being granted or revoked
+	RoleSyncActionType string
+)
+
+const (
+	// RoleSyncGrant is used when a user has a paid membership of a type with a role they don't have
+	RoleSyncGrant RoleSyncActionType = "grant"
+	// RoleSyncRevoke is used when a role granted by the sync is no longer backed by a paid membership
+	RoleSyncRevoke RoleSyncActionType = "revoke"
+)
+
+// roleSyncHeldWhere is when role_members rm already gives the role for the sync, a current membership that was
+// granted by hand or by this sync. A membership only held through the officership sync still needs this sync to back
+// it, otherwise it would be revoked when the officer's term ends
+const roleSyncHeldWhere = "(rm.membership_sync OR NOT rm.officership_sync) AND " + user.CurrentRoleMemberWhere
@@ -2,12 +2,16 @@ package officership
 
 import (
 	"context"
+	"slices"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
+	"gopkg.in/guregu/null.v4"
 
 	"github.com/ystv/web-auth/user"
 	"github.com/ystv/web-auth/utils"
+	"github.com/ystv/web-auth/webhook"
 )
 
 func (s *Store) countOfficerships(ctx context.Context) (CountOfficerships, error) {
@@ -18,7 +22,7 @@ func (s *Store) countOfficerships(ctx context.Context) (CountOfficerships, error
 		(SELECT COUNT(*) FROM people.officerships) as total_officerships,
 		(SELECT COUNT(*) FROM people.officerships WHERE is_current = true) as current_officerships,
 		(SELECT COUNT(*) FROM people.officership_members) as total_officers,
-		(SELECT COUNT(*) FROM people.officership_members WHERE end_date IS NULL) as current_officers;`)
+		(SELECT COUNT(*) FROM people.officership_members om WHERE `+CurrentOfficerWhere+`) as current_officers;`)
 	if err != nil {
 		return countOfficerships, errors.Errorf("failed to count officerships all from db: %+v", err)
 	}
@@ -33,8 +37,8 @@ func (s *Store) getOfficerships(ctx context.Context, officershipStatus Officersh
 		"COUNT(DISTINCT omp.officership_member_id) AS previous_officers", "otm.team_id AS team_id",
 		"ot.name AS team_name").
 		From("people.officerships o").
-		LeftJoin("people.officership_members omc ON o.officer_id = omc.officer_id AND omc.end_date IS NULL").
-		LeftJoin("people.officership_members omp ON o.officer_id = omp.officer_id AND omp.end_date IS NOT NULL").
+		LeftJoin("people.officership_members omc ON o.officer_id = omc.officer_id AND "+currentOfficerWhereAs("omc")).
+		LeftJoin("people.officership_members omp ON o.officer_id = omp.officer_id AND "+previousOfficerWhereAs("omp")).
 		LeftJoin("people.officership_team_members otm ON o.officer_id = otm.officer_id").
 		LeftJoin("people.officership_teams ot ON ot.team_id = otm.team_id").
 		GroupBy("o", "o.officer_id", "o.name", "o.email_alias", "description", "historywiki_url", "role_id",
@@ -78,8 +82,8 @@ func (s *Store) getOfficership(ctx context.Context, o1 Officership) (Officership
 		"COUNT(DISTINCT omp.officership_member_id) AS previous_officers", "otm.team_id AS team_id",
 		"ot.name AS team_name", "otm.is_leader AS is_team_leader", "otm.is_deputy AS is_team_deputy").
 		From("people.officerships o").
-		LeftJoin("people.officership_members omc ON o.officer_id = omc.officer_id AND omc.end_date IS NULL").
-		LeftJoin("people.officership_members omp ON o.officer_id = omp.officer_id AND omp.end_date IS NOT NULL").
+		LeftJoin("people.officership_members omc ON o.officer_id = omc.officer_id AND "+currentOfficerWhereAs("omc")).
+		LeftJoin("people.officership_members omp ON o.officer_id = omp.officer_id AND "+previousOfficerWhereAs("omp")).
 		LeftJoin("people.officership_team_members otm ON o.officer_id = otm.officer_id").
 		LeftJoin("people.officership_teams ot ON ot.team_id = otm.team_id").
 		Where(sq.Or{sq.Eq{"o.officer_id": o1.OfficershipID}, sq.And{sq.Eq{"o.name": o1.Name}, sq.NotEq{"o.name": ""}}}).
@@ -108,10 +112,20 @@ func (s *Store) getOfficership(ctx context.Context, o1 Officership) (Officership
 }
 
 func (s *Store) addOfficership(ctx context.Context, o Officership) (Officership, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Officership{}, errors.Errorf("failed to begin add officership transaction: %+v", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
 	builder := utils.PSQL().Insert("people.officerships").
 		Columns("name", "email_alias", "description", "historywiki_url", "role_id", "is_current",
-			"if_unfilled").
-		Values(o.Name, o.EmailAlias, o.Description, o.HistoryWikiURL, o.RoleID, o.IsCurrent, o.IfUnfilled).
+			"if_unfilled", "co_held").
+		Values(o.Name, o.EmailAlias, o.Description, o.HistoryWikiURL, o.RoleID, o.IsCurrent, o.IfUnfilled,
+			o.CoHeld).
 		Suffix("RETURNING officer_id")
 
 	sql, args, err := builder.ToSql()
@@ -119,22 +133,34 @@ func (s *Store) addOfficership(ctx context.Context, o Officership) (Officership,
 		panic(errors.Errorf("failed to build sql for addOfficership: %+v", err))
 	}
 
-	stmt, err := s.db.PrepareContext(ctx, sql)
+	err = tx.QueryRowxContext(ctx, sql, args...).Scan(&o.OfficershipID)
 	if err != nil {
 		return Officership{}, errors.Errorf("failed to add officership: %+v", err)
 	}
 
-	defer stmt.Close()
+	err = s.webhook.Emit(ctx, tx, webhook.OfficershipCreated, o)
+	if err != nil {
+		return Officership{}, errors.Errorf("failed to emit officership created: %+v", err)
+	}
 
-	err = stmt.QueryRow(args...).Scan(&o.OfficershipID)
+	err = tx.Commit()
 	if err != nil {
-		return Officership{}, errors.Errorf("failed to add officership: %+v", err)
+		return Officership{}, errors.Errorf("failed to commit add officership: %+v", err)
 	}
 
 	return o, nil
 }
 
 func (s *Store) editOfficership(ctx context.Context, o Officership) (Officership, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Officership{}, errors.Errorf("failed to begin edit officership transaction: %+v", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
 	builder := utils.PSQL().Update("people.officerships").
 		SetMap(map[string]interface{}{
 			"name":            o.Name,
@@ -144,6 +170,7 @@ func (s *Store) editOfficership(ctx context.Context, o Officership) (Officership
 			"role_id":         o.RoleID,
 			"is_current":      o.IsCurrent,
 			"if_unfilled":     o.IfUnfilled,
+			"co_held":         o.CoHeld,
 		}).
 		Where(sq.Eq{"officer_id": o.OfficershipID})
 
@@ -152,7 +179,7 @@ func (s *Store) editOfficership(ctx context.Context, o Officership) (Officership
 		panic(errors.Errorf("failed to build sql for editOfficership: %+v", err))
 	}
 
-	res, err := s.db.ExecContext(ctx, sql, args...)
+	res, err := tx.ExecContext(ctx, sql, args...)
 	if err != nil {
 		return Officership{}, errors.Errorf("failed to edit officership: %+v", err)
 	}
@@ -166,10 +193,29 @@ func (s *Store) editOfficership(ctx context.Context, o Officership) (Officership
 		return Officership{}, errors.Errorf("failed to edit officerhip: invalid rows affected: %d", rows)
 	}
 
+	err = s.webhook.Emit(ctx, tx, webhook.OfficershipUpdated, o)
+	if err != nil {
+		return Officership{}, errors.Errorf("failed to emit officership updated: %+v", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Officership{}, errors.Errorf("failed to commit edit officership: %+v", err)
+	}
+
 	return o, nil
 }
 
 func (s *Store) deleteOfficership(ctx context.Context, o Officership) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Errorf("failed to begin delete officership transaction: %+v", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
 	builder := utils.PSQL().Delete("people.officerships").
 		Where(sq.Eq{"officer_id": o.OfficershipID})
 
@@ -178,11 +224,21 @@ func (s *Store) deleteOfficership(ctx context.Context, o Officership) error {
 		panic(errors.Errorf("failed to build sql for deleteOfficership: %+v", err))
 	}
 
-	_, err = s.db.ExecContext(ctx, sql, args...)
+	_, err = tx.ExecContext(ctx, sql, args...)
 	if err != nil {
 		return errors.Errorf("failed to delete officership: %+v", err)
 	}
 
+	err = s.webhook.Emit(ctx, tx, webhook.OfficershipDeleted, o)
+	if err != nil {
+		return errors.Errorf("failed to emit officership deleted: %+v", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.Errorf("failed to commit delete officership: %+v", err)
+	}
+
 	return nil
 }
 
@@ -194,7 +250,8 @@ func (s *Store) getOfficershipTeams(ctx context.Context) ([]OfficershipTeam, err
 		From("people.officership_teams ot").
 		LeftJoin("people.officership_team_members otm ON ot.team_id = otm.team_id").
 		LeftJoin("people.officerships o ON otm.officer_id = o.officer_id").
-		LeftJoin("people.officership_members om ON o.officer_id = om.officer_id AND om.end_date IS NULL AND o.is_current = true").
+		LeftJoin("people.officership_members om ON o.officer_id = om.officer_id AND o.is_current = true AND "+
+			CurrentOfficerWhere).
 		GroupBy("ot", "ot.team_id", "ot.name", "ot.email_alias", "short_description", "full_description")
 
 	sql, args, err := builder.ToSql()
@@ -315,8 +372,8 @@ func (s *Store) getOfficershipTeamMembers(ctx context.Context, t1 *OfficershipTe
 		"COUNT(DISTINCT omp.officership_member_id) AS previous_officers", "o.is_current AS is_current").
 		From("people.officership_team_members otm").
 		LeftJoin("people.officerships o on o.officer_id = otm.officer_id").
-		LeftJoin("people.officership_members omc ON o.officer_id = omc.officer_id AND omc.end_date IS NULL").
-		LeftJoin("people.officership_members omp ON o.officer_id = omp.officer_id AND omp.end_date IS NOT NULL")
+		LeftJoin("people.officership_members omc ON o.officer_id = omc.officer_id AND " + currentOfficerWhereAs("omc")).
+		LeftJoin("people.officership_members omp ON o.officer_id = omp.officer_id AND " + previousOfficerWhereAs("omp"))
 
 	if t1 != nil {
 		builder = builder.Where(sq.Eq{"otm.team_id": t1.TeamID})
@@ -397,8 +454,8 @@ func (s *Store) getOfficershipTeamMember(ctx context.Context, m1 OfficershipTeam
 		"COUNT(DISTINCT omp.officership_member_id) AS previous_officers").
 		From("people.officership_team_members otm").
 		LeftJoin("people.officerships o on o.officer_id = otm.officer_id").
-		LeftJoin("people.officership_members omc ON o.officer_id = omc.officer_id AND omc.end_date IS NULL").
-		LeftJoin("people.officership_members omp ON o.officer_id = omp.officer_id AND omp.end_date IS NOT NULL").
+		LeftJoin("people.officership_members omc ON o.officer_id = omc.officer_id AND "+currentOfficerWhereAs("omc")).
+		LeftJoin("people.officership_members omp ON o.officer_id = omp.officer_id AND "+previousOfficerWhereAs("omp")).
 		Where(sq.And{
 			sq.Eq{"otm.team_id": m1.TeamID},
 			sq.Eq{"otm.officer_id": m1.OfficerID},
@@ -487,7 +544,8 @@ func (s *Store) getOfficershipMembers(ctx context.Context, o1 *Officership, u *u
 	var o []OfficershipMember
 
 	builder := utils.PSQL().Select("om.*", "o.name AS officership_name",
-		"CONCAT(u.first_name, ' ', u.last_name) AS user_name", "otm.team_id AS team_id", "ot.name AS team_name").
+		"CONCAT(u.first_name, ' ', u.last_name) AS user_name", "otm.team_id AS team_id", "ot.name AS team_name",
+		"COALESCE(o.co_held, false) AS co_held").
 		From("people.officership_members om").
 		LeftJoin("people.officerships o ON o.officer_id = om.officer_id").
 		LeftJoin("people.officership_team_members otm ON otm.officer_id = om.officer_id").
@@ -518,9 +576,9 @@ func (s *Store) getOfficershipMembers(ctx context.Context, o1 *Officership, u *u
 	switch officershipMemberStatus {
 	case Any:
 	case Current:
-		builder = builder.Where("om.end_date IS NULL")
+		builder = builder.Where(CurrentOfficerWhere)
 	case Retired:
-		builder = builder.Where("om.end_date IS NOT NULL")
+		builder = builder.Where(previousOfficerWhereAs("om"))
 	}
 
 	if orderByOfficerName {
@@ -575,6 +633,15 @@ func (s *Store) getOfficershipMember(ctx context.Context, m1 OfficershipMember)
 }
 
 func (s *Store) addOfficershipMember(ctx context.Context, m OfficershipMember) (OfficershipMember, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return OfficershipMember{}, errors.Errorf("failed to begin add officership member transaction: %+v", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
 	builder := utils.PSQL().Insert("people.officership_members").
 		Columns("user_id", "officer_id", "start_date", "end_date").
 		Values(m.UserID, m.OfficerID, m.StartDate, m.EndDate).
@@ -585,22 +652,34 @@ func (s *Store) addOfficershipMember(ctx context.Context, m OfficershipMember) (
 		panic(errors.Errorf("failed to build sql for addOfficershipMember: %+v", err))
 	}
 
-	stmt, err := s.db.PrepareContext(ctx, sql)
+	err = tx.QueryRowxContext(ctx, sql, args...).Scan(&m.OfficershipMemberID)
 	if err != nil {
-		return OfficershipMember{}, errors.Errorf("failed to add officership member: %+v", err)
+		return OfficershipMember{}, errors.Errorf("failed to add offciership member: %+v", err)
 	}
 
-	defer stmt.Close()
+	err = s.webhook.Emit(ctx, tx, webhook.OfficershipMemberAdded, m)
+	if err != nil {
+		return OfficershipMember{}, errors.Errorf("failed to emit officership member added: %+v", err)
+	}
 
-	err = stmt.QueryRow(args...).Scan(&m.OfficershipMemberID)
+	err = tx.Commit()
 	if err != nil {
-		return OfficershipMember{}, errors.Errorf("failed to add offciership member: %+v", err)
+		return OfficershipMember{}, errors.Errorf("failed to commit add officership member: %+v", err)
 	}
 
 	return m, nil
 }
 
 func (s *Store) editOfficershipMember(ctx context.Context, m OfficershipMember) (OfficershipMember, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return OfficershipMember{}, errors.Errorf("failed to begin edit officership member transaction: %+v", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
 	builder := utils.PSQL().Update("people.officership_members").
 		SetMap(map[string]interface{}{
 			"user_id":    m.UserID,
@@ -615,7 +694,7 @@ func (s *Store) editOfficershipMember(ctx context.Context, m OfficershipMember)
 		panic(errors.Errorf("failed to build sql for editOfficershipMember: %+v", err))
 	}
 
-	res, err := s.db.ExecContext(ctx, sql, args...)
+	res, err := tx.ExecContext(ctx, sql, args...)
 	if err != nil {
 		return OfficershipMember{}, errors.Errorf("failed to edit officership member: %+v", err)
 	}
@@ -630,10 +709,29 @@ func (s *Store) editOfficershipMember(ctx context.Context, m OfficershipMember)
 			errors.Errorf("failed to edit officerhip member: invalid rows affected: %d", rows)
 	}
 
+	err = s.webhook.Emit(ctx, tx, webhook.OfficershipMemberUpdated, m)
+	if err != nil {
+		return OfficershipMember{}, errors.Errorf("failed to emit officership member updated: %+v", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return OfficershipMember{}, errors.Errorf("failed to commit edit officership member: %+v", err)
+	}
+
 	return m, nil
 }
 
 func (s *Store) deleteOfficershipMember(ctx context.Context, m OfficershipMember) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Errorf("failed to begin delete officership member transaction: %+v", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
 	builder := utils.PSQL().Delete("people.officership_members").
 		Where(sq.Eq{"officership_member_id": m.OfficershipMemberID})
 
@@ -642,44 +740,414 @@ func (s *Store) deleteOfficershipMember(ctx context.Context, m OfficershipMember
 		panic(errors.Errorf("failed to build sql for deleteOfficershipMember: %+v", err))
 	}
 
-	_, err = s.db.ExecContext(ctx, sql, args...)
+	_, err = tx.ExecContext(ctx, sql, args...)
 	if err != nil {
 		return errors.Errorf("failed to delete officership member: %+v", err)
 	}
 
+	err = s.webhook.Emit(ctx, tx, webhook.OfficershipMemberRemoved, m)
+	if err != nil {
+		return errors.Errorf("failed to emit officership member removed: %+v", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return errors.Errorf("failed to commit delete officership member: %+v", err)
+	}
+
 	return nil
 }
 
 func (s *Store) removeOfficershipForOfficershipMembers(ctx context.Context, o Officership) error {
+	return s.removeOfficershipMembers(ctx, sq.Eq{"om.officer_id": o.OfficershipID})
+}
+
+func (s *Store) removeUserForOfficershipMembers(ctx context.Context, u user.User) error {
+	return s.removeOfficershipMembers(ctx, sq.Eq{"om.user_id": u.UserID})
+}
+
+// removeOfficershipMembers deletes the officership members matching where, each one is emitted as removed in the
+// same transaction
+func (s *Store) removeOfficershipMembers(ctx context.Context, where sq.Eq) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return errors.Errorf("failed to begin remove officership members transaction: %+v", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var members []OfficershipMember
+
+	sql, args, err := removedOfficershipMembersBuilder(where).ToSql()
+	if err != nil {
+		panic(errors.Errorf("failed to build sql for removeOfficershipMembers: %+v", err))
+	}
+
+	err = tx.SelectContext(ctx, &members, sql, args...)
+	if err != nil {
+		return errors.Errorf("failed to get officership members to remove: %+v", err)
+	}
+
+	if len(members) == 0 {
+		return nil
+	}
+
+	// an officership in more than one team is joined once per team, each member is only removed and emitted once
+	members = slices.CompactFunc(members, func(a, b OfficershipMember) bool {
+		return a.OfficershipMemberID == b.OfficershipMemberID
+	})
+
+	ids := make([]int, 0, len(members))
+
+	for _, m := range members {
+		ids = append(ids, m.OfficershipMemberID)
+	}
+
 	builder := utils.PSQL().Delete("people.officership_members").
-		Where(sq.Eq{"officer_id": o.OfficershipID})
+		Where(sq.Eq{"officership_member_id": ids})
 
-	sql, args, err := builder.ToSql()
+	sql, args, err = builder.ToSql()
 	if err != nil {
-		panic(errors.Errorf("failed to build sql for removeOfficershipForOfficershipMembers: %+v", err))
+		panic(errors.Errorf("failed to build sql for removeOfficershipMembers: %+v", err))
 	}
 
-	_, err = s.db.ExecContext(ctx, sql, args...)
+	_, err = tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return errors.Errorf("failed to remove officership members: %+v", err)
+	}
+
+	for _, m := range members {
+		err = s.webhook.Emit(ctx, tx, webhook.OfficershipMemberRemoved, m)
+		if err != nil {
+			return errors.Errorf("failed to emit officership member removed: %+v", err)
+		}
+	}
+
+	err = tx.Commit()
 	if err != nil {
-		return errors.Errorf("failed to remove officership for officership members: %+v", err)
+		return errors.Errorf("failed to commit remove officership members: %+v", err)
 	}
 
 	return nil
 }
 
-func (s *Store) removeUserForOfficershipMembers(ctx context.Context, u user.User) error {
-	builder := utils.PSQL().Delete("people.officership_members").
-		Where(sq.Eq{"user_id": u.UserID})
+// removedOfficershipMembersBuilder gets the officership members about to be removed with their names for the
+// events, they are locked so a concurrent edit can't be emitted after them
+func removedOfficershipMembersBuilder(where sq.Eq) sq.SelectBuilder {
+	return utils.PSQL().Select("om.*", "o.name AS officership_name",
+		"CONCAT(u.first_name, ' ', u.last_name) AS user_name", "otm.team_id AS team_id", "ot.name AS team_name").
+		From("people.officership_members om").
+		LeftJoin("people.officerships o ON o.officer_id = om.officer_id").
+		LeftJoin("people.officership_team_members otm ON otm.officer_id = om.officer_id").
+		LeftJoin("people.officership_teams ot ON ot.team_id = otm.team_id").
+		LeftJoin("people.users u ON u.user_id = om.user_id").
+		Where(where).
+		OrderBy("om.officership_member_id").
+		Suffix("FOR UPDATE OF om")
+}
+
+func (s *Store) applyHandover(ctx context.Context, h Handover) (appliedHandover, error) {
+	var applied appliedHandover
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return appliedHandover{}, errors.Errorf("failed to begin handover transaction: %+v", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, change := range h.Changes {
+		for _, m := range change.Outgoing {
+			m.EndDate = null.TimeFrom(h.EndDate)
+
+			sql, args, err := endOfficerBuilder(m).ToSql()
+			if err != nil {
+				panic(errors.Errorf("failed to build sql for applyHandover end officer: %+v", err))
+			}
+
+			res, err := tx.ExecContext(ctx, sql, args...)
+			if err != nil {
+				return appliedHandover{}, errors.Errorf("failed to end officer for handover: %+v", err)
+			}
+
+			rows, err := res.RowsAffected()
+			if err != nil {
+				return appliedHandover{}, errors.Errorf("failed to end officer for handover: %+v", err)
+			}
+
+			if rows < 1 {
+				return appliedHandover{}, errors.Errorf("failed to end officer for handover: officer %d has already ended",
+					m.OfficershipMemberID)
+			}
+
+			applied.ended = append(applied.ended, m)
+		}
+
+		for _, u := range change.Incoming {
+			m := OfficershipMember{
+				UserID:          u.UserID,
+				OfficerID:       change.Officership.OfficershipID,
+				StartDate:       null.TimeFrom(h.StartDate),
+				OfficershipName: change.Officership.Name,
+				UserName:        u.Firstname + " " + u.Lastname,
+			}
+
+			builder := utils.PSQL().Insert("people.officership_members").
+				Columns("user_id", "officer_id", "start_date", "end_date").
+				Values(m.UserID, m.OfficerID, m.StartDate, m.EndDate).
+				Suffix("RETURNING officership_member_id")
+
+			sql, args, err := builder.ToSql()
+			if err != nil {
+				panic(errors.Errorf("failed to build sql for applyHandover add officer: %+v", err))
+			}
+
+			err = tx.QueryRowContext(ctx, sql, args...).Scan(&m.OfficershipMemberID)
+			if err != nil {
+				return appliedHandover{}, errors.Errorf("failed to add officer for handover: %+v", err)
+			}
+
+			applied.added = append(applied.added, m)
+		}
+	}
+
+	userIDs := make([]int, 0)
+
+	for _, m := range applied.ended {
+		userIDs = append(userIDs, m.UserID)
+
+		err = s.webhook.Emit(ctx, tx, webhook.OfficershipMemberUpdated, m)
+		if err != nil {
+			return appliedHandover{}, errors.Errorf("failed to emit officership member updated for handover: %+v", err)
+		}
+	}
+
+	for _, m := range applied.added {
+		userIDs = append(userIDs, m.UserID)
+
+		err = s.webhook.Emit(ctx, tx, webhook.OfficershipMemberAdded, m)
+		if err != nil {
+			return appliedHandover{}, errors.Errorf("failed to emit officership member added for handover: %+v", err)
+		}
+	}
+
+	// The roles are synced once every officer has been moved, so someone who is outgoing from one officership
+	// keeps the role if they are still a current officer of another officership with the same role
+	actions, err := s.getRoleSyncActions(ctx, tx, userIDs)
+	if err != nil {
+		return appliedHandover{}, errors.Errorf("failed to get role sync for handover: %+v", err)
+	}
+
+	applied.roles, err = s.applyRoleSyncActions(ctx, tx, actions)
+	if err != nil {
+		return appliedHandover{}, errors.Errorf("failed to sync roles for handover: %+v", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return appliedHandover{}, errors.Errorf("failed to commit handover: %+v", err)
+	}
+
+	return applied, nil
+}
+
+// roleSyncActionsBuilders selects the roles to grant and revoke for the users, nil is every user. An officer holds
+// their role until their end date has passed, so an outgoing officer of a handover keeps it until their term ends
+func roleSyncActionsBuilders(userIDs []int) (grantBuilder, revokeBuilder sq.SelectBuilder) {
+	grantBuilder = utils.PSQL().Select("'grant' AS action", "o.role_id", "r.name AS role_name",
+		"om.user_id", "CONCAT(u.first_name, ' ', u.last_name) AS user_name").
+		Distinct().
+		From("people.officership_members om").
+		InnerJoin("people.officerships o ON o.officer_id = om.officer_id").
+		InnerJoin("people.roles r ON r.role_id = o.role_id").
+		InnerJoin("people.users u ON u.user_id = om.user_id").
+		Where(CurrentOfficerWhere).
+		Where(`NOT EXISTS (SELECT 1 FROM people.role_members rm
+			WHERE rm.role_id = o.role_id AND rm.user_id = om.user_id AND `+roleSyncHeldWhere+`)`).
+		OrderBy("user_name", "role_name")
+
+	revokeBuilder = utils.PSQL().Select("'revoke' AS action", "rm.role_id", "r.name AS role_name",
+		"rm.user_id", "CONCAT(u.first_name, ' ', u.last_name) AS user_name").
+		From("people.role_members rm").
+		InnerJoin("people.roles r ON r.role_id = rm.role_id").
+		InnerJoin("people.users u ON u.user_id = rm.user_id").
+		Where(sq.Eq{"rm.officership_sync": true}).
+		Where(`NOT EXISTS (SELECT 1 FROM people.officership_members om
+			INNER JOIN people.officerships o ON o.officer_id = om.officer_id
+			WHERE o.role_id = rm.role_id AND om.user_id = rm.user_id AND `+CurrentOfficerWhere+`)`).
+		OrderBy("user_name", "role_name")
+
+	if userIDs != nil {
+		grantBuilder = grantBuilder.Where(sq.Eq{"om.user_id": userIDs})
+		revokeBuilder = revokeBuilder.Where(sq.Eq{"rm.user_id": userIDs})
+	}
+
+	return grantBuilder, revokeBuilder
+}
+
+// endOfficerBuilder sets the end date of an officer who hasn't already ended
+func endOfficerBuilder(m OfficershipMember) sq.UpdateBuilder {
+	return utils.PSQL().Update("people.officership_members").
+		Set("end_date", m.EndDate).
+		Where(sq.And{
+			sq.Eq{"officership_member_id": m.OfficershipMemberID},
+			sq.Eq{"end_date": nil},
+		})
+}
+
+// getRoleSyncActions works out the role memberships to grant and revoke for the roles to match the current officers,
+// userIDs limits it to those users or all users when nil
+func (s *Store) getRoleSyncActions(ctx context.Context, q sqlx.QueryerContext, userIDs []int) ([]RoleSyncAction, error) {
+	var grants, revokes []RoleSyncAction
+
+	grantBuilder, revokeBuilder := roleSyncActionsBuilders(userIDs)
+
+	sql, args, err := grantBuilder.ToSql()
+	if err != nil {
+		panic(errors.Errorf("failed to build sql for getRoleSyncActions grants: %+v", err))
+	}
 
+	err = sqlx.SelectContext(ctx, q, &grants, sql, args...)
+	if err != nil {
+		return nil, errors.Errorf("failed to get role sync grants: %+v", err)
+	}
+
+	sql, args, err = revokeBuilder.ToSql()
+	if err != nil {
+		panic(errors.Errorf("failed to build sql for getRoleSyncActions revokes: %+v", err))
+	}
+
+	err = sqlx.SelectContext(ctx, q, &revokes, sql, args...)
+	if err != nil {
+		return nil, errors.Errorf("failed to get role sync revokes: %+v", err)
+	}
+
+	return append(grants, revokes...), nil
+}
+
+// applyRoleSyncActions grants and revokes the role memberships, only memberships granted by the sync are revoked,
+// the webhook events are queued with the changes
+func (s *Store) applyRoleSyncActions(ctx context.Context, e sqlx.ExecerContext,
+	actions []RoleSyncAction) ([]RoleSyncAction, error) {
+	applied := make([]RoleSyncAction, 0, len(actions))
+
+	for _, a := range actions {
+		var rows int64
+
+		var err error
+
+		switch a.Action {
+		case RoleSyncGrant:
+			rows, err = execRoleSync(ctx, e, roleSyncGrantBuilder(a))
+		case RoleSyncRevoke:
+			rows, err = execRoleSync(ctx, e, roleSyncRevokeBuilder(a))
+			if err == nil && rows < 1 {
+				// the membership sync still backs the role so it is only handed over to that sync
+				_, err = execRoleSync(ctx, e, roleSyncReleaseBuilder(a))
+			}
+		default:
+			return nil, errors.Errorf("failed to apply role sync: unknown action: %s", a.Action)
+		}
+
+		if err != nil {
+			return nil, errors.Errorf("failed to %s role for role sync: %+v", a.Action, err)
+		}
+
+		if rows < 1 {
+			continue
+		}
+
+		event := webhook.RoleUserAdded
+		if a.Action == RoleSyncRevoke {
+			event = webhook.RoleUserRemoved
+		}
+
+		err = s.webhook.Emit(ctx, e, event, user.RoleUser{RoleID: a.RoleID, UserID: a.UserID, OfficershipSync: true})
+		if err != nil {
+			return nil, errors.Errorf("failed to emit %s for role sync: %+v", event, err)
+		}
+
+		applied = append(applied, a)
+	}
+
+	return applied, nil
+}
+
+// execRoleSync runs one of the role sync statements and returns the rows it changed
+func execRoleSync(ctx context.Context, e sqlx.ExecerContext, builder sq.Sqlizer) (int64, error) {
 	sql, args, err := builder.ToSql()
 	if err != nil {
-		panic(errors.Errorf("failed to build sql for removeUsersForOfficershipMembers: %+v", err))
+		panic(errors.Errorf("failed to build sql for applyRoleSyncActions: %+v", err))
 	}
 
-	_, err = s.db.ExecContext(ctx, sql, args...)
+	res, err := e.ExecContext(ctx, sql, args...)
 	if err != nil {
-		return errors.Errorf("failed to remove users for officership members: %+v", err)
+		return 0, err
 	}
 
-	return nil
+	return res.RowsAffected()
+}
+
+// roleSyncGrantBuilder gives the role, a membership the user already has that isn't current or is only held
+// through the membership sync is taken on by this sync and made current
+func roleSyncGrantBuilder(a RoleSyncAction) sq.InsertBuilder {
+	return utils.PSQL().Insert("people.role_members").
+		Columns("role_id", "user_id", "officership_sync").
+		Values(a.RoleID, a.UserID, true).
+		Suffix(`ON CONFLICT (role_id, user_id) DO UPDATE SET officership_sync = true, starts_at = NULL,
+			ends_at = NULL, expiry_notified_at = NULL`)
+}
+
+// roleSyncRevokeBuilder removes a membership only this sync is backing
+func roleSyncRevokeBuilder(a RoleSyncAction) sq.DeleteBuilder {
+	return utils.PSQL().Delete("people.role_members").
+		Where(sq.And{
+			sq.Eq{"role_id": a.RoleID},
+			sq.Eq{"user_id": a.UserID},
+			sq.Eq{"officership_sync": true},
+			sq.Eq{"membership_sync": false},
+		})
+}
+
+// roleSyncReleaseBuilder stops this sync backing a membership the membership sync is also backing
+func roleSyncReleaseBuilder(a RoleSyncAction) sq.UpdateBuilder {
+	return utils.PSQL().Update("people.role_members").
+		Set("officership_sync", false).
+		Where(sq.And{
+			sq.Eq{"role_id": a.RoleID},
+			sq.Eq{"user_id": a.UserID},
+			sq.Eq{"officership_sync": true},
+		})
+}
+
+func (s *Store) syncRoles(ctx context.Context) ([]RoleSyncAction, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Errorf("failed to begin role sync transaction: %+v", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	actions, err := s.getRoleSyncActions(ctx, tx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := s.applyRoleSyncActions(ctx, tx, actions)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, errors.Errorf("failed to commit role sync: %+v", err)
+	}
+
+	return applied, nil
 }
@@ -0,0 +1,28 @@
+package officership
+
+import (
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemovedOfficershipMembersSQL(t *testing.T) {
+	sql, args, err := removedOfficershipMembersBuilder(sq.Eq{"om.user_id": 5}).ToSql()
+	require.NoError(t, err)
+
+	assert.Contains(t, sql, "WHERE om.user_id = $1")
+	// the rows of a member in more than one team are next to each other so they are only emitted once
+	assert.Contains(t, sql, "ORDER BY om.officership_member_id FOR UPDATE OF om")
+	assert.Equal(t, []interface{}{5}, args)
+}
+
+func TestOfficerWindows(t *testing.T) {
+	assert.Equal(t, "(omc.start_date IS NULL OR omc.start_date <= NOW()) AND "+
+		"(omc.end_date IS NULL OR omc.end_date > NOW())", currentOfficerWhereAs("omc"))
+	assert.Equal(t, currentOfficerWhereAs("om"), CurrentOfficerWhere)
+
+	// an officer whose term ends in the future is still current, so isn't counted as a previous officer yet
+	assert.Equal(t, "omp.end_date <= NOW()", previousOfficerWhereAs("omp"))
+}
@@ -0,0 +1,107 @@
+package officership
+
+import (
+	"time"
+
+	"github.com/ystv/web-auth/user"
+)
+
+type (
+	// HandoverResult is a single line of an election result set, the user who will hold the officership
+	HandoverResult struct {
+		Officership Officership `json:"officership"`
+		User        user.User   `json:"user"`
+	}
+
+	// HandoverChange is the difference between the current officers and the election results for one officership
+	HandoverChange struct {
+		Officership Officership         `json:"officership"`
+		Incoming    []user.User         `json:"incoming"`
+		Outgoing    []OfficershipMember `json:"outgoing"`
+		Continuing  []OfficershipMember `json:"continuing"`
+	}
+
+	// Handover is a planned term handover, incoming officers start on StartDate and outgoing officers end on EndDate
+	Handover struct {
+		StartDate time.Time        `json:"startDate"`
+		EndDate   time.Time        `json:"endDate"`
+		Changes   []HandoverChange `json:"changes"`
+	}
+
+	// appliedHandover is what was changed by a handover, used for the webhook events
+	appliedHandover struct {
+		ended []OfficershipMember
+		added []OfficershipMember
+		roles []RoleSyncAction
+	}
+)
+
+// PlanHandover works out the changes needed to go from the current officers to the election results,
+// only officerships that are in the results are touched
+func PlanHandover(current []OfficershipMember, results []HandoverResult) []HandoverChange {
+	changes := make([]HandoverChange, 0)
+	index := make(map[int]int)
+
+	for _, r := range results {
+		i, ok := index[r.Officership.OfficershipID]
+		if !ok {
+			i = len(changes)
+			index[r.Officership.OfficershipID] = i
+
+			changes = append(changes, HandoverChange{Officership: r.Officership})
+		}
+
+		duplicate := false
+
+		for _, u := range changes[i].Incoming {
+			if u.UserID == r.User.UserID {
+				duplicate = true
+			}
+		}
+
+		if !duplicate {
+			changes[i].Incoming = append(changes[i].Incoming, r.User)
+		}
+	}
+
+	for i := range changes {
+		elected := changes[i].Incoming
+		changes[i].Incoming = nil
+
+		for _, m := range current {
+			if m.OfficerID != changes[i].Officership.OfficershipID || m.EndDate.Valid {
+				continue
+			}
+
+			reElected := false
+
+			for _, u := range elected {
+				if u.UserID == m.UserID {
+					reElected = true
+				}
+			}
+
+			if reElected {
+				changes[i].Continuing = append(changes[i].Continuing, m)
+			} else {
+				changes[i].Outgoing = append(changes[i].Outgoing, m)
+			}
+		}
+
+		for _, u := range elected {
+			continuing := false
+
+			for _, m := range changes[i].Continuing {
+				if m.UserID == u.UserID {
+					continuing = true
+				}
+			}
+
+			if !continuing {
+				changes[i].Incoming = append(changes[i].Incoming, u)
+			}
+		}
+	}
+
+	return changes
+}
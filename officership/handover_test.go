@@ -0,0 +1,76 @@
+package officership
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/user"
+)
+
+func TestPlanHandover(t *testing.T) {
+	director := Officership{OfficershipID: 1, Name: "Station Director"}
+	tech := Officership{OfficershipID: 2, Name: "Head of Tech"}
+	welfare := Officership{OfficershipID: 3, Name: "Head of Welfare and Training"}
+
+	alice := user.User{UserID: 1}
+	bob := user.User{UserID: 2}
+	carol := user.User{UserID: 3}
+
+	current := []OfficershipMember{
+		{OfficershipMemberID: 10, UserID: alice.UserID, OfficerID: director.OfficershipID},
+		{OfficershipMemberID: 11, UserID: bob.UserID, OfficerID: tech.OfficershipID},
+		{OfficershipMemberID: 12, UserID: carol.UserID, OfficerID: tech.OfficershipID},
+		{OfficershipMemberID: 13, UserID: bob.UserID, OfficerID: director.OfficershipID,
+			EndDate: null.TimeFrom(time.Now())},
+		{OfficershipMemberID: 14, UserID: carol.UserID, OfficerID: welfare.OfficershipID},
+	}
+
+	results := []HandoverResult{
+		{Officership: director, User: bob},
+		{Officership: tech, User: carol},
+		{Officership: tech, User: alice},
+		{Officership: tech, User: alice},
+	}
+
+	changes := PlanHandover(current, results)
+
+	assert.Len(t, changes, 2)
+
+	assert.Equal(t, director, changes[0].Officership)
+	assert.Equal(t, []user.User{bob}, changes[0].Incoming)
+	assert.Equal(t, []OfficershipMember{current[0]}, changes[0].Outgoing)
+	assert.Empty(t, changes[0].Continuing)
+
+	assert.Equal(t, tech, changes[1].Officership)
+	assert.Equal(t, []user.User{alice}, changes[1].Incoming)
+	assert.Equal(t, []OfficershipMember{current[1]}, changes[1].Outgoing)
+	assert.Equal(t, []OfficershipMember{current[2]}, changes[1].Continuing)
+}
+
+func TestApplyHandoverSQL(t *testing.T) {
+	// outgoing officers are given the handover's end date rather than being ended straight away
+	endDate := time.Now().AddDate(0, 0, 14)
+
+	sql, args, err := endOfficerBuilder(OfficershipMember{OfficershipMemberID: 4, EndDate: null.TimeFrom(endDate)}).
+		ToSql()
+	require.NoError(t, err)
+
+	assert.Equal(t, "UPDATE people.officership_members SET end_date = $1 WHERE (officership_member_id = $2 AND "+
+		"end_date IS NULL)", sql)
+	assert.Equal(t, []interface{}{null.TimeFrom(endDate), 4}, args)
+
+	// so the roles synced in the handover's transaction are only revoked once that end date has passed
+	_, revokeBuilder := roleSyncActionsBuilders([]int{1, 2})
+
+	sql, args, err = revokeBuilder.ToSql()
+	require.NoError(t, err)
+
+	assert.Contains(t, sql, CurrentOfficerWhere)
+	assert.Contains(t, CurrentOfficerWhere, "(om.end_date IS NULL OR om.end_date > NOW())")
+	assert.NotContains(t, sql, "AND om.end_date IS NULL")
+	assert.Equal(t, []interface{}{true, 1, 2}, args)
+}
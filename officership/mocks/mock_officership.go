@@ -102,6 +102,20 @@ func (mr *MockRepoMockRecorder) AddOfficershipTeamMember(arg0, arg1 any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOfficershipTeamMember", reflect.TypeOf((*MockRepo)(nil).AddOfficershipTeamMember), arg0, arg1)
 }
 
+// ApplyHandover mocks base method.
+func (m *MockRepo) ApplyHandover(arg0 context.Context, arg1 officership.Handover) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyHandover", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApplyHandover indicates an expected call of ApplyHandover.
+func (mr *MockRepoMockRecorder) ApplyHandover(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyHandover", reflect.TypeOf((*MockRepo)(nil).ApplyHandover), arg0, arg1)
+}
+
 // CountOfficerships mocks base method.
 func (m *MockRepo) CountOfficerships(arg0 context.Context) (officership.CountOfficerships, error) {
 	m.ctrl.T.Helper()
@@ -353,6 +367,21 @@ func (mr *MockRepoMockRecorder) GetOfficershipsNotInTeam(arg0, arg1 any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOfficershipsNotInTeam", reflect.TypeOf((*MockRepo)(nil).GetOfficershipsNotInTeam), arg0, arg1)
 }
 
+// GetRoleSyncActions mocks base method.
+func (m *MockRepo) GetRoleSyncActions(arg0 context.Context) ([]officership.RoleSyncAction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoleSyncActions", arg0)
+	ret0, _ := ret[0].([]officership.RoleSyncAction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoleSyncActions indicates an expected call of GetRoleSyncActions.
+func (mr *MockRepoMockRecorder) GetRoleSyncActions(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoleSyncActions", reflect.TypeOf((*MockRepo)(nil).GetRoleSyncActions), arg0)
+}
+
 // RemoveOfficershipForOfficershipMembers mocks base method.
 func (m *MockRepo) RemoveOfficershipForOfficershipMembers(arg0 context.Context, arg1 officership.Officership) error {
 	m.ctrl.T.Helper()
@@ -394,3 +423,18 @@ func (mr *MockRepoMockRecorder) RemoveUserForOfficershipMembers(arg0, arg1 any)
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUserForOfficershipMembers", reflect.TypeOf((*MockRepo)(nil).RemoveUserForOfficershipMembers), arg0, arg1)
 }
+
+// SyncRoles mocks base method.
+func (m *MockRepo) SyncRoles(arg0 context.Context) ([]officership.RoleSyncAction, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncRoles", arg0)
+	ret0, _ := ret[0].([]officership.RoleSyncAction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncRoles indicates an expected call of SyncRoles.
+func (mr *MockRepoMockRecorder) SyncRoles(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncRoles", reflect.TypeOf((*MockRepo)(nil).SyncRoles), arg0)
+}
@@ -2,11 +2,13 @@ package officership
 
 import (
 	"context"
+	"log"
 
 	"github.com/jmoiron/sqlx"
 	"gopkg.in/guregu/null.v4"
 
 	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/webhook"
 )
 
 //go:generate mockgen -destination mocks/mock_officership.go -package mock_officership github.com/ystv/web-auth/officership Repo
@@ -37,11 +39,15 @@ type (
 		DeleteOfficershipMember(context.Context, OfficershipMember) error
 		RemoveOfficershipForOfficershipMembers(context.Context, Officership) error
 		RemoveUserForOfficershipMembers(context.Context, user.User) error
+		ApplyHandover(context.Context, Handover) error
+		GetRoleSyncActions(context.Context) ([]RoleSyncAction, error)
+		SyncRoles(context.Context) ([]RoleSyncAction, error)
 	}
 
 	// Store stores the dependencies
 	Store struct {
-		db *sqlx.DB
+		db      *sqlx.DB
+		webhook webhook.Repo
 	}
 
 	// Officership represents relevant officership fields
@@ -60,6 +66,8 @@ type (
 		TeamName         null.String `db:"team_name" json:"teamName"`
 		IsTeamLeader     null.Bool   `db:"is_team_leader" json:"isTeamLeader"`
 		IsTeamDeputy     null.Bool   `db:"is_team_deputy" json:"isTeamDeputy"`
+		// CoHeld is set when more than one officer holds the officership at once on purpose
+		CoHeld bool `db:"co_held" json:"coHeld"`
 	}
 
 	// OfficershipsStatus indicates the state desired for a database get of officers
@@ -91,6 +99,8 @@ type (
 		UserName            string      `db:"user_name" json:"userName"`
 		TeamID              null.Int    `db:"team_id" json:"teamID"`
 		TeamName            null.String `db:"team_name" json:"teamName"`
+		// CoHeld is the officership's, overlapping terms of a co-held officership aren't an issue
+		CoHeld bool `db:"co_held" json:"coHeld"`
 	}
 
 	// OfficershipTeamMember represents relevant officership team member fields
@@ -122,9 +132,10 @@ const (
 )
 
 // NewOfficershipRepo stores our dependency
-func NewOfficershipRepo(db *sqlx.DB) *Store {
+func NewOfficershipRepo(db *sqlx.DB, wh webhook.Repo) *Store {
 	return &Store{
-		db: db,
+		db:      db,
+		webhook: wh,
 	}
 }
 
@@ -145,11 +156,25 @@ func (s *Store) AddOfficership(ctx context.Context, o Officership) (Officership,
 }
 
 func (s *Store) EditOfficership(ctx context.Context, o Officership) (Officership, error) {
-	return s.editOfficership(ctx, o)
+	o, err := s.editOfficership(ctx, o)
+	if err != nil {
+		return Officership{}, err
+	}
+
+	s.sync(ctx)
+
+	return o, nil
 }
 
 func (s *Store) DeleteOfficership(ctx context.Context, o Officership) error {
-	return s.deleteOfficership(ctx, o)
+	err := s.deleteOfficership(ctx, o)
+	if err != nil {
+		return err
+	}
+
+	s.sync(ctx)
+
+	return nil
 }
 
 func (s *Store) GetOfficershipTeams(ctx context.Context) ([]OfficershipTeam, error) {
@@ -207,21 +232,83 @@ func (s *Store) GetOfficershipMember(ctx context.Context, m OfficershipMember) (
 }
 
 func (s *Store) AddOfficershipMember(ctx context.Context, m OfficershipMember) (OfficershipMember, error) {
-	return s.addOfficershipMember(ctx, m)
+	m, err := s.addOfficershipMember(ctx, m)
+	if err != nil {
+		return OfficershipMember{}, err
+	}
+
+	s.sync(ctx)
+
+	return m, nil
 }
 
 func (s *Store) EditOfficershipMember(ctx context.Context, m OfficershipMember) (OfficershipMember, error) {
-	return s.editOfficershipMember(ctx, m)
+	m, err := s.editOfficershipMember(ctx, m)
+	if err != nil {
+		return OfficershipMember{}, err
+	}
+
+	s.sync(ctx)
+
+	return m, nil
 }
 
 func (s *Store) DeleteOfficershipMember(ctx context.Context, m OfficershipMember) error {
-	return s.deleteOfficershipMember(ctx, m)
+	err := s.deleteOfficershipMember(ctx, m)
+	if err != nil {
+		return err
+	}
+
+	s.sync(ctx)
+
+	return nil
 }
 
 func (s *Store) RemoveOfficershipForOfficershipMembers(ctx context.Context, o Officership) error {
-	return s.removeOfficershipForOfficershipMembers(ctx, o)
+	err := s.removeOfficershipForOfficershipMembers(ctx, o)
+	if err != nil {
+		return err
+	}
+
+	s.sync(ctx)
+
+	return nil
 }
 
 func (s *Store) RemoveUserForOfficershipMembers(ctx context.Context, u user.User) error {
-	return s.removeUserForOfficershipMembers(ctx, u)
+	err := s.removeUserForOfficershipMembers(ctx, u)
+	if err != nil {
+		return err
+	}
+
+	s.sync(ctx)
+
+	return nil
+}
+
+// ApplyHandover ends the outgoing officers and adds the incoming officers in a single transaction,
+// the officership roles are granted and revoked along with them
+func (s *Store) ApplyHandover(ctx context.Context, h Handover) error {
+	_, err := s.applyHandover(ctx, h)
+
+	return err
+}
+
+// GetRoleSyncActions returns the role changes the sync would make without making them
+func (s *Store) GetRoleSyncActions(ctx context.Context) ([]RoleSyncAction, error) {
+	return s.getRoleSyncActions(ctx, s.db, nil)
+}
+
+// SyncRoles grants the officership roles to current officers and revokes the roles it granted
+// from officers whose terms have ended, returning the changes made
+func (s *Store) SyncRoles(ctx context.Context) ([]RoleSyncAction, error) {
+	return s.syncRoles(ctx)
+}
+
+// sync runs the role sync after an officer change, a failure is only logged as the scheduled sync will catch up
+func (s *Store) sync(ctx context.Context) {
+	_, err := s.SyncRoles(ctx)
+	if err != nil {
+		log.Printf("failed to sync officership roles: %+v", err)
+	}
 }
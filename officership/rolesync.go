@@ -0,0 +1,48 @@
+package officership
+
+import (
+	"fmt"
+
+	"github.com/ystv/web-auth/user"
+)
+
+type (
+	// RoleSyncAction is a change to a role membership needed for the roles to match the current officers
+	RoleSyncAction struct {
+		Action   RoleSyncActionType `db:"action" json:"action"`
+		RoleID   int                `db:"role_id" json:"roleID"`
+		RoleName string             `db:"role_name" json:"roleName"`
+		UserID   int                `db:"user_id" json:"userID"`
+		UserName string             `db:"user_name" json:"userName"`
+	}
+
+	// RoleSyncActionType is whether the role is being granted or revoked
+	RoleSyncActionType string
+)
+
+const (
+	// RoleSyncGrant is used when a user is a current officer of an officership with a role they don't have
+	RoleSyncGrant RoleSyncActionType = "grant"
+	// RoleSyncRevoke is used when a role granted by the sync is no longer backed by a current officership
+	RoleSyncRevoke RoleSyncActionType = "revoke"
+)
+
+// CurrentOfficerWhere is the condition for officership_members om to hold their officership right now, this takes
+// the dates into account so terms that start or end in the future are handled by the scheduled sync
+var CurrentOfficerWhere = currentOfficerWhereAs("om")
+
+// currentOfficerWhereAs is CurrentOfficerWhere for officership_members joined under another alias
+func currentOfficerWhereAs(alias string) string {
+	return fmt.Sprintf("(%[1]s.start_date IS NULL OR %[1]s.start_date <= NOW()) AND "+
+		"(%[1]s.end_date IS NULL OR %[1]s.end_date > NOW())", alias)
+}
+
+// previousOfficerWhereAs is the condition for officership_members joined as alias to have finished their term
+func previousOfficerWhereAs(alias string) string {
+	return alias + ".end_date <= NOW()"
+}
+
+// roleSyncHeldWhere is when role_members rm already gives the role for the sync, a current membership that was
+// granted by hand or by this sync. A membership only held through the membership sync still needs this sync to back
+// it, otherwise it would be revoked when the paid membership ends
+const roleSyncHeldWhere = "(rm.officership_sync OR NOT rm.membership_sync) AND " + user.CurrentRoleMemberWhere
@@ -0,0 +1,48 @@
+package officership
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystv/web-auth/user"
+)
+
+func TestRoleSyncSQL(t *testing.T) {
+	a := RoleSyncAction{RoleID: 3, UserID: 1}
+
+	t.Run("Held", func(t *testing.T) {
+		// an expired or future membership doesn't give the role, nor does one only the membership sync is backing
+		assert.Contains(t, roleSyncHeldWhere, user.CurrentRoleMemberWhere)
+		assert.Contains(t, roleSyncHeldWhere, "(rm.officership_sync OR NOT rm.membership_sync)")
+	})
+
+	t.Run("Grant", func(t *testing.T) {
+		sql, args, err := roleSyncGrantBuilder(a).ToSql()
+		require.NoError(t, err)
+
+		// a membership already there is taken on rather than the grant failing or being skipped
+		assert.Contains(t, sql, "ON CONFLICT (role_id, user_id) DO UPDATE SET officership_sync = true")
+		assert.Contains(t, sql, "ends_at = NULL")
+		assert.Equal(t, []interface{}{3, 1, true}, args)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		sql, args, err := roleSyncRevokeBuilder(a).ToSql()
+		require.NoError(t, err)
+
+		assert.Equal(t, "DELETE FROM people.role_members WHERE (role_id = $1 AND user_id = $2 AND "+
+			"officership_sync = $3 AND membership_sync = $4)", sql)
+		assert.Equal(t, []interface{}{3, 1, true, false}, args)
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		sql, args, err := roleSyncReleaseBuilder(a).ToSql()
+		require.NoError(t, err)
+
+		assert.Equal(t, "UPDATE people.role_members SET officership_sync = $1 WHERE (role_id = $2 AND "+
+			"user_id = $3 AND officership_sync = $4)", sql)
+		assert.Equal(t, []interface{}{false, 3, 1, true}, args)
+	})
+}
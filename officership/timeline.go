@@ -0,0 +1,137 @@
+package officership
+
+import (
+	"sort"
+	"time"
+)
+
+type (
+	// TermIssue is a problem found between two terms of the same officership, either a gap where nobody held it or
+	// an overlap where two people held it at the same time
+	TermIssue struct {
+		Type            TermIssueType     `json:"type"`
+		OfficershipID   int               `json:"officershipID"`
+		OfficershipName string            `json:"officershipName"`
+		Start           time.Time         `json:"start"`
+		End             time.Time         `json:"end"`
+		Previous        OfficershipMember `json:"previous"`
+		Next            OfficershipMember `json:"next"`
+	}
+
+	// TermIssueType is the kind of TermIssue
+	TermIssueType string
+)
+
+const (
+	TermGap     TermIssueType = "gap"
+	TermOverlap TermIssueType = "overlap"
+)
+
+// gapTolerance is how long an officership can be empty before it is reported, officers are normally ended at
+// midnight and started at an Admin meeting so a handover is never exact
+const gapTolerance = 7 * 24 * time.Hour
+
+// AcademicYear returns the year the academic year containing t started in, academic years start on 1st September
+func AcademicYear(t time.Time) int {
+	if t.Month() < time.September {
+		return t.Year() - 1
+	}
+
+	return t.Year()
+}
+
+// AcademicYearRange returns the start and the exclusive end of the academic year starting in year
+func AcademicYearRange(year int) (time.Time, time.Time) {
+	start := time.Date(year, time.September, 1, 0, 0, 0, 0, time.UTC)
+
+	return start, start.AddDate(1, 0, 0)
+}
+
+// TermsBetween returns the terms that were held at any point between start and the exclusive end,
+// a term without an end date is still being held
+func TermsBetween(members []OfficershipMember, start, end time.Time) []OfficershipMember {
+	terms := make([]OfficershipMember, 0)
+
+	for _, m := range members {
+		if m.StartDate.Valid && !m.StartDate.Time.Before(end) {
+			continue
+		}
+
+		if m.EndDate.Valid && m.EndDate.Time.Before(start) {
+			continue
+		}
+
+		terms = append(terms, m)
+	}
+
+	return terms
+}
+
+// FindTermIssues looks for gaps and overlaps between the terms of each officership, terms without a start date
+// are ignored as there is nothing to compare them with and overlaps of a co-held officership are expected
+func FindTermIssues(members []OfficershipMember) []TermIssue {
+	byOfficership := make(map[int][]OfficershipMember)
+	order := make([]int, 0)
+
+	for _, m := range members {
+		if !m.StartDate.Valid {
+			continue
+		}
+
+		if _, ok := byOfficership[m.OfficerID]; !ok {
+			order = append(order, m.OfficerID)
+		}
+
+		byOfficership[m.OfficerID] = append(byOfficership[m.OfficerID], m)
+	}
+
+	issues := make([]TermIssue, 0)
+
+	for _, officerID := range order {
+		terms := byOfficership[officerID]
+
+		sort.SliceStable(terms, func(i, j int) bool {
+			return terms[i].StartDate.Time.Before(terms[j].StartDate.Time)
+		})
+
+		// latest is the term that ends last so far, as a short term can sit inside a longer one
+		latest := terms[0]
+
+		for _, next := range terms[1:] {
+			switch {
+			case next.CoHeld && (!latest.EndDate.Valid || latest.EndDate.Time.After(next.StartDate.Time)):
+			case !latest.EndDate.Valid || latest.EndDate.Time.After(next.StartDate.Time):
+				end := latest.EndDate.Time
+				if !latest.EndDate.Valid || (next.EndDate.Valid && next.EndDate.Time.Before(end)) {
+					end = next.EndDate.Time
+				}
+
+				issues = append(issues, TermIssue{
+					Type:            TermOverlap,
+					OfficershipID:   officerID,
+					OfficershipName: next.OfficershipName,
+					Start:           next.StartDate.Time,
+					End:             end,
+					Previous:        latest,
+					Next:            next,
+				})
+			case next.StartDate.Time.Sub(latest.EndDate.Time) > gapTolerance:
+				issues = append(issues, TermIssue{
+					Type:            TermGap,
+					OfficershipID:   officerID,
+					OfficershipName: next.OfficershipName,
+					Start:           latest.EndDate.Time,
+					End:             next.StartDate.Time,
+					Previous:        latest,
+					Next:            next,
+				})
+			}
+
+			if latest.EndDate.Valid && (!next.EndDate.Valid || next.EndDate.Time.After(latest.EndDate.Time)) {
+				latest = next
+			}
+		}
+	}
+
+	return issues
+}
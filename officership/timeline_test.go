@@ -0,0 +1,98 @@
+package officership
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v4"
+)
+
+func date(year int, month time.Month, day int) null.Time {
+	return null.TimeFrom(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+func TestAcademicYear(t *testing.T) {
+	assert.Equal(t, 2015, AcademicYear(time.Date(2015, time.September, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 2015, AcademicYear(time.Date(2016, time.August, 31, 23, 0, 0, 0, time.UTC)))
+	assert.Equal(t, 2014, AcademicYear(time.Date(2015, time.March, 10, 0, 0, 0, 0, time.UTC)))
+
+	start, end := AcademicYearRange(2015)
+	assert.Equal(t, date(2015, time.September, 1).Time, start)
+	assert.Equal(t, date(2016, time.September, 1).Time, end)
+}
+
+func TestTermsBetween(t *testing.T) {
+	members := []OfficershipMember{
+		{OfficershipMemberID: 1, StartDate: date(2013, time.March, 1), EndDate: date(2014, time.March, 1)},
+		{OfficershipMemberID: 2, StartDate: date(2015, time.March, 1), EndDate: date(2016, time.March, 1)},
+		{OfficershipMemberID: 3, StartDate: date(2016, time.March, 1)},
+		{OfficershipMemberID: 4, StartDate: date(2017, time.March, 1)},
+	}
+
+	start, end := AcademicYearRange(2015)
+
+	terms := TermsBetween(members, start, end)
+
+	assert.Len(t, terms, 2)
+	assert.Equal(t, 2, terms[0].OfficershipMemberID)
+	assert.Equal(t, 3, terms[1].OfficershipMemberID)
+}
+
+func TestFindTermIssues(t *testing.T) {
+	members := []OfficershipMember{
+		// handed over on the same day
+		{OfficershipMemberID: 1, OfficerID: 1, StartDate: date(2014, time.March, 1), EndDate: date(2015, time.March, 1)},
+		{OfficershipMemberID: 2, OfficerID: 1, StartDate: date(2015, time.March, 1), EndDate: date(2016, time.March, 1)},
+		// nobody for a month
+		{OfficershipMemberID: 3, OfficerID: 1, StartDate: date(2016, time.April, 1), EndDate: date(2017, time.March, 1)},
+		// started before the previous term ended
+		{OfficershipMemberID: 4, OfficerID: 1, StartDate: date(2017, time.January, 1)},
+		// another officership with no end date being overlapped
+		{OfficershipMemberID: 5, OfficerID: 2, StartDate: date(2014, time.March, 1)},
+		{OfficershipMemberID: 6, OfficerID: 2, StartDate: date(2015, time.March, 1), EndDate: date(2016, time.March, 1)},
+		// no start date
+		{OfficershipMemberID: 7, OfficerID: 2},
+	}
+
+	issues := FindTermIssues(members)
+
+	assert.Len(t, issues, 3)
+
+	assert.Equal(t, TermGap, issues[0].Type)
+	assert.Equal(t, 2, issues[0].Previous.OfficershipMemberID)
+	assert.Equal(t, 3, issues[0].Next.OfficershipMemberID)
+	assert.Equal(t, date(2016, time.March, 1).Time, issues[0].Start)
+	assert.Equal(t, date(2016, time.April, 1).Time, issues[0].End)
+
+	assert.Equal(t, TermOverlap, issues[1].Type)
+	assert.Equal(t, 3, issues[1].Previous.OfficershipMemberID)
+	assert.Equal(t, 4, issues[1].Next.OfficershipMemberID)
+	assert.Equal(t, date(2017, time.January, 1).Time, issues[1].Start)
+	assert.Equal(t, date(2017, time.March, 1).Time, issues[1].End)
+
+	assert.Equal(t, TermOverlap, issues[2].Type)
+	assert.Equal(t, 2, issues[2].OfficershipID)
+	assert.Equal(t, 5, issues[2].Previous.OfficershipMemberID)
+	assert.Equal(t, date(2016, time.March, 1).Time, issues[2].End)
+}
+
+func TestFindTermIssuesCoHeld(t *testing.T) {
+	members := []OfficershipMember{
+		// two officers share the officership on purpose
+		{OfficershipMemberID: 1, OfficerID: 3, StartDate: date(2020, time.March, 1), EndDate: date(2021, time.March, 1),
+			CoHeld: true},
+		{OfficershipMemberID: 2, OfficerID: 3, StartDate: date(2020, time.April, 1), EndDate: date(2021, time.April, 1),
+			CoHeld: true},
+		// nobody holds it for a year
+		{OfficershipMemberID: 3, OfficerID: 3, StartDate: date(2022, time.April, 1), CoHeld: true},
+	}
+
+	issues := FindTermIssues(members)
+
+	require.Len(t, issues, 1)
+	assert.Equal(t, TermGap, issues[0].Type)
+	assert.Equal(t, 2, issues[0].Previous.OfficershipMemberID)
+	assert.Equal(t, 3, issues[0].Next.OfficershipMemberID)
+}
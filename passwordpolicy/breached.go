@@ -0,0 +1,252 @@
+package passwordpolicy
+
+import (
+	"bufio"
+	"bytes"
+	//nolint:gosec
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The breached passwords are a local copy of the Have I Been Pwned Pwned Passwords SHA-1 list. Lookups work the same
+// way as the range API, only the first 5 characters of the hash are used to find the range and the rest of the hash
+// is compared here, so the list can be swapped for a range server without changing anything else.
+
+const (
+	// prefixLength is the number of characters of the hash a range is looked up by
+	prefixLength = 5
+	// hashLength is the number of characters in a SHA-1 hash
+	hashLength = 40
+	// maxLineLength is longer than any line in the list, a hash, a colon and the count
+	maxLineLength = 128
+)
+
+type (
+	// Ranger returns the hash suffixes and how many times they've been seen for a 5 character prefix
+	Ranger interface {
+		Range(prefix string) ([]Suffix, error)
+	}
+
+	// Suffix is the rest of a breached hash after the prefix and how many times it has been seen
+	Suffix struct {
+		Suffix string
+		Count  int
+	}
+
+	// rangeFile is the list downloaded as one file, every line is a full hash and the lines are sorted by hash
+	rangeFile struct {
+		f    *os.File
+		size int64
+	}
+
+	// rangeDir is the list downloaded as a file for each prefix, named after the prefix, every line is the suffix
+	rangeDir struct {
+		dir string
+	}
+)
+
+// OpenRanger opens the breached password list at path, which is either the one sorted file or a directory of range
+// files
+func OpenRanger(path string) (Ranger, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+
+	if info.IsDir() {
+		return &rangeDir{dir: path}, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+
+	return &rangeFile{f: f, size: info.Size()}, nil
+}
+
+// Breached returns how many times the password has been seen in breaches, 0 if it hasn't
+func Breached(r Ranger, password string) (int, error) {
+	//nolint:gosec
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := r.Range(hash[:prefixLength])
+	if err != nil {
+		return 0, fmt.Errorf("failed to get breached range: %w", err)
+	}
+
+	for _, s := range suffixes {
+		if s.Suffix == hash[prefixLength:] {
+			return s.Count, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// Range reads the prefix's file, a missing file is an empty range
+func (r *rangeDir) Range(prefix string) ([]Suffix, error) {
+	prefix, err := checkPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(r.dir, prefix+".txt"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to open range: %w", err)
+	}
+
+	defer f.Close()
+
+	var suffixes []Suffix
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		s, ok := parseLine(scanner.Text())
+		if ok {
+			suffixes = append(suffixes, s)
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read range: %w", err)
+	}
+
+	return suffixes, nil
+}
+
+// Range binary searches the file for the first line of the prefix then reads lines until the prefix changes, the
+// file is tens of gigabytes so only the lines needed are read
+func (r *rangeFile) Range(prefix string) ([]Suffix, error) {
+	prefix, err := checkPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	lo, hi := int64(0), r.size
+
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		_, line, err := r.lineAfter(mid)
+		if err != nil {
+			return nil, err
+		}
+
+		if line != "" && strings.ToUpper(line[:min(prefixLength, len(line))]) < prefix {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	start, _, err := r.lineAfter(lo)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(r.f, start, r.size-start))
+
+	var suffixes []Suffix
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read range: %w", err)
+		}
+
+		line = strings.TrimSpace(line)
+		if len(line) < prefixLength || strings.ToUpper(line[:prefixLength]) != prefix {
+			break
+		}
+
+		s, ok := parseLine(line[prefixLength:])
+		if ok {
+			suffixes = append(suffixes, s)
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+
+	return suffixes, nil
+}
+
+// lineAfter returns where the first line starting at or after offset starts and the line, the line is empty at the
+// end of the file
+func (r *rangeFile) lineAfter(offset int64) (int64, string, error) {
+	start := offset
+
+	if offset > 0 {
+		// the line only starts at offset if the character before is the end of the last one
+		buf := make([]byte, maxLineLength)
+
+		n, err := r.f.ReadAt(buf, offset-1)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, "", fmt.Errorf("failed to read breached password list: %w", err)
+		}
+
+		i := bytes.IndexByte(buf[:n], '\n')
+		if i < 0 {
+			return r.size, "", nil
+		}
+
+		start = offset + int64(i)
+	}
+
+	buf := make([]byte, maxLineLength)
+
+	n, err := r.f.ReadAt(buf, start)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, "", fmt.Errorf("failed to read breached password list: %w", err)
+	}
+
+	line, _, _ := bytes.Cut(buf[:n], []byte{'\n'})
+
+	return start, strings.TrimSpace(string(line)), nil
+}
+
+// parseLine parses a SUFFIX:COUNT line, the count is optional
+func parseLine(line string) (Suffix, bool) {
+	suffix, count, _ := strings.Cut(strings.TrimSpace(line), ":")
+	if len(suffix) != hashLength-prefixLength {
+		return Suffix{}, false
+	}
+
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		n = 1
+	}
+
+	return Suffix{Suffix: strings.ToUpper(suffix), Count: n}, true
+}
+
+// checkPrefix returns the prefix in upper case if it is 5 hex characters
+func checkPrefix(prefix string) (string, error) {
+	prefix = strings.ToUpper(prefix)
+
+	if len(prefix) != prefixLength {
+		return "", fmt.Errorf("prefix must be %d characters", prefixLength)
+	}
+
+	_, err := hex.DecodeString(prefix + "0")
+	if err != nil {
+		return "", fmt.Errorf("prefix must be hex: %w", err)
+	}
+
+	return prefix, nil
+}
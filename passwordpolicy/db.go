@@ -0,0 +1,169 @@
+package passwordpolicy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/utils"
+)
+
+// usedBefore returns if the password is the user's current one or one of the ones before it, the old hashes were
+// made with the same salt so the new password is hashed once and compared
+func (s *Store) usedBefore(ctx context.Context, u user.User, password string) (bool, error) {
+	var current struct {
+		Password string `db:"password"`
+		Salt     string `db:"salt"`
+	}
+
+	builder := utils.PSQL().Select("COALESCE(password, '') AS password", "COALESCE(salt, '') AS salt").
+		From("people.users").
+		Where(sq.Eq{"user_id": u.UserID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for usedBefore: %w", err))
+	}
+
+	err = s.db.GetContext(ctx, &current, sql, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to get current password: %w", err)
+	}
+
+	hash := utils.HashPass(current.Salt + password)
+
+	if hash == current.Password {
+		return true, nil
+	}
+
+	if s.policy.History < 2 {
+		return false, nil
+	}
+
+	var history []string
+
+	builder = utils.PSQL().Select("password").
+		From("people.password_history").
+		Where(sq.Eq{"user_id": u.UserID}).
+		OrderBy("replaced_at DESC").
+		Limit(uint64(s.policy.History - 1))
+
+	sql, args, err = builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for usedBefore: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &history, sql, args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to get password history: %w", err)
+	}
+
+	for _, old := range history {
+		if old == hash {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// expiries is every enabled user with a role that has a password maximum age, each with the role that has the
+// shortest, roles included by another count the same as they do for permissions. Given user ids, only the roles of
+// those users are walked
+func expiries(userIDs ...int) sq.SelectBuilder {
+	members := sq.And{sq.Expr(user.CurrentRoleMemberWhere)}
+	if len(userIDs) > 0 {
+		members = append(members, sq.Eq{"rm.user_id": userIDs})
+	}
+
+	membersWhere, args, err := members.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for expiries: %w", err))
+	}
+
+	return sq.Select("DISTINCT ON (u.user_id) u.user_id", "u.first_name", "u.email", "u.language", "r.role_id",
+		"r.name AS role_name", "r.password_max_age_days", "u.password_changed_at", "u.password_expiry_warned_at",
+		"u.password_changed_at + r.password_max_age_days * INTERVAL '1 day' AS expires_at").
+		Prefix(`WITH RECURSIVE user_roles(user_id, role_id) AS (
+			SELECT rm.user_id, rm.role_id
+			FROM people.role_members rm
+			WHERE `+membersWhere+`
+			UNION
+			SELECT ur.user_id, ri.included_role_id
+			FROM people.role_inclusions ri
+			INNER JOIN user_roles ur ON ur.role_id = ri.role_id
+		)`, args...).
+		From("people.users u").
+		InnerJoin("user_roles ur ON ur.user_id = u.user_id").
+		InnerJoin("people.roles r ON r.role_id = ur.role_id").
+		Where(sq.NotEq{"r.password_max_age_days": nil}).
+		Where(sq.Eq{"u.enabled": true, "u.deleted_at": nil}).
+		OrderBy("u.user_id", "r.password_max_age_days", "r.name")
+}
+
+func (s *Store) getExpiry(ctx context.Context, u user.User) (Expiry, error) {
+	var e []Expiry
+
+	builder := utils.PSQL().Select("*").
+		FromSelect(expiries(u.UserID), "e")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getExpiry: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &e, sql, args...)
+	if err != nil {
+		return Expiry{}, fmt.Errorf("failed to get password expiry: %w", err)
+	}
+
+	// none of the user's roles have a maximum age
+	if len(e) == 0 {
+		return Expiry{}, nil
+	}
+
+	return e[0], nil
+}
+
+func (s *Store) getExpiring(ctx context.Context, before time.Time) ([]Expiry, error) {
+	var e []Expiry
+
+	builder := utils.PSQL().Select("*").
+		FromSelect(expiries(), "e").
+		Where(sq.LtOrEq{"e.expires_at": before}).
+		Where(sq.Eq{"e.password_expiry_warned_at": nil}).
+		OrderBy("e.expires_at")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getExpiring: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &e, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expiring passwords: %w", err)
+	}
+
+	return e, nil
+}
+
+func (s *Store) setExpiryWarned(ctx context.Context, e Expiry) error {
+	builder := utils.PSQL().Update("people.users").
+		Set("password_expiry_warned_at", sq.Expr("NOW()")).
+		Where(sq.Eq{"user_id": e.UserID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for setExpiryWarned: %w", err))
+	}
+
+	_, err = s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to set password expiry warned: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,272 @@
+package passwordpolicy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/utils"
+)
+
+//go:generate mockgen -destination mocks/mock_passwordpolicy.go -package mock_passwordpolicy github.com/ystv/web-auth/passwordpolicy Repo
+
+type (
+	Repo interface {
+		Check(context.Context, user.User, string) ([]string, error)
+		Generate(context.Context, user.User) (string, error)
+		GetPolicy() Policy
+		GetExpiry(context.Context, user.User) (Expiry, error)
+		GetExpiring(context.Context) ([]Expiry, error)
+		SetExpiryWarned(context.Context, Expiry) error
+	}
+
+	// Store stores the dependencies
+	Store struct {
+		db       *sqlx.DB
+		policy   Policy
+		breached Ranger
+	}
+
+	// Policy is what every new password has to meet, a zero value turns a check off
+	Policy struct {
+		// MinLength is the fewest characters a password can have
+		MinLength int
+		// MinScore is the lowest strength score from 0 to 4 a password can have
+		MinScore int
+		// AllowPersonal lets a password contain the user's names, usernames or email
+		AllowPersonal bool
+		// History is how many of the user's last passwords, including the current one, can't be used again
+		History int
+		// BreachedFile is the local copy of the Have I Been Pwned passwords, either one file or a directory of ranges
+		BreachedFile string
+		// ExpiryWarning is how long before a password expires that the user is warned
+		ExpiryWarning time.Duration
+		// ExpiryGrace is how long after a password expires that the user can still log in with it
+		ExpiryGrace time.Duration
+	}
+
+	// Expiry is when a user's password has to be changed by, it is set by the role with the shortest maximum age
+	// and the zero value is a password that doesn't expire
+	Expiry struct {
+		UserID     int         `db:"user_id" json:"userID"`
+		Firstname  string      `db:"first_name" json:"firstName"`
+		Email      string      `db:"email" json:"email"`
+		Language   null.String `db:"language" json:"-"`
+		RoleID     int         `db:"role_id" json:"roleID"`
+		RoleName   string      `db:"role_name" json:"roleName"`
+		MaxAgeDays int         `db:"password_max_age_days" json:"maxAgeDays"`
+		ChangedAt  time.Time   `db:"password_changed_at" json:"changedAt"`
+		WarnedAt   null.Time   `db:"password_expiry_warned_at" json:"warnedAt"`
+		ExpiresAt  time.Time   `db:"expires_at" json:"expiresAt"`
+		WarnFrom   time.Time   `db:"-" json:"warnFrom"`
+		LocksAt    time.Time   `db:"-" json:"locksAt"`
+	}
+
+	// ExpiryState is where a password is in its life
+	ExpiryState string
+)
+
+const (
+	// Current is a password that doesn't expire or isn't close to expiring
+	Current ExpiryState = ""
+	// Expiring is a password that expires within the warning
+	Expiring ExpiryState = "expiring"
+	// Expired is a password that has expired but can still be logged in with during the grace period
+	Expired ExpiryState = "expired"
+	// Locked is a password that has expired and the grace period has passed, it has to be reset to log in
+	Locked ExpiryState = "locked"
+)
+
+const (
+	// minPersonalLength stops short names like "Al" ruling out every password with them in
+	minPersonalLength = 3
+	// generateAttempts is how many random passwords are tried before giving up
+	generateAttempts = 10
+)
+
+var _ Repo = &Store{}
+
+// NewPasswordPolicyRepo stores our dependency, the breached password list is opened now so a missing file is found
+// at startup
+func NewPasswordPolicyRepo(db *sqlx.DB, policy Policy) (*Store, error) {
+	s := &Store{
+		db:     db,
+		policy: policy,
+	}
+
+	if len(policy.BreachedFile) > 0 {
+		r, err := OpenRanger(policy.BreachedFile)
+		if err != nil {
+			return nil, err
+		}
+
+		s.breached = r
+	}
+
+	return s, nil
+}
+
+// GetPolicy returns the policy passwords are checked against
+func (s *Store) GetPolicy() Policy {
+	return s.policy
+}
+
+// Check returns every way the password doesn't meet the policy for the user, none if it does, the error is only set
+// if the password couldn't be checked
+func (s *Store) Check(ctx context.Context, u user.User, password string) ([]string, error) {
+	problems := s.policy.check(u, password)
+
+	if s.breached != nil {
+		count, err := Breached(s.breached, password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check breached passwords: %w", err)
+		}
+
+		if count > 0 {
+			problems = append(problems, "password has appeared in a data breach, choose one that hasn't")
+		}
+	}
+
+	if s.policy.History > 0 && u.UserID > 0 {
+		used, err := s.usedBefore(ctx, u, password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check password history: %w", err)
+		}
+
+		if used {
+			problems = append(problems, fmt.Sprintf("password can't be one of your last %d passwords",
+				s.policy.History))
+		}
+	}
+
+	return problems, nil
+}
+
+// Generate returns a random password for the user that meets the policy, it is at least as long as the policy needs
+func (s *Store) Generate(ctx context.Context, u user.User) (string, error) {
+	length := max(int(utils.PasswordLength), s.policy.MinLength)
+
+	for range generateAttempts {
+		password, err := utils.GenerateRandomLength(length, utils.GeneratePassword)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate password: %w", err)
+		}
+
+		problems, err := s.Check(ctx, u, password)
+		if err != nil {
+			return "", fmt.Errorf("failed to check generated password: %w", err)
+		}
+
+		if len(problems) == 0 {
+			return password, nil
+		}
+	}
+
+	return "", fmt.Errorf("failed to generate a password that meets the policy in %d attempts", generateAttempts)
+}
+
+// GetExpiry returns when the user's password expires, the zero value if none of their roles have a maximum age
+func (s *Store) GetExpiry(ctx context.Context, u user.User) (Expiry, error) {
+	e, err := s.getExpiry(ctx, u)
+	if err != nil {
+		return Expiry{}, err
+	}
+
+	return s.withPolicy(e), nil
+}
+
+// GetExpiring returns the users whose passwords expire within the warning and haven't been warned since they last
+// changed it
+func (s *Store) GetExpiring(ctx context.Context) ([]Expiry, error) {
+	expiring, err := s.getExpiring(ctx, time.Now().Add(s.policy.ExpiryWarning))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, e := range expiring {
+		expiring[i] = s.withPolicy(e)
+	}
+
+	return expiring, nil
+}
+
+// SetExpiryWarned records that the user has been warned, it is cleared when they change their password
+func (s *Store) SetExpiryWarned(ctx context.Context, e Expiry) error {
+	return s.setExpiryWarned(ctx, e)
+}
+
+// State returns where the password is in its life at now
+func (e Expiry) State(now time.Time) ExpiryState {
+	switch {
+	case e.MaxAgeDays == 0 || now.Before(e.WarnFrom):
+		return Current
+	case now.Before(e.ExpiresAt):
+		return Expiring
+	case now.Before(e.LocksAt):
+		return Expired
+	default:
+		return Locked
+	}
+}
+
+// withPolicy sets when the warning starts and the grace period ends
+func (s *Store) withPolicy(e Expiry) Expiry {
+	if e.MaxAgeDays == 0 {
+		return e
+	}
+
+	e.WarnFrom = e.ExpiresAt.Add(-s.policy.ExpiryWarning)
+	e.LocksAt = e.ExpiresAt.Add(s.policy.ExpiryGrace)
+
+	return e
+}
+
+// check returns the problems that don't need the database or the breached passwords
+func (p Policy) check(u user.User, password string) []string {
+	var problems []string
+
+	if len([]rune(password)) < p.MinLength {
+		problems = append(problems, fmt.Sprintf("password must be at least %d characters long", p.MinLength))
+	}
+
+	personal := personalTerms(u)
+
+	if !p.AllowPersonal {
+		lower := strings.ToLower(password)
+
+		for _, term := range personal {
+			if strings.Contains(lower, term) {
+				problems = append(problems, "password can't contain your name, username or email")
+
+				break
+			}
+		}
+	}
+
+	if Score(password, personal...) < p.MinScore {
+		problems = append(problems, "password is too easy to guess, try adding more words or making it longer")
+	}
+
+	return problems
+}
+
+// personalTerms returns the user's details in lower case that are long enough to matter
+func personalTerms(u user.User) []string {
+	localPart, _, _ := strings.Cut(u.Email, "@")
+
+	var terms []string
+
+	for _, term := range []string{u.Username, u.UniversityUsername, u.Firstname, u.Nickname, u.Lastname, localPart} {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if len([]rune(term)) >= minPersonalLength {
+			terms = append(terms, term)
+		}
+	}
+
+	return terms
+}
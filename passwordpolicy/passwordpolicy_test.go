@@ -0,0 +1,197 @@
+package passwordpolicy
+
+import (
+	"context"
+	//nolint:gosec
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystv/web-auth/user"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		password string
+		want     int
+	}{
+		{password: "password", want: 0},
+		{password: "qwertyuiop", want: 0},
+		{password: "abc123", want: 0},
+		{password: "aaaaaaaaaaaa", want: 0},
+		{password: "Password1!", want: 1},
+		{password: "Tr0ub4dor&3", want: 4},
+		{password: "correcthorsebatterystaple", want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			assert.Equal(t, tt.want, Score(tt.password))
+		})
+	}
+}
+
+func TestScoreUserInputs(t *testing.T) {
+	assert.Less(t, Score("jamiesmith", "jamie", "smith"), Score("jamiesmith"))
+}
+
+func TestCheck(t *testing.T) {
+	s, err := NewPasswordPolicyRepo(nil, Policy{MinLength: 10, MinScore: 3})
+	require.NoError(t, err)
+
+	u := user.User{Username: "jsmith", Firstname: "Jamie", Lastname: "Smith", Email: "jamie.smith@york.ac.uk"}
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{name: "Strong", password: "9Gk#m2Lp!x7Q"},
+		{name: "Short", password: "9Gk#m2L", want: []string{"password must be at least 10 characters long",
+			"password is too easy to guess, try adding more words or making it longer"}},
+		{name: "Personal", password: "9Gk#SMITH!x7Q",
+			want: []string{"password can't contain your name, username or email"}},
+		{name: "Weak", password: "password1234", want: []string{
+			"password is too easy to guess, try adding more words or making it longer"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems, err := s.Check(context.Background(), u, tt.password)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, problems)
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	s, err := NewPasswordPolicyRepo(nil, Policy{MinLength: 16, MinScore: 4})
+	require.NoError(t, err)
+
+	password, err := s.Generate(context.Background(), user.User{})
+	require.NoError(t, err)
+	assert.Len(t, password, 16)
+}
+
+func TestBreached(t *testing.T) {
+	hash := func(password string) string {
+		//nolint:gosec
+		sum := sha1.Sum([]byte(password))
+
+		return strings.ToUpper(hex.EncodeToString(sum[:]))
+	}
+
+	breached := []string{"password", "letmein", "hunter2", "correct horse"}
+
+	lines := make([]string, 0, len(breached)+2)
+	for i, p := range breached {
+		lines = append(lines, hash(p)+":"+strings.Repeat("9", i+1))
+	}
+
+	// the neighbours of a breached hash mustn't match it
+	lines = append(lines, hash("hunter2")[:5]+strings.Repeat("0", 35)+":1",
+		hash("hunter2")[:5]+strings.Repeat("F", 35)+":1")
+
+	dir := t.TempDir()
+
+	t.Run("File", func(t *testing.T) {
+		sorted := append([]string(nil), lines...)
+		slices.Sort(sorted)
+
+		path := filepath.Join(dir, "pwned-passwords-sha1-ordered-by-hash.txt")
+		require.NoError(t, os.WriteFile(path, []byte(strings.Join(sorted, "\r\n")+"\r\n"), 0o600))
+
+		r, err := OpenRanger(path)
+		require.NoError(t, err)
+
+		assertBreached(t, r, breached)
+	})
+
+	t.Run("Directory", func(t *testing.T) {
+		ranges := filepath.Join(dir, "ranges")
+		require.NoError(t, os.Mkdir(ranges, 0o700))
+
+		for _, line := range lines {
+			f, err := os.OpenFile(filepath.Join(ranges, line[:5]+".txt"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+			require.NoError(t, err)
+
+			_, err = f.WriteString(line[5:] + "\n")
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+		}
+
+		r, err := OpenRanger(ranges)
+		require.NoError(t, err)
+
+		assertBreached(t, r, breached)
+	})
+}
+
+func assertBreached(t *testing.T, r Ranger, breached []string) {
+	t.Helper()
+
+	for i, p := range breached {
+		count, err := Breached(r, p)
+		require.NoError(t, err)
+
+		want, _ := strconv.Atoi(strings.Repeat("9", i+1))
+		assert.Equal(t, want, count, p)
+	}
+
+	for _, p := range []string{"9Gk#m2Lp!x7Q", "hunter3", ""} {
+		count, err := Breached(r, p)
+		require.NoError(t, err)
+		assert.Zero(t, count, p)
+	}
+}
+
+func TestExpiryState(t *testing.T) {
+	s, err := NewPasswordPolicyRepo(nil, Policy{ExpiryWarning: 14 * 24 * time.Hour, ExpiryGrace: 7 * 24 * time.Hour})
+	require.NoError(t, err)
+
+	expiresAt := time.Date(2026, time.October, 1, 12, 0, 0, 0, time.UTC)
+	e := s.withPolicy(Expiry{MaxAgeDays: 365, ExpiresAt: expiresAt})
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want ExpiryState
+	}{
+		{name: "Current", now: expiresAt.AddDate(0, -1, 0), want: Current},
+		{name: "Expiring", now: expiresAt.AddDate(0, 0, -3), want: Expiring},
+		{name: "Expired", now: expiresAt.AddDate(0, 0, 3), want: Expired},
+		{name: "Locked", now: expiresAt.AddDate(0, 0, 8), want: Locked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, e.State(tt.now))
+		})
+	}
+
+	assert.Equal(t, Current, Expiry{}.State(expiresAt), "a password without a maximum age never expires")
+}
+
+func TestExpiriesSQL(t *testing.T) {
+	sql, args, err := expiries(5).ToSql()
+	require.NoError(t, err)
+
+	// the recursive walk starts from the one user's current memberships rather than every user's
+	assert.Contains(t, sql, "WHERE ("+user.CurrentRoleMemberWhere+" AND rm.user_id IN (?))\n")
+	assert.Equal(t, []interface{}{5, true}, args)
+
+	sql, args, err = expiries().ToSql()
+	require.NoError(t, err)
+
+	assert.Contains(t, sql, "WHERE ("+user.CurrentRoleMemberWhere+")\n")
+	assert.Equal(t, []interface{}{true}, args)
+}
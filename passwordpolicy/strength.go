@@ -0,0 +1,427 @@
+package passwordpolicy
+
+import (
+	_ "embed"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// The strength score works the same way as zxcvbn, the password is split into the patterns an attacker would try
+// first (common passwords, the user's own details, repeats, sequences, keyboard runs and years) and whatever is left
+// is brute forced. The split needing the fewest guesses is how strong the password is.
+
+// scoreThresholds are the number of guesses, as a power of ten, a password needs to reach each score above 0
+//
+//nolint:gochecknoglobals
+var scoreThresholds = []float64{3, 6, 8, 10}
+
+// keyboardRows are runs of keys next to each other that are typed as if they were random
+//
+//nolint:gochecknoglobals
+var keyboardRows = []string{
+	"`1234567890-=",
+	"qwertyuiop[]\\",
+	"asdfghjkl;'#",
+	"zxcvbnm,./",
+	"qazwsxedcrfvtgbyhnujmikolp",
+}
+
+// leet are the common substitutions of letters
+//
+//nolint:gochecknoglobals
+var leet = map[rune]rune{
+	'4': 'a', '@': 'a', '8': 'b', '(': 'c', '3': 'e', '6': 'g', '1': 'i', '!': 'i', '|': 'i', '0': 'o', '$': 's',
+	'5': 's', '7': 't', '+': 't', '2': 'z',
+}
+
+//go:embed common.txt
+var commonList string
+
+// common ranks the common passwords and words, the most common is 1
+//
+//nolint:gochecknoglobals
+var common = func() map[string]int {
+	words := strings.Fields(commonList)
+	ranks := make(map[string]int, len(words))
+
+	for i, w := range words {
+		if _, ok := ranks[w]; !ok {
+			ranks[w] = i + 1
+		}
+	}
+
+	return ranks
+}()
+
+const (
+	// bruteforceCardinality is the guesses per character of a part of the password that isn't a pattern
+	bruteforceCardinality = 10
+	// minYearSpace stops years close to now being counted as only a handful of guesses
+	minYearSpace = 20
+	// referenceYear is the year the guesses for years are counted from
+	referenceYear = 2026
+	// minGuessesPerPart is log10 of the guesses added for every extra part, so many short parts aren't weak
+	minGuessesPerPart = 4
+	// maxLength is how much of a password is looked at, anything longer is already too strong to guess
+	maxLength = 72
+)
+
+// match is a part of the password, from i to j inclusive, and log10 of the guesses needed to find it
+type match struct {
+	i, j    int
+	guesses float64
+}
+
+// Score returns how hard the password is to guess from 0, guessed almost straight away, to 4, very unlikely to be
+// guessed, userInputs are the user's own details which are treated as the most common passwords
+func Score(password string, userInputs ...string) int {
+	guesses := Guesses(password, userInputs...)
+
+	for score, threshold := range scoreThresholds {
+		if guesses < threshold {
+			return score
+		}
+	}
+
+	return len(scoreThresholds)
+}
+
+// Guesses returns log10 of the number of guesses needed to find the password
+func Guesses(password string, userInputs ...string) float64 {
+	runes := []rune(password)
+	if len(runes) > maxLength {
+		runes = runes[:maxLength]
+	}
+
+	n := len(runes)
+
+	if n == 0 {
+		return 0
+	}
+
+	inputs := make(map[string]int, len(userInputs))
+
+	for i, input := range userInputs {
+		input = strings.ToLower(input)
+		if len(input) > 0 {
+			if _, ok := inputs[input]; !ok {
+				inputs[input] = i + 1
+			}
+		}
+	}
+
+	// matches are looked up by the rune they end on
+	ends := make([][]match, n)
+	for _, m := range findMatches(runes, inputs) {
+		ends[m.j] = append(ends[m.j], m)
+	}
+
+	// best[j][k] is the fewest guesses for the first j runes split into k parts
+	best := make([]map[int]float64, n+1)
+	for j := range best {
+		best[j] = map[int]float64{}
+	}
+
+	best[0][0] = 0
+
+	for j := 1; j <= n; j++ {
+		for start := 0; start < j; start++ {
+			candidates := []float64{bruteforceGuesses(j - start)}
+
+			for _, m := range ends[j-1] {
+				if m.i == start {
+					candidates = append(candidates, m.guesses)
+				}
+			}
+
+			for k, prev := range best[start] {
+				for _, g := range candidates {
+					total := prev + g
+					if current, ok := best[j][k+1]; !ok || total < current {
+						best[j][k+1] = total
+					}
+				}
+			}
+		}
+	}
+
+	// the attacker also has to guess how many parts there are and how they are arranged
+	result := math.Inf(1)
+
+	for k, g := range best[n] {
+		total := g + logFactorial(k)
+		if k > 1 {
+			total = logAdd(total, minGuessesPerPart*float64(k-1))
+		}
+
+		result = math.Min(result, total)
+	}
+
+	return result
+}
+
+// findMatches returns every part of the password that is a pattern
+func findMatches(runes []rune, inputs map[string]int) []match {
+	var matches []match
+
+	matches = append(matches, dictionaryMatches(runes, inputs)...)
+	matches = append(matches, repeatMatches(runes)...)
+	matches = append(matches, sequenceMatches(runes)...)
+	matches = append(matches, keyboardMatches(runes)...)
+	matches = append(matches, yearMatches(runes)...)
+
+	return matches
+}
+
+// dictionaryMatches finds the common words and the user's details, including ones with capitals or substitutions
+func dictionaryMatches(runes []rune, inputs map[string]int) []match {
+	var matches []match
+
+	lower := []rune(strings.ToLower(string(runes)))
+	unleet := make([]rune, len(lower))
+
+	for i, r := range lower {
+		if sub, ok := leet[r]; ok {
+			unleet[i] = sub
+		} else {
+			unleet[i] = r
+		}
+	}
+
+	for i := range runes {
+		for j := i + 2; j < len(runes); j++ {
+			rank, ok := lookup(string(lower[i:j+1]), inputs)
+			substituted := false
+
+			if !ok {
+				rank, ok = lookup(string(unleet[i:j+1]), inputs)
+				substituted = true
+			}
+
+			if !ok {
+				continue
+			}
+
+			guesses := math.Log10(float64(rank)) + uppercaseVariations(runes[i:j+1])
+			if substituted {
+				guesses += math.Log10(2)
+			}
+
+			matches = append(matches, match{i: i, j: j, guesses: math.Max(guesses, 0)})
+		}
+	}
+
+	return matches
+}
+
+// lookup returns the rank of the word in the user's details then the common passwords
+func lookup(word string, inputs map[string]int) (int, bool) {
+	rank, ok := inputs[word]
+	if ok {
+		return rank, true
+	}
+
+	rank, ok = common[word]
+
+	return rank, ok
+}
+
+// uppercaseVariations returns log10 of the ways the capitals in a word could have been chosen
+func uppercaseVariations(word []rune) float64 {
+	var upper, lower int
+
+	for _, r := range word {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+
+	if upper == 0 {
+		return 0
+	}
+
+	// all capitals, or just the first or last letter, are tried straight after all lower case
+	if lower == 0 || upper == 1 && (unicode.IsUpper(word[0]) || unicode.IsUpper(word[len(word)-1])) {
+		return math.Log10(2)
+	}
+
+	var variations float64
+
+	for k := 1; k <= min(upper, lower); k++ {
+		variations += binomial(upper+lower, k)
+	}
+
+	return math.Log10(math.Max(variations, 1))
+}
+
+// repeatMatches finds the same character three or more times in a row
+func repeatMatches(runes []rune) []match {
+	var matches []match
+
+	for i := 0; i < len(runes); {
+		j := i
+		for j+1 < len(runes) && runes[j+1] == runes[i] {
+			j++
+		}
+
+		if j-i >= 2 {
+			matches = append(matches, match{i: i, j: j, guesses: math.Log10(float64(cardinality(runes[i]) * (j - i + 1)))})
+		}
+
+		i = j + 1
+	}
+
+	return matches
+}
+
+// sequenceMatches finds runs like abcd, 1234 or 9753 where every step is the same
+func sequenceMatches(runes []rune) []match {
+	var matches []match
+
+	for i := 0; i+2 < len(runes); {
+		step := runes[i+1] - runes[i]
+		if step == 0 || step > 5 || step < -5 || cardinality(runes[i]) != cardinality(runes[i+1]) {
+			i++
+
+			continue
+		}
+
+		j := i + 1
+		for j+1 < len(runes) && runes[j+1]-runes[j] == step && cardinality(runes[j+1]) == cardinality(runes[i]) {
+			j++
+		}
+
+		if j-i >= 2 {
+			base := float64(cardinality(runes[i]))
+			if strings.ContainsRune("aAzZ019", runes[i]) {
+				base = 4
+			}
+
+			if step < 0 {
+				base *= 2
+			}
+
+			matches = append(matches, match{i: i, j: j, guesses: math.Log10(base * float64(j-i+1))})
+		}
+
+		i = j
+	}
+
+	return matches
+}
+
+// keyboardMatches finds runs of four or more keys next to each other on a keyboard, either way along a row
+func keyboardMatches(runes []rune) []match {
+	var matches []match
+
+	lower := []rune(strings.ToLower(string(runes)))
+
+	for i := range lower {
+		for j := len(lower) - 1; j >= i+3; j-- {
+			part := string(lower[i : j+1])
+
+			for _, row := range keyboardRows {
+				if strings.Contains(row, part) || strings.Contains(reverse(row), part) {
+					matches = append(matches, match{i: i, j: j, guesses: math.Log10(float64(10 * (j - i + 1)))})
+
+					break
+				}
+			}
+		}
+	}
+
+	return matches
+}
+
+// yearMatches finds four digit years between 1900 and 2099
+func yearMatches(runes []rune) []match {
+	var matches []match
+
+	for i := 0; i+3 < len(runes); i++ {
+		year := 0
+
+		for _, r := range runes[i : i+4] {
+			if r < '0' || r > '9' {
+				year = -1
+
+				break
+			}
+
+			year = year*10 + int(r-'0')
+		}
+
+		if year >= 1900 && year <= 2099 {
+			space := max(referenceYear-year, year-referenceYear, minYearSpace)
+			matches = append(matches, match{i: i, j: i + 3, guesses: math.Log10(float64(space))})
+		}
+	}
+
+	return matches
+}
+
+// bruteforceGuesses returns log10 of the guesses for a part of the password that has to be brute forced
+func bruteforceGuesses(length int) float64 {
+	guesses := float64(length) * math.Log10(bruteforceCardinality)
+
+	if length == 1 {
+		return math.Max(guesses, math.Log10(11))
+	}
+
+	return math.Max(guesses, math.Log10(51))
+}
+
+// cardinality returns how many characters are like r
+func cardinality(r rune) int {
+	switch {
+	case unicode.IsDigit(r):
+		return 10
+	case unicode.IsLower(r), unicode.IsUpper(r):
+		return 26
+	default:
+		return 33
+	}
+}
+
+// logFactorial returns log10 of n!
+func logFactorial(n int) float64 {
+	var f float64
+
+	for i := 2; i <= n; i++ {
+		f += math.Log10(float64(i))
+	}
+
+	return f
+}
+
+// logAdd returns log10(10^a + 10^b) without leaving log space
+func logAdd(a, b float64) float64 {
+	hi, lo := math.Max(a, b), math.Min(a, b)
+
+	return hi + math.Log10(1+math.Pow(10, lo-hi))
+}
+
+// binomial returns n choose k
+func binomial(n, k int) float64 {
+	r := 1.0
+
+	for i := 1; i <= k; i++ {
+		r = r * float64(n-k+i) / float64(i)
+	}
+
+	return r
+}
+
+// reverse returns s backwards
+func reverse(s string) string {
+	runes := []rune(s)
+
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+
+	return string(runes)
+}
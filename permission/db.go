@@ -3,8 +3,10 @@ package permission
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
 
 	"github.com/ystv/web-auth/utils"
 )
@@ -149,3 +151,204 @@ func (s *Store) removePermissionForRoles(ctx context.Context, p Permission) erro
 
 	return nil
 }
+
+// getPermissionImplications returns every edge in the permission hierarchy
+func (s *Store) getPermissionImplications(ctx context.Context, q sqlx.QueryerContext) ([]Implication, error) {
+	var i []Implication
+
+	builder := utils.PSQL().Select("parent_permission_id", "child_permission_id").
+		From("people.permission_implications").
+		OrderBy("parent_permission_id", "child_permission_id")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getPermissionImplications: %w", err))
+	}
+
+	err = sqlx.SelectContext(ctx, q, &i, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get permission implications: %w", err)
+	}
+
+	return i, nil
+}
+
+// getParentPermissions returns the permissions that directly imply a permission
+func (s *Store) getParentPermissions(ctx context.Context, p Permission) ([]Permission, error) {
+	var p1 []Permission
+
+	builder := utils.PSQL().Select("p.*").
+		From("people.permissions p").
+		InnerJoin("people.permission_implications pi ON pi.parent_permission_id = p.permission_id").
+		Where(sq.Eq{"pi.child_permission_id": p.PermissionID}).
+		OrderBy("p.name")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getParentPermissions: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &p1, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parent permissions: %w", err)
+	}
+
+	return p1, nil
+}
+
+// getChildPermissions returns the permissions that a permission directly implies
+func (s *Store) getChildPermissions(ctx context.Context, p Permission) ([]Permission, error) {
+	var p1 []Permission
+
+	builder := utils.PSQL().Select("p.*").
+		From("people.permissions p").
+		InnerJoin("people.permission_implications pi ON pi.child_permission_id = p.permission_id").
+		Where(sq.Eq{"pi.parent_permission_id": p.PermissionID}).
+		OrderBy("p.name")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getChildPermissions: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &p1, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get child permissions: %w", err)
+	}
+
+	return p1, nil
+}
+
+// setPermissionImplications replaces the parents and children of a permission, nothing is changed if the new edges
+// would make a cycle
+func (s *Store) setPermissionImplications(ctx context.Context, p Permission, parents, children []Permission) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin permission implications transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// stops two edits being made at the same time which together would make a cycle
+	_, err = tx.ExecContext(ctx, "LOCK TABLE people.permission_implications IN SHARE ROW EXCLUSIVE MODE")
+	if err != nil {
+		return fmt.Errorf("failed to lock permission implications: %w", err)
+	}
+
+	existing, err := s.getPermissionImplications(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	implications, added, err := replaceImplications(existing, p, parents, children)
+	if err != nil {
+		return err
+	}
+
+	if cycle := FindCycle(implications); cycle != nil {
+		return s.cycleError(ctx, cycle)
+	}
+
+	sql, args, err := deleteImplicationsBuilder(p).ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for setPermissionImplications delete: %w", err))
+	}
+
+	_, err = tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete permission implications: %w", err)
+	}
+
+	if len(added) > 0 {
+		sql, args, err = addImplicationsBuilder(added).ToSql()
+		if err != nil {
+			panic(fmt.Errorf("failed to build sql for setPermissionImplications insert: %w", err))
+		}
+
+		_, err = tx.ExecContext(ctx, sql, args...)
+		if err != nil {
+			return fmt.Errorf("failed to add permission implications: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit permission implications: %w", err)
+	}
+
+	return nil
+}
+
+// replaceImplications returns the hierarchy with the edges of a permission replaced by the new parents and children,
+// and the edges that are added
+func replaceImplications(existing []Implication, p Permission, parents, children []Permission) ([]Implication,
+	[]Implication, error) {
+	implications := make([]Implication, 0, len(existing)+len(parents)+len(children))
+
+	for _, i := range existing {
+		if i.ParentPermissionID != p.PermissionID && i.ChildPermissionID != p.PermissionID {
+			implications = append(implications, i)
+		}
+	}
+
+	added := make([]Implication, 0, len(parents)+len(children))
+
+	for _, parent := range parents {
+		added = append(added, Implication{ParentPermissionID: parent.PermissionID, ChildPermissionID: p.PermissionID})
+	}
+
+	for _, child := range children {
+		added = append(added, Implication{ParentPermissionID: p.PermissionID, ChildPermissionID: child.PermissionID})
+	}
+
+	for _, i := range added {
+		if i.ParentPermissionID == i.ChildPermissionID {
+			return nil, nil, fmt.Errorf("permission \"%s\" can't imply itself", p.Name)
+		}
+	}
+
+	return append(implications, added...), added, nil
+}
+
+// deleteImplicationsBuilder removes every edge to and from a permission
+func deleteImplicationsBuilder(p Permission) sq.DeleteBuilder {
+	return utils.PSQL().Delete("people.permission_implications").
+		Where(sq.Or{
+			sq.Eq{"parent_permission_id": p.PermissionID},
+			sq.Eq{"child_permission_id": p.PermissionID},
+		})
+}
+
+func addImplicationsBuilder(added []Implication) sq.InsertBuilder {
+	insert := utils.PSQL().Insert("people.permission_implications").
+		Columns("parent_permission_id", "child_permission_id").
+		Suffix("ON CONFLICT DO NOTHING")
+
+	for _, i := range added {
+		insert = insert.Values(i.ParentPermissionID, i.ChildPermissionID)
+	}
+
+	return insert
+}
+
+// cycleError names the permissions in a cycle so the admin can see which edge to remove
+func (s *Store) cycleError(ctx context.Context, cycle []int) error {
+	all, err := s.getPermissions(ctx)
+	if err != nil {
+		return fmt.Errorf("permission hierarchy would contain a cycle: %v", cycle)
+	}
+
+	names := make(map[int]string)
+	for _, p := range all {
+		names[p.PermissionID] = p.Name
+	}
+
+	path := make([]string, 0, len(cycle))
+	for _, id := range cycle {
+		path = append(path, names[id])
+	}
+
+	return fmt.Errorf("permission hierarchy would contain a cycle: %s", strings.Join(path, " -> "))
+}
@@ -0,0 +1,62 @@
+package permission
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceImplications(t *testing.T) {
+	existing := []Implication{
+		{ParentPermissionID: 1, ChildPermissionID: 2},
+		{ParentPermissionID: 2, ChildPermissionID: 3},
+		{ParentPermissionID: 4, ChildPermissionID: 5},
+	}
+
+	t.Run("Replaced", func(t *testing.T) {
+		implications, added, err := replaceImplications(existing, Permission{PermissionID: 2},
+			[]Permission{{PermissionID: 4}}, []Permission{{PermissionID: 5}})
+		require.NoError(t, err)
+
+		assert.Equal(t, []Implication{
+			{ParentPermissionID: 4, ChildPermissionID: 2},
+			{ParentPermissionID: 2, ChildPermissionID: 5},
+		}, added)
+		assert.Equal(t, append([]Implication{{ParentPermissionID: 4, ChildPermissionID: 5}}, added...), implications)
+		assert.Nil(t, FindCycle(implications))
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		implications, _, err := replaceImplications(existing, Permission{PermissionID: 5},
+			[]Permission{{PermissionID: 4}}, []Permission{{PermissionID: 1}, {PermissionID: 4}})
+		require.NoError(t, err)
+
+		assert.Equal(t, []int{4, 5, 4}, FindCycle(implications))
+	})
+
+	t.Run("Self", func(t *testing.T) {
+		_, _, err := replaceImplications(existing, Permission{PermissionID: 3, Name: "CMS.Admin"},
+			[]Permission{{PermissionID: 3}}, nil)
+		assert.EqualError(t, err, "permission \"CMS.Admin\" can't imply itself")
+	})
+}
+
+func TestImplicationsSQL(t *testing.T) {
+	sql, args, err := deleteImplicationsBuilder(Permission{PermissionID: 2}).ToSql()
+	require.NoError(t, err)
+
+	assert.Equal(t, "DELETE FROM people.permission_implications "+
+		"WHERE (parent_permission_id = $1 OR child_permission_id = $2)", sql)
+	assert.Equal(t, []interface{}{2, 2}, args)
+
+	sql, args, err = addImplicationsBuilder([]Implication{
+		{ParentPermissionID: 4, ChildPermissionID: 2},
+		{ParentPermissionID: 2, ChildPermissionID: 5},
+	}).ToSql()
+	require.NoError(t, err)
+
+	assert.Equal(t, "INSERT INTO people.permission_implications (parent_permission_id,child_permission_id) "+
+		"VALUES ($1,$2),($3,$4) ON CONFLICT DO NOTHING", sql)
+	assert.Equal(t, []interface{}{4, 2, 2, 5}, args)
+}
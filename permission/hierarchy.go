@@ -0,0 +1,23 @@
+package permission
+
+import "github.com/ystv/web-auth/utils"
+
+type (
+	// Implication is an edge in the permission hierarchy, holding the parent permission also grants the child
+	Implication struct {
+		ParentPermissionID int `db:"parent_permission_id" json:"parentPermissionID"`
+		ChildPermissionID  int `db:"child_permission_id" json:"childPermissionID"`
+	}
+)
+
+// FindCycle returns the permission IDs making up a cycle in the hierarchy, starting and ending with the same
+// permission, or nil when there isn't one
+func FindCycle(implications []Implication) []int {
+	edges := make([][2]int, 0, len(implications))
+
+	for _, i := range implications {
+		edges = append(edges, [2]int{i.ParentPermissionID, i.ChildPermissionID})
+	}
+
+	return utils.FindCycle(edges)
+}
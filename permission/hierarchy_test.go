@@ -0,0 +1,22 @@
+package permission
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindCycle(t *testing.T) {
+	implications := []Implication{
+		{ParentPermissionID: 1, ChildPermissionID: 2},
+		{ParentPermissionID: 2, ChildPermissionID: 3},
+		{ParentPermissionID: 1, ChildPermissionID: 3},
+		{ParentPermissionID: 4, ChildPermissionID: 3},
+	}
+
+	assert.Nil(t, FindCycle(implications))
+
+	implications = append(implications, Implication{ParentPermissionID: 3, ChildPermissionID: 1})
+
+	assert.Equal(t, []int{1, 2, 3, 1}, FindCycle(implications))
+}
@@ -85,6 +85,36 @@ func (mr *MockRepoMockRecorder) EditPermission(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EditPermission", reflect.TypeOf((*MockRepo)(nil).EditPermission), arg0, arg1)
 }
 
+// GetChildPermissions mocks base method.
+func (m *MockRepo) GetChildPermissions(arg0 context.Context, arg1 permission.Permission) ([]permission.Permission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChildPermissions", arg0, arg1)
+	ret0, _ := ret[0].([]permission.Permission)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChildPermissions indicates an expected call of GetChildPermissions.
+func (mr *MockRepoMockRecorder) GetChildPermissions(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChildPermissions", reflect.TypeOf((*MockRepo)(nil).GetChildPermissions), arg0, arg1)
+}
+
+// GetParentPermissions mocks base method.
+func (m *MockRepo) GetParentPermissions(arg0 context.Context, arg1 permission.Permission) ([]permission.Permission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetParentPermissions", arg0, arg1)
+	ret0, _ := ret[0].([]permission.Permission)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetParentPermissions indicates an expected call of GetParentPermissions.
+func (mr *MockRepoMockRecorder) GetParentPermissions(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetParentPermissions", reflect.TypeOf((*MockRepo)(nil).GetParentPermissions), arg0, arg1)
+}
+
 // GetPermission mocks base method.
 func (m *MockRepo) GetPermission(arg0 context.Context, arg1 permission.Permission) (permission.Permission, error) {
 	m.ctrl.T.Helper()
@@ -100,6 +130,21 @@ func (mr *MockRepoMockRecorder) GetPermission(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPermission", reflect.TypeOf((*MockRepo)(nil).GetPermission), arg0, arg1)
 }
 
+// GetPermissionImplications mocks base method.
+func (m *MockRepo) GetPermissionImplications(arg0 context.Context) ([]permission.Implication, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPermissionImplications", arg0)
+	ret0, _ := ret[0].([]permission.Implication)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPermissionImplications indicates an expected call of GetPermissionImplications.
+func (mr *MockRepoMockRecorder) GetPermissionImplications(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPermissionImplications", reflect.TypeOf((*MockRepo)(nil).GetPermissionImplications), arg0)
+}
+
 // GetPermissions mocks base method.
 func (m *MockRepo) GetPermissions(arg0 context.Context) ([]permission.Permission, error) {
 	m.ctrl.T.Helper()
@@ -128,3 +173,17 @@ func (mr *MockRepoMockRecorder) RemovePermissionForRoles(arg0, arg1 any) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovePermissionForRoles", reflect.TypeOf((*MockRepo)(nil).RemovePermissionForRoles), arg0, arg1)
 }
+
+// SetPermissionImplications mocks base method.
+func (m *MockRepo) SetPermissionImplications(ctx context.Context, p permission.Permission, parents, children []permission.Permission) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPermissionImplications", ctx, p, parents, children)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPermissionImplications indicates an expected call of SetPermissionImplications.
+func (mr *MockRepoMockRecorder) SetPermissionImplications(ctx, p, parents, children any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPermissionImplications", reflect.TypeOf((*MockRepo)(nil).SetPermissionImplications), ctx, p, parents, children)
+}
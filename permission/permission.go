@@ -17,6 +17,10 @@ type (
 		EditPermission(context.Context, Permission) (Permission, error)
 		DeletePermission(context.Context, Permission) error
 		RemovePermissionForRoles(context.Context, Permission) error
+		GetPermissionImplications(context.Context) ([]Implication, error)
+		GetParentPermissions(context.Context, Permission) ([]Permission, error)
+		GetChildPermissions(context.Context, Permission) ([]Permission, error)
+		SetPermissionImplications(ctx context.Context, p Permission, parents, children []Permission) error
 	}
 
 	// Store stores the dependencies
@@ -82,3 +86,24 @@ func (s *Store) DeletePermission(ctx context.Context, p Permission) error {
 func (s *Store) RemovePermissionForRoles(ctx context.Context, p Permission) error {
 	return s.removePermissionForRoles(ctx, p)
 }
+
+// GetPermissionImplications returns every edge in the permission hierarchy
+func (s *Store) GetPermissionImplications(ctx context.Context) ([]Implication, error) {
+	return s.getPermissionImplications(ctx, s.db)
+}
+
+// GetParentPermissions returns the permissions that directly imply a permission
+func (s *Store) GetParentPermissions(ctx context.Context, p Permission) ([]Permission, error) {
+	return s.getParentPermissions(ctx, p)
+}
+
+// GetChildPermissions returns the permissions that a permission directly implies
+func (s *Store) GetChildPermissions(ctx context.Context, p Permission) ([]Permission, error) {
+	return s.getChildPermissions(ctx, p)
+}
+
+// SetPermissionImplications replaces the parents and children of a permission in the hierarchy,
+// an error is returned if this would make a cycle
+func (s *Store) SetPermissionImplications(ctx context.Context, p Permission, parents, children []Permission) error {
+	return s.setPermissionImplications(ctx, p, parents, children)
+}
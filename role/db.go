@@ -3,10 +3,12 @@ package role
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	sq "github.com/Masterminds/squirrel"
 
 	"github.com/ystv/web-auth/utils"
+	"github.com/ystv/web-auth/webhook"
 )
 
 // getRoles returns all roles for a user
@@ -62,6 +64,15 @@ func (s *Store) getRole(ctx context.Context, r1 Role) (Role, error) {
 
 // addRole adds a new Role
 func (s *Store) addRole(ctx context.Context, r Role) (Role, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Role{}, fmt.Errorf("failed to begin add role transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
 	builder := utils.PSQL().Insert("people.roles").
 		Columns("name", "description").
 		Values(r.Name, r.Description).
@@ -72,16 +83,19 @@ func (s *Store) addRole(ctx context.Context, r Role) (Role, error) {
 		panic(fmt.Errorf("failed to build sql for addRole: %w", err))
 	}
 
-	stmt, err := s.db.PrepareContext(ctx, sql)
+	err = tx.QueryRowxContext(ctx, sql, args...).Scan(&r.RoleID)
 	if err != nil {
 		return Role{}, fmt.Errorf("failed to add role: %w", err)
 	}
 
-	defer stmt.Close()
+	err = s.webhook.Emit(ctx, tx, webhook.RoleCreated, r)
+	if err != nil {
+		return Role{}, fmt.Errorf("failed to emit role created: %w", err)
+	}
 
-	err = stmt.QueryRow(args...).Scan(&r.RoleID)
+	err = tx.Commit()
 	if err != nil {
-		return Role{}, fmt.Errorf("failed to add role: %w", err)
+		return Role{}, fmt.Errorf("failed to commit add role: %w", err)
 	}
 
 	return r, nil
@@ -89,10 +103,22 @@ func (s *Store) addRole(ctx context.Context, r Role) (Role, error) {
 
 // editRole edits an existing Role
 func (s *Store) editRole(ctx context.Context, r Role) (Role, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Role{}, fmt.Errorf("failed to begin edit role transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
 	builder := utils.PSQL().Update("people.roles").
 		SetMap(map[string]interface{}{
-			"name":        r.Name,
-			"description": r.Description,
+			"name":                   r.Name,
+			"description":            r.Description,
+			"requestable":            r.Requestable,
+			"approver_permission_id": r.ApproverPermissionID,
+			"password_max_age_days":  r.PasswordMaxAgeDays,
 		}).
 		Where(sq.Eq{"role_id": r.RoleID})
 
@@ -101,7 +127,7 @@ func (s *Store) editRole(ctx context.Context, r Role) (Role, error) {
 		panic(fmt.Errorf("failed to build sql for editRole: %w", err))
 	}
 
-	res, err := s.db.ExecContext(ctx, sql, args...)
+	res, err := tx.ExecContext(ctx, sql, args...)
 	if err != nil {
 		return Role{}, fmt.Errorf("failed to edit role: %w", err)
 	}
@@ -115,11 +141,30 @@ func (s *Store) editRole(ctx context.Context, r Role) (Role, error) {
 		return Role{}, fmt.Errorf("failed to edit role: invalid rows affected: %d", rows)
 	}
 
+	err = s.webhook.Emit(ctx, tx, webhook.RoleUpdated, r)
+	if err != nil {
+		return Role{}, fmt.Errorf("failed to emit role updated: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Role{}, fmt.Errorf("failed to commit edit role: %w", err)
+	}
+
 	return r, nil
 }
 
 // deleteRole deletes a specific Role
 func (s *Store) deleteRole(ctx context.Context, r Role) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin delete role transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
 	builder := utils.PSQL().Delete("people.roles").
 		Where(sq.Eq{"role_id": r.RoleID})
 
@@ -128,11 +173,21 @@ func (s *Store) deleteRole(ctx context.Context, r Role) error {
 		panic(fmt.Errorf("failed to build sql for deleteRole: %w", err))
 	}
 
-	_, err = s.db.ExecContext(ctx, sql, args...)
+	_, err = tx.ExecContext(ctx, sql, args...)
 	if err != nil {
 		return fmt.Errorf("failed to delete role: %w", err)
 	}
 
+	err = s.webhook.Emit(ctx, tx, webhook.RoleDeleted, r)
+	if err != nil {
+		return fmt.Errorf("failed to emit role deleted: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit delete role: %w", err)
+	}
+
 	return nil
 }
 
@@ -154,20 +209,212 @@ func (s *Store) removeRoleForPermissions(ctx context.Context, r Role) error {
 	return nil
 }
 
-// removeRoleForUsers deletes links between a Role and Users
-func (s *Store) removeRoleForUsers(ctx context.Context, r Role) error {
-	builder := utils.PSQL().Delete("people.role_members").
-		Where(sq.Eq{"role_id": r.RoleID})
+// getIncludedRoles returns the roles directly included by a Role
+func (s *Store) getIncludedRoles(ctx context.Context, r Role) ([]Role, error) {
+	var r1 []Role
+
+	builder := utils.PSQL().Select("r.*").
+		From("people.roles r").
+		InnerJoin("people.role_inclusions ri ON ri.included_role_id = r.role_id").
+		Where(sq.Eq{"ri.role_id": r.RoleID}).
+		OrderBy("r.name")
 
 	sql, args, err := builder.ToSql()
 	if err != nil {
-		panic(fmt.Errorf("failed to build sql for removeRoleForUsers: %w", err))
+		panic(fmt.Errorf("failed to build sql for getIncludedRoles: %w", err))
 	}
 
-	_, err = s.db.ExecContext(ctx, sql, args...)
+	err = s.db.SelectContext(ctx, &r1, sql, args...)
 	if err != nil {
-		return fmt.Errorf("failed to delete roleUser: %w", err)
+		return nil, fmt.Errorf("failed to get included roles: %w", err)
+	}
+
+	return r1, nil
+}
+
+// getIncludingRoles returns the roles that directly include a Role
+func (s *Store) getIncludingRoles(ctx context.Context, r Role) ([]Role, error) {
+	var r1 []Role
+
+	builder := utils.PSQL().Select("r.*").
+		From("people.roles r").
+		InnerJoin("people.role_inclusions ri ON ri.role_id = r.role_id").
+		Where(sq.Eq{"ri.included_role_id": r.RoleID}).
+		OrderBy("r.name")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getIncludingRoles: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &r1, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get including roles: %w", err)
+	}
+
+	return r1, nil
+}
+
+// getRolesNotIncluded returns the roles, other than itself, that a Role doesn't directly include
+func (s *Store) getRolesNotIncluded(ctx context.Context, r Role) ([]Role, error) {
+	var r1 []Role
+
+	subQuery := utils.PSQL().Select("ri.included_role_id").
+		From("people.role_inclusions ri").
+		Where(sq.Eq{"ri.role_id": r.RoleID})
+
+	builder := utils.PSQL().Select("r.*").
+		From("people.roles r").
+		Where(sq.And{
+			sq.NotEq{"r.role_id": r.RoleID},
+			utils.NotIn("r.role_id", subQuery),
+		}).
+		OrderBy("r.name")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getRolesNotIncluded: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &r1, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roles not included: %w", err)
+	}
+
+	return r1, nil
+}
+
+// addRoleInclusion makes a Role include another, the whole graph is checked for a cycle in the same transaction
+func (s *Store) addRoleInclusion(ctx context.Context, ri RoleInclusion) (RoleInclusion, error) {
+	if ri.RoleID == ri.IncludedRoleID {
+		return RoleInclusion{}, fmt.Errorf("a role can't include itself")
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return RoleInclusion{}, fmt.Errorf("failed to begin role inclusion transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// stops two inclusions being added at the same time which together would make a cycle
+	_, err = tx.ExecContext(ctx, "LOCK TABLE people.role_inclusions IN SHARE ROW EXCLUSIVE MODE")
+	if err != nil {
+		return RoleInclusion{}, fmt.Errorf("failed to lock role inclusions: %w", err)
+	}
+
+	var inclusions []RoleInclusion
+
+	builder := utils.PSQL().Select("role_id", "included_role_id").
+		From("people.role_inclusions")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for addRoleInclusion select: %w", err))
+	}
+
+	err = tx.SelectContext(ctx, &inclusions, sql, args...)
+	if err != nil {
+		return RoleInclusion{}, fmt.Errorf("failed to get role inclusions: %w", err)
+	}
+
+	edges := make([][2]int, 0, len(inclusions)+1)
+
+	for _, i := range inclusions {
+		edges = append(edges, [2]int{i.RoleID, i.IncludedRoleID})
+	}
+
+	edges = append(edges, [2]int{ri.RoleID, ri.IncludedRoleID})
+
+	if cycle := utils.FindCycle(edges); cycle != nil {
+		return RoleInclusion{}, s.cycleError(ctx, cycle)
+	}
+
+	insert := utils.PSQL().Insert("people.role_inclusions").
+		Columns("role_id", "included_role_id").
+		Values(ri.RoleID, ri.IncludedRoleID)
+
+	sql, args, err = insert.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for addRoleInclusion insert: %w", err))
+	}
+
+	_, err = tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return RoleInclusion{}, fmt.Errorf("failed to add role inclusion: %w", err)
+	}
+
+	err = s.webhook.Emit(ctx, tx, webhook.RoleInclusionAdded, ri)
+	if err != nil {
+		return RoleInclusion{}, fmt.Errorf("failed to emit role inclusion added: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return RoleInclusion{}, fmt.Errorf("failed to commit role inclusion: %w", err)
+	}
+
+	return ri, nil
+}
+
+// removeRoleInclusion stops a Role including another
+func (s *Store) removeRoleInclusion(ctx context.Context, ri RoleInclusion) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin remove role inclusion transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	builder := utils.PSQL().Delete("people.role_inclusions").
+		Where(sq.And{
+			sq.Eq{"role_id": ri.RoleID},
+			sq.Eq{"included_role_id": ri.IncludedRoleID},
+		})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for removeRoleInclusion: %w", err))
+	}
+
+	_, err = tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete role inclusion: %w", err)
+	}
+
+	err = s.webhook.Emit(ctx, tx, webhook.RoleInclusionRemoved, ri)
+	if err != nil {
+		return fmt.Errorf("failed to emit role inclusion removed: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit remove role inclusion: %w", err)
 	}
 
 	return nil
 }
+
+// cycleError names the roles in a cycle so the admin can see why the inclusion was refused
+func (s *Store) cycleError(ctx context.Context, cycle []int) error {
+	roles, err := s.getRoles(ctx)
+	if err != nil {
+		return fmt.Errorf("role inclusions would contain a cycle: %v", cycle)
+	}
+
+	names := make(map[int]string)
+	for _, r := range roles {
+		names[r.RoleID] = r.Name
+	}
+
+	path := make([]string, 0, len(cycle))
+	for _, id := range cycle {
+		path = append(path, names[id])
+	}
+
+	return fmt.Errorf("role inclusions would contain a cycle: %s", strings.Join(path, " includes "))
+}
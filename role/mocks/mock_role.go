@@ -56,6 +56,21 @@ func (mr *MockRepoMockRecorder) AddRole(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRole", reflect.TypeOf((*MockRepo)(nil).AddRole), arg0, arg1)
 }
 
+// AddRoleInclusion mocks base method.
+func (m *MockRepo) AddRoleInclusion(arg0 context.Context, arg1 role.RoleInclusion) (role.RoleInclusion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRoleInclusion", arg0, arg1)
+	ret0, _ := ret[0].(role.RoleInclusion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddRoleInclusion indicates an expected call of AddRoleInclusion.
+func (mr *MockRepoMockRecorder) AddRoleInclusion(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRoleInclusion", reflect.TypeOf((*MockRepo)(nil).AddRoleInclusion), arg0, arg1)
+}
+
 // DeleteRole mocks base method.
 func (m *MockRepo) DeleteRole(arg0 context.Context, arg1 role.Role) error {
 	m.ctrl.T.Helper()
@@ -85,6 +100,36 @@ func (mr *MockRepoMockRecorder) EditRole(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EditRole", reflect.TypeOf((*MockRepo)(nil).EditRole), arg0, arg1)
 }
 
+// GetIncludedRoles mocks base method.
+func (m *MockRepo) GetIncludedRoles(arg0 context.Context, arg1 role.Role) ([]role.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIncludedRoles", arg0, arg1)
+	ret0, _ := ret[0].([]role.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIncludedRoles indicates an expected call of GetIncludedRoles.
+func (mr *MockRepoMockRecorder) GetIncludedRoles(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIncludedRoles", reflect.TypeOf((*MockRepo)(nil).GetIncludedRoles), arg0, arg1)
+}
+
+// GetIncludingRoles mocks base method.
+func (m *MockRepo) GetIncludingRoles(arg0 context.Context, arg1 role.Role) ([]role.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIncludingRoles", arg0, arg1)
+	ret0, _ := ret[0].([]role.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIncludingRoles indicates an expected call of GetIncludingRoles.
+func (mr *MockRepoMockRecorder) GetIncludingRoles(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIncludingRoles", reflect.TypeOf((*MockRepo)(nil).GetIncludingRoles), arg0, arg1)
+}
+
 // GetRole mocks base method.
 func (m *MockRepo) GetRole(arg0 context.Context, arg1 role.Role) (role.Role, error) {
 	m.ctrl.T.Helper()
@@ -115,6 +160,21 @@ func (mr *MockRepoMockRecorder) GetRoles(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoles", reflect.TypeOf((*MockRepo)(nil).GetRoles), arg0)
 }
 
+// GetRolesNotIncluded mocks base method.
+func (m *MockRepo) GetRolesNotIncluded(arg0 context.Context, arg1 role.Role) ([]role.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRolesNotIncluded", arg0, arg1)
+	ret0, _ := ret[0].([]role.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRolesNotIncluded indicates an expected call of GetRolesNotIncluded.
+func (mr *MockRepoMockRecorder) GetRolesNotIncluded(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRolesNotIncluded", reflect.TypeOf((*MockRepo)(nil).GetRolesNotIncluded), arg0, arg1)
+}
+
 // RemoveRoleForPermissions mocks base method.
 func (m *MockRepo) RemoveRoleForPermissions(arg0 context.Context, arg1 role.Role) error {
 	m.ctrl.T.Helper()
@@ -129,16 +189,16 @@ func (mr *MockRepoMockRecorder) RemoveRoleForPermissions(arg0, arg1 any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveRoleForPermissions", reflect.TypeOf((*MockRepo)(nil).RemoveRoleForPermissions), arg0, arg1)
 }
 
-// RemoveRoleForUsers mocks base method.
-func (m *MockRepo) RemoveRoleForUsers(arg0 context.Context, arg1 role.Role) error {
+// RemoveRoleInclusion mocks base method.
+func (m *MockRepo) RemoveRoleInclusion(arg0 context.Context, arg1 role.RoleInclusion) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RemoveRoleForUsers", arg0, arg1)
+	ret := m.ctrl.Call(m, "RemoveRoleInclusion", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// RemoveRoleForUsers indicates an expected call of RemoveRoleForUsers.
-func (mr *MockRepoMockRecorder) RemoveRoleForUsers(arg0, arg1 any) *gomock.Call {
+// RemoveRoleInclusion indicates an expected call of RemoveRoleInclusion.
+func (mr *MockRepoMockRecorder) RemoveRoleInclusion(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveRoleForUsers", reflect.TypeOf((*MockRepo)(nil).RemoveRoleForUsers), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveRoleInclusion", reflect.TypeOf((*MockRepo)(nil).RemoveRoleInclusion), arg0, arg1)
 }
@@ -5,6 +5,9 @@ import (
 	"fmt"
 
 	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/webhook"
 )
 
 //go:generate mockgen -destination mocks/mock_role.go -package mock_role github.com/ystv/web-auth/role Repo
@@ -17,28 +20,46 @@ type (
 		EditRole(context.Context, Role) (Role, error)
 		DeleteRole(context.Context, Role) error
 		RemoveRoleForPermissions(context.Context, Role) error
-		RemoveRoleForUsers(context.Context, Role) error
+		GetIncludedRoles(context.Context, Role) ([]Role, error)
+		GetIncludingRoles(context.Context, Role) ([]Role, error)
+		GetRolesNotIncluded(context.Context, Role) ([]Role, error)
+		AddRoleInclusion(context.Context, RoleInclusion) (RoleInclusion, error)
+		RemoveRoleInclusion(context.Context, RoleInclusion) error
 	}
 
 	// Store stores the dependencies
 	Store struct {
-		db *sqlx.DB
+		db      *sqlx.DB
+		webhook webhook.Repo
 	}
 
-	// Role represents relevant user fields
+	// Role represents relevant user fields, a Requestable role can be asked for on the request access page
+	// and the requests can be approved by anyone holding ApproverPermissionID, members have to change their
+	// password every PasswordMaxAgeDays if it is set
 	Role struct {
-		RoleID      int    `db:"role_id" json:"id"`
-		Name        string `db:"name" json:"name" schema:"name"`
-		Description string `db:"description" json:"description" schema:"description"`
-		Users       int    `db:"users" json:"users"`
-		Permissions int    `db:"permissions" json:"permissions"`
+		RoleID               int      `db:"role_id" json:"id"`
+		Name                 string   `db:"name" json:"name" schema:"name"`
+		Description          string   `db:"description" json:"description" schema:"description"`
+		Requestable          bool     `db:"requestable" json:"requestable"`
+		ApproverPermissionID null.Int `db:"approver_permission_id" json:"approverPermissionID"`
+		PasswordMaxAgeDays   null.Int `db:"password_max_age_days" json:"passwordMaxAgeDays"`
+		Users                int      `db:"users" json:"users"`
+		Permissions          int      `db:"permissions" json:"permissions"`
+	}
+
+	// RoleInclusion symbolises a role including another, members of the role are also treated as members of the
+	// included role
+	RoleInclusion struct {
+		RoleID         int `db:"role_id" json:"roleID"`
+		IncludedRoleID int `db:"included_role_id" json:"includedRoleID"`
 	}
 )
 
 // NewRoleRepo stores our dependency
-func NewRoleRepo(db *sqlx.DB) *Store {
+func NewRoleRepo(db *sqlx.DB, wh webhook.Repo) *Store {
 	return &Store{
-		db: db,
+		db:      db,
+		webhook: wh,
 	}
 }
 
@@ -72,6 +93,9 @@ func (s *Store) EditRole(ctx context.Context, r Role) (Role, error) {
 		role.Description = r.Description
 	}
 
+	role.Requestable = r.Requestable
+	role.ApproverPermissionID = r.ApproverPermissionID
+
 	return s.editRole(ctx, role)
 }
 
@@ -85,7 +109,27 @@ func (s *Store) RemoveRoleForPermissions(ctx context.Context, r Role) error {
 	return s.removeRoleForPermissions(ctx, r)
 }
 
-// RemoveRoleForUsers deletes a roleUser
-func (s *Store) RemoveRoleForUsers(ctx context.Context, r Role) error {
-	return s.removeRoleForUsers(ctx, r)
+// GetIncludedRoles returns the roles directly included by a role
+func (s *Store) GetIncludedRoles(ctx context.Context, r Role) ([]Role, error) {
+	return s.getIncludedRoles(ctx, r)
+}
+
+// GetIncludingRoles returns the roles that directly include a role
+func (s *Store) GetIncludingRoles(ctx context.Context, r Role) ([]Role, error) {
+	return s.getIncludingRoles(ctx, r)
+}
+
+// GetRolesNotIncluded returns the roles that a role could include
+func (s *Store) GetRolesNotIncluded(ctx context.Context, r Role) ([]Role, error) {
+	return s.getRolesNotIncluded(ctx, r)
+}
+
+// AddRoleInclusion makes a role include another, an error is returned if this would make a cycle
+func (s *Store) AddRoleInclusion(ctx context.Context, ri RoleInclusion) (RoleInclusion, error) {
+	return s.addRoleInclusion(ctx, ri)
+}
+
+// RemoveRoleInclusion stops a role including another
+func (s *Store) RemoveRoleInclusion(ctx context.Context, ri RoleInclusion) error {
+	return s.removeRoleInclusion(ctx, ri)
 }
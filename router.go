@@ -108,7 +108,38 @@ func (r *Router) loadRoutes() {
 	settings := internal.Group("/settings")
 	settings.Match(validMethods, "/uploadavatar", r.views.UploadAvatarFunc)
 	settings.Match(validMethods, "/removeavatar", r.views.RemoveAvatarFunc)
+	settings.Match(validMethods, "/export", r.views.SettingsExportFunc)
+	settings.Match(validMethods, "/email", r.views.SettingsEmailFunc)
+	settings.Match(validMethods, "/email/cancel", r.views.SettingsEmailCancelFunc)
+	settings.Match(validMethods, "/emails/add", r.views.SettingsEmailsAddFunc)
+	settings.Match(validMethods, "/emails/remove", r.views.SettingsEmailsRemoveFunc)
+	settings.Match(validMethods, "/emails/primary", r.views.SettingsEmailsPrimaryFunc)
 	settings.Match(validMethods, "", r.views.SettingsFunc)
+	access := internal.Group("/access")
+	// access is for requesting roles and deciding the requests, who can decide is checked for each role
+	access.Match(validMethods, "/requests", r.views.AccessRequestsFunc)
+	access.Match(validMethods, "/requests/:requestid/approve", r.views.AccessRequestApproveFunc)
+	access.Match(validMethods, "/requests/:requestid/deny", r.views.AccessRequestDenyFunc)
+	access.Match(validMethods, "/:requestid/cancel", r.views.AccessRequestCancelFunc)
+	access.Match(validMethods, "", r.views.AccessRequestFunc)
+
+	// review/tasks is for the reviewers, who can review is checked for each role like access requests
+	internal.Match(validMethods, "/review/tasks", r.views.AccessReviewTasksFunc)
+	internal.Match(validMethods, "/review/item/:itemid", r.views.AccessReviewDecideFunc)
+
+	if !r.config.Debug {
+		internal.GET("/reviews", r.views.AccessReviewsFunc, r.views.RequirePermission(permissions.ManageMembersGroup))
+	} else {
+		internal.GET("/reviews", r.views.AccessReviewsFunc)
+	}
+
+	review := internal.Group("/review")
+	if !r.config.Debug {
+		review.Use(r.views.RequirePermission(permissions.ManageMembersGroup))
+	}
+
+	review.Match(validMethods, "/add", r.views.AccessReviewAddFunc)
+	review.Match(validMethods, "/:reviewid", r.views.AccessReviewFunc)
 
 	// permissions are for listing the permissions
 	if !r.config.Debug {
@@ -156,6 +187,10 @@ func (r *Router) loadRoutes() {
 	roleUser := roleID.Group("/user")
 	roleUser.Match(validMethods, "/add", r.views.RoleAddUserFunc)
 	roleUser.Match(validMethods, "/remove/:userid", r.views.RoleRemoveUserFunc)
+
+	roleInclude := roleID.Group("/include")
+	roleInclude.Match(validMethods, "/add", r.views.RoleAddIncludedRoleFunc)
+	roleInclude.Match(validMethods, "/remove/:includedroleid", r.views.RoleRemoveIncludedRoleFunc)
 	roleID.Match(validMethods, "", r.views.RoleFunc)
 
 	// this section of users is a bit weird, users is valid for anyone who can list users and user/add can be used by
@@ -170,6 +205,53 @@ func (r *Router) loadRoutes() {
 		internal.Match(validMethods, "/user/add", r.views.UserAddFunc)
 	}
 
+	userImport := internal.Group("/user/import")
+	// userImport can update existing users as well as add them so needs the admin permission
+	if !r.config.Debug {
+		userImport.Use(r.views.RequirePermission(permissions.ManageMembersMembersAdmin))
+	}
+
+	userImport.Match(validMethods, "/:importid/run", r.views.UserImportRunFunc)
+	userImport.Match(validMethods, "/:importid", r.views.UserImportJobFunc)
+	userImport.Match(validMethods, "", r.views.UserImportFunc)
+
+	userRetention := internal.Group("/user/retention")
+	// userRetention shows the deleted users that are waiting to be anonymised
+	if !r.config.Debug {
+		userRetention.Use(r.views.RequirePermission(permissions.ManageMembersMembersAdmin))
+	}
+
+	userRetention.Match(validMethods, "", r.views.UserRetentionFunc)
+
+	userMerge := internal.Group("/user/merge")
+	// userMerge moves everything from one user to another and deletes the first
+	if !r.config.Debug {
+		userMerge.Use(r.views.RequirePermission(permissions.ManageMembersMembersAdmin))
+	}
+
+	userMerge.Match(validMethods, "", r.views.UserMergeFunc)
+
+	userDuplicates := internal.Group("/user/duplicates")
+	// userDuplicates finds the users that might be the same person so they can be merged
+	if !r.config.Debug {
+		userDuplicates.Use(r.views.RequirePermission(permissions.ManageMembersMembersAdmin))
+	}
+
+	userDuplicates.Match(validMethods, "/dismiss", r.views.UserDuplicatesDismissFunc)
+	userDuplicates.Match(validMethods, "/restore", r.views.UserDuplicatesRestoreFunc)
+	userDuplicates.Match(validMethods, "", r.views.UserDuplicatesFunc)
+
+	userStatuses := internal.Group("/user/statuses")
+	// userStatuses sets the roles given to each status and graduates members to alumni
+	if !r.config.Debug {
+		userStatuses.Use(r.views.RequirePermission(permissions.ManageMembersMembersAdmin))
+	}
+
+	userStatuses.Match(validMethods, "/graduate", r.views.UserStatusesGraduateFunc)
+	userStatuses.Match(validMethods, "/role/add", r.views.UserStatusesRoleAddFunc)
+	userStatuses.Match(validMethods, "/role/remove", r.views.UserStatusesRoleRemoveFunc)
+	userStatuses.Match(validMethods, "", r.views.UserStatusesFunc)
+
 	internal.Match(validMethods, "/user/release", r.views.ReleaseUserFunc)
 	user := internal.Group("/user/:userid")
 	// user is any function to do with a specific user
@@ -181,11 +263,65 @@ func (r *Router) loadRoutes() {
 	user.Match(validMethods, "/delete", r.views.UserDeleteFunc)
 	user.Match(validMethods, "/reset", r.views.ResetUserPasswordFunc)
 	user.Match(validMethods, "/toggle", r.views.UserToggleEnabledFunc)
+	user.Match(validMethods, "/status", r.views.UserStatusFunc)
 	user.Match(validMethods, "/assume", r.views.AssumeUserFunc, r.views.RequirePermission(permissions.SuperUser))
 	user.Match(validMethods, "/uploadavatar", r.views.UploadAvatarUserFunc)
 	user.Match(validMethods, "/removeavatar", r.views.RemoveAvatarUserFunc)
+	user.Match(validMethods, "/export", r.views.UserExportFunc)
+	user.Match(validMethods, "/purge", r.views.UserPurgeFunc)
 	user.Match(validMethods, "", r.views.UserFunc)
 
+	// memberships are for the people who chase up unpaid members
+	if !r.config.Debug {
+		internal.GET("/memberships", r.views.MembershipsFunc,
+			r.views.RequirePermission(permissions.ManageMembersMiscUnpaidList))
+	} else {
+		internal.GET("/memberships", r.views.MembershipsFunc)
+	}
+
+	membershipRoute := internal.Group("/membership")
+	if !r.config.Debug {
+		membershipRoute.Use(r.views.RequirePermission(permissions.ManageMembersMiscUnpaidList))
+	}
+
+	membershipRoute.Match(validMethods, "/add", r.views.MembershipAddFunc)
+	membershipRoute.Match(validMethods, "/unpaid", r.views.MembershipUnpaidFunc)
+	membershipRoute.Match(validMethods, "/import", r.views.MembershipImportFunc)
+	membershipRoute.Match(validMethods, "/:membershipid/delete", r.views.MembershipDeleteFunc)
+
+	membershipType := membershipRoute.Group("/type")
+	// membershipType can change which role paid members are given so also needs the roles permission
+	if !r.config.Debug {
+		membershipType.Use(r.views.RequirePermission(permissions.ManageMembersGroup))
+	}
+
+	membershipType.Match(validMethods, "/add", r.views.MembershipTypeAddFunc)
+	membershipType.Match(validMethods, "/:typeid/edit", r.views.MembershipTypeEditFunc)
+	membershipType.Match(validMethods, "/:typeid/delete", r.views.MembershipTypeDeleteFunc)
+
+	keylistRoute := internal.Group("/keylist")
+	// keylist can be seen and exported by anyone who keeps the keylist, only KeyList.Manage can give keys out
+	if !r.config.Debug {
+		keylistRoute.Use(r.views.RequirePermission(permissions.ManageMembersMicsKeyList))
+	}
+
+	keylistRoute.Match(validMethods, "/print", r.views.KeylistPrintFunc)
+	keylistRoute.Match(validMethods, "/lapsed", r.views.KeylistLapsedFunc)
+	keylistRoute.Match(validMethods, "/key/:keyid", r.views.KeylistKeyFunc)
+	keylistRoute.Match(validMethods, "", r.views.KeylistFunc)
+
+	var keylistManage []echo.MiddlewareFunc
+	if !r.config.Debug {
+		keylistManage = append(keylistManage, r.views.RequirePermission(permissions.KeyListManage))
+	}
+
+	keylistRoute.Match(validMethods, "/issue", r.views.KeylistIssueFunc, keylistManage...)
+	keylistRoute.Match(validMethods, "/grant/:grantid/extend", r.views.KeylistGrantExtendFunc, keylistManage...)
+	keylistRoute.Match(validMethods, "/grant/:grantid/return", r.views.KeylistGrantReturnFunc, keylistManage...)
+	keylistRoute.Match(validMethods, "/key/add", r.views.KeylistKeyAddFunc, keylistManage...)
+	keylistRoute.Match(validMethods, "/key/:keyid/edit", r.views.KeylistKeyEditFunc, keylistManage...)
+	keylistRoute.Match(validMethods, "/key/:keyid/delete", r.views.KeylistKeyDeleteFunc, keylistManage...)
+
 	internal.Match(validMethods, "/officerships", r.views.OfficershipsFunc,
 		r.views.RequirePermission(permissions.ManageMembersOfficers))
 
@@ -199,6 +335,9 @@ func (r *Router) loadRoutes() {
 
 	officershipsRoute.Match(validMethods, "/officers", r.views.OfficersFunc)
 	officershipsRoute.Match(validMethods, "/officer/add", r.views.OfficerAddFunc)
+	officershipsRoute.Match(validMethods, "/handover", r.views.OfficershipHandoverFunc)
+	officershipsRoute.Match(validMethods, "/rolesync", r.views.OfficershipRoleSyncFunc)
+	officershipsRoute.Match(validMethods, "/timeline", r.views.OfficershipTimelineFunc)
 
 	officer := officershipsRoute.Group("/officer/:officerid")
 	officer.Match(validMethods, "/edit", r.views.OfficerEditFunc)
@@ -230,6 +369,58 @@ func (r *Router) loadRoutes() {
 	crowdAppRoute.Match(validMethods, "/delete", r.views.CrowdAppDeleteFunc)
 	crowdAppRoute.Match(validMethods, "", r.views.CrowdAppFunc)
 
+	// webhooks send events to external services so are limited to SuperUser like crowd apps
+	if !r.config.Debug {
+		internal.Match(validMethods, "/webhooks", r.views.WebhooksFunc, r.views.RequirePermission(permissions.SuperUser))
+	} else {
+		internal.Match(validMethods, "/webhooks", r.views.WebhooksFunc)
+	}
+
+	webhookRoute := internal.Group("/webhook")
+	if !r.config.Debug {
+		webhookRoute.Use(r.views.RequirePermission(permissions.SuperUser))
+	}
+
+	webhookRoute.Match(validMethods, "/add", r.views.WebhookAddFunc)
+	webhookID := webhookRoute.Group("/:webhookid")
+	webhookID.Match(validMethods, "/edit", r.views.WebhookEditFunc)
+	webhookID.Match(validMethods, "/delete", r.views.WebhookDeleteFunc)
+	webhookID.Match(validMethods, "/delivery/:deliveryid/redeliver", r.views.WebhookRedeliverFunc)
+	webhookID.Match(validMethods, "", r.views.WebhookFunc)
+
+	// email templates change what every user is sent so are limited to SuperUser like webhooks
+	if !r.config.Debug {
+		internal.Match(validMethods, "/emailtemplates", r.views.EmailTemplatesFunc,
+			r.views.RequirePermission(permissions.SuperUser))
+	} else {
+		internal.Match(validMethods, "/emailtemplates", r.views.EmailTemplatesFunc)
+	}
+
+	emailTemplateRoute := internal.Group("/emailtemplate")
+	if !r.config.Debug {
+		emailTemplateRoute.Use(r.views.RequirePermission(permissions.SuperUser))
+	}
+
+	emailTemplateRoute.Match(validMethods, "/:name/translate", r.views.EmailTemplateTranslateFunc)
+	emailTemplateLanguage := emailTemplateRoute.Group("/:name/:language")
+	emailTemplateLanguage.Match(validMethods, "/edit", r.views.EmailTemplateEditFunc)
+	emailTemplateLanguage.Match(validMethods, "/preview", r.views.EmailTemplatePreviewFunc)
+	emailTemplateLanguage.Match(validMethods, "/test", r.views.EmailTemplateTestFunc)
+	emailTemplateLanguage.Match(validMethods, "/default", r.views.EmailTemplateDefaultFunc)
+	emailTemplateLanguage.Match(validMethods, "/delete", r.views.EmailTemplateDeleteFunc)
+	emailTemplateLanguage.Match(validMethods, "/version/:version/restore", r.views.EmailTemplateRestoreFunc)
+	emailTemplateLanguage.Match(validMethods, "/version/:version", r.views.EmailTemplateVersionFunc)
+	emailTemplateLanguage.Match(validMethods, "", r.views.EmailTemplateFunc)
+
+	// the mail queue has the reset links and passwords sent to users so is limited to SuperUser
+	mailRoute := internal.Group("/mail")
+	if !r.config.Debug {
+		mailRoute.Use(r.views.RequirePermission(permissions.SuperUser))
+	}
+
+	mailRoute.Match(validMethods, "/:messageid/resend", r.views.MailQueueResendFunc)
+	mailRoute.Match(validMethods, "", r.views.MailQueueFunc)
+
 	internalAPI := internal.Group("/api")
 	internalAPI.Match(validMethods, "/set_token", r.views.SetTokenHandler)
 	manage := internalAPI.Group("/manage")
@@ -242,6 +433,13 @@ func (r *Router) loadRoutes() {
 	api.GET("/set_token", r.views.SetTokenHandler, r.views.RequiresLoginJSON)
 	api.GET("/crowdcurrentuser", r.views.CrowdXMLHandler, r.views.RequiresLoginCrowd)
 	api.GET("/test", r.views.TestAPITokenFunc)
+	apiV1 := api.Group("/v1", r.views.RequiresAPIToken)
+	// apiV1 is for other services, they use an API token made on the manage API page
+	apiV1.POST("/authorize", r.views.AuthorizeFunc)
+	apiV1.GET("/users/:id/effective-permissions", r.views.EffectivePermissionsFunc)
+	apiV1.GET("/users/:id/userinfo", r.views.UserInfoFunc)
+	// public is for the other YSTV sites so doesn't require being logged in
+	api.GET("/public/officers", r.views.OfficerDirectoryFunc)
 	api.GET("/health", func(c echo.Context) error {
 		marshal, err := json.Marshal(struct {
 			Status int `json:"status"`
@@ -271,4 +469,7 @@ func (r *Router) loadRoutes() {
 	base.Match(validMethods, "signup", r.views.SignUpFunc)
 	base.Match(validMethods, "forgot", r.views.ForgotFunc)
 	base.Match(validMethods, "reset/:url", r.views.ResetURLFunc)
+	base.Match(validMethods, "email/verify/:token", r.views.EmailVerifyFunc)
+	base.Match(validMethods, "email/revert/:token", r.views.EmailRevertFunc)
+	base.Match(validMethods, "email/address/:token", r.views.EmailAddressFunc)
 }
@@ -59,6 +59,45 @@ const (
 	OfficershipTeamTemplate  Template = "officershipTeam.tmpl"
 	CrowdAppsTemplate        Template = "crowdApps.tmpl"
 	CrowdAppTemplate         Template = "crowdApp.tmpl"
+	WebhooksTemplate         Template = "webhooks.tmpl"
+	WebhookTemplate          Template = "webhook.tmpl"
+
+	OfficershipHandoverTemplate   Template = "officershipHandover.tmpl"
+	OfficerHandoverEmailTemplate  Template = "officerHandoverEmail.tmpl" // generated by go generate
+	OfficershipRoleSyncTemplate   Template = "officershipRoleSync.tmpl"
+	OfficerDirectoryTemplate      Template = "officerDirectory.tmpl"
+	OfficershipTimelineTemplate   Template = "officershipTimeline.tmpl"
+	RoleExpiryEmailTemplate       Template = "roleExpiryEmail.tmpl" // generated by go generate
+	AccessRequestTemplate         Template = "accessRequest.tmpl"
+	AccessRequestsTemplate        Template = "accessRequests.tmpl"
+	AccessRequestEmailTemplate    Template = "accessRequestEmail.tmpl"  // generated by go generate
+	AccessDecisionEmailTemplate   Template = "accessDecisionEmail.tmpl" // generated by go generate
+	AccessReviewsTemplate         Template = "accessReviews.tmpl"
+	AccessReviewTemplate          Template = "accessReview.tmpl"
+	AccessReviewTasksTemplate     Template = "accessReviewTasks.tmpl"
+	AccessReviewEmailTemplate     Template = "accessReviewEmail.tmpl" // generated by go generate
+	UserImportTemplate            Template = "userImport.tmpl"
+	UserImportJobTemplate         Template = "userImportJob.tmpl"
+	MembershipsTemplate           Template = "memberships.tmpl"
+	MembershipUnpaidTemplate      Template = "membershipUnpaid.tmpl"
+	MembershipImportTemplate      Template = "membershipImport.tmpl"
+	MembershipExpiryEmailTemplate Template = "membershipExpiryEmail.tmpl" // generated by go generate
+	KeylistTemplate               Template = "keylist.tmpl"
+	KeylistKeyTemplate            Template = "keylistKey.tmpl"
+	KeylistLapsedTemplate         Template = "keylistLapsed.tmpl"
+	KeylistPrintTemplate          Template = "keylistPrint.tmpl"
+	UserRetentionTemplate         Template = "userRetention.tmpl"
+	UserMergeTemplate             Template = "userMerge.tmpl"
+	UserDuplicatesTemplate        Template = "userDuplicates.tmpl"
+	EmailChangeTemplate           Template = "emailChange.tmpl"
+	EmailVerifyEmailTemplate      Template = "emailVerifyEmail.tmpl"  // generated by go generate
+	EmailChangedEmailTemplate     Template = "emailChangedEmail.tmpl" // generated by go generate
+	EmailAddressEmailTemplate     Template = "emailAddressEmail.tmpl" // generated by go generate
+	UserStatusesTemplate          Template = "userStatuses.tmpl"
+	PasswordExpiryEmailTemplate   Template = "passwordExpiryEmail.tmpl" // generated by go generate
+	EmailTemplatesTemplate        Template = "emailTemplates.tmpl"
+	EmailTemplateTemplate         Template = "emailTemplate.tmpl"
+	MailQueueTemplate             Template = "mailQueue.tmpl"
 )
 
 type TemplateType int
@@ -67,6 +106,7 @@ const (
 	NoNavType TemplateType = iota
 	PaginationType
 	RegularType
+	FragmentType
 )
 
 // NewTemplate returns the template format to be used
@@ -100,6 +140,13 @@ func (t *Templater) RenderTemplate(w io.Writer, data interface{}, mainTmpl Templ
 	case RegularType:
 		t1, err = t1.ParseFS(tmpls, "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
 			"_sidebar.tmpl", mainTmpl.String())
+	case FragmentType:
+		// fragments are embedded in other sites so don't have any of our page around them
+		t1 = template.New(mainTmpl.String())
+
+		t1.Funcs(t.getFuncMaps())
+
+		t1, err = t1.ParseFS(tmpls, mainTmpl.String())
 	default:
 		return fmt.Errorf("unable to parse template, invalid type: %d", templateType)
 	}
@@ -113,8 +160,14 @@ func (t *Templater) RenderTemplate(w io.Writer, data interface{}, mainTmpl Templ
 	return t1.Execute(w, data)
 }
 
-func (t *Templater) GetEmailTemplate(emailTemplate Template) (*template.Template, error) {
-	return template.New(emailTemplate.String()).ParseFS(tmpls, emailTemplate.String())
+// GetEmailSource returns an email template before it is parsed, it is the default that can be edited
+func GetEmailSource(emailTemplate Template) (string, error) {
+	b, err := tmpls.ReadFile(emailTemplate.String())
+	if err != nil {
+		return "", fmt.Errorf("failed to read email template: %w", err)
+	}
+
+	return string(b), nil
 }
 
 // getFuncMaps returns all the in built functions that templates can use
@@ -130,13 +183,7 @@ func (t *Templater) getFuncMaps() template.FuncMap {
 			return a - 1
 		},
 		"checkPermission": func(perms []permission.Permission, p string) bool {
-			m := permission1.SufficientPermissionsFor(permissions.Permissions(p))
-			for _, perm := range perms {
-				if m[perm.Name] {
-					return true
-				}
-			}
-			return false
+			return permission1.HasPermission(perms, permissions.Permissions(p))
 		},
 		"getUserModifierField": func(u user.User, atTime null.String, prefix string) template.HTML {
 			var s string
@@ -244,6 +291,58 @@ var (
 			"_sidebar.tmpl", "_pagination.tmpl"},
 		{"crowdApp.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
 			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"webhooks.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"webhook.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"officershipHandover.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"officershipRoleSync.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"officershipTimeline.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"officerDirectory.tmpl"},
+		{"officerHandoverEmail.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"roleExpiryEmail.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"accessRequest.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"accessRequests.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"accessRequestEmail.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"accessDecisionEmail.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"accessReviews.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"accessReview.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"accessReviewTasks.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"accessReviewEmail.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"userImport.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"userImportJob.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"memberships.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"membershipUnpaid.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"membershipImport.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"membershipExpiryEmail.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"keylist.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"keylistKey.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"keylistLapsed.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
+		{"keylistPrint.tmpl"},
+		{"userRetention.tmpl", "_base.tmpl", "_body.tmpl", "_head.tmpl", "_footer.tmpl", "_navbar.tmpl",
+			"_sidebar.tmpl", "_pagination.tmpl"},
 	}
 
 	_ = AllTemplates
@@ -6,7 +6,9 @@ import (
 
 	//nolint:gosec
 	"crypto/md5"
+	dbSQL "database/sql"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -14,10 +16,13 @@ import (
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/jinzhu/copier"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 
 	"github.com/ystv/web-auth/permission"
 	"github.com/ystv/web-auth/role"
 	"github.com/ystv/web-auth/utils"
+	"github.com/ystv/web-auth/webhook"
 )
 
 // countUsersAll will get the number of total users
@@ -45,6 +50,15 @@ func (s *Store) countUsersAll(ctx context.Context) (CountUsers, error) {
 
 // addUser will add a user
 func (s *Store) addUser(ctx context.Context, u User) (User, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return User{}, fmt.Errorf("failed to begin add user transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
 	builder := utils.PSQL().Insert("people.users").
 		Columns("username", "university_username", "pronouns", "email", "first_name", "last_name", "nickname",
 			"login_type", "password", "salt", "reset_pw", "enabled", "created_at", "created_by").
@@ -57,23 +71,135 @@ func (s *Store) addUser(ctx context.Context, u User) (User, error) {
 		panic(fmt.Errorf("failed to build sql for addUser: %w", err))
 	}
 
-	stmt, err := s.db.PrepareContext(ctx, sql)
+	err = tx.QueryRowxContext(ctx, sql, args...).Scan(&u.UserID)
 	if err != nil {
 		return User{}, fmt.Errorf("failed to add user: %w", err)
 	}
 
-	defer stmt.Close()
+	// the address a user is added with is trusted the same as it always has been
+	if u.Email != "" {
+		emailBuilder := utils.PSQL().Insert("people.user_emails").
+			Columns("user_id", "email", "verified", "is_primary", "verified_at").
+			Values(u.UserID, u.Email, true, true, time.Now())
+
+		sql, args, err = emailBuilder.ToSql()
+		if err != nil {
+			panic(fmt.Errorf("failed to build sql for addUser: %w", err))
+		}
 
-	err = stmt.QueryRow(args...).Scan(&u.UserID)
+		_, err = tx.ExecContext(ctx, sql, args...)
+		if err != nil {
+			return User{}, fmt.Errorf("failed to add user email, it may be used by someone else: %w", err)
+		}
+	}
+
+	err = s.webhook.Emit(ctx, tx, webhook.UserCreated, u)
 	if err != nil {
-		return User{}, fmt.Errorf("failed to add user: %w", err)
+		return User{}, fmt.Errorf("failed to emit user created: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return User{}, fmt.Errorf("failed to commit add user: %w", err)
 	}
 
 	return u, nil
 }
 
-// editUser will edit a user record by ID
-func (s *Store) editUser(ctx context.Context, u User) error {
+// getUserBuilder finds a user by the addresses in people.user_emails, people.users.email is only a copy of the
+// primary one
+func getUserBuilder(u1 User, l lookup) sq.SelectBuilder {
+	// an unverified primary address still belongs to the user, but they haven't shown they own it
+	emails := "SELECT user_id FROM people.user_emails WHERE lower(email) = lower(?) AND (verified OR is_primary)"
+	if l == loginUser {
+		emails = "SELECT user_id FROM people.user_emails WHERE lower(email) = lower(?) AND verified"
+	}
+
+	builder := utils.PSQL().Select("*").
+		From("people.users").
+		Where(sq.Or{
+			sq.And{sq.Eq{"username": u1.Username}, sq.NotEq{"username": ""}},
+			sq.And{sq.Expr("? <> ''", u1.Email), sq.Expr("user_id IN ("+emails+")", u1.Email)},
+			sq.And{sq.Eq{"ldap_username": u1.LDAPUsername}, sq.NotEq{"ldap_username": ""}},
+			sq.Eq{"user_id": u1.UserID}}).
+		Limit(1)
+
+	if l == existingUser {
+		builder = builder.Where(sq.Eq{"deleted_at": nil})
+	}
+
+	return builder
+}
+
+// addPasswordHistory keeps the user's current password hash before it is replaced, as part of the transaction given
+func (s *Store) addPasswordHistory(ctx context.Context, tx sqlx.ExecerContext, u User) error {
+	builder := utils.PSQL().Insert("people.password_history").
+		Columns("user_id", "password").
+		Values(u.UserID, u.Password)
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for addPasswordHistory: %w", err))
+	}
+
+	_, err = tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to add password history: %w", err)
+	}
+
+	return nil
+}
+
+// editUserPassword sets a user's password and when it was changed, the old password is kept in the history in the
+// same transaction so the password can't change without it
+func (s *Store) editUserPassword(ctx context.Context, old, u User) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin edit user password transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if old.Password.Valid && len(old.Password.String) > 0 {
+		err = s.addPasswordHistory(ctx, tx, old)
+		if err != nil {
+			return err
+		}
+	}
+
+	builder := utils.PSQL().Update("people.users").
+		SetMap(map[string]interface{}{
+			"password":                  u.Password,
+			"reset_pw":                  u.ResetPw,
+			"updated_by":                u.UpdatedBy,
+			"updated_at":                u.UpdatedAt,
+			"password_changed_at":       u.PasswordChangedAt,
+			"password_expiry_warned_at": u.PasswordWarnedAt,
+		}).
+		Where(sq.Eq{"user_id": u.UserID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for editUserPassword: %w", err))
+	}
+
+	_, err = tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to edit user password: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit edit user password: %w", err)
+	}
+
+	return nil
+}
+
+// editUser will edit a user record by ID, as part of the transaction given or straight on the db
+func (s *Store) editUser(ctx context.Context, tx sqlx.ExecerContext, u User) error {
 	builder := utils.PSQL().Update("people.users").
 		SetMap(map[string]interface{}{
 			"password":            u.Password,
@@ -84,6 +210,8 @@ func (s *Store) editUser(ctx context.Context, u User) error {
 			"reset_pw":            u.ResetPw,
 			"avatar":              u.Avatar,
 			"use_gravatar":        u.UseGravatar,
+			"hide_from_public":    u.HideFromPublic,
+			"language":            u.Language,
 			"first_name":          u.Firstname,
 			"nickname":            u.Nickname,
 			"last_name":           u.Lastname,
@@ -104,7 +232,7 @@ func (s *Store) editUser(ctx context.Context, u User) error {
 		panic(fmt.Errorf("failed to build sql for editUser: %w", err))
 	}
 
-	res, err := s.db.ExecContext(ctx, sql, args...)
+	res, err := tx.ExecContext(ctx, sql, args...)
 	if err != nil {
 		return fmt.Errorf("failed to edit user: %w", err)
 	}
@@ -122,20 +250,92 @@ func (s *Store) editUser(ctx context.Context, u User) error {
 	return nil
 }
 
-// getUser will get a user using any unique identity fields for a user
-func (s *Store) getUser(ctx context.Context, u1 User) (User, error) {
-	var u User
+// editUserAndEmit edits a user and queues the webhook events for it in the same transaction
+func (s *Store) editUserAndEmit(ctx context.Context, u User, events ...webhook.Event) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin edit user transaction: %w", err)
+	}
 
-	builder := utils.PSQL().Select("*").
-		From("people.users").
-		Where(sq.Or{
-			sq.And{sq.Eq{"username": u1.Username}, sq.NotEq{"username": ""}},
-			sq.And{sq.Eq{"email": u1.Email}, sq.NotEq{"email": ""}},
-			sq.And{sq.Eq{"ldap_username": u1.LDAPUsername}, sq.NotEq{"ldap_username": ""}},
-			sq.Eq{"user_id": u1.UserID}}).
-		Limit(1)
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	err = s.editUser(ctx, tx, u)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		err = s.webhook.Emit(ctx, tx, e, u)
+		if err != nil {
+			return fmt.Errorf("failed to emit %s: %w", e, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit edit user: %w", err)
+	}
+
+	return nil
+}
+
+// deleteUser soft deletes the user and releases their addresses so they can be used by another account, the
+// addresses are kept unverified until the user is anonymised so their mail can still be found
+func (s *Store) deleteUser(ctx context.Context, u User) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin delete user transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	err = s.editUser(ctx, tx, u)
+	if err != nil {
+		return err
+	}
+
+	builder := utils.PSQL().Update("people.user_emails").
+		SetMap(map[string]interface{}{
+			"verified":          false,
+			"is_primary":        false,
+			"verify_token":      nil,
+			"verify_expires_at": nil,
+		}).
+		Where(sq.Eq{"user_id": u.UserID})
 
 	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for deleteUser: %w", err))
+	}
+
+	_, err = tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to release emails of deleted user: %w", err)
+	}
+
+	err = s.webhook.Emit(ctx, tx, webhook.UserDeleted, u)
+	if err != nil {
+		return fmt.Errorf("failed to emit %s: %w", webhook.UserDeleted, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit delete user: %w", err)
+	}
+
+	return nil
+}
+
+// getUser will get a user using any unique identity fields for a user, the lookup is which users and addresses
+// are matched
+func (s *Store) getUser(ctx context.Context, u1 User, l lookup) (User, error) {
+	var u User
+
+	sql, args, err := getUserBuilder(u1, l).ToSql()
 	if err != nil {
 		panic(fmt.Errorf("failed to build sql for getUser: %w", err))
 	}
@@ -146,19 +346,79 @@ func (s *Store) getUser(ctx context.Context, u1 User) (User, error) {
 		return u, fmt.Errorf("failed to get user from db: %w", err)
 	}
 
+	u.Avatar = s.avatarURL(u)
+
+	return u, nil
+}
+
+// getUsersByID gets the users with the ids in one query, the ones that don't exist are left out
+func (s *Store) getUsersByID(ctx context.Context, userIDs []int) ([]User, error) {
+	var u []User
+
+	builder := utils.PSQL().Select("*").
+		From("people.users").
+		Where(sq.Eq{"user_id": userIDs}).
+		OrderBy("user_id")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getUsersByID: %w", err))
+	}
+
+	//nolint:musttag
+	err = s.db.SelectContext(ctx, &u, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users by id: %w", err)
+	}
+
+	for i := range u {
+		u[i].Avatar = s.avatarURL(u[i])
+	}
+
+	return u, nil
+}
+
+// avatarURL is the address of a user's avatar, their gravatar when they use it
+func (s *Store) avatarURL(u User) string {
 	switch avatar := u.Avatar; {
 	case u.UseGravatar:
 		//nolint:gosec
 		hash := md5.Sum([]byte(strings.ToLower(strings.TrimSpace(u.Email))))
-		u.Avatar = "https://www.gravatar.com/avatar/" + hex.EncodeToString(hash[:])
+		return "https://www.gravatar.com/avatar/" + hex.EncodeToString(hash[:])
 	case avatar == "":
-		u.Avatar = "/public/ystv-colour-white.png"
+		return "/public/ystv-colour-white.png"
 	case strings.Contains(avatar, s.cdnEndpoint):
+		return avatar
 	case strings.Contains(avatar, fmt.Sprintf("%d.", u.UserID)):
-		u.Avatar = "https://ystv.co.uk/static/images/members/thumb/" + avatar
+		return "https://ystv.co.uk/static/images/members/thumb/" + avatar
 	default:
 		log.Printf("unknown avatar, user id: %d, length: %d, db string: %s, continuing", u.UserID, len(u.Avatar), u.Avatar)
-		u.Avatar = ""
+		return ""
+	}
+}
+
+func (s *Store) getUserByUniversityUsername(ctx context.Context, u1 User) (User, error) {
+	var u User
+
+	builder := utils.PSQL().Select("*").
+		From("people.users").
+		Where(sq.And{
+			sq.Eq{"LOWER(university_username)": strings.ToLower(u1.UniversityUsername)},
+			sq.NotEq{"university_username": ""},
+			sq.Eq{"deleted_at": nil},
+		}).
+		OrderBy("user_id DESC").
+		Limit(1)
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getUserByUniversityUsername: %w", err))
+	}
+
+	//nolint:musttag
+	err = s.db.GetContext(ctx, &u, sql, args...)
+	if err != nil {
+		return u, fmt.Errorf("failed to get user by university username from db: %w", err)
 	}
 
 	return u, nil
@@ -167,12 +427,12 @@ func (s *Store) getUser(ctx context.Context, u1 User) (User, error) {
 // getUsers will get users search with sorting with size and page, enabled and deleted
 // Use the parameter direction for determining of the sorting will be ascending(asc) or descending(desc)
 func (s *Store) getUsers(ctx context.Context, size, page int, search, sortBy, direction, enabled,
-	deleted string) ([]User, int, error) {
+	deleted, status string) ([]User, int, error) {
 	var u []User
 
 	var count int
 
-	builder, err := s._getUsersBuilder(size, page, search, sortBy, direction, enabled, deleted)
+	builder, err := s._getUsersBuilder(size, page, search, sortBy, direction, enabled, deleted, status)
 	if err != nil {
 		return nil, -1, fmt.Errorf("failed to build sql for getUsers: %w", err)
 	}
@@ -221,7 +481,7 @@ func (s *Store) getUsers(ctx context.Context, size, page int, search, sortBy, di
 }
 
 func (s *Store) _getUsersBuilder(size, page int, search, sortBy, direction, enabled,
-	deleted string) (*sq.SelectBuilder, error) {
+	deleted, status string) (*sq.SelectBuilder, error) {
 	builder := utils.PSQL().Select("*", "count(*) OVER() AS full_count").
 		From("people.users")
 
@@ -251,6 +511,10 @@ func (s *Store) _getUsersBuilder(size, page int, search, sortBy, direction, enab
 		builder = builder.Where(sq.NotEq{"deleted_by": nil})
 	}
 
+	if len(status) > 0 {
+		builder = builder.Where(sq.Eq{"status": status})
+	}
+
 	if len(sortBy) > 0 && len(direction) > 0 {
 		switch direction {
 		case "asc":
@@ -292,15 +556,41 @@ func (s *Store) _getUsersBuilder(size, page int, search, sortBy, direction, enab
 	return &builder, nil
 }
 
+// CurrentRoleMemberWhere limits role_members rm to the memberships that count right now
+const CurrentRoleMemberWhere = "(rm.starts_at IS NULL OR rm.starts_at <= NOW()) AND " +
+	"(rm.ends_at IS NULL OR rm.ends_at > NOW())"
+
+// userRolesCTE is the roles a user is a member of, directly or inherited through a role including another,
+// UNION rather than UNION ALL means this still finishes if the inclusions somehow have a cycle. A role can be in it
+// twice when it is held both ways
+const userRolesCTE = `user_roles(role_id, inherited) AS (
+			SELECT rm.role_id, false
+			FROM people.role_members rm
+			WHERE rm.user_id = ? AND ` + CurrentRoleMemberWhere + `
+			UNION
+			SELECT ri.included_role_id, true
+			FROM people.role_inclusions ri
+			INNER JOIN user_roles ur ON ur.role_id = ri.role_id
+		)`
+
 // getPermissionsForUser returns all permissions for a user
 func (s *Store) getPermissionsForUser(ctx context.Context, u User) ([]permission.Permission, error) {
 	var p []permission.Permission
 
+	// the permissions from the user's roles and everything they imply in the hierarchy
 	builder := utils.PSQL().Select("p.*").
+		Prefix(`WITH RECURSIVE `+userRolesCTE+`, effective(permission_id) AS (
+			SELECT rp.permission_id
+			FROM people.role_permissions rp
+			INNER JOIN user_roles ur ON ur.role_id = rp.role_id
+			UNION
+			SELECT pi.child_permission_id
+			FROM people.permission_implications pi
+			INNER JOIN effective e ON e.permission_id = pi.parent_permission_id
+		)`, u.UserID).
 		From("people.permissions p").
-		LeftJoin("people.role_permissions rp ON rp.permission_id = p.permission_id").
-		LeftJoin("people.role_members rm ON rm.role_id = rp.role_id").
-		Where(sq.Eq{"rm.user_id": u.UserID})
+		InnerJoin("effective e ON e.permission_id = p.permission_id").
+		OrderBy("p.name")
 
 	sql, args, err := builder.ToSql()
 	if err != nil {
@@ -315,16 +605,11 @@ func (s *Store) getPermissionsForUser(ctx context.Context, u User) ([]permission
 	return p, nil
 }
 
-// getRolesForUser returns all roles for a user
+// getRolesForUser returns all roles for a user, including the ones included by their roles
 func (s *Store) getRolesForUser(ctx context.Context, u User) ([]role.Role, error) {
 	var r []role.Role
 
-	builder := utils.PSQL().Select("r.*").
-		From("people.roles r").
-		LeftJoin("people.role_members rm ON rm.role_id = r.role_id").
-		Where(sq.Eq{"rm.user_id": u.UserID})
-
-	sql, args, err := builder.ToSql()
+	sql, args, err := rolesForUserBuilder(u, "r.*").ToSql()
 	if err != nil {
 		panic(fmt.Errorf("failed to build sql for getRolesForUser: %w", err))
 	}
@@ -337,6 +622,75 @@ func (s *Store) getRolesForUser(ctx context.Context, u User) ([]role.Role, error
 	return r, nil
 }
 
+// getEffectiveRolesForUser returns all roles of a user marking the ones only held through another role
+func (s *Store) getEffectiveRolesForUser(ctx context.Context, u User) ([]EffectiveRole, error) {
+	var r []EffectiveRole
+
+	sql, args, err := rolesForUserBuilder(u, "r.*", "bool_and(ur.inherited) AS inherited").ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getEffectiveRolesForUser: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &r, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get effective roles for user: %w", err)
+	}
+
+	return r, nil
+}
+
+// rolesForUserBuilder selects the roles in userRolesCTE once each, a role held directly as well as inherited
+// counts as direct
+func rolesForUserBuilder(u User, columns ...string) sq.SelectBuilder {
+	return utils.PSQL().Select(columns...).
+		Prefix(`WITH RECURSIVE `+userRolesCTE, u.UserID).
+		From("people.roles r").
+		InnerJoin("user_roles ur ON ur.role_id = r.role_id").
+		GroupBy("r.role_id").
+		OrderBy("r.name")
+}
+
+// getEffectivePermissionsForRole returns every permission a member of a role has with where each came from,
+// a permission given in more than one way is returned once for each
+func (s *Store) getEffectivePermissionsForRole(ctx context.Context, r role.Role) ([]EffectivePermission, error) {
+	var p []EffectivePermission
+
+	builder := utils.PSQL().Select("p.*", "sr.role_id AS source_role_id", "sr.name AS source_role_name",
+		"ip.name AS implied_by").
+		Prefix(`WITH RECURSIVE included_roles(role_id) AS (
+			SELECT ?::int
+			UNION
+			SELECT ri.included_role_id
+			FROM people.role_inclusions ri
+			INNER JOIN included_roles ir ON ir.role_id = ri.role_id
+		), effective(permission_id, role_id, implied_by) AS (
+			SELECT rp.permission_id, rp.role_id, NULL::int
+			FROM people.role_permissions rp
+			INNER JOIN included_roles ir ON ir.role_id = rp.role_id
+			UNION
+			SELECT pi.child_permission_id, e.role_id, pi.parent_permission_id
+			FROM people.permission_implications pi
+			INNER JOIN effective e ON e.permission_id = pi.parent_permission_id
+		)`, r.RoleID).
+		From("effective e").
+		InnerJoin("people.permissions p ON p.permission_id = e.permission_id").
+		InnerJoin("people.roles sr ON sr.role_id = e.role_id").
+		LeftJoin("people.permissions ip ON ip.permission_id = e.implied_by").
+		OrderBy("p.name", "sr.name", "ip.name NULLS FIRST")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getEffectivePermissionsForRole: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &p, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get effective permissions for role: %w", err)
+	}
+
+	return p, nil
+}
+
 // getUsersForRole returns all users for a role - moved here for cycle import reasons
 func (s *Store) getUsersForRole(ctx context.Context, r role.Role) ([]User, error) {
 	var u []User
@@ -360,6 +714,52 @@ func (s *Store) getUsersForRole(ctx context.Context, r role.Role) ([]User, error
 	return u, nil
 }
 
+// getUsersWithPermission returns the current, enabled users who effectively have a permission, through the
+// permission hierarchy and role inclusions
+func (s *Store) getUsersWithPermission(ctx context.Context, p permission.Permission) ([]User, error) {
+	var u []User
+
+	builder := utils.PSQL().Select("u.*").
+		Prefix(`WITH RECURSIVE implying(permission_id) AS (
+			SELECT ?::int
+			UNION
+			SELECT pi.parent_permission_id
+			FROM people.permission_implications pi
+			INNER JOIN implying i ON i.permission_id = pi.child_permission_id
+		), granting_roles(role_id) AS (
+			SELECT rp.role_id
+			FROM people.role_permissions rp
+			INNER JOIN implying i ON i.permission_id = rp.permission_id
+			UNION
+			SELECT ri.role_id
+			FROM people.role_inclusions ri
+			INNER JOIN granting_roles gr ON gr.role_id = ri.included_role_id
+		)`, p.PermissionID).
+		From("people.users u").
+		Where(sq.And{
+			sq.Expr(`u.user_id IN (SELECT rm.user_id
+				FROM people.role_members rm
+				INNER JOIN granting_roles gr ON gr.role_id = rm.role_id
+				WHERE ` + CurrentRoleMemberWhere + `)`),
+			sq.Eq{"u.enabled": true},
+			sq.Eq{"u.deleted_at": nil},
+		}).
+		OrderBy("u.user_id")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getUsersWithPermission: %w", err))
+	}
+
+	//nolint:musttag
+	err = s.db.SelectContext(ctx, &u, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users with permission: %w", err)
+	}
+
+	return u, nil
+}
+
 // getRoleUser returns a role user - moved here for cycle import reasons
 func (s *Store) getRoleUser(ctx context.Context, ru1 RoleUser) (RoleUser, error) {
 	var ru RoleUser
@@ -421,68 +821,131 @@ func (s *Store) getUsersNotInRole(ctx context.Context, r role.Role) ([]User, err
 func (s *Store) addRoleUser(ctx context.Context, ru1 RoleUser) (RoleUser, error) {
 	var ru RoleUser
 
-	builder := utils.PSQL().Insert("people.role_members").
-		Columns("role_id", "user_id").
-		Values(ru1.RoleID, ru1.UserID).
-		Suffix("RETURNING role_id, user_id")
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return RoleUser{}, fmt.Errorf("failed to begin add role user transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
 
-	sql, args, err := builder.ToSql()
+	sql, args, err := addRoleUserBuilder(ru1).ToSql()
 	if err != nil {
 		panic(fmt.Errorf("failed to build sql for addRoleUser: %w", err))
 	}
 
-	stmt, err := s.db.PrepareContext(ctx, sql)
+	err = tx.GetContext(ctx, &ru, sql, args...)
 	if err != nil {
+		if errors.Is(err, dbSQL.ErrNoRows) {
+			return RoleUser{}, fmt.Errorf("failed to add role user: user %d is already a member of role %d",
+				ru1.UserID, ru1.RoleID)
+		}
+
 		return RoleUser{}, fmt.Errorf("failed to add role user: %w", err)
 	}
 
-	defer stmt.Close()
+	err = s.webhook.Emit(ctx, tx, webhook.RoleUserAdded, ru)
+	if err != nil {
+		return RoleUser{}, fmt.Errorf("failed to emit role user added: %w", err)
+	}
 
-	err = stmt.QueryRow(args...).Scan(&ru.RoleID, &ru.UserID)
+	err = tx.Commit()
 	if err != nil {
-		return RoleUser{}, fmt.Errorf("failed to add role user: %w", err)
+		return RoleUser{}, fmt.Errorf("failed to commit add role user: %w", err)
 	}
 
 	return ru, nil
 }
 
+// addRoleUserBuilder inserts the membership, when the user only has the role through a sync the membership is taken
+// over by the grant and the sync flags cleared so the syncs won't revoke it. A membership granted by hand is left
+// alone and nothing is returned
+func addRoleUserBuilder(ru RoleUser) sq.InsertBuilder {
+	return utils.PSQL().Insert("people.role_members").
+		Columns("role_id", "user_id", "starts_at", "ends_at", "granted_by", "reason").
+		Values(ru.RoleID, ru.UserID, ru.StartsAt, ru.EndsAt, ru.GrantedBy, ru.Reason).
+		Suffix(`ON CONFLICT (role_id, user_id) DO UPDATE SET starts_at = EXCLUDED.starts_at,
+			ends_at = EXCLUDED.ends_at, granted_by = EXCLUDED.granted_by, granted_at = NOW(), reason = EXCLUDED.reason,
+			officership_sync = false, membership_sync = false, expiry_notified_at = NULL
+			WHERE role_members.officership_sync OR role_members.membership_sync
+			RETURNING *`)
+}
+
 // removeRoleUser removes a link between a role.Role and User
 func (s *Store) removeRoleUser(ctx context.Context, ru RoleUser) error {
-	builder := utils.PSQL().Delete("people.role_members").
-		Where(sq.And{
-			sq.Eq{"role_id": ru.RoleID},
-			sq.Eq{"user_id": ru.UserID},
-		})
+	_, err := s.removeRoleUsers(ctx, sq.And{
+		sq.Eq{"role_id": ru.RoleID},
+		sq.Eq{"user_id": ru.UserID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove role user: %w", err)
+	}
 
-	sql, args, err := builder.ToSql()
+	return nil
+}
+
+// removeUserForRoles removes all links between role.Role and a User
+func (s *Store) removeUserForRoles(ctx context.Context, u User) error {
+	_, err := s.removeRoleUsers(ctx, sq.Eq{"user_id": u.UserID})
 	if err != nil {
-		panic(fmt.Errorf("failed to build sql for removeRoleUser: %w", err))
+		return fmt.Errorf("failed to remove user for roles: %w", err)
 	}
 
-	_, err = s.db.ExecContext(ctx, sql, args...)
+	return nil
+}
+
+// removeRoleForUsers removes all links between a role.Role and User
+func (s *Store) removeRoleForUsers(ctx context.Context, r role.Role) error {
+	_, err := s.removeRoleUsers(ctx, sq.Eq{"role_id": r.RoleID})
 	if err != nil {
-		return fmt.Errorf("failed to remove role user: %w", err)
+		return fmt.Errorf("failed to remove role for users: %w", err)
 	}
 
 	return nil
 }
 
-// removeUserForRoles removes all links between role.Role and a User
-func (s *Store) removeUserForRoles(ctx context.Context, u User) error {
+// removeRoleUsers deletes the role_members rows matching where and queues a removed event for each in the same
+// transaction, returning the rows removed
+func (s *Store) removeRoleUsers(ctx context.Context, where sq.Sqlizer) ([]RoleUser, error) {
+	var ru []RoleUser
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin remove role users transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
 	builder := utils.PSQL().Delete("people.role_members").
-		Where(sq.Eq{"user_id": u.UserID})
+		Where(where).
+		Suffix("RETURNING *")
 
 	sql, args, err := builder.ToSql()
 	if err != nil {
-		panic(fmt.Errorf("failed to build sql for removeUserForRoles: %w", err))
+		panic(fmt.Errorf("failed to build sql for removeRoleUsers: %w", err))
 	}
 
-	_, err = s.db.ExecContext(ctx, sql, args...)
+	err = tx.SelectContext(ctx, &ru, sql, args...)
 	if err != nil {
-		return fmt.Errorf("failed to remove user for roles: %w", err)
+		return nil, fmt.Errorf("failed to remove role users: %w", err)
 	}
 
-	return nil
+	for _, r := range ru {
+		err = s.webhook.Emit(ctx, tx, webhook.RoleUserRemoved, r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to emit role user removed: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit remove role users: %w", err)
+	}
+
+	return ru, nil
 }
 
 // getPermissionsForRole returns all permissions for a role - moved here for cycle import reasons
@@ -587,6 +1050,15 @@ func (s *Store) getPermissionsNotInRole(ctx context.Context, r role.Role) ([]per
 func (s *Store) addRolePermission(ctx context.Context, rp1 RolePermission) (RolePermission, error) {
 	var rp RolePermission
 
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return RolePermission{}, fmt.Errorf("failed to begin add rolePermission transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
 	builder := utils.PSQL().Insert("people.role_permissions").
 		Columns("role_id ", "permission_id").
 		Values(rp1.RoleID, rp1.PermissionID).
@@ -597,16 +1069,19 @@ func (s *Store) addRolePermission(ctx context.Context, rp1 RolePermission) (Role
 		panic(fmt.Errorf("failed to build sql for addRolePermission: %w", err))
 	}
 
-	stmt, err := s.db.PrepareContext(ctx, sql)
+	err = tx.QueryRowxContext(ctx, sql, args...).Scan(&rp.RoleID, &rp.PermissionID)
 	if err != nil {
 		return RolePermission{}, fmt.Errorf("failed to add rolePermission: %w", err)
 	}
 
-	defer stmt.Close()
+	err = s.webhook.Emit(ctx, tx, webhook.RolePermissionAdded, rp)
+	if err != nil {
+		return RolePermission{}, fmt.Errorf("failed to emit role permission added: %w", err)
+	}
 
-	err = stmt.QueryRow(args...).Scan(&rp.RoleID, &rp.PermissionID)
+	err = tx.Commit()
 	if err != nil {
-		return RolePermission{}, fmt.Errorf("failed to add rolePermission: %w", err)
+		return RolePermission{}, fmt.Errorf("failed to commit add rolePermission: %w", err)
 	}
 
 	return rp, nil
@@ -614,6 +1089,15 @@ func (s *Store) addRolePermission(ctx context.Context, rp1 RolePermission) (Role
 
 // removeRolePermission removes a link between a role.Role and permission.Permission
 func (s *Store) removeRolePermission(ctx context.Context, rp RolePermission) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin remove rolePermission transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
 	builder := utils.PSQL().Delete("people.role_permissions").
 		Where(sq.And{sq.Eq{"role_id": rp.RoleID}, sq.Eq{"permission_id": rp.PermissionID}})
 
@@ -622,10 +1106,310 @@ func (s *Store) removeRolePermission(ctx context.Context, rp RolePermission) err
 		panic(fmt.Errorf("failed to build sql for removeRolePermission: %w", err))
 	}
 
-	_, err = s.db.ExecContext(ctx, sql, args...)
+	_, err = tx.ExecContext(ctx, sql, args...)
 	if err != nil {
 		return fmt.Errorf("failed to delete rolePermission: %w", err)
 	}
 
+	err = s.webhook.Emit(ctx, tx, webhook.RolePermissionRemoved, rp)
+	if err != nil {
+		return fmt.Errorf("failed to emit role permission removed: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit remove rolePermission: %w", err)
+	}
+
 	return nil
 }
+
+// getRoleUsersForRole returns the memberships of a role.Role
+func (s *Store) getRoleUsersForRole(ctx context.Context, r role.Role) ([]RoleUser, error) {
+	var ru []RoleUser
+
+	builder := utils.PSQL().Select("*").
+		From("people.role_members").
+		Where(sq.Eq{"role_id": r.RoleID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getRoleUsersForRole: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &ru, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role users for role: %w", err)
+	}
+
+	return ru, nil
+}
+
+// getRoleUsersForUser returns the role_members rows of a user, these don't include the roles given by an inclusion
+func (s *Store) getRoleUsersForUser(ctx context.Context, u User) ([]RoleUser, error) {
+	var ru []RoleUser
+
+	builder := utils.PSQL().Select("*").
+		From("people.role_members").
+		Where(sq.Eq{"user_id": u.UserID}).
+		OrderBy("role_id")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getRoleUsersForUser: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &ru, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role users for user: %w", err)
+	}
+
+	return ru, nil
+}
+
+// getRoleUsersExpiringBefore returns the current memberships ending before a time that haven't been warned about
+func (s *Store) getRoleUsersExpiringBefore(ctx context.Context, before time.Time) ([]RoleUserExpiry, error) {
+	var ru []RoleUserExpiry
+
+	builder := utils.PSQL().Select("rm.*", "r.name AS role_name", "u.first_name", "u.email", "u.language").
+		From("people.role_members rm").
+		InnerJoin("people.roles r ON r.role_id = rm.role_id").
+		InnerJoin("people.users u ON u.user_id = rm.user_id").
+		Where(CurrentRoleMemberWhere).
+		Where(sq.And{
+			sq.Lt{"rm.ends_at": before},
+			sq.Eq{"rm.expiry_notified_at": nil},
+			sq.Eq{"u.deleted_at": nil},
+			sq.Eq{"u.enabled": true},
+		}).
+		OrderBy("rm.ends_at")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getRoleUsersExpiringBefore: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &ru, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expiring role users: %w", err)
+	}
+
+	return ru, nil
+}
+
+// setRoleUserExpiryNotified records when the user was warned their membership is ending
+func (s *Store) setRoleUserExpiryNotified(ctx context.Context, ru RoleUser) error {
+	builder := utils.PSQL().Update("people.role_members").
+		Set("expiry_notified_at", time.Now()).
+		Where(sq.And{
+			sq.Eq{"role_id": ru.RoleID},
+			sq.Eq{"user_id": ru.UserID},
+		})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for setRoleUserExpiryNotified: %w", err))
+	}
+
+	_, err = s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to set role user expiry notified: %w", err)
+	}
+
+	return nil
+}
+
+// removeExpiredRoleUsers deletes the memberships whose end has passed
+func (s *Store) removeExpiredRoleUsers(ctx context.Context) ([]RoleUser, error) {
+	ru, err := s.removeRoleUsers(ctx, sq.Expr("ends_at <= NOW()"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove expired role users: %w", err)
+	}
+
+	return ru, nil
+}
+
+func (s *Store) getUsersToAnonymise(ctx context.Context, deletedBefore time.Time) ([]User, error) {
+	var u []User
+
+	builder := utils.PSQL().Select("*").
+		From("people.users").
+		Where(sq.And{
+			sq.NotEq{"deleted_at": nil},
+			sq.Eq{"anonymised_at": nil},
+		}).
+		OrderBy("deleted_at")
+
+	if !deletedBefore.IsZero() {
+		builder = builder.Where(sq.Lt{"deleted_at": deletedBefore})
+	}
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getUsersToAnonymise: %w", err))
+	}
+
+	//nolint:musttag
+	err = s.db.SelectContext(ctx, &u, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users to anonymise: %w", err)
+	}
+
+	return u, nil
+}
+
+// anonymiseUser replaces everything that identifies the user with placeholders, deleted_at is checked again so an
+// account that has been restored in the meantime isn't touched
+func (s *Store) anonymiseUser(ctx context.Context, u User) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin anonymise user transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	builder := utils.PSQL().Update("people.users").
+		SetMap(map[string]interface{}{
+			"username":            deletedUsername(u.UserID),
+			"university_username": "",
+			"ldap_username":       nil,
+			"email":               deletedEmail(u.UserID),
+			"first_name":          "*Deleted*",
+			"nickname":            "",
+			"last_name":           "*User*",
+			"pronouns":            nil,
+			"password":            "",
+			"salt":                "",
+			"avatar":              "",
+			"use_gravatar":        false,
+			"hide_from_public":    true,
+			"last_login":          nil,
+			"enabled":             false,
+			"anonymised_at":       sq.Expr("NOW()"),
+		}).
+		Where(sq.And{
+			sq.Eq{"user_id": u.UserID},
+			sq.NotEq{"deleted_at": nil},
+			sq.Eq{"anonymised_at": nil},
+		})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for anonymiseUser: %w", err))
+	}
+
+	res, err := tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to anonymise user: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to anonymise user: %w", err)
+	}
+
+	if rows < 1 {
+		return fmt.Errorf("failed to anonymise user: user %d isn't deleted or has already been anonymised", u.UserID)
+	}
+
+	var addresses pq.StringArray
+
+	sql, args, err = userAddressesBuilder(u).ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for anonymiseUser addresses: %w", err))
+	}
+
+	err = tx.SelectContext(ctx, &addresses, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to get addresses of anonymised user: %w", err)
+	}
+
+	for _, b := range anonymiseBuilders(u, addresses) {
+		sql, args, err = b.ToSql()
+		if err != nil {
+			panic(fmt.Errorf("failed to build sql for anonymiseUser: %w", err))
+		}
+
+		_, err = tx.ExecContext(ctx, sql, args...)
+		if err != nil {
+			return fmt.Errorf("failed to scrub personal details of anonymised user: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit anonymise user: %w", err)
+	}
+
+	return nil
+}
+
+// userAddressesBuilder selects every address a user has had, lowercased
+func userAddressesBuilder(u User) sq.SelectBuilder {
+	return utils.PSQL().Select("LOWER(email)").
+		From("people.user_emails").
+		Where(sq.Eq{"user_id": u.UserID}).
+		Suffix(`UNION SELECT LOWER(old_email) FROM people.email_changes WHERE user_id = ?
+			UNION SELECT LOWER(new_email) FROM people.email_changes WHERE user_id = ?`, u.UserID, u.UserID)
+}
+
+// anonymiseBuilders scrubs what is left about a user outside people.users, the mail sent to their addresses, the
+// webhook payloads about them, the notes about their memberships and keys and their addresses and password history
+func anonymiseBuilders(u User, addresses pq.StringArray) []sq.Sqlizer {
+	if addresses == nil {
+		addresses = pq.StringArray{}
+	}
+
+	replace := func(column string) sq.Sqlizer {
+		return sq.Expr(fmt.Sprintf("ARRAY(SELECT CASE WHEN LOWER(a) = ANY(?) THEN ? ELSE a END FROM UNNEST(%s) a)",
+			column), addresses, deletedEmail(u.UserID))
+	}
+
+	return []sq.Sqlizer{
+		utils.PSQL().Update("web_auth.mail_queue").
+			SetMap(map[string]interface{}{
+				"text_body": "",
+				"html_body": "",
+				"to_address": sq.Expr("CASE WHEN LOWER(to_address) = ANY(?) THEN ? ELSE to_address END",
+					addresses, deletedEmail(u.UserID)),
+				"cc_addresses":  replace("cc_addresses"),
+				"bcc_addresses": replace("bcc_addresses"),
+			}).
+			Where(sq.Or{
+				sq.Expr("LOWER(to_address) = ANY(?)", addresses),
+				sq.Expr("EXISTS (SELECT 1 FROM UNNEST(cc_addresses || bcc_addresses) a WHERE LOWER(a) = ANY(?))",
+					addresses),
+			}),
+		utils.PSQL().Update("web_auth.webhook_deliveries").
+			Set("payload", sq.Expr("jsonb_set(payload::jsonb, '{data}', jsonb_build_object('userID', ?::int))::text",
+				u.UserID)).
+			Where(sq.Expr("(payload::jsonb -> 'data' ->> 'userID') = ?", strconv.Itoa(u.UserID))),
+		utils.PSQL().Update("people.memberships").
+			Set("reference", "").
+			Where(sq.Eq{"user_id": u.UserID}),
+		utils.PSQL().Update("people.keylist_events").
+			Set("note", "").
+			Where(sq.Expr("grant_id IN (SELECT grant_id FROM people.keylist_grants WHERE user_id = ?)", u.UserID)),
+		utils.PSQL().Update("people.keylist_grants").
+			Set("note", "").
+			Where(sq.Eq{"user_id": u.UserID}),
+		utils.PSQL().Delete("people.email_changes").
+			Where(sq.Eq{"user_id": u.UserID}),
+		utils.PSQL().Delete("people.user_emails").
+			Where(sq.Eq{"user_id": u.UserID}),
+		utils.PSQL().Delete("people.password_history").
+			Where(sq.Eq{"user_id": u.UserID}),
+	}
+}
+
+// deletedUsername is the username of a deleted user, it frees their real one for another account
+func deletedUsername(userID int) string {
+	return fmt.Sprintf("deleted-%d", userID)
+}
+
+// deletedEmail is the address of a deleted user, it frees their real one for another account
+func deletedEmail(userID int) string {
+	return fmt.Sprintf("noreply+%d@ystv.co.uk", userID)
+}
@@ -0,0 +1,138 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v4"
+)
+
+func TestAddRoleUserSQL(t *testing.T) {
+	sql, args, err := addRoleUserBuilder(RoleUser{RoleID: 3, UserID: 1, GrantedBy: null.IntFrom(2),
+		Reason: "Editing"}).ToSql()
+	require.NoError(t, err)
+
+	// a membership from a sync is taken over and no longer revoked by it, one given by hand isn't touched
+	assert.Contains(t, sql, "ON CONFLICT (role_id, user_id) DO UPDATE SET")
+	assert.Contains(t, sql, "officership_sync = false, membership_sync = false")
+	assert.Contains(t, sql, "WHERE role_members.officership_sync OR role_members.membership_sync")
+	assert.Contains(t, sql, "RETURNING *")
+	assert.Len(t, args, 6)
+}
+
+func TestGetUserSQL(t *testing.T) {
+	u := User{Username: "someone@example.com", Email: "someone@example.com"}
+
+	sql, _, err := getUserBuilder(u, anyUser).ToSql()
+	require.NoError(t, err)
+
+	// people.users.email is only a copy of the primary address, it isn't matched
+	assert.NotContains(t, sql, "OR lower(email)")
+	assert.Contains(t, sql, "FROM people.user_emails WHERE lower(email) = lower($4) AND (verified OR is_primary))")
+	assert.NotContains(t, sql, "deleted_at")
+
+	sql, _, err = getUserBuilder(u, existingUser).ToSql()
+	require.NoError(t, err)
+	assert.Contains(t, sql, "AND deleted_at IS NULL")
+
+	// an unverified primary address can't be used to log in or reset the password
+	sql, _, err = getUserBuilder(u, loginUser).ToSql()
+	require.NoError(t, err)
+	assert.Contains(t, sql, "FROM people.user_emails WHERE lower(email) = lower($4) AND verified)")
+	assert.NotContains(t, sql, "is_primary")
+}
+
+func TestRolesForUserSQL(t *testing.T) {
+	sql, args, err := rolesForUserBuilder(User{UserID: 1}, "r.*", "bool_and(ur.inherited) AS inherited").ToSql()
+	require.NoError(t, err)
+
+	// UNION keeps the recursion finite if the inclusions ever have a cycle
+	assert.Contains(t, sql, "WITH RECURSIVE user_roles(role_id, inherited) AS (")
+	assert.Contains(t, sql, "UNION\n")
+	assert.NotContains(t, sql, "UNION ALL")
+
+	// only current memberships count, a direct membership isn't inherited and anything reached through an inclusion is
+	assert.Contains(t, sql, "SELECT rm.role_id, false")
+	assert.Contains(t, sql, "WHERE rm.user_id = $1 AND "+CurrentRoleMemberWhere)
+	assert.Contains(t, sql, "SELECT ri.included_role_id, true")
+
+	// a role held both ways is returned once, as direct
+	assert.Contains(t, sql, "GROUP BY r.role_id ORDER BY r.name")
+	assert.Equal(t, []interface{}{1}, args)
+}
+
+func TestAnonymiseSQL(t *testing.T) {
+	u := User{UserID: 7}
+
+	sql, args, err := userAddressesBuilder(u).ToSql()
+	require.NoError(t, err)
+
+	// the addresses the user has had, their primary one is in people.user_emails as well
+	assert.Contains(t, sql, "SELECT LOWER(email) FROM people.user_emails WHERE user_id = $1")
+	assert.Contains(t, sql, "UNION SELECT LOWER(old_email) FROM people.email_changes WHERE user_id = $2")
+	assert.Equal(t, []interface{}{7, 7, 7}, args)
+
+	addresses := pq.StringArray{"jane@example.com"}
+
+	statements := make([]string, 0)
+
+	for _, b := range anonymiseBuilders(u, addresses) {
+		sql, args, err = b.ToSql()
+		require.NoError(t, err)
+
+		assert.NotEmpty(t, args)
+
+		statements = append(statements, sql)
+	}
+
+	require.Len(t, statements, 8)
+
+	// the bodies of the mail sent to the user are scrubbed and their addresses replaced
+	assert.Contains(t, statements[0], "UPDATE web_auth.mail_queue SET")
+	assert.Contains(t, statements[0], "html_body = $")
+	assert.Contains(t, statements[0], "text_body = $")
+	assert.Contains(t, statements[0], "to_address = CASE WHEN LOWER(to_address) = ANY($")
+	assert.Contains(t, statements[0], "WHERE (LOWER(to_address) = ANY($")
+
+	// the webhook payloads about the user only keep their id
+	assert.Contains(t, statements[1], "UPDATE web_auth.webhook_deliveries SET payload = jsonb_set(")
+	assert.Contains(t, statements[1], "WHERE (payload::jsonb -> 'data' ->> 'userID') = $2")
+
+	assert.Equal(t, "UPDATE people.memberships SET reference = $1 WHERE user_id = $2", statements[2])
+	assert.Contains(t, statements[3], "UPDATE people.keylist_events SET note = $1")
+	assert.Equal(t, "UPDATE people.keylist_grants SET note = $1 WHERE user_id = $2", statements[4])
+	assert.Equal(t, "DELETE FROM people.email_changes WHERE user_id = $1", statements[5])
+	assert.Equal(t, "DELETE FROM people.user_emails WHERE user_id = $1", statements[6])
+	assert.Equal(t, "DELETE FROM people.password_history WHERE user_id = $1", statements[7])
+}
+
+func TestDeletedPlaceholders(t *testing.T) {
+	assert.Equal(t, "deleted-7", deletedUsername(7))
+	assert.Equal(t, "noreply+7@ystv.co.uk", deletedEmail(7))
+}
+
+func TestAvatarURL(t *testing.T) {
+	s := &Store{cdnEndpoint: "cdn.ystv.co.uk"}
+
+	assert.Equal(t, "https://www.gravatar.com/avatar/16d113840f999444259f73bac9ab8b10",
+		s.avatarURL(User{UserID: 1, Email: " Someone@Example.com ", UseGravatar: true, Avatar: "1.jpg"}))
+	assert.Equal(t, "/public/ystv-colour-white.png", s.avatarURL(User{UserID: 1}))
+	assert.Equal(t, "https://cdn.ystv.co.uk/avatars/1.png",
+		s.avatarURL(User{UserID: 1, Avatar: "https://cdn.ystv.co.uk/avatars/1.png"}))
+	assert.Equal(t, "https://ystv.co.uk/static/images/members/thumb/1.jpg", s.avatarURL(User{UserID: 1, Avatar: "1.jpg"}))
+}
+
+func TestApplyEdit(t *testing.T) {
+	existing := User{UserID: 1, Firstname: "Jane", Email: "jane@ystv.co.uk", Enabled: true}
+
+	u, err := applyEdit(existing, User{UserID: 1, Firstname: "Janet", Email: "Jane@YSTV.co.uk", Enabled: true})
+	require.NoError(t, err)
+	assert.Equal(t, "Janet", u.Firstname)
+	assert.Equal(t, "jane@ystv.co.uk", u.Email)
+
+	// the email is a copy of the primary address in people.user_emails so it can't be changed here
+	_, err = applyEdit(existing, User{UserID: 1, Email: "jane.doe@ystv.co.uk"})
+	assert.Error(t, err)
+}
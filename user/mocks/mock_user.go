@@ -12,6 +12,7 @@ package mock_user
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	permission "github.com/ystv/web-auth/permission"
 	role "github.com/ystv/web-auth/role"
@@ -88,6 +89,20 @@ func (mr *MockRepoMockRecorder) AddUser(arg0, arg1, arg2 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockRepo)(nil).AddUser), arg0, arg1, arg2)
 }
 
+// AnonymiseUser mocks base method.
+func (m *MockRepo) AnonymiseUser(arg0 context.Context, arg1 user.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnonymiseUser", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AnonymiseUser indicates an expected call of AnonymiseUser.
+func (mr *MockRepoMockRecorder) AnonymiseUser(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnonymiseUser", reflect.TypeOf((*MockRepo)(nil).AnonymiseUser), arg0, arg1)
+}
+
 // CountUsersAll mocks base method.
 func (m *MockRepo) CountUsersAll(arg0 context.Context) (user.CountUsers, error) {
 	m.ctrl.T.Helper()
@@ -173,6 +188,66 @@ func (mr *MockRepoMockRecorder) EditUserPassword(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EditUserPassword", reflect.TypeOf((*MockRepo)(nil).EditUserPassword), arg0, arg1)
 }
 
+// GetEffectivePermissionsForRole mocks base method.
+func (m *MockRepo) GetEffectivePermissionsForRole(arg0 context.Context, arg1 role.Role) ([]user.EffectivePermission, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEffectivePermissionsForRole", arg0, arg1)
+	ret0, _ := ret[0].([]user.EffectivePermission)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEffectivePermissionsForRole indicates an expected call of GetEffectivePermissionsForRole.
+func (mr *MockRepoMockRecorder) GetEffectivePermissionsForRole(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEffectivePermissionsForRole", reflect.TypeOf((*MockRepo)(nil).GetEffectivePermissionsForRole), arg0, arg1)
+}
+
+// GetEffectiveRolesForUser mocks base method.
+func (m *MockRepo) GetEffectiveRolesForUser(arg0 context.Context, arg1 user.User) ([]user.EffectiveRole, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEffectiveRolesForUser", arg0, arg1)
+	ret0, _ := ret[0].([]user.EffectiveRole)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEffectiveRolesForUser indicates an expected call of GetEffectiveRolesForUser.
+func (mr *MockRepoMockRecorder) GetEffectiveRolesForUser(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEffectiveRolesForUser", reflect.TypeOf((*MockRepo)(nil).GetEffectiveRolesForUser), arg0, arg1)
+}
+
+// GetExistingUser mocks base method.
+func (m *MockRepo) GetExistingUser(arg0 context.Context, arg1 user.User) (user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExistingUser", arg0, arg1)
+	ret0, _ := ret[0].(user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExistingUser indicates an expected call of GetExistingUser.
+func (mr *MockRepoMockRecorder) GetExistingUser(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExistingUser", reflect.TypeOf((*MockRepo)(nil).GetExistingUser), arg0, arg1)
+}
+
+// GetLoginUser mocks base method.
+func (m *MockRepo) GetLoginUser(arg0 context.Context, arg1 user.User) (user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginUser", arg0, arg1)
+	ret0, _ := ret[0].(user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginUser indicates an expected call of GetLoginUser.
+func (mr *MockRepoMockRecorder) GetLoginUser(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginUser", reflect.TypeOf((*MockRepo)(nil).GetLoginUser), arg0, arg1)
+}
+
 // GetPermissionsForRole mocks base method.
 func (m *MockRepo) GetPermissionsForRole(arg0 context.Context, arg1 role.Role) ([]permission.Permission, error) {
 	m.ctrl.T.Helper()
@@ -248,6 +323,51 @@ func (mr *MockRepoMockRecorder) GetRoleUser(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoleUser", reflect.TypeOf((*MockRepo)(nil).GetRoleUser), arg0, arg1)
 }
 
+// GetRoleUsersExpiringBefore mocks base method.
+func (m *MockRepo) GetRoleUsersExpiringBefore(arg0 context.Context, arg1 time.Time) ([]user.RoleUserExpiry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoleUsersExpiringBefore", arg0, arg1)
+	ret0, _ := ret[0].([]user.RoleUserExpiry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoleUsersExpiringBefore indicates an expected call of GetRoleUsersExpiringBefore.
+func (mr *MockRepoMockRecorder) GetRoleUsersExpiringBefore(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoleUsersExpiringBefore", reflect.TypeOf((*MockRepo)(nil).GetRoleUsersExpiringBefore), arg0, arg1)
+}
+
+// GetRoleUsersForRole mocks base method.
+func (m *MockRepo) GetRoleUsersForRole(arg0 context.Context, arg1 role.Role) ([]user.RoleUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoleUsersForRole", arg0, arg1)
+	ret0, _ := ret[0].([]user.RoleUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoleUsersForRole indicates an expected call of GetRoleUsersForRole.
+func (mr *MockRepoMockRecorder) GetRoleUsersForRole(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoleUsersForRole", reflect.TypeOf((*MockRepo)(nil).GetRoleUsersForRole), arg0, arg1)
+}
+
+// GetRoleUsersForUser mocks base method.
+func (m *MockRepo) GetRoleUsersForUser(arg0 context.Context, arg1 user.User) ([]user.RoleUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoleUsersForUser", arg0, arg1)
+	ret0, _ := ret[0].([]user.RoleUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoleUsersForUser indicates an expected call of GetRoleUsersForUser.
+func (mr *MockRepoMockRecorder) GetRoleUsersForUser(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoleUsersForUser", reflect.TypeOf((*MockRepo)(nil).GetRoleUsersForUser), arg0, arg1)
+}
+
 // GetRolesForPermission mocks base method.
 func (m *MockRepo) GetRolesForPermission(arg0 context.Context, arg1 permission.Permission) ([]role.Role, error) {
 	m.ctrl.T.Helper()
@@ -293,6 +413,21 @@ func (mr *MockRepoMockRecorder) GetUser(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockRepo)(nil).GetUser), arg0, arg1)
 }
 
+// GetUserByUniversityUsername mocks base method.
+func (m *MockRepo) GetUserByUniversityUsername(arg0 context.Context, arg1 user.User) (user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByUniversityUsername", arg0, arg1)
+	ret0, _ := ret[0].(user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByUniversityUsername indicates an expected call of GetUserByUniversityUsername.
+func (mr *MockRepoMockRecorder) GetUserByUniversityUsername(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUniversityUsername", reflect.TypeOf((*MockRepo)(nil).GetUserByUniversityUsername), arg0, arg1)
+}
+
 // GetUserValid mocks base method.
 func (m *MockRepo) GetUserValid(arg0 context.Context, arg1 user.User) (user.User, error) {
 	m.ctrl.T.Helper()
@@ -309,9 +444,9 @@ func (mr *MockRepoMockRecorder) GetUserValid(arg0, arg1 any) *gomock.Call {
 }
 
 // GetUsers mocks base method.
-func (m *MockRepo) GetUsers(arg0 context.Context, arg1, arg2 int, arg3, arg4, arg5, arg6, arg7 string) ([]user.User, int, error) {
+func (m *MockRepo) GetUsers(arg0 context.Context, arg1, arg2 int, arg3, arg4, arg5, arg6, arg7, arg8 string) ([]user.User, int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsers", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+	ret := m.ctrl.Call(m, "GetUsers", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8)
 	ret0, _ := ret[0].([]user.User)
 	ret1, _ := ret[1].(int)
 	ret2, _ := ret[2].(error)
@@ -319,9 +454,24 @@ func (m *MockRepo) GetUsers(arg0 context.Context, arg1, arg2 int, arg3, arg4, ar
 }
 
 // GetUsers indicates an expected call of GetUsers.
-func (mr *MockRepoMockRecorder) GetUsers(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7 any) *gomock.Call {
+func (mr *MockRepoMockRecorder) GetUsers(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockRepo)(nil).GetUsers), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8)
+}
+
+// GetUsersByID mocks base method.
+func (m *MockRepo) GetUsersByID(arg0 context.Context, arg1 []int) ([]user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersByID", arg0, arg1)
+	ret0, _ := ret[0].([]user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersByID indicates an expected call of GetUsersByID.
+func (mr *MockRepoMockRecorder) GetUsersByID(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockRepo)(nil).GetUsers), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersByID", reflect.TypeOf((*MockRepo)(nil).GetUsersByID), arg0, arg1)
 }
 
 // GetUsersForRole mocks base method.
@@ -354,6 +504,65 @@ func (mr *MockRepoMockRecorder) GetUsersNotInRole(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersNotInRole", reflect.TypeOf((*MockRepo)(nil).GetUsersNotInRole), arg0, arg1)
 }
 
+// GetUsersToAnonymise mocks base method.
+func (m *MockRepo) GetUsersToAnonymise(arg0 context.Context, arg1 time.Time) ([]user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersToAnonymise", arg0, arg1)
+	ret0, _ := ret[0].([]user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersToAnonymise indicates an expected call of GetUsersToAnonymise.
+func (mr *MockRepoMockRecorder) GetUsersToAnonymise(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersToAnonymise", reflect.TypeOf((*MockRepo)(nil).GetUsersToAnonymise), arg0, arg1)
+}
+
+// GetUsersWithPermission mocks base method.
+func (m *MockRepo) GetUsersWithPermission(arg0 context.Context, arg1 permission.Permission) ([]user.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUsersWithPermission", arg0, arg1)
+	ret0, _ := ret[0].([]user.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUsersWithPermission indicates an expected call of GetUsersWithPermission.
+func (mr *MockRepoMockRecorder) GetUsersWithPermission(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersWithPermission", reflect.TypeOf((*MockRepo)(nil).GetUsersWithPermission), arg0, arg1)
+}
+
+// RemoveExpiredRoleUsers mocks base method.
+func (m *MockRepo) RemoveExpiredRoleUsers(arg0 context.Context) ([]user.RoleUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveExpiredRoleUsers", arg0)
+	ret0, _ := ret[0].([]user.RoleUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveExpiredRoleUsers indicates an expected call of RemoveExpiredRoleUsers.
+func (mr *MockRepoMockRecorder) RemoveExpiredRoleUsers(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveExpiredRoleUsers", reflect.TypeOf((*MockRepo)(nil).RemoveExpiredRoleUsers), arg0)
+}
+
+// RemoveRoleForUsers mocks base method.
+func (m *MockRepo) RemoveRoleForUsers(arg0 context.Context, arg1 role.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveRoleForUsers", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveRoleForUsers indicates an expected call of RemoveRoleForUsers.
+func (mr *MockRepoMockRecorder) RemoveRoleForUsers(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveRoleForUsers", reflect.TypeOf((*MockRepo)(nil).RemoveRoleForUsers), arg0, arg1)
+}
+
 // RemoveRolePermission mocks base method.
 func (m *MockRepo) RemoveRolePermission(arg0 context.Context, arg1 user.RolePermission) error {
 	m.ctrl.T.Helper()
@@ -396,6 +605,20 @@ func (mr *MockRepoMockRecorder) RemoveUserForRoles(arg0, arg1 any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveUserForRoles", reflect.TypeOf((*MockRepo)(nil).RemoveUserForRoles), arg0, arg1)
 }
 
+// SetRoleUserExpiryNotified mocks base method.
+func (m *MockRepo) SetRoleUserExpiryNotified(arg0 context.Context, arg1 user.RoleUser) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRoleUserExpiryNotified", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRoleUserExpiryNotified indicates an expected call of SetRoleUserExpiryNotified.
+func (mr *MockRepoMockRecorder) SetRoleUserExpiryNotified(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRoleUserExpiryNotified", reflect.TypeOf((*MockRepo)(nil).SetRoleUserExpiryNotified), arg0, arg1)
+}
+
 // SetUserLoggedIn mocks base method.
 func (m *MockRepo) SetUserLoggedIn(arg0 context.Context, arg1 user.User) error {
 	m.ctrl.T.Helper()
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Clarilab/gocloaksession"
@@ -13,6 +14,7 @@ import (
 	"github.com/ystv/web-auth/permission"
 	"github.com/ystv/web-auth/role"
 	"github.com/ystv/web-auth/utils"
+	"github.com/ystv/web-auth/webhook"
 )
 
 //go:generate mockgen -destination mocks/mock_user.go -package mock_user github.com/ystv/web-auth/user Repo
@@ -21,8 +23,12 @@ type (
 	Repo interface {
 		CountUsersAll(context.Context) (CountUsers, error)
 		GetUser(context.Context, User) (User, error)
+		GetExistingUser(context.Context, User) (User, error)
+		GetLoginUser(context.Context, User) (User, error)
 		GetUserValid(context.Context, User) (User, error)
-		GetUsers(context.Context, int, int, string, string, string, string, string) ([]User, int, error)
+		GetUserByUniversityUsername(context.Context, User) (User, error)
+		GetUsersByID(context.Context, []int) ([]User, error)
+		GetUsers(context.Context, int, int, string, string, string, string, string, string) ([]User, int, error)
 		VerifyUser(context.Context, User) (User, bool, error)
 		AddUser(context.Context, User, int) (User, error)
 		EditUserPassword(context.Context, User) error
@@ -31,15 +37,26 @@ type (
 		EditUserAvatar(context.Context, User) error
 		EditUserAvatarUser(context.Context, User, int) error
 		DeleteUser(context.Context, User, int) error
+		GetUsersToAnonymise(context.Context, time.Time) ([]User, error)
+		AnonymiseUser(context.Context, User) error
 		GetPermissionsForUser(context.Context, User) ([]permission.Permission, error)
 		GetRolesForUser(context.Context, User) ([]role.Role, error)
+		GetEffectiveRolesForUser(context.Context, User) ([]EffectiveRole, error)
 		GetUsersForRole(context.Context, role.Role) ([]User, error)
+		GetUsersWithPermission(context.Context, permission.Permission) ([]User, error)
 		GetRoleUser(context.Context, RoleUser) (RoleUser, error)
 		GetUsersNotInRole(context.Context, role.Role) ([]User, error)
 		AddRoleUser(context.Context, RoleUser) (RoleUser, error)
 		RemoveRoleUser(context.Context, RoleUser) error
+		GetRoleUsersForRole(context.Context, role.Role) ([]RoleUser, error)
+		GetRoleUsersForUser(context.Context, User) ([]RoleUser, error)
+		GetRoleUsersExpiringBefore(context.Context, time.Time) ([]RoleUserExpiry, error)
+		SetRoleUserExpiryNotified(context.Context, RoleUser) error
+		RemoveExpiredRoleUsers(context.Context) ([]RoleUser, error)
 		RemoveUserForRoles(context.Context, User) error
+		RemoveRoleForUsers(context.Context, role.Role) error
 		GetPermissionsForRole(context.Context, role.Role) ([]permission.Permission, error)
+		GetEffectivePermissionsForRole(context.Context, role.Role) ([]EffectivePermission, error)
 		GetRolesForPermission(context.Context, permission.Permission) ([]role.Role, error)
 		GetRolePermission(context.Context, RolePermission) (RolePermission, error)
 		GetPermissionsNotInRole(context.Context, role.Role) ([]permission.Permission, error)
@@ -52,6 +69,7 @@ type (
 		db          *sqlx.DB
 		cdnEndpoint string
 		cloak       *gocloaksession.GoCloakSession
+		webhook     webhook.Repo
 	}
 
 	// User represents relevant user fields
@@ -81,8 +99,15 @@ type (
 		DeletedAt          null.Time               `db:"deleted_at" json:"deletedAt"`
 		DeletedBy          null.Int                `db:"deleted_by" json:"deletedBy"`
 		UseGravatar        bool                    `db:"use_gravatar" json:"useGravatar" schema:"useGravatar"`
+		HideFromPublic     bool                    `db:"hide_from_public" json:"hideFromPublic"`
+		AnonymisedAt       null.Time               `db:"anonymised_at" json:"anonymisedAt"`
+		Status             Status                  `db:"status" json:"status"`
+		PasswordChangedAt  null.Time               `db:"password_changed_at" json:"passwordChangedAt"`
+		PasswordWarnedAt   null.Time               `db:"password_expiry_warned_at" json:"-"`
+		Language           null.String             `db:"language" json:"language"`
 		Permissions        []permission.Permission `json:"permissions"`
 		Roles              []role.Role             `json:"roles"`
+		InheritedRoles     []role.Role             `json:"inheritedRoles"`
 		Authenticated      bool                    `json:"authenticated"`
 		AssumedUser        *User                   `json:"assumedUser"`
 	}
@@ -97,6 +122,7 @@ type (
 		LastLogin string
 		Enabled   bool
 		Deleted   bool
+		Status    Status
 	}
 
 	// DetailedUser is the user object in full for the front end
@@ -122,9 +148,12 @@ type (
 		UpdatedBy          User                    `json:"updatedBy"`
 		DeletedAt          null.String             `json:"deletedAt"`
 		DeletedBy          User                    `json:"deletedBy"`
+		AnonymisedAt       null.String             `json:"anonymisedAt"`
+		Status             Status                  `json:"status"`
 		Gravatar           null.String             `json:"gravatar"`
 		Permissions        []permission.Permission `json:"permissions"`
 		Roles              []role.Role             `json:"roles"`
+		InheritedRoles     []role.Role             `json:"inheritedRoles"`
 		Officers           []OfficershipMember     `json:"officers"`
 	}
 
@@ -151,11 +180,17 @@ type (
 	}
 
 	RoleTemplate struct {
-		RoleID      int
-		Name        string
-		Description string
-		Permissions []permission.Permission
-		Users       []User
+		RoleID               int
+		Name                 string
+		Description          string
+		Requestable          bool
+		ApproverPermissionID null.Int
+		PasswordMaxAgeDays   null.Int
+		Permissions          []permission.Permission
+		Users                []User
+		IncludedRoles        []role.Role
+		IncludingRoles       []role.Role
+		EffectivePermissions []EffectivePermission
 	}
 
 	// PermissionTemplate is for the front end of permission
@@ -164,6 +199,23 @@ type (
 		Name         string
 		Description  string
 		Roles        []role.Role
+		ImpliedBy    []permission.Permission
+		Implies      []permission.Permission
+	}
+
+	// EffectivePermission is a permission held by members of a role with where it came from, SourceRoleID is the
+	// role or included role holding it and ImpliedBy is set when it comes from the permission hierarchy
+	EffectivePermission struct {
+		permission.Permission
+		SourceRoleID   int         `db:"source_role_id" json:"sourceRoleID"`
+		SourceRoleName string      `db:"source_role_name" json:"sourceRoleName"`
+		ImpliedBy      null.String `db:"implied_by" json:"impliedBy"`
+	}
+
+	// EffectiveRole is a role a user has, Inherited is set when they only have it through a role including it
+	EffectiveRole struct {
+		role.Role
+		Inherited bool `db:"inherited" json:"inherited"`
 	}
 
 	// RolePermission symbolises a link between a role.Role and permission.Permission
@@ -173,20 +225,82 @@ type (
 	}
 
 	// RoleUser symbolises a link between a role.Role and User
+	// StartsAt and EndsAt are optional and limit when the membership counts, outside of them it is ignored
 	RoleUser struct {
-		RoleID int `db:"role_id" json:"roleID"`
-		UserID int `db:"user_id" json:"userID"`
-	}
+		RoleID           int       `db:"role_id" json:"roleID"`
+		UserID           int       `db:"user_id" json:"userID"`
+		OfficershipSync  bool      `db:"officership_sync" json:"officershipSync"`
+		MembershipSync   bool      `db:"membership_sync" json:"membershipSync"`
+		StartsAt         null.Time `db:"starts_at" json:"startsAt"`
+		EndsAt           null.Time `db:"ends_at" json:"endsAt"`
+		GrantedBy        null.Int  `db:"granted_by" json:"grantedBy"`
+		GrantedAt        null.Time `db:"granted_at" json:"grantedAt"`
+		Reason           string    `db:"reason" json:"reason"`
+		ExpiryNotifiedAt null.Time `db:"expiry_notified_at" json:"expiryNotifiedAt"`
+	}
+
+	// Status is where a user is in their time with YSTV, see userstatus for how it changes
+	Status string
+
+	// RoleUserExpiry is a temporary role membership that is about to end, used to warn the user
+	RoleUserExpiry struct {
+		RoleUser
+		RoleName  string      `db:"role_name" json:"roleName"`
+		Firstname string      `db:"first_name" json:"firstName"`
+		Email     string      `db:"email" json:"email"`
+		Language  null.String `db:"language" json:"-"`
+	}
+
+	// lookup is which users and addresses getUser matches
+	lookup int
 )
 
+const (
+	Member    Status = "member"
+	Alumni    Status = "alumni"
+	Honorary  Status = "honorary"
+	Suspended Status = "suspended"
+)
+
+const (
+	// anyUser matches every user by a verified address or their primary one
+	anyUser lookup = iota
+	// existingUser leaves out deleted users
+	existingUser
+	// loginUser only matches a verified address
+	loginUser
+)
+
+// Statuses is the list of statuses in the order they are shown
+//
+//nolint:gochecknoglobals
+var Statuses = []Status{Member, Alumni, Honorary, Suspended}
+
+// Name is the status as it is shown to users
+func (s Status) Name() string {
+	switch s {
+	case Member:
+		return "Member"
+	case Alumni:
+		return "Alumni"
+	case Honorary:
+		return "Honorary"
+	case Suspended:
+		return "Suspended"
+	default:
+		return string(s)
+	}
+}
+
 var _ Repo = &Store{}
 
 // NewUserRepo stores our dependency
-func NewUserRepo(db *sqlx.DB, cdnEndpoint string) *Store {
+func NewUserRepo(db *sqlx.DB, cdnEndpoint string, wh webhook.Repo) *Store {
 	return &Store{
 		db:          db,
 		cloak:       nil,
 		cdnEndpoint: cdnEndpoint,
+		webhook:     wh,
 	}
 }
 
@@ -197,7 +311,25 @@ func (s *Store) CountUsersAll(ctx context.Context) (CountUsers, error) {
 
 // GetUser returns a user using any unique identity fields
 func (s *Store) GetUser(ctx context.Context, u User) (User, error) {
-	return s.getUser(ctx, u)
+	return s.getUser(ctx, u, anyUser)
+}
+
+// GetExistingUser returns a user that hasn't been deleted using any unique identity fields, it is used to check if
+// an account already exists
+func (s *Store) GetExistingUser(ctx context.Context, u User) (User, error) {
+	return s.getUser(ctx, u, existingUser)
+}
+
+// GetLoginUser returns a user using any unique identity fields but only by a verified address, as those are the
+// only ones that can be used to log in or reset a password
+func (s *Store) GetLoginUser(ctx context.Context, u User) (User, error) {
+	return s.getUser(ctx, u, loginUser)
+}
+
+// GetUsersByID returns the users with the ids, deleted users included, with their avatar urls worked out the same as
+// GetUser
+func (s *Store) GetUsersByID(ctx context.Context, userIDs []int) ([]User, error) {
+	return s.getUsersByID(ctx, userIDs)
 }
 
 // GetUserValid returns a user using any unique identity fields which is enabled and not deleted
@@ -215,6 +347,10 @@ func (s *Store) GetUserValid(ctx context.Context, u User) (User, error) {
 		return u, errors.New("user has been deleted, contact Computing Team for help")
 	}
 
+	if user.Status == Suspended {
+		return u, errors.New("user has been suspended, contact Computing Team for help")
+	}
+
 	if user.ResetPw {
 		u.UserID = user.UserID
 
@@ -224,16 +360,22 @@ func (s *Store) GetUserValid(ctx context.Context, u User) (User, error) {
 	return user, nil
 }
 
+// GetUserByUniversityUsername returns a user using their university username, this isn't unique so the newest is
+// returned
+func (s *Store) GetUserByUniversityUsername(ctx context.Context, u User) (User, error) {
+	return s.getUserByUniversityUsername(ctx, u)
+}
+
 func (s *Store) GetUsers(ctx context.Context, size, page int, search, sortBy, direction, enabled,
-	deleted string) ([]User, int, error) {
-	return s.getUsers(ctx, size, page, search, sortBy, direction, enabled, deleted)
+	deleted, status string) ([]User, int, error) {
+	return s.getUsers(ctx, size, page, search, sortBy, direction, enabled, deleted, status)
 }
 
 // VerifyUser will check that the password is correct with provided
 // credentials and if verified will return the User object
 // returned is the user object, bool of if the password is forced to be changed and any errors encountered
 func (s *Store) VerifyUser(ctx context.Context, u User) (User, bool, error) {
-	user, err := s.GetUser(ctx, u)
+	user, err := s.GetLoginUser(ctx, u)
 	if err != nil {
 		return u, false, fmt.Errorf("failed to get user: %w", err)
 	}
@@ -246,6 +388,10 @@ func (s *Store) VerifyUser(ctx context.Context, u User) (User, bool, error) {
 		return u, false, errors.New("user has been deleted, contact Computing Team for help")
 	}
 
+	if user.Status == Suspended {
+		return u, false, errors.New("user has been suspended, contact Computing Team for help")
+	}
+
 	if utils.HashPass(user.Salt.String+u.Password.String) == user.Password.String {
 		if user.ResetPw {
 			u.UserID = user.UserID
@@ -282,19 +428,24 @@ func (s *Store) AddUser(ctx context.Context, u User, userID int) (User, error) {
 	return u, nil
 }
 
-// EditUserPassword will edit the password and set the reset_pw to false
+// EditUserPassword will edit the password and set the reset_pw to false, the password's age starts again
 func (s *Store) EditUserPassword(ctx context.Context, u User) error {
 	user, err := s.GetUser(ctx, u)
 	if err != nil {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
+	// the old hash is kept so the password policy can stop it being used again
+	old := user
+
 	user.Password = null.StringFrom(utils.HashPass(user.Salt.String + u.Password.String))
 	user.ResetPw = false
 	user.UpdatedBy = null.IntFrom(int64(user.UserID))
 	user.UpdatedAt = null.TimeFrom(time.Now())
+	user.PasswordChangedAt = user.UpdatedAt
+	user.PasswordWarnedAt = null.Time{}
 
-	err = s.editUser(ctx, user)
+	err = s.editUserPassword(ctx, old, user)
 	if err != nil {
 		return fmt.Errorf("failed to edit user for editUserPassword: %w", err)
 	}
@@ -302,13 +453,43 @@ func (s *Store) EditUserPassword(ctx context.Context, u User) error {
 	return nil
 }
 
-// EditUser will edit the user
+// EditUser will edit the user, the email can't be changed here as it has to go through people.user_emails, which
+// an email change does
 func (s *Store) EditUser(ctx context.Context, u User, userID int) error {
 	user, err := s.GetUser(ctx, u)
 	if err != nil {
 		return fmt.Errorf("failed to get user for editUser: %w", err)
 	}
 
+	events := []webhook.Event{webhook.UserUpdated}
+
+	if user.Enabled && !u.Enabled {
+		events = append(events, webhook.UserDisabled)
+	}
+
+	user, err = applyEdit(user, u)
+	if err != nil {
+		return fmt.Errorf("failed to edit user: %w", err)
+	}
+
+	user.UpdatedBy = null.IntFrom(int64(userID))
+	user.UpdatedAt = null.TimeFrom(time.Now())
+
+	err = s.editUserAndEmit(ctx, user, events...)
+	if err != nil {
+		return fmt.Errorf("failed to edit user: %w", err)
+	}
+
+	return nil
+}
+
+// applyEdit copies the set fields of u onto user, the email is only a copy of the primary address in
+// people.user_emails so a different one is refused
+func applyEdit(user, u User) (User, error) {
+	if len(u.Email) > 0 && !strings.EqualFold(u.Email, user.Email) {
+		return User{}, errors.New("the email can only be changed by an email change")
+	}
+
 	if len(u.Username) > 0 {
 		user.Username = u.Username
 	}
@@ -341,39 +522,29 @@ func (s *Store) EditUser(ctx context.Context, u User, userID int) error {
 		user.Avatar = u.Avatar
 	}
 
-	if len(u.Email) > 0 {
-		user.Email = u.Email
-	}
-
 	user.ResetPw = u.ResetPw
 	user.Enabled = u.Enabled
 	user.UseGravatar = u.UseGravatar
-	user.UpdatedBy = null.IntFrom(int64(userID))
-	user.UpdatedAt = null.TimeFrom(time.Now())
+	user.HideFromPublic = u.HideFromPublic
 
-	err = s.editUser(ctx, user)
-	if err != nil {
-		return fmt.Errorf("failed to edit user: %w", err)
-	}
-
-	return nil
+	return user, nil
 }
 
 // SetUserLoggedIn will set the last login date to now
 func (s *Store) SetUserLoggedIn(ctx context.Context, u User) error {
 	u.LastLogin = null.TimeFrom(time.Now())
 
-	return s.editUser(ctx, u)
+	return s.editUser(ctx, s.db, u)
 }
 
 func (s *Store) EditUserAvatar(ctx context.Context, userParam User) error {
-	user, err := s.getUser(ctx, userParam)
+	user, err := s.getUser(ctx, userParam, anyUser)
 	if err != nil {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 	user.UseGravatar = userParam.UseGravatar
 	user.Avatar = userParam.Avatar
-	err = s.editUser(ctx, user)
+	err = s.editUser(ctx, s.db, user)
 	if err != nil {
 		return fmt.Errorf("failed to edit user for edit user password: %w", err)
 	}
@@ -381,7 +552,7 @@ func (s *Store) EditUserAvatar(ctx context.Context, userParam User) error {
 }
 
 func (s *Store) EditUserAvatarUser(ctx context.Context, userParam User, userID int) error {
-	user, err := s.getUser(ctx, userParam)
+	user, err := s.getUser(ctx, userParam, anyUser)
 	if err != nil {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
@@ -389,54 +560,73 @@ func (s *Store) EditUserAvatarUser(ctx context.Context, userParam User, userID i
 	user.Avatar = userParam.Avatar
 	user.UpdatedBy = null.IntFrom(int64(userID))
 	user.UpdatedAt = null.TimeFrom(time.Now())
-	err = s.editUser(ctx, user)
+	err = s.editUser(ctx, s.db, user)
 	if err != nil {
 		return fmt.Errorf("failed to edit user for edit user password: %w", err)
 	}
 	return nil
 }
 
-// DeleteUser will soft delete a user
+// DeleteUser will soft delete a user, they can't log in and their username and email are freed straight away for
+// another account but their other details are kept until they are anonymised once the retention period has passed
 func (s *Store) DeleteUser(ctx context.Context, u User, userID int) error {
 	now := null.TimeFrom(time.Now())
 	id := null.IntFrom(int64(userID))
 	blank := null.NewString("", true)
 
-	u.Username = fmt.Sprintf("deleted-%d", u.UserID)
-	u.Firstname = "*Deleted*"
-	u.Nickname = ""
-	u.Lastname = "*User*"
-	u.LDAPUsername = null.NewString("", false)
-	u.Email = fmt.Sprintf("noreply+%d@ystv.co.uk", u.UserID)
-	u.UniversityUsername = ""
 	u.Enabled = false
-	u.Avatar = ""
-	u.UseGravatar = false
 	u.Password = blank
 	u.Salt = blank
 	u.UpdatedBy = id
 	u.UpdatedAt = now
 	u.DeletedBy = id
 	u.DeletedAt = now
+	u.Username = deletedUsername(u.UserID)
+	u.Email = deletedEmail(u.UserID)
+	u.LDAPUsername = null.String{}
+
+	return s.deleteUser(ctx, u)
+}
+
+// GetUsersToAnonymise returns the soft deleted users that haven't been anonymised yet and were deleted before the
+// time given, oldest first, the zero time returns all of them
+func (s *Store) GetUsersToAnonymise(ctx context.Context, deletedBefore time.Time) ([]User, error) {
+	return s.getUsersToAnonymise(ctx, deletedBefore)
+}
 
-	return s.editUser(ctx, u)
+// AnonymiseUser scrubs the personal details of a soft deleted user, the row is kept so the officership history
+// still points at someone, it is shown as the placeholder name
+func (s *Store) AnonymiseUser(ctx context.Context, u User) error {
+	return s.anonymiseUser(ctx, u)
 }
 
-// GetPermissionsForUser returns all permissions of a user
+// GetPermissionsForUser returns all the effective permissions of a user, including the ones implied by the
+// permission hierarchy
 func (s *Store) GetPermissionsForUser(ctx context.Context, u User) ([]permission.Permission, error) {
 	return s.getPermissionsForUser(ctx, u)
 }
 
-// GetRolesForUser returns all roles of a user
+// GetRolesForUser returns all roles of a user, including the roles included by them
 func (s *Store) GetRolesForUser(ctx context.Context, u User) ([]role.Role, error) {
 	return s.getRolesForUser(ctx, u)
 }
 
+// GetEffectiveRolesForUser returns all roles of a user, the ones only held through a role including them are marked
+// as inherited and can't be removed from the user directly
+func (s *Store) GetEffectiveRolesForUser(ctx context.Context, u User) ([]EffectiveRole, error) {
+	return s.getEffectiveRolesForUser(ctx, u)
+}
+
 // GetUsersForRole returns all the Users that are linked to a role.Role
 func (s *Store) GetUsersForRole(ctx context.Context, r role.Role) ([]User, error) {
 	return s.getUsersForRole(ctx, r)
 }
 
+// GetUsersWithPermission returns the current users who effectively have a permission
+func (s *Store) GetUsersWithPermission(ctx context.Context, p permission.Permission) ([]User, error) {
+	return s.getUsersWithPermission(ctx, p)
+}
+
 // GetRoleUser returns a single link between a role.Role and User
 func (s *Store) GetRoleUser(ctx context.Context, ru RoleUser) (RoleUser, error) {
 	return s.getRoleUser(ctx, ru)
@@ -447,7 +637,7 @@ func (s *Store) GetUsersNotInRole(ctx context.Context, r role.Role) ([]User, err
 	return s.getUsersNotInRole(ctx, r)
 }
 
-// AddRoleUser adds a link between a role.Role and User
+// AddRoleUser adds a link between a role.Role and User, a membership given by a sync becomes this one
 func (s *Store) AddRoleUser(ctx context.Context, ru RoleUser) (RoleUser, error) {
 	return s.addRoleUser(ctx, ru)
 }
@@ -457,16 +647,52 @@ func (s *Store) RemoveRoleUser(ctx context.Context, ru RoleUser) error {
 	return s.removeRoleUser(ctx, ru)
 }
 
+// GetRoleUsersForRole returns the memberships of a role, including the ones outside their window
+func (s *Store) GetRoleUsersForRole(ctx context.Context, r role.Role) ([]RoleUser, error) {
+	return s.getRoleUsersForRole(ctx, r)
+}
+
+// GetRoleUsersForUser returns the roles a user is directly a member of, including the ones outside their window
+func (s *Store) GetRoleUsersForUser(ctx context.Context, u User) ([]RoleUser, error) {
+	return s.getRoleUsersForUser(ctx, u)
+}
+
+// GetRoleUsersExpiringBefore returns the current memberships ending before a time whose user hasn't been warned
+func (s *Store) GetRoleUsersExpiringBefore(ctx context.Context, before time.Time) ([]RoleUserExpiry, error) {
+	return s.getRoleUsersExpiringBefore(ctx, before)
+}
+
+// SetRoleUserExpiryNotified records that the user has been warned their membership is ending
+func (s *Store) SetRoleUserExpiryNotified(ctx context.Context, ru RoleUser) error {
+	return s.setRoleUserExpiryNotified(ctx, ru)
+}
+
+// RemoveExpiredRoleUsers removes the memberships whose end has passed and returns them
+func (s *Store) RemoveExpiredRoleUsers(ctx context.Context) ([]RoleUser, error) {
+	return s.removeExpiredRoleUsers(ctx)
+}
+
 // RemoveUserForRoles removes links between a User and Roles
 func (s *Store) RemoveUserForRoles(ctx context.Context, u User) error {
 	return s.removeUserForRoles(ctx, u)
 }
 
+// RemoveRoleForUsers removes links between a role.Role and Users
+func (s *Store) RemoveRoleForUsers(ctx context.Context, r role.Role) error {
+	return s.removeRoleForUsers(ctx, r)
+}
+
 // GetPermissionsForRole returns all permissions for role
 func (s *Store) GetPermissionsForRole(ctx context.Context, r role.Role) ([]permission.Permission, error) {
 	return s.getPermissionsForRole(ctx, r)
 }
 
+// GetEffectivePermissionsForRole returns every permission a member of a role has, including from included roles and
+// the permission hierarchy, with where each came from
+func (s *Store) GetEffectivePermissionsForRole(ctx context.Context, r role.Role) ([]EffectivePermission, error) {
+	return s.getEffectivePermissionsForRole(ctx, r)
+}
+
 // GetRolesForPermission returns all roles where a permission is used
 func (s *Store) GetRolesForPermission(ctx context.Context, p permission.Permission) ([]role.Role, error) {
 	return s.getRolesForPermission(ctx, p)
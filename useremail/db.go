@@ -0,0 +1,158 @@
+package useremail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/utils"
+)
+
+func (s *Store) getEmailsForUser(ctx context.Context, u user.User) ([]Email, error) {
+	var e []Email
+
+	builder := utils.PSQL().Select("*").
+		From("people.user_emails").
+		Where(sq.Eq{"user_id": u.UserID}).
+		OrderBy("is_primary DESC", "created_at", "email_id")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getEmailsForUser: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &e, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get emails for user: %w", err)
+	}
+
+	return e, nil
+}
+
+func (s *Store) getEmail(ctx context.Context, e1 Email) (Email, error) {
+	var e Email
+
+	builder := utils.PSQL().Select("*").
+		From("people.user_emails").
+		Where(sq.Eq{"email_id": e1.EmailID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getEmail: %w", err))
+	}
+
+	err = s.db.GetContext(ctx, &e, sql, args...)
+	if err != nil {
+		return e, fmt.Errorf("failed to get email: %w", err)
+	}
+
+	return e, nil
+}
+
+func (s *Store) getEmailByVerifyToken(ctx context.Context, token string) (Email, error) {
+	var e Email
+
+	if token == "" {
+		return e, errors.New("failed to get email: token must be set")
+	}
+
+	builder := utils.PSQL().Select("*").
+		From("people.user_emails").
+		Where(sq.Eq{"verify_token": token})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getEmailByVerifyToken: %w", err))
+	}
+
+	err = s.db.GetContext(ctx, &e, sql, args...)
+	if err != nil {
+		return e, fmt.Errorf("failed to get email: %w", err)
+	}
+
+	return e, nil
+}
+
+func (s *Store) addEmail(ctx context.Context, e Email) (Email, error) {
+	builder := utils.PSQL().Insert("people.user_emails").
+		Columns("user_id", "email", "verify_token", "verify_expires_at").
+		Values(e.UserID, e.Email, e.VerifyToken, e.VerifyExpiresAt).
+		Suffix(`ON CONFLICT (user_id, lower(email)) DO UPDATE
+			SET verify_token = EXCLUDED.verify_token, verify_expires_at = EXCLUDED.verify_expires_at
+			WHERE NOT user_emails.verified
+			RETURNING *`)
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for addEmail: %w", err))
+	}
+
+	var added Email
+
+	err = s.db.GetContext(ctx, &added, sql, args...)
+	if err != nil {
+		return Email{}, fmt.Errorf("failed to add email, it may already be verified: %w", err)
+	}
+
+	return added, nil
+}
+
+func (s *Store) verifyEmail(ctx context.Context, e Email) (Email, error) {
+	builder := utils.PSQL().Update("people.user_emails").
+		SetMap(map[string]interface{}{
+			"verified":          true,
+			"verified_at":       time.Now(),
+			"verify_token":      nil,
+			"verify_expires_at": nil,
+		}).
+		Where(sq.And{
+			sq.Eq{"email_id": e.EmailID, "verified": false},
+			sq.Expr("verify_expires_at > NOW()"),
+		}).
+		Suffix("RETURNING *")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for verifyEmail: %w", err))
+	}
+
+	var verified Email
+
+	err = s.db.GetContext(ctx, &verified, sql, args...)
+	if err != nil {
+		return Email{}, fmt.Errorf("failed to verify email, it may have expired or be used by someone else: %w",
+			err)
+	}
+
+	return verified, nil
+}
+
+func (s *Store) removeEmail(ctx context.Context, e Email) error {
+	builder := utils.PSQL().Delete("people.user_emails").
+		Where(sq.Eq{"email_id": e.EmailID, "is_primary": false})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for removeEmail: %w", err))
+	}
+
+	res, err := s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to remove email: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for removeEmail: %w", err)
+	}
+
+	if rows < 1 {
+		return errors.New("failed to remove email: the primary email can't be removed")
+	}
+
+	return nil
+}
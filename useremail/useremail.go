@@ -0,0 +1,110 @@
+package useremail
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/user"
+)
+
+//go:generate mockgen -destination mocks/mock_useremail.go -package mock_useremail github.com/ystv/web-auth/useremail Repo
+
+type (
+	Repo interface {
+		GetEmailsForUser(context.Context, user.User) ([]Email, error)
+		GetEmail(context.Context, Email) (Email, error)
+		GetEmailByVerifyToken(context.Context, string) (Email, error)
+		AddEmail(context.Context, Email) (Email, error)
+		VerifyEmail(context.Context, Email) (Email, error)
+		RemoveEmail(context.Context, Email) error
+	}
+
+	// Store stores the dependencies
+	Store struct {
+		db *sqlx.DB
+	}
+
+	// Email is one of a user's addresses, the primary one is the one they are emailed at and is also in
+	// user.User's Email
+	Email struct {
+		EmailID         int         `db:"email_id" json:"emailID"`
+		UserID          int         `db:"user_id" json:"userID"`
+		Email           string      `db:"email" json:"email"`
+		Verified        bool        `db:"verified" json:"verified"`
+		Primary         bool        `db:"is_primary" json:"primary"`
+		VerifyToken     null.String `db:"verify_token" json:"-"`
+		VerifyExpiresAt null.Time   `db:"verify_expires_at" json:"verifyExpiresAt"`
+		CreatedAt       time.Time   `db:"created_at" json:"createdAt"`
+		VerifiedAt      null.Time   `db:"verified_at" json:"verifiedAt"`
+	}
+)
+
+// VerifyFor is how long the link sent to a new address works for
+const VerifyFor = 24 * time.Hour
+
+var _ Repo = &Store{}
+
+// NewUserEmailRepo stores our dependency
+func NewUserEmailRepo(db *sqlx.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+// GetEmailsForUser returns all of a user's addresses, the primary one first
+func (s *Store) GetEmailsForUser(ctx context.Context, u user.User) ([]Email, error) {
+	return s.getEmailsForUser(ctx, u)
+}
+
+// GetEmail returns a single address by its id
+func (s *Store) GetEmail(ctx context.Context, e Email) (Email, error) {
+	return s.getEmail(ctx, e)
+}
+
+// GetEmailByVerifyToken returns the address the link sent to it is for
+func (s *Store) GetEmailByVerifyToken(ctx context.Context, token string) (Email, error) {
+	return s.getEmailByVerifyToken(ctx, token)
+}
+
+// AddEmail adds an unverified address to a user, adding one they already have waiting to be verified replaces
+// its link
+func (s *Store) AddEmail(ctx context.Context, e Email) (Email, error) {
+	return s.addEmail(ctx, e)
+}
+
+// VerifyEmail marks an address as verified once the link sent to it is pressed
+func (s *Store) VerifyEmail(ctx context.Context, e Email) (Email, error) {
+	return s.verifyEmail(ctx, e)
+}
+
+// RemoveEmail removes an address that isn't the primary one
+func (s *Store) RemoveEmail(ctx context.Context, e Email) error {
+	return s.removeEmail(ctx, e)
+}
+
+// CanVerify is if the link sent to the address still works
+func (e Email) CanVerify() bool {
+	return !e.Verified && e.VerifyToken.Valid && e.VerifyExpiresAt.Valid && time.Now().Before(e.VerifyExpiresAt.Time)
+}
+
+// Addresses are the verified addresses with the primary one first, these are the ones given to other services
+func Addresses(emails []Email) []string {
+	addresses := make([]string, 0, len(emails))
+
+	for _, e := range emails {
+		if !e.Verified && !e.Primary {
+			continue
+		}
+
+		if e.Primary {
+			addresses = append([]string{e.Email}, addresses...)
+		} else {
+			addresses = append(addresses, e.Email)
+		}
+	}
+
+	return addresses
+}
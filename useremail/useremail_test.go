@@ -0,0 +1,34 @@
+package useremail
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/guregu/null.v4"
+)
+
+func TestAddresses(t *testing.T) {
+	emails := []Email{
+		{Email: "old@york.ac.uk", Verified: true},
+		{Email: "pending@example.com"},
+		{Email: "primary@example.com", Verified: true, Primary: true},
+		{Email: "personal@example.com", Verified: true},
+	}
+
+	assert.Equal(t, []string{"primary@example.com", "old@york.ac.uk", "personal@example.com"}, Addresses(emails))
+	assert.Empty(t, Addresses(nil))
+
+	// an admin can make an address primary without it being verified, it is still the one they log in with
+	assert.Equal(t, []string{"set@example.com"}, Addresses([]Email{{Email: "set@example.com", Primary: true}}))
+}
+
+func TestCanVerify(t *testing.T) {
+	token := null.StringFrom("token")
+
+	assert.True(t, Email{VerifyToken: token, VerifyExpiresAt: null.TimeFrom(time.Now().Add(time.Hour))}.CanVerify())
+	assert.False(t, Email{VerifyToken: token, VerifyExpiresAt: null.TimeFrom(time.Now().Add(-time.Hour))}.CanVerify())
+	assert.False(t, Email{Verified: true, VerifyToken: token,
+		VerifyExpiresAt: null.TimeFrom(time.Now().Add(time.Hour))}.CanVerify())
+	assert.False(t, Email{}.CanVerify())
+}
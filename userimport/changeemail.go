@@ -0,0 +1,31 @@
+package userimport
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/emailchange"
+	"github.com/ystv/web-auth/user"
+)
+
+// NewChangeEmail returns a ChangeEmail that records the change as made straight away, the new address isn't
+// verified and the old address isn't emailed the link to change it back
+func NewChangeEmail(changes emailchange.Repo) ChangeEmail {
+	return func(ctx context.Context, u user.User, email string, changedBy int) error {
+		_, err := changes.AddChange(ctx, emailchange.Change{
+			UserID:      u.UserID,
+			OldEmail:    u.Email,
+			NewEmail:    strings.ToLower(strings.TrimSpace(email)),
+			Status:      emailchange.Changed,
+			RevertToken: null.StringFrom(uuid.NewString()),
+			RequestedBy: null.IntFrom(int64(changedBy)),
+			ExpiresAt:   time.Now(),
+		})
+
+		return err
+	}
+}
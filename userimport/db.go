@@ -0,0 +1,237 @@
+package userimport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/utils"
+)
+
+// storedJob is a row of web_auth.user_imports, the rows, options and progress are kept as json
+type storedJob struct {
+	ImportID string `db:"import_id"`
+	Rows     []byte `db:"rows"`
+	Options  []byte `db:"options"`
+	Progress []byte `db:"progress"`
+}
+
+func (s *Store) addJob(ctx context.Context, j *Job) error {
+	importID := uuid.NewString()
+
+	rows, err := json.Marshal(j.rows)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rows: %w", err)
+	}
+
+	opts, err := json.Marshal(j.opts)
+	if err != nil {
+		return fmt.Errorf("failed to marshal options: %w", err)
+	}
+
+	progress, err := json.Marshal(j.Progress())
+	if err != nil {
+		return fmt.Errorf("failed to marshal progress: %w", err)
+	}
+
+	builder := utils.PSQL().Insert("web_auth.user_imports").
+		Columns("import_id", "rows", "options", "progress", "created_by").
+		// lib/pq sends []byte as bytea, jsonb needs the text
+		Values(importID, string(rows), string(opts), string(progress), null.NewInt(int64(j.opts.ImportedBy), j.opts.ImportedBy != 0))
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for addJob: %w", err))
+	}
+
+	_, err = s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to add job: %w", err)
+	}
+
+	j.importID = importID
+	j.store = s
+
+	return nil
+}
+
+func (s *Store) getJob(ctx context.Context, importID string) (*Job, error) {
+	var stored storedJob
+
+	builder := utils.PSQL().Select("import_id", "rows", "options", "progress").
+		From("web_auth.user_imports").
+		Where(sq.Eq{"import_id": importID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getJob: %w", err))
+	}
+
+	err = s.db.GetContext(ctx, &stored, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	return s.loadJob(stored)
+}
+
+// loadJob makes a job from its row, the password generator isn't stored so it is given back here
+func (s *Store) loadJob(stored storedJob) (*Job, error) {
+	j := &Job{
+		importID: stored.ImportID,
+		store:    s,
+	}
+
+	err := json.Unmarshal(stored.Rows, &j.rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rows: %w", err)
+	}
+
+	err = json.Unmarshal(stored.Options, &j.opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal options: %w", err)
+	}
+
+	err = json.Unmarshal(stored.Progress, &j.progress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal progress: %w", err)
+	}
+
+	j.opts.Passwords = s.passwords
+
+	return j, nil
+}
+
+// startJob saves the progress of a job that is starting, it fails when the job has already been started
+func (s *Store) startJob(ctx context.Context, importID string, p Progress) error {
+	progress, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to marshal progress: %w", err)
+	}
+
+	res, err := s.execProgress(ctx, startJobBuilder(importID, progress))
+	if err != nil {
+		return fmt.Errorf("failed to start job: %w", err)
+	}
+
+	if res == 0 {
+		return errors.New("import has already been run")
+	}
+
+	return nil
+}
+
+func (s *Store) saveProgress(ctx context.Context, importID string, p Progress) error {
+	progress, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to marshal progress: %w", err)
+	}
+
+	_, err = s.execProgress(ctx, saveProgressBuilder(importID, progress))
+	if err != nil {
+		return fmt.Errorf("failed to save progress: %w", err)
+	}
+
+	return nil
+}
+
+// execProgress runs a progress update and returns how many jobs it changed
+func (s *Store) execProgress(ctx context.Context, builder sq.UpdateBuilder) (int64, error) {
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for execProgress: %w", err))
+	}
+
+	res, err := s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (s *Store) interruptJobs(ctx context.Context) error {
+	var stored []storedJob
+
+	builder := utils.PSQL().Select("import_id", "rows", "options", "progress").
+		From("web_auth.user_imports").
+		Where(runningWhere)
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for interruptJobs: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &stored, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to get running jobs: %w", err)
+	}
+
+	for _, st := range stored {
+		j, err := s.loadJob(st)
+		if err != nil {
+			return fmt.Errorf("failed to load job %s: %w", st.ImportID, err)
+		}
+
+		err = s.saveProgress(ctx, j.importID, interrupt(j.progress, time.Now()))
+		if err != nil {
+			return fmt.Errorf("failed to interrupt job %s: %w", st.ImportID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) deleteOldJobs(ctx context.Context, before time.Time) error {
+	builder := utils.PSQL().Delete("web_auth.user_imports").
+		Where(sq.And{
+			sq.Lt{"created_at": before},
+			sq.Expr("NOT (" + runningWhere + ")"),
+		})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for deleteOldJobs: %w", err))
+	}
+
+	_, err = s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete old jobs: %w", err)
+	}
+
+	return nil
+}
+
+// runningWhere matches the jobs that have been started and haven't finished
+const runningWhere = "(progress->>'started')::bool AND NOT (progress->>'finished')::bool"
+
+// startJobBuilder saves the progress only if the stored job hasn't been started, so a job loaded by two requests
+// is only run once
+func startJobBuilder(importID string, progress []byte) sq.UpdateBuilder {
+	return saveProgressBuilder(importID, progress).
+		Where("NOT (progress->>'started')::bool")
+}
+
+func saveProgressBuilder(importID string, progress []byte) sq.UpdateBuilder {
+	return utils.PSQL().Update("web_auth.user_imports").
+		Set("progress", string(progress)).
+		Set("updated_at", sq.Expr("NOW()")).
+		Where(sq.Eq{"import_id": importID})
+}
+
+// interrupt finishes the progress of a job that stopped part way through, the rows after the ones done weren't
+// imported
+func interrupt(p Progress, now time.Time) Progress {
+	p.Finished = true
+	p.FinishedAt = null.TimeFrom(now)
+	p.Errors = append(p.Errors, fmt.Sprintf("import was interrupted by a restart after %d of %d rows, the rest "+
+		"weren't imported", p.Done, p.Total))
+
+	return p
+}
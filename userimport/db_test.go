@@ -0,0 +1,29 @@
+package userimport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartJobSQL(t *testing.T) {
+	sql, args, err := startJobBuilder("abc", []byte(`{"started":true}`)).ToSql()
+	require.NoError(t, err)
+
+	assert.Equal(t, "UPDATE web_auth.user_imports SET progress = $1, updated_at = NOW() "+
+		"WHERE import_id = $2 AND NOT (progress->>'started')::bool", sql)
+	assert.Equal(t, []interface{}{`{"started":true}`, "abc"}, args)
+}
+
+func TestInterrupt(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	p := interrupt(Progress{Total: 10, Done: 4, Started: true, Errors: []string{"line 2: failed"}}, now)
+
+	assert.True(t, p.Finished)
+	assert.Equal(t, now, p.FinishedAt.Time)
+	assert.Equal(t, []string{"line 2: failed",
+		"import was interrupted by a restart after 4 of 10 rows, the rest weren't imported"}, p.Errors)
+}
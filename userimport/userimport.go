@@ -0,0 +1,723 @@
+package userimport
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/passwordpolicy"
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/utils"
+)
+
+//go:generate mockgen -destination mocks/mock_userimport.go -package mock_userimport github.com/ystv/web-auth/userimport Repo
+
+type (
+	// Repo keeps the jobs so a previewed or running import isn't lost when the server restarts
+	Repo interface {
+		AddJob(context.Context, *Job) error
+		GetJob(context.Context, string) (*Job, error)
+		InterruptJobs(context.Context) error
+		DeleteOldJobs(context.Context, time.Duration) error
+	}
+
+	// Store stores the dependencies
+	Store struct {
+		db        *sqlx.DB
+		passwords passwordpolicy.Repo
+	}
+
+	// Field is a user.User field that a column of the CSV can be mapped to
+	Field string
+
+	// Mapping maps a Field to the header of the column it is read from, fields that aren't mapped are matched
+	// with the usual headers for them, so the Students' Union membership export doesn't need any mapping
+	Mapping map[Field]string
+
+	// Action is what the import will do with a row
+	Action string
+
+	// Row is a line of the CSV and what the import will do with it
+	Row struct {
+		Line int `json:"line"`
+		// Values are the fields read from the line, the username can be made up when it is missing so only
+		// these are changed on an existing user
+		Values         map[Field]string `json:"values"`
+		User           user.User        `json:"user"`
+		Action         Action           `json:"action"`
+		ExistingUserID int              `json:"existingUserID,omitempty"`
+		Changes        []Field          `json:"changes,omitempty"`
+		Problems       []string         `json:"problems,omitempty"`
+	}
+
+	// Options are applied to every row of an import
+	Options struct {
+		// RoleIDs are the roles every created or updated user is added to
+		RoleIDs []int `json:"roleIDs"`
+		// SendEmail emails the created users their username and password
+		SendEmail bool `json:"sendEmail"`
+		// ImportedBy is the user id the changes are made by
+		ImportedBy int `json:"importedBy"`
+		// Passwords generates the created users' passwords so they meet the policy, a random password of the
+		// default length is used if it isn't set, the Store sets it when a job is loaded
+		Passwords passwordpolicy.Repo `json:"-"`
+	}
+
+	// Welcome sends a created user their username and password
+	Welcome func(ctx context.Context, u user.User, password string) error
+
+	// ChangeEmail changes an existing user's primary email, it can't be done by user.Repo's EditUser as the
+	// address has to be moved in people.user_emails
+	ChangeEmail func(ctx context.Context, u user.User, email string, changedBy int) error
+
+	// Progress is how far through an import is
+	Progress struct {
+		Total         int       `json:"total"`
+		Done          int       `json:"done"`
+		Created       int       `json:"created"`
+		Updated       int       `json:"updated"`
+		Skipped       int       `json:"skipped"`
+		Failed        int       `json:"failed"`
+		Emailed       int       `json:"emailed"`
+		EmailsFailed  int       `json:"emailsFailed"`
+		RolesAssigned int       `json:"rolesAssigned"`
+		Errors        []string  `json:"errors"`
+		StartedAt     null.Time `json:"startedAt"`
+		FinishedAt    null.Time `json:"finishedAt"`
+		Started       bool      `json:"started"`
+		Finished      bool      `json:"finished"`
+	}
+
+	// Job is a planned import, it can be run once and its progress checked while it runs
+	Job struct {
+		mu       sync.Mutex
+		importID string
+		rows     []Row
+		opts     Options
+		progress Progress
+		// store saves the progress as the job runs, it is nil when the job isn't kept by a Store
+		store *Store
+	}
+)
+
+const (
+	Username           Field = "username"
+	UniversityUsername Field = "university_username"
+	Firstname          Field = "first_name"
+	Nickname           Field = "nickname"
+	Lastname           Field = "last_name"
+	Email              Field = "email"
+	Pronouns           Field = "pronouns"
+
+	Create Action = "create"
+	Update Action = "update"
+	Skip   Action = "skip"
+
+	// universityDomain is the email domain the university username is taken from when there isn't a column for it
+	universityDomain = "@york.ac.uk"
+)
+
+var _ Repo = &Store{}
+
+// NewUserImportRepo stores our dependency, passwords is given to the loaded jobs to generate the created users'
+// passwords
+func NewUserImportRepo(db *sqlx.DB, passwords passwordpolicy.Repo) *Store {
+	return &Store{
+		db:        db,
+		passwords: passwords,
+	}
+}
+
+// AddJob keeps a new job and gives it an import id
+func (s *Store) AddJob(ctx context.Context, j *Job) error {
+	return s.addJob(ctx, j)
+}
+
+// GetJob returns a kept job, sql.ErrNoRows is returned when it doesn't exist or has been deleted
+func (s *Store) GetJob(ctx context.Context, importID string) (*Job, error) {
+	return s.getJob(ctx, importID)
+}
+
+// InterruptJobs finishes the jobs that were running when the server stopped with an error saying how far they got,
+// it is run on startup before any job can be started
+func (s *Store) InterruptJobs(ctx context.Context) error {
+	return s.interruptJobs(ctx)
+}
+
+// DeleteOldJobs deletes the jobs uploaded more than maxAge ago that aren't running
+func (s *Store) DeleteOldJobs(ctx context.Context, maxAge time.Duration) error {
+	return s.deleteOldJobs(ctx, time.Now().Add(-maxAge))
+}
+
+// Fields are all the fields that can be imported, in the order they are shown
+var Fields = []Field{Username, UniversityUsername, Firstname, Nickname, Lastname, Email, Pronouns}
+
+// headers are the lower case headers each field is matched with when it isn't mapped, these include the ones
+// used by the Students' Union membership export
+var headers = map[Field][]string{
+	Username:           {"username", "user name"},
+	UniversityUsername: {"university_username", "university username", "university id", "uni username"},
+	Firstname:          {"first_name", "first name", "firstname", "forename", "given name"},
+	Nickname:           {"nickname", "preferred name", "known as"},
+	Lastname:           {"last_name", "last name", "lastname", "surname", "family name"},
+	Email:              {"email", "email address", "e-mail", "e-mail address"},
+	Pronouns:           {"pronouns"},
+}
+
+// Parse reads the CSV, the first line is the headers and every field is read from the column the mapping gives or
+// the usual header for it
+func Parse(r io.Reader, m Mapping) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+
+	for i, h := range header {
+		// a byte order mark is left on the first header by excel
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if _, ok := columns[h]; !ok {
+			columns[h] = i
+		}
+	}
+
+	fields := make(map[Field]int)
+
+	for _, f := range Fields {
+		if h, ok := m[f]; ok && h != "" {
+			i, ok := columns[strings.ToLower(strings.TrimSpace(h))]
+			if !ok {
+				return nil, fmt.Errorf("column \"%s\" for %s isn't in the csv", h, f)
+			}
+
+			fields[f] = i
+
+			continue
+		}
+
+		for _, h := range headers[f] {
+			if i, ok := columns[h]; ok {
+				fields[f] = i
+
+				break
+			}
+		}
+	}
+
+	if _, ok := fields[Email]; !ok {
+		return nil, errors.New("csv must have an email column")
+	}
+
+	var rows []Row
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read csv line %d: %w", line, err)
+		}
+
+		values := make(map[Field]string)
+
+		for f, i := range fields {
+			if i < len(record) && strings.TrimSpace(record[i]) != "" {
+				values[f] = strings.TrimSpace(record[i])
+			}
+		}
+
+		if len(values) == 0 {
+			continue
+		}
+
+		rows = append(rows, newRow(line, values))
+	}
+
+	return rows, nil
+}
+
+// newRow fills the user from the values, emails and usernames are lower case and missing ones are made from the
+// email
+func newRow(line int, values map[Field]string) Row {
+	for _, f := range []Field{Email, Username, UniversityUsername} {
+		if v, ok := values[f]; ok {
+			values[f] = strings.ToLower(v)
+		}
+	}
+
+	u := user.User{
+		Username:           values[Username],
+		UniversityUsername: values[UniversityUsername],
+		Firstname:          values[Firstname],
+		Nickname:           values[Nickname],
+		Lastname:           values[Lastname],
+		Email:              values[Email],
+		Pronouns:           null.NewString(values[Pronouns], values[Pronouns] != ""),
+		LoginType:          "internal",
+		ResetPw:            true,
+		Enabled:            true,
+	}
+
+	if u.UniversityUsername == "" && strings.HasSuffix(u.Email, universityDomain) {
+		u.UniversityUsername = strings.TrimSuffix(u.Email, universityDomain)
+	}
+
+	if u.Username == "" {
+		u.Username = u.UniversityUsername
+	}
+
+	if u.Username == "" {
+		u.Username, _, _ = strings.Cut(u.Email, "@")
+	}
+
+	if u.Nickname == "" {
+		u.Nickname = u.Firstname
+	}
+
+	return Row{
+		Line:   line,
+		Values: values,
+		User:   u,
+	}
+}
+
+// Plan validates the rows and works out whether each one creates a user, updates the existing user with the
+// same email or is skipped, nothing is changed
+func Plan(ctx context.Context, users user.Repo, rows []Row) ([]Row, error) {
+	emails := make(map[string]int)
+	usernames := make(map[string]int)
+	universityUsernames := make(map[string]int)
+
+	for i := range rows {
+		row := &rows[i]
+		row.Action = Skip
+		row.Changes = nil
+		row.Problems = validate(row.User)
+
+		for _, seen := range []struct {
+			lines map[string]int
+			value string
+			name  string
+		}{
+			// emails are matched without case, the same as with the existing users
+			{emails, strings.ToLower(row.User.Email), "email"},
+			{usernames, row.User.Username, "username"},
+			{universityUsernames, row.User.UniversityUsername, "university username"},
+		} {
+			if seen.value == "" {
+				continue
+			}
+
+			if line, ok := seen.lines[seen.value]; ok {
+				row.Problems = append(row.Problems, fmt.Sprintf("%s is the same as line %d", seen.name, line))
+			} else {
+				seen.lines[seen.value] = row.Line
+			}
+		}
+
+		if len(row.Problems) > 0 {
+			continue
+		}
+
+		err := plan(ctx, users, row)
+		if err != nil {
+			return nil, fmt.Errorf("failed to plan line %d: %w", row.Line, err)
+		}
+	}
+
+	return rows, nil
+}
+
+// plan compares a valid row with the users that have the same email, username and university username, deleted
+// users are left out as their account is gone
+func plan(ctx context.Context, users user.Repo, row *Row) error {
+	// only a missing row means there isn't a user, any other error would make the row create a duplicate
+	existing, err := users.GetExistingUser(ctx, user.User{Email: row.User.Email})
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to get user by email: %w", err)
+		}
+
+		existing = user.User{}
+	}
+
+	byUsername, err := users.GetExistingUser(ctx, user.User{Username: row.User.Username})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get user by username: %w", err)
+	}
+
+	if err == nil && byUsername.UserID != existing.UserID {
+		row.Problems = append(row.Problems, fmt.Sprintf("username \"%s\" belongs to a different user",
+			row.User.Username))
+	}
+
+	if row.User.UniversityUsername != "" {
+		var byUniversityUsername user.User
+
+		byUniversityUsername, err = users.GetUserByUniversityUsername(ctx,
+			user.User{UniversityUsername: row.User.UniversityUsername})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to get user by university username: %w", err)
+		}
+
+		if err == nil && byUniversityUsername.UserID != existing.UserID {
+			row.Problems = append(row.Problems, fmt.Sprintf("university username \"%s\" belongs to a different user",
+				row.User.UniversityUsername))
+		}
+	}
+
+	if existing.UserID == 0 {
+		if row.Values[Firstname] == "" || row.Values[Lastname] == "" {
+			row.Problems = append(row.Problems, "first and last name must be set for a new user")
+		}
+
+		if len(row.Problems) == 0 {
+			row.Action = Create
+		}
+
+		return nil
+	}
+
+	row.ExistingUserID = existing.UserID
+
+	if len(row.Problems) > 0 {
+		return nil
+	}
+
+	for _, f := range Fields {
+		v, ok := row.Values[f]
+		if !ok || v == current(existing, f) {
+			continue
+		}
+
+		row.Changes = append(row.Changes, f)
+	}
+
+	// an existing user is still added to the roles, so it is updated even without any changes
+	row.Action = Update
+
+	return nil
+}
+
+// validate checks the values of a user are usable
+func validate(u user.User) []string {
+	var problems []string
+
+	if _, domain, ok := strings.Cut(u.Email, "@"); !ok || domain == "" || strings.ContainsAny(u.Email, " ,;<>") {
+		problems = append(problems, fmt.Sprintf("email \"%s\" isn't valid", u.Email))
+	}
+
+	if u.Username == "" || strings.ContainsAny(u.Username, " @") {
+		problems = append(problems, fmt.Sprintf("username \"%s\" isn't valid", u.Username))
+	}
+
+	for _, r := range u.UniversityUsername {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			problems = append(problems, fmt.Sprintf("university username \"%s\" must only be letters and numbers",
+				u.UniversityUsername))
+
+			break
+		}
+	}
+
+	return problems
+}
+
+// current returns the value of a field of a user
+func current(u user.User, f Field) string {
+	switch f {
+	case Username:
+		return u.Username
+	case UniversityUsername:
+		return u.UniversityUsername
+	case Firstname:
+		return u.Firstname
+	case Nickname:
+		return u.Nickname
+	case Lastname:
+		return u.Lastname
+	case Email:
+		return u.Email
+	case Pronouns:
+		return u.Pronouns.String
+	}
+
+	return ""
+}
+
+// NewJob makes a job of planned rows
+func NewJob(rows []Row, opts Options) *Job {
+	return &Job{
+		rows: rows,
+		opts: opts,
+		progress: Progress{
+			Total: len(rows),
+		},
+	}
+}
+
+// ImportID returns the id the job is kept with, it is empty until the job is added to a Store
+func (j *Job) ImportID() string {
+	return j.importID
+}
+
+// Rows returns the planned rows
+func (j *Job) Rows() []Row {
+	return j.rows
+}
+
+// Options returns the options the job runs with
+func (j *Job) Options() Options {
+	return j.opts
+}
+
+// Progress returns a copy of how far through the job is
+func (j *Job) Progress() Progress {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	p := j.progress
+	p.Errors = append([]string(nil), j.progress.Errors...)
+
+	return p
+}
+
+// Run creates and updates the users, changeEmail is called for each updated email and welcome for each created
+// user when the job sends emails, a job can only be run once
+func (j *Job) Run(ctx context.Context, users user.Repo, changeEmail ChangeEmail, welcome Welcome) error {
+	j.mu.Lock()
+	if j.progress.Started {
+		j.mu.Unlock()
+
+		return errors.New("import has already been run")
+	}
+
+	j.progress.Started = true
+	j.progress.StartedAt = null.TimeFrom(time.Now())
+	j.mu.Unlock()
+
+	if j.store != nil {
+		// another request may have loaded and started the same job, only the first one runs it
+		err := j.store.startJob(ctx, j.importID, j.Progress())
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, row := range j.rows {
+		j.run(ctx, users, changeEmail, welcome, row)
+
+		if j.store != nil {
+			err := j.store.saveProgress(ctx, j.importID, j.Progress())
+			if err != nil {
+				log.Printf("failed to save progress of user import %s: %+v", j.importID, err)
+			}
+		}
+	}
+
+	j.mu.Lock()
+	j.progress.Finished = true
+	j.progress.FinishedAt = null.TimeFrom(time.Now())
+	j.mu.Unlock()
+
+	if j.store != nil {
+		err := j.store.saveProgress(ctx, j.importID, j.Progress())
+		if err != nil {
+			return fmt.Errorf("failed to save finished import: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// run does the action of a row and records it
+func (j *Job) run(ctx context.Context, users user.Repo, changeEmail ChangeEmail, welcome Welcome, row Row) {
+	var err error
+
+	var emailErr error
+
+	var emailed bool
+
+	var roles int
+
+	switch row.Action {
+	case Create:
+		var u user.User
+
+		var password string
+
+		u, password, err = j.create(ctx, users, row)
+		if err == nil {
+			roles, err = j.addRoles(ctx, users, u)
+		}
+
+		if err == nil && j.opts.SendEmail && welcome != nil {
+			emailErr = welcome(ctx, u, password)
+			emailed = emailErr == nil
+		}
+	case Update:
+		err = j.update(ctx, users, changeEmail, row)
+		if err == nil {
+			roles, err = j.addRoles(ctx, users, user.User{UserID: row.ExistingUserID})
+		}
+	case Skip:
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.progress.Done++
+	j.progress.RolesAssigned += roles
+
+	switch {
+	case err != nil:
+		j.progress.Failed++
+		j.progress.Errors = append(j.progress.Errors, fmt.Sprintf("line %d: %s", row.Line, err))
+	case row.Action == Create:
+		j.progress.Created++
+	case row.Action == Update:
+		j.progress.Updated++
+	default:
+		j.progress.Skipped++
+	}
+
+	if emailErr != nil {
+		j.progress.EmailsFailed++
+		j.progress.Errors = append(j.progress.Errors, fmt.Sprintf("line %d: failed to send email: %s", row.Line,
+			emailErr))
+	} else if emailed {
+		j.progress.Emailed++
+	}
+}
+
+// create adds the user with a random password that has to be changed when they first log in
+func (j *Job) create(ctx context.Context, users user.Repo, row Row) (user.User, string, error) {
+	var password string
+
+	var err error
+
+	if j.opts.Passwords != nil {
+		password, err = j.opts.Passwords.Generate(ctx, row.User)
+	} else {
+		password, err = utils.GenerateRandom(utils.GeneratePassword)
+	}
+
+	if err != nil {
+		return user.User{}, "", fmt.Errorf("failed to generate password: %w", err)
+	}
+
+	salt, err := utils.GenerateRandom(utils.GenerateSalt)
+	if err != nil {
+		return user.User{}, "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	u := row.User
+	u.Password = null.StringFrom(password)
+	u.Salt = null.StringFrom(salt)
+
+	u, err = users.AddUser(ctx, u, j.opts.ImportedBy)
+	if err != nil {
+		return user.User{}, "", fmt.Errorf("failed to add user: %w", err)
+	}
+
+	return u, password, nil
+}
+
+// update changes the fields that were in the row and are different on the existing user, the email is changed
+// after the rest
+func (j *Job) update(ctx context.Context, users user.Repo, changeEmail ChangeEmail, row Row) error {
+	if len(row.Changes) == 0 {
+		return nil
+	}
+
+	u, err := users.GetUser(ctx, user.User{UserID: row.ExistingUserID})
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	for _, f := range row.Changes {
+		switch f {
+		case Username:
+			u.Username = row.User.Username
+		case UniversityUsername:
+			u.UniversityUsername = row.User.UniversityUsername
+		case Firstname:
+			u.Firstname = row.User.Firstname
+		case Nickname:
+			u.Nickname = row.User.Nickname
+		case Lastname:
+			u.Lastname = row.User.Lastname
+		case Email:
+		case Pronouns:
+			u.Pronouns = row.User.Pronouns
+		}
+	}
+
+	err = users.EditUser(ctx, u, j.opts.ImportedBy)
+	if err != nil {
+		return fmt.Errorf("failed to edit user: %w", err)
+	}
+
+	if !slices.Contains(row.Changes, Email) {
+		return nil
+	}
+
+	if changeEmail == nil {
+		return errors.New("failed to change email: emails can't be changed by this import")
+	}
+
+	err = changeEmail(ctx, u, row.User.Email, j.opts.ImportedBy)
+	if err != nil {
+		return fmt.Errorf("failed to change email: %w", err)
+	}
+
+	return nil
+}
+
+// addRoles adds the user to the roles of the job they aren't already in and returns how many they were added to
+func (j *Job) addRoles(ctx context.Context, users user.Repo, u user.User) (int, error) {
+	var added int
+
+	for _, roleID := range j.opts.RoleIDs {
+		ru := user.RoleUser{RoleID: roleID, UserID: u.UserID}
+
+		_, err := users.GetRoleUser(ctx, ru)
+		if err == nil {
+			continue
+		}
+
+		if !errors.Is(err, sql.ErrNoRows) {
+			return added, fmt.Errorf("failed to get role user for role id %d: %w", roleID, err)
+		}
+
+		ru.GrantedBy = null.IntFrom(int64(j.opts.ImportedBy))
+		ru.Reason = "Bulk import"
+
+		_, err = users.AddRoleUser(ctx, ru)
+		if err != nil {
+			return added, fmt.Errorf("failed to add user to role id %d: %w", roleID, err)
+		}
+
+		added++
+	}
+
+	return added, nil
+}
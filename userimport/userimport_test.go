@@ -0,0 +1,158 @@
+package userimport
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/ystv/web-auth/user"
+	mock_user "github.com/ystv/web-auth/user/mocks"
+)
+
+func TestParse(t *testing.T) {
+	csv := "\ufeffFirst Name,Last Name,Email,Membership Type\n" +
+		"Alice,Smith,ABC123@york.ac.uk,Full\n" +
+		",,,\n" +
+		"Bob,Jones,bob@example.com,Associate\n"
+
+	rows, err := Parse(strings.NewReader(csv), nil)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, 2, rows[0].Line)
+	assert.Equal(t, "abc123@york.ac.uk", rows[0].User.Email)
+	assert.Equal(t, "abc123", rows[0].User.UniversityUsername)
+	assert.Equal(t, "abc123", rows[0].User.Username)
+	assert.Equal(t, "Alice", rows[0].User.Nickname)
+	assert.NotContains(t, rows[0].Values, Username)
+
+	assert.Equal(t, 4, rows[1].Line)
+	assert.Equal(t, "bob", rows[1].User.Username)
+	assert.Empty(t, rows[1].User.UniversityUsername)
+
+	rows, err = Parse(strings.NewReader("Forename,Mail\nCarol,carol@example.com\n"), Mapping{Email: "mail"})
+	require.NoError(t, err)
+	assert.Equal(t, "carol@example.com", rows[0].User.Email)
+	assert.Equal(t, "Carol", rows[0].User.Firstname)
+
+	_, err = Parse(strings.NewReader("Forename,Mail\n"), nil)
+	assert.EqualError(t, err, "csv must have an email column")
+
+	_, err = Parse(strings.NewReader("Forename,Mail\n"), Mapping{Email: "Email"})
+	assert.EqualError(t, err, "column \"Email\" for email isn't in the csv")
+}
+
+func TestPlan(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	users := mock_user.NewMockRepo(ctrl)
+
+	existing := user.User{UserID: 7, Username: "abc123", UniversityUsername: "abc123", Firstname: "Alice",
+		Nickname: "Alice", Lastname: "Smith", Email: "abc123@york.ac.uk"}
+	notFound := fmt.Errorf("failed to get user from db: %w", sql.ErrNoRows)
+
+	users.EXPECT().GetExistingUser(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, u user.User) (user.User,
+		error) {
+		if u.Email == existing.Email || u.Username == existing.Username {
+			return existing, nil
+		}
+
+		return user.User{}, notFound
+	}).AnyTimes()
+	users.EXPECT().GetUserByUniversityUsername(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, u user.User) (user.User, error) {
+			if u.UniversityUsername == existing.UniversityUsername {
+				return existing, nil
+			}
+
+			return user.User{}, notFound
+		}).AnyTimes()
+
+	csv := "First Name,Last Name,Email,Username\n" +
+		"Alice,Smyth,abc123@york.ac.uk,\n" +
+		"Bob,Jones,def456@york.ac.uk,\n" +
+		"Bobby,Jones,DEF456@york.ac.uk,\n" +
+		"Carol,,carol@example.com,\n" +
+		"Dan,Brown,dan@example.com,abc123\n" +
+		"Eve,White,not an email,\n"
+
+	rows, err := Parse(strings.NewReader(csv), nil)
+	require.NoError(t, err)
+
+	rows, err = Plan(context.Background(), users, rows)
+	require.NoError(t, err)
+	require.Len(t, rows, 6)
+
+	assert.Equal(t, Update, rows[0].Action)
+	assert.Equal(t, 7, rows[0].ExistingUserID)
+	assert.Equal(t, []Field{Lastname}, rows[0].Changes)
+
+	assert.Equal(t, Create, rows[1].Action)
+
+	assert.Equal(t, Skip, rows[2].Action)
+	assert.Contains(t, rows[2].Problems, "email is the same as line 3")
+
+	assert.Equal(t, Skip, rows[3].Action)
+	assert.Equal(t, []string{"first and last name must be set for a new user"}, rows[3].Problems)
+
+	assert.Equal(t, Skip, rows[4].Action)
+	assert.Contains(t, rows[4].Problems, "username is the same as line 2")
+
+	assert.Equal(t, Skip, rows[5].Action)
+	assert.Contains(t, rows[5].Problems, "email \"not an email\" isn't valid")
+}
+
+func TestPlanLookupError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	users := mock_user.NewMockRepo(ctrl)
+
+	// a failed lookup mustn't look like there isn't a user, or the row would create a duplicate
+	users.EXPECT().GetExistingUser(gomock.Any(), gomock.Any()).Return(user.User{}, errors.New("connection refused"))
+
+	rows, err := Parse(strings.NewReader("First Name,Last Name,Email\nBob,Jones,def456@york.ac.uk\n"), nil)
+	require.NoError(t, err)
+
+	_, err = Plan(context.Background(), users, rows)
+	assert.ErrorContains(t, err, "connection refused")
+}
+
+func TestUpdateEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	users := mock_user.NewMockRepo(ctrl)
+
+	existing := user.User{UserID: 4, Firstname: "Bob", Email: "bob@ystv.co.uk"}
+
+	users.EXPECT().GetUser(gomock.Any(), user.User{UserID: 4}).Return(existing, nil)
+	// the rest of the row is edited with the email left alone, EditUser can't change it
+	users.EXPECT().EditUser(gomock.Any(), user.User{UserID: 4, Firstname: "Robert", Email: "bob@ystv.co.uk"}, 2).
+		Return(nil)
+
+	var changed []string
+
+	changeEmail := func(_ context.Context, u user.User, email string, changedBy int) error {
+		assert.Equal(t, 4, u.UserID)
+		assert.Equal(t, 2, changedBy)
+
+		changed = append(changed, email)
+
+		return nil
+	}
+
+	j := NewJob(nil, Options{ImportedBy: 2})
+
+	err := j.update(context.Background(), users, changeEmail, Row{
+		Action:         Update,
+		ExistingUserID: 4,
+		User:           user.User{Firstname: "Robert", Email: "bob.jones@york.ac.uk"},
+		Changes:        []Field{Firstname, Email},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"bob.jones@york.ac.uk"}, changed)
+}
@@ -0,0 +1,32 @@
+package userimport
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ystv/web-auth/emailtemplate"
+	"github.com/ystv/web-auth/mailqueue"
+	"github.com/ystv/web-auth/user"
+)
+
+// NewWelcome returns a Welcome that adds the signup email to the mail queue, it is sent by the queue's worker
+func NewWelcome(q mailqueue.Repo, t emailtemplate.Repo) Welcome {
+	return func(ctx context.Context, u user.User, password string) error {
+		file, err := t.Mail(ctx, emailtemplate.Signup, u.Language.String, u.Email,
+			emailtemplate.SignupData{
+				Name:     u.Firstname,
+				Username: u.Username,
+				Password: password,
+			})
+		if err != nil {
+			return fmt.Errorf("failed to render email: %w", err)
+		}
+
+		_, err = q.Queue(ctx, file)
+		if err != nil {
+			return fmt.Errorf("failed to queue email: %w", err)
+		}
+
+		return nil
+	}
+}
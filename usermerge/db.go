@@ -0,0 +1,518 @@
+package usermerge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ystv/web-auth/accessrequest"
+	"github.com/ystv/web-auth/keylist"
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/utils"
+	"github.com/ystv/web-auth/webhook"
+)
+
+type (
+	// move is one kind of row that belongs to a user and is moved to the Survivor
+	move struct {
+		name   string
+		table  string
+		column string
+		// clash finds the Loser's rows the Survivor already has an equivalent of, nil when there can't be any
+		clash      func(survivorID int) sq.Sqlizer
+		resolve    resolve
+		resolution string
+	}
+
+	// resolve is what is done with a clashing row
+	resolve int
+
+	// reference is a column that records which user did something
+	reference struct {
+		table  string
+		column string
+	}
+)
+
+const (
+	// resolveDelete removes the clashing row
+	resolveDelete resolve = iota
+	// resolveCancel cancels the clashing pending access request, it is moved after
+	resolveCancel
+	// resolveReturn returns the clashing held key to whoever did the merge, it is moved after
+	resolveReturn
+	// resolveWiden stretches the Survivor's role membership to cover the clashing one's dates, then removes it
+	resolveWiden
+)
+
+var moves = []move{
+	{
+		name:   "Roles",
+		table:  "people.role_members",
+		column: "user_id",
+		clash: func(survivorID int) sq.Sqlizer {
+			return sq.Expr("role_id IN (SELECT role_id FROM people.role_members WHERE user_id = ?)", survivorID)
+		},
+		resolve:    resolveWiden,
+		resolution: "merged into the survivor's membership of the role, which covers the longer of the two",
+	},
+	{
+		name:   "Officerships",
+		table:  "people.officership_members",
+		column: "user_id",
+	},
+	{
+		name:   "API tokens",
+		table:  "web_auth.api_tokens",
+		column: "user_id",
+	},
+	{
+		name:   "Access requests",
+		table:  "people.access_requests",
+		column: "user_id",
+		clash: func(survivorID int) sq.Sqlizer {
+			return sq.And{
+				sq.Eq{"status": accessrequest.Pending},
+				sq.Expr("role_id IN (SELECT role_id FROM people.access_requests WHERE user_id = ? AND status = ?)",
+					survivorID, accessrequest.Pending),
+			}
+		},
+		resolve:    resolveCancel,
+		resolution: "cancelled as the survivor has already requested the role",
+	},
+	{
+		name:   "Access review items",
+		table:  "people.access_review_items",
+		column: "user_id",
+		clash: func(survivorID int) sq.Sqlizer {
+			return sq.Expr("(review_id, role_id) IN "+
+				"(SELECT review_id, role_id FROM people.access_review_items WHERE user_id = ?)", survivorID)
+		},
+		resolve:    resolveDelete,
+		resolution: "removed as the survivor is already in the review for the role",
+	},
+	{
+		name:   "Memberships",
+		table:  "people.memberships",
+		column: "user_id",
+		clash: func(survivorID int) sq.Sqlizer {
+			return sq.Expr("(type_id, academic_year) IN "+
+				"(SELECT type_id, academic_year FROM people.memberships WHERE user_id = ?)", survivorID)
+		},
+		resolve:    resolveDelete,
+		resolution: "removed as the survivor already has the membership for the year",
+	},
+	{
+		name:   "Keys",
+		table:  "people.keylist_grants",
+		column: "user_id",
+		clash: func(survivorID int) sq.Sqlizer {
+			return sq.And{
+				sq.Eq{"returned_at": nil},
+				sq.Expr("key_id IN (SELECT key_id FROM people.keylist_grants WHERE user_id = ? AND returned_at IS NULL)",
+					survivorID),
+			}
+		},
+		resolve:    resolveReturn,
+		resolution: "returned as the survivor already holds the key",
+	},
+	{
+		name:   "Data exports",
+		table:  "people.data_exports",
+		column: "user_id",
+	},
+	{
+		name:   "Email addresses",
+		table:  "people.user_emails",
+		column: "user_id",
+		clash: func(survivorID int) sq.Sqlizer {
+			return sq.Expr("lower(email) IN (SELECT lower(email) FROM people.user_emails WHERE user_id = ?)", survivorID)
+		},
+		resolve:    resolveDelete,
+		resolution: "removed as the survivor already has the address",
+	},
+	{
+		name:   "Status changes",
+		table:  "people.status_changes",
+		column: "user_id",
+	},
+}
+
+// references are the columns recording who did something, they are moved so the history follows the Survivor
+var references = []reference{
+	{table: "people.users", column: "created_by"},
+	{table: "people.users", column: "updated_by"},
+	{table: "people.users", column: "deleted_by"},
+	{table: "people.role_members", column: "granted_by"},
+	{table: "people.access_requests", column: "decided_by"},
+	{table: "people.access_reviews", column: "created_by"},
+	{table: "people.access_review_items", column: "decided_by"},
+	{table: "people.memberships", column: "created_by"},
+	{table: "people.keylist_grants", column: "issued_by"},
+	{table: "people.keylist_grants", column: "returned_to"},
+	{table: "people.keylist_events", column: "event_by"},
+	{table: "people.data_exports", column: "exported_by"},
+	{table: "people.duplicate_dismissals", column: "dismissed_by"},
+	{table: "people.email_changes", column: "requested_by"},
+	{table: "people.status_changes", column: "changed_by"},
+	{table: "web_auth.webhooks", column: "created_by"},
+	{table: "web_auth.email_templates", column: "updated_by"},
+	{table: "web_auth.email_template_versions", column: "created_by"},
+}
+
+func (s *Store) preview(ctx context.Context, m Merge) ([]Move, error) {
+	previews := make([]Move, 0, len(moves)+1)
+
+	for _, mv := range moves {
+		rows, err := s.count(ctx, mv.table, sq.Eq{mv.column: m.Loser.UserID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to count %s for merge preview: %w", mv.name, err)
+		}
+
+		p := Move{
+			Name: mv.name,
+			Rows: rows,
+		}
+
+		if mv.clash != nil {
+			p.Clashes, err = s.count(ctx, mv.table,
+				sq.And{sq.Eq{mv.column: m.Loser.UserID}, mv.clash(m.Survivor.UserID)})
+			if err != nil {
+				return nil, fmt.Errorf("failed to count clashing %s for merge preview: %w", mv.name, err)
+			}
+
+			p.Resolution = mv.resolution
+
+			if mv.resolve == resolveDelete || mv.resolve == resolveWiden {
+				p.Rows -= p.Clashes
+			}
+		}
+
+		previews = append(previews, p)
+	}
+
+	audit := Move{Name: "Audit references"}
+
+	for _, ref := range references {
+		rows, err := s.count(ctx, ref.table, sq.Eq{ref.column: m.Loser.UserID})
+		if err != nil {
+			return nil, fmt.Errorf("failed to count %s.%s for merge preview: %w", ref.table, ref.column, err)
+		}
+
+		audit.Rows += rows
+	}
+
+	return append(previews, audit), nil
+}
+
+func (s *Store) count(ctx context.Context, table string, where sq.Sqlizer) (int, error) {
+	var count int
+
+	builder := utils.PSQL().Select("COUNT(*)").
+		From(table).
+		Where(where)
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for count: %w", err))
+	}
+
+	err = s.db.GetContext(ctx, &count, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count rows: %w", err)
+	}
+
+	return count, nil
+}
+
+// merge frees the Loser's unique fields before the Survivor takes any of them, so the Loser's row is read first
+func (s *Store) merge(ctx context.Context, m Merge) error {
+	if m.Survivor.UserID == m.Loser.UserID {
+		return errors.New("failed to merge users: a user can't be merged into themself")
+	}
+
+	cols, err := columns(m.FromLoser)
+	if err != nil {
+		return fmt.Errorf("failed to merge users: %w", err)
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin merge transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var loser user.User
+
+	builder := utils.PSQL().Select("*").
+		From("people.users").
+		Where(sq.Eq{"user_id": m.Loser.UserID}).
+		Suffix("FOR UPDATE")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for merge: %w", err))
+	}
+
+	//nolint:musttag
+	err = tx.GetContext(ctx, &loser, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to get user to merge: %w", err)
+	}
+
+	now := time.Now()
+
+	deleted, err := s.updateUser(ctx, tx, m.Loser.UserID, map[string]interface{}{
+		"username":      fmt.Sprintf("merged-%d", m.Loser.UserID),
+		"email":         fmt.Sprintf("noreply+%d@ystv.co.uk", m.Loser.UserID),
+		"ldap_username": nil,
+		"password":      "",
+		"salt":          "",
+		"enabled":       false,
+		"updated_at":    now,
+		"updated_by":    m.MergedBy,
+		"deleted_at":    now,
+		"deleted_by":    m.MergedBy,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete merged user: %w", err)
+	}
+
+	picked := userColumns(loser)
+
+	set := map[string]interface{}{
+		"updated_at": now,
+		"updated_by": m.MergedBy,
+	}
+
+	for _, col := range cols {
+		set[col] = picked[col]
+	}
+
+	survivor, err := s.updateUser(ctx, tx, m.Survivor.UserID, set)
+	if err != nil {
+		return fmt.Errorf("failed to update surviving user: %w", err)
+	}
+
+	// the Survivor can only have one primary address, it is set again from their email after the moves
+	err = s.setPrimaryEmail(ctx, tx, m.Loser.UserID, "")
+	if err != nil {
+		return fmt.Errorf("failed to update merged user's emails: %w", err)
+	}
+
+	for _, mv := range moves {
+		if mv.clash != nil {
+			err = s.resolveClashes(ctx, tx, mv, m)
+			if err != nil {
+				return fmt.Errorf("failed to resolve clashing %s: %w", mv.name, err)
+			}
+		}
+
+		err = s.moveColumn(ctx, tx, mv.table, mv.column, m)
+		if err != nil {
+			return fmt.Errorf("failed to move %s: %w", mv.name, err)
+		}
+	}
+
+	for _, ref := range references {
+		err = s.moveColumn(ctx, tx, ref.table, ref.column, m)
+		if err != nil {
+			return fmt.Errorf("failed to move %s.%s: %w", ref.table, ref.column, err)
+		}
+	}
+
+	err = s.setPrimaryEmail(ctx, tx, m.Survivor.UserID, survivor.Email)
+	if err != nil {
+		return fmt.Errorf("failed to update surviving user's emails: %w", err)
+	}
+
+	err = s.webhook.Emit(ctx, tx, webhook.UserUpdated, survivor)
+	if err != nil {
+		return fmt.Errorf("failed to emit user updated for merge: %w", err)
+	}
+
+	err = s.webhook.Emit(ctx, tx, webhook.UserDeleted, deleted)
+	if err != nil {
+		return fmt.Errorf("failed to emit user deleted for merge: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit merge: %w", err)
+	}
+
+	return nil
+}
+
+// setPrimaryEmail makes the user's address matching email their primary one, none are primary when it is empty
+func (s *Store) setPrimaryEmail(ctx context.Context, tx *sqlx.Tx, userID int, email string) error {
+	builder := utils.PSQL().Update("people.user_emails").
+		Set("is_primary", false).
+		Where(sq.Eq{"user_id": userID, "is_primary": true})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for setPrimaryEmail: %w", err))
+	}
+
+	_, err = tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to clear primary email: %w", err)
+	}
+
+	if email == "" {
+		return nil
+	}
+
+	builder = utils.PSQL().Update("people.user_emails").
+		Set("is_primary", true).
+		Where(sq.And{sq.Eq{"user_id": userID}, sq.Expr("lower(email) = lower(?)", email)})
+
+	sql, args, err = builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for setPrimaryEmail: %w", err))
+	}
+
+	_, err = tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to set primary email: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) updateUser(ctx context.Context, tx *sqlx.Tx, userID int,
+	set map[string]interface{}) (user.User, error) {
+	var u user.User
+
+	builder := utils.PSQL().Update("people.users").
+		SetMap(set).
+		Where(sq.Eq{"user_id": userID}).
+		Suffix("RETURNING *")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for updateUser: %w", err))
+	}
+
+	//nolint:musttag
+	err = tx.GetContext(ctx, &u, sql, args...)
+	if err != nil {
+		return u, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return u, nil
+}
+
+// resolveClashes deals with the Loser's rows the Survivor already has an equivalent of before the rest are moved
+func (s *Store) resolveClashes(ctx context.Context, tx *sqlx.Tx, mv move, m Merge) error {
+	where := sq.And{sq.Eq{mv.column: m.Loser.UserID}, mv.clash(m.Survivor.UserID)}
+
+	var builders []sq.Sqlizer
+
+	switch mv.resolve {
+	case resolveDelete:
+		builders = append(builders, utils.PSQL().Delete(mv.table).
+			Where(where))
+	case resolveWiden:
+		builders = append(builders, widenRoleMembersBuilder(m), utils.PSQL().Delete(mv.table).
+			Where(where))
+	case resolveCancel:
+		builders = append(builders, utils.PSQL().Update(mv.table).
+			SetMap(map[string]interface{}{
+				"status":     accessrequest.Cancelled,
+				"decided_by": m.MergedBy,
+				"decided_at": time.Now(),
+				"comment":    "Cancelled when the account was merged",
+			}).
+			Where(where))
+	case resolveReturn:
+		// the porters' log has to show the key coming back as well as the grant
+		builders = append(builders, utils.PSQL().Insert("people.keylist_events").
+			Columns("grant_id", "event", "event_by", "note").
+			Select(utils.PSQL().Select("grant_id").
+				Column("?", keylist.Returned).
+				Column("?::int", m.MergedBy).
+				Column("?", "Returned when the account was merged").
+				From(mv.table).
+				Where(where)),
+			utils.PSQL().Update(mv.table).
+				SetMap(map[string]interface{}{
+					"returned_at": time.Now(),
+					"returned_to": m.MergedBy,
+				}).
+				Where(where))
+	default:
+		return fmt.Errorf("unknown resolve: %d", mv.resolve)
+	}
+
+	for _, builder := range builders {
+		sql, args, err := builder.ToSql()
+		if err != nil {
+			panic(fmt.Errorf("failed to build sql for resolveClashes: %w", err))
+		}
+
+		_, err = tx.ExecContext(ctx, sql, args...)
+		if err != nil {
+			return fmt.Errorf("failed to resolve clashes in %s: %w", mv.table, err)
+		}
+	}
+
+	return nil
+}
+
+// widenRoleMembersBuilder gives the Survivor's memberships of the roles the Loser also has the earlier start and the
+// later end of the two, a NULL start or end is open so it wins
+func widenRoleMembersBuilder(m Merge) sq.UpdateBuilder {
+	return utils.PSQL().Update("people.role_members rm").
+		Set("starts_at", sq.Expr("CASE WHEN rm.starts_at IS NULL OR l.starts_at IS NULL THEN NULL "+
+			"ELSE LEAST(rm.starts_at, l.starts_at) END")).
+		Set("ends_at", sq.Expr("CASE WHEN rm.ends_at IS NULL OR l.ends_at IS NULL THEN NULL "+
+			"ELSE GREATEST(rm.ends_at, l.ends_at) END")).
+		From("people.role_members l").
+		Where(sq.Eq{"rm.user_id": m.Survivor.UserID, "l.user_id": m.Loser.UserID}).
+		Where("l.role_id = rm.role_id")
+}
+
+func (s *Store) moveColumn(ctx context.Context, tx *sqlx.Tx, table, column string, m Merge) error {
+	builder := utils.PSQL().Update(table).
+		Set(column, m.Survivor.UserID).
+		Where(sq.Eq{column: m.Loser.UserID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for moveColumn: %w", err))
+	}
+
+	_, err = tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to move %s.%s: %w", table, column, err)
+	}
+
+	return nil
+}
+
+// userColumns are the values of the columns a Field can copy, the user has to be read straight from the db as
+// user.GetUser replaces the avatar with its url
+func userColumns(u user.User) map[string]interface{} {
+	return map[string]interface{}{
+		"username":            u.Username,
+		"university_username": u.UniversityUsername,
+		"ldap_username":       u.LDAPUsername,
+		"email":               u.Email,
+		"first_name":          u.Firstname,
+		"nickname":            u.Nickname,
+		"last_name":           u.Lastname,
+		"pronouns":            u.Pronouns,
+		"language":            u.Language,
+		"avatar":              u.Avatar,
+		"use_gravatar":        u.UseGravatar,
+	}
+}
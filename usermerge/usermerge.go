@@ -0,0 +1,161 @@
+package usermerge
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/webhook"
+)
+
+//go:generate mockgen -destination mocks/mock_usermerge.go -package mock_usermerge github.com/ystv/web-auth/usermerge Repo
+
+type (
+	Repo interface {
+		Preview(context.Context, Merge) ([]Move, error)
+		Merge(context.Context, Merge) error
+	}
+
+	// Store stores the dependencies
+	Store struct {
+		db      *sqlx.DB
+		webhook webhook.Repo
+	}
+
+	// Merge is two accounts for the same person, the Loser's records are moved to the Survivor and the Loser is
+	// deleted, FromLoser are the names of the Fields the Survivor takes from the Loser
+	Merge struct {
+		Survivor  user.User
+		Loser     user.User
+		FromLoser []string
+		MergedBy  int
+	}
+
+	// Move is how many of the Loser's rows of one kind are moved to the Survivor, Clashes are the rows the Survivor
+	// already has an equivalent of, which are dealt with as Resolution says rather than moved
+	Move struct {
+		Name       string `json:"name"`
+		Rows       int    `json:"rows"`
+		Clashes    int    `json:"clashes"`
+		Resolution string `json:"resolution"`
+	}
+
+	// Field is a user field that can be picked from either account, Columns are copied together and Image is
+	// set when the value is the url of an image
+	Field struct {
+		Name    string
+		Label   string
+		Columns []string
+		Image   bool
+		value   func(user.User) string
+	}
+)
+
+// Fields are the user fields that can be picked from either account, in the order they are shown
+var Fields = []Field{
+	{
+		Name:    "username",
+		Label:   "Username",
+		Columns: []string{"username"},
+		value:   func(u user.User) string { return u.Username },
+	},
+	{
+		Name:    "universityUsername",
+		Label:   "University username",
+		Columns: []string{"university_username"},
+		value:   func(u user.User) string { return u.UniversityUsername },
+	},
+	{
+		Name:    "ldapUsername",
+		Label:   "LDAP username",
+		Columns: []string{"ldap_username"},
+		value:   func(u user.User) string { return u.LDAPUsername.String },
+	},
+	{
+		Name:    "email",
+		Label:   "Email",
+		Columns: []string{"email"},
+		value:   func(u user.User) string { return u.Email },
+	},
+	{
+		Name:    "firstname",
+		Label:   "First name",
+		Columns: []string{"first_name"},
+		value:   func(u user.User) string { return u.Firstname },
+	},
+	{
+		Name:    "nickname",
+		Label:   "Nickname",
+		Columns: []string{"nickname"},
+		value:   func(u user.User) string { return u.Nickname },
+	},
+	{
+		Name:    "lastname",
+		Label:   "Last name",
+		Columns: []string{"last_name"},
+		value:   func(u user.User) string { return u.Lastname },
+	},
+	{
+		Name:    "pronouns",
+		Label:   "Pronouns",
+		Columns: []string{"pronouns"},
+		value:   func(u user.User) string { return u.Pronouns.String },
+	},
+	{
+		Name:    "language",
+		Label:   "Email language",
+		Columns: []string{"language"},
+		value:   func(u user.User) string { return u.Language.String },
+	},
+	{
+		Name:    "avatar",
+		Label:   "Avatar",
+		Columns: []string{"avatar", "use_gravatar"},
+		Image:   true,
+		value:   func(u user.User) string { return u.Avatar },
+	},
+}
+
+var _ Repo = &Store{}
+
+// NewUserMergeRepo stores our dependency
+func NewUserMergeRepo(db *sqlx.DB, wh webhook.Repo) *Store {
+	return &Store{
+		db:      db,
+		webhook: wh,
+	}
+}
+
+// Preview returns what would be moved from the Loser to the Survivor without changing anything
+func (s *Store) Preview(ctx context.Context, m Merge) ([]Move, error) {
+	return s.preview(ctx, m)
+}
+
+// Merge saves the Survivor, moves the Loser's records to them and deletes the Loser in one transaction
+func (s *Store) Merge(ctx context.Context, m Merge) error {
+	return s.merge(ctx, m)
+}
+
+// Value returns the field's value for a user
+func (f Field) Value(u user.User) string {
+	return f.value(u)
+}
+
+// columns returns the columns of the named fields, an unknown name is an error rather than being ignored
+func columns(names []string) ([]string, error) {
+	cols := make([]string, 0, len(names))
+
+	for _, name := range names {
+		i := slices.IndexFunc(Fields, func(f Field) bool { return f.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown field: %s", name)
+		}
+
+		cols = append(cols, Fields[i].Columns...)
+	}
+
+	return cols, nil
+}
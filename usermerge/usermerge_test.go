@@ -0,0 +1,57 @@
+package usermerge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystv/web-auth/user"
+)
+
+func TestColumns(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		want  []string
+	}{
+		{name: "None", names: nil, want: []string{}},
+		{name: "Email", names: []string{"email"}, want: []string{"email"}},
+		{name: "AvatarWithGravatar", names: []string{"firstname", "avatar"},
+			want: []string{"first_name", "avatar", "use_gravatar"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cols, err := columns(tt.names)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cols)
+		})
+	}
+
+	t.Run("Unknown", func(t *testing.T) {
+		_, err := columns([]string{"email", "password"})
+		assert.Error(t, err)
+	})
+}
+
+func TestFieldsHaveColumns(t *testing.T) {
+	cols := userColumns(user.User{})
+
+	for _, f := range Fields {
+		for _, col := range f.Columns {
+			assert.Contains(t, cols, col, "field %s", f.Name)
+		}
+	}
+}
+
+func TestWidenRoleMembersSQL(t *testing.T) {
+	sql, args, err := widenRoleMembersBuilder(Merge{Survivor: user.User{UserID: 1}, Loser: user.User{UserID: 2}}).ToSql()
+	require.NoError(t, err)
+
+	assert.Equal(t, "UPDATE people.role_members rm SET "+
+		"starts_at = CASE WHEN rm.starts_at IS NULL OR l.starts_at IS NULL THEN NULL ELSE LEAST(rm.starts_at, l.starts_at) END, "+
+		"ends_at = CASE WHEN rm.ends_at IS NULL OR l.ends_at IS NULL THEN NULL ELSE GREATEST(rm.ends_at, l.ends_at) END "+
+		"FROM people.role_members l WHERE l.user_id = $1 AND rm.user_id = $2 AND l.role_id = rm.role_id", sql)
+	assert.Equal(t, []interface{}{2, 1}, args)
+}
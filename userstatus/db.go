@@ -0,0 +1,259 @@
+package userstatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/utils"
+	"github.com/ystv/web-auth/webhook"
+)
+
+func (s *Store) getStatusRoles(ctx context.Context) ([]StatusRole, error) {
+	var sr []StatusRole
+
+	builder := utils.PSQL().Select("sr.status", "sr.role_id", "r.name AS role_name").
+		From("people.status_roles sr").
+		InnerJoin("people.roles r ON r.role_id = sr.role_id").
+		OrderBy("sr.status", "r.name")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getStatusRoles: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &sr, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status roles: %w", err)
+	}
+
+	return sr, nil
+}
+
+func (s *Store) addStatusRole(ctx context.Context, sr StatusRole) error {
+	builder := utils.PSQL().Insert("people.status_roles").
+		Columns("status", "role_id").
+		Values(sr.Status, sr.RoleID)
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for addStatusRole: %w", err))
+	}
+
+	_, err = s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to add status role: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) removeStatusRole(ctx context.Context, sr StatusRole) error {
+	builder := utils.PSQL().Delete("people.status_roles").
+		Where(sq.Eq{"status": sr.Status, "role_id": sr.RoleID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for removeStatusRole: %w", err))
+	}
+
+	_, err = s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to remove status role: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) getChangesForUser(ctx context.Context, u user.User) ([]Change, error) {
+	var c []Change
+
+	builder := utils.PSQL().Select("c.*", "NULLIF(CONCAT(b.first_name, ' ', b.last_name), ' ') AS by_name").
+		From("people.status_changes c").
+		LeftJoin("people.users b ON b.user_id = c.changed_by").
+		Where(sq.Eq{"c.user_id": u.UserID}).
+		OrderBy("c.changed_at DESC")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getChangesForUser: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &c, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status changes for user: %w", err)
+	}
+
+	return c, nil
+}
+
+func (s *Store) getCohort(ctx context.Context, academicYear string) ([]user.User, error) {
+	var u []user.User
+
+	builder := utils.PSQL().Select("u.*").
+		From("people.users u").
+		Where(sq.Eq{"u.status": user.Member, "u.deleted_at": nil}).
+		Where("(SELECT MAX(m.academic_year) FROM people.memberships m WHERE m.user_id = u.user_id) = ?",
+			academicYear).
+		OrderBy("u.last_name", "u.first_name")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getCohort: %w", err))
+	}
+
+	//nolint:musttag
+	err = s.db.SelectContext(ctx, &u, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cohort: %w", err)
+	}
+
+	return u, nil
+}
+
+//gocyclo:ignore
+func (s *Store) changeStatus(ctx context.Context, c Change) (Change, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to begin change status transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var u user.User
+
+	// the user is locked so two changes at once can't both pass the transition check
+	builder := utils.PSQL().Select("*").
+		From("people.users").
+		Where(sq.Eq{"user_id": c.UserID}).
+		Suffix("FOR UPDATE")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for changeStatus: %w", err))
+	}
+
+	//nolint:musttag
+	err = tx.GetContext(ctx, &u, sql, args...)
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to get user for status change: %w", err)
+	}
+
+	if u.DeletedAt.Valid {
+		return Change{}, errors.New("failed to change status: the user has been deleted")
+	}
+
+	err = CanTransition(u.Status, c.ToStatus)
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to change status: %w", err)
+	}
+
+	c.FromStatus = u.Status
+	c.ChangedAt = time.Now()
+
+	update := utils.PSQL().Update("people.users").
+		SetMap(map[string]interface{}{
+			"status":     c.ToStatus,
+			"updated_at": c.ChangedAt,
+			"updated_by": c.ChangedBy,
+		}).
+		Where(sq.Eq{"user_id": c.UserID}).
+		Suffix("RETURNING *")
+
+	sql, args, err = update.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for changeStatus: %w", err))
+	}
+
+	//nolint:musttag
+	err = tx.GetContext(ctx, &u, sql, args...)
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to update user status: %w", err)
+	}
+
+	// roles shared by both statuses are left alone so their grant details are kept
+	revoke := utils.PSQL().Delete("people.role_members").
+		Where(sq.Eq{"user_id": c.UserID}).
+		Where("role_id IN (SELECT role_id FROM people.status_roles WHERE status = ?)", c.FromStatus).
+		Where("role_id NOT IN (SELECT role_id FROM people.status_roles WHERE status = ?)", c.ToStatus).
+		Suffix("RETURNING *")
+
+	sql, args, err = revoke.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for changeStatus: %w", err))
+	}
+
+	var revoked, granted []user.RoleUser
+
+	err = tx.SelectContext(ctx, &revoked, sql, args...)
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to remove roles of old status: %w", err)
+	}
+
+	grant := utils.PSQL().Insert("people.role_members").
+		Columns("role_id", "user_id", "granted_by", "reason").
+		Select(sq.Select("role_id").
+			Column("?", c.UserID).
+			Column("?::int", c.ChangedBy).
+			Column("?", c.ToStatus.Name()+" status").
+			From("people.status_roles").
+			Where(sq.Eq{"status": c.ToStatus})).
+		Suffix("ON CONFLICT DO NOTHING RETURNING *")
+
+	sql, args, err = grant.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for changeStatus: %w", err))
+	}
+
+	err = tx.SelectContext(ctx, &granted, sql, args...)
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to give roles of new status: %w", err)
+	}
+
+	insert := utils.PSQL().Insert("people.status_changes").
+		Columns("user_id", "from_status", "to_status", "reason", "changed_at", "changed_by").
+		Values(c.UserID, c.FromStatus, c.ToStatus, c.Reason, c.ChangedAt, c.ChangedBy).
+		Suffix("RETURNING change_id")
+
+	sql, args, err = insert.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for changeStatus: %w", err))
+	}
+
+	err = tx.QueryRowxContext(ctx, sql, args...).Scan(&c.ChangeID)
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to add status change: %w", err)
+	}
+
+	err = s.webhook.Emit(ctx, tx, webhook.UserUpdated, u)
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to emit user updated: %w", err)
+	}
+
+	for _, ru := range revoked {
+		err = s.webhook.Emit(ctx, tx, webhook.RoleUserRemoved, ru)
+		if err != nil {
+			return Change{}, fmt.Errorf("failed to emit role user removed: %w", err)
+		}
+	}
+
+	for _, ru := range granted {
+		err = s.webhook.Emit(ctx, tx, webhook.RoleUserAdded, ru)
+		if err != nil {
+			return Change{}, fmt.Errorf("failed to emit role user added: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Change{}, fmt.Errorf("failed to commit change status: %w", err)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,127 @@
+package userstatus
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/webhook"
+)
+
+//go:generate mockgen -destination mocks/mock_userstatus.go -package mock_userstatus github.com/ystv/web-auth/userstatus Repo
+
+type (
+	Repo interface {
+		GetStatusRoles(context.Context) ([]StatusRole, error)
+		AddStatusRole(context.Context, StatusRole) error
+		RemoveStatusRole(context.Context, StatusRole) error
+		GetChangesForUser(context.Context, user.User) ([]Change, error)
+		GetCohort(context.Context, string) ([]user.User, error)
+		ChangeStatus(context.Context, Change) (Change, error)
+	}
+
+	// Store stores the dependencies
+	Store struct {
+		db      *sqlx.DB
+		webhook webhook.Repo
+	}
+
+	// StatusRole is a role given to every user with the status
+	StatusRole struct {
+		Status   user.Status `db:"status" json:"status"`
+		RoleID   int         `db:"role_id" json:"roleID"`
+		RoleName string      `db:"role_name" json:"roleName"`
+	}
+
+	// Change is a user moving from one status to another, it is kept as the history
+	Change struct {
+		ChangeID   int         `db:"change_id" json:"changeID"`
+		UserID     int         `db:"user_id" json:"userID"`
+		FromStatus user.Status `db:"from_status" json:"fromStatus"`
+		ToStatus   user.Status `db:"to_status" json:"toStatus"`
+		Reason     string      `db:"reason" json:"reason"`
+		ChangedAt  time.Time   `db:"changed_at" json:"changedAt"`
+		ChangedBy  null.Int    `db:"changed_by" json:"changedBy"`
+		ByName     null.String `db:"by_name" json:"byName"`
+	}
+)
+
+// transitions are the statuses a user with each status can be moved to, honorary users go back to being alumni
+// rather than members as they aren't expected to pay again and suspended users can be reinstated as anything
+//
+//nolint:gochecknoglobals
+var transitions = map[user.Status][]user.Status{
+	user.Member:    {user.Alumni, user.Honorary, user.Suspended},
+	user.Alumni:    {user.Member, user.Honorary, user.Suspended},
+	user.Honorary:  {user.Alumni, user.Suspended},
+	user.Suspended: {user.Member, user.Alumni, user.Honorary},
+}
+
+var _ Repo = &Store{}
+
+// NewUserStatusRepo stores our dependency
+func NewUserStatusRepo(db *sqlx.DB, wh webhook.Repo) *Store {
+	return &Store{
+		db:      db,
+		webhook: wh,
+	}
+}
+
+// GetStatusRoles returns the roles given to each status
+func (s *Store) GetStatusRoles(ctx context.Context) ([]StatusRole, error) {
+	return s.getStatusRoles(ctx)
+}
+
+// AddStatusRole gives a role to a status, it is only given to users as they move to the status
+func (s *Store) AddStatusRole(ctx context.Context, sr StatusRole) error {
+	return s.addStatusRole(ctx, sr)
+}
+
+// RemoveStatusRole stops giving a role to a status, users that already have it keep it
+func (s *Store) RemoveStatusRole(ctx context.Context, sr StatusRole) error {
+	return s.removeStatusRole(ctx, sr)
+}
+
+// GetChangesForUser returns every change of a user's status, newest first
+func (s *Store) GetChangesForUser(ctx context.Context, u user.User) ([]Change, error) {
+	return s.getChangesForUser(ctx, u)
+}
+
+// GetCohort returns the members whose last membership was in the academic year, the ones who are likely to have
+// graduated once it is over
+func (s *Store) GetCohort(ctx context.Context, academicYear string) ([]user.User, error) {
+	return s.getCohort(ctx, academicYear)
+}
+
+// ChangeStatus moves a user to ToStatus, the roles of their old status that the new one doesn't have are removed
+// and the roles of the new one are given
+func (s *Store) ChangeStatus(ctx context.Context, c Change) (Change, error) {
+	return s.changeStatus(ctx, c)
+}
+
+// Transitions returns the statuses a user with the status can be moved to
+func Transitions(from user.Status) []user.Status {
+	return transitions[from]
+}
+
+// CanTransition returns why a user can't be moved between the statuses, nil if they can
+func CanTransition(from, to user.Status) error {
+	if !slices.Contains(user.Statuses, to) {
+		return fmt.Errorf("\"%s\" isn't a status", to)
+	}
+
+	if from == to {
+		return fmt.Errorf("the user is already %s", to.Name())
+	}
+
+	if !slices.Contains(transitions[from], to) {
+		return fmt.Errorf("%s users can't be made %s", from.Name(), to.Name())
+	}
+
+	return nil
+}
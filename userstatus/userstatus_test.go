@@ -0,0 +1,47 @@
+package userstatus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ystv/web-auth/user"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    user.Status
+		to      user.Status
+		wantErr string
+	}{
+		{name: "Graduate", from: user.Member, to: user.Alumni},
+		{name: "ReturningAlumni", from: user.Alumni, to: user.Member},
+		{name: "Reinstate", from: user.Suspended, to: user.Honorary},
+		{name: "HonoraryToMember", from: user.Honorary, to: user.Member,
+			wantErr: "Honorary users can't be made Member"},
+		{name: "Same", from: user.Alumni, to: user.Alumni, wantErr: "the user is already Alumni"},
+		{name: "Unknown", from: user.Member, to: "retired", wantErr: "\"retired\" isn't a status"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CanTransition(tt.from, tt.to)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTransitionsAreValid(t *testing.T) {
+	for _, from := range user.Statuses {
+		assert.NotEmpty(t, Transitions(from), "status %s", from)
+
+		for _, to := range Transitions(from) {
+			assert.NoError(t, CanTransition(from, to))
+		}
+	}
+}
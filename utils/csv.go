@@ -0,0 +1,13 @@
+package utils
+
+import "strings"
+
+// CSVCell stops a spreadsheet opening an export from running a value as a formula, a value starting with =, +, -,
+// @, a tab or a carriage return has a ' put in front of it
+func CSVCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+
+	return s
+}
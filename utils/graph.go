@@ -0,0 +1,64 @@
+package utils
+
+// FindCycle returns the nodes making up a cycle in a directed graph given as [from, to] edges, starting and ending
+// with the same node, or nil when there isn't one
+func FindCycle(edges [][2]int) []int {
+	next := make(map[int][]int)
+	order := make([]int, 0)
+
+	for _, e := range edges {
+		if _, ok := next[e[0]]; !ok {
+			order = append(order, e[0])
+		}
+
+		next[e[0]] = append(next[e[0]], e[1])
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[int]int)
+	path := make([]int, 0)
+
+	var visit func(node int) []int
+
+	visit = func(node int) []int {
+		switch state[node] {
+		case visited:
+			return nil
+		case visiting:
+			for i, p := range path {
+				if p == node {
+					return append(append([]int{}, path[i:]...), node)
+				}
+			}
+		}
+
+		state[node] = visiting
+		path = append(path, node)
+
+		for _, n := range next[node] {
+			if cycle := visit(n); cycle != nil {
+				return cycle
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[node] = visited
+
+		return nil
+	}
+
+	for _, node := range order {
+		if state[node] == unvisited {
+			if cycle := visit(node); cycle != nil {
+				return cycle
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindCycle(t *testing.T) {
+	t.Run("None", func(t *testing.T) {
+		// a diamond reaches the same node twice without being a cycle
+		assert.Nil(t, FindCycle([][2]int{{1, 2}, {2, 3}, {1, 3}, {4, 3}}))
+		assert.Nil(t, FindCycle(nil))
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		assert.Equal(t, []int{1, 2, 3, 1}, FindCycle([][2]int{{1, 2}, {2, 3}, {1, 3}, {4, 3}, {3, 1}}))
+	})
+
+	t.Run("SelfLoop", func(t *testing.T) {
+		assert.Equal(t, []int{5, 5}, FindCycle([][2]int{{1, 2}, {5, 5}}))
+	})
+
+	t.Run("Disconnected", func(t *testing.T) {
+		// the cycle is only reachable from a node visited after the acyclic part
+		assert.Equal(t, []int{7, 8, 7}, FindCycle([][2]int{{1, 2}, {2, 3}, {6, 7}, {7, 8}, {8, 7}}))
+	})
+}
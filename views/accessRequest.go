@@ -0,0 +1,405 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/accessrequest"
+	"github.com/ystv/web-auth/emailtemplate"
+	infraPermission "github.com/ystv/web-auth/infrastructure/permission"
+	"github.com/ystv/web-auth/permission"
+	"github.com/ystv/web-auth/permission/permissions"
+	"github.com/ystv/web-auth/role"
+	"github.com/ystv/web-auth/templates"
+	"github.com/ystv/web-auth/user"
+)
+
+type (
+	// AccessRequestTemplate represents the page for requesting a role
+	AccessRequestTemplate struct {
+		Roles     []role.Role
+		Current   map[int]bool
+		Pending   map[int]bool
+		Requests  []accessrequest.Request
+		CanReview bool
+		TemplateHelper
+	}
+
+	// AccessRequestsTemplate represents the queue of requests a user can decide
+	AccessRequestsTemplate struct {
+		Pending []accessrequest.Request
+		Decided []accessrequest.Request
+		TemplateHelper
+	}
+)
+
+// accessRequestHistory is how many decided requests are shown under the queue
+const accessRequestHistory = 50
+
+// AccessRequestFunc shows the roles a user can request and their previous requests, a POST makes a new request
+func (v *Views) AccessRequestFunc(c echo.Context) error {
+	c1 := v.getSessionData(c)
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return fmt.Errorf("failed to get user permissions for accessRequest: %w", err)
+	}
+
+	if c.Request().Method == http.MethodPost {
+		roleID, err := strconv.Atoi(c.Request().FormValue("roleID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get roleID for accessRequest: %w", err))
+		}
+
+		r, err := v.role.GetRole(c.Request().Context(), role.Role{RoleID: roleID})
+		if err != nil {
+			return fmt.Errorf("failed to get role for accessRequest: %w", err)
+		}
+
+		if !r.Requestable {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("this role can't be requested"))
+		}
+
+		_, err = v.user.GetRoleUser(c.Request().Context(), user.RoleUser{RoleID: roleID, UserID: c1.User.UserID})
+		if err == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("you are already a member of this role"))
+		}
+
+		request, err := v.accessRequest.AddRequest(c.Request().Context(), accessrequest.Request{
+			RoleID: roleID,
+			UserID: c1.User.UserID,
+			Reason: c.Request().FormValue("reason"),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add request for accessRequest: %w", err)
+		}
+
+		err = v.sendAccessRequestEmails(c.Request().Context(), r, request)
+		if err != nil {
+			log.Printf("failed to send access request emails for request id %d: %+v", request.RequestID, err)
+		}
+
+		return c.Redirect(http.StatusFound, "/internal/access")
+	}
+
+	roles, err := v.role.GetRoles(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get roles for accessRequest: %w", err)
+	}
+
+	data := AccessRequestTemplate{
+		Current: make(map[int]bool),
+		Pending: make(map[int]bool),
+	}
+
+	for _, r := range roles {
+		if r.Requestable {
+			data.Roles = append(data.Roles, r)
+		}
+
+		if canApproveRole(p1, r) {
+			data.CanReview = true
+		}
+	}
+
+	current, err := v.user.GetRolesForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return fmt.Errorf("failed to get roles for user for accessRequest: %w", err)
+	}
+
+	for _, r := range current {
+		data.Current[r.RoleID] = true
+	}
+
+	data.Requests, err = v.accessRequest.GetRequestsForUser(c.Request().Context(), c1.User.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get requests for accessRequest: %w", err)
+	}
+
+	for _, r := range data.Requests {
+		if r.Status == accessrequest.Pending {
+			data.Pending[r.RoleID] = true
+		}
+	}
+
+	data.TemplateHelper = TemplateHelper{
+		UserPermissions: p1,
+		ActivePage:      "access",
+		Assumed:         c1.Assumed,
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.AccessRequestTemplate, templates.RegularType)
+}
+
+// AccessRequestCancelFunc lets a user withdraw their own pending request
+func (v *Views) AccessRequestCancelFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		requestID, err := strconv.Atoi(c.Param("requestid"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to get requestid for accessRequestCancel: %w", err))
+		}
+
+		request, err := v.accessRequest.GetRequest(c.Request().Context(), accessrequest.Request{RequestID: requestID})
+		if err != nil {
+			return fmt.Errorf("failed to get request for accessRequestCancel: %w", err)
+		}
+
+		if request.UserID != c1.User.UserID {
+			return echo.NewHTTPError(http.StatusForbidden, errors.New("you can only cancel your own requests"))
+		}
+
+		request.Status = accessrequest.Cancelled
+		request.DecidedBy = null.IntFrom(int64(c1.User.UserID))
+
+		_, err = v.accessRequest.DecideRequest(c.Request().Context(), request)
+		if err != nil {
+			return fmt.Errorf("failed to cancel request for accessRequestCancel: %w", err)
+		}
+
+		return c.Redirect(http.StatusFound, "/internal/access")
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// AccessRequestsFunc shows the pending requests for the roles the user can approve and the recent decisions
+func (v *Views) AccessRequestsFunc(c echo.Context) error {
+	if c.Request().Method != http.MethodGet {
+		return v.invalidMethodUsed(c)
+	}
+
+	c1 := v.getSessionData(c)
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return fmt.Errorf("failed to get user permissions for accessRequests: %w", err)
+	}
+
+	roleIDs, err := v.approvableRoleIDs(c.Request().Context(), p1)
+	if err != nil {
+		return fmt.Errorf("failed to get approvable roles for accessRequests: %w", err)
+	}
+
+	if roleIDs != nil && len(roleIDs) == 0 {
+		return echo.NewHTTPError(http.StatusForbidden, errors.New("you are not authorised for accessing this"))
+	}
+
+	data := AccessRequestsTemplate{}
+
+	data.Pending, err = v.accessRequest.GetPendingRequests(c.Request().Context(), roleIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get pending requests for accessRequests: %w", err)
+	}
+
+	data.Decided, err = v.accessRequest.GetDecidedRequests(c.Request().Context(), roleIDs, accessRequestHistory)
+	if err != nil {
+		return fmt.Errorf("failed to get decided requests for accessRequests: %w", err)
+	}
+
+	data.TemplateHelper = TemplateHelper{
+		UserPermissions: p1,
+		ActivePage:      "accessRequests",
+		Assumed:         c1.Assumed,
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.AccessRequestsTemplate, templates.RegularType)
+}
+
+// AccessRequestApproveFunc approves a request and adds the user to the role, the approver can make the membership
+// temporary with an end date
+func (v *Views) AccessRequestApproveFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		return v.decideAccessRequest(c, accessrequest.Approved)
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// AccessRequestDenyFunc denies a request
+func (v *Views) AccessRequestDenyFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		return v.decideAccessRequest(c, accessrequest.Denied)
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// decideAccessRequest records the decision on a request, emails the requester and on approval adds them to the role
+func (v *Views) decideAccessRequest(c echo.Context, status accessrequest.Status) error {
+	c1 := v.getSessionData(c)
+
+	requestID, err := strconv.Atoi(c.Param("requestid"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Errorf("failed to get requestid for decideAccessRequest: %w", err))
+	}
+
+	request, err := v.accessRequest.GetRequest(c.Request().Context(), accessrequest.Request{RequestID: requestID})
+	if err != nil {
+		return fmt.Errorf("failed to get request for decideAccessRequest: %w", err)
+	}
+
+	r, err := v.role.GetRole(c.Request().Context(), role.Role{RoleID: request.RoleID})
+	if err != nil {
+		return fmt.Errorf("failed to get role for decideAccessRequest: %w", err)
+	}
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return fmt.Errorf("failed to get user permissions for decideAccessRequest: %w", err)
+	}
+
+	if !canApproveRole(p1, r) {
+		return echo.NewHTTPError(http.StatusForbidden, errors.New("you are not authorised for accessing this"))
+	}
+
+	if request.UserID == c1.User.UserID {
+		return echo.NewHTTPError(http.StatusForbidden, errors.New("you can't decide your own request"))
+	}
+
+	request.Status = status
+	request.DecidedBy = null.IntFrom(int64(c1.User.UserID))
+	request.Comment = c.Request().FormValue("comment")
+
+	if status == accessrequest.Approved {
+		_, request.EndsAt, err = parseRoleUserWindow("", c.Request().FormValue("endsAt"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to parse end date for decideAccessRequest: %w", err))
+		}
+	}
+
+	request, err = v.accessRequest.DecideRequest(c.Request().Context(), request)
+	if err != nil {
+		return fmt.Errorf("failed to decide request for decideAccessRequest: %w", err)
+	}
+
+	err = v.sendAccessDecisionEmail(c.Request().Context(), request)
+	if err != nil {
+		log.Printf("failed to send access decision email for request id %d: %+v", request.RequestID, err)
+	}
+
+	return c.Redirect(http.StatusFound, "/internal/access/requests")
+}
+
+// canApproveRole returns if the permissions are enough to decide requests for a role, ManageMembers.Groups can
+// decide any role otherwise the role's approver permission is needed
+func canApproveRole(perms []permission.Permission, r role.Role) bool {
+	if infraPermission.HasPermission(perms, permissions.ManageMembersGroup) {
+		return true
+	}
+
+	if !r.ApproverPermissionID.Valid {
+		return false
+	}
+
+	for _, p := range perms {
+		if int64(p.PermissionID) == r.ApproverPermissionID.Int64 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// approvableRoleIDs returns the ids of the roles the permissions can approve, nil is every role
+func (v *Views) approvableRoleIDs(ctx context.Context, perms []permission.Permission) ([]int, error) {
+	if infraPermission.HasPermission(perms, permissions.ManageMembersGroup) {
+		return nil, nil
+	}
+
+	roles, err := v.role.GetRoles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+
+	roleIDs := make([]int, 0)
+
+	for _, r := range roles {
+		if canApproveRole(perms, r) {
+			roleIDs = append(roleIDs, r.RoleID)
+		}
+	}
+
+	return roleIDs, nil
+}
+
+// sendAccessRequestEmails lets the approvers of a role know about a new request, when the role has no approver
+// permission the holders of ManageMembers.Groups are emailed
+func (v *Views) sendAccessRequestEmails(ctx context.Context, r role.Role, request accessrequest.Request) error {
+	approver := permission.Permission{Name: permissions.ManageMembersGroup.String()}
+	if r.ApproverPermissionID.Valid {
+		approver = permission.Permission{PermissionID: int(r.ApproverPermissionID.Int64)}
+	}
+
+	approver, err := v.permission.GetPermission(ctx, approver)
+	if err != nil {
+		return fmt.Errorf("failed to get approver permission: %w", err)
+	}
+
+	approvers, err := v.user.GetUsersWithPermission(ctx, approver)
+	if err != nil {
+		return fmt.Errorf("failed to get approvers: %w", err)
+	}
+
+	if len(approvers) == 0 {
+		return nil
+	}
+
+	for _, u := range approvers {
+		if u.UserID == request.UserID {
+			continue
+		}
+
+		err := v.queueMail(ctx, emailtemplate.AccessRequest, u.Language.String, u.Email,
+			emailtemplate.AccessRequestData{
+				Name:      u.Firstname,
+				Requester: request.UserName,
+				Role:      request.RoleName,
+				Reason:    request.Reason,
+				URL:       fmt.Sprintf("https://%s/internal/access/requests", v.conf.DomainName),
+			})
+		if err != nil {
+			log.Printf("failed to queue access request email to user id %d: %+v", u.UserID, err)
+		}
+	}
+
+	return nil
+}
+
+// sendAccessDecisionEmail lets the requester know the outcome of their request
+func (v *Views) sendAccessDecisionEmail(ctx context.Context, request accessrequest.Request) error {
+	u, err := v.user.GetUser(ctx, user.User{UserID: request.UserID})
+	if err != nil {
+		return fmt.Errorf("failed to get requester: %w", err)
+	}
+
+	var endsAt string
+	if request.EndsAt.Valid {
+		endsAt = request.EndsAt.Time.Format("02/01/2006 15:04")
+	}
+
+	err = v.queueMail(ctx, emailtemplate.AccessDecision, u.Language.String, u.Email,
+		emailtemplate.AccessDecisionData{
+			Name:    u.Firstname,
+			Role:    request.RoleName,
+			Status:  string(request.Status),
+			Comment: request.Comment,
+			EndsAt:  endsAt,
+		})
+	if err != nil {
+		return fmt.Errorf("failed to queue access decision email: %w", err)
+	}
+
+	return nil
+}
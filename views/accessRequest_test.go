@@ -0,0 +1,251 @@
+package views
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/accessrequest"
+	mockaccessrequest "github.com/ystv/web-auth/accessrequest/mocks"
+	"github.com/ystv/web-auth/emailtemplate"
+	mockemailtemplate "github.com/ystv/web-auth/emailtemplate/mocks"
+	"github.com/ystv/web-auth/infrastructure/mail"
+	"github.com/ystv/web-auth/mailqueue"
+	mockmailqueue "github.com/ystv/web-auth/mailqueue/mocks"
+	"github.com/ystv/web-auth/permission"
+	mockpermission "github.com/ystv/web-auth/permission/mocks"
+	"github.com/ystv/web-auth/permission/permissions"
+	"github.com/ystv/web-auth/role"
+	mockrole "github.com/ystv/web-auth/role/mocks"
+	"github.com/ystv/web-auth/user"
+	mockuser "github.com/ystv/web-auth/user/mocks"
+)
+
+func TestDecideAccessRequest(t *testing.T) {
+	approver := user.User{UserID: 2}
+	requester := user.User{UserID: 1, Firstname: "Jane", Email: "jane.doe@ystv.co.uk"}
+
+	pending := accessrequest.Request{RequestID: 5, RoleID: 3, UserID: 1, Reason: "Editing", Status: accessrequest.Pending}
+	r := role.Role{RoleID: 3, Name: "Editor", ApproverPermissionID: null.IntFrom(7)}
+	perms := []permission.Permission{{PermissionID: 7}}
+
+	setup := func(t *testing.T) (*Views, *mockaccessrequest.MockRepo, *mockuser.MockRepo) {
+		ctr := gomock.NewController(t)
+		mockAccessRequest := mockaccessrequest.NewMockRepo(ctr)
+		mockRole := mockrole.NewMockRepo(ctr)
+		mockUser := mockuser.NewMockRepo(ctr)
+		mockEmailTemplate := mockemailtemplate.NewMockRepo(ctr)
+		mockMailQueue := mockmailqueue.NewMockRepo(ctr)
+
+		mockAccessRequest.EXPECT().GetRequest(gomock.Any(), accessrequest.Request{RequestID: 5}).
+			Return(pending, nil)
+		mockRole.EXPECT().GetRole(gomock.Any(), role.Role{RoleID: 3}).Return(r, nil)
+
+		mockUser.EXPECT().GetUser(gomock.Any(), user.User{UserID: 1}).Return(requester, nil).AnyTimes()
+		mockEmailTemplate.EXPECT().Mail(gomock.Any(), emailtemplate.AccessDecision, gomock.Any(), requester.Email,
+			gomock.Any()).Return(mail.Mail{}, nil).AnyTimes()
+		mockMailQueue.EXPECT().Queue(gomock.Any(), gomock.Any()).Return(mailqueue.Message{}, nil).AnyTimes()
+
+		v := newTestViews()
+		v.accessRequest = mockAccessRequest
+		v.role = mockRole
+		v.user = mockUser
+		v.emailTemplate = mockEmailTemplate
+		v.mailQueue = mockMailQueue
+
+		return v, mockAccessRequest, mockUser
+	}
+
+	t.Run("Approve", func(t *testing.T) {
+		v, mockAccessRequest, mockUser := setup(t)
+
+		endsAt := time.Now().AddDate(0, 1, 0)
+
+		mockUser.EXPECT().GetPermissionsForUser(gomock.Any(), gomock.Any()).Return(perms, nil)
+		// the store grants the role in the decision's transaction, the view mustn't add the membership itself
+		mockAccessRequest.EXPECT().DecideRequest(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, request accessrequest.Request) (accessrequest.Request, error) {
+				assert.Equal(t, accessrequest.Approved, request.Status)
+				assert.Equal(t, null.IntFrom(2), request.DecidedBy)
+				assert.Equal(t, "Go ahead", request.Comment)
+				require.True(t, request.EndsAt.Valid)
+				assert.Equal(t, endsAt.AddDate(0, 0, 1).Format(time.DateOnly), request.EndsAt.Time.Format(time.DateOnly))
+
+				return request, nil
+			})
+
+		c, rec := newTestContext(t, v, approver, url.Values{
+			"comment": {"Go ahead"},
+			"endsAt":  {endsAt.Format("02/01/2006")},
+		}, "requestid", "5")
+
+		err := v.decideAccessRequest(c, accessrequest.Approved)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+	})
+
+	t.Run("Deny", func(t *testing.T) {
+		v, mockAccessRequest, mockUser := setup(t)
+
+		mockUser.EXPECT().GetPermissionsForUser(gomock.Any(), gomock.Any()).Return(perms, nil)
+		mockAccessRequest.EXPECT().DecideRequest(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, request accessrequest.Request) (accessrequest.Request, error) {
+				assert.Equal(t, accessrequest.Denied, request.Status)
+				assert.False(t, request.EndsAt.Valid)
+
+				return request, nil
+			})
+
+		c, _ := newTestContext(t, v, approver, url.Values{"endsAt": {"01/01/2000"}}, "requestid", "5")
+
+		err := v.decideAccessRequest(c, accessrequest.Denied)
+		require.NoError(t, err)
+	})
+
+	t.Run("DecisionFails", func(t *testing.T) {
+		v, mockAccessRequest, mockUser := setup(t)
+
+		mockUser.EXPECT().GetPermissionsForUser(gomock.Any(), gomock.Any()).Return(perms, nil)
+		mockAccessRequest.EXPECT().DecideRequest(gomock.Any(), gomock.Any()).
+			Return(accessrequest.Request{}, errors.New("request 5 is not pending"))
+
+		c, _ := newTestContext(t, v, approver, url.Values{}, "requestid", "5")
+
+		err := v.decideAccessRequest(c, accessrequest.Approved)
+		assert.Error(t, err)
+	})
+
+	t.Run("NotApprover", func(t *testing.T) {
+		v, _, mockUser := setup(t)
+
+		mockUser.EXPECT().GetPermissionsForUser(gomock.Any(), gomock.Any()).Return(nil, nil)
+
+		c, _ := newTestContext(t, v, approver, url.Values{}, "requestid", "5")
+
+		err := v.decideAccessRequest(c, accessrequest.Approved)
+
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusForbidden, httpErr.Code)
+	})
+
+	t.Run("OwnRequest", func(t *testing.T) {
+		v, _, mockUser := setup(t)
+
+		mockUser.EXPECT().GetPermissionsForUser(gomock.Any(), gomock.Any()).
+			Return([]permission.Permission{{Name: permissions.ManageMembersGroup.String()}}, nil)
+
+		c, _ := newTestContext(t, v, requester, url.Values{}, "requestid", "5")
+
+		err := v.decideAccessRequest(c, accessrequest.Approved)
+
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusForbidden, httpErr.Code)
+	})
+}
+
+func TestRequestAccess(t *testing.T) {
+	requester := user.User{UserID: 1}
+	approver := user.User{UserID: 2, Firstname: "John", Email: "john.doe@ystv.co.uk"}
+
+	setup := func(t *testing.T, r role.Role) (*Views, *mockaccessrequest.MockRepo, *mockuser.MockRepo) {
+		ctr := gomock.NewController(t)
+		mockAccessRequest := mockaccessrequest.NewMockRepo(ctr)
+		mockRole := mockrole.NewMockRepo(ctr)
+		mockUser := mockuser.NewMockRepo(ctr)
+
+		mockUser.EXPECT().GetPermissionsForUser(gomock.Any(), gomock.Any()).Return(nil, nil)
+		mockRole.EXPECT().GetRole(gomock.Any(), role.Role{RoleID: 3}).Return(r, nil)
+
+		v := newTestViews()
+		v.accessRequest = mockAccessRequest
+		v.role = mockRole
+		v.user = mockUser
+
+		return v, mockAccessRequest, mockUser
+	}
+
+	t.Run("Request", func(t *testing.T) {
+		v, mockAccessRequest, mockUser := setup(t, role.Role{RoleID: 3, Requestable: true,
+			ApproverPermissionID: null.IntFrom(7)})
+
+		ctr := gomock.NewController(t)
+		mockPermission := mockpermission.NewMockRepo(ctr)
+		mockEmailTemplate := mockemailtemplate.NewMockRepo(ctr)
+		mockMailQueue := mockmailqueue.NewMockRepo(ctr)
+
+		v.permission = mockPermission
+		v.emailTemplate = mockEmailTemplate
+		v.mailQueue = mockMailQueue
+		v.conf.DomainName = "auth.ystv.co.uk"
+
+		approverPermission := permission.Permission{PermissionID: 7, Name: "Editor.Approve"}
+
+		mockUser.EXPECT().GetRoleUser(gomock.Any(), user.RoleUser{RoleID: 3, UserID: 1}).
+			Return(user.RoleUser{}, sql.ErrNoRows)
+		mockAccessRequest.EXPECT().AddRequest(gomock.Any(),
+			accessrequest.Request{RoleID: 3, UserID: 1, Reason: "Editing"}).
+			Return(accessrequest.Request{RequestID: 5, RoleID: 3, UserID: 1, Reason: "Editing", UserName: "Jane Doe",
+				RoleName: "Editor", Status: accessrequest.Pending}, nil)
+		mockPermission.EXPECT().GetPermission(gomock.Any(), permission.Permission{PermissionID: 7}).
+			Return(approverPermission, nil)
+		// the requester can hold the approver permission, they aren't emailed about their own request
+		mockUser.EXPECT().GetUsersWithPermission(gomock.Any(), approverPermission).
+			Return([]user.User{requester, approver}, nil)
+		mockEmailTemplate.EXPECT().Mail(gomock.Any(), emailtemplate.AccessRequest, "", approver.Email,
+			emailtemplate.AccessRequestData{
+				Name:      "John",
+				Requester: "Jane Doe",
+				Role:      "Editor",
+				Reason:    "Editing",
+				URL:       "https://auth.ystv.co.uk/internal/access/requests",
+			}).Return(mail.Mail{}, nil)
+		mockMailQueue.EXPECT().Queue(gomock.Any(), gomock.Any()).Return(mailqueue.Message{}, nil)
+
+		c, rec := newTestContext(t, v, requester, url.Values{"roleID": {"3"}, "reason": {"Editing"}})
+
+		err := v.AccessRequestFunc(c)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+	})
+
+	t.Run("NotRequestable", func(t *testing.T) {
+		v, _, _ := setup(t, role.Role{RoleID: 3})
+
+		c, _ := newTestContext(t, v, requester, url.Values{"roleID": {"3"}})
+
+		err := v.AccessRequestFunc(c)
+
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	})
+
+	t.Run("AlreadyMember", func(t *testing.T) {
+		v, _, mockUser := setup(t, role.Role{RoleID: 3, Requestable: true})
+
+		mockUser.EXPECT().GetRoleUser(gomock.Any(), user.RoleUser{RoleID: 3, UserID: 1}).
+			Return(user.RoleUser{RoleID: 3, UserID: 1}, nil)
+
+		c, _ := newTestContext(t, v, requester, url.Values{"roleID": {"3"}})
+
+		err := v.AccessRequestFunc(c)
+
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	})
+}
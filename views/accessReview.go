@@ -0,0 +1,336 @@
+package views
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/accessreview"
+	"github.com/ystv/web-auth/role"
+	"github.com/ystv/web-auth/templates"
+	"github.com/ystv/web-auth/utils"
+)
+
+type (
+	// AccessReviewsTemplate represents the list of access reviews and starting a new one
+	AccessReviewsTemplate struct {
+		Reviews []accessreview.Review
+		Roles   []role.Role
+		TemplateHelper
+	}
+
+	// AccessReviewTemplate represents the report of an access review
+	AccessReviewTemplate struct {
+		Review accessreview.Review
+		Items  []accessreview.Item
+		TemplateHelper
+	}
+
+	// AccessReviewTasksTemplate represents the memberships a reviewer has to keep or revoke
+	AccessReviewTasksTemplate struct {
+		Items []accessreview.Item
+		TemplateHelper
+	}
+
+	// AccessReviewExport is the JSON export of an access review
+	AccessReviewExport struct {
+		Review accessreview.Review `json:"review"`
+		Items  []accessreview.Item `json:"items"`
+	}
+)
+
+// AccessReviewsFunc lists the access reviews
+func (v *Views) AccessReviewsFunc(c echo.Context) error {
+	if c.Request().Method != http.MethodGet {
+		return v.invalidMethodUsed(c)
+	}
+
+	c1 := v.getSessionData(c)
+
+	reviews, err := v.accessReview.GetReviews(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get reviews for accessReviews: %w", err)
+	}
+
+	roles, err := v.role.GetRoles(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get roles for accessReviews: %w", err)
+	}
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return fmt.Errorf("failed to get user permissions for accessReviews: %w", err)
+	}
+
+	data := AccessReviewsTemplate{
+		Reviews: reviews,
+		Roles:   roles,
+		TemplateHelper: TemplateHelper{
+			UserPermissions: p1,
+			ActivePage:      "accessReviews",
+			Assumed:         c1.Assumed,
+		},
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.AccessReviewsTemplate, templates.RegularType)
+}
+
+// AccessReviewAddFunc starts an access review of the current members of the picked roles, the reviewers are
+// emailed straight away
+func (v *Views) AccessReviewAddFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		err := c.Request().ParseForm()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to parse form for accessReviewAdd: %w", err))
+		}
+
+		name := c.Request().FormValue("name")
+		if name == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("name must be set"))
+		}
+
+		deadline, err := time.Parse("02/01/2006", c.Request().FormValue("deadline"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to parse deadline for accessReviewAdd: %w", err))
+		}
+
+		// the deadline is the end of the picked day
+		deadline = deadline.AddDate(0, 0, 1)
+
+		if !deadline.After(time.Now()) {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("deadline can't be in the past"))
+		}
+
+		roleIDs := make([]int, 0, len(c.Request().Form["roleIDs"]))
+
+		for _, r := range c.Request().Form["roleIDs"] {
+			roleID, err := strconv.Atoi(r)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest,
+					fmt.Errorf("failed to parse roleID for accessReviewAdd: %w", err))
+			}
+
+			roleIDs = append(roleIDs, roleID)
+		}
+
+		if len(roleIDs) == 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("at least one role must be picked"))
+		}
+
+		review, err := v.accessReview.AddReview(c.Request().Context(), accessreview.Review{
+			Name:       name,
+			Deadline:   deadline,
+			AutoRevoke: c.Request().FormValue("autoRevoke") == "on",
+			CreatedBy:  null.IntFrom(int64(v.getSessionData(c).User.UserID)),
+		}, roleIDs)
+		if err != nil {
+			return fmt.Errorf("failed to add review for accessReviewAdd: %w", err)
+		}
+
+		err = v.remindAccessReview(c.Request().Context(), review)
+		if err != nil {
+			log.Printf("failed to email reviewers of access review id %d: %+v", review.ReviewID, err)
+		}
+
+		return c.Redirect(http.StatusFound, fmt.Sprintf("/internal/review/%d", review.ReviewID))
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// AccessReviewFunc shows the report of an access review, ?format=csv or ?format=json exports it instead
+func (v *Views) AccessReviewFunc(c echo.Context) error {
+	if c.Request().Method != http.MethodGet {
+		return v.invalidMethodUsed(c)
+	}
+
+	c1 := v.getSessionData(c)
+
+	reviewID, err := strconv.Atoi(c.Param("reviewid"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get reviewid for accessReview: %w", err))
+	}
+
+	review, err := v.accessReview.GetReview(c.Request().Context(), accessreview.Review{ReviewID: reviewID})
+	if err != nil {
+		return fmt.Errorf("failed to get review for accessReview: %w", err)
+	}
+
+	items, err := v.accessReview.GetItems(c.Request().Context(), review)
+	if err != nil {
+		return fmt.Errorf("failed to get items for accessReview: %w", err)
+	}
+
+	switch c.QueryParam("format") {
+	case "":
+	case "json":
+		return c.JSON(http.StatusOK, AccessReviewExport{
+			Review: review,
+			Items:  items,
+		})
+	case "csv":
+		return accessReviewCSV(c, review, items)
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, errors.New("format must be set to either \"csv\" or \"json\""))
+	}
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return fmt.Errorf("failed to get user permissions for accessReview: %w", err)
+	}
+
+	data := AccessReviewTemplate{
+		Review: review,
+		Items:  items,
+		TemplateHelper: TemplateHelper{
+			UserPermissions: p1,
+			ActivePage:      "accessReview",
+			Assumed:         c1.Assumed,
+		},
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.AccessReviewTemplate, templates.RegularType)
+}
+
+// AccessReviewTasksFunc shows the memberships of the open reviews waiting for the user to keep or revoke, a user
+// reviews the roles they can approve access requests for
+func (v *Views) AccessReviewTasksFunc(c echo.Context) error {
+	if c.Request().Method != http.MethodGet {
+		return v.invalidMethodUsed(c)
+	}
+
+	c1 := v.getSessionData(c)
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return fmt.Errorf("failed to get user permissions for accessReviewTasks: %w", err)
+	}
+
+	roleIDs, err := v.approvableRoleIDs(c.Request().Context(), p1)
+	if err != nil {
+		return fmt.Errorf("failed to get approvable roles for accessReviewTasks: %w", err)
+	}
+
+	if roleIDs != nil && len(roleIDs) == 0 {
+		return echo.NewHTTPError(http.StatusForbidden, errors.New("you are not authorised for accessing this"))
+	}
+
+	items, err := v.accessReview.GetPendingItems(c.Request().Context(), roleIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get items for accessReviewTasks: %w", err)
+	}
+
+	data := AccessReviewTasksTemplate{
+		Items: items,
+		TemplateHelper: TemplateHelper{
+			UserPermissions: p1,
+			ActivePage:      "accessReviewTasks",
+			Assumed:         c1.Assumed,
+		},
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.AccessReviewTasksTemplate, templates.RegularType)
+}
+
+// AccessReviewDecideFunc keeps or revokes a membership in a review, revoking removes the user from the role
+// straight away
+func (v *Views) AccessReviewDecideFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		itemID, err := strconv.Atoi(c.Param("itemid"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get itemid for accessReviewDecide: %w", err))
+		}
+
+		item, err := v.accessReview.GetItem(c.Request().Context(), accessreview.Item{ItemID: itemID})
+		if err != nil {
+			return fmt.Errorf("failed to get item for accessReviewDecide: %w", err)
+		}
+
+		if item.ReviewCompletedAt.Valid {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("the review has been completed"))
+		}
+
+		if item.Decision != accessreview.Pending {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("the membership has already been reviewed"))
+		}
+
+		r, err := v.role.GetRole(c.Request().Context(), role.Role{RoleID: item.RoleID})
+		if err != nil {
+			return fmt.Errorf("failed to get role for accessReviewDecide: %w", err)
+		}
+
+		p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+		if err != nil {
+			return fmt.Errorf("failed to get user permissions for accessReviewDecide: %w", err)
+		}
+
+		if !canApproveRole(p1, r) {
+			return echo.NewHTTPError(http.StatusForbidden, errors.New("you are not authorised for accessing this"))
+		}
+
+		if item.UserID == c1.User.UserID {
+			return echo.NewHTTPError(http.StatusForbidden, errors.New("you can't review your own access"))
+		}
+
+		item.Decision = accessreview.Decision(c.Request().FormValue("decision"))
+		if item.Decision != accessreview.Keep && item.Decision != accessreview.Revoke {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("decision must be set to either \"keep\" or \"revoke\""))
+		}
+
+		item.DecidedBy = null.IntFrom(int64(c1.User.UserID))
+		item.Comment = c.Request().FormValue("comment")
+
+		err = v.decideAccessReviewItem(c.Request().Context(), item)
+		if err != nil {
+			return fmt.Errorf("failed to decide item for accessReviewDecide: %w", err)
+		}
+
+		return c.Redirect(http.StatusFound, "/internal/review/tasks")
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+func accessReviewCSV(c echo.Context, review accessreview.Review, items []accessreview.Item) error {
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=\"access-review-%d.csv\"", review.ReviewID))
+	c.Response().WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(c.Response())
+
+	err := w.Write([]string{"item_id", "role_id", "role", "user_id", "user", "decision", "decided_by",
+		"decided_at", "auto_revoked", "comment"})
+	if err != nil {
+		return fmt.Errorf("failed to write access review csv: %w", err)
+	}
+
+	for _, i := range items {
+		var decidedAt string
+
+		if i.DecidedAt.Valid {
+			decidedAt = i.DecidedAt.Time.Format(time.RFC3339)
+		}
+
+		err = w.Write([]string{strconv.Itoa(i.ItemID), strconv.Itoa(i.RoleID), utils.CSVCell(i.RoleName),
+			strconv.Itoa(i.UserID), utils.CSVCell(i.UserName), string(i.Decision), utils.CSVCell(i.DeciderName.String),
+			decidedAt, strconv.FormatBool(i.AutoRevoked), utils.CSVCell(i.Comment)})
+		if err != nil {
+			return fmt.Errorf("failed to write access review csv: %w", err)
+		}
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
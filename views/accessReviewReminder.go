@@ -0,0 +1,173 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ystv/web-auth/accessreview"
+	"github.com/ystv/web-auth/emailtemplate"
+	"github.com/ystv/web-auth/permission"
+	"github.com/ystv/web-auth/permission/permissions"
+	"github.com/ystv/web-auth/role"
+	"github.com/ystv/web-auth/user"
+)
+
+// accessReviewReminder is how often the reviewers of an open review are reminded
+const accessReviewReminder = 3 * 24 * time.Hour
+
+// processAccessReviews revokes the overdue memberships of reviews that auto revoke, completes the reviews that
+// are finished and reminds the reviewers of the open ones, this is run in the background
+func (v *Views) processAccessReviews(ctx context.Context) error {
+	overdue, err := v.accessReview.GetOverdueItems(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get overdue access review items: %w", err)
+	}
+
+	for _, item := range overdue {
+		item.Decision = accessreview.Revoke
+		item.AutoRevoked = true
+		item.Comment = "Not reviewed by the deadline"
+
+		err = v.decideAccessReviewItem(ctx, item)
+		if err != nil {
+			log.Printf("failed to auto revoke access review item id %d: %+v", item.ItemID, err)
+		}
+	}
+
+	completed, err := v.accessReview.CompleteReviews(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to complete access reviews: %w", err)
+	}
+
+	for _, r := range completed {
+		log.Printf("completed access review id %d", r.ReviewID)
+	}
+
+	reviews, err := v.accessReview.GetReviews(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get access reviews: %w", err)
+	}
+
+	for _, r := range reviews {
+		if r.CompletedAt.Valid || r.Pending == 0 ||
+			(r.RemindedAt.Valid && time.Since(r.RemindedAt.Time) < accessReviewReminder) {
+			continue
+		}
+
+		err = v.remindAccessReview(ctx, r)
+		if err != nil {
+			log.Printf("failed to remind reviewers of access review id %d: %+v", r.ReviewID, err)
+		}
+	}
+
+	return nil
+}
+
+// decideAccessReviewItem records the decision on an item and removes the membership when it is revoked, it may
+// have already gone since the review started
+func (v *Views) decideAccessReviewItem(ctx context.Context, item accessreview.Item) error {
+	_, err := v.accessReview.DecideItem(ctx, item)
+	if err != nil {
+		return err
+	}
+
+	if item.Decision != accessreview.Revoke {
+		return nil
+	}
+
+	roleUser := user.RoleUser{RoleID: item.RoleID, UserID: item.UserID}
+
+	_, err = v.user.GetRoleUser(ctx, roleUser)
+	if err != nil {
+		return nil
+	}
+
+	err = v.user.RemoveRoleUser(ctx, roleUser)
+	if err != nil {
+		return fmt.Errorf("failed to remove revoked role user: %w", err)
+	}
+
+	return nil
+}
+
+// remindAccessReview emails each reviewer how many memberships they have left to review, the reviewers of a role
+// are the same people who approve its access requests
+func (v *Views) remindAccessReview(ctx context.Context, review accessreview.Review) error {
+	items, err := v.accessReview.GetItems(ctx, review)
+	if err != nil {
+		return fmt.Errorf("failed to get access review items: %w", err)
+	}
+
+	reviewers := make(map[int]user.User)
+	counts := make(map[int]int)
+	roleReviewers := make(map[int][]user.User)
+
+	for _, item := range items {
+		if item.Decision != accessreview.Pending {
+			continue
+		}
+
+		users, ok := roleReviewers[item.RoleID]
+		if !ok {
+			users, err = v.getRoleReviewers(ctx, item.RoleID)
+			if err != nil {
+				return fmt.Errorf("failed to get reviewers for role id %d: %w", item.RoleID, err)
+			}
+
+			roleReviewers[item.RoleID] = users
+		}
+
+		for _, u := range users {
+			if u.UserID == item.UserID {
+				continue
+			}
+
+			reviewers[u.UserID] = u
+			counts[u.UserID]++
+		}
+	}
+
+	if len(reviewers) == 0 {
+		return nil
+	}
+
+	for _, u := range reviewers {
+		err := v.queueMail(ctx, emailtemplate.AccessReview, u.Language.String, u.Email,
+			emailtemplate.AccessReviewData{
+				Name:       u.Firstname,
+				Review:     review.Name,
+				Count:      counts[u.UserID],
+				Deadline:   review.Deadline.Format("02/01/2006 15:04"),
+				AutoRevoke: review.AutoRevoke,
+				URL:        fmt.Sprintf("https://%s/internal/review/tasks", v.conf.DomainName),
+			})
+		if err != nil {
+			log.Printf("failed to queue access review email to user id %d: %+v", u.UserID, err)
+		}
+	}
+
+	return v.accessReview.SetReviewReminded(ctx, review)
+}
+
+// getRoleReviewers returns the users who hold the approver permission of a role or ManageMembers.Groups when it
+// doesn't have one
+func (v *Views) getRoleReviewers(ctx context.Context, roleID int) ([]user.User, error) {
+	r, err := v.role.GetRole(ctx, role.Role{RoleID: roleID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	approver := permission.Permission{Name: permissions.ManageMembersGroup.String()}
+	if r.ApproverPermissionID.Valid {
+		approver = permission.Permission{PermissionID: int(r.ApproverPermissionID.Int64)}
+	}
+
+	approver, err = v.permission.GetPermission(ctx, approver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approver permission: %w", err)
+	}
+
+	return v.user.GetUsersWithPermission(ctx, approver)
+}
@@ -0,0 +1,204 @@
+package views
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/accessreview"
+	mockaccessreview "github.com/ystv/web-auth/accessreview/mocks"
+	"github.com/ystv/web-auth/emailtemplate"
+	mockemailtemplate "github.com/ystv/web-auth/emailtemplate/mocks"
+	"github.com/ystv/web-auth/infrastructure/mail"
+	"github.com/ystv/web-auth/mailqueue"
+	mockmailqueue "github.com/ystv/web-auth/mailqueue/mocks"
+	"github.com/ystv/web-auth/permission"
+	mockpermission "github.com/ystv/web-auth/permission/mocks"
+	"github.com/ystv/web-auth/role"
+	mockrole "github.com/ystv/web-auth/role/mocks"
+	"github.com/ystv/web-auth/user"
+	mockuser "github.com/ystv/web-auth/user/mocks"
+)
+
+func TestProcessAccessReviews(t *testing.T) {
+	deadline := time.Date(2026, 10, 30, 17, 0, 0, 0, time.UTC)
+
+	member := accessreview.Item{ItemID: 1, ReviewID: 1, RoleID: 3, UserID: 4, Decision: accessreview.Pending}
+	left := accessreview.Item{ItemID: 2, ReviewID: 1, RoleID: 3, UserID: 5, Decision: accessreview.Pending}
+
+	open := accessreview.Review{ReviewID: 2, Name: "Autumn", Deadline: deadline, AutoRevoke: true, Pending: 2}
+	reviewer := user.User{UserID: 2, Firstname: "John", Email: "john.doe@ystv.co.uk"}
+	approver := permission.Permission{PermissionID: 7}
+
+	ctr := gomock.NewController(t)
+	mockAccessReview := mockaccessreview.NewMockRepo(ctr)
+	mockUser := mockuser.NewMockRepo(ctr)
+	mockRole := mockrole.NewMockRepo(ctr)
+	mockPermission := mockpermission.NewMockRepo(ctr)
+	mockEmailTemplate := mockemailtemplate.NewMockRepo(ctr)
+	mockMailQueue := mockmailqueue.NewMockRepo(ctr)
+
+	mockAccessReview.EXPECT().GetOverdueItems(gomock.Any()).Return([]accessreview.Item{member, left}, nil)
+
+	for _, item := range []accessreview.Item{member, left} {
+		mockAccessReview.EXPECT().DecideItem(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, i accessreview.Item) (accessreview.Item, error) {
+				assert.Equal(t, item.ItemID, i.ItemID)
+				assert.Equal(t, accessreview.Revoke, i.Decision)
+				assert.True(t, i.AutoRevoked)
+				assert.False(t, i.DecidedBy.Valid)
+
+				return i, nil
+			})
+	}
+
+	// the membership is only removed when it is still there, the other user has already left the role
+	mockUser.EXPECT().GetRoleUser(gomock.Any(), user.RoleUser{RoleID: 3, UserID: 4}).
+		Return(user.RoleUser{RoleID: 3, UserID: 4}, nil)
+	mockUser.EXPECT().RemoveRoleUser(gomock.Any(), user.RoleUser{RoleID: 3, UserID: 4}).Return(nil)
+	mockUser.EXPECT().GetRoleUser(gomock.Any(), user.RoleUser{RoleID: 3, UserID: 5}).
+		Return(user.RoleUser{}, sql.ErrNoRows)
+
+	mockAccessReview.EXPECT().CompleteReviews(gomock.Any()).Return([]accessreview.Review{{ReviewID: 1}}, nil)
+	mockAccessReview.EXPECT().GetReviews(gomock.Any()).Return([]accessreview.Review{
+		{ReviewID: 1, Pending: 0, CompletedAt: null.TimeFrom(time.Now())},
+		{ReviewID: 3, Pending: 1, RemindedAt: null.TimeFrom(time.Now().Add(-time.Hour))},
+		open,
+	}, nil)
+
+	// only the open review that hasn't been reminded recently gets a reminder
+	mockAccessReview.EXPECT().GetItems(gomock.Any(), open).Return([]accessreview.Item{
+		{ItemID: 3, ReviewID: 2, RoleID: 3, UserID: 4, Decision: accessreview.Pending},
+		{ItemID: 4, ReviewID: 2, RoleID: 3, UserID: 2, Decision: accessreview.Pending},
+		{ItemID: 5, ReviewID: 2, RoleID: 3, UserID: 6, Decision: accessreview.Keep},
+	}, nil)
+	mockRole.EXPECT().GetRole(gomock.Any(), role.Role{RoleID: 3}).
+		Return(role.Role{RoleID: 3, ApproverPermissionID: null.IntFrom(7)}, nil)
+	mockPermission.EXPECT().GetPermission(gomock.Any(), approver).Return(approver, nil)
+	mockUser.EXPECT().GetUsersWithPermission(gomock.Any(), approver).Return([]user.User{reviewer}, nil)
+	// the reviewer can't review their own membership so it isn't counted
+	mockEmailTemplate.EXPECT().Mail(gomock.Any(), emailtemplate.AccessReview, "", reviewer.Email,
+		emailtemplate.AccessReviewData{
+			Name:       "John",
+			Review:     "Autumn",
+			Count:      1,
+			Deadline:   "30/10/2026 17:00",
+			AutoRevoke: true,
+			URL:        "https://auth.ystv.co.uk/internal/review/tasks",
+		}).Return(mail.Mail{}, nil)
+	mockMailQueue.EXPECT().Queue(gomock.Any(), gomock.Any()).Return(mailqueue.Message{}, nil)
+	mockAccessReview.EXPECT().SetReviewReminded(gomock.Any(), open).Return(nil)
+
+	v := &Views{
+		accessReview:  mockAccessReview,
+		user:          mockUser,
+		role:          mockRole,
+		permission:    mockPermission,
+		emailTemplate: mockEmailTemplate,
+		mailQueue:     mockMailQueue,
+		conf:          &Config{DomainName: "auth.ystv.co.uk"},
+	}
+
+	require.NoError(t, v.processAccessReviews(context.Background()))
+}
+
+func TestAccessReviewDecide(t *testing.T) {
+	reviewer := user.User{UserID: 2}
+	item := accessreview.Item{ItemID: 1, ReviewID: 1, RoleID: 3, UserID: 4, Decision: accessreview.Pending}
+
+	setup := func(t *testing.T, item accessreview.Item) (*Views, *mockaccessreview.MockRepo, *mockuser.MockRepo) {
+		ctr := gomock.NewController(t)
+		mockAccessReview := mockaccessreview.NewMockRepo(ctr)
+		mockRole := mockrole.NewMockRepo(ctr)
+		mockUser := mockuser.NewMockRepo(ctr)
+
+		mockAccessReview.EXPECT().GetItem(gomock.Any(), accessreview.Item{ItemID: 1}).Return(item, nil)
+		mockRole.EXPECT().GetRole(gomock.Any(), role.Role{RoleID: 3}).
+			Return(role.Role{RoleID: 3, ApproverPermissionID: null.IntFrom(7)}, nil).AnyTimes()
+		mockUser.EXPECT().GetPermissionsForUser(gomock.Any(), gomock.Any()).
+			Return([]permission.Permission{{PermissionID: 7}}, nil).AnyTimes()
+
+		v := newTestViews()
+		v.accessReview = mockAccessReview
+		v.role = mockRole
+		v.user = mockUser
+
+		return v, mockAccessReview, mockUser
+	}
+
+	t.Run("Revoke", func(t *testing.T) {
+		v, mockAccessReview, mockUser := setup(t, item)
+
+		mockAccessReview.EXPECT().DecideItem(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, i accessreview.Item) (accessreview.Item, error) {
+				assert.Equal(t, accessreview.Revoke, i.Decision)
+				assert.Equal(t, null.IntFrom(2), i.DecidedBy)
+				assert.Equal(t, "Left the society", i.Comment)
+
+				return i, nil
+			})
+		mockUser.EXPECT().GetRoleUser(gomock.Any(), user.RoleUser{RoleID: 3, UserID: 4}).
+			Return(user.RoleUser{RoleID: 3, UserID: 4}, nil)
+		mockUser.EXPECT().RemoveRoleUser(gomock.Any(), user.RoleUser{RoleID: 3, UserID: 4}).Return(nil)
+
+		c, rec := newTestContext(t, v, reviewer, url.Values{
+			"decision": {"revoke"},
+			"comment":  {"Left the society"},
+		}, "itemid", "1")
+
+		require.NoError(t, v.AccessReviewDecideFunc(c))
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+	})
+
+	t.Run("Keep", func(t *testing.T) {
+		v, mockAccessReview, _ := setup(t, item)
+
+		mockAccessReview.EXPECT().DecideItem(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, i accessreview.Item) (accessreview.Item, error) {
+				assert.Equal(t, accessreview.Keep, i.Decision)
+
+				return i, nil
+			})
+
+		c, _ := newTestContext(t, v, reviewer, url.Values{"decision": {"keep"}}, "itemid", "1")
+
+		require.NoError(t, v.AccessReviewDecideFunc(c))
+	})
+
+	t.Run("ReviewCompleted", func(t *testing.T) {
+		completed := item
+		completed.ReviewCompletedAt = null.TimeFrom(time.Now())
+
+		v, _, _ := setup(t, completed)
+
+		c, _ := newTestContext(t, v, reviewer, url.Values{"decision": {"keep"}}, "itemid", "1")
+
+		err := v.AccessReviewDecideFunc(c)
+
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	})
+
+	t.Run("OwnAccess", func(t *testing.T) {
+		v, _, _ := setup(t, item)
+
+		c, _ := newTestContext(t, v, user.User{UserID: 4}, url.Values{"decision": {"keep"}}, "itemid", "1")
+
+		err := v.AccessReviewDecideFunc(c)
+
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusForbidden, httpErr.Code)
+	})
+}
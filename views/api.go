@@ -19,6 +19,7 @@ import (
 	"github.com/ystv/web-auth/api"
 	"github.com/ystv/web-auth/templates"
 	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/useremail"
 )
 
 type (
@@ -26,6 +27,10 @@ type (
 	JWTClaims struct {
 		UserID      int      `json:"id"`
 		Permissions []string `json:"perms"`
+		// Email and Emails are only in the short-lived tokens for logged-in users, an API token would keep them
+		// after they have changed
+		Email  string   `json:"email,omitempty"`
+		Emails []string `json:"emails,omitempty"`
 		jwt.RegisteredClaims
 	}
 	// statusStruct used for test API as the return JSON
@@ -320,9 +325,16 @@ func (v *Views) _newJWT(u user.User, expiration time.Time) (string, error) {
 		p2 = append(p2, p.Name)
 	}
 
+	emails, err := v.userEmail.GetEmailsForUser(context.Background(), u)
+	if err != nil {
+		return "", fmt.Errorf("failed to get user emails: %w", err)
+	}
+
 	claims := &JWTClaims{
 		UserID:      u.UserID,
 		Permissions: p2,
+		Email:       u.Email,
+		Emails:      useremail.Addresses(emails),
 		RegisteredClaims: jwt.RegisteredClaims{
 			IssuedAt:  &jwt.NumericDate{Time: time.Now()},
 			ExpiresAt: &jwt.NumericDate{Time: expiration},
@@ -0,0 +1,237 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ystv/web-auth/client"
+	infraPermission "github.com/ystv/web-auth/infrastructure/permission"
+	"github.com/ystv/web-auth/permission"
+	"github.com/ystv/web-auth/permission/permissions"
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/useremail"
+)
+
+const (
+	// apiClaimsKey is where RequiresAPIToken stores the JWTClaims of the caller
+	apiClaimsKey = "apiClaims"
+
+	// authorizeMaxChecks limits how many checks can be in one authorize request
+	authorizeMaxChecks = 100
+)
+
+// AuthorizeFunc answers a batch of "does this user have this permission" checks for other services, the user is
+// picked by user ID or by a JWT we issued and the permissions are looked up fresh so the hierarchy and SuperUser are
+// applied. Checking a user other than the caller needs ManageMembers.Members.List
+func (v *Views) AuthorizeFunc(c echo.Context) error {
+	caller, ok := c.Get(apiClaimsKey).(*JWTClaims)
+	if !ok {
+		return errors.New("failed to get claims for authorize")
+	}
+
+	var req client.AuthorizeRequest
+
+	err := c.Bind(&req)
+	if err != nil {
+		return apiError(c, http.StatusBadRequest, fmt.Sprintf("failed to bind request for authorize: %+v", err))
+	}
+
+	if len(req.Checks) == 0 || len(req.Checks) > authorizeMaxChecks {
+		return apiError(c, http.StatusBadRequest,
+			fmt.Sprintf("between 1 and %d checks must be given", authorizeMaxChecks))
+	}
+
+	perms := make(map[int][]permission.Permission)
+
+	callerPerms, err := v.apiUserPermissions(c.Request().Context(), perms, caller.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get caller permissions for authorize: %w", err)
+	}
+
+	canCheckOthers := infraPermission.HasPermission(callerPerms, permissions.ManageMembersMembersList)
+
+	res := client.AuthorizeResponse{Results: make([]client.Result, 0, len(req.Checks))}
+
+	for _, check := range req.Checks {
+		result := client.Result{
+			UserID:     check.UserID,
+			Permission: check.Permission,
+		}
+
+		switch {
+		case check.Token != "":
+			var claims *JWTClaims
+
+			_, claims, err = v.ValidateToken(check.Token)
+			if err != nil {
+				result.Error = "invalid token"
+				res.Results = append(res.Results, result)
+
+				continue
+			}
+
+			result.UserID = claims.UserID
+		case check.UserID == 0:
+			result.UserID = caller.UserID
+		case check.UserID != caller.UserID && !canCheckOthers:
+			result.Error = "not authorised to check other users"
+			res.Results = append(res.Results, result)
+
+			continue
+		}
+
+		if check.Permission == "" {
+			result.Error = "no permission given"
+			res.Results = append(res.Results, result)
+
+			continue
+		}
+
+		p, err := v.apiUserPermissions(c.Request().Context(), perms, result.UserID)
+		if err != nil {
+			result.Error = "user not found"
+			res.Results = append(res.Results, result)
+
+			continue
+		}
+
+		result.Allowed = infraPermission.HasPermission(p, permissions.Permissions(check.Permission))
+		res.Results = append(res.Results, result)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// EffectivePermissionsFunc returns every permission a user has, including the ones implied by others, the caller
+// needs ManageMembers.Members.List unless it is their own
+func (v *Views) EffectivePermissionsFunc(c echo.Context) error {
+	caller, ok := c.Get(apiClaimsKey).(*JWTClaims)
+	if !ok {
+		return errors.New("failed to get claims for effectivePermissions")
+	}
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return apiError(c, http.StatusBadRequest, fmt.Sprintf("failed to get user id for effectivePermissions: %+v", err))
+	}
+
+	perms := make(map[int][]permission.Permission)
+
+	if userID != caller.UserID {
+		callerPerms, err := v.apiUserPermissions(c.Request().Context(), perms, caller.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get caller permissions for effectivePermissions: %w", err)
+		}
+
+		if !infraPermission.HasPermission(callerPerms, permissions.ManageMembersMembersList) {
+			return apiError(c, http.StatusForbidden, "you are not authorised for accessing this")
+		}
+	}
+
+	p, err := v.apiUserPermissions(c.Request().Context(), perms, userID)
+	if err != nil {
+		return apiError(c, http.StatusNotFound, "user not found")
+	}
+
+	res := client.EffectivePermissions{
+		UserID:      userID,
+		SuperUser:   infraPermission.HasPermission(p, permissions.SuperUser),
+		Permissions: make([]client.Permission, 0, len(p)),
+	}
+
+	for _, perm := range removeDuplicate(p) {
+		res.Permissions = append(res.Permissions, client.Permission{
+			PermissionID: perm.PermissionID,
+			Name:         perm.Name,
+			Description:  perm.Description,
+		})
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// UserInfoFunc returns who a user is and their verified email addresses, looking up a user other than the caller
+// needs ManageMembers.Members.List
+func (v *Views) UserInfoFunc(c echo.Context) error {
+	caller, ok := c.Get(apiClaimsKey).(*JWTClaims)
+	if !ok {
+		return errors.New("failed to get claims for userInfo")
+	}
+
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return apiError(c, http.StatusBadRequest, fmt.Sprintf("failed to get user id for userInfo: %+v", err))
+	}
+
+	if userID != caller.UserID {
+		callerPerms, err := v.apiUserPermissions(c.Request().Context(), make(map[int][]permission.Permission),
+			caller.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get caller permissions for userInfo: %w", err)
+		}
+
+		if !infraPermission.HasPermission(callerPerms, permissions.ManageMembersMembersList) {
+			return apiError(c, http.StatusForbidden, "you are not authorised for accessing this")
+		}
+	}
+
+	u, err := v.user.GetUserValid(c.Request().Context(), user.User{UserID: userID})
+	if err != nil {
+		return apiError(c, http.StatusNotFound, "user not found")
+	}
+
+	emails, err := v.userEmail.GetEmailsForUser(c.Request().Context(), u)
+	if err != nil {
+		return fmt.Errorf("failed to get emails for userInfo: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, client.UserInfo{
+		UserID:    u.UserID,
+		Username:  u.Username,
+		Firstname: u.Firstname,
+		Nickname:  u.Nickname,
+		Lastname:  u.Lastname,
+		Email:     u.Email,
+		Emails:    useremail.Addresses(emails),
+		Status:    string(u.Status),
+	})
+}
+
+// apiUserPermissions returns the permissions of a valid user, they are kept in perms so a batch only looks each
+// user up once
+func (v *Views) apiUserPermissions(ctx context.Context, perms map[int][]permission.Permission,
+	userID int) ([]permission.Permission, error) {
+	if p, ok := perms[userID]; ok {
+		return p, nil
+	}
+
+	u, err := v.user.GetUserValid(ctx, user.User{UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get valid user: %w", err)
+	}
+
+	p, err := v.user.GetPermissionsForUser(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user permissions: %w", err)
+	}
+
+	perms[userID] = p
+
+	return p, nil
+}
+
+// apiError returns an error in the JSON format used by the API
+func apiError(c echo.Context, code int, message string) error {
+	data := struct {
+		Error string `json:"error"`
+	}{
+		Error: message,
+	}
+
+	return c.JSON(code, data)
+}
@@ -0,0 +1,135 @@
+package views
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/ystv/web-auth/client"
+	"github.com/ystv/web-auth/permission"
+	"github.com/ystv/web-auth/permission/permissions"
+	"github.com/ystv/web-auth/user"
+	mockuser "github.com/ystv/web-auth/user/mocks"
+)
+
+func TestAuthorize(t *testing.T) {
+	setup := func(t *testing.T, checks ...client.Check) (*Views, *mockuser.MockRepo, echo.Context,
+		*httptest.ResponseRecorder) {
+		ctr := gomock.NewController(t)
+		mockUser := mockuser.NewMockRepo(ctr)
+
+		v := newTestViews()
+		v.user = mockUser
+		v.conf.Security.SigningKey = "signing-key"
+
+		body, err := json.Marshal(client.AuthorizeRequest{Checks: checks})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/authorize", strings.NewReader(string(body)))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		c := echo.New().NewContext(req, rec)
+		c.Set(apiClaimsKey, &JWTClaims{UserID: 1})
+
+		return v, mockUser, c, rec
+	}
+
+	expectUser := func(mockUser *mockuser.MockRepo, userID int, perms ...permissions.Permissions) {
+		u := user.User{UserID: userID}
+		p := make([]permission.Permission, 0, len(perms))
+
+		for _, perm := range perms {
+			p = append(p, permission.Permission{Name: perm.String()})
+		}
+
+		// the permissions of each user are only looked up once however many checks they are in
+		mockUser.EXPECT().GetUserValid(gomock.Any(), u).Return(u, nil)
+		mockUser.EXPECT().GetPermissionsForUser(gomock.Any(), u).Return(p, nil)
+	}
+
+	results := func(t *testing.T, rec *httptest.ResponseRecorder) []client.Result {
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var res client.AuthorizeResponse
+
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+		return res.Results
+	}
+
+	t.Run("Caller", func(t *testing.T) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS512, &JWTClaims{
+			UserID: 4,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: &jwt.NumericDate{Time: time.Now().Add(time.Hour)},
+			},
+		}).SignedString([]byte("signing-key"))
+		require.NoError(t, err)
+
+		v, mockUser, c, rec := setup(t,
+			client.Check{Permission: permissions.CMSAdmin.String()},
+			client.Check{Permission: permissions.ManageMembersMembersList.String()},
+			client.Check{UserID: 2, Permission: permissions.CMSAdmin.String()},
+			client.Check{Token: token, Permission: permissions.CMSAdmin.String()},
+			client.Check{Token: "not-a-token", Permission: permissions.CMSAdmin.String()},
+			client.Check{},
+		)
+
+		expectUser(mockUser, 1, permissions.CMSAdmin)
+		expectUser(mockUser, 4, permissions.SuperUser)
+		// validating the token checks its user is still valid as well
+		mockUser.EXPECT().GetUserValid(gomock.Any(), user.User{UserID: 4}).Return(user.User{UserID: 4}, nil)
+
+		require.NoError(t, v.AuthorizeFunc(c))
+
+		assert.Equal(t, []client.Result{
+			{UserID: 1, Permission: permissions.CMSAdmin.String(), Allowed: true},
+			{UserID: 1, Permission: permissions.ManageMembersMembersList.String()},
+			{UserID: 2, Permission: permissions.CMSAdmin.String(), Error: "not authorised to check other users"},
+			// a token is checked for its own user without needing ManageMembers.Members.List
+			{UserID: 4, Permission: permissions.CMSAdmin.String(), Allowed: true},
+			{Permission: permissions.CMSAdmin.String(), Error: "invalid token"},
+			{UserID: 1, Error: "no permission given"},
+		}, results(t, rec))
+	})
+
+	t.Run("OtherUsers", func(t *testing.T) {
+		v, mockUser, c, rec := setup(t,
+			client.Check{UserID: 2, Permission: permissions.CMSAdmin.String()},
+			client.Check{UserID: 2, Permission: permissions.MenuDisabled.String()},
+			client.Check{UserID: 3, Permission: permissions.CMSAdmin.String()},
+		)
+
+		expectUser(mockUser, 1, permissions.ManageMembersMembersList)
+		expectUser(mockUser, 2, permissions.SuperUser)
+		mockUser.EXPECT().GetUserValid(gomock.Any(), user.User{UserID: 3}).Return(user.User{}, sql.ErrNoRows)
+
+		require.NoError(t, v.AuthorizeFunc(c))
+
+		assert.Equal(t, []client.Result{
+			{UserID: 2, Permission: permissions.CMSAdmin.String(), Allowed: true},
+			// SuperUser is sufficient for everything other than Menu.Disabled
+			{UserID: 2, Permission: permissions.MenuDisabled.String()},
+			{UserID: 3, Permission: permissions.CMSAdmin.String(), Error: "user not found"},
+		}, results(t, rec))
+	})
+
+	t.Run("TooManyChecks", func(t *testing.T) {
+		v, _, c, rec := setup(t, make([]client.Check, authorizeMaxChecks+1)...)
+
+		require.NoError(t, v.AuthorizeFunc(c))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -3,6 +3,7 @@ package views
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	"gopkg.in/guregu/null.v4"
@@ -38,9 +39,13 @@ func (v *Views) ChangePasswordFunc(c echo.Context) error {
 
 		password := c.Request().FormValue("newPassword")
 
-		errString := minRequirementsMet(password)
-		if len(errString) > 0 {
-			message.Error = "new password doesn't meet the old requirements: " + errString
+		problems, err := v.passwordPolicy.Check(c.Request().Context(), c1.User, password)
+		if err != nil {
+			return fmt.Errorf("failed to check password for changePassword: %w", err)
+		}
+
+		if len(problems) > 0 {
+			message.Error = "new password doesn't meet the requirements: " + strings.Join(problems, " and ")
 
 			return c.JSON(status, message)
 		}
@@ -0,0 +1,181 @@
+package views
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/dataexport"
+	"github.com/ystv/web-auth/officership"
+	"github.com/ystv/web-auth/user"
+)
+
+// SettingsExportFunc downloads everything held about the logged-in user
+func (v *Views) SettingsExportFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		u, err := v.user.GetUser(c.Request().Context(), c1.User)
+		if err != nil {
+			return fmt.Errorf("failed to get user for settingsExport: %w", err)
+		}
+
+		return v.exportUserData(c, u, c1.User.UserID)
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// UserExportFunc downloads everything held about a user to answer a subject access request
+func (v *Views) UserExportFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		userID, err := strconv.Atoi(c.Param("userid"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get userid for userExport: %w", err))
+		}
+
+		u, err := v.user.GetUser(c.Request().Context(), user.User{UserID: userID})
+		if err != nil {
+			return fmt.Errorf("failed to get user for userExport: %w", err)
+		}
+
+		return v.exportUserData(c, u, c1.User.UserID)
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// exportUserData records the export then sends the user's data as a ZIP, the export is built before anything is
+// written so a failure is still shown as an error page
+func (v *Views) exportUserData(c echo.Context, u user.User, exportedBy int) error {
+	d, err := v.getUserData(c.Request().Context(), u)
+	if err != nil {
+		return fmt.Errorf("failed to get user data for export: %w", err)
+	}
+
+	e, err := v.dataExport.AddExport(c.Request().Context(), dataexport.Export{
+		UserID:     u.UserID,
+		ExportedBy: null.IntFrom(int64(exportedBy)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add export: %w", err)
+	}
+
+	d.Exports = append([]dataexport.Export{e}, d.Exports...)
+	d.ExportedAt = e.ExportedAt
+
+	var b bytes.Buffer
+
+	err = dataexport.WriteZip(&b, d)
+	if err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=\"ystv-data-%s-%s.zip\"", u.Username, time.Now().Format("2006-01-02")))
+
+	return c.Blob(http.StatusOK, "application/zip", b.Bytes())
+}
+
+// getUserData collects everything held about a user from every repo
+func (v *Views) getUserData(ctx context.Context, u user.User) (dataexport.Data, error) {
+	var err error
+
+	d := dataexport.Data{User: u}
+
+	d.Emails, err = v.userEmail.GetEmailsForUser(ctx, u)
+	if err != nil {
+		return dataexport.Data{}, fmt.Errorf("failed to get emails: %w", err)
+	}
+
+	d.EmailChanges, err = v.emailChange.GetChangesForUser(ctx, u)
+	if err != nil {
+		return dataexport.Data{}, fmt.Errorf("failed to get email changes: %w", err)
+	}
+
+	d.StatusChanges, err = v.userStatus.GetChangesForUser(ctx, u)
+	if err != nil {
+		return dataexport.Data{}, fmt.Errorf("failed to get status changes: %w", err)
+	}
+
+	d.Roles, err = v.user.GetRolesForUser(ctx, u)
+	if err != nil {
+		return dataexport.Data{}, fmt.Errorf("failed to get roles: %w", err)
+	}
+
+	d.RoleMemberships, err = v.user.GetRoleUsersForUser(ctx, u)
+	if err != nil {
+		return dataexport.Data{}, fmt.Errorf("failed to get role memberships: %w", err)
+	}
+
+	d.Permissions, err = v.user.GetPermissionsForUser(ctx, u)
+	if err != nil {
+		return dataexport.Data{}, fmt.Errorf("failed to get permissions: %w", err)
+	}
+
+	d.Permissions = removeDuplicate(d.Permissions)
+
+	d.Officerships, err = v.officership.GetOfficershipMembers(ctx, nil, &u, officership.Any, officership.Any, false)
+	if err != nil {
+		return dataexport.Data{}, fmt.Errorf("failed to get officerships: %w", err)
+	}
+
+	d.APITokens, err = v.api.GetTokens(ctx, u.UserID)
+	if err != nil {
+		return dataexport.Data{}, fmt.Errorf("failed to get api tokens: %w", err)
+	}
+
+	d.AccessRequests, err = v.accessRequest.GetRequestsForUser(ctx, u.UserID)
+	if err != nil {
+		return dataexport.Data{}, fmt.Errorf("failed to get access requests: %w", err)
+	}
+
+	d.AccessReviews, err = v.accessReview.GetItemsForUser(ctx, u.UserID)
+	if err != nil {
+		return dataexport.Data{}, fmt.Errorf("failed to get access reviews: %w", err)
+	}
+
+	d.Memberships, err = v.membership.GetMembershipsForUser(ctx, u)
+	if err != nil {
+		return dataexport.Data{}, fmt.Errorf("failed to get memberships: %w", err)
+	}
+
+	d.Keys, err = v.keylist.GetGrantsForUser(ctx, u)
+	if err != nil {
+		return dataexport.Data{}, fmt.Errorf("failed to get keys: %w", err)
+	}
+
+	addresses := []string{u.Email}
+	for _, e := range d.Emails {
+		addresses = append(addresses, e.Email)
+	}
+
+	for _, c := range d.EmailChanges {
+		addresses = append(addresses, c.OldEmail, c.NewEmail)
+	}
+
+	d.Mail, err = v.mailQueue.GetMessagesForAddresses(ctx, addresses)
+	if err != nil {
+		return dataexport.Data{}, fmt.Errorf("failed to get mail: %w", err)
+	}
+
+	d.WebhookDeliveries, err = v.webhook.GetDeliveriesForUser(ctx, u.UserID)
+	if err != nil {
+		return dataexport.Data{}, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	d.Exports, err = v.dataExport.GetExportsForUser(ctx, u)
+	if err != nil {
+		return dataexport.Data{}, fmt.Errorf("failed to get exports: %w", err)
+	}
+
+	return d, nil
+}
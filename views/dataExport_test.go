@@ -0,0 +1,116 @@
+package views
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	mockaccessrequest "github.com/ystv/web-auth/accessrequest/mocks"
+	mockaccessreview "github.com/ystv/web-auth/accessreview/mocks"
+	mockapi "github.com/ystv/web-auth/api/mocks"
+	"github.com/ystv/web-auth/dataexport"
+	mockdataexport "github.com/ystv/web-auth/dataexport/mocks"
+	"github.com/ystv/web-auth/emailchange"
+	mockemailchange "github.com/ystv/web-auth/emailchange/mocks"
+	mockkeylist "github.com/ystv/web-auth/keylist/mocks"
+	"github.com/ystv/web-auth/mailqueue"
+	mockmailqueue "github.com/ystv/web-auth/mailqueue/mocks"
+	mockmembership "github.com/ystv/web-auth/membership/mocks"
+	"github.com/ystv/web-auth/officership"
+	mockofficership "github.com/ystv/web-auth/officership/mocks"
+	"github.com/ystv/web-auth/permission"
+	"github.com/ystv/web-auth/role"
+	"github.com/ystv/web-auth/user"
+	mockuser "github.com/ystv/web-auth/user/mocks"
+	"github.com/ystv/web-auth/useremail"
+	mockuseremail "github.com/ystv/web-auth/useremail/mocks"
+	"github.com/ystv/web-auth/userstatus"
+	mockuserstatus "github.com/ystv/web-auth/userstatus/mocks"
+	"github.com/ystv/web-auth/webhook"
+	mockwebhook "github.com/ystv/web-auth/webhook/mocks"
+)
+
+func TestGetUserData(t *testing.T) {
+	ctr := gomock.NewController(t)
+	mockUser := mockuser.NewMockRepo(ctr)
+	mockUserEmail := mockuseremail.NewMockRepo(ctr)
+	mockEmailChange := mockemailchange.NewMockRepo(ctr)
+	mockUserStatus := mockuserstatus.NewMockRepo(ctr)
+	mockOfficership := mockofficership.NewMockRepo(ctr)
+	mockAPI := mockapi.NewMockRepo(ctr)
+	mockAccessRequest := mockaccessrequest.NewMockRepo(ctr)
+	mockAccessReview := mockaccessreview.NewMockRepo(ctr)
+	mockMembership := mockmembership.NewMockRepo(ctr)
+	mockKeylist := mockkeylist.NewMockRepo(ctr)
+	mockMailQueue := mockmailqueue.NewMockRepo(ctr)
+	mockWebhook := mockwebhook.NewMockRepo(ctr)
+	mockDataExport := mockdataexport.NewMockRepo(ctr)
+
+	u := user.User{UserID: 1, Email: "jane.doe@ystv.co.uk"}
+
+	// Member is given directly, Editor comes from an inclusion so has no role_members row and Crew hasn't started yet
+	direct := []user.RoleUser{{RoleID: 1, UserID: 1}, {RoleID: 3, UserID: 1}}
+	effective := []role.Role{{RoleID: 1, Name: "Member"}, {RoleID: 2, Name: "Editor"}}
+	perms := []permission.Permission{{PermissionID: 1, Name: "A"}, {PermissionID: 1, Name: "A"}}
+	emails := []useremail.Email{{EmailID: 1, Email: "jane@example.com"}}
+	changes := []emailchange.Change{{ChangeID: 1, OldEmail: "old@example.com", NewEmail: "jane.doe@ystv.co.uk"}}
+	statuses := []userstatus.Change{{UserID: 1}}
+	messages := []mailqueue.Message{{MessageID: 1, To: "jane.doe@ystv.co.uk"}}
+	deliveries := []webhook.Delivery{{DeliveryID: 1, Event: webhook.UserUpdated}}
+
+	mockUserEmail.EXPECT().GetEmailsForUser(gomock.Any(), u).Return(emails, nil)
+	mockEmailChange.EXPECT().GetChangesForUser(gomock.Any(), u).Return(changes, nil)
+	mockUserStatus.EXPECT().GetChangesForUser(gomock.Any(), u).Return(statuses, nil)
+	mockUser.EXPECT().GetRolesForUser(gomock.Any(), u).Return(effective, nil)
+	mockUser.EXPECT().GetRoleUsersForUser(gomock.Any(), u).Return(direct, nil)
+	mockUser.EXPECT().GetPermissionsForUser(gomock.Any(), u).Return(perms, nil)
+	mockOfficership.EXPECT().GetOfficershipMembers(gomock.Any(), nil, &u, officership.Any, officership.Any, false).
+		Return(nil, nil)
+	mockAPI.EXPECT().GetTokens(gomock.Any(), 1).Return(nil, nil)
+	mockAccessRequest.EXPECT().GetRequestsForUser(gomock.Any(), 1).Return(nil, nil)
+	mockAccessReview.EXPECT().GetItemsForUser(gomock.Any(), 1).Return(nil, nil)
+	mockMembership.EXPECT().GetMembershipsForUser(gomock.Any(), u).Return(nil, nil)
+	mockKeylist.EXPECT().GetGrantsForUser(gomock.Any(), u).Return(nil, nil)
+	mockMailQueue.EXPECT().GetMessagesForAddresses(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, addresses []string) ([]mailqueue.Message, error) {
+			assert.ElementsMatch(t, []string{"jane.doe@ystv.co.uk", "jane@example.com", "old@example.com",
+				"jane.doe@ystv.co.uk"}, addresses)
+
+			return messages, nil
+		})
+	mockWebhook.EXPECT().GetDeliveriesForUser(gomock.Any(), 1).Return(deliveries, nil)
+	mockDataExport.EXPECT().GetExportsForUser(gomock.Any(), u).Return([]dataexport.Export{{ExportID: 1}}, nil)
+
+	v := &Views{
+		user:          mockUser,
+		userEmail:     mockUserEmail,
+		emailChange:   mockEmailChange,
+		userStatus:    mockUserStatus,
+		officership:   mockOfficership,
+		api:           mockAPI,
+		accessRequest: mockAccessRequest,
+		accessReview:  mockAccessReview,
+		membership:    mockMembership,
+		keylist:       mockKeylist,
+		mailQueue:     mockMailQueue,
+		webhook:       mockWebhook,
+		dataExport:    mockDataExport,
+	}
+
+	d, err := v.getUserData(context.Background(), u)
+	require.NoError(t, err)
+
+	assert.Equal(t, u, d.User)
+	assert.Equal(t, effective, d.Roles)
+	assert.Equal(t, direct, d.RoleMemberships)
+	assert.Len(t, d.Permissions, 1)
+	assert.Equal(t, emails, d.Emails)
+	assert.Equal(t, changes, d.EmailChanges)
+	assert.Equal(t, statuses, d.StatusChanges)
+	assert.Equal(t, messages, d.Mail)
+	assert.Equal(t, deliveries, d.WebhookDeliveries)
+	assert.Len(t, d.Exports, 1)
+}
@@ -0,0 +1,281 @@
+package views
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/officership"
+	"github.com/ystv/web-auth/templates"
+	"github.com/ystv/web-auth/user"
+)
+
+type (
+	// OfficerDirectory is the public list of current officers grouped by team
+	OfficerDirectory struct {
+		Teams     []DirectoryTeam `json:"teams"`
+		UpdatedAt time.Time       `json:"updatedAt"`
+	}
+
+	// DirectoryTeam is a team in the officer directory, officerships without a team are under a team with ID 0
+	DirectoryTeam struct {
+		TeamID           int                    `json:"teamID"`
+		Name             string                 `json:"name"`
+		Email            string                 `json:"email"`
+		ShortDescription string                 `json:"shortDescription"`
+		Officerships     []DirectoryOfficership `json:"officerships"`
+	}
+
+	// DirectoryOfficership is a current officership in the officer directory
+	DirectoryOfficership struct {
+		OfficershipID  int                `json:"officershipID"`
+		Name           string             `json:"name"`
+		Email          string             `json:"email"`
+		Description    string             `json:"description"`
+		HistoryWikiURL string             `json:"historyWikiURL,omitempty"`
+		IsTeamLeader   bool               `json:"isTeamLeader"`
+		IsTeamDeputy   bool               `json:"isTeamDeputy"`
+		Officers       []DirectoryOfficer `json:"officers"`
+	}
+
+	// DirectoryOfficer is the public information of a current officer, users who hide themselves are left out
+	DirectoryOfficer struct {
+		Name      string    `json:"name"`
+		Nickname  string    `json:"nickname,omitempty"`
+		Pronouns  string    `json:"pronouns,omitempty"`
+		Avatar    string    `json:"avatar"`
+		StartDate null.Time `json:"startDate"`
+	}
+)
+
+const (
+	officerDirectoryCacheKey = "officerDirectory"
+	officerDirectoryMaxAge   = 5 * time.Minute
+)
+
+// OfficerDirectoryFunc returns the current officers for the public websites, this doesn't need a login so only
+// public information is returned. ?format=html returns a fragment that can be embedded instead of the JSON
+func (v *Views) OfficerDirectoryFunc(c echo.Context) error {
+	var directory OfficerDirectory
+
+	cached, found := v.cache.Get(officerDirectoryCacheKey)
+	if found {
+		directory = cached.(OfficerDirectory)
+	} else {
+		var err error
+
+		directory, err = v.getOfficerDirectory(c.Request().Context())
+		if err != nil {
+			return errors.Errorf("failed to get officer directory: %+v", err)
+		}
+
+		v.cache.Set(officerDirectoryCacheKey, directory, officerDirectoryMaxAge)
+	}
+
+	var body bytes.Buffer
+
+	contentType := echo.MIMEApplicationJSONCharsetUTF8
+
+	switch c.QueryParam("format") {
+	case "json", "":
+		err := json.NewEncoder(&body).Encode(directory)
+		if err != nil {
+			return errors.Errorf("failed to encode officer directory: %+v", err)
+		}
+	case "html":
+		contentType = echo.MIMETextHTMLCharsetUTF8
+
+		err := v.template.RenderTemplate(&body, directory, templates.OfficerDirectoryTemplate, templates.FragmentType)
+		if err != nil {
+			return errors.Errorf("failed to render officer directory: %+v", err)
+		}
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, errors.New("format must be set to either \"json\" or \"html\""))
+	}
+
+	hash := sha256.Sum256(body.Bytes())
+	eTag := `"` + hex.EncodeToString(hash[:16]) + `"`
+
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	c.Response().Header().Set("ETag", eTag)
+	c.Response().Header().Set("Vary", "Accept-Encoding")
+
+	if c.Request().Header.Get("If-None-Match") == eTag {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.Blob(http.StatusOK, contentType, body.Bytes())
+}
+
+// getOfficerDirectory builds the directory from the current officerships, the teams are in name order with the
+// leader and deputy first and the officerships otherwise follow the usual officership order
+func (v *Views) getOfficerDirectory(ctx context.Context) (OfficerDirectory, error) {
+	officerships, err := v.officership.GetOfficerships(ctx, officership.Current)
+	if err != nil {
+		return OfficerDirectory{}, errors.Errorf("failed to get officerships: %+v", err)
+	}
+
+	officers, err := v.officership.GetOfficershipMembers(ctx, nil, nil, officership.Current, officership.Current,
+		true)
+	if err != nil {
+		return OfficerDirectory{}, errors.Errorf("failed to get officers: %+v", err)
+	}
+
+	teams, err := v.officership.GetOfficershipTeams(ctx)
+	if err != nil {
+		return OfficerDirectory{}, errors.Errorf("failed to get officership teams: %+v", err)
+	}
+
+	directory := OfficerDirectory{
+		Teams:     make([]DirectoryTeam, 0, len(teams)+1),
+		UpdatedAt: time.Now(),
+	}
+
+	teamIndex := make(map[int]int)
+	flags := make(map[int]officership.OfficershipTeamMember)
+
+	for _, t := range teams {
+		teamIndex[t.TeamID] = len(directory.Teams)
+
+		directory.Teams = append(directory.Teams, DirectoryTeam{
+			TeamID:           t.TeamID,
+			Name:             t.Name,
+			Email:            v.directoryEmail(t.EmailAlias),
+			ShortDescription: t.ShortDescription,
+			Officerships:     make([]DirectoryOfficership, 0),
+		})
+
+		var members []officership.OfficershipTeamMember
+
+		members, err = v.officership.GetOfficershipTeamMembers(ctx, &t, officership.Current)
+		if err != nil {
+			return OfficerDirectory{}, errors.Errorf("failed to get officership team members: %+v", err)
+		}
+
+		for _, m := range members {
+			flags[m.OfficerID] = m
+		}
+	}
+
+	teamIndex[0] = len(directory.Teams)
+
+	directory.Teams = append(directory.Teams, DirectoryTeam{
+		Name:         "Other",
+		Officerships: make([]DirectoryOfficership, 0),
+	})
+
+	userIDs := make([]int, 0, len(officers))
+
+	for _, m := range officers {
+		userIDs = append(userIDs, m.UserID)
+	}
+
+	officerUsers, err := v.user.GetUsersByID(ctx, userIDs)
+	if err != nil {
+		return OfficerDirectory{}, errors.Errorf("failed to get users for officer directory: %+v", err)
+	}
+
+	users := make(map[int]user.User, len(officerUsers))
+
+	for _, u := range officerUsers {
+		users[u.UserID] = u
+	}
+
+	for _, o := range officerships {
+		do := DirectoryOfficership{
+			OfficershipID:  o.OfficershipID,
+			Name:           o.Name,
+			Email:          v.directoryEmail(o.EmailAlias),
+			Description:    o.Description,
+			HistoryWikiURL: o.HistoryWikiURL,
+			IsTeamLeader:   flags[o.OfficershipID].IsLeader,
+			IsTeamDeputy:   flags[o.OfficershipID].IsDeputy,
+			Officers:       make([]DirectoryOfficer, 0),
+		}
+
+		for _, m := range officers {
+			if m.OfficerID != o.OfficershipID {
+				continue
+			}
+
+			// disabled and suspended users can't sign in, so they aren't listed as officers either
+			u, ok := users[m.UserID]
+			if !ok || u.HideFromPublic || u.DeletedAt.Valid || !u.Enabled || u.Status == user.Suspended {
+				continue
+			}
+
+			// the avatar is the url GetUsersByID worked out, the gravatar one when the user uses it
+			officer := DirectoryOfficer{
+				Name:      u.Firstname + " " + u.Lastname,
+				Pronouns:  u.Pronouns.String,
+				Avatar:    u.Avatar,
+				StartDate: m.StartDate,
+			}
+
+			// the default avatar is served by us so needs to be absolute for the other sites
+			if strings.HasPrefix(officer.Avatar, "/") {
+				officer.Avatar = "https://" + v.conf.DomainName + officer.Avatar
+			}
+
+			if u.Nickname != u.Firstname {
+				officer.Nickname = u.Nickname
+			}
+
+			do.Officers = append(do.Officers, officer)
+		}
+
+		i, ok := teamIndex[int(o.TeamID.Int64)]
+		if !ok {
+			i = teamIndex[0]
+		}
+
+		directory.Teams[i].Officerships = append(directory.Teams[i].Officerships, do)
+	}
+
+	filtered := make([]DirectoryTeam, 0, len(directory.Teams))
+
+	for _, t := range directory.Teams {
+		if len(t.Officerships) == 0 {
+			continue
+		}
+
+		sort.SliceStable(t.Officerships, func(i, j int) bool {
+			return directoryRank(t.Officerships[i]) < directoryRank(t.Officerships[j])
+		})
+
+		filtered = append(filtered, t)
+	}
+
+	directory.Teams = filtered
+
+	return directory, nil
+}
+
+func (v *Views) directoryEmail(alias string) string {
+	if alias == "" {
+		return ""
+	}
+
+	return alias + "@" + v.conf.BaseDomainName
+}
+
+func directoryRank(o DirectoryOfficership) int {
+	switch {
+	case o.IsTeamLeader:
+		return 0
+	case o.IsTeamDeputy:
+		return 1
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,90 @@
+package views
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/officership"
+	mockofficership "github.com/ystv/web-auth/officership/mocks"
+	"github.com/ystv/web-auth/user"
+	mockuser "github.com/ystv/web-auth/user/mocks"
+)
+
+func TestOfficerDirectory(t *testing.T) {
+	ctr := gomock.NewController(t)
+	mockOfficership := mockofficership.NewMockRepo(ctr)
+	mockUser := mockuser.NewMockRepo(ctr)
+
+	director := officership.Officership{OfficershipID: 1, Name: "Station Director", EmailAlias: "director",
+		TeamID: null.IntFrom(4)}
+	computing := officership.Officership{OfficershipID: 2, Name: "Computing Director"}
+
+	// the directory is built once and then served from the cache
+	mockOfficership.EXPECT().GetOfficerships(gomock.Any(), officership.Current).
+		Return([]officership.Officership{director, computing}, nil)
+	mockOfficership.EXPECT().GetOfficershipMembers(gomock.Any(), nil, nil, officership.Current, officership.Current,
+		true).Return([]officership.OfficershipMember{
+		{UserID: 1, OfficerID: 1},
+		{UserID: 2, OfficerID: 2},
+		{UserID: 3, OfficerID: 2},
+		{UserID: 4, OfficerID: 2},
+		{UserID: 5, OfficerID: 2},
+	}, nil)
+	mockOfficership.EXPECT().GetOfficershipTeams(gomock.Any()).
+		Return([]officership.OfficershipTeam{{TeamID: 4, Name: "Management"}}, nil)
+	mockOfficership.EXPECT().GetOfficershipTeamMembers(gomock.Any(), gomock.Any(), officership.Current).
+		Return([]officership.OfficershipTeamMember{{TeamID: 4, OfficerID: 1, IsLeader: true}}, nil)
+
+	// every officer is got in one query
+	mockUser.EXPECT().GetUsersByID(gomock.Any(), []int{1, 2, 3, 4, 5}).Return([]user.User{
+		{UserID: 1, Firstname: "Jane", Nickname: "Janey", Lastname: "Doe", Avatar: "/public/ystv-colour-white.png",
+			Enabled: true},
+		{UserID: 2, Firstname: "John", Nickname: "John", Lastname: "Smith", UseGravatar: true,
+			Avatar: "https://www.gravatar.com/avatar/abc", Enabled: true},
+		{UserID: 3, Firstname: "Hidden", Lastname: "Person", HideFromPublic: true, Enabled: true},
+		{UserID: 4, Firstname: "Disabled", Lastname: "Person"},
+		{UserID: 5, Firstname: "Suspended", Lastname: "Person", Status: user.Suspended, Enabled: true},
+	}, nil)
+
+	v := newTestViews()
+	v.officership = mockOfficership
+	v.user = mockUser
+	v.cache = cache.New(time.Hour, time.Hour)
+	v.conf.DomainName = "auth.ystv.co.uk"
+	v.conf.BaseDomainName = "ystv.co.uk"
+
+	for range 2 {
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+		err := v.OfficerDirectoryFunc(c)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var directory OfficerDirectory
+
+		err = json.Unmarshal(rec.Body.Bytes(), &directory)
+		require.NoError(t, err)
+		require.Len(t, directory.Teams, 2)
+
+		require.Len(t, directory.Teams[0].Officerships, 1)
+		assert.Equal(t, "director@ystv.co.uk", directory.Teams[0].Officerships[0].Email)
+		assert.True(t, directory.Teams[0].Officerships[0].IsTeamLeader)
+		assert.Equal(t, []DirectoryOfficer{{Name: "Jane Doe", Nickname: "Janey",
+			Avatar: "https://auth.ystv.co.uk/public/ystv-colour-white.png"}}, directory.Teams[0].Officerships[0].Officers)
+
+		require.Len(t, directory.Teams[1].Officerships, 1)
+		assert.Equal(t, []DirectoryOfficer{{Name: "John Smith", Avatar: "https://www.gravatar.com/avatar/abc"}},
+			directory.Teams[1].Officerships[0].Officers)
+	}
+}
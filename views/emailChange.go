@@ -0,0 +1,285 @@
+package views
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/emailchange"
+	"github.com/ystv/web-auth/emailtemplate"
+	"github.com/ystv/web-auth/templates"
+	"github.com/ystv/web-auth/user"
+)
+
+// EmailChangeTemplate is the public page the links in the email change emails go to
+type EmailChangeTemplate struct {
+	Title   string
+	Message string
+	Error   string
+	// Button is the label of the button that carries out the change, the change isn't made on the GET so link
+	// scanners in email clients don't make it
+	Button string
+	// Forgot shows the link to reset the password after a change was reverted
+	Forgot bool
+}
+
+// SettingsEmailFunc sends a link to a user's new email, their email only changes once it has been pressed
+func (v *Views) SettingsEmailFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		_, err := v.requestEmailChange(c.Request().Context(), c1.User, c.FormValue("email"), c1.User.UserID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to change email for settings: %w", err))
+		}
+
+		return c.Redirect(http.StatusFound, "/internal/settings")
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// SettingsEmailCancelFunc stops the link sent to a user's new email from working
+func (v *Views) SettingsEmailCancelFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		change, err := v.emailChange.GetPendingChange(c.Request().Context(), c1.User)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to get pending email change for settings: %w", err))
+		}
+
+		err = v.emailChange.CancelChange(c.Request().Context(), change)
+		if err != nil {
+			return fmt.Errorf("failed to cancel email change for settings: %w", err)
+		}
+
+		return c.Redirect(http.StatusFound, "/internal/settings")
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// EmailVerifyFunc changes a user's email once the link sent to the new address is pressed, the old address is
+// sent a link to change it back
+func (v *Views) EmailVerifyFunc(c echo.Context) error {
+	data := EmailChangeTemplate{Title: "Confirm email"}
+
+	change, err := v.emailChange.GetChangeByVerifyToken(c.Request().Context(), c.Param("token"))
+	if err != nil || change.Status != emailchange.Pending || time.Now().After(change.ExpiresAt) {
+		data.Error = "This link has expired or has already been used, please change your email again from your " +
+			"settings"
+
+		return v.template.RenderTemplate(c.Response(), data, templates.EmailChangeTemplate, templates.NoNavType)
+	}
+
+	switch c.Request().Method {
+	case http.MethodGet:
+		data.Message = fmt.Sprintf("Press the button below to change the email of your YSTV account to %s",
+			change.NewEmail)
+		data.Button = "Confirm email"
+	case http.MethodPost:
+		change, err = v.emailChange.ApplyChange(c.Request().Context(), change)
+		if err != nil {
+			log.Printf("failed to apply email change %d: %+v", change.ChangeID, err)
+
+			data.Error = "Your email couldn't be changed, it may have changed since the link was sent or be used " +
+				"by another account"
+
+			break
+		}
+
+		v.refreshSessionEmail(c, change)
+		v.sendEmailChanged(c.Request().Context(), change)
+
+		data.Title = "Email changed"
+		data.Message = fmt.Sprintf("Your email is now %s", change.NewEmail)
+	default:
+		return v.invalidMethodUsed(c)
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.EmailChangeTemplate, templates.NoNavType)
+}
+
+// EmailRevertFunc changes a user's email back when the link sent to the old address is pressed
+func (v *Views) EmailRevertFunc(c echo.Context) error {
+	data := EmailChangeTemplate{Title: "Change email back"}
+
+	change, err := v.emailChange.GetChangeByRevertToken(c.Request().Context(), c.Param("token"))
+	if err != nil || !change.CanRevert() {
+		data.Error = "This link has expired or has already been used, please contact the Computing Team on Slack " +
+			"at #computing if you need help with your account"
+
+		return v.template.RenderTemplate(c.Response(), data, templates.EmailChangeTemplate, templates.NoNavType)
+	}
+
+	switch c.Request().Method {
+	case http.MethodGet:
+		data.Message = fmt.Sprintf("Press the button below to change the email of your YSTV account back from %s "+
+			"to %s", change.NewEmail, change.OldEmail)
+		data.Button = "Change it back"
+	case http.MethodPost:
+		change, err = v.emailChange.RevertChange(c.Request().Context(), change)
+		if err != nil {
+			log.Printf("failed to revert email change %d: %+v", change.ChangeID, err)
+
+			data.Error = "Your email couldn't be changed back, please contact the Computing Team on Slack at " +
+				"#computing"
+
+			break
+		}
+
+		data.Title = "Email changed back"
+		data.Message = fmt.Sprintf("Your email is %s again. If you didn't change it then someone else may "+
+			"have your password, please reset it now", change.OldEmail)
+		data.Forgot = true
+	default:
+		return v.invalidMethodUsed(c)
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.EmailChangeTemplate, templates.NoNavType)
+}
+
+// requestEmailChange records a pending change and sends the link to confirm it to the new address
+func (v *Views) requestEmailChange(ctx context.Context, u user.User, email string,
+	requestedBy int) (emailchange.Change, error) {
+	email, err := v.checkNewEmail(ctx, u, email)
+	if err != nil {
+		return emailchange.Change{}, err
+	}
+
+	change, err := v.emailChange.AddChange(ctx, emailchange.Change{
+		UserID:      u.UserID,
+		OldEmail:    u.Email,
+		NewEmail:    email,
+		Status:      emailchange.Pending,
+		VerifyToken: null.StringFrom(uuid.NewString()),
+		RevertToken: null.StringFrom(uuid.NewString()),
+		RequestedBy: null.IntFrom(int64(requestedBy)),
+		ExpiresAt:   time.Now().Add(emailchange.VerifyFor),
+	})
+	if err != nil {
+		return emailchange.Change{}, fmt.Errorf("failed to add email change: %w", err)
+	}
+
+	err = v.queueMail(ctx, emailtemplate.EmailVerify, u.Language.String, change.NewEmail,
+		emailtemplate.EmailVerifyData{
+			Name:  u.Firstname,
+			Email: change.NewEmail,
+			URL:   "https://" + v.conf.DomainName + "/email/verify/" + change.VerifyToken.String,
+		})
+	if err != nil {
+		return emailchange.Change{}, fmt.Errorf("failed to queue email verify email: %w", err)
+	}
+
+	return change, nil
+}
+
+// changeEmailNow changes a user's email without sending a link to the new address, either as it is already verified
+// or an admin has chosen not to, the old address is still sent a link to change it back
+func (v *Views) changeEmailNow(ctx context.Context, u user.User, email string, verified bool, requestedBy int) error {
+	email, err := v.checkNewEmail(ctx, u, email)
+	if err != nil {
+		return err
+	}
+
+	change, err := v.emailChange.AddChange(ctx, emailchange.Change{
+		UserID:      u.UserID,
+		OldEmail:    u.Email,
+		NewEmail:    email,
+		Status:      emailchange.Changed,
+		Verified:    verified,
+		RevertToken: null.StringFrom(uuid.NewString()),
+		RequestedBy: null.IntFrom(int64(requestedBy)),
+		ExpiresAt:   time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add email change: %w", err)
+	}
+
+	v.sendEmailChanged(ctx, change)
+
+	return nil
+}
+
+// checkNewEmail returns the cleaned up email if the user can change to it
+func (v *Views) checkNewEmail(ctx context.Context, u user.User, email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	err := v.validate.Var(email, "required,email")
+	if err != nil {
+		return "", fmt.Errorf("\"%s\" isn't a valid email", email)
+	}
+
+	if email == strings.ToLower(u.Email) {
+		return "", fmt.Errorf("\"%s\" is already the user's email", email)
+	}
+
+	other, err := v.user.GetUser(ctx, user.User{Email: email})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return email, nil
+		}
+
+		return "", fmt.Errorf("failed to get user with the new email: %w", err)
+	}
+
+	if other.UserID != u.UserID {
+		return "", fmt.Errorf("\"%s\" is used by another user", email)
+	}
+
+	return email, nil
+}
+
+// sendEmailChanged tells the old address about a change with the link to change it back, a failure is only logged
+// as the change has already been made
+func (v *Views) sendEmailChanged(ctx context.Context, change emailchange.Change) {
+	u, err := v.user.GetUser(ctx, user.User{UserID: change.UserID})
+	if err != nil {
+		log.Printf("failed to get user for email changed: %+v", err)
+
+		return
+	}
+
+	err = v.queueMail(ctx, emailtemplate.EmailChanged, u.Language.String, change.OldEmail,
+		emailtemplate.EmailChangedData{
+			Name:     u.Firstname,
+			OldEmail: change.OldEmail,
+			NewEmail: change.NewEmail,
+			URL:      "https://" + v.conf.DomainName + "/email/revert/" + change.RevertToken.String,
+		})
+	if err != nil {
+		log.Printf("failed to queue email changed email for user id %d: %+v", u.UserID, err)
+	}
+}
+
+// refreshSessionEmail updates the email in the session if the change was verified by the logged-in user
+func (v *Views) refreshSessionEmail(c echo.Context, change emailchange.Change) {
+	session, err := v.cookie.Get(c.Request(), v.conf.SessionCookieName)
+	if err != nil {
+		return
+	}
+
+	u, ok := session.Values["user"].(user.User)
+	if !ok || u.UserID != change.UserID {
+		return
+	}
+
+	u.Email = change.NewEmail
+	session.Values["user"] = u
+
+	err = session.Save(c.Request(), c.Response())
+	if err != nil {
+		log.Printf("failed to save user session for email verify: %+v", err)
+	}
+}
@@ -0,0 +1,238 @@
+package views
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/emailchange"
+	mockemailchange "github.com/ystv/web-auth/emailchange/mocks"
+	"github.com/ystv/web-auth/emailtemplate"
+	mockemailtemplate "github.com/ystv/web-auth/emailtemplate/mocks"
+	"github.com/ystv/web-auth/infrastructure/mail"
+	"github.com/ystv/web-auth/mailqueue"
+	mockmailqueue "github.com/ystv/web-auth/mailqueue/mocks"
+	"github.com/ystv/web-auth/templates"
+	"github.com/ystv/web-auth/user"
+	mockuser "github.com/ystv/web-auth/user/mocks"
+)
+
+func TestEmailVerify(t *testing.T) {
+	u := user.User{UserID: 1, Firstname: "Jane", Email: "jane@ystv.co.uk"}
+	pending := emailchange.Change{
+		ChangeID:    5,
+		UserID:      1,
+		OldEmail:    "jane@ystv.co.uk",
+		NewEmail:    "jane.doe@ystv.co.uk",
+		Status:      emailchange.Pending,
+		VerifyToken: null.StringFrom("verify-token"),
+		RevertToken: null.StringFrom("revert-token"),
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	setup := func(t *testing.T, change emailchange.Change,
+		err error) (*Views, *mockemailchange.MockRepo, *mockuser.MockRepo) {
+		ctr := gomock.NewController(t)
+		mockEmailChange := mockemailchange.NewMockRepo(ctr)
+		mockUser := mockuser.NewMockRepo(ctr)
+
+		mockEmailChange.EXPECT().GetChangeByVerifyToken(gomock.Any(), "verify-token").Return(change, err)
+
+		v := newTestViews()
+		v.emailChange = mockEmailChange
+		v.user = mockUser
+		v.template = templates.NewTemplate(nil, nil, mockUser)
+		v.conf.DomainName = "auth.ystv.co.uk"
+
+		return v, mockEmailChange, mockUser
+	}
+
+	t.Run("Confirm", func(t *testing.T) {
+		v, mockEmailChange, mockUser := setup(t, pending, nil)
+
+		ctr := gomock.NewController(t)
+		mockEmailTemplate := mockemailtemplate.NewMockRepo(ctr)
+		mockMailQueue := mockmailqueue.NewMockRepo(ctr)
+
+		v.emailTemplate = mockEmailTemplate
+		v.mailQueue = mockMailQueue
+
+		changed := pending
+		changed.Status = emailchange.Changed
+		changed.ChangedAt = null.TimeFrom(time.Now())
+
+		mockEmailChange.EXPECT().ApplyChange(gomock.Any(), pending).Return(changed, nil)
+		mockUser.EXPECT().GetUser(gomock.Any(), user.User{UserID: 1}).Return(u, nil)
+		// the old address gets the link to change it back
+		mockEmailTemplate.EXPECT().Mail(gomock.Any(), emailtemplate.EmailChanged, "", "jane@ystv.co.uk",
+			emailtemplate.EmailChangedData{
+				Name:     "Jane",
+				OldEmail: "jane@ystv.co.uk",
+				NewEmail: "jane.doe@ystv.co.uk",
+				URL:      "https://auth.ystv.co.uk/email/revert/revert-token",
+			}).Return(mail.Mail{}, nil)
+		mockMailQueue.EXPECT().Queue(gomock.Any(), gomock.Any()).Return(mailqueue.Message{}, nil)
+
+		c, rec := newTestContext(t, v, u, url.Values{}, "token", "verify-token")
+
+		require.NoError(t, v.EmailVerifyFunc(c))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Your email is now jane.doe@ystv.co.uk")
+
+		// the logged-in user's session has their new email
+		session, err := v.cookie.Get(c.Request(), v.conf.SessionCookieName)
+		require.NoError(t, err)
+		assert.Equal(t, "jane.doe@ystv.co.uk", session.Values["user"].(user.User).Email)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		expired := pending
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+		v, _, _ := setup(t, expired, nil)
+
+		c, rec := newTestContext(t, v, u, url.Values{}, "token", "verify-token")
+
+		require.NoError(t, v.EmailVerifyFunc(c))
+
+		assert.Contains(t, rec.Body.String(), "This link has expired or has already been used")
+	})
+
+	t.Run("UnknownToken", func(t *testing.T) {
+		v, _, _ := setup(t, emailchange.Change{}, sql.ErrNoRows)
+
+		c, rec := newTestContext(t, v, u, url.Values{}, "token", "verify-token")
+
+		require.NoError(t, v.EmailVerifyFunc(c))
+
+		assert.Contains(t, rec.Body.String(), "This link has expired or has already been used")
+	})
+}
+
+func TestEmailRevert(t *testing.T) {
+	changed := emailchange.Change{
+		ChangeID:    5,
+		UserID:      1,
+		OldEmail:    "jane@ystv.co.uk",
+		NewEmail:    "jane.doe@ystv.co.uk",
+		Status:      emailchange.Changed,
+		RevertToken: null.StringFrom("revert-token"),
+		ChangedAt:   null.TimeFrom(time.Now().Add(-24 * time.Hour)),
+	}
+
+	setup := func(t *testing.T, change emailchange.Change) (*Views, *mockemailchange.MockRepo) {
+		ctr := gomock.NewController(t)
+		mockEmailChange := mockemailchange.NewMockRepo(ctr)
+
+		mockEmailChange.EXPECT().GetChangeByRevertToken(gomock.Any(), "revert-token").Return(change, nil)
+
+		v := newTestViews()
+		v.emailChange = mockEmailChange
+		v.template = templates.NewTemplate(nil, nil, nil)
+
+		return v, mockEmailChange
+	}
+
+	t.Run("Revert", func(t *testing.T) {
+		v, mockEmailChange := setup(t, changed)
+
+		mockEmailChange.EXPECT().RevertChange(gomock.Any(), changed).
+			DoAndReturn(func(_ context.Context, change emailchange.Change) (emailchange.Change, error) {
+				change.Status = emailchange.Reverted
+				change.RevertedAt = null.TimeFrom(time.Now())
+
+				return change, nil
+			})
+
+		c, rec := newTestContext(t, v, user.User{}, url.Values{}, "token", "revert-token")
+
+		require.NoError(t, v.EmailRevertFunc(c))
+
+		assert.Contains(t, rec.Body.String(), "Your email is jane@ystv.co.uk again")
+	})
+
+	t.Run("TooLate", func(t *testing.T) {
+		late := changed
+		late.ChangedAt = null.TimeFrom(time.Now().Add(-emailchange.RevertFor - time.Hour))
+
+		v, _ := setup(t, late)
+
+		c, rec := newTestContext(t, v, user.User{}, url.Values{}, "token", "revert-token")
+
+		require.NoError(t, v.EmailRevertFunc(c))
+
+		assert.Contains(t, rec.Body.String(), "This link has expired or has already been used")
+	})
+
+	t.Run("AlreadyReverted", func(t *testing.T) {
+		reverted := changed
+		reverted.Status = emailchange.Reverted
+
+		v, _ := setup(t, reverted)
+
+		c, rec := newTestContext(t, v, user.User{}, url.Values{}, "token", "revert-token")
+
+		require.NoError(t, v.EmailRevertFunc(c))
+
+		assert.Contains(t, rec.Body.String(), "This link has expired or has already been used")
+	})
+}
+
+func TestCheckNewEmail(t *testing.T) {
+	u := user.User{UserID: 1, Email: "jane@ystv.co.uk"}
+
+	setup := func(t *testing.T) (*Views, *mockuser.MockRepo) {
+		ctr := gomock.NewController(t)
+		mockUser := mockuser.NewMockRepo(ctr)
+
+		v := newTestViews()
+		v.user = mockUser
+		v.validate = validator.New()
+
+		return v, mockUser
+	}
+
+	t.Run("Free", func(t *testing.T) {
+		v, mockUser := setup(t)
+
+		mockUser.EXPECT().GetUser(gomock.Any(), user.User{Email: "jane.doe@ystv.co.uk"}).
+			Return(user.User{}, fmt.Errorf("failed to get user: %w", sql.ErrNoRows))
+
+		email, err := v.checkNewEmail(context.Background(), u, " Jane.Doe@ystv.co.uk ")
+		require.NoError(t, err)
+		assert.Equal(t, "jane.doe@ystv.co.uk", email)
+	})
+
+	t.Run("UsedByAnotherUser", func(t *testing.T) {
+		v, mockUser := setup(t)
+
+		mockUser.EXPECT().GetUser(gomock.Any(), user.User{Email: "jane.doe@ystv.co.uk"}).
+			Return(user.User{UserID: 2}, nil)
+
+		_, err := v.checkNewEmail(context.Background(), u, "jane.doe@ystv.co.uk")
+		assert.ErrorContains(t, err, "is used by another user")
+	})
+
+	t.Run("LookupFails", func(t *testing.T) {
+		v, mockUser := setup(t)
+
+		// a failed lookup mustn't look like the address is free
+		mockUser.EXPECT().GetUser(gomock.Any(), user.User{Email: "jane.doe@ystv.co.uk"}).
+			Return(user.User{}, errors.New("connection refused"))
+
+		_, err := v.checkNewEmail(context.Background(), u, "jane.doe@ystv.co.uk")
+		assert.ErrorContains(t, err, "connection refused")
+	})
+}
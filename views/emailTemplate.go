@@ -0,0 +1,450 @@
+package views
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ystv/web-auth/emailtemplate"
+	"github.com/ystv/web-auth/templates"
+)
+
+type (
+	// EmailTemplatesTemplate lists every email with the languages it has been saved in
+	EmailTemplatesTemplate struct {
+		Emails          []EmailTemplateSummary
+		DefaultLanguage string
+		TemplateHelper
+	}
+
+	// EmailTemplateSummary is an email and its saved templates
+	EmailTemplateSummary struct {
+		Default emailtemplate.Default
+		Saved   []emailtemplate.Template
+	}
+
+	// EmailTemplateTemplate is the editor of a template
+	EmailTemplateTemplate struct {
+		Default         emailtemplate.Default
+		Template        emailtemplate.Template
+		Preview         emailtemplate.Rendered
+		PreviewError    string
+		Versions        []emailtemplate.Version
+		Languages       []string
+		DefaultLanguage string
+		Error           string
+		TemplateHelper
+	}
+
+	// EmailTemplateResponse is returned by the preview and test sends
+	EmailTemplateResponse struct {
+		Message  string                  `json:"message"`
+		Error    string                  `json:"error"`
+		Rendered *emailtemplate.Rendered `json:"rendered,omitempty"`
+	}
+)
+
+// EmailTemplatesFunc lists the emails that are sent
+func (v *Views) EmailTemplatesFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodGet {
+		c1 := v.getSessionData(c)
+
+		saved, err := v.emailTemplate.GetTemplates(c.Request().Context())
+		if err != nil {
+			return fmt.Errorf("failed to get email templates: %w", err)
+		}
+
+		emails := make([]EmailTemplateSummary, 0, len(emailtemplate.Defaults))
+
+		for _, d := range emailtemplate.Defaults {
+			e := EmailTemplateSummary{Default: d}
+
+			for _, t := range saved {
+				if t.Name == d.Name {
+					e.Saved = append(e.Saved, t)
+				}
+			}
+
+			emails = append(emails, e)
+		}
+
+		p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+		if err != nil {
+			return fmt.Errorf("failed to get user permissions for email templates: %w", err)
+		}
+
+		data := EmailTemplatesTemplate{
+			Emails:          emails,
+			DefaultLanguage: emailtemplate.DefaultLanguage,
+			TemplateHelper: TemplateHelper{
+				UserPermissions: p1,
+				ActivePage:      "emailtemplates",
+				Assumed:         c1.Assumed,
+			},
+		}
+
+		return v.template.RenderTemplate(c.Response(), data, templates.EmailTemplatesTemplate, templates.RegularType)
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// EmailTemplateFunc shows the editor of a template in a language with its preview and versions
+func (v *Views) EmailTemplateFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodGet {
+		c1 := v.getSessionData(c)
+
+		d, t, err := v.getEmailTemplate(c)
+		if err != nil {
+			return err
+		}
+
+		var previewError string
+
+		preview, err := t.Preview()
+		if err != nil {
+			previewError = err.Error()
+		}
+
+		var versions []emailtemplate.Version
+
+		if t.IsSaved() {
+			versions, err = v.emailTemplate.GetVersions(c.Request().Context(), t)
+			if err != nil {
+				return fmt.Errorf("failed to get versions for email template: %w", err)
+			}
+		}
+
+		languages, err := v.emailTemplate.GetLanguages(c.Request().Context())
+		if err != nil {
+			return fmt.Errorf("failed to get languages for email template: %w", err)
+		}
+
+		p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+		if err != nil {
+			return fmt.Errorf("failed to get user permissions for email template: %w", err)
+		}
+
+		data := EmailTemplateTemplate{
+			Default:         d,
+			Template:        t,
+			Preview:         preview,
+			PreviewError:    previewError,
+			Versions:        versions,
+			Languages:       languages,
+			DefaultLanguage: emailtemplate.DefaultLanguage,
+			Error:           c.QueryParam("error"),
+			TemplateHelper: TemplateHelper{
+				UserPermissions: p1,
+				ActivePage:      "emailtemplate",
+				Assumed:         c1.Assumed,
+			},
+		}
+
+		return v.template.RenderTemplate(c.Response(), data, templates.EmailTemplateTemplate, templates.RegularType)
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// EmailTemplateTranslateFunc goes to the editor of a template in the language typed
+func (v *Views) EmailTemplateTranslateFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		d, ok := emailtemplate.GetDefault(emailtemplate.Name(c.Param("name")))
+		if !ok {
+			return echo.NewHTTPError(http.StatusNotFound, emailtemplate.ErrNotFound)
+		}
+
+		lang, err := emailtemplate.CanonicalLanguage(c.FormValue("language"))
+		if err != nil {
+			return c.Redirect(http.StatusFound, fmt.Sprintf("/internal/emailtemplate/%s/%s?error=%s", d.Name,
+				emailtemplate.DefaultLanguage, url.QueryEscape(err.Error())))
+		}
+
+		return c.Redirect(http.StatusFound, fmt.Sprintf("/internal/emailtemplate/%s/%s", d.Name, lang))
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// EmailTemplateEditFunc saves the template as a new version
+func (v *Views) EmailTemplateEditFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		_, t, err := v.getEmailTemplate(c)
+		if err != nil {
+			return err
+		}
+
+		t = parseEmailTemplateForm(c, t)
+
+		_, err = v.emailTemplate.SaveTemplate(c.Request().Context(), t, c1.User.UserID)
+		if err != nil {
+			return c.Redirect(http.StatusFound, emailTemplateURL(t)+"?error="+url.QueryEscape(err.Error()))
+		}
+
+		log.Printf("email template %s in %s saved by user id %d", t.Name, t.Language, c1.User.UserID)
+
+		return c.Redirect(http.StatusFound, emailTemplateURL(t))
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// EmailTemplatePreviewFunc renders the template in the form with the sample data without saving it
+func (v *Views) EmailTemplatePreviewFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		_, t, err := v.getEmailTemplate(c)
+		if err != nil {
+			return err
+		}
+
+		var res EmailTemplateResponse
+
+		rendered, err := parseEmailTemplateForm(c, t).Preview()
+		if err != nil {
+			res.Error = err.Error()
+
+			return c.JSON(http.StatusOK, res)
+		}
+
+		res.Rendered = &rendered
+
+		return c.JSON(http.StatusOK, res)
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// EmailTemplateTestFunc sends the template in the form with the sample data to the logged-in user
+func (v *Views) EmailTemplateTestFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		_, t, err := v.getEmailTemplate(c)
+		if err != nil {
+			return err
+		}
+
+		var res EmailTemplateResponse
+
+		rendered, err := parseEmailTemplateForm(c, t).Preview()
+		if err != nil {
+			res.Error = err.Error()
+
+			return c.JSON(http.StatusOK, res)
+		}
+
+		// a test is sent straight away rather than queued so a problem with the mail server is shown
+		mailer := v.mailer.ConnectMailer()
+		if mailer == nil {
+			log.Printf("no Mailer present")
+
+			res.Error = "No mailer present"
+
+			return c.JSON(http.StatusOK, res)
+		}
+
+		defer func() {
+			_ = mailer.Close()
+		}()
+
+		file := rendered.Mail(c1.User.Email)
+		file.Subject = "[Test] " + file.Subject
+
+		err = mailer.SendMail(file)
+		if err != nil {
+			log.Printf("failed to send test email template: %+v", err)
+
+			res.Error = fmt.Sprintf("Failed to send test email: %s", err)
+
+			return c.JSON(http.StatusOK, res)
+		}
+
+		res.Message = fmt.Sprintf("Test email sent to: \"%s\"", c1.User.Email)
+
+		return c.JSON(http.StatusOK, res)
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// EmailTemplateVersionFunc returns a version so it can be loaded into the editor
+func (v *Views) EmailTemplateVersionFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodGet {
+		version, err := v.getEmailTemplateVersion(c)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(http.StatusOK, version)
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// EmailTemplateRestoreFunc saves an old version as the newest one
+func (v *Views) EmailTemplateRestoreFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		_, t, err := v.getEmailTemplate(c)
+		if err != nil {
+			return err
+		}
+
+		version, err := v.getEmailTemplateVersion(c)
+		if err != nil {
+			return err
+		}
+
+		t, err = v.emailTemplate.SaveTemplate(c.Request().Context(), version.Template(t), c1.User.UserID)
+		if err != nil {
+			return c.Redirect(http.StatusFound, emailTemplateURL(t)+"?error="+url.QueryEscape(err.Error()))
+		}
+
+		log.Printf("email template %s in %s restored to version %d by user id %d", t.Name, t.Language,
+			version.Version, c1.User.UserID)
+
+		return c.Redirect(http.StatusFound, emailTemplateURL(t))
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// EmailTemplateDefaultFunc saves the built-in template as the newest version, so the history is kept
+func (v *Views) EmailTemplateDefaultFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		d, t, err := v.getEmailTemplate(c)
+		if err != nil {
+			return err
+		}
+
+		builtIn, err := d.Template()
+		if err != nil {
+			return fmt.Errorf("failed to get built-in email template: %w", err)
+		}
+
+		builtIn.Language = t.Language
+
+		_, err = v.emailTemplate.SaveTemplate(c.Request().Context(), builtIn, c1.User.UserID)
+		if err != nil {
+			return c.Redirect(http.StatusFound, emailTemplateURL(t)+"?error="+url.QueryEscape(err.Error()))
+		}
+
+		log.Printf("email template %s in %s reset to built-in by user id %d", t.Name, t.Language, c1.User.UserID)
+
+		return c.Redirect(http.StatusFound, emailTemplateURL(t))
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// EmailTemplateDeleteFunc deletes a translation and its versions, the default language can only be reset
+func (v *Views) EmailTemplateDeleteFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		_, t, err := v.getEmailTemplate(c)
+		if err != nil {
+			return err
+		}
+
+		if t.Language == emailtemplate.DefaultLanguage {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				errors.New("the default language can't be deleted, reset it to the built-in template instead"))
+		}
+
+		if !t.IsSaved() {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("this translation hasn't been saved"))
+		}
+
+		err = v.emailTemplate.DeleteTemplate(c.Request().Context(), t)
+		if err != nil {
+			return fmt.Errorf("failed to delete email template: %w", err)
+		}
+
+		log.Printf("email template %s in %s deleted by user id %d", t.Name, t.Language, c1.User.UserID)
+
+		return c.Redirect(http.StatusFound, "/internal/emailtemplates")
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// getEmailTemplate returns the email and its template in the language of the url
+func (v *Views) getEmailTemplate(c echo.Context) (emailtemplate.Default, emailtemplate.Template, error) {
+	d, ok := emailtemplate.GetDefault(emailtemplate.Name(c.Param("name")))
+	if !ok {
+		return emailtemplate.Default{}, emailtemplate.Template{},
+			echo.NewHTTPError(http.StatusNotFound, emailtemplate.ErrNotFound)
+	}
+
+	lang, err := emailtemplate.CanonicalLanguage(c.Param("language"))
+	if err != nil {
+		return emailtemplate.Default{}, emailtemplate.Template{}, echo.NewHTTPError(http.StatusBadRequest, err)
+	}
+
+	t, err := v.emailTemplate.GetTemplate(c.Request().Context(), emailtemplate.Template{
+		Name:     d.Name,
+		Language: lang,
+	})
+	if err != nil {
+		return emailtemplate.Default{}, emailtemplate.Template{},
+			fmt.Errorf("failed to get email template: %w", err)
+	}
+
+	return d, t, nil
+}
+
+// getEmailTemplateVersion returns the version of the template in the url
+func (v *Views) getEmailTemplateVersion(c echo.Context) (emailtemplate.Version, error) {
+	_, t, err := v.getEmailTemplate(c)
+	if err != nil {
+		return emailtemplate.Version{}, err
+	}
+
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		return emailtemplate.Version{}, echo.NewHTTPError(http.StatusBadRequest,
+			fmt.Errorf("failed to parse version for email template: %w", err))
+	}
+
+	if !t.IsSaved() {
+		return emailtemplate.Version{}, echo.NewHTTPError(http.StatusNotFound,
+			errors.New("this template hasn't been saved"))
+	}
+
+	v1, err := v.emailTemplate.GetVersion(c.Request().Context(), emailtemplate.Version{
+		TemplateID: t.TemplateID,
+		Version:    version,
+	})
+	if err != nil {
+		return emailtemplate.Version{}, fmt.Errorf("failed to get email template version: %w", err)
+	}
+
+	return v1, nil
+}
+
+// parseEmailTemplateForm replaces the parts of the template with the ones in the form
+func parseEmailTemplateForm(c echo.Context, t emailtemplate.Template) emailtemplate.Template {
+	t.Subject = c.FormValue("subject")
+	t.From = c.FormValue("from")
+	t.Text = c.FormValue("text")
+	t.HTML = c.FormValue("html")
+
+	return t
+}
+
+// emailTemplateURL is the editor of the template
+func emailTemplateURL(t emailtemplate.Template) string {
+	return fmt.Sprintf("/internal/emailtemplate/%s/%s", t.Name, t.Language)
+}
@@ -2,7 +2,6 @@ package views
 
 import (
 	"fmt"
-	"html/template"
 	"log"
 	"net/http"
 
@@ -10,7 +9,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/patrickmn/go-cache"
 
-	"github.com/ystv/web-auth/infrastructure/mail"
+	"github.com/ystv/web-auth/emailtemplate"
 	"github.com/ystv/web-auth/templates"
 	"github.com/ystv/web-auth/user"
 )
@@ -43,7 +42,8 @@ func (v *Views) ForgotFunc(c echo.Context) error {
 			return v.template.RenderTemplate(c.Response(), nil, templates.ForgotTemplate, templates.NoNavType)
 		}
 		// Get user and check if it exists
-		userFromDB, err := v.user.GetUser(c.Request().Context(), u)
+		// Only a verified address can reset the password, or whoever set an unverified one could take the account
+		userFromDB, err := v.user.GetLoginUser(c.Request().Context(), u)
 		if err != nil {
 			// User doesn't exist, we'll pretend they've got an email
 			log.Printf("request for reset on unknown email \"%s\"", u.Email)
@@ -52,49 +52,29 @@ func (v *Views) ForgotFunc(c echo.Context) error {
 				templates.NoNavType)
 		}
 
-		url := uuid.NewString()
-		v.cache.Set(url, userFromDB.UserID, cache.DefaultExpiration)
-
-		mailer := v.mailer.ConnectMailer()
-
-		// Valid request, send email with reset code
-		if mailer != nil {
-			var emailTemplate *template.Template
-
-			emailTemplate, err = v.template.GetEmailTemplate(templates.ForgotEmailTemplate)
-			if err != nil {
-				return fmt.Errorf("failed to render email for forgot: %w", err)
-			}
-
-			file := mail.Mail{
-				Subject: "YSTV Security - Reset Password",
-				Tpl:     emailTemplate,
-				To:      userFromDB.Email,
-				From:    "YSTV Security <no-reply@ystv.co.uk>",
-				TplData: struct {
-					Email string
-					URL   string
-				}{
-					Email: userFromDB.Email,
-					URL:   "https://" + v.conf.DomainName + "/reset/" + url,
-				},
-			}
-
-			err = mailer.SendMail(file)
-			if err != nil {
-				log.Printf("failed to send mail for forgot: %+v", err)
+		// Deleted users can't log in so don't need a reset
+		if userFromDB.DeletedAt.Valid {
+			log.Printf("request for reset on deleted user %d", userFromDB.UserID)
 
-				return fmt.Errorf("failed to send email for forgot: %w", err)
-			}
+			return v.template.RenderTemplate(c.Response(), notification, templates.NotificationTemplate,
+				templates.NoNavType)
+		}
 
-			_ = mailer.Close()
+		url := uuid.NewString()
+		v.cache.Set(url, userFromDB.UserID, cache.DefaultExpiration)
 
-			log.Printf("request for password reset email: \"%s\"", userFromDB.Email)
-		} else {
-			log.Printf("no Mailer present")
-			log.Printf("forgot password requested for email: %s", userFromDB.Email)
+		// Valid request, queue email with reset code to the address typed as it can be any of the user's verified ones
+		err = v.queueMail(c.Request().Context(), emailtemplate.Forgot, userFromDB.Language.String, u.Email,
+			emailtemplate.ResetData{
+				Email: u.Email,
+				URL:   "https://" + v.conf.DomainName + "/reset/" + url,
+			})
+		if err != nil {
+			return fmt.Errorf("failed to queue email for forgot: %w", err)
 		}
 
+		log.Printf("request for password reset email: \"%s\"", u.Email)
+
 		// User doesn't exist, we'll pretend they've got an email
 		return v.template.RenderTemplate(c.Response().Writer, notification, templates.NotificationTemplate,
 			templates.NoNavType)
@@ -0,0 +1,228 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/ystv/web-auth/emailtemplate"
+	"github.com/ystv/web-auth/officership"
+	"github.com/ystv/web-auth/templates"
+	"github.com/ystv/web-auth/user"
+)
+
+// OfficershipHandoverTemplate represents the handover page, Changes is only set once a preview has been made
+type OfficershipHandoverTemplate struct {
+	Officerships []officership.Officership
+	Results      string
+	StartDate    string
+	EndDate      string
+	Changes      []officership.HandoverChange
+	Errors       []string
+	Message      string
+	TemplateHelper
+}
+
+// OfficershipHandoverFunc takes an election result set, previews the changes to the current officers and applies them
+func (v *Views) OfficershipHandoverFunc(c echo.Context) error {
+	c1 := v.getSessionData(c)
+
+	officerships, err := v.officership.GetOfficerships(c.Request().Context(), officership.Current)
+	if err != nil {
+		return errors.Errorf("failed to get officerships for handover: %+v", err)
+	}
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return errors.Errorf("failed to get user permissions for handover: %+v", err)
+	}
+
+	data := OfficershipHandoverTemplate{
+		Officerships: officerships,
+		TemplateHelper: TemplateHelper{
+			UserPermissions: p1,
+			ActivePage:      "officershipHandover",
+			Assumed:         c1.Assumed,
+		},
+	}
+
+	switch c.Request().Method {
+	case http.MethodGet:
+		return v.template.RenderTemplate(c.Response(), data, templates.OfficershipHandoverTemplate, templates.RegularType)
+	case http.MethodPost:
+		data.Results = c.FormValue("results")
+		data.StartDate = c.FormValue("startDate")
+		data.EndDate = c.FormValue("endDate")
+
+		var handover officership.Handover
+
+		handover, data.Errors, err = v.parseHandover(c.Request().Context(), data.Results, data.StartDate, data.EndDate)
+		if err != nil {
+			return errors.Errorf("failed to parse handover: %+v", err)
+		}
+
+		data.Changes = handover.Changes
+
+		if len(data.Errors) > 0 || c.FormValue("action") != "apply" {
+			return v.template.RenderTemplate(c.Response(), data, templates.OfficershipHandoverTemplate,
+				templates.RegularType)
+		}
+
+		err = v.officership.ApplyHandover(c.Request().Context(), handover)
+		if err != nil {
+			return errors.Errorf("failed to apply handover: %+v", err)
+		}
+
+		log.Printf("officership handover applied by user id: %d", c1.User.UserID)
+
+		data.Message = v.sendHandoverEmails(c.Request().Context(), handover)
+
+		return v.template.RenderTemplate(c.Response(), data, templates.OfficershipHandoverTemplate, templates.RegularType)
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// parseHandover reads the result set, one "officership, user" pair per line, and plans the handover against the
+// current officers, problems with the input are returned as messages so the form can be corrected
+func (v *Views) parseHandover(ctx context.Context, results, tempStartDate, tempEndDate string) (
+	officership.Handover, []string, error) {
+	var handover officership.Handover
+
+	var messages []string
+
+	parsedStart, err := time.Parse("02/01/2006", tempStartDate)
+	if err != nil {
+		messages = append(messages, "start date must be set")
+	} else {
+		// Add 19 hours to match the start of an Admin meeting, the same as adding an officer
+		handover.StartDate = parsedStart.Add(time.Hour * 19)
+	}
+
+	handover.EndDate, err = time.Parse("02/01/2006", tempEndDate)
+	if err != nil {
+		messages = append(messages, "end date must be set")
+	}
+
+	var handoverResults []officership.HandoverResult
+
+	for i, line := range strings.Split(results, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		officershipName, username, found := strings.Cut(line, ",")
+		if !found {
+			messages = append(messages, fmt.Sprintf("line %d: expected \"officership, user\"", i+1))
+			continue
+		}
+
+		officershipName = strings.TrimSpace(officershipName)
+		username = strings.TrimSpace(username)
+
+		o := officership.Officership{Name: officershipName}
+		if officershipID, err := strconv.Atoi(officershipName); err == nil {
+			o = officership.Officership{OfficershipID: officershipID}
+		}
+
+		o, err = v.officership.GetOfficership(ctx, o)
+		if err != nil {
+			messages = append(messages, fmt.Sprintf("line %d: officership \"%s\" not found", i+1, officershipName))
+			continue
+		}
+
+		if !o.IsCurrent {
+			messages = append(messages, fmt.Sprintf("line %d: officership \"%s\" is not current", i+1, o.Name))
+			continue
+		}
+
+		u := user.User{Username: username, Email: username}
+		if userID, err := strconv.Atoi(username); err == nil {
+			u = user.User{UserID: userID}
+		}
+
+		u, err = v.user.GetUser(ctx, u)
+		if err != nil {
+			messages = append(messages, fmt.Sprintf("line %d: user \"%s\" not found", i+1, username))
+			continue
+		}
+
+		if u.DeletedAt.Valid || !u.Enabled {
+			messages = append(messages, fmt.Sprintf("line %d: user \"%s\" is disabled or deleted", i+1, username))
+			continue
+		}
+
+		handoverResults = append(handoverResults, officership.HandoverResult{Officership: o, User: u})
+	}
+
+	if len(handoverResults) == 0 {
+		messages = append(messages, "no results have been entered")
+	}
+
+	current, err := v.officership.GetOfficershipMembers(ctx, nil, nil, officership.Current, officership.Current,
+		false)
+	if err != nil {
+		return officership.Handover{}, nil, errors.Errorf("failed to get current officers: %+v", err)
+	}
+
+	handover.Changes = officership.PlanHandover(current, handoverResults)
+
+	return handover, messages, nil
+}
+
+// sendHandoverEmails lets the incoming and outgoing officers know, failures are logged and reported in the message
+// as the handover has already been applied
+func (v *Views) sendHandoverEmails(ctx context.Context, handover officership.Handover) string {
+	queued, failed := 0, 0
+
+	send := func(u user.User, o officership.Officership, incoming bool) {
+		err := v.queueMail(ctx, emailtemplate.OfficerHandover, u.Language.String, u.Email,
+			emailtemplate.HandoverData{
+				Name:        u.Firstname,
+				Officership: o.Name,
+				Incoming:    incoming,
+				StartDate:   handover.StartDate.Format("02/01/2006"),
+				EndDate:     handover.EndDate.Format("02/01/2006"),
+			})
+		if err != nil {
+			log.Printf("failed to queue handover email to user id %d: %+v", u.UserID, err)
+			failed++
+
+			return
+		}
+
+		queued++
+	}
+
+	for _, change := range handover.Changes {
+		for _, u := range change.Incoming {
+			send(u, change.Officership, true)
+		}
+
+		for _, m := range change.Outgoing {
+			u, err := v.user.GetUser(ctx, user.User{UserID: m.UserID})
+			if err != nil {
+				log.Printf("failed to get user for handover email: %+v", err)
+				failed++
+
+				continue
+			}
+
+			send(u, change.Officership, false)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Sprintf("Handover applied, queued %d emails, failed to queue %d emails", queued, failed)
+	}
+
+	return fmt.Sprintf("Handover applied, queued %d emails", queued)
+}
@@ -5,7 +5,6 @@ import (
 
 	"fmt"
 	"log"
-	"regexp"
 	"time"
 
 	// importing time zones in case the system doesn't have them
@@ -173,6 +172,7 @@ func DBUsersToUsersTemplateFormat(dbUsers []user.User) []user.StrippedUser {
 
 		strippedUser.Email = dbUser.Email
 		strippedUser.Enabled = dbUser.Enabled
+		strippedUser.Status = dbUser.Status
 
 		if dbUser.DeletedAt.Valid || dbUser.DeletedBy.Valid {
 			strippedUser.Deleted = true
@@ -217,6 +217,7 @@ func DBUserToDetailedUser(dbUser user.User, store user.Repo, officers []officers
 		dbUser.LastLogin.Valid)
 	u.ResetPw = dbUser.ResetPw
 	u.Enabled = dbUser.Enabled
+	u.Status = dbUser.Status
 	u.CreatedAt = null.StringFrom(dbUser.CreatedAt.Time.In(location).Format("2006-01-02 15:04:05 MST"))
 	u.Avatar = dbUser.Avatar
 
@@ -295,6 +296,12 @@ func DBUserToDetailedUser(dbUser user.User, store user.Repo, officers []officers
 		}
 	}
 
+	if dbUser.AnonymisedAt.Valid {
+		u.AnonymisedAt = null.StringFrom(dbUser.AnonymisedAt.Time.In(location).Format("2006-01-02 15:04:05 MST"))
+	} else {
+		u.AnonymisedAt = null.NewString("", false)
+	}
+
 	officerMembers := make([]user.OfficershipMember, 0)
 
 	for _, o := range officers {
@@ -332,52 +339,3 @@ func removeDuplicate(strSlice []permission.Permission) []permission.Permission {
 
 	return list
 }
-
-// minRequirementsMet tests if the password meets the minimum requirements
-func minRequirementsMet(password string) string {
-	var match bool
-
-	var errString string
-
-	match, err := regexp.MatchString("^.*[a-z].*$", password)
-	if err != nil || !match {
-		errString = "password must contain at least 1 lower case letter"
-	}
-
-	match, err = regexp.MatchString("^.*[A-Z].*$", password)
-	if err != nil || !match {
-		if len(errString) > 0 {
-			errString += " and password must contain at least 1 upper case letter"
-		} else {
-			errString = "password must contain at least 1 upper case letter"
-		}
-	}
-
-	match, err = regexp.MatchString("^.*\\d.*$", password)
-	if err != nil || !match {
-		if len(errString) > 0 {
-			errString += " and password must contain at least 1 number"
-		} else {
-			errString = "password must contain at least 1 number"
-		}
-	}
-
-	match, err = regexp.MatchString("^.*[@$!%*?&|^£;:/.,<>()_=+~§±#{}-].*$", password)
-	if err != nil || !match {
-		if len(errString) > 0 {
-			errString += " and password must contain at least 1 special character"
-		} else {
-			errString = "password must contain at least 1 special character"
-		}
-	}
-
-	if len(password) <= 8 {
-		if len(errString) > 0 {
-			errString += " and password must be at least 8 characters long"
-		} else {
-			errString = "password must be at least 8 characters long"
-		}
-	}
-
-	return errString
-}
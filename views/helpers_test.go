@@ -0,0 +1,70 @@
+package views
+
+import (
+	"encoding/gob"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ystv/web-auth/user"
+)
+
+// newTestViews returns a Views with the session cookie store set up, the repos are filled in by the test
+func newTestViews() *Views {
+	gob.Register(user.User{})
+	gob.Register(InternalContext{})
+
+	return &Views{
+		conf:   &Config{SessionCookieName: "session"},
+		cookie: sessions.NewCookieStore(securecookie.GenerateRandomKey(64), securecookie.GenerateRandomKey(32)),
+	}
+}
+
+// newTestContext returns a POST echo.Context with the form and u logged in to the session, the path params are
+// pairs of name and value
+func newTestContext(t *testing.T, v *Views, u user.User, form url.Values,
+	params ...string) (echo.Context, *httptest.ResponseRecorder) {
+	t.Helper()
+
+	login := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+
+	session, err := v.cookie.Get(login, v.conf.SessionCookieName)
+	require.NoError(t, err)
+
+	u.Authenticated = true
+	session.Values["user"] = u
+
+	err = session.Save(login, rec)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+
+	for _, c := range rec.Result().Cookies() {
+		req.AddCookie(c)
+	}
+
+	rec = httptest.NewRecorder()
+
+	c := echo.New().NewContext(req, rec)
+
+	var names, values []string
+
+	for i := 0; i+1 < len(params); i += 2 {
+		names = append(names, params[i])
+		values = append(values, params[i+1])
+	}
+
+	c.SetParamNames(names...)
+	c.SetParamValues(values...)
+
+	return c, rec
+}
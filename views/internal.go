@@ -7,6 +7,7 @@ import (
 	"github.com/dustin/go-humanize"
 	"github.com/labstack/echo/v4"
 
+	"github.com/ystv/web-auth/passwordpolicy"
 	"github.com/ystv/web-auth/templates"
 	"github.com/ystv/web-auth/user"
 )
@@ -18,6 +19,9 @@ type (
 		Nickname  string
 		LastLogin string
 		CountAll  user.CountUsers
+		// PasswordExpiry is shown once the user's password is close to expiring
+		PasswordExpiry      passwordpolicy.Expiry
+		PasswordExpiryState passwordpolicy.ExpiryState
 		TemplateHelper
 	}
 )
@@ -41,10 +45,17 @@ func (v *Views) InternalFunc(c echo.Context) error {
 		return fmt.Errorf("failed to get permissions for internal: %w", err)
 	}
 
+	expiry, err := v.passwordPolicy.GetExpiry(c.Request().Context(), c1.User)
+	if err != nil {
+		return fmt.Errorf("failed to get password expiry for internal: %w", err)
+	}
+
 	ctx := InternalTemplate{
-		Nickname:  c1.User.Nickname,
-		LastLogin: humanize.Time(lastLogin),
-		CountAll:  countAll,
+		Nickname:            c1.User.Nickname,
+		LastLogin:           humanize.Time(lastLogin),
+		CountAll:            countAll,
+		PasswordExpiry:      expiry,
+		PasswordExpiryState: expiry.State(time.Now()),
 		TemplateHelper: TemplateHelper{
 			UserPermissions: p1,
 			ActivePage:      "dashboard",
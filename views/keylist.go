@@ -0,0 +1,452 @@
+package views
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/keylist"
+	"github.com/ystv/web-auth/templates"
+	"github.com/ystv/web-auth/utils"
+)
+
+type (
+	// KeylistTemplate represents the keys held and the keys that can be given out
+	KeylistTemplate struct {
+		Held []keylist.Grant
+		Keys []keylist.Key
+		TemplateHelper
+	}
+
+	// KeylistKeyTemplate represents a key with everyone who has held it and its log
+	KeylistKeyTemplate struct {
+		Key    keylist.Key
+		Grants []keylist.Grant
+		Events []keylist.Event
+		TemplateHelper
+	}
+
+	// KeylistLapsedTemplate represents the keyholders who are no longer members or officers
+	KeylistLapsedTemplate struct {
+		Lapsed []keylist.Grant
+		TemplateHelper
+	}
+
+	// KeylistPrintTemplate represents the keylist printed for the porters
+	KeylistPrintTemplate struct {
+		Held      []keylist.Grant
+		PrintedAt time.Time
+	}
+)
+
+// KeylistFunc shows the keylist, ?format=csv exports it for the porters
+func (v *Views) KeylistFunc(c echo.Context) error {
+	if c.Request().Method != http.MethodGet {
+		return v.invalidMethodUsed(c)
+	}
+
+	c1 := v.getSessionData(c)
+
+	held, err := v.keylist.GetHeld(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get held keys for keylist: %w", err)
+	}
+
+	switch c.QueryParam("format") {
+	case "":
+	case "csv":
+		return keylistCSV(c, held)
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, errors.New("format must be set to \"csv\""))
+	}
+
+	keys, err := v.keylist.GetKeys(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get keys for keylist: %w", err)
+	}
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return fmt.Errorf("failed to get user permissions for keylist: %w", err)
+	}
+
+	data := KeylistTemplate{
+		Held: held,
+		Keys: keys,
+		TemplateHelper: TemplateHelper{
+			UserPermissions: p1,
+			ActivePage:      "keylist",
+			Assumed:         c1.Assumed,
+		},
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.KeylistTemplate, templates.RegularType)
+}
+
+// KeylistPrintFunc shows the keylist on its own page to be printed
+func (v *Views) KeylistPrintFunc(c echo.Context) error {
+	if c.Request().Method != http.MethodGet {
+		return v.invalidMethodUsed(c)
+	}
+
+	held, err := v.keylist.GetHeld(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get held keys for keylistPrint: %w", err)
+	}
+
+	data := KeylistPrintTemplate{
+		Held:      held,
+		PrintedAt: time.Now(),
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.KeylistPrintTemplate, templates.FragmentType)
+}
+
+// KeylistLapsedFunc lists the keyholders who are no longer paid members or officers so the keys can be collected
+func (v *Views) KeylistLapsedFunc(c echo.Context) error {
+	if c.Request().Method != http.MethodGet {
+		return v.invalidMethodUsed(c)
+	}
+
+	c1 := v.getSessionData(c)
+
+	lapsed, err := v.keylist.GetLapsed(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get lapsed for keylistLapsed: %w", err)
+	}
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return fmt.Errorf("failed to get user permissions for keylistLapsed: %w", err)
+	}
+
+	data := KeylistLapsedTemplate{
+		Lapsed: lapsed,
+		TemplateHelper: TemplateHelper{
+			UserPermissions: p1,
+			ActivePage:      "keylistLapsed",
+			Assumed:         c1.Assumed,
+		},
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.KeylistLapsedTemplate, templates.RegularType)
+}
+
+// KeylistKeyFunc shows a key with everyone who has held it and its log
+func (v *Views) KeylistKeyFunc(c echo.Context) error {
+	if c.Request().Method != http.MethodGet {
+		return v.invalidMethodUsed(c)
+	}
+
+	c1 := v.getSessionData(c)
+
+	keyID, err := strconv.Atoi(c.Param("keyid"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get keyid for keylistKey: %w", err))
+	}
+
+	k, err := v.keylist.GetKey(c.Request().Context(), keylist.Key{KeyID: keyID})
+	if err != nil {
+		return fmt.Errorf("failed to get key for keylistKey: %w", err)
+	}
+
+	grants, err := v.keylist.GetGrantsForKey(c.Request().Context(), k)
+	if err != nil {
+		return fmt.Errorf("failed to get grants for keylistKey: %w", err)
+	}
+
+	events, err := v.keylist.GetEvents(c.Request().Context(), k)
+	if err != nil {
+		return fmt.Errorf("failed to get events for keylistKey: %w", err)
+	}
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return fmt.Errorf("failed to get user permissions for keylistKey: %w", err)
+	}
+
+	data := KeylistKeyTemplate{
+		Key:    k,
+		Grants: grants,
+		Events: events,
+		TemplateHelper: TemplateHelper{
+			UserPermissions: p1,
+			ActivePage:      "keylist",
+			Assumed:         c1.Assumed,
+		},
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.KeylistKeyTemplate, templates.RegularType)
+}
+
+// KeylistKeyAddFunc adds a key
+func (v *Views) KeylistKeyAddFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		k, err := keyFromForm(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to parse form for keylistKeyAdd: %w", err))
+		}
+
+		_, err = v.keylist.GetKey(c.Request().Context(), keylist.Key{Name: k.Name})
+		if err == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("key \"%s\" already exists", k.Name))
+		}
+
+		k, err = v.keylist.AddKey(c.Request().Context(), k)
+		if err != nil {
+			return fmt.Errorf("failed to add key for keylistKeyAdd: %w", err)
+		}
+
+		return c.Redirect(http.StatusFound, fmt.Sprintf("/internal/keylist/key/%d", k.KeyID))
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// KeylistKeyEditFunc edits a key
+func (v *Views) KeylistKeyEditFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		keyID, err := strconv.Atoi(c.Param("keyid"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get keyid for keylistKeyEdit: %w", err))
+		}
+
+		existing, err := v.keylist.GetKey(c.Request().Context(), keylist.Key{KeyID: keyID})
+		if err != nil {
+			return fmt.Errorf("failed to get key for keylistKeyEdit: %w", err)
+		}
+
+		k, err := keyFromForm(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to parse form for keylistKeyEdit: %w", err))
+		}
+
+		k.KeyID = existing.KeyID
+
+		_, err = v.keylist.EditKey(c.Request().Context(), k)
+		if err != nil {
+			return fmt.Errorf("failed to edit key for keylistKeyEdit: %w", err)
+		}
+
+		return c.Redirect(http.StatusFound, fmt.Sprintf("/internal/keylist/key/%d", k.KeyID))
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// KeylistKeyDeleteFunc deletes a key that has never been issued
+func (v *Views) KeylistKeyDeleteFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		keyID, err := strconv.Atoi(c.Param("keyid"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get keyid for keylistKeyDelete: %w", err))
+		}
+
+		k, err := v.keylist.GetKey(c.Request().Context(), keylist.Key{KeyID: keyID})
+		if err != nil {
+			return fmt.Errorf("failed to get key for keylistKeyDelete: %w", err)
+		}
+
+		err = v.keylist.DeleteKey(c.Request().Context(), k)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to delete key, it may have been issued: %w", err))
+		}
+
+		return c.Redirect(http.StatusFound, "/internal/keylist")
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// KeylistIssueFunc gives a key to a user found by their username or email
+func (v *Views) KeylistIssueFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		u, err := v.findUser(c.Request().Context(), c.FormValue("user"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get user for keylistIssue: %w", err))
+		}
+
+		keyID, err := strconv.Atoi(c.FormValue("keyID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get keyID for keylistIssue: %w", err))
+		}
+
+		k, err := v.keylist.GetKey(c.Request().Context(), keylist.Key{KeyID: keyID})
+		if err != nil {
+			return fmt.Errorf("failed to get key for keylistIssue: %w", err)
+		}
+
+		expiresAt, err := parseKeyExpiry(c.FormValue("expiresAt"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to parse expiry for keylistIssue: %w", err))
+		}
+
+		_, err = v.keylist.IssueGrant(c.Request().Context(), keylist.Grant{
+			KeyID:     k.KeyID,
+			UserID:    u.UserID,
+			IssuedBy:  null.IntFrom(int64(c1.User.UserID)),
+			ExpiresAt: expiresAt,
+			Note:      c.FormValue("note"),
+		})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to issue key, the user may already hold it: %w", err))
+		}
+
+		return c.Redirect(http.StatusFound, "/internal/keylist")
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// KeylistGrantExtendFunc changes when a held key should be returned by
+func (v *Views) KeylistGrantExtendFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		g, err := v.getKeylistGrant(c)
+		if err != nil {
+			return err
+		}
+
+		g.ExpiresAt, err = parseKeyExpiry(c.FormValue("expiresAt"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to parse expiry for keylistGrantExtend: %w", err))
+		}
+
+		err = v.keylist.ExtendGrant(c.Request().Context(), g, keylist.Event{
+			Event:   keylist.Extended,
+			EventBy: null.IntFrom(int64(v.getSessionData(c).User.UserID)),
+			Note:    c.FormValue("note"),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to extend grant for keylistGrantExtend: %w", err)
+		}
+
+		return c.Redirect(http.StatusFound, "/internal/keylist")
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// KeylistGrantReturnFunc records a key being returned to the logged-in user
+func (v *Views) KeylistGrantReturnFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		g, err := v.getKeylistGrant(c)
+		if err != nil {
+			return err
+		}
+
+		err = v.keylist.ReturnGrant(c.Request().Context(), g, keylist.Event{
+			Event:   keylist.Returned,
+			EventBy: null.IntFrom(int64(v.getSessionData(c).User.UserID)),
+			Note:    c.FormValue("note"),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to return grant for keylistGrantReturn: %w", err)
+		}
+
+		return c.Redirect(http.StatusFound, "/internal/keylist")
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+func (v *Views) getKeylistGrant(c echo.Context) (keylist.Grant, error) {
+	grantID, err := strconv.Atoi(c.Param("grantid"))
+	if err != nil {
+		return keylist.Grant{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get grantid: %w", err))
+	}
+
+	g, err := v.keylist.GetGrant(c.Request().Context(), keylist.Grant{GrantID: grantID})
+	if err != nil {
+		return keylist.Grant{}, fmt.Errorf("failed to get grant: %w", err)
+	}
+
+	if g.ReturnedAt.Valid {
+		return keylist.Grant{}, echo.NewHTTPError(http.StatusBadRequest, errors.New("key has already been returned"))
+	}
+
+	return g, nil
+}
+
+// keyFromForm reads a key from the add and edit forms
+func keyFromForm(c echo.Context) (keylist.Key, error) {
+	k := keylist.Key{
+		Name:        strings.TrimSpace(c.FormValue("name")),
+		Kind:        keylist.Kind(c.FormValue("kind")),
+		Description: c.FormValue("description"),
+		Location:    c.FormValue("location"),
+	}
+
+	if k.Name == "" {
+		return keylist.Key{}, errors.New("name must be set")
+	}
+
+	if k.Kind != keylist.PhysicalKey && k.Kind != keylist.CardAccess {
+		return keylist.Key{}, errors.New("kind must be set to either \"key\" or \"card\"")
+	}
+
+	return k, nil
+}
+
+// parseKeyExpiry reads the date a key should be returned by, it is the end of the picked day and a blank date
+// means it doesn't have to be returned by a date
+func parseKeyExpiry(s string) (null.Time, error) {
+	if s == "" {
+		return null.Time{}, nil
+	}
+
+	t, err := time.ParseInLocation("02/01/2006", s, time.Local)
+	if err != nil {
+		return null.Time{}, err
+	}
+
+	t = t.AddDate(0, 0, 1)
+
+	if !t.After(time.Now()) {
+		return null.Time{}, errors.New("expiry can't be in the past")
+	}
+
+	return null.TimeFrom(t), nil
+}
+
+func keylistCSV(c echo.Context, held []keylist.Grant) error {
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=\"keylist-%s.csv\"", time.Now().Format("2006-01-02")))
+	c.Response().WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(c.Response())
+
+	err := w.Write([]string{"key", "kind", "holder", "university_username", "issued", "expires", "note"})
+	if err != nil {
+		return fmt.Errorf("failed to write keylist csv: %w", err)
+	}
+
+	for _, g := range held {
+		var expires string
+
+		if g.ExpiresAt.Valid {
+			expires = g.ExpiresAt.Time.Format("02/01/2006")
+		}
+
+		err = w.Write([]string{utils.CSVCell(g.KeyName), string(g.KeyKind), utils.CSVCell(g.UserName),
+			utils.CSVCell(g.UniversityUsername), g.IssuedAt.Format("02/01/2006"), expires, utils.CSVCell(g.Note)})
+		if err != nil {
+			return fmt.Errorf("failed to write keylist csv: %w", err)
+		}
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
@@ -0,0 +1,54 @@
+package views
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/keylist"
+)
+
+func TestKeylistCSV(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+
+	issued := time.Date(2026, time.October, 1, 12, 0, 0, 0, time.UTC)
+
+	err := keylistCSV(c, []keylist.Grant{
+		{
+			KeyName:            "Studio",
+			KeyKind:            keylist.PhysicalKey,
+			UserName:           "Jane Doe",
+			UniversityUsername: "jd123",
+			IssuedAt:           issued,
+			ExpiresAt:          null.TimeFrom(issued.AddDate(1, 0, 0)),
+			Note:               "Spare",
+		},
+		{
+			KeyName:            "Store",
+			KeyKind:            keylist.CardAccess,
+			UserName:           "=HYPERLINK(\"https://example.com\")",
+			UniversityUsername: "+jd456",
+			IssuedAt:           issued,
+			Note:               "@SUM(A1:A2)",
+		},
+	})
+	require.NoError(t, err)
+
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{
+		{"key", "kind", "holder", "university_username", "issued", "expires", "note"},
+		{"Studio", "key", "Jane Doe", "jd123", "01/10/2026", "01/10/2027", "Spare"},
+		// the user typed cells can't be run as formulas by a spreadsheet
+		{"Store", "card", "'=HYPERLINK(\"https://example.com\")", "'+jd456", "01/10/2026", "", "'@SUM(A1:A2)"},
+	}, records)
+}
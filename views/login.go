@@ -13,6 +13,7 @@ import (
 	"github.com/patrickmn/go-cache"
 	"gopkg.in/guregu/null.v4"
 
+	"github.com/ystv/web-auth/passwordpolicy"
 	"github.com/ystv/web-auth/templates"
 	"github.com/ystv/web-auth/user"
 )
@@ -85,20 +86,7 @@ func (v *Views) _loginPost(c echo.Context) error {
 		}
 
 		if resetPw {
-			ctx := v.getSessionData(c)
-			ctx.Callback = callback
-			ctx.Message = "Password reset required"
-			ctx.MsgType = "is-danger"
-
-			err = v.setMessagesInSession(c, ctx)
-			if err != nil {
-				return fmt.Errorf("failed to set message for login: %w", err)
-			}
-
-			url1 := uuid.NewString()
-			v.cache.Set(url1, u.UserID, cache.DefaultExpiration)
-
-			return c.Redirect(http.StatusFound, fmt.Sprintf("https://%s/reset/%s", v.conf.DomainName, url1))
+			return v._loginReset(c, callback, u, "Password reset required")
 		}
 
 		ctx := v.getSessionData(c)
@@ -114,6 +102,23 @@ func (v *Views) _loginPost(c echo.Context) error {
 		return c.Redirect(http.StatusFound, "/login")
 	}
 
+	// a password past its role's maximum age and the grace period is treated the same as one that has to be reset
+	expiry, err := v.passwordPolicy.GetExpiry(c.Request().Context(), u)
+	if err != nil {
+		return fmt.Errorf("failed to get password expiry for login: %w", err)
+	}
+
+	if expiry.State(time.Now()) == passwordpolicy.Locked {
+		log.Printf("password expired for \"%s\", reset required", u.Username)
+
+		err = session.Save(c.Request(), c.Response())
+		if err != nil {
+			return fmt.Errorf("failed to save session for login: %w", err)
+		}
+
+		return v._loginReset(c, callback, u, "Your password has expired, please choose a new one")
+	}
+
 	prevLogin := u.LastLogin
 	// Update last logged in
 	err = v.user.SetUserLoggedIn(c.Request().Context(), u)
@@ -170,3 +175,21 @@ func (v *Views) _loginPost(c echo.Context) error {
 
 	return c.Redirect(http.StatusFound, callback)
 }
+
+// _loginReset sends the user to the reset page with a one time link instead of logging them in
+func (v *Views) _loginReset(c echo.Context, callback string, u user.User, message string) error {
+	ctx := v.getSessionData(c)
+	ctx.Callback = callback
+	ctx.Message = message
+	ctx.MsgType = "is-danger"
+
+	err := v.setMessagesInSession(c, ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set message for login: %w", err)
+	}
+
+	url1 := uuid.NewString()
+	v.cache.Set(url1, u.UserID, cache.DefaultExpiration)
+
+	return c.Redirect(http.StatusFound, fmt.Sprintf("https://%s/reset/%s", v.conf.DomainName, url1))
+}
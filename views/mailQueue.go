@@ -0,0 +1,114 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ystv/web-auth/emailtemplate"
+	"github.com/ystv/web-auth/mailqueue"
+	"github.com/ystv/web-auth/templates"
+)
+
+type (
+	// MailQueueTemplate is the list of messages in the mail queue
+	MailQueueTemplate struct {
+		Messages []mailqueue.Message
+		Counts   mailqueue.Counts
+		Statuses []mailqueue.Status
+		Status   mailqueue.Status
+		Error    string
+		TemplateHelper
+	}
+)
+
+// mailQueueLimit is the number of messages shown on the mail queue page
+const mailQueueLimit = 200
+
+// queueMail renders the email template in the user's language and adds it to the mail queue to be sent
+func (v *Views) queueMail(ctx context.Context, name emailtemplate.Name, lang, to string, data interface{}) error {
+	file, err := v.emailTemplate.Mail(ctx, name, lang, to, data)
+	if err != nil {
+		return fmt.Errorf("failed to render email %s: %w", name, err)
+	}
+
+	_, err = v.mailQueue.Queue(ctx, file)
+	if err != nil {
+		return fmt.Errorf("failed to queue email %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// MailQueueFunc lists the most recent messages in the mail queue
+func (v *Views) MailQueueFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodGet {
+		c1 := v.getSessionData(c)
+
+		status := mailqueue.Status(c.QueryParam("status"))
+		if len(status) > 0 && !slices.Contains(mailqueue.Statuses, status) {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("invalid status: %s", status))
+		}
+
+		messages, err := v.mailQueue.GetMessages(c.Request().Context(), status, mailQueueLimit)
+		if err != nil {
+			return fmt.Errorf("failed to get messages for mail queue: %w", err)
+		}
+
+		counts, err := v.mailQueue.GetCounts(c.Request().Context())
+		if err != nil {
+			return fmt.Errorf("failed to get counts for mail queue: %w", err)
+		}
+
+		p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+		if err != nil {
+			return fmt.Errorf("failed to get user permissions for mail queue: %w", err)
+		}
+
+		data := MailQueueTemplate{
+			Messages: messages,
+			Counts:   counts,
+			Statuses: mailqueue.Statuses,
+			Status:   status,
+			Error:    c.QueryParam("error"),
+			TemplateHelper: TemplateHelper{
+				UserPermissions: p1,
+				ActivePage:      "mailqueue",
+				Assumed:         c1.Assumed,
+			},
+		}
+
+		return v.template.RenderTemplate(c.Response(), data, templates.MailQueueTemplate, templates.RegularType)
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// MailQueueResendFunc queues a message again
+func (v *Views) MailQueueResendFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		messageID, err := strconv.Atoi(c.Param("messageid"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to parse messageid for resend: %w", err))
+		}
+
+		m, err := v.mailQueue.Resend(c.Request().Context(), mailqueue.Message{MessageID: messageID})
+		if err != nil {
+			return c.Redirect(http.StatusFound, "/internal/mail?error="+url.QueryEscape(err.Error()))
+		}
+
+		log.Printf("message %d resent as %d by user id %d", messageID, m.MessageID, c1.User.UserID)
+
+		return c.Redirect(http.StatusFound, "/internal/mail?status="+mailqueue.Queued.String())
+	}
+
+	return v.invalidMethodUsed(c)
+}
@@ -0,0 +1,452 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/membership"
+	"github.com/ystv/web-auth/role"
+	"github.com/ystv/web-auth/templates"
+	"github.com/ystv/web-auth/user"
+)
+
+type (
+	// MembershipsTemplate represents the memberships of an academic year and the membership types
+	MembershipsTemplate struct {
+		AcademicYear  string
+		AcademicYears []string
+		Memberships   []membership.Membership
+		Types         []membership.Type
+		Roles         []role.Role
+		TemplateHelper
+	}
+
+	// MembershipUnpaidTemplate represents the users whose membership has ended or was never paid
+	MembershipUnpaidTemplate struct {
+		Memberships []membership.Membership
+		TemplateHelper
+	}
+
+	// MembershipImportTemplate represents the Students' Union import form and, once run, what it did with each row
+	MembershipImportTemplate struct {
+		Types    []membership.Type
+		Rows     []membership.ImportRow
+		Imported int
+		Ran      bool
+		TemplateHelper
+	}
+)
+
+// MembershipsFunc lists the memberships of an academic year, ?year= picks the year, the current one by default
+func (v *Views) MembershipsFunc(c echo.Context) error {
+	if c.Request().Method != http.MethodGet {
+		return v.invalidMethodUsed(c)
+	}
+
+	c1 := v.getSessionData(c)
+
+	academicYear := c.QueryParam("year")
+	if academicYear == "" {
+		academicYear = membership.AcademicYear(time.Now())
+	}
+
+	academicYears, err := v.membership.GetAcademicYears(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get academic years for memberships: %w", err)
+	}
+
+	for _, y := range []string{membership.AcademicYear(time.Now()), academicYear} {
+		if !slices.Contains(academicYears, y) {
+			academicYears = append(academicYears, y)
+		}
+	}
+
+	slices.Sort(academicYears)
+	slices.Reverse(academicYears)
+
+	memberships, err := v.membership.GetMemberships(c.Request().Context(), academicYear)
+	if err != nil {
+		return fmt.Errorf("failed to get memberships for memberships: %w", err)
+	}
+
+	types, err := v.membership.GetTypes(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get types for memberships: %w", err)
+	}
+
+	roles, err := v.role.GetRoles(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get roles for memberships: %w", err)
+	}
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return fmt.Errorf("failed to get user permissions for memberships: %w", err)
+	}
+
+	data := MembershipsTemplate{
+		AcademicYear:  academicYear,
+		AcademicYears: academicYears,
+		Memberships:   memberships,
+		Types:         types,
+		Roles:         roles,
+		TemplateHelper: TemplateHelper{
+			UserPermissions: p1,
+			ActivePage:      "memberships",
+			Assumed:         c1.Assumed,
+		},
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.MembershipsTemplate, templates.RegularType)
+}
+
+// MembershipAddFunc records a membership for a user found by their username or email, leaving the paid date
+// blank records it as unpaid
+func (v *Views) MembershipAddFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		u, err := v.findUser(c.Request().Context(), c.FormValue("user"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get user for membershipAdd: %w", err))
+		}
+
+		typeID, err := strconv.Atoi(c.FormValue("typeID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get typeID for membershipAdd: %w", err))
+		}
+
+		academicYear := c.FormValue("academicYear")
+
+		paidUntil, err := membership.AcademicYearEnd(academicYear)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to parse year for membershipAdd: %w", err))
+		}
+
+		var paidAt null.Time
+
+		if c.FormValue("paidAt") != "" {
+			t, err := time.ParseInLocation("02/01/2006", c.FormValue("paidAt"), time.Local)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest,
+					fmt.Errorf("failed to parse paid date for membershipAdd: %w", err))
+			}
+
+			paidAt = null.TimeFrom(t)
+		}
+
+		amountPence, err := membership.ParsePence(c.FormValue("amount"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to parse amount for membershipAdd: %w", err))
+		}
+
+		_, err = v.membership.AddMembership(c.Request().Context(), membership.Membership{
+			UserID:       u.UserID,
+			TypeID:       typeID,
+			AcademicYear: academicYear,
+			PaidAt:       paidAt,
+			PaidUntil:    paidUntil,
+			AmountPence:  amountPence,
+			Source:       membership.Manual,
+			Reference:    c.FormValue("reference"),
+			CreatedBy:    null.IntFrom(int64(c1.User.UserID)),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add membership for membershipAdd: %w", err)
+		}
+
+		v.syncMembershipRoles(c.Request().Context())
+
+		return c.Redirect(http.StatusFound, "/internal/memberships?year="+academicYear)
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// MembershipDeleteFunc deletes a membership, any role it gave is removed by the sync
+func (v *Views) MembershipDeleteFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		membershipID, err := strconv.Atoi(c.Param("membershipid"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to get membershipid for membershipDelete: %w", err))
+		}
+
+		m, err := v.membership.GetMembership(c.Request().Context(), membership.Membership{MembershipID: membershipID})
+		if err != nil {
+			return fmt.Errorf("failed to get membership for membershipDelete: %w", err)
+		}
+
+		err = v.membership.DeleteMembership(c.Request().Context(), m)
+		if err != nil {
+			return fmt.Errorf("failed to delete membership for membershipDelete: %w", err)
+		}
+
+		v.syncMembershipRoles(c.Request().Context())
+
+		return c.Redirect(http.StatusFound, "/internal/memberships?year="+m.AcademicYear)
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// MembershipUnpaidFunc lists the users who have had a membership but don't have a paid one now
+func (v *Views) MembershipUnpaidFunc(c echo.Context) error {
+	if c.Request().Method != http.MethodGet {
+		return v.invalidMethodUsed(c)
+	}
+
+	c1 := v.getSessionData(c)
+
+	memberships, err := v.membership.GetUnpaid(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get unpaid for membershipUnpaid: %w", err)
+	}
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return fmt.Errorf("failed to get user permissions for membershipUnpaid: %w", err)
+	}
+
+	data := MembershipUnpaidTemplate{
+		Memberships: memberships,
+		TemplateHelper: TemplateHelper{
+			UserPermissions: p1,
+			ActivePage:      "membershipUnpaid",
+			Assumed:         c1.Assumed,
+		},
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.MembershipUnpaidTemplate, templates.RegularType)
+}
+
+// MembershipImportFunc shows the import form and imports an uploaded Students' Union export, the rows it couldn't
+// import are listed with why
+func (v *Views) MembershipImportFunc(c echo.Context) error {
+	c1 := v.getSessionData(c)
+
+	types, err := v.membership.GetTypes(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get types for membershipImport: %w", err)
+	}
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return fmt.Errorf("failed to get user permissions for membershipImport: %w", err)
+	}
+
+	data := MembershipImportTemplate{
+		Types: types,
+		TemplateHelper: TemplateHelper{
+			UserPermissions: p1,
+			ActivePage:      "membershipImport",
+			Assumed:         c1.Assumed,
+		},
+	}
+
+	switch c.Request().Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		file, err := c.FormFile("upload")
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get file for membershipImport: %w", err))
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open file for membershipImport: %w", err)
+		}
+
+		defer src.Close()
+
+		rows, err := membership.ParseImport(src)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to parse csv for membershipImport: %w", err))
+		}
+
+		var defaultType membership.Type
+
+		if c.FormValue("typeID") != "" {
+			typeID, err := strconv.Atoi(c.FormValue("typeID"))
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest,
+					fmt.Errorf("failed to get typeID for membershipImport: %w", err))
+			}
+
+			defaultType, err = v.membership.GetType(c.Request().Context(), membership.Type{TypeID: typeID})
+			if err != nil {
+				return fmt.Errorf("failed to get type for membershipImport: %w", err)
+			}
+		}
+
+		data.Rows, err = membership.Import(c.Request().Context(), v.membership, v.user, rows, defaultType,
+			c1.User.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to import for membershipImport: %w", err)
+		}
+
+		for _, row := range data.Rows {
+			if row.MembershipID != 0 {
+				data.Imported++
+			}
+		}
+
+		data.Ran = true
+
+		log.Printf("membership import run by user id: %d, %d of %d rows imported", c1.User.UserID, data.Imported,
+			len(data.Rows))
+
+		v.syncMembershipRoles(c.Request().Context())
+	default:
+		return v.invalidMethodUsed(c)
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.MembershipImportTemplate, templates.RegularType)
+}
+
+// MembershipTypeAddFunc adds a membership type
+func (v *Views) MembershipTypeAddFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		t, err := membershipTypeFromForm(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to parse form for membershipTypeAdd: %w", err))
+		}
+
+		_, err = v.membership.GetType(c.Request().Context(), membership.Type{Name: t.Name})
+		if err == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("membership type \"%s\" already exists", t.Name))
+		}
+
+		_, err = v.membership.AddType(c.Request().Context(), t)
+		if err != nil {
+			return fmt.Errorf("failed to add type for membershipTypeAdd: %w", err)
+		}
+
+		v.syncMembershipRoles(c.Request().Context())
+
+		return c.Redirect(http.StatusFound, "/internal/memberships")
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// MembershipTypeEditFunc edits a membership type, changing its role moves the paid members to the new one
+func (v *Views) MembershipTypeEditFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		typeID, err := strconv.Atoi(c.Param("typeid"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get typeid for membershipTypeEdit: %w", err))
+		}
+
+		existing, err := v.membership.GetType(c.Request().Context(), membership.Type{TypeID: typeID})
+		if err != nil {
+			return fmt.Errorf("failed to get type for membershipTypeEdit: %w", err)
+		}
+
+		t, err := membershipTypeFromForm(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to parse form for membershipTypeEdit: %w", err))
+		}
+
+		t.TypeID = existing.TypeID
+
+		_, err = v.membership.EditType(c.Request().Context(), t)
+		if err != nil {
+			return fmt.Errorf("failed to edit type for membershipTypeEdit: %w", err)
+		}
+
+		v.syncMembershipRoles(c.Request().Context())
+
+		return c.Redirect(http.StatusFound, "/internal/memberships")
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// MembershipTypeDeleteFunc deletes a membership type that doesn't have any memberships
+func (v *Views) MembershipTypeDeleteFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		typeID, err := strconv.Atoi(c.Param("typeid"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to get typeid for membershipTypeDelete: %w", err))
+		}
+
+		t, err := v.membership.GetType(c.Request().Context(), membership.Type{TypeID: typeID})
+		if err != nil {
+			return fmt.Errorf("failed to get type for membershipTypeDelete: %w", err)
+		}
+
+		err = v.membership.DeleteType(c.Request().Context(), t)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to delete type, it may still have memberships: %w", err))
+		}
+
+		return c.Redirect(http.StatusFound, "/internal/memberships")
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// membershipTypeFromForm reads a membership type from the add and edit forms, a blank role gives no role
+func membershipTypeFromForm(c echo.Context) (membership.Type, error) {
+	t := membership.Type{
+		Name:        strings.TrimSpace(c.FormValue("name")),
+		Description: c.FormValue("description"),
+	}
+
+	if t.Name == "" {
+		return membership.Type{}, errors.New("name must be set")
+	}
+
+	if c.FormValue("roleID") != "" {
+		roleID, err := strconv.Atoi(c.FormValue("roleID"))
+		if err != nil {
+			return membership.Type{}, fmt.Errorf("failed to parse roleID: %w", err)
+		}
+
+		t.RoleID = null.IntFrom(int64(roleID))
+	}
+
+	return t, nil
+}
+
+// findUser returns the user with the username or email
+func (v *Views) findUser(ctx context.Context, s string) (user.User, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return user.User{}, errors.New("username or email must be set")
+	}
+
+	u, err := v.user.GetUser(ctx, user.User{Username: s})
+	if err == nil {
+		return u, nil
+	}
+
+	u, err = v.user.GetUser(ctx, user.User{Email: s})
+	if err == nil {
+		return u, nil
+	}
+
+	return user.User{}, fmt.Errorf("no user has the username or email \"%s\"", s)
+}
+
+// syncMembershipRoles applies a membership change to the roles straight away rather than waiting for the
+// background sync, a failure is only logged as the background sync tries again
+func (v *Views) syncMembershipRoles(ctx context.Context) {
+	_, err := v.membership.SyncRoles(ctx)
+	if err != nil {
+		log.Printf("failed to sync membership roles: %+v", err)
+	}
+}
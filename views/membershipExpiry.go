@@ -0,0 +1,57 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ystv/web-auth/emailtemplate"
+)
+
+// membershipExpiryWarning is how long before a paid membership ends that the member is emailed
+const membershipExpiryWarning = 14 * 24 * time.Hour
+
+// processMemberships syncs the membership type roles and reminds the members whose membership ends within two
+// weeks to renew it, this is run in the background
+func (v *Views) processMemberships(ctx context.Context) error {
+	synced, err := v.membership.SyncRoles(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to sync membership roles: %w", err)
+	}
+
+	for _, a := range synced {
+		log.Printf("membership role sync: %s role id %d for user id %d", a.Action, a.RoleID, a.UserID)
+	}
+
+	expiring, err := v.membership.GetExpiringBefore(ctx, time.Now().Add(membershipExpiryWarning))
+	if err != nil {
+		return fmt.Errorf("failed to get expiring memberships: %w", err)
+	}
+
+	if len(expiring) == 0 {
+		return nil
+	}
+
+	for _, m := range expiring {
+		err := v.queueMail(ctx, emailtemplate.MembershipExpiry, m.Language.String, m.Email,
+			emailtemplate.MembershipExpiryData{
+				Name:         m.Firstname,
+				Type:         m.TypeName,
+				AcademicYear: m.AcademicYear,
+				PaidUntil:    m.PaidUntil.Format("02/01/2006"),
+			})
+		if err != nil {
+			log.Printf("failed to queue membership expiry email to user id %d: %+v", m.UserID, err)
+
+			continue
+		}
+
+		err = v.membership.SetReminded(ctx, m)
+		if err != nil {
+			log.Printf("failed to set membership reminded for membership id %d: %+v", m.MembershipID, err)
+		}
+	}
+
+	return nil
+}
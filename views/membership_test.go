@@ -0,0 +1,149 @@
+package views
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/emailtemplate"
+	mockemailtemplate "github.com/ystv/web-auth/emailtemplate/mocks"
+	"github.com/ystv/web-auth/infrastructure/mail"
+	"github.com/ystv/web-auth/mailqueue"
+	mockmailqueue "github.com/ystv/web-auth/mailqueue/mocks"
+	"github.com/ystv/web-auth/membership"
+	mockmembership "github.com/ystv/web-auth/membership/mocks"
+	"github.com/ystv/web-auth/user"
+	mockuser "github.com/ystv/web-auth/user/mocks"
+)
+
+func TestProcessMemberships(t *testing.T) {
+	expiring := membership.Membership{
+		MembershipID: 1,
+		UserID:       1,
+		AcademicYear: "2025/26",
+		PaidUntil:    time.Date(2026, time.September, 1, 0, 0, 0, 0, time.UTC),
+		TypeName:     "Full",
+		Firstname:    "Jane",
+		Email:        "jane.doe@ystv.co.uk",
+	}
+	failing := membership.Membership{MembershipID: 2, UserID: 2, Email: "john.doe@ystv.co.uk"}
+
+	ctr := gomock.NewController(t)
+	mockMembership := mockmembership.NewMockRepo(ctr)
+	mockEmailTemplate := mockemailtemplate.NewMockRepo(ctr)
+	mockMailQueue := mockmailqueue.NewMockRepo(ctr)
+
+	mockMembership.EXPECT().SyncRoles(gomock.Any()).Return([]membership.RoleSyncAction{
+		{Action: membership.RoleSyncGrant, RoleID: 3, UserID: 1},
+		{Action: membership.RoleSyncRevoke, RoleID: 3, UserID: 4},
+	}, nil)
+	mockMembership.EXPECT().GetExpiringBefore(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, before time.Time) ([]membership.Membership, error) {
+			assert.WithinDuration(t, time.Now().Add(membershipExpiryWarning), before, time.Minute)
+
+			return []membership.Membership{failing, expiring}, nil
+		})
+
+	mockEmailTemplate.EXPECT().Mail(gomock.Any(), emailtemplate.MembershipExpiry, "", failing.Email, gomock.Any()).
+		Return(mail.Mail{}, errors.New("failed"))
+	mockEmailTemplate.EXPECT().Mail(gomock.Any(), emailtemplate.MembershipExpiry, "", expiring.Email,
+		emailtemplate.MembershipExpiryData{
+			Name:         "Jane",
+			Type:         "Full",
+			AcademicYear: "2025/26",
+			PaidUntil:    "01/09/2026",
+		}).Return(mail.Mail{}, nil)
+	mockMailQueue.EXPECT().Queue(gomock.Any(), gomock.Any()).Return(mailqueue.Message{}, nil)
+
+	// the member who couldn't be emailed is reminded next time
+	mockMembership.EXPECT().SetReminded(gomock.Any(), expiring).Return(nil)
+
+	v := &Views{
+		membership:    mockMembership,
+		emailTemplate: mockEmailTemplate,
+		mailQueue:     mockMailQueue,
+	}
+
+	require.NoError(t, v.processMemberships(context.Background()))
+}
+
+func TestMembershipAdd(t *testing.T) {
+	admin := user.User{UserID: 2}
+	member := user.User{UserID: 1, Email: "jane.doe@ystv.co.uk"}
+
+	setup := func(t *testing.T) (*Views, *mockmembership.MockRepo) {
+		ctr := gomock.NewController(t)
+		mockMembership := mockmembership.NewMockRepo(ctr)
+		mockUser := mockuser.NewMockRepo(ctr)
+
+		// the user is looked up by username first then by email
+		mockUser.EXPECT().GetUser(gomock.Any(), user.User{Username: "jane.doe@ystv.co.uk"}).
+			Return(user.User{}, sql.ErrNoRows)
+		mockUser.EXPECT().GetUser(gomock.Any(), user.User{Email: "jane.doe@ystv.co.uk"}).Return(member, nil)
+
+		v := newTestViews()
+		v.membership = mockMembership
+		v.user = mockUser
+
+		return v, mockMembership
+	}
+
+	form := url.Values{
+		"user":         {" Jane.Doe@ystv.co.uk "},
+		"typeID":       {"3"},
+		"academicYear": {"2026/27"},
+		"paidAt":       {"01/10/2026"},
+		"amount":       {"£5.50"},
+		"reference":    {"SU-1234"},
+	}
+
+	t.Run("Paid", func(t *testing.T) {
+		v, mockMembership := setup(t)
+
+		mockMembership.EXPECT().AddMembership(gomock.Any(), membership.Membership{
+			UserID:       1,
+			TypeID:       3,
+			AcademicYear: "2026/27",
+			PaidAt:       null.TimeFrom(time.Date(2026, time.October, 1, 0, 0, 0, 0, time.Local)),
+			PaidUntil:    time.Date(2027, time.September, 1, 0, 0, 0, 0, time.Local),
+			AmountPence:  550,
+			Source:       membership.Manual,
+			Reference:    "SU-1234",
+			CreatedBy:    null.IntFrom(2),
+		}).Return(membership.Membership{MembershipID: 1}, nil)
+		// the roles are synced straight away rather than waiting for the background sync
+		mockMembership.EXPECT().SyncRoles(gomock.Any()).Return(nil, nil)
+
+		c, rec := newTestContext(t, v, admin, form)
+
+		require.NoError(t, v.MembershipAddFunc(c))
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "/internal/memberships?year=2026/27", rec.Header().Get(echo.HeaderLocation))
+	})
+
+	t.Run("SyncFails", func(t *testing.T) {
+		v, mockMembership := setup(t)
+
+		mockMembership.EXPECT().AddMembership(gomock.Any(), gomock.Any()).
+			Return(membership.Membership{MembershipID: 1}, nil)
+		// the background sync tries again so the membership is still added
+		mockMembership.EXPECT().SyncRoles(gomock.Any()).Return(nil, errors.New("failed"))
+
+		c, rec := newTestContext(t, v, admin, form)
+
+		require.NoError(t, v.MembershipAddFunc(c))
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+	})
+}
@@ -51,7 +51,7 @@ func (v *Views) RequiresLogin(next echo.HandlerFunc) echo.HandlerFunc {
 			return c.Redirect(http.StatusFound, "/login")
 		}
 
-		if userFromDB.DeletedBy.Valid || !c1.User.Enabled {
+		if userFromDB.DeletedBy.Valid || !c1.User.Enabled || userFromDB.Status == user.Suspended {
 			session.Values["user"] = &user.User{}
 			session.Options.MaxAge = -1
 
@@ -109,7 +109,7 @@ func (v *Views) RequiresLoginJSON(next echo.HandlerFunc) echo.HandlerFunc {
 			return c.JSON(http.StatusInternalServerError, data)
 		}
 
-		if userFromDB.DeletedBy.Valid || !c1.User.Enabled {
+		if userFromDB.DeletedBy.Valid || !c1.User.Enabled || userFromDB.Status == user.Suspended {
 			session.Values["user"] = &user.User{}
 			session.Options.MaxAge = -1
 
@@ -128,7 +128,7 @@ func (v *Views) RequiresLoginJSON(next echo.HandlerFunc) echo.HandlerFunc {
 			data := struct {
 				Error string `json:"error"`
 			}{
-				Error: "user deleted or not enabled",
+				Error: "user deleted, not enabled or suspended",
 			}
 
 			return c.JSON(http.StatusUnauthorized, data)
@@ -174,7 +174,7 @@ func (v *Views) RequiresLoginCrowd(next echo.HandlerFunc) echo.HandlerFunc {
 			return c.XML(http.StatusInternalServerError, data)
 		}
 
-		if userFromDB.DeletedBy.Valid || !c1.User.Enabled {
+		if userFromDB.DeletedBy.Valid || !c1.User.Enabled || userFromDB.Status == user.Suspended {
 			session.Values["user"] = &user.User{}
 			session.Options.MaxAge = -1
 
@@ -190,7 +190,7 @@ func (v *Views) RequiresLoginCrowd(next echo.HandlerFunc) echo.HandlerFunc {
 			}
 
 			data := XMLError{
-				Message: "user deleted or not enabled",
+				Message: "user deleted, not enabled or suspended",
 				Reason:  "USER_NOT_ENABLED",
 			}
 
@@ -276,15 +276,45 @@ func (v *Views) RequirePermission(p permissions.Permissions) echo.MiddlewareFunc
 				return fmt.Errorf("failed to get permissions for requirePermission: %w", err)
 			}
 
-			acceptedPerms := permission.SufficientPermissionsFor(p)
-
-			for _, perm := range perms {
-				if acceptedPerms[perm.Name] {
-					return next(c)
-				}
+			if permission.HasPermission(perms, p) {
+				return next(c)
 			}
 
 			return echo.NewHTTPError(http.StatusForbidden, errors.New("you are not authorised for accessing this"))
 		}
 	}
 }
+
+// RequiresAPIToken is a middleware for the API which checks the JWT in the Authorization header, the claims are
+// stored in the context for the handler
+func (v *Views) RequiresAPIToken(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		splitToken := strings.Split(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+		if len(splitToken) <= 1 || splitToken[1] == "" {
+			data := struct {
+				Error string `json:"error"`
+			}{
+				Error: "no bearer token provided",
+			}
+
+			return c.JSON(http.StatusUnauthorized, data)
+		}
+
+		valid, claims, err := v.ValidateToken(splitToken[1])
+		if err != nil || !valid {
+			log.Printf("failed to validate bearer token for requiresAPIToken: %+v", err)
+
+			data := struct {
+				Error string `json:"error"`
+			}{
+				Error: "invalid bearer token provided",
+			}
+
+			return c.JSON(http.StatusUnauthorized, data)
+		}
+
+		c.Set(apiClaimsKey, claims)
+
+		return next(c)
+	}
+}
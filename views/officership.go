@@ -150,6 +150,8 @@ func (v *Views) OfficershipAddFunc(c echo.Context) error {
 			isCurrent = true
 		}
 
+		coHeld := c.FormValue("coHeld") == "on"
+
 		if name == "" || emailAlias == "" || description == "" {
 			return c.Redirect(http.StatusFound, "/internal/officerships?error="+
 				url.QueryEscape("Name, email alias and description must be filled"))
@@ -175,6 +177,7 @@ func (v *Views) OfficershipAddFunc(c echo.Context) error {
 				Description:    description,
 				HistoryWikiURL: historyWikiURL,
 				IsCurrent:      isCurrent,
+				CoHeld:         coHeld,
 			})
 		if err != nil {
 			return errors.Errorf("failed to add officerships for addOfficership: %+v", err)
@@ -222,6 +225,7 @@ func (v *Views) OfficershipEditFunc(c echo.Context) error {
 		officership1.Description = description
 		officership1.HistoryWikiURL = historyWikiURL
 		officership1.IsCurrent = isCurrent
+		officership1.CoHeld = c.FormValue("coHeld") == "on"
 
 		_, err = v.officership.EditOfficership(c.Request().Context(), officership1)
 		if err != nil {
@@ -355,7 +359,7 @@ func (v *Views) _officersGet(c echo.Context) error {
 
 		var err error
 
-		users, _, err = v.user.GetUsers(c.Request().Context(), 0, 0, "", "", "", "", "not_deleted")
+		users, _, err = v.user.GetUsers(c.Request().Context(), 0, 0, "", "", "", "", "not_deleted", "")
 		if errArr != nil {
 			errArr = append(errArr, errors.Errorf("failed to get users: %+v", err))
 		}
@@ -451,7 +455,7 @@ func (v *Views) OfficerFunc(c echo.Context) error {
 			return errors.Errorf("failed to get officerships: %+v", err)
 		}
 
-		users, _, err := v.user.GetUsers(c.Request().Context(), 0, 0, "", "", "", "", "not_deleted")
+		users, _, err := v.user.GetUsers(c.Request().Context(), 0, 0, "", "", "", "", "not_deleted", "")
 		if err != nil {
 			return errors.Errorf("failed to get users: %+v", err)
 		}
@@ -0,0 +1,46 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/ystv/web-auth/emailtemplate"
+)
+
+// warnPasswordExpiry emails the users whose passwords expire soon, each is only emailed once until they change it,
+// this is run in the background
+func (v *Views) warnPasswordExpiry(ctx context.Context) error {
+	expiring, err := v.passwordPolicy.GetExpiring(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get expiring passwords: %w", err)
+	}
+
+	if len(expiring) == 0 {
+		return nil
+	}
+
+	for _, e := range expiring {
+		err := v.queueMail(ctx, emailtemplate.PasswordExpiry, e.Language.String, e.Email,
+			emailtemplate.PasswordExpiryData{
+				Name:       e.Firstname,
+				Role:       e.RoleName,
+				MaxAgeDays: e.MaxAgeDays,
+				ExpiresAt:  e.ExpiresAt.Format("02/01/2006"),
+				LocksAt:    e.LocksAt.Format("02/01/2006"),
+				URL:        fmt.Sprintf("https://%s/internal/settings", v.conf.DomainName),
+			})
+		if err != nil {
+			log.Printf("failed to queue password expiry email to user id %d: %+v", e.UserID, err)
+
+			continue
+		}
+
+		err = v.passwordPolicy.SetExpiryWarned(ctx, e)
+		if err != nil {
+			log.Printf("failed to set password expiry warned for user id %d: %+v", e.UserID, err)
+		}
+	}
+
+	return nil
+}
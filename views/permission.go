@@ -21,7 +21,8 @@ type (
 
 	// PermissionTemplate is for the permission front end
 	PermissionTemplate struct {
-		Permission user.PermissionTemplate
+		Permission  user.PermissionTemplate
+		Permissions []permission.Permission
 		TemplateHelper
 	}
 )
@@ -83,13 +84,29 @@ func (v *Views) PermissionFunc(c echo.Context) error {
 		return fmt.Errorf("failed to get roles for permission: %w", err)
 	}
 
+	permissionTemplate.ImpliedBy, err = v.permission.GetParentPermissions(c.Request().Context(), permission1)
+	if err != nil {
+		return fmt.Errorf("failed to get parent permissions for permission: %w", err)
+	}
+
+	permissionTemplate.Implies, err = v.permission.GetChildPermissions(c.Request().Context(), permission1)
+	if err != nil {
+		return fmt.Errorf("failed to get child permissions for permission: %w", err)
+	}
+
+	permissions, err := v.permission.GetPermissions(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get permissions for permission: %w", err)
+	}
+
 	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
 	if err != nil {
 		return fmt.Errorf("failed to get user permissions for permission: %w", err)
 	}
 
 	data := PermissionTemplate{
-		Permission: permissionTemplate,
+		Permission:  permissionTemplate,
+		Permissions: permissions,
 		TemplateHelper: TemplateHelper{
 			UserPermissions: p1,
 			ActivePage:      "permission",
@@ -153,11 +170,27 @@ func (v *Views) PermissionEditFunc(c echo.Context) error {
 			permission1.Description = description
 		}
 
+		parents, err := formPermissions(c.Request().Form["impliedBy"])
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to parse impliedBy: %w", err))
+		}
+
+		children, err := formPermissions(c.Request().Form["implies"])
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to parse implies: %w", err))
+		}
+
 		_, err = v.permission.EditPermission(c.Request().Context(), permission1)
 		if err != nil {
 			return fmt.Errorf("failed to edit permission for editPermission: %w", err)
 		}
 
+		err = v.permission.SetPermissionImplications(c.Request().Context(), permission1, parents, children)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to set permission hierarchy for editPermission: %w", err))
+		}
+
 		return c.Redirect(http.StatusFound, fmt.Sprintf("/internal/permission/%d", permissionID))
 	}
 
@@ -193,3 +226,19 @@ func (v *Views) PermissionDeleteFunc(c echo.Context) error {
 
 	return v.invalidMethodUsed(c)
 }
+
+// formPermissions converts the permission ids from a multiple select to permissions
+func formPermissions(values []string) ([]permission.Permission, error) {
+	perms := make([]permission.Permission, 0, len(values))
+
+	for _, value := range values {
+		permissionID, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, err
+		}
+
+		perms = append(perms, permission.Permission{PermissionID: permissionID})
+	}
+
+	return perms, nil
+}
@@ -3,17 +3,17 @@ package views
 import (
 	"errors"
 	"fmt"
-	"html/template"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/patrickmn/go-cache"
 	"gopkg.in/guregu/null.v4"
 
-	"github.com/ystv/web-auth/infrastructure/mail"
+	"github.com/ystv/web-auth/emailtemplate"
 	"github.com/ystv/web-auth/templates"
 	"github.com/ystv/web-auth/user"
 )
@@ -49,18 +49,24 @@ func (v *Views) ResetURLFunc(c echo.Context) error {
 	case http.MethodPost:
 		password := c.FormValue("password")
 		if password != c.FormValue("confirmpassword") {
-			return v.template.RenderTemplate(c.Response(), nil, templates.ResetTemplate, templates.NoNavType)
+			data1.Error = "passwords don't match"
+
+			return v.template.RenderTemplate(c.Response(), data1, templates.ResetTemplate, templates.NoNavType)
 		}
 
-		originalUser.Password = null.StringFrom(password)
+		problems, err := v.passwordPolicy.Check(c.Request().Context(), originalUser, password)
+		if err != nil {
+			return fmt.Errorf("failed to check password for reset: %w", err)
+		}
 
-		errString := minRequirementsMet(password)
-		if len(errString) > 0 {
-			data := struct{ Error string }{Error: errString}
+		if len(problems) > 0 {
+			data1.Error = strings.Join(problems, " and ")
 
-			return v.template.RenderTemplate(c.Response().Writer, data, templates.ResetTemplate, templates.NoNavType)
+			return v.template.RenderTemplate(c.Response(), data1, templates.ResetTemplate, templates.NoNavType)
 		}
 
+		originalUser.Password = null.StringFrom(password)
+
 		err = v.user.EditUserPassword(c.Request().Context(), originalUser)
 		if err != nil {
 			log.Printf("failed to reset user: %+v", err)
@@ -104,54 +110,25 @@ func (v *Views) ResetUserPasswordFunc(c echo.Context) error {
 		Error   error  `json:"error"`
 	}
 
-	mailer := v.mailer.ConnectMailer()
-
-	// Valid request, send email with reset code
-	if mailer != nil {
-		var emailTemplate *template.Template
-
-		emailTemplate, err = v.template.GetEmailTemplate(templates.ResetEmailTemplate)
-		if err != nil {
-			return fmt.Errorf("failed to render email for reset: %w", err)
-		}
-
-		file := mail.Mail{
-			Subject: "YSTV Security - Reset Password",
-			Tpl:     emailTemplate,
-			To:      userFromDB.Email,
-			From:    "YSTV Security <no-reply@ystv.co.uk>",
-			TplData: struct {
-				Email string
-				URL   string
-			}{
-				Email: userFromDB.Email,
-				URL:   fmt.Sprintf("https://%s/reset/%s", v.conf.DomainName, url),
-			},
-		}
-
-		err = mailer.SendMail(file)
-		if err != nil {
-			message.Message = fmt.Sprintf(`Please forward the link to this email: %s, reset link: 
+	// Valid request, queue email with reset code
+	err = v.queueMail(c.Request().Context(), emailtemplate.Reset, userFromDB.Language.String, userFromDB.Email,
+		emailtemplate.ResetData{
+			Email: userFromDB.Email,
+			URL:   fmt.Sprintf("https://%s/reset/%s", v.conf.DomainName, url),
+		})
+	if err != nil {
+		message.Message = fmt.Sprintf(`Please forward the link to this email: %s, reset link: 
 https://%s/reset/%s`, userFromDB.Email, v.conf.DomainName, url)
-			message.Error = fmt.Errorf("failed to send mail: %w", err)
-			log.Printf("failed to send mail: %+v", err)
-			log.Printf("password reset requested for email: %s by user: %d", userFromDB.Email, c1.User.UserID)
-
-			return c.JSON(http.StatusInternalServerError, message)
-		}
-
-		_ = mailer.Close()
-
-		log.Printf("password reset requested for email: %s by user: %d", userFromDB.Email, c1.User.UserID)
-		message.Message = fmt.Sprintf("Reset email sent to: \"%s\"", userFromDB.Email)
-	} else {
-		message.Message = fmt.Sprintf(`No mailer present\nPlease forward the link to this email: %s, 
-reset link: https://%s/reset/%s`, userFromDB.Email, v.conf.DomainName, url)
-		message.Error = errors.New("no mailer present")
-		log.Printf("no Mailer present")
+		message.Error = fmt.Errorf("failed to queue mail: %w", err)
+		log.Printf("failed to queue mail: %+v", err)
 		log.Printf("password reset requested for email: %s by user: %d", userFromDB.Email, c1.User.UserID)
+
+		return c.JSON(http.StatusInternalServerError, message)
 	}
 
+	log.Printf("password reset requested for email: %s by user: %d", userFromDB.Email, c1.User.UserID)
+	message.Message = fmt.Sprintf("Reset email queued to: \"%s\"", userFromDB.Email)
+
 	log.Printf("reset for %d (%s) requested by %d (%s)", userFromDB.UserID,
 		userFromDB.Firstname+" "+userFromDB.Lastname, c1.User.UserID, c1.User.Firstname+" "+c1.User.Lastname)
 
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"gopkg.in/guregu/null.v4"
 
 	"github.com/ystv/web-auth/permission"
 	"github.com/ystv/web-auth/role"
@@ -26,6 +28,9 @@ type (
 		Role                 user.RoleTemplate
 		PermissionsNotInRole []permission.Permission
 		UsersNotInRole       []user.User
+		RolesNotIncluded     []role.Role
+		Memberships          map[int]user.RoleUser
+		AllPermissions       []permission.Permission
 		TemplateHelper
 	}
 )
@@ -33,9 +38,12 @@ type (
 // bindRoleToTemplate converts from role.Role to user.RoleTemplate
 func (v *Views) bindRoleToTemplate(r1 role.Role) user.RoleTemplate {
 	return user.RoleTemplate{
-		RoleID:      r1.RoleID,
-		Name:        r1.Name,
-		Description: r1.Description,
+		RoleID:               r1.RoleID,
+		Name:                 r1.Name,
+		Description:          r1.Description,
+		Requestable:          r1.Requestable,
+		ApproverPermissionID: r1.ApproverPermissionID,
+		PasswordMaxAgeDays:   r1.PasswordMaxAgeDays,
 	}
 }
 
@@ -91,6 +99,21 @@ func (v *Views) RoleFunc(c echo.Context) error {
 		return fmt.Errorf("failed to get users for role: %w", err)
 	}
 
+	roleTemplate.IncludedRoles, err = v.role.GetIncludedRoles(c.Request().Context(), role1)
+	if err != nil {
+		return fmt.Errorf("failed to get included roles for role: %w", err)
+	}
+
+	roleTemplate.IncludingRoles, err = v.role.GetIncludingRoles(c.Request().Context(), role1)
+	if err != nil {
+		return fmt.Errorf("failed to get including roles for role: %w", err)
+	}
+
+	roleTemplate.EffectivePermissions, err = v.user.GetEffectivePermissionsForRole(c.Request().Context(), role1)
+	if err != nil {
+		return fmt.Errorf("failed to get effective permissions for role: %w", err)
+	}
+
 	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
 	if err != nil {
 		return fmt.Errorf("failed to get user permissions for role: %w", err)
@@ -106,10 +129,33 @@ func (v *Views) RoleFunc(c echo.Context) error {
 		return fmt.Errorf("failed to get users not in role for role: %w", err)
 	}
 
+	roles, err := v.role.GetRolesNotIncluded(c.Request().Context(), role1)
+	if err != nil {
+		return fmt.Errorf("failed to get roles not included for role: %w", err)
+	}
+
+	allPermissions, err := v.permission.GetPermissions(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get all permissions for role: %w", err)
+	}
+
+	roleUsers, err := v.user.GetRoleUsersForRole(c.Request().Context(), role1)
+	if err != nil {
+		return fmt.Errorf("failed to get role users for role: %w", err)
+	}
+
+	memberships := make(map[int]user.RoleUser, len(roleUsers))
+	for _, ru := range roleUsers {
+		memberships[ru.UserID] = ru
+	}
+
 	data := RoleTemplate{
 		Role:                 roleTemplate,
 		PermissionsNotInRole: permissions,
 		UsersNotInRole:       users,
+		RolesNotIncluded:     roles,
+		Memberships:          memberships,
+		AllPermissions:       allPermissions,
 		TemplateHelper: TemplateHelper{
 			UserPermissions: p1,
 			ActivePage:      "role",
@@ -171,6 +217,31 @@ func (v *Views) RoleEditFunc(c echo.Context) error {
 			role1.Description = description
 		}
 
+		role1.Requestable = c.Request().FormValue("requestable") == "on"
+		role1.ApproverPermissionID = null.Int{}
+
+		if approver := c.Request().FormValue("approverPermissionID"); approver != "" {
+			approverPermissionID, err := strconv.Atoi(approver)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest,
+					fmt.Errorf("failed to get approverPermissionID for editRole: %w", err))
+			}
+
+			role1.ApproverPermissionID = null.IntFrom(int64(approverPermissionID))
+		}
+
+		role1.PasswordMaxAgeDays = null.Int{}
+
+		if maxAge := c.Request().FormValue("passwordMaxAgeDays"); maxAge != "" {
+			passwordMaxAgeDays, err := strconv.Atoi(maxAge)
+			if err != nil || passwordMaxAgeDays < 1 {
+				return echo.NewHTTPError(http.StatusBadRequest,
+					fmt.Errorf("failed to get passwordMaxAgeDays for editRole: \"%s\" isn't a number of days", maxAge))
+			}
+
+			role1.PasswordMaxAgeDays = null.IntFrom(int64(passwordMaxAgeDays))
+		}
+
 		_, err = v.role.EditRole(c.Request().Context(), role1)
 		if err != nil {
 			return fmt.Errorf("failed to edit role for editRole: %w", err)
@@ -200,7 +271,7 @@ func (v *Views) RoleDeleteFunc(c echo.Context) error {
 			return fmt.Errorf("failed to delete rolePermission for deleteRole: %w", err)
 		}
 
-		err = v.role.RemoveRoleForUsers(c.Request().Context(), role1)
+		err = v.user.RemoveRoleForUsers(c.Request().Context(), role1)
 		if err != nil {
 			return fmt.Errorf("failed to delete roleUser for deleteRole: %w", err)
 		}
@@ -328,12 +399,21 @@ func (v *Views) RoleAddUserFunc(c echo.Context) error {
 		}
 
 		roleUser := user.RoleUser{
-			RoleID: roleID,
-			UserID: userID,
+			RoleID:    roleID,
+			UserID:    userID,
+			GrantedBy: null.IntFrom(int64(v.getSessionData(c).User.UserID)),
+			Reason:    c.Request().FormValue("reason"),
 		}
 
-		_, err = v.user.GetRoleUser(c.Request().Context(), roleUser)
-		if err == nil {
+		roleUser.StartsAt, roleUser.EndsAt, err = parseRoleUserWindow(c.Request().FormValue("startsAt"),
+			c.Request().FormValue("endsAt"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to parse dates for roleAddUser: %w", err))
+		}
+
+		// a membership given by the officership or membership sync is taken over by the grant
+		existing, err := v.user.GetRoleUser(c.Request().Context(), roleUser)
+		if err == nil && !existing.OfficershipSync && !existing.MembershipSync {
 			return errors.New("failed to add roleUser for roleAddUser: row already exists")
 		}
 
@@ -391,3 +471,105 @@ func (v *Views) RoleRemoveUserFunc(c echo.Context) error {
 
 	return v.invalidMethodUsed(c)
 }
+
+// RoleAddIncludedRoleFunc handles a role inclusion add request
+func (v *Views) RoleAddIncludedRoleFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		roleID, err := strconv.Atoi(c.Param("roleid"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get roleid for roleAddIncludedRole: %w", err))
+		}
+
+		_, err = v.role.GetRole(c.Request().Context(), role.Role{RoleID: roleID})
+		if err != nil {
+			return fmt.Errorf("failed to get role for roleAddIncludedRole: %w", err)
+		}
+
+		includedRoleID, err := strconv.Atoi(c.Request().FormValue("includedRoleID"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to get includedRoleID for roleAddIncludedRole: %w", err))
+		}
+
+		_, err = v.role.GetRole(c.Request().Context(), role.Role{RoleID: includedRoleID})
+		if err != nil {
+			return fmt.Errorf("failed to get included role for roleAddIncludedRole: %w", err)
+		}
+
+		_, err = v.role.AddRoleInclusion(c.Request().Context(), role.RoleInclusion{
+			RoleID:         roleID,
+			IncludedRoleID: includedRoleID,
+		})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to add role inclusion for roleAddIncludedRole: %w", err))
+		}
+
+		return c.Redirect(http.StatusFound, fmt.Sprintf("/internal/role/%d", roleID))
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// RoleRemoveIncludedRoleFunc handles a role inclusion remove request
+func (v *Views) RoleRemoveIncludedRoleFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		roleID, err := strconv.Atoi(c.Param("roleid"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to get roleid for roleRemoveIncludedRole: %w", err))
+		}
+
+		includedRoleID, err := strconv.Atoi(c.Param("includedroleid"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to get includedroleid for roleRemoveIncludedRole: %w", err))
+		}
+
+		err = v.role.RemoveRoleInclusion(c.Request().Context(), role.RoleInclusion{
+			RoleID:         roleID,
+			IncludedRoleID: includedRoleID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to remove role inclusion for roleRemoveIncludedRole: %w", err)
+		}
+
+		return c.Redirect(http.StatusFound, fmt.Sprintf("/internal/role/%d", roleID))
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// parseRoleUserWindow parses the optional dates of a temporary membership, it starts at the beginning of the start
+// date and ends at the end of the end date
+func parseRoleUserWindow(startsAt, endsAt string) (null.Time, null.Time, error) {
+	var start, end null.Time
+
+	if startsAt != "" {
+		t, err := time.Parse("02/01/2006", startsAt)
+		if err != nil {
+			return null.Time{}, null.Time{}, fmt.Errorf("failed to parse start date: %w", err)
+		}
+
+		start = null.TimeFrom(t)
+	}
+
+	if endsAt != "" {
+		t, err := time.Parse("02/01/2006", endsAt)
+		if err != nil {
+			return null.Time{}, null.Time{}, fmt.Errorf("failed to parse end date: %w", err)
+		}
+
+		end = null.TimeFrom(t.AddDate(0, 0, 1))
+
+		if !end.Time.After(time.Now()) {
+			return null.Time{}, null.Time{}, errors.New("end date can't be in the past")
+		}
+	}
+
+	if start.Valid && end.Valid && !start.Time.Before(end.Time) {
+		return null.Time{}, null.Time{}, errors.New("start date must be before the end date")
+	}
+
+	return start, end, nil
+}
@@ -0,0 +1,57 @@
+package views
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ystv/web-auth/emailtemplate"
+)
+
+// roleExpiryWarning is how long before a temporary role membership ends that the user is emailed
+const roleExpiryWarning = 7 * 24 * time.Hour
+
+// expireRoleUsers removes the temporary role memberships that have ended and warns the users whose memberships end
+// within a week, this is run in the background
+func (v *Views) expireRoleUsers(ctx context.Context) error {
+	removed, err := v.user.RemoveExpiredRoleUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to remove expired role users: %w", err)
+	}
+
+	for _, ru := range removed {
+		log.Printf("removed expired membership of role id %d for user id %d", ru.RoleID, ru.UserID)
+	}
+
+	expiring, err := v.user.GetRoleUsersExpiringBefore(ctx, time.Now().Add(roleExpiryWarning))
+	if err != nil {
+		return fmt.Errorf("failed to get expiring role users: %w", err)
+	}
+
+	if len(expiring) == 0 {
+		return nil
+	}
+
+	for _, ru := range expiring {
+		err := v.queueMail(ctx, emailtemplate.RoleExpiry, ru.Language.String, ru.Email,
+			emailtemplate.RoleExpiryData{
+				Name:   ru.Firstname,
+				Role:   ru.RoleName,
+				EndsAt: ru.EndsAt.Time.Format("02/01/2006 15:04"),
+				Reason: ru.Reason,
+			})
+		if err != nil {
+			log.Printf("failed to queue role expiry email to user id %d: %+v", ru.UserID, err)
+
+			continue
+		}
+
+		err = v.user.SetRoleUserExpiryNotified(ctx, ru.RoleUser)
+		if err != nil {
+			log.Printf("failed to set role expiry notified for user id %d: %+v", ru.UserID, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/emailtemplate"
+	mockemailtemplate "github.com/ystv/web-auth/emailtemplate/mocks"
+	"github.com/ystv/web-auth/infrastructure/mail"
+	"github.com/ystv/web-auth/mailqueue"
+	mockmailqueue "github.com/ystv/web-auth/mailqueue/mocks"
+	"github.com/ystv/web-auth/user"
+	mockuser "github.com/ystv/web-auth/user/mocks"
+)
+
+func TestExpireRoleUsers(t *testing.T) {
+	endsAt := time.Date(2026, 10, 22, 18, 30, 0, 0, time.UTC)
+
+	expiring := user.RoleUserExpiry{
+		RoleUser:  user.RoleUser{RoleID: 3, UserID: 1, EndsAt: null.TimeFrom(endsAt), Reason: "Freshers' fair"},
+		RoleName:  "Editor",
+		Firstname: "Jane",
+		Email:     "jane.doe@ystv.co.uk",
+	}
+	failing := user.RoleUserExpiry{
+		RoleUser: user.RoleUser{RoleID: 3, UserID: 2, EndsAt: null.TimeFrom(endsAt)},
+		Email:    "john.doe@ystv.co.uk",
+	}
+
+	ctr := gomock.NewController(t)
+	mockUser := mockuser.NewMockRepo(ctr)
+	mockEmailTemplate := mockemailtemplate.NewMockRepo(ctr)
+	mockMailQueue := mockmailqueue.NewMockRepo(ctr)
+
+	mockUser.EXPECT().RemoveExpiredRoleUsers(gomock.Any()).Return([]user.RoleUser{{RoleID: 3, UserID: 4}}, nil)
+	mockUser.EXPECT().GetRoleUsersExpiringBefore(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, before time.Time) ([]user.RoleUserExpiry, error) {
+			assert.WithinDuration(t, time.Now().Add(roleExpiryWarning), before, time.Minute)
+
+			return []user.RoleUserExpiry{failing, expiring}, nil
+		})
+
+	mockEmailTemplate.EXPECT().Mail(gomock.Any(), emailtemplate.RoleExpiry, "", failing.Email, gomock.Any()).
+		Return(mail.Mail{}, errors.New("failed"))
+	mockEmailTemplate.EXPECT().Mail(gomock.Any(), emailtemplate.RoleExpiry, "", expiring.Email,
+		emailtemplate.RoleExpiryData{
+			Name:   "Jane",
+			Role:   "Editor",
+			EndsAt: "22/10/2026 18:30",
+			Reason: "Freshers' fair",
+		}).Return(mail.Mail{}, nil)
+	mockMailQueue.EXPECT().Queue(gomock.Any(), gomock.Any()).Return(mailqueue.Message{}, nil)
+
+	// only the user who was emailed is marked as warned, the other is tried again next time
+	mockUser.EXPECT().SetRoleUserExpiryNotified(gomock.Any(), expiring.RoleUser).Return(nil)
+
+	v := &Views{
+		user:          mockUser,
+		emailTemplate: mockEmailTemplate,
+		mailQueue:     mockMailQueue,
+	}
+
+	require.NoError(t, v.expireRoleUsers(context.Background()))
+}
+
+func TestExpireRoleUsersRemoveFails(t *testing.T) {
+	ctr := gomock.NewController(t)
+	mockUser := mockuser.NewMockRepo(ctr)
+
+	mockUser.EXPECT().RemoveExpiredRoleUsers(gomock.Any()).Return(nil, errors.New("failed"))
+
+	v := &Views{user: mockUser}
+
+	assert.Error(t, v.expireRoleUsers(context.Background()))
+}
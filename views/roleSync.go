@@ -0,0 +1,61 @@
+package views
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/ystv/web-auth/officership"
+	"github.com/ystv/web-auth/templates"
+)
+
+// OfficershipRoleSyncTemplate represents the role sync report, Applied is set once a sync has been run
+type OfficershipRoleSyncTemplate struct {
+	Actions []officership.RoleSyncAction
+	Applied bool
+	Message string
+	TemplateHelper
+}
+
+// OfficershipRoleSyncFunc shows the role changes the sync would make, a POST runs the sync
+func (v *Views) OfficershipRoleSyncFunc(c echo.Context) error {
+	c1 := v.getSessionData(c)
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return errors.Errorf("failed to get user permissions for role sync: %+v", err)
+	}
+
+	data := OfficershipRoleSyncTemplate{
+		TemplateHelper: TemplateHelper{
+			UserPermissions: p1,
+			ActivePage:      "officershipRoleSync",
+			Assumed:         c1.Assumed,
+		},
+	}
+
+	switch c.Request().Method {
+	case http.MethodGet:
+		data.Actions, err = v.officership.GetRoleSyncActions(c.Request().Context())
+		if err != nil {
+			return errors.Errorf("failed to get role sync actions: %+v", err)
+		}
+	case http.MethodPost:
+		data.Actions, err = v.officership.SyncRoles(c.Request().Context())
+		if err != nil {
+			return errors.Errorf("failed to sync roles: %+v", err)
+		}
+
+		data.Applied = true
+		data.Message = fmt.Sprintf("Role sync run, %d changes made", len(data.Actions))
+
+		log.Printf("officership role sync run by user id: %d, %d changes made", c1.User.UserID, len(data.Actions))
+	default:
+		return v.invalidMethodUsed(c)
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.OfficershipRoleSyncTemplate, templates.RegularType)
+}
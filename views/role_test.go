@@ -0,0 +1,85 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/ystv/web-auth/role"
+	mockrole "github.com/ystv/web-auth/role/mocks"
+	"github.com/ystv/web-auth/user"
+	mockuser "github.com/ystv/web-auth/user/mocks"
+)
+
+func TestRoleAddUser(t *testing.T) {
+	admin := user.User{UserID: 2}
+	form := url.Values{"userID": {"1"}, "reason": {"Editing"}}
+
+	setup := func(t *testing.T) (*Views, *mockuser.MockRepo) {
+		ctr := gomock.NewController(t)
+		mockRole := mockrole.NewMockRepo(ctr)
+		mockUser := mockuser.NewMockRepo(ctr)
+
+		mockRole.EXPECT().GetRole(gomock.Any(), role.Role{RoleID: 3}).Return(role.Role{RoleID: 3}, nil)
+		mockUser.EXPECT().GetUser(gomock.Any(), user.User{UserID: 1}).Return(user.User{UserID: 1}, nil)
+
+		v := newTestViews()
+		v.role = mockRole
+		v.user = mockUser
+
+		return v, mockUser
+	}
+
+	t.Run("New", func(t *testing.T) {
+		v, mockUser := setup(t)
+
+		mockUser.EXPECT().GetRoleUser(gomock.Any(), gomock.Any()).Return(user.RoleUser{}, errors.New("no rows"))
+		mockUser.EXPECT().AddRoleUser(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, ru user.RoleUser) (user.RoleUser, error) {
+				assert.Equal(t, 3, ru.RoleID)
+				assert.Equal(t, 1, ru.UserID)
+				assert.Equal(t, int64(2), ru.GrantedBy.Int64)
+				assert.Equal(t, "Editing", ru.Reason)
+
+				return ru, nil
+			})
+
+		c, rec := newTestContext(t, v, admin, form, "roleid", "3")
+
+		err := v.RoleAddUserFunc(c)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+	})
+
+	t.Run("TakesOverSynced", func(t *testing.T) {
+		v, mockUser := setup(t)
+
+		// the officership sync gave the role, granting it by hand means the sync won't revoke it
+		mockUser.EXPECT().GetRoleUser(gomock.Any(), gomock.Any()).
+			Return(user.RoleUser{RoleID: 3, UserID: 1, OfficershipSync: true}, nil)
+		mockUser.EXPECT().AddRoleUser(gomock.Any(), gomock.Any()).Return(user.RoleUser{RoleID: 3, UserID: 1}, nil)
+
+		c, _ := newTestContext(t, v, admin, form, "roleid", "3")
+
+		err := v.RoleAddUserFunc(c)
+		require.NoError(t, err)
+	})
+
+	t.Run("AlreadyMember", func(t *testing.T) {
+		v, mockUser := setup(t)
+
+		mockUser.EXPECT().GetRoleUser(gomock.Any(), gomock.Any()).Return(user.RoleUser{RoleID: 3, UserID: 1}, nil)
+
+		c, _ := newTestContext(t, v, admin, form, "roleid", "3")
+
+		err := v.RoleAddUserFunc(c)
+		assert.Error(t, err)
+	})
+}
@@ -15,17 +15,25 @@ import (
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/dustin/go-humanize"
 	"github.com/labstack/echo/v4"
+	"gopkg.in/guregu/null.v4"
 
+	"github.com/ystv/web-auth/emailchange"
+	"github.com/ystv/web-auth/emailtemplate"
 	"github.com/ystv/web-auth/templates"
 	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/useremail"
 )
 
 type (
 	// SettingsTemplate is for the settings front end
 	SettingsTemplate struct {
-		User      user.User
-		LastLogin string
-		Gravatar  string
+		User         user.User
+		LastLogin    string
+		Gravatar     string
+		PendingEmail *emailchange.Change
+		Emails       []useremail.Email
+		Languages    []string
+		Language     string
 		TemplateHelper
 	}
 )
@@ -56,11 +64,32 @@ func (v *Views) SettingsFunc(c echo.Context) error {
 			c1.User.Lastname = lastName
 		}
 
-		err := v.user.EditUser(c.Request().Context(), c1.User, c1.User.UserID)
+		c1.User.HideFromPublic = c.Request().FormValue("hideFromPublic") == "on"
+
+		// the email in the session may be from before an email change so the one in the database is kept
+		current, err := v.user.GetUser(c.Request().Context(), user.User{UserID: c1.User.UserID})
+		if err != nil {
+			return fmt.Errorf("failed to get user for settings: %w", err)
+		}
+
+		c1.User.Email = current.Email
+
+		// the default language is stored as null so users follow it if it changes
+		language, err := emailtemplate.CanonicalLanguage(c.Request().FormValue("language"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err)
+		}
+
+		c1.User.Language = null.NewString(language, language != emailtemplate.DefaultLanguage)
+
+		err = v.user.EditUser(c.Request().Context(), c1.User, c1.User.UserID)
 		if err != nil {
 			return fmt.Errorf("failed to edit user for settings: %w", err)
 		}
 
+		// the public directory shows the names and leaves out hidden users, so hiding is seen straight away
+		v.cache.Delete(officerDirectoryCacheKey)
+
 		session.Values["user"] = c1.User
 
 		err = session.Save(c.Request(), c.Response())
@@ -84,15 +113,41 @@ func (v *Views) SettingsFunc(c echo.Context) error {
 		gravatar = "https://www.gravatar.com/avatar/" + hex.EncodeToString(hash[:])
 	}
 
+	var pendingEmail *emailchange.Change
+
+	change, err := v.emailChange.GetPendingChange(c.Request().Context(), c1.User)
+	if err == nil && time.Now().Before(change.ExpiresAt) {
+		pendingEmail = &change
+	}
+
+	emails, err := v.userEmail.GetEmailsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return fmt.Errorf("failed to get emails for settings: %w", err)
+	}
+
+	languages, err := v.emailTemplate.GetLanguages(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get languages for settings: %w", err)
+	}
+
+	language := emailtemplate.DefaultLanguage
+	if c1.User.Language.Valid {
+		language = c1.User.Language.String
+	}
+
 	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
 	if err != nil {
 		return fmt.Errorf("failed to get user permissions for settings: %w", err)
 	}
 
 	ctx := SettingsTemplate{
-		User:      c1.User,
-		LastLogin: humanize.Time(lastLogin),
-		Gravatar:  gravatar,
+		User:         c1.User,
+		LastLogin:    humanize.Time(lastLogin),
+		Gravatar:     gravatar,
+		PendingEmail: pendingEmail,
+		Emails:       emails,
+		Languages:    languages,
+		Language:     language,
 		TemplateHelper: TemplateHelper{
 			UserPermissions: p1,
 			ActivePage:      "settings",
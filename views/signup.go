@@ -3,6 +3,7 @@ package views
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/schema"
 	"github.com/labstack/echo/v4"
@@ -18,8 +19,8 @@ type UserSignup struct {
 	Firstname       string `db:"first_name" schema:"firstname" validate:"required,gte=3"`
 	Lastname        string `db:"last_name" schema:"lastname" validate:"required,gte=3"`
 	Email           string `db:"email" schema:"email" validate:"required,email"`
-	Password        string `db:"password" schema:"password" validate:"required,gte=8"`
-	ConfirmPassword string `schema:"confirmpassword" validate:"required,eqfield=Password,gte=8"`
+	Password        string `db:"password" schema:"password" validate:"required"`
+	ConfirmPassword string `schema:"confirmpassword" validate:"required,eqfield=Password"`
 }
 
 // SignUpFunc will enable new users to sign up to our service
@@ -42,10 +43,22 @@ func (v *Views) SignUpFunc(c echo.Context) error {
 		}
 
 		uNormal := user.User{
-			Email: uSignup.Email,
+			Email:     uSignup.Email,
+			Firstname: uSignup.Firstname,
+			Lastname:  uSignup.Lastname,
 		}
 
-		_, err = v.user.GetUser(c.Request().Context(), uNormal)
+		problems, err := v.passwordPolicy.Check(c.Request().Context(), uNormal, uSignup.Password)
+		if err != nil {
+			return fmt.Errorf("failed to check password for signup: %w", err)
+		}
+
+		if len(problems) > 0 {
+			return v.template.RenderTemplate(c.Response(), strings.Join(problems, " and "), templates.SignupTemplate,
+				templates.NoNavType)
+		}
+
+		_, err = v.user.GetExistingUser(c.Request().Context(), uNormal)
 		if err == nil {
 			return v.template.RenderTemplate(c.Response(), "Account already exists", templates.SignupTemplate,
 				templates.NoNavType)
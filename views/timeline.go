@@ -0,0 +1,266 @@
+package views
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/ystv/web-auth/officership"
+	"github.com/ystv/web-auth/templates"
+	"github.com/ystv/web-auth/utils"
+)
+
+type (
+	// OfficershipTimelineTemplate represents the officer timeline and reporting page
+	OfficershipTimelineTemplate struct {
+		Year          int
+		OfficershipID int
+		UserID        int
+		Years         []int
+		Officerships  []officership.Officership
+		Users         []TimelineUser
+		Terms         []officership.OfficershipMember
+		Issues        []officership.TermIssue
+		Query         template.URL
+		TemplateHelper
+	}
+
+	// TimelineUser is a user who has held an officership, used for picking a user's timeline
+	TimelineUser struct {
+		UserID   int
+		UserName string
+	}
+
+	// TimelineExport is the JSON export of the officer timeline
+	TimelineExport struct {
+		Year          int                             `json:"year,omitempty"`
+		OfficershipID int                             `json:"officershipID,omitempty"`
+		UserID        int                             `json:"userID,omitempty"`
+		Terms         []officership.OfficershipMember `json:"terms"`
+		Issues        []officership.TermIssue         `json:"issues"`
+	}
+)
+
+// OfficershipTimelineFunc shows who held which officership, filtered by academic year, officership or user,
+// along with any gaps or overlaps between terms. ?format=csv or ?format=json exports it instead
+func (v *Views) OfficershipTimelineFunc(c echo.Context) error {
+	if c.Request().Method != http.MethodGet {
+		return v.invalidMethodUsed(c)
+	}
+
+	c1 := v.getSessionData(c)
+
+	var err error
+
+	data := OfficershipTimelineTemplate{}
+
+	data.Year, err = timelineParam(c, "year")
+	if err != nil {
+		return err
+	}
+
+	data.OfficershipID, err = timelineParam(c, "officershipID")
+	if err != nil {
+		return err
+	}
+
+	data.UserID, err = timelineParam(c, "userID")
+	if err != nil {
+		return err
+	}
+
+	if data.Year == 0 && data.OfficershipID == 0 && data.UserID == 0 {
+		data.Year = officership.AcademicYear(time.Now())
+	}
+
+	members, err := v.officership.GetOfficershipMembers(c.Request().Context(), nil, nil, officership.Any,
+		officership.Any, true)
+	if err != nil {
+		return errors.Errorf("failed to get officers for timeline: %+v", err)
+	}
+
+	data.Terms, data.Issues = filterTimeline(members, data.Year, data.OfficershipID, data.UserID)
+
+	switch c.QueryParam("format") {
+	case "":
+	case "json":
+		return c.JSON(http.StatusOK, TimelineExport{
+			Year:          data.Year,
+			OfficershipID: data.OfficershipID,
+			UserID:        data.UserID,
+			Terms:         data.Terms,
+			Issues:        data.Issues,
+		})
+	case "csv":
+		return timelineCSV(c, data.Terms)
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, errors.New("format must be set to either \"csv\" or \"json\""))
+	}
+
+	data.Officerships, err = v.officership.GetOfficerships(c.Request().Context(), officership.Any)
+	if err != nil {
+		return errors.Errorf("failed to get officerships for timeline: %+v", err)
+	}
+
+	seen := make(map[int]bool)
+	firstYear := officership.AcademicYear(time.Now())
+
+	for _, m := range members {
+		if !seen[m.UserID] {
+			seen[m.UserID] = true
+
+			data.Users = append(data.Users, TimelineUser{UserID: m.UserID, UserName: m.UserName})
+		}
+
+		if m.StartDate.Valid && officership.AcademicYear(m.StartDate.Time) < firstYear {
+			firstYear = officership.AcademicYear(m.StartDate.Time)
+		}
+	}
+
+	sort.Slice(data.Users, func(i, j int) bool {
+		return data.Users[i].UserName < data.Users[j].UserName
+	})
+
+	for year := officership.AcademicYear(time.Now()); year >= firstYear; year-- {
+		data.Years = append(data.Years, year)
+	}
+
+	q := url.Values{}
+	if data.Year != 0 {
+		q.Set("year", strconv.Itoa(data.Year))
+	}
+
+	if data.OfficershipID != 0 {
+		q.Set("officershipID", strconv.Itoa(data.OfficershipID))
+	}
+
+	if data.UserID != 0 {
+		q.Set("userID", strconv.Itoa(data.UserID))
+	}
+
+	// #nosec
+	data.Query = template.URL(q.Encode())
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return errors.Errorf("failed to get user permissions for timeline: %+v", err)
+	}
+
+	data.TemplateHelper = TemplateHelper{
+		UserPermissions: p1,
+		ActivePage:      "officershipTimeline",
+		Assumed:         c1.Assumed,
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.OfficershipTimelineTemplate, templates.RegularType)
+}
+
+// timelineParam returns the ID in the query param or 0 when it isn't set
+func timelineParam(c echo.Context, param string) (int, error) {
+	if c.QueryParam(param) == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.Atoi(c.QueryParam(param))
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, errors.Errorf("failed to parse %s: %+v", param, err))
+	}
+
+	return id, nil
+}
+
+// filterTimeline picks the terms for the filters, the issues are found using every term of the officerships so a
+// gap or overlap with a term outside the filters is still found
+func filterTimeline(members []officership.OfficershipMember, year, officershipID,
+	userID int) ([]officership.OfficershipMember, []officership.TermIssue) {
+	terms := make([]officership.OfficershipMember, 0)
+	officerships := make(map[int]bool)
+
+	for _, m := range members {
+		if (officershipID != 0 && m.OfficerID != officershipID) || (userID != 0 && m.UserID != userID) {
+			continue
+		}
+
+		terms = append(terms, m)
+		officerships[m.OfficerID] = true
+	}
+
+	var start, end time.Time
+
+	if year != 0 {
+		start, end = officership.AcademicYearRange(year)
+
+		terms = officership.TermsBetween(terms, start, end)
+	}
+
+	history := make([]officership.OfficershipMember, 0)
+
+	for _, m := range members {
+		if officerships[m.OfficerID] {
+			history = append(history, m)
+		}
+	}
+
+	issues := make([]officership.TermIssue, 0)
+
+	for _, issue := range officership.FindTermIssues(history) {
+		if userID != 0 && issue.Previous.UserID != userID && issue.Next.UserID != userID {
+			continue
+		}
+
+		if year != 0 && (!issue.Start.Before(end) || (!issue.End.IsZero() && issue.End.Before(start))) {
+			continue
+		}
+
+		issues = append(issues, issue)
+	}
+
+	return terms, issues
+}
+
+func timelineCSV(c echo.Context, terms []officership.OfficershipMember) error {
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=\"officers-%s.csv\"", time.Now().Format("2006-01-02")))
+	c.Response().WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(c.Response())
+
+	err := w.Write([]string{"officership_member_id", "officership_id", "officership", "team", "user_id", "user",
+		"start_date", "end_date"})
+	if err != nil {
+		return errors.Errorf("failed to write timeline csv: %+v", err)
+	}
+
+	for _, m := range terms {
+		var startDate, endDate string
+
+		if m.StartDate.Valid {
+			startDate = m.StartDate.Time.Format("2006-01-02")
+		}
+
+		if m.EndDate.Valid {
+			endDate = m.EndDate.Time.Format("2006-01-02")
+		}
+
+		// the names are typed by users so can't be left to run as formulas
+		err = w.Write([]string{strconv.Itoa(m.OfficershipMemberID), strconv.Itoa(m.OfficerID),
+			utils.CSVCell(m.OfficershipName), utils.CSVCell(m.TeamName.String), strconv.Itoa(m.UserID),
+			utils.CSVCell(m.UserName), startDate, endDate})
+		if err != nil {
+			return errors.Errorf("failed to write timeline csv: %+v", err)
+		}
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
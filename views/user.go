@@ -4,12 +4,12 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"html/template"
 	"log"
 	"math"
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -19,12 +19,19 @@ import (
 	"github.com/labstack/echo/v4"
 	"gopkg.in/guregu/null.v4"
 
-	"github.com/ystv/web-auth/infrastructure/mail"
+	"github.com/ystv/web-auth/dataexport"
+	"github.com/ystv/web-auth/emailchange"
+	"github.com/ystv/web-auth/emailtemplate"
 	"github.com/ystv/web-auth/infrastructure/permission"
+	"github.com/ystv/web-auth/keylist"
+	"github.com/ystv/web-auth/membership"
 	"github.com/ystv/web-auth/officership"
 	"github.com/ystv/web-auth/permission/permissions"
+	"github.com/ystv/web-auth/role"
 	"github.com/ystv/web-auth/templates"
 	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/useremail"
+	"github.com/ystv/web-auth/userstatus"
 	"github.com/ystv/web-auth/utils"
 )
 
@@ -50,11 +57,21 @@ type (
 		Search     string
 		Enabled    string
 		Deleted    string
+		Status     string
 	}
 
 	// UserTemplate is for the user front end
 	UserTemplate struct {
-		User user.DetailedUser
+		User         user.DetailedUser
+		Memberships  []membership.Membership
+		Keys         []keylist.Grant
+		Exports      []dataexport.Export
+		EmailChanges []emailchange.Change
+		Emails       []useremail.Email
+		// StatusChanges is the history of the user's status and StatusTransitions are the statuses they can be
+		// moved to
+		StatusChanges     []userstatus.Change
+		StatusTransitions []user.Status
 		TemplateHelper
 	}
 )
@@ -85,6 +102,11 @@ func (v *Views) _usersGet(c echo.Context) error {
 
 	enabled := c.QueryParam("enabled")
 	deleted := c.QueryParam("deleted")
+	status := c.QueryParam("status")
+
+	if !slices.Contains(user.Statuses, user.Status(status)) {
+		status = ""
+	}
 
 	var size, page int
 
@@ -126,7 +148,7 @@ func (v *Views) _usersGet(c echo.Context) error {
 	}
 
 	dbUsers, fullCount, err := v.user.GetUsers(c.Request().Context(), size, page, search, column, direction, enabled,
-		deleted)
+		deleted, status)
 	if err != nil {
 		return fmt.Errorf("failed to get users for users: %w", err)
 	}
@@ -170,6 +192,7 @@ func (v *Views) _usersGet(c echo.Context) error {
 			Search:     search,
 			Enabled:    enabled,
 			Deleted:    deleted,
+			Status:     status,
 		},
 	}
 
@@ -190,6 +213,7 @@ func (v *Views) _usersPost(c echo.Context) error {
 	search := c.FormValue("search")
 	enabled := c.FormValue("enabled")
 	deleted := c.FormValue("deleted")
+	status := c.FormValue("status")
 
 	var size int
 
@@ -223,6 +247,13 @@ func (v *Views) _usersPost(c echo.Context) error {
 			errors.New("deleted must be set to either \"any\", \"deleted\" or \"not_deleted\""))
 	}
 
+	if slices.Contains(user.Statuses, user.Status(status)) {
+		q.Set("status", status)
+	} else if status != "any" {
+		return echo.NewHTTPError(http.StatusBadRequest,
+			errors.New("status must be set to either \"any\", \"member\", \"alumni\", \"honorary\" or \"suspended\""))
+	}
+
 	if column == "userId" || column == "name" || column == "username" || column == "email" || column == "lastLogin" {
 		if direction == "asc" || direction == "desc" {
 			q.Set("column", column)
@@ -275,18 +306,57 @@ func (v *Views) UserFunc(c echo.Context) error {
 
 	detailedUser.Permissions = removeDuplicate(detailedUser.Permissions)
 
-	detailedUser.Roles, err = v.user.GetRolesForUser(c.Request().Context(), user.User{UserID: detailedUser.UserID})
+	roles, err := v.user.GetEffectiveRolesForUser(c.Request().Context(), user.User{UserID: detailedUser.UserID})
 	if err != nil {
 		return fmt.Errorf("failed to get roles for user: %w", err)
 	}
 
+	detailedUser.Roles, detailedUser.InheritedRoles = splitInheritedRoles(roles)
+
+	memberships, err := v.membership.GetMembershipsForUser(c.Request().Context(), userFromDB)
+	if err != nil {
+		return fmt.Errorf("failed to get memberships for user: %w", err)
+	}
+
+	keys, err := v.keylist.GetHeldForUser(c.Request().Context(), userFromDB)
+	if err != nil {
+		return fmt.Errorf("failed to get keys for user: %w", err)
+	}
+
+	exports, err := v.dataExport.GetExportsForUser(c.Request().Context(), userFromDB)
+	if err != nil {
+		return fmt.Errorf("failed to get exports for user: %w", err)
+	}
+
+	emailChanges, err := v.emailChange.GetChangesForUser(c.Request().Context(), userFromDB)
+	if err != nil {
+		return fmt.Errorf("failed to get email changes for user: %w", err)
+	}
+
+	emails, err := v.userEmail.GetEmailsForUser(c.Request().Context(), userFromDB)
+	if err != nil {
+		return fmt.Errorf("failed to get emails for user: %w", err)
+	}
+
+	statusChanges, err := v.userStatus.GetChangesForUser(c.Request().Context(), userFromDB)
+	if err != nil {
+		return fmt.Errorf("failed to get status changes for user: %w", err)
+	}
+
 	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
 	if err != nil {
 		return fmt.Errorf("failed to get user permissions for user: %w", err)
 	}
 
 	data := UserTemplate{
-		User: detailedUser,
+		User:              detailedUser,
+		Memberships:       memberships,
+		Keys:              keys,
+		Exports:           exports,
+		EmailChanges:      emailChanges,
+		Emails:            emails,
+		StatusChanges:     statusChanges,
+		StatusTransitions: userstatus.Transitions(userFromDB.Status),
 		TemplateHelper: TemplateHelper{
 			UserPermissions: p1,
 			ActivePage:      "user",
@@ -421,21 +491,17 @@ func (v *Views) _userAddPost(c echo.Context) error {
 	tempDisableSendEmail := c.FormValue("disablesendemail")
 	var sendEmail = true
 
-	if tempDisableSendEmail == "on" && func() bool {
-		m := permission.SufficientPermissionsFor(permissions.SuperUser)
-
-		for _, perm := range c1.User.Permissions {
-			if m[perm.Name] {
-				return true
-			}
-		}
-
-		return false
-	}() {
+	if tempDisableSendEmail == "on" && permission.HasPermission(c1.User.Permissions, permissions.SuperUser) {
 		sendEmail = false
 	}
 
-	password, err := utils.GenerateRandom(utils.GeneratePassword)
+	password, err := v.passwordPolicy.Generate(c.Request().Context(), user.User{
+		Username:           username,
+		UniversityUsername: universityUsername,
+		Firstname:          firstName,
+		Lastname:           lastName,
+		Email:              email,
+	})
 	if err != nil {
 		return fmt.Errorf("error generating password: %w", err)
 	}
@@ -473,43 +539,21 @@ func (v *Views) _userAddPost(c echo.Context) error {
 		Error   error  `json:"error"`
 	}
 
-	mailer := v.mailer.ConnectMailer()
-
-	if mailer != nil && sendEmail {
-		var tmpl *template.Template
-
-		tmpl, err = v.template.GetEmailTemplate(templates.SignupEmailTemplate)
-		if err != nil {
-			return fmt.Errorf("failed to get email in addUser: %w", err)
-		}
-
-		file := mail.Mail{
-			Subject: "Welcome to YSTV!",
-			Tpl:     tmpl,
-			To:      u.Email,
-			From:    "YSTV No-Reply <no-reply@ystv.co.uk>",
-			TplData: struct {
-				Name     string
-				Username string
-				Password string
-			}{
+	if sendEmail {
+		err = v.queueMail(c.Request().Context(), emailtemplate.Signup, u.Language.String, u.Email,
+			emailtemplate.SignupData{
 				Name:     firstName,
 				Username: username,
 				Password: password,
-			},
-		}
-
-		err = mailer.SendMail(file)
+			})
 		if err != nil {
-			return fmt.Errorf("failed to send email in addUser: %w", err)
+			return fmt.Errorf("failed to queue email in addUser: %w", err)
 		}
 
-		message.Message = fmt.Sprintf("Successfully sent user email to: \"%s\"", email)
+		message.Message = fmt.Sprintf("Successfully queued user email to: \"%s\"", email)
 	} else {
-		message.Message = fmt.Sprintf(`No mailer present<br>Please send the username and password to this email: 
+		message.Message = fmt.Sprintf(`Please send the username and password to this email: 
 %s, username: %s, password: %s`, email, username, password)
-		message.Error = errors.New("no mailer present")
-		log.Printf("no Mailer present")
 	}
 
 	log.Printf("created user: %s", u.Username)
@@ -574,15 +618,25 @@ func (v *Views) UserEditFunc(c echo.Context) error {
 			user1.LDAPUsername = null.StringFrom(LDAPUsername)
 		}
 
-		if len(email) > 0 {
-			user1.Email = email
-		}
-
 		err = v.user.EditUser(c.Request().Context(), user1, c1.User.UserID)
 		if err != nil {
 			return fmt.Errorf("failed to edit user for editUser: %w", err)
 		}
 
+		// the email is changed through an email change so the old address can change it back
+		if len(email) > 0 && !strings.EqualFold(strings.TrimSpace(email), user1.Email) {
+			if c.FormValue("verifyemail") == "on" {
+				_, err = v.requestEmailChange(c.Request().Context(), user1, email, c1.User.UserID)
+			} else {
+				err = v.changeEmailNow(c.Request().Context(), user1, email, false, c1.User.UserID)
+			}
+
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest,
+					fmt.Errorf("failed to change email for editUser: %w", err))
+			}
+		}
+
 		return c.Redirect(http.StatusFound, fmt.Sprintf("/internal/user/%d", userID))
 	}
 
@@ -799,3 +853,20 @@ func (v *Views) RemoveAvatarUserFunc(c echo.Context) error {
 	}
 	return v.invalidMethodUsed(c)
 }
+
+// splitInheritedRoles splits a user's roles into the ones they are a member of and the ones they only have through
+// a role including them, only the first can be removed from the user
+func splitInheritedRoles(roles []user.EffectiveRole) ([]role.Role, []role.Role) {
+	direct := make([]role.Role, 0, len(roles))
+	inherited := make([]role.Role, 0)
+
+	for _, r := range roles {
+		if r.Inherited {
+			inherited = append(inherited, r.Role)
+		} else {
+			direct = append(direct, r.Role)
+		}
+	}
+
+	return direct, inherited
+}
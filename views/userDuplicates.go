@@ -0,0 +1,142 @@
+package views
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/duplicate"
+	"github.com/ystv/web-auth/templates"
+)
+
+// UserDuplicatesTemplate is the report of users that might be the same person
+type UserDuplicatesTemplate struct {
+	Clusters   []duplicate.Cluster
+	Dismissals []duplicate.Dismissal
+	TemplateHelper
+}
+
+// UserDuplicatesFunc clusters the users that might be the same person so they can be merged
+func (v *Views) UserDuplicatesFunc(c echo.Context) error {
+	if c.Request().Method != http.MethodGet {
+		return v.invalidMethodUsed(c)
+	}
+
+	c1 := v.getSessionData(c)
+
+	users, err := v.duplicate.GetUsers(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get users for userDuplicates: %w", err)
+	}
+
+	emails, err := v.duplicate.GetEmails(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get emails for userDuplicates: %w", err)
+	}
+
+	dismissals, err := v.duplicate.GetDismissals(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get dismissals for userDuplicates: %w", err)
+	}
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return fmt.Errorf("failed to get user permissions for userDuplicates: %w", err)
+	}
+
+	data := UserDuplicatesTemplate{
+		Clusters:   duplicate.Find(users, emails, dismissals),
+		Dismissals: dismissals,
+		TemplateHelper: TemplateHelper{
+			UserPermissions: p1,
+			ActivePage:      "users",
+			Assumed:         c1.Assumed,
+		},
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.UserDuplicatesTemplate, templates.RegularType)
+}
+
+// UserDuplicatesDismissFunc marks pairs of users as not the same person so they aren't reported again
+func (v *Views) UserDuplicatesDismissFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		form, err := c.FormParams()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to parse form for userDuplicatesDismiss: %w", err))
+		}
+
+		dismissals := make([]duplicate.Dismissal, 0, len(form["pair"]))
+
+		for _, pair := range form["pair"] {
+			d, err := parseDismissal(pair)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest,
+					fmt.Errorf("failed to parse pair for userDuplicatesDismiss: %w", err))
+			}
+
+			d.DismissedBy = null.IntFrom(int64(c1.User.UserID))
+
+			dismissals = append(dismissals, d)
+		}
+
+		err = v.duplicate.AddDismissals(c.Request().Context(), dismissals)
+		if err != nil {
+			return fmt.Errorf("failed to add dismissals for userDuplicatesDismiss: %w", err)
+		}
+
+		return c.Redirect(http.StatusFound, "/internal/user/duplicates")
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// UserDuplicatesRestoreFunc lets a dismissed pair of users be reported again
+func (v *Views) UserDuplicatesRestoreFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		d, err := parseDismissal(c.FormValue("pair"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to parse pair for userDuplicatesRestore: %w", err))
+		}
+
+		err = v.duplicate.RemoveDismissal(c.Request().Context(), d)
+		if err != nil {
+			return fmt.Errorf("failed to remove dismissal for userDuplicatesRestore: %w", err)
+		}
+
+		return c.Redirect(http.StatusFound, "/internal/user/duplicates")
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// parseDismissal parses a pair of user ids in the form "1-2"
+func parseDismissal(pair string) (duplicate.Dismissal, error) {
+	a, b, ok := strings.Cut(pair, "-")
+	if !ok {
+		return duplicate.Dismissal{}, fmt.Errorf("pair \"%s\" isn't two user ids", pair)
+	}
+
+	userID1, err := strconv.Atoi(a)
+	if err != nil {
+		return duplicate.Dismissal{}, fmt.Errorf("failed to parse user id: %w", err)
+	}
+
+	userID2, err := strconv.Atoi(b)
+	if err != nil {
+		return duplicate.Dismissal{}, fmt.Errorf("failed to parse user id: %w", err)
+	}
+
+	if userID1 == userID2 {
+		return duplicate.Dismissal{}, fmt.Errorf("pair \"%s\" is the same user twice", pair)
+	}
+
+	return duplicate.NewDismissal(userID1, userID2), nil
+}
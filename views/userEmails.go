@@ -0,0 +1,194 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/emailtemplate"
+	"github.com/ystv/web-auth/templates"
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/useremail"
+)
+
+// SettingsEmailsAddFunc adds another address to a user, it can only be used once the link sent to it is pressed
+func (v *Views) SettingsEmailsAddFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		err := v.addEmailAddress(c.Request().Context(), c1.User, c.FormValue("email"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to add email for settings: %w", err))
+		}
+
+		return c.Redirect(http.StatusFound, "/internal/settings")
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// SettingsEmailsRemoveFunc removes one of a user's addresses that isn't their primary one
+func (v *Views) SettingsEmailsRemoveFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		e, err := v.ownEmailAddress(c, c1.User)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get email for settings: %w", err))
+		}
+
+		err = v.userEmail.RemoveEmail(c.Request().Context(), e)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to remove email for settings: %w", err))
+		}
+
+		return c.Redirect(http.StatusFound, "/internal/settings")
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// SettingsEmailsPrimaryFunc makes one of a user's verified addresses their primary one, the old primary address is
+// sent a link to change it back the same as any other email change
+func (v *Views) SettingsEmailsPrimaryFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		e, err := v.ownEmailAddress(c, c1.User)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get email for settings: %w", err))
+		}
+
+		if !e.Verified {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("the email has to be verified first"))
+		}
+
+		// the session may be from before an email change
+		u, err := v.user.GetUser(c.Request().Context(), user.User{UserID: c1.User.UserID})
+		if err != nil {
+			return fmt.Errorf("failed to get user for settings: %w", err)
+		}
+
+		err = v.changeEmailNow(c.Request().Context(), u, e.Email, true, c1.User.UserID)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to change email for settings: %w", err))
+		}
+
+		session, _ := v.cookie.Get(c.Request(), v.conf.SessionCookieName)
+
+		c1.User.Email = e.Email
+		session.Values["user"] = c1.User
+
+		err = session.Save(c.Request(), c.Response())
+		if err != nil {
+			return fmt.Errorf("failed to save user session in settings: %w", err)
+		}
+
+		return c.Redirect(http.StatusFound, "/internal/settings")
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// EmailAddressFunc verifies an address added to a user once the link sent to it is pressed
+func (v *Views) EmailAddressFunc(c echo.Context) error {
+	data := EmailChangeTemplate{Title: "Confirm email"}
+
+	e, err := v.userEmail.GetEmailByVerifyToken(c.Request().Context(), c.Param("token"))
+	if err != nil || !e.CanVerify() {
+		data.Error = "This link has expired or has already been used, please add the email again from your settings"
+
+		return v.template.RenderTemplate(c.Response(), data, templates.EmailChangeTemplate, templates.NoNavType)
+	}
+
+	switch c.Request().Method {
+	case http.MethodGet:
+		data.Message = fmt.Sprintf("Press the button below to add %s to your YSTV account", e.Email)
+		data.Button = "Confirm email"
+	case http.MethodPost:
+		e, err = v.userEmail.VerifyEmail(c.Request().Context(), e)
+		if err != nil {
+			log.Printf("failed to verify email id %d: %+v", e.EmailID, err)
+
+			data.Error = "The email couldn't be added, it may already be used by another account"
+
+			break
+		}
+
+		data.Title = "Email added"
+		data.Message = fmt.Sprintf("You can now log in and reset your password with %s", e.Email)
+	default:
+		return v.invalidMethodUsed(c)
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.EmailChangeTemplate, templates.NoNavType)
+}
+
+// addEmailAddress adds an unverified address to a user and sends the link to verify it
+func (v *Views) addEmailAddress(ctx context.Context, u user.User, email string) error {
+	email, err := v.checkNewEmail(ctx, u, email)
+	if err != nil {
+		return err
+	}
+
+	emails, err := v.userEmail.GetEmailsForUser(ctx, u)
+	if err != nil {
+		return fmt.Errorf("failed to get emails: %w", err)
+	}
+
+	for _, e := range emails {
+		if e.Verified && strings.EqualFold(e.Email, email) {
+			return fmt.Errorf("\"%s\" has already been added", email)
+		}
+	}
+
+	e, err := v.userEmail.AddEmail(ctx, useremail.Email{
+		UserID:          u.UserID,
+		Email:           email,
+		VerifyToken:     null.StringFrom(uuid.NewString()),
+		VerifyExpiresAt: null.TimeFrom(time.Now().Add(useremail.VerifyFor)),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add email: %w", err)
+	}
+
+	err = v.queueMail(ctx, emailtemplate.EmailAddress, u.Language.String, e.Email,
+		emailtemplate.EmailVerifyData{
+			Name:  u.Firstname,
+			Email: e.Email,
+			URL:   "https://" + v.conf.DomainName + "/email/address/" + e.VerifyToken.String,
+		})
+	if err != nil {
+		return fmt.Errorf("failed to queue email address email: %w", err)
+	}
+
+	return nil
+}
+
+// ownEmailAddress returns the address in the form if it belongs to the user
+func (v *Views) ownEmailAddress(c echo.Context, u user.User) (useremail.Email, error) {
+	emailID, err := strconv.Atoi(c.FormValue("emailID"))
+	if err != nil {
+		return useremail.Email{}, fmt.Errorf("failed to parse email id: %w", err)
+	}
+
+	e, err := v.userEmail.GetEmail(c.Request().Context(), useremail.Email{EmailID: emailID})
+	if err != nil {
+		return useremail.Email{}, fmt.Errorf("failed to get email: %w", err)
+	}
+
+	if e.UserID != u.UserID {
+		return useremail.Email{}, errors.New("the email isn't yours")
+	}
+
+	return e, nil
+}
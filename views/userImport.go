@@ -0,0 +1,259 @@
+package views
+
+import (
+	"context"
+	dbSQL "database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ystv/web-auth/role"
+	"github.com/ystv/web-auth/templates"
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/userimport"
+)
+
+type (
+	// UserImportTemplate represents the page to upload a CSV of users
+	UserImportTemplate struct {
+		Roles  []role.Role
+		Fields []userimport.Field
+		TemplateHelper
+	}
+
+	// UserImportJobTemplate represents the preview of an import before it is run and its progress after
+	UserImportJobTemplate struct {
+		ImportID string
+		Rows     []userimport.Row
+		Roles    []role.Role
+		Counts   map[string]int
+		Options  userimport.Options
+		Progress userimport.Progress
+		TemplateHelper
+	}
+)
+
+// userImportMaxAge is how long an import is kept to be run and have its progress checked, the hourly cleanup
+// deletes older ones
+const userImportMaxAge = 24 * time.Hour
+
+// UserImportFunc shows the import page and previews an uploaded CSV, the preview is kept for a day to be run
+func (v *Views) UserImportFunc(c echo.Context) error {
+	switch c.Request().Method {
+	case http.MethodGet:
+		return v._userImportGet(c)
+	case http.MethodPost:
+		return v._userImportPost(c)
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+func (v *Views) _userImportGet(c echo.Context) error {
+	c1 := v.getSessionData(c)
+
+	roles, err := v.role.GetRoles(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get roles for userImport: %w", err)
+	}
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return fmt.Errorf("failed to get user permissions for userImport: %w", err)
+	}
+
+	data := UserImportTemplate{
+		Roles:  roles,
+		Fields: userimport.Fields,
+		TemplateHelper: TemplateHelper{
+			UserPermissions: p1,
+			ActivePage:      "users",
+			Assumed:         c1.Assumed,
+		},
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.UserImportTemplate, templates.RegularType)
+}
+
+func (v *Views) _userImportPost(c echo.Context) error {
+	c1 := v.getSessionData(c)
+
+	file, err := c.FormFile("upload")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get file for userImport: %w", err))
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open file for userImport: %w", err)
+	}
+
+	defer src.Close()
+
+	mapping := make(userimport.Mapping)
+
+	for _, f := range userimport.Fields {
+		mapping[f] = c.FormValue("column_" + string(f))
+	}
+
+	rows, err := userimport.Parse(src, mapping)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to parse csv for userImport: %w", err))
+	}
+
+	rows, err = userimport.Plan(c.Request().Context(), v.user, rows)
+	if err != nil {
+		return fmt.Errorf("failed to plan import for userImport: %w", err)
+	}
+
+	form, err := c.FormParams()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to parse form for userImport: %w", err))
+	}
+
+	roleIDs := make([]int, 0, len(form["roleIDs"]))
+
+	for _, r := range form["roleIDs"] {
+		roleID, err := strconv.Atoi(r)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to parse roleID for userImport: %w", err))
+		}
+
+		roleIDs = append(roleIDs, roleID)
+	}
+
+	job := userimport.NewJob(rows, userimport.Options{
+		RoleIDs:    roleIDs,
+		SendEmail:  c.FormValue("sendemail") == "on",
+		ImportedBy: c1.User.UserID,
+		Passwords:  v.passwordPolicy,
+	})
+
+	err = v.userImport.AddJob(c.Request().Context(), job)
+	if err != nil {
+		return fmt.Errorf("failed to add import for userImport: %w", err)
+	}
+
+	return c.Redirect(http.StatusFound, "/internal/user/import/"+job.ImportID())
+}
+
+// UserImportJobFunc shows the preview of an import or its progress once it has been run, ?format=json returns the
+// progress for polling
+func (v *Views) UserImportJobFunc(c echo.Context) error {
+	if c.Request().Method != http.MethodGet {
+		return v.invalidMethodUsed(c)
+	}
+
+	c1 := v.getSessionData(c)
+
+	job, err := v.getUserImport(c.Request().Context(), c.Param("importid"))
+	if err != nil {
+		return err
+	}
+
+	if c.QueryParam("format") == "json" {
+		return c.JSON(http.StatusOK, job.Progress())
+	}
+
+	roles, err := v.role.GetRoles(c.Request().Context())
+	if err != nil {
+		return fmt.Errorf("failed to get roles for userImportJob: %w", err)
+	}
+
+	importRoles := make([]role.Role, 0, len(job.Options().RoleIDs))
+
+	for _, r := range roles {
+		for _, roleID := range job.Options().RoleIDs {
+			if r.RoleID == roleID {
+				importRoles = append(importRoles, r)
+			}
+		}
+	}
+
+	counts := make(map[string]int)
+
+	for _, row := range job.Rows() {
+		counts[string(row.Action)]++
+	}
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return fmt.Errorf("failed to get user permissions for userImportJob: %w", err)
+	}
+
+	data := UserImportJobTemplate{
+		ImportID: c.Param("importid"),
+		Rows:     job.Rows(),
+		Roles:    importRoles,
+		Counts:   counts,
+		Options:  job.Options(),
+		Progress: job.Progress(),
+		TemplateHelper: TemplateHelper{
+			UserPermissions: p1,
+			ActivePage:      "users",
+			Assumed:         c1.Assumed,
+		},
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.UserImportJobTemplate, templates.RegularType)
+}
+
+// UserImportRunFunc runs a previewed import in the background, the welcome emails are sent as each user is created
+func (v *Views) UserImportRunFunc(c echo.Context) error {
+	if c.Request().Method != http.MethodPost {
+		return v.invalidMethodUsed(c)
+	}
+
+	importID := c.Param("importid")
+
+	job, err := v.getUserImport(c.Request().Context(), importID)
+	if err != nil {
+		return err
+	}
+
+	if job.Progress().Started {
+		return echo.NewHTTPError(http.StatusBadRequest, errors.New("import has already been run"))
+	}
+
+	go func() {
+		err := job.Run(context.Background(), v.user, v.changeImportedEmail,
+			userimport.NewWelcome(v.mailQueue, v.emailTemplate))
+		if err != nil {
+			log.Printf("failed to run user import %s: %+v", importID, err)
+
+			return
+		}
+
+		p := job.Progress()
+		log.Printf("user import %s finished: %d created, %d updated, %d skipped, %d failed", importID, p.Created,
+			p.Updated, p.Skipped, p.Failed)
+	}()
+
+	return c.Redirect(http.StatusFound, "/internal/user/import/"+importID)
+}
+
+// getUserImport returns a previewed import, a missing one is a not found error
+func (v *Views) getUserImport(ctx context.Context, importID string) (*userimport.Job, error) {
+	job, err := v.userImport.GetJob(ctx, importID)
+	if err != nil {
+		if errors.Is(err, dbSQL.ErrNoRows) {
+			return nil, echo.NewHTTPError(http.StatusNotFound, errors.New("import not found, it may have expired"))
+		}
+
+		return nil, fmt.Errorf("failed to get import: %w", err)
+	}
+
+	return job, nil
+}
+
+// changeImportedEmail changes the email of a user updated by an import, the address isn't verified and the old one
+// is sent the link to change it back
+func (v *Views) changeImportedEmail(ctx context.Context, u user.User, email string, changedBy int) error {
+	return v.changeEmailNow(ctx, u, email, false, changedBy)
+}
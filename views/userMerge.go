@@ -0,0 +1,175 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ystv/web-auth/templates"
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/usermerge"
+)
+
+type (
+	// UserMergeTemplate is the side-by-side comparison of two accounts and the preview of merging them
+	UserMergeTemplate struct {
+		SurvivorSearch string
+		LoserSearch    string
+		Survivor       user.User
+		Loser          user.User
+		Fields         []UserMergeField
+		Moves          []usermerge.Move
+		TemplateHelper
+	}
+
+	// UserMergeField is a field that can be picked from either account
+	UserMergeField struct {
+		Name     string
+		Label    string
+		Image    bool
+		Survivor string
+		Loser    string
+		Differs  bool
+	}
+)
+
+// UserMergeFunc compares two accounts for the same person and previews merging them, posting the picked fields
+// merges the loser into the survivor and deletes the loser
+func (v *Views) UserMergeFunc(c echo.Context) error {
+	switch c.Request().Method {
+	case http.MethodGet:
+		return v._userMergeGet(c)
+	case http.MethodPost:
+		return v._userMergePost(c)
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+func (v *Views) _userMergeGet(c echo.Context) error {
+	c1 := v.getSessionData(c)
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return fmt.Errorf("failed to get user permissions for userMerge: %w", err)
+	}
+
+	data := UserMergeTemplate{
+		SurvivorSearch: c.QueryParam("survivor"),
+		LoserSearch:    c.QueryParam("loser"),
+		TemplateHelper: TemplateHelper{
+			UserPermissions: p1,
+			ActivePage:      "users",
+			Assumed:         c1.Assumed,
+		},
+	}
+
+	if data.SurvivorSearch != "" && data.LoserSearch != "" {
+		data.Survivor, err = v.findUser(c.Request().Context(), data.SurvivorSearch)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get survivor for userMerge: %w", err))
+		}
+
+		data.Loser, err = v.findUser(c.Request().Context(), data.LoserSearch)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get loser for userMerge: %w", err))
+		}
+
+		err = canMergeUsers(data.Survivor, data.Loser, c1.User)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to merge users: %w", err))
+		}
+
+		for _, f := range usermerge.Fields {
+			survivor, loser := f.Value(data.Survivor), f.Value(data.Loser)
+
+			data.Fields = append(data.Fields, UserMergeField{
+				Name:     f.Name,
+				Label:    f.Label,
+				Image:    f.Image,
+				Survivor: survivor,
+				Loser:    loser,
+				Differs:  survivor != loser,
+			})
+		}
+
+		data.Moves, err = v.userMerge.Preview(c.Request().Context(), usermerge.Merge{
+			Survivor: data.Survivor,
+			Loser:    data.Loser,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to preview merge for userMerge: %w", err)
+		}
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.UserMergeTemplate, templates.RegularType)
+}
+
+func (v *Views) _userMergePost(c echo.Context) error {
+	c1 := v.getSessionData(c)
+
+	survivor, err := v.getMergeUser(c.Request().Context(), c.FormValue("survivorID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get survivor for userMerge: %w", err))
+	}
+
+	loser, err := v.getMergeUser(c.Request().Context(), c.FormValue("loserID"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get loser for userMerge: %w", err))
+	}
+
+	err = canMergeUsers(survivor, loser, c1.User)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to merge users: %w", err))
+	}
+
+	var fromLoser []string
+
+	for _, f := range usermerge.Fields {
+		if c.FormValue("field_"+f.Name) == "loser" {
+			fromLoser = append(fromLoser, f.Name)
+		}
+	}
+
+	err = v.userMerge.Merge(c.Request().Context(), usermerge.Merge{
+		Survivor:  survivor,
+		Loser:     loser,
+		FromLoser: fromLoser,
+		MergedBy:  c1.User.UserID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to merge users for userMerge: %w", err)
+	}
+
+	return c.Redirect(http.StatusFound, fmt.Sprintf("/internal/user/%d", survivor.UserID))
+}
+
+func (v *Views) getMergeUser(ctx context.Context, s string) (user.User, error) {
+	userID, err := strconv.Atoi(s)
+	if err != nil {
+		return user.User{}, fmt.Errorf("failed to parse user id: %w", err)
+	}
+
+	return v.user.GetUser(ctx, user.User{UserID: userID})
+}
+
+// canMergeUsers checks the loser can be merged into the survivor by the current user, the current user can't be
+// the loser as they would be logged out part way through
+func canMergeUsers(survivor, loser, current user.User) error {
+	switch {
+	case survivor.UserID == loser.UserID:
+		return errors.New("a user can't be merged into themself")
+	case survivor.DeletedAt.Valid:
+		return errors.New("the survivor has been deleted")
+	case loser.DeletedAt.Valid:
+		return errors.New("the loser has already been deleted")
+	case loser.UserID == current.UserID:
+		return errors.New("you can't merge yourself into another user, ask another admin to")
+	}
+
+	return nil
+}
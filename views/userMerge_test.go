@@ -0,0 +1,92 @@
+package views
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/user"
+	mockuser "github.com/ystv/web-auth/user/mocks"
+	"github.com/ystv/web-auth/usermerge"
+	mockusermerge "github.com/ystv/web-auth/usermerge/mocks"
+)
+
+func TestUserMergePost(t *testing.T) {
+	admin := user.User{UserID: 3}
+	survivor := user.User{UserID: 1, Username: "jdoe"}
+	loser := user.User{UserID: 2, Username: "jane.doe"}
+
+	setup := func(t *testing.T, survivor, loser user.User) (*Views, *mockusermerge.MockRepo) {
+		ctr := gomock.NewController(t)
+		mockUser := mockuser.NewMockRepo(ctr)
+		mockUserMerge := mockusermerge.NewMockRepo(ctr)
+
+		mockUser.EXPECT().GetUser(gomock.Any(), user.User{UserID: 1}).Return(survivor, nil)
+		mockUser.EXPECT().GetUser(gomock.Any(), user.User{UserID: 2}).Return(loser, nil)
+
+		v := newTestViews()
+		v.user = mockUser
+		v.userMerge = mockUserMerge
+
+		return v, mockUserMerge
+	}
+
+	t.Run("Merge", func(t *testing.T) {
+		v, mockUserMerge := setup(t, survivor, loser)
+
+		// only the fields picked from the loser are copied, the rest are kept from the survivor
+		mockUserMerge.EXPECT().Merge(gomock.Any(), usermerge.Merge{
+			Survivor:  survivor,
+			Loser:     loser,
+			FromLoser: []string{"email"},
+			MergedBy:  3,
+		}).Return(nil)
+
+		c, rec := newTestContext(t, v, admin, url.Values{
+			"survivorID":     {"1"},
+			"loserID":        {"2"},
+			"field_username": {"survivor"},
+			"field_email":    {"loser"},
+		})
+
+		require.NoError(t, v.UserMergeFunc(c))
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "/internal/user/1", rec.Header().Get(echo.HeaderLocation))
+	})
+
+	t.Run("LoserDeleted", func(t *testing.T) {
+		deleted := loser
+		deleted.DeletedAt = null.TimeFrom(time.Now())
+
+		v, _ := setup(t, survivor, deleted)
+
+		c, _ := newTestContext(t, v, admin, url.Values{"survivorID": {"1"}, "loserID": {"2"}})
+
+		err := v.UserMergeFunc(c)
+
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	})
+
+	t.Run("MergingYourself", func(t *testing.T) {
+		v, _ := setup(t, survivor, loser)
+
+		// the admin would be logged out part way through the merge
+		c, _ := newTestContext(t, v, loser, url.Values{"survivorID": {"1"}, "loserID": {"2"}})
+
+		err := v.UserMergeFunc(c)
+
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	})
+}
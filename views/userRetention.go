@@ -0,0 +1,188 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/labstack/echo/v4"
+
+	"github.com/ystv/web-auth/templates"
+	"github.com/ystv/web-auth/user"
+)
+
+type (
+	// UserRetentionTemplate is the dry run of the deleted users waiting to be anonymised
+	UserRetentionTemplate struct {
+		Users         []UserRetention
+		RetentionDays int
+		TemplateHelper
+	}
+
+	// UserRetention is a deleted user with what will be removed when they are anonymised
+	UserRetention struct {
+		User        user.User
+		AnonymiseAt time.Time
+		Due         bool
+		CDNAvatar   bool
+		APITokens   int
+	}
+)
+
+// UserRetentionFunc lists the deleted users that haven't been anonymised yet and when they will be
+func (v *Views) UserRetentionFunc(c echo.Context) error {
+	if c.Request().Method != http.MethodGet {
+		return v.invalidMethodUsed(c)
+	}
+
+	c1 := v.getSessionData(c)
+
+	users, err := v.user.GetUsersToAnonymise(c.Request().Context(), time.Time{})
+	if err != nil {
+		return fmt.Errorf("failed to get users for userRetention: %w", err)
+	}
+
+	retention := make([]UserRetention, 0, len(users))
+
+	for _, u := range users {
+		tokens, err := v.api.GetTokens(c.Request().Context(), u.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get api tokens for userRetention: %w", err)
+		}
+
+		_, cdnAvatar := v.cdnAvatarKey(u.Avatar)
+
+		anonymiseAt := u.DeletedAt.Time.Add(v.conf.DeletedUserRetention)
+
+		retention = append(retention, UserRetention{
+			User:        u,
+			AnonymiseAt: anonymiseAt,
+			Due:         !anonymiseAt.After(time.Now()),
+			CDNAvatar:   cdnAvatar,
+			APITokens:   len(tokens),
+		})
+	}
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return fmt.Errorf("failed to get user permissions for userRetention: %w", err)
+	}
+
+	data := UserRetentionTemplate{
+		Users:         retention,
+		RetentionDays: int(v.conf.DeletedUserRetention.Hours() / 24),
+		TemplateHelper: TemplateHelper{
+			UserPermissions: p1,
+			ActivePage:      "users",
+			Assumed:         c1.Assumed,
+		},
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.UserRetentionTemplate, templates.RegularType)
+}
+
+// UserPurgeFunc anonymises a deleted user now rather than waiting for the retention period
+func (v *Views) UserPurgeFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		userID, err := strconv.Atoi(c.Param("userid"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get userid for userPurge: %w", err))
+		}
+
+		u, err := v.user.GetUser(c.Request().Context(), user.User{UserID: userID})
+		if err != nil {
+			return fmt.Errorf("failed to get user for userPurge: %w", err)
+		}
+
+		if !u.DeletedAt.Valid {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("user must be deleted before being purged"))
+		}
+
+		if u.AnonymisedAt.Valid {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("user has already been anonymised"))
+		}
+
+		err = v.anonymiseUser(c.Request().Context(), u)
+		if err != nil {
+			return fmt.Errorf("failed to anonymise user for userPurge: %w", err)
+		}
+
+		return c.Redirect(http.StatusFound, fmt.Sprintf("/internal/user/%d", u.UserID))
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// anonymiseDeletedUsers anonymises the users deleted longer ago than the retention period, this is run in the
+// background
+func (v *Views) anonymiseDeletedUsers(ctx context.Context) error {
+	users, err := v.user.GetUsersToAnonymise(ctx, time.Now().Add(-v.conf.DeletedUserRetention))
+	if err != nil {
+		return fmt.Errorf("failed to get users to anonymise: %w", err)
+	}
+
+	for _, u := range users {
+		err = v.anonymiseUser(ctx, u)
+		if err != nil {
+			// the user is left as they are so they are tried again on the next run
+			log.Printf("failed to anonymise user id %d: %+v", u.UserID, err)
+
+			continue
+		}
+
+		log.Printf("anonymised deleted user id %d", u.UserID)
+	}
+
+	return nil
+}
+
+// anonymiseUser removes the avatar from the CDN, the API tokens and the old emails before scrubbing the user, the
+// avatar has to go first as its key is lost once the user is scrubbed
+func (v *Views) anonymiseUser(ctx context.Context, u user.User) error {
+	if key, ok := v.cdnAvatarKey(u.Avatar); ok {
+		_, err := v.cdn.DeleteObject(&s3.DeleteObjectInput{
+			Bucket: aws.String("avatars"),
+			Key:    aws.String(key),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete avatar from cdn: %w", err)
+		}
+	}
+
+	tokens, err := v.api.GetTokens(ctx, u.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get api tokens: %w", err)
+	}
+
+	for _, t := range tokens {
+		err = v.api.DeleteToken(ctx, t)
+		if err != nil {
+			return fmt.Errorf("failed to delete api token: %w", err)
+		}
+	}
+
+	err = v.user.AnonymiseUser(ctx, u)
+	if err != nil {
+		return fmt.Errorf("failed to anonymise user: %w", err)
+	}
+
+	return nil
+}
+
+// cdnAvatarKey returns the object key of an avatar if it is stored on our CDN
+func (v *Views) cdnAvatarKey(avatar string) (string, bool) {
+	if len(avatar) == 0 || len(v.conf.CDNEndpoint) == 0 || !strings.Contains(avatar, v.conf.CDNEndpoint) {
+		return "", false
+	}
+
+	split := strings.Split(avatar, "/")
+
+	return split[len(split)-1], true
+}
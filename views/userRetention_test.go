@@ -0,0 +1,66 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/api"
+	mockapi "github.com/ystv/web-auth/api/mocks"
+	"github.com/ystv/web-auth/user"
+	mockuser "github.com/ystv/web-auth/user/mocks"
+)
+
+func TestCDNAvatarKey(t *testing.T) {
+	v := &Views{conf: &Config{CDNEndpoint: "https://cdn.ystv.co.uk"}}
+
+	key, ok := v.cdnAvatarKey("https://cdn.ystv.co.uk/avatars/1.png")
+	assert.True(t, ok)
+	assert.Equal(t, "1.png", key)
+
+	_, ok = v.cdnAvatarKey("1.jpg")
+	assert.False(t, ok)
+
+	_, ok = v.cdnAvatarKey("")
+	assert.False(t, ok)
+
+	v.conf.CDNEndpoint = ""
+
+	_, ok = v.cdnAvatarKey("https://cdn.ystv.co.uk/avatars/1.png")
+	assert.False(t, ok)
+}
+
+func TestAnonymiseDeletedUsers(t *testing.T) {
+	ctr := gomock.NewController(t)
+	mockUser := mockuser.NewMockRepo(ctr)
+	mockAPI := mockapi.NewMockRepo(ctr)
+
+	deleted := user.User{UserID: 1, DeletedAt: null.TimeFrom(time.Now().Add(-100 * 24 * time.Hour))}
+	failing := user.User{UserID: 2, DeletedAt: null.TimeFrom(time.Now().Add(-100 * 24 * time.Hour))}
+	token := api.Token{TokenID: "token", UserID: 1}
+
+	mockUser.EXPECT().GetUsersToAnonymise(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, before time.Time) ([]user.User, error) {
+			assert.WithinDuration(t, time.Now().Add(-90*24*time.Hour), before, time.Minute)
+
+			return []user.User{failing, deleted}, nil
+		})
+	mockAPI.EXPECT().GetTokens(gomock.Any(), 2).Return(nil, errors.New("failed"))
+	mockAPI.EXPECT().GetTokens(gomock.Any(), 1).Return([]api.Token{token}, nil)
+	mockAPI.EXPECT().DeleteToken(gomock.Any(), token).Return(nil)
+	mockUser.EXPECT().AnonymiseUser(gomock.Any(), deleted).Return(nil)
+
+	v := &Views{
+		api:  mockAPI,
+		user: mockUser,
+		conf: &Config{DeletedUserRetention: 90 * 24 * time.Hour},
+	}
+
+	require.NoError(t, v.anonymiseDeletedUsers(context.Background()))
+}
@@ -0,0 +1,274 @@
+package views
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/membership"
+	"github.com/ystv/web-auth/role"
+	"github.com/ystv/web-auth/templates"
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/userstatus"
+)
+
+type (
+	// UserStatusesTemplate is the roles given to each status and the cohort of members that can be graduated,
+	// Message and Errors are set once a cohort has been graduated
+	UserStatusesTemplate struct {
+		Statuses      []UserStatus
+		Roles         []role.Role
+		AcademicYear  string
+		AcademicYears []string
+		Cohort        []user.User
+		Message       string
+		Errors        []string
+		TemplateHelper
+	}
+
+	// UserStatus is a status with the roles given to it and the statuses it can be changed to
+	UserStatus struct {
+		Status      user.Status
+		Roles       []userstatus.StatusRole
+		Transitions []user.Status
+	}
+)
+
+// UserStatusesFunc shows the roles given to each status and the members whose last membership was in the academic
+// year so they can be graduated together
+func (v *Views) UserStatusesFunc(c echo.Context) error {
+	if c.Request().Method != http.MethodGet {
+		return v.invalidMethodUsed(c)
+	}
+
+	data, err := v.userStatusesData(c, c.QueryParam("year"))
+	if err != nil {
+		return err
+	}
+
+	return v.template.RenderTemplate(c.Response(), data, templates.UserStatusesTemplate, templates.RegularType)
+}
+
+// UserStatusesGraduateFunc makes the chosen members of a cohort alumni, a user that can't be is reported and the
+// rest are still graduated
+func (v *Views) UserStatusesGraduateFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		form, err := c.FormParams()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to parse form for userStatusesGraduate: %w", err))
+		}
+
+		academicYear := form.Get("academicYear")
+
+		reason := form.Get("reason")
+		if len(reason) == 0 {
+			reason = fmt.Sprintf("Graduated, last membership was %s", academicYear)
+		}
+
+		var graduated int
+
+		var failed []string
+
+		for _, rawUserID := range form["userID"] {
+			userID, err := strconv.Atoi(rawUserID)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest,
+					fmt.Errorf("failed to parse userID for userStatusesGraduate: %w", err))
+			}
+
+			_, err = v.userStatus.ChangeStatus(c.Request().Context(), userstatus.Change{
+				UserID:    userID,
+				ToStatus:  user.Alumni,
+				Reason:    reason,
+				ChangedBy: null.IntFrom(int64(c1.User.UserID)),
+			})
+			if err != nil {
+				failed = append(failed, fmt.Sprintf("User %d: %s", userID, err))
+
+				continue
+			}
+
+			graduated++
+		}
+
+		log.Printf("%d users graduated from %s by user id: %d, %d failed", graduated, academicYear,
+			c1.User.UserID, len(failed))
+
+		data, err := v.userStatusesData(c, academicYear)
+		if err != nil {
+			return err
+		}
+
+		data.Message = fmt.Sprintf("%d users are now alumni", graduated)
+		data.Errors = failed
+
+		return v.template.RenderTemplate(c.Response(), data, templates.UserStatusesTemplate, templates.RegularType)
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// UserStatusesRoleAddFunc gives a role to a status, users are given it as they move to the status
+func (v *Views) UserStatusesRoleAddFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		sr, err := formStatusRole(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get status role: %w", err))
+		}
+
+		_, err = v.role.GetRole(c.Request().Context(), role.Role{RoleID: sr.RoleID})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get role for status role: %w", err))
+		}
+
+		err = v.userStatus.AddStatusRole(c.Request().Context(), sr)
+		if err != nil {
+			return fmt.Errorf("failed to add status role: %w", err)
+		}
+
+		return c.Redirect(http.StatusFound, "/internal/user/statuses")
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// UserStatusesRoleRemoveFunc stops giving a role to a status
+func (v *Views) UserStatusesRoleRemoveFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		sr, err := formStatusRole(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get status role: %w", err))
+		}
+
+		err = v.userStatus.RemoveStatusRole(c.Request().Context(), sr)
+		if err != nil {
+			return fmt.Errorf("failed to remove status role: %w", err)
+		}
+
+		return c.Redirect(http.StatusFound, "/internal/user/statuses")
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// UserStatusFunc moves a user to another status
+func (v *Views) UserStatusFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		userID, err := strconv.Atoi(c.Param("userid"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to get userid for userStatus: %w", err))
+		}
+
+		if userID == c1.User.UserID {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.New("you can't change your own status"))
+		}
+
+		_, err = v.userStatus.ChangeStatus(c.Request().Context(), userstatus.Change{
+			UserID:    userID,
+			ToStatus:  user.Status(c.FormValue("status")),
+			Reason:    c.FormValue("reason"),
+			ChangedBy: null.IntFrom(int64(c1.User.UserID)),
+		})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to change status for userStatus: %w", err))
+		}
+
+		return c.Redirect(http.StatusFound, fmt.Sprintf("/internal/user/%d", userID))
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// userStatusesData gets everything on the statuses page, the academic year defaults to the last one that has ended
+func (v *Views) userStatusesData(c echo.Context, academicYear string) (UserStatusesTemplate, error) {
+	c1 := v.getSessionData(c)
+
+	if len(academicYear) == 0 {
+		academicYear = membership.AcademicYear(time.Now().AddDate(-1, 0, 0))
+	}
+
+	statusRoles, err := v.userStatus.GetStatusRoles(c.Request().Context())
+	if err != nil {
+		return UserStatusesTemplate{}, fmt.Errorf("failed to get status roles for userStatuses: %w", err)
+	}
+
+	statuses := make([]UserStatus, 0, len(user.Statuses))
+
+	for _, s := range user.Statuses {
+		statuses = append(statuses, UserStatus{
+			Status: s,
+			Roles: slices.DeleteFunc(slices.Clone(statusRoles), func(sr userstatus.StatusRole) bool {
+				return sr.Status != s
+			}),
+			Transitions: userstatus.Transitions(s),
+		})
+	}
+
+	roles, err := v.role.GetRoles(c.Request().Context())
+	if err != nil {
+		return UserStatusesTemplate{}, fmt.Errorf("failed to get roles for userStatuses: %w", err)
+	}
+
+	academicYears, err := v.membership.GetAcademicYears(c.Request().Context())
+	if err != nil {
+		return UserStatusesTemplate{}, fmt.Errorf("failed to get academic years for userStatuses: %w", err)
+	}
+
+	if !slices.Contains(academicYears, academicYear) {
+		academicYears = append(academicYears, academicYear)
+
+		slices.Sort(academicYears)
+		slices.Reverse(academicYears)
+	}
+
+	cohort, err := v.userStatus.GetCohort(c.Request().Context(), academicYear)
+	if err != nil {
+		return UserStatusesTemplate{}, fmt.Errorf("failed to get cohort for userStatuses: %w", err)
+	}
+
+	p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+	if err != nil {
+		return UserStatusesTemplate{}, fmt.Errorf("failed to get user permissions for userStatuses: %w", err)
+	}
+
+	return UserStatusesTemplate{
+		Statuses:      statuses,
+		Roles:         roles,
+		AcademicYear:  academicYear,
+		AcademicYears: academicYears,
+		Cohort:        cohort,
+		TemplateHelper: TemplateHelper{
+			UserPermissions: p1,
+			ActivePage:      "users",
+			Assumed:         c1.Assumed,
+		},
+	}, nil
+}
+
+// formStatusRole returns the status role in the form
+func formStatusRole(c echo.Context) (userstatus.StatusRole, error) {
+	status := user.Status(c.FormValue("status"))
+
+	if !slices.Contains(user.Statuses, status) {
+		return userstatus.StatusRole{}, fmt.Errorf("\"%s\" isn't a status", status)
+	}
+
+	roleID, err := strconv.Atoi(c.FormValue("roleID"))
+	if err != nil {
+		return userstatus.StatusRole{}, fmt.Errorf("failed to parse role id: %w", err)
+	}
+
+	return userstatus.StatusRole{Status: status, RoleID: roleID}, nil
+}
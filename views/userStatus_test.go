@@ -0,0 +1,136 @@
+package views
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/role"
+	mockrole "github.com/ystv/web-auth/role/mocks"
+	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/userstatus"
+	mockuserstatus "github.com/ystv/web-auth/userstatus/mocks"
+)
+
+func TestUserStatus(t *testing.T) {
+	admin := user.User{UserID: 2}
+
+	setup := func(t *testing.T) (*Views, *mockuserstatus.MockRepo) {
+		ctr := gomock.NewController(t)
+		mockUserStatus := mockuserstatus.NewMockRepo(ctr)
+
+		v := newTestViews()
+		v.userStatus = mockUserStatus
+
+		return v, mockUserStatus
+	}
+
+	t.Run("Change", func(t *testing.T) {
+		v, mockUserStatus := setup(t)
+
+		// the store swaps the roles of the old status for the new one's in the same transaction
+		mockUserStatus.EXPECT().ChangeStatus(gomock.Any(), userstatus.Change{
+			UserID:    1,
+			ToStatus:  user.Alumni,
+			Reason:    "Graduated",
+			ChangedBy: null.IntFrom(2),
+		}).Return(userstatus.Change{ChangeID: 1, UserID: 1, FromStatus: user.Member, ToStatus: user.Alumni}, nil)
+
+		c, rec := newTestContext(t, v, admin, url.Values{"status": {"alumni"}, "reason": {"Graduated"}},
+			"userid", "1")
+
+		require.NoError(t, v.UserStatusFunc(c))
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "/internal/user/1", rec.Header().Get(echo.HeaderLocation))
+	})
+
+	t.Run("NotATransition", func(t *testing.T) {
+		v, mockUserStatus := setup(t)
+
+		mockUserStatus.EXPECT().ChangeStatus(gomock.Any(), gomock.Any()).
+			Return(userstatus.Change{}, errors.New("a honorary user can't become a member"))
+
+		c, _ := newTestContext(t, v, admin, url.Values{"status": {"member"}}, "userid", "1")
+
+		err := v.UserStatusFunc(c)
+
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	})
+
+	t.Run("OwnStatus", func(t *testing.T) {
+		v, _ := setup(t)
+
+		c, _ := newTestContext(t, v, admin, url.Values{"status": {"alumni"}}, "userid", "2")
+
+		err := v.UserStatusFunc(c)
+
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	})
+}
+
+func TestUserStatusesRoleAdd(t *testing.T) {
+	setup := func(t *testing.T) (*Views, *mockuserstatus.MockRepo, *mockrole.MockRepo) {
+		ctr := gomock.NewController(t)
+		mockUserStatus := mockuserstatus.NewMockRepo(ctr)
+		mockRole := mockrole.NewMockRepo(ctr)
+
+		v := newTestViews()
+		v.userStatus = mockUserStatus
+		v.role = mockRole
+
+		return v, mockUserStatus, mockRole
+	}
+
+	t.Run("Add", func(t *testing.T) {
+		v, mockUserStatus, mockRole := setup(t)
+
+		mockRole.EXPECT().GetRole(gomock.Any(), role.Role{RoleID: 3}).Return(role.Role{RoleID: 3}, nil)
+		mockUserStatus.EXPECT().AddStatusRole(gomock.Any(), userstatus.StatusRole{Status: user.Alumni, RoleID: 3}).
+			Return(nil)
+
+		c, rec := newTestContext(t, v, user.User{UserID: 2}, url.Values{"status": {"alumni"}, "roleID": {"3"}})
+
+		require.NoError(t, v.UserStatusesRoleAddFunc(c))
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+	})
+
+	t.Run("UnknownRole", func(t *testing.T) {
+		v, _, mockRole := setup(t)
+
+		mockRole.EXPECT().GetRole(gomock.Any(), role.Role{RoleID: 3}).Return(role.Role{}, sql.ErrNoRows)
+
+		c, _ := newTestContext(t, v, user.User{UserID: 2}, url.Values{"status": {"alumni"}, "roleID": {"3"}})
+
+		err := v.UserStatusesRoleAddFunc(c)
+
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	})
+
+	t.Run("UnknownStatus", func(t *testing.T) {
+		v, _, _ := setup(t)
+
+		c, _ := newTestContext(t, v, user.User{UserID: 2}, url.Values{"status": {"retired"}, "roleID": {"3"}})
+
+		err := v.UserStatusesRoleAddFunc(c)
+
+		var httpErr *echo.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	})
+}
@@ -0,0 +1,31 @@
+package views
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/ystv/web-auth/role"
+	"github.com/ystv/web-auth/user"
+)
+
+func TestSplitInheritedRoles(t *testing.T) {
+	member := role.Role{RoleID: 1, Name: "Member"}
+	editor := role.Role{RoleID: 2, Name: "Editor"}
+	crew := role.Role{RoleID: 3, Name: "Crew"}
+
+	direct, inherited := splitInheritedRoles([]user.EffectiveRole{
+		{Role: crew, Inherited: true},
+		{Role: editor},
+		{Role: member},
+	})
+
+	// the user page only offers to remove the roles the user is a member of
+	assert.Equal(t, []role.Role{editor, member}, direct)
+	assert.Equal(t, []role.Role{crew}, inherited)
+
+	direct, inherited = splitInheritedRoles(nil)
+
+	assert.Empty(t, direct)
+	assert.Empty(t, inherited)
+}
@@ -18,35 +18,52 @@ import (
 	"github.com/gorilla/sessions"
 	"github.com/patrickmn/go-cache"
 
+	"github.com/ystv/web-auth/accessrequest"
+	"github.com/ystv/web-auth/accessreview"
 	"github.com/ystv/web-auth/api"
 	"github.com/ystv/web-auth/crowd"
+	"github.com/ystv/web-auth/dataexport"
+	"github.com/ystv/web-auth/duplicate"
+	"github.com/ystv/web-auth/emailchange"
+	"github.com/ystv/web-auth/emailtemplate"
 	"github.com/ystv/web-auth/infrastructure/db"
 	"github.com/ystv/web-auth/infrastructure/mail"
+	"github.com/ystv/web-auth/keylist"
+	"github.com/ystv/web-auth/mailqueue"
+	"github.com/ystv/web-auth/membership"
 	"github.com/ystv/web-auth/officership"
+	"github.com/ystv/web-auth/passwordpolicy"
 	"github.com/ystv/web-auth/permission"
 	"github.com/ystv/web-auth/role"
 	"github.com/ystv/web-auth/templates"
 	"github.com/ystv/web-auth/user"
+	"github.com/ystv/web-auth/useremail"
+	"github.com/ystv/web-auth/userimport"
+	"github.com/ystv/web-auth/usermerge"
+	"github.com/ystv/web-auth/userstatus"
 	"github.com/ystv/web-auth/utils"
+	"github.com/ystv/web-auth/webhook"
 )
 
 type (
 	// Config the global web-auth configuration
 	Config struct {
-		Version           string
-		Commit            string
-		Debug             bool
-		Address           string
-		DatabaseURL       string
-		BaseDomainName    string
-		DomainName        string
-		LogoutEndpoint    string
-		JWTCookieName     string
-		SessionCookieName string
-		CDNEndpoint       string
-		Mail              SMTPConfig
-		Security          SecurityConfig
-		Logger            *utils.Logger
+		Version              string
+		Commit               string
+		Debug                bool
+		Address              string
+		DatabaseURL          string
+		BaseDomainName       string
+		DomainName           string
+		LogoutEndpoint       string
+		JWTCookieName        string
+		SessionCookieName    string
+		CDNEndpoint          string
+		DeletedUserRetention time.Duration
+		PasswordPolicy       passwordpolicy.Policy
+		Mail                 SMTPConfig
+		Security             SecurityConfig
+		Logger               *utils.Logger
 	}
 
 	// SMTPConfig stores the SMTP Mailer configuration
@@ -67,20 +84,35 @@ type (
 
 	// Views encapsulates our view dependencies
 	Views struct {
-		api         api.Repo
-		cache       *cache.Cache
-		cdn         *s3.S3
-		conf        *Config
-		cookie      *sessions.CookieStore
-		crowd       crowd.Repo
-		Mailer      *mail.Mailer
-		officership officership.Repo
-		permission  permission.Repo
-		role        role.Repo
-		template    *templates.Templater
-		user        user.Repo
-		mailer      *mail.MailerInit
-		validate    *validator.Validate
+		accessRequest  accessrequest.Repo
+		accessReview   accessreview.Repo
+		api            api.Repo
+		cache          *cache.Cache
+		cdn            *s3.S3
+		conf           *Config
+		cookie         *sessions.CookieStore
+		crowd          crowd.Repo
+		dataExport     dataexport.Repo
+		duplicate      duplicate.Repo
+		emailChange    emailchange.Repo
+		emailTemplate  emailtemplate.Repo
+		keylist        keylist.Repo
+		Mailer         *mail.Mailer
+		officership    officership.Repo
+		passwordPolicy passwordpolicy.Repo
+		permission     permission.Repo
+		role           role.Repo
+		template       *templates.Templater
+		user           user.Repo
+		userEmail      useremail.Repo
+		userImport     userimport.Repo
+		userMerge      usermerge.Repo
+		userStatus     userstatus.Repo
+		mailer         *mail.MailerInit
+		mailQueue      mailqueue.Repo
+		membership     membership.Repo
+		validate       *validator.Validate
+		webhook        webhook.Repo
 	}
 
 	TemplateHelper struct {
@@ -101,12 +133,39 @@ func New(conf *Config, host string, cdn *s3.S3) *Views {
 	v := &Views{}
 	// Connecting to stores
 	dbStore := db.NewStore(conf.DatabaseURL, host, conf.Logger)
-	v.officership = officership.NewOfficershipRepo(dbStore)
+	// one webhook repo is shared by every store so their changes and the events for them are saved together
+	v.webhook = webhook.NewWebhookRepo(dbStore)
+	v.officership = officership.NewOfficershipRepo(dbStore, v.webhook)
 	v.permission = permission.NewPermissionRepo(dbStore)
-	v.role = role.NewRoleRepo(dbStore)
-	v.user = user.NewUserRepo(dbStore, conf.CDNEndpoint)
+	v.role = role.NewRoleRepo(dbStore, v.webhook)
+	v.user = user.NewUserRepo(dbStore, conf.CDNEndpoint, v.webhook)
 	v.api = api.NewAPIRepo(dbStore)
 	v.crowd = crowd.NewCrowdRepo(dbStore)
+	v.accessRequest = accessrequest.NewAccessRequestRepo(dbStore, v.webhook)
+	v.accessReview = accessreview.NewAccessReviewRepo(dbStore)
+	v.membership = membership.NewMembershipRepo(dbStore, v.webhook)
+	v.keylist = keylist.NewKeylistRepo(dbStore)
+	v.dataExport = dataexport.NewDataExportRepo(dbStore)
+	v.userMerge = usermerge.NewUserMergeRepo(dbStore, v.webhook)
+	v.duplicate = duplicate.NewDuplicateRepo(dbStore)
+	v.emailChange = emailchange.NewEmailChangeRepo(dbStore, v.webhook)
+	v.emailTemplate = emailtemplate.NewEmailTemplateRepo(dbStore)
+	v.userEmail = useremail.NewUserEmailRepo(dbStore)
+	v.userStatus = userstatus.NewUserStatusRepo(dbStore, v.webhook)
+
+	passwordPolicy, err := passwordpolicy.NewPasswordPolicyRepo(dbStore, conf.PasswordPolicy)
+	if err != nil {
+		log.Fatalf("failed to load password policy: %+v", err)
+	}
+
+	v.passwordPolicy = passwordPolicy
+	v.userImport = userimport.NewUserImportRepo(dbStore, v.passwordPolicy)
+
+	// only one instance runs imports, so any still running were stopped by the restart
+	err = v.userImport.InterruptJobs(context.Background())
+	if err != nil {
+		log.Printf("failed to interrupt user imports: %+v", err)
+	}
 
 	v.cdn = cdn
 
@@ -124,6 +183,8 @@ func New(conf *Config, host string, cdn *s3.S3) *Views {
 		DomainName: conf.Mail.DomainName,
 	})
 
+	v.mailQueue = mailqueue.NewMailQueueRepo(dbStore, v.mailer)
+
 	// Initialising session cookie
 	authKey, _ := hex.DecodeString(conf.Security.AuthenticationKey)
 	if len(authKey) == 0 {
@@ -170,6 +231,80 @@ func New(conf *Config, host string, cdn *s3.S3) *Views {
 		}
 	}()
 
+	go func() {
+		for {
+			err := v.webhook.DeliverPending(context.Background())
+			if err != nil {
+				log.Printf("failed to deliver pending webhooks func: %+v", err)
+			}
+
+			time.Sleep(10 * time.Second)
+		}
+	}()
+
+	go func() {
+		for {
+			err := v.mailQueue.SendPending(context.Background())
+			if err != nil {
+				log.Printf("failed to send pending mail func: %+v", err)
+			}
+
+			time.Sleep(10 * time.Second)
+		}
+	}()
+
+	go func() {
+		for {
+			_, err := v.officership.SyncRoles(context.Background())
+			if err != nil {
+				log.Printf("failed to sync officership roles func: %+v", err)
+			}
+
+			time.Sleep(10 * time.Minute)
+		}
+	}()
+
+	go func() {
+		for {
+			err := v.expireRoleUsers(context.Background())
+			if err != nil {
+				log.Printf("failed to expire role users func: %+v", err)
+			}
+
+			err = v.processAccessReviews(context.Background())
+			if err != nil {
+				log.Printf("failed to process access reviews func: %+v", err)
+			}
+
+			err = v.processMemberships(context.Background())
+			if err != nil {
+				log.Printf("failed to process memberships func: %+v", err)
+			}
+
+			err = v.anonymiseDeletedUsers(context.Background())
+			if err != nil {
+				log.Printf("failed to anonymise deleted users func: %+v", err)
+			}
+
+			err = v.warnPasswordExpiry(context.Background())
+			if err != nil {
+				log.Printf("failed to warn password expiry func: %+v", err)
+			}
+
+			err = v.mailQueue.DeleteOldMessages(context.Background())
+			if err != nil {
+				log.Printf("failed to delete old mail func: %+v", err)
+			}
+
+			err = v.userImport.DeleteOldJobs(context.Background(), userImportMaxAge)
+			if err != nil {
+				log.Printf("failed to delete old user imports func: %+v", err)
+			}
+
+			time.Sleep(1 * time.Hour)
+		}
+	}()
+
 	return v
 }
 
@@ -0,0 +1,288 @@
+package views
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/ystv/web-auth/templates"
+	"github.com/ystv/web-auth/utils"
+	"github.com/ystv/web-auth/webhook"
+)
+
+type (
+	WebhooksTemplate struct {
+		Webhooks []webhook.Webhook
+		Events   []webhook.Event
+		Error    string
+		TemplateHelper
+	}
+
+	WebhookTemplate struct {
+		Webhook    webhook.Webhook
+		Deliveries []webhook.Delivery
+		Events     []webhook.Event
+		Error      string
+		TemplateHelper
+	}
+)
+
+// webhookDeliveryLogLength is the number of deliveries shown on a webhook's page
+const webhookDeliveryLogLength = 100
+
+// WebhooksFunc lists the webhooks
+func (v *Views) WebhooksFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodGet {
+		c1 := v.getSessionData(c)
+
+		webhooks, err := v.webhook.GetWebhooks(c.Request().Context())
+		if err != nil {
+			return fmt.Errorf("failed to get webhooks: %w", err)
+		}
+
+		p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+		if err != nil {
+			return fmt.Errorf("failed to get user permissions for webhooks: %w", err)
+		}
+
+		data := WebhooksTemplate{
+			Webhooks: webhooks,
+			Events:   webhook.Events,
+			Error:    c.QueryParam("error"),
+			TemplateHelper: TemplateHelper{
+				UserPermissions: p1,
+				ActivePage:      "webhooks",
+				Assumed:         c1.Assumed,
+			},
+		}
+
+		return v.template.RenderTemplate(c.Response(), data, templates.WebhooksTemplate, templates.RegularType)
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// WebhookFunc shows a webhook and its delivery log
+func (v *Views) WebhookFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodGet {
+		c1 := v.getSessionData(c)
+
+		webhookID, err := strconv.Atoi(c.Param("webhookid"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Errorf("failed to parse webhookid for webhook: %w", err))
+		}
+
+		webhook1, err := v.webhook.GetWebhook(c.Request().Context(), webhook.Webhook{WebhookID: webhookID})
+		if err != nil {
+			return fmt.Errorf("failed to get webhook: %w", err)
+		}
+
+		deliveries, err := v.webhook.GetDeliveries(c.Request().Context(), webhook1, webhookDeliveryLogLength)
+		if err != nil {
+			return fmt.Errorf("failed to get deliveries for webhook: %w", err)
+		}
+
+		p1, err := v.user.GetPermissionsForUser(c.Request().Context(), c1.User)
+		if err != nil {
+			return fmt.Errorf("failed to get user permissions for webhook: %w", err)
+		}
+
+		data := WebhookTemplate{
+			Webhook:    webhook1,
+			Deliveries: deliveries,
+			Events:     webhook.Events,
+			Error:      c.QueryParam("error"),
+			TemplateHelper: TemplateHelper{
+				UserPermissions: p1,
+				ActivePage:      "webhook",
+				Assumed:         c1.Assumed,
+			},
+		}
+
+		return v.template.RenderTemplate(c.Response(), data, templates.WebhookTemplate, templates.RegularType)
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// WebhookAddFunc adds a webhook with a generated secret
+func (v *Views) WebhookAddFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		c1 := v.getSessionData(c)
+
+		webhook1, err := v.parseWebhookForm(c)
+		if err != nil {
+			return c.Redirect(http.StatusFound, "/internal/webhooks?error="+url.QueryEscape(err.Error()))
+		}
+
+		webhook1.Secret, err = utils.GenerateRandomLength(40, utils.GeneratePassword)
+		if err != nil {
+			return fmt.Errorf("error generating secret: %w", err)
+		}
+
+		webhook1.CreatedBy = null.IntFrom(int64(c1.User.UserID))
+
+		webhook1, err = v.webhook.AddWebhook(c.Request().Context(), webhook1)
+		if err != nil {
+			return fmt.Errorf("failed to add webhook for addWebhook: %w", err)
+		}
+
+		return c.Redirect(http.StatusFound, fmt.Sprintf("/internal/webhook/%d", webhook1.WebhookID))
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// WebhookEditFunc edits a webhook, optionally regenerating the secret
+func (v *Views) WebhookEditFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		webhookID, err := strconv.Atoi(c.Param("webhookid"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to parse webhookid for webhook edit: %w", err))
+		}
+
+		webhook1, err := v.webhook.GetWebhook(c.Request().Context(), webhook.Webhook{WebhookID: webhookID})
+		if err != nil {
+			return fmt.Errorf("failed to get webhook for webhook edit: %w", err)
+		}
+
+		edited, err := v.parseWebhookForm(c)
+		if err != nil {
+			return c.Redirect(http.StatusFound, fmt.Sprintf("/internal/webhook/%d?error=%s", webhookID,
+				url.QueryEscape(err.Error())))
+		}
+
+		webhook1.Name = edited.Name
+		webhook1.URL = edited.URL
+		webhook1.Events = edited.Events
+		webhook1.Active = edited.Active
+
+		if c.FormValue("regenerateSecret") == "on" {
+			webhook1.Secret, err = utils.GenerateRandomLength(40, utils.GeneratePassword)
+			if err != nil {
+				return fmt.Errorf("error generating secret: %w", err)
+			}
+		}
+
+		_, err = v.webhook.EditWebhook(c.Request().Context(), webhook1)
+		if err != nil {
+			return fmt.Errorf("failed to edit webhook for webhook edit: %w", err)
+		}
+
+		return c.Redirect(http.StatusFound, fmt.Sprintf("/internal/webhook/%d", webhookID))
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// WebhookDeleteFunc deletes a webhook
+func (v *Views) WebhookDeleteFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		webhookID, err := strconv.Atoi(c.Param("webhookid"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to parse webhookid for webhook delete: %w", err))
+		}
+
+		webhook1, err := v.webhook.GetWebhook(c.Request().Context(), webhook.Webhook{WebhookID: webhookID})
+		if err != nil {
+			return fmt.Errorf("failed to get webhook for webhook delete: %w", err)
+		}
+
+		err = v.webhook.DeleteWebhook(c.Request().Context(), webhook1)
+		if err != nil {
+			return fmt.Errorf("failed to delete webhook for webhook delete: %w", err)
+		}
+
+		return c.Redirect(http.StatusFound, "/internal/webhooks")
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// WebhookRedeliverFunc queues a delivery again with the original payload
+func (v *Views) WebhookRedeliverFunc(c echo.Context) error {
+	if c.Request().Method == http.MethodPost {
+		webhookID, err := strconv.Atoi(c.Param("webhookid"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to parse webhookid for webhook redeliver: %w", err))
+		}
+
+		deliveryID, err := strconv.Atoi(c.Param("deliveryid"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				fmt.Errorf("failed to parse deliveryid for webhook redeliver: %w", err))
+		}
+
+		delivery, err := v.webhook.GetDelivery(c.Request().Context(), webhook.Delivery{DeliveryID: deliveryID})
+		if err != nil {
+			return fmt.Errorf("failed to get delivery for webhook redeliver: %w", err)
+		}
+
+		if delivery.WebhookID != webhookID {
+			return echo.NewHTTPError(http.StatusBadRequest,
+				errors.New("delivery does not belong to this webhook"))
+		}
+
+		_, err = v.webhook.Redeliver(c.Request().Context(), delivery)
+		if err != nil {
+			return fmt.Errorf("failed to redeliver for webhook redeliver: %w", err)
+		}
+
+		return c.Redirect(http.StatusFound, fmt.Sprintf("/internal/webhook/%d", webhookID))
+	}
+
+	return v.invalidMethodUsed(c)
+}
+
+// parseWebhookForm reads the fields shared by the add and edit forms
+func (v *Views) parseWebhookForm(c echo.Context) (webhook.Webhook, error) {
+	var w webhook.Webhook
+
+	w.Name = c.FormValue("name")
+	if len(w.Name) == 0 {
+		return w, errors.New("name must be filled")
+	}
+
+	w.URL = c.FormValue("url")
+
+	u, err := url.ParseRequestURI(w.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || len(u.Host) == 0 {
+		return w, errors.New("url must be a valid http or https url")
+	}
+
+	params, err := c.FormParams()
+	if err != nil {
+		return w, fmt.Errorf("failed to get form params: %w", err)
+	}
+
+	valid := make(map[string]bool)
+	for _, e := range webhook.Events {
+		valid[e.String()] = true
+	}
+
+	valid[webhook.AllEvents.String()] = true
+
+	for _, e := range params["events"] {
+		if !valid[e] {
+			return w, fmt.Errorf("invalid event: %s", e)
+		}
+
+		w.Events = append(w.Events, e)
+	}
+
+	if len(w.Events) == 0 {
+		return w, errors.New("at least one event must be selected")
+	}
+
+	w.Active = c.FormValue("active") == "on"
+
+	return w, nil
+}
@@ -0,0 +1,340 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/ystv/web-auth/utils"
+)
+
+// getWebhooks returns all webhooks
+func (s *Store) getWebhooks(ctx context.Context) ([]Webhook, error) {
+	var w []Webhook
+
+	builder := utils.PSQL().Select("*").
+		From("web_auth.webhooks").
+		OrderBy("name")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getWebhooks: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &w, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	return w, nil
+}
+
+// getWebhook returns a specific webhook
+func (s *Store) getWebhook(ctx context.Context, w1 Webhook) (Webhook, error) {
+	var w Webhook
+
+	builder := utils.PSQL().Select("*").
+		From("web_auth.webhooks").
+		Where(sq.Eq{"webhook_id": w1.WebhookID}).
+		Limit(1)
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getWebhook: %w", err))
+	}
+
+	err = s.db.GetContext(ctx, &w, sql, args...)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return w, nil
+}
+
+// addWebhook adds a new webhook
+func (s *Store) addWebhook(ctx context.Context, w Webhook) (Webhook, error) {
+	builder := utils.PSQL().Insert("web_auth.webhooks").
+		Columns("name", "url", "secret", "events", "active", "created_by").
+		Values(w.Name, w.URL, w.Secret, w.Events, w.Active, w.CreatedBy).
+		Suffix("RETURNING webhook_id, created_at")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for addWebhook: %w", err))
+	}
+
+	stmt, err := s.db.PrepareContext(ctx, sql)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("failed to add webhook: %w", err)
+	}
+
+	defer stmt.Close()
+
+	err = stmt.QueryRow(args...).Scan(&w.WebhookID, &w.CreatedAt)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("failed to add webhook: %w", err)
+	}
+
+	return w, nil
+}
+
+// editWebhook edits an existing webhook
+func (s *Store) editWebhook(ctx context.Context, w Webhook) (Webhook, error) {
+	builder := utils.PSQL().Update("web_auth.webhooks").
+		SetMap(map[string]interface{}{
+			"name":   w.Name,
+			"url":    w.URL,
+			"secret": w.Secret,
+			"events": w.Events,
+			"active": w.Active,
+		}).
+		Where(sq.Eq{"webhook_id": w.WebhookID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for editWebhook: %w", err))
+	}
+
+	res, err := s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("failed to edit webhook: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return Webhook{}, fmt.Errorf("failed to edit webhook: %w", err)
+	}
+
+	if rows < 1 {
+		return Webhook{}, fmt.Errorf("failed to edit webhook: invalid rows affected: %d, this webhook may not exist: %d",
+			rows, w.WebhookID)
+	}
+
+	return w, nil
+}
+
+// deleteWebhook deletes a webhook, the deliveries are removed by the cascade
+func (s *Store) deleteWebhook(ctx context.Context, w Webhook) error {
+	builder := utils.PSQL().Delete("web_auth.webhooks").
+		Where(sq.Eq{"webhook_id": w.WebhookID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for deleteWebhook: %w", err))
+	}
+
+	_, err = s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	return nil
+}
+
+// getDeliveries returns the most recent deliveries for a webhook
+func (s *Store) getDeliveries(ctx context.Context, w Webhook, limit int) ([]Delivery, error) {
+	var d []Delivery
+
+	builder := utils.PSQL().Select("d.*", "w.url", "w.secret").
+		From("web_auth.webhook_deliveries d").
+		LeftJoin("web_auth.webhooks w ON w.webhook_id = d.webhook_id").
+		Where(sq.Eq{"d.webhook_id": w.WebhookID}).
+		OrderBy("d.created_at DESC", "d.delivery_id DESC").
+		Limit(uint64(limit))
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getDeliveries: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &d, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deliveries: %w", err)
+	}
+
+	return d, nil
+}
+
+// getDelivery returns a specific delivery
+func (s *Store) getDelivery(ctx context.Context, d1 Delivery) (Delivery, error) {
+	var d Delivery
+
+	builder := utils.PSQL().Select("d.*", "w.url", "w.secret").
+		From("web_auth.webhook_deliveries d").
+		LeftJoin("web_auth.webhooks w ON w.webhook_id = d.webhook_id").
+		Where(sq.Eq{"d.delivery_id": d1.DeliveryID}).
+		Limit(1)
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getDelivery: %w", err))
+	}
+
+	err = s.db.GetContext(ctx, &d, sql, args...)
+	if err != nil {
+		return Delivery{}, fmt.Errorf("failed to get delivery: %w", err)
+	}
+
+	return d, nil
+}
+
+// getDeliveriesForUser returns the deliveries whose data has the user's id, which is every user, role
+// membership and officership member event about them
+func (s *Store) getDeliveriesForUser(ctx context.Context, userID int) ([]Delivery, error) {
+	var d []Delivery
+
+	builder := utils.PSQL().Select("d.*", "w.url", "w.secret").
+		From("web_auth.webhook_deliveries d").
+		LeftJoin("web_auth.webhooks w ON w.webhook_id = d.webhook_id").
+		Where(sq.Expr("(d.payload::jsonb -> 'data' ->> 'userID') = ?", strconv.Itoa(userID))).
+		OrderBy("d.created_at DESC", "d.delivery_id DESC")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for getDeliveriesForUser: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &d, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deliveries for user: %w", err)
+	}
+
+	return d, nil
+}
+
+// claimDueDeliveriesBuilder pushes back the next attempt of the pending deliveries that are due for active webhooks,
+// the rows are locked and skipped by anyone else claiming at the same time so each delivery is only sent by one worker
+func claimDueDeliveriesBuilder() sq.UpdateBuilder {
+	return utils.PSQL().Update("web_auth.webhook_deliveries d").
+		Set("next_attempt_at", time.Now().Add(claimFor)).
+		From("web_auth.webhooks w").
+		Where("w.webhook_id = d.webhook_id").
+		Where(sq.Expr(`d.delivery_id IN (SELECT due.delivery_id FROM web_auth.webhook_deliveries due
+			INNER JOIN web_auth.webhooks hook ON hook.webhook_id = due.webhook_id
+			WHERE due.status = ? AND due.next_attempt_at <= NOW() AND hook.active
+			ORDER BY due.next_attempt_at
+			LIMIT ?
+			FOR UPDATE OF due SKIP LOCKED)`, Pending, batchSize)).
+		Suffix("RETURNING d.*, w.url, w.secret")
+}
+
+// claimDueDeliveries claims the deliveries that are due and returns them
+func (s *Store) claimDueDeliveries(ctx context.Context) ([]Delivery, error) {
+	var d []Delivery
+
+	sql, args, err := claimDueDeliveriesBuilder().ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for claimDueDeliveries: %w", err))
+	}
+
+	err = s.db.SelectContext(ctx, &d, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due deliveries: %w", err)
+	}
+
+	return d, nil
+}
+
+// addDelivery queues a new delivery for a webhook
+func (s *Store) addDelivery(ctx context.Context, d Delivery) (Delivery, error) {
+	builder := utils.PSQL().Insert("web_auth.webhook_deliveries").
+		Columns("webhook_id", "event", "payload").
+		Values(d.WebhookID, d.Event, d.Payload).
+		Suffix("RETURNING delivery_id, status, attempts, next_attempt_at, created_at")
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for addDelivery: %w", err))
+	}
+
+	stmt, err := s.db.PrepareContext(ctx, sql)
+	if err != nil {
+		return Delivery{}, fmt.Errorf("failed to add delivery: %w", err)
+	}
+
+	defer stmt.Close()
+
+	err = stmt.QueryRow(args...).Scan(&d.DeliveryID, &d.Status, &d.Attempts, &d.NextAttemptAt, &d.CreatedAt)
+	if err != nil {
+		return Delivery{}, fmt.Errorf("failed to add delivery: %w", err)
+	}
+
+	d.LastAttemptAt.Valid = false
+	d.ResponseCode.Valid = false
+	d.ResponseBody.Valid = false
+	d.Error.Valid = false
+
+	return d, nil
+}
+
+// queueDeliveries adds a delivery of the payload for every active webhook subscribed to the event
+func (s *Store) queueDeliveries(ctx context.Context, tx sqlx.ExecerContext, e Event, payload string) error {
+	subscribers := utils.PSQL().Select("webhook_id").
+		Column("?", e).
+		Column("?", payload).
+		From("web_auth.webhooks").
+		Where(sq.And{
+			sq.Eq{"active": true},
+			sq.Or{
+				sq.Expr("? = ANY(events)", e),
+				sq.Expr("? = ANY(events)", AllEvents),
+			},
+		})
+
+	builder := utils.PSQL().Insert("web_auth.webhook_deliveries").
+		Columns("webhook_id", "event", "payload").
+		Select(subscribers)
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for queueDeliveries: %w", err))
+	}
+
+	_, err = tx.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to queue deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// editDelivery updates the result of a delivery attempt
+func (s *Store) editDelivery(ctx context.Context, d Delivery) error {
+	builder := utils.PSQL().Update("web_auth.webhook_deliveries").
+		SetMap(map[string]interface{}{
+			"status":          d.Status,
+			"attempts":        d.Attempts,
+			"next_attempt_at": d.NextAttemptAt,
+			"last_attempt_at": d.LastAttemptAt,
+			"response_code":   d.ResponseCode,
+			"response_body":   d.ResponseBody,
+			"error":           d.Error,
+		}).
+		Where(sq.Eq{"delivery_id": d.DeliveryID})
+
+	sql, args, err := builder.ToSql()
+	if err != nil {
+		panic(fmt.Errorf("failed to build sql for editDelivery: %w", err))
+	}
+
+	res, err := s.db.ExecContext(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("failed to edit delivery: %w", err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to edit delivery: %w", err)
+	}
+
+	if rows < 1 {
+		return fmt.Errorf("failed to edit delivery: invalid rows affected: %d, this delivery may not exist: %d",
+			rows, d.DeliveryID)
+	}
+
+	return nil
+}
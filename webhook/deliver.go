@@ -0,0 +1,119 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/guregu/null.v4"
+)
+
+// DeliverPending claims the pending deliveries that are due and attempts them, rescheduling failures with back-off,
+// when the result can't be saved it is saved again without the response body so the attempt still counts, if that
+// fails too it is logged and tried again once its claim runs out
+func (s *Store) DeliverPending(ctx context.Context) error {
+	deliveries, err := s.claimDueDeliveries(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to claim due deliveries for deliverPending: %w", err)
+	}
+
+	for _, d := range deliveries {
+		d = s.attempt(ctx, d)
+
+		err = s.editDelivery(ctx, d)
+		if err == nil {
+			continue
+		}
+
+		log.Printf("failed to edit delivery %d for deliverPending, saving it without the response body: %+v",
+			d.DeliveryID, err)
+
+		d.ResponseBody = null.String{}
+
+		err = s.editDelivery(ctx, d)
+		if err != nil {
+			log.Printf("failed to edit delivery %d for deliverPending: %+v", d.DeliveryID, err)
+		}
+	}
+
+	return nil
+}
+
+// attempt sends the delivery to the webhook and records the outcome on the delivery
+func (s *Store) attempt(ctx context.Context, d Delivery) Delivery {
+	d.Attempts++
+	d.LastAttemptAt = null.TimeFrom(time.Now())
+	d.ResponseCode = null.Int{}
+	d.ResponseBody = null.String{}
+	d.Error = null.String{}
+
+	code, body, err := s.send(ctx, d)
+	if err == nil {
+		d.ResponseCode = null.IntFrom(int64(code))
+		d.ResponseBody = null.StringFrom(body)
+
+		if code >= 200 && code < 300 {
+			d.Status = Succeeded
+
+			return d
+		}
+
+		err = fmt.Errorf("receiver responded with status %d", code)
+	}
+
+	d.Error = null.StringFrom(err.Error())
+
+	if d.Attempts >= maxAttempts {
+		d.Status = Failed
+
+		return d
+	}
+
+	d.Status = Pending
+	d.NextAttemptAt = time.Now().Add(backOff(d.Attempts))
+
+	return d
+}
+
+// send posts the signed payload to the webhook, returning the status code and a truncated body that can be stored
+// as text
+func (s *Store) send(ctx context.Context, d Delivery) (int, string, error) {
+	body := []byte(d.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "web-auth-webhook")
+	req.Header.Set(EventHeader, d.Event.String())
+	req.Header.Set(DeliveryHeader, strconv.Itoa(d.DeliveryID))
+	req.Header.Set(SignatureHeader, Sign(d.Secret, body))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to send request: %w", err)
+	}
+
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(io.LimitReader(res.Body, maxResponseBody))
+	if err != nil {
+		return res.StatusCode, "", nil
+	}
+
+	return res.StatusCode, cleanResponseBody(resBody), nil
+}
+
+// cleanResponseBody makes a response body safe for a text column, truncating it can split a character and the
+// receiver can send anything so invalid UTF-8 and NUL bytes are dropped
+func cleanResponseBody(b []byte) string {
+	return strings.ReplaceAll(strings.ToValidUTF8(string(b), ""), "\x00", "")
+}
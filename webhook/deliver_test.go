@@ -0,0 +1,138 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimDueDeliveriesSQL(t *testing.T) {
+	// the claim has to lock the rows it takes and skip the ones another worker has, otherwise two workers send the
+	// same delivery
+	builder := claimDueDeliveriesBuilder()
+
+	sql, args, err := builder.ToSql()
+	require.NoError(t, err)
+
+	assert.Contains(t, sql, "FOR UPDATE OF due SKIP LOCKED")
+	assert.Contains(t, sql, "RETURNING d.*, w.url, w.secret")
+	assert.Len(t, args, 3)
+	assert.Equal(t, Pending, args[1])
+	assert.Equal(t, batchSize, args[2])
+}
+
+func TestCleanResponseBody(t *testing.T) {
+	// the last character was split by the truncation
+	assert.Equal(t, "caf", cleanResponseBody([]byte("caf\xc3")))
+	assert.Equal(t, "ok", cleanResponseBody([]byte("o\x00k")))
+	assert.Equal(t, "bad  ok", cleanResponseBody([]byte("bad \xff\xfe ok")))
+	assert.Equal(t, "café", cleanResponseBody([]byte("café")))
+}
+
+func TestAttempt(t *testing.T) {
+	status := http.StatusOK
+
+	var received *http.Request
+
+	var body string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		received = r
+		body = string(b)
+
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(strings.Repeat("a", maxResponseBody+10)))
+	}))
+	defer server.Close()
+
+	s := &Store{client: server.Client()}
+
+	d := Delivery{
+		DeliveryID: 1,
+		Event:      UserCreated,
+		Payload:    `{"event":"user.created"}`,
+		Status:     Pending,
+		URL:        server.URL,
+		Secret:     "secret",
+	}
+
+	t.Run("Delivered", func(t *testing.T) {
+		status = http.StatusOK
+
+		d1 := s.attempt(context.Background(), d)
+
+		assert.Equal(t, Succeeded, d1.Status)
+		assert.Equal(t, 1, d1.Attempts)
+		assert.Equal(t, int64(http.StatusOK), d1.ResponseCode.Int64)
+		assert.Len(t, d1.ResponseBody.String, maxResponseBody)
+		assert.False(t, d1.Error.Valid)
+
+		require.NotNil(t, received)
+		assert.Equal(t, d.Payload, body)
+		assert.Equal(t, Sign("secret", []byte(d.Payload)), received.Header.Get(SignatureHeader))
+		assert.Equal(t, "user.created", received.Header.Get(EventHeader))
+		assert.Equal(t, "1", received.Header.Get(DeliveryHeader))
+	})
+
+	t.Run("Retried", func(t *testing.T) {
+		status = http.StatusInternalServerError
+
+		d1 := s.attempt(context.Background(), d)
+
+		assert.Equal(t, Pending, d1.Status)
+		assert.Equal(t, 1, d1.Attempts)
+		assert.Equal(t, int64(http.StatusInternalServerError), d1.ResponseCode.Int64)
+		assert.Equal(t, "receiver responded with status 500", d1.Error.String)
+		assert.WithinDuration(t, time.Now().Add(backOff(1)), d1.NextAttemptAt, time.Second)
+
+		d1 = s.attempt(context.Background(), d1)
+
+		assert.Equal(t, 2, d1.Attempts)
+		assert.WithinDuration(t, time.Now().Add(backOff(2)), d1.NextAttemptAt, time.Second)
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		status = http.StatusBadGateway
+
+		d1 := d
+		d1.Attempts = maxAttempts - 1
+
+		d1 = s.attempt(context.Background(), d1)
+
+		assert.Equal(t, Failed, d1.Status)
+		assert.Equal(t, maxAttempts, d1.Attempts)
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		d1 := d
+		d1.URL = "http://127.0.0.1:0"
+
+		d1 = s.attempt(context.Background(), d1)
+
+		assert.Equal(t, Pending, d1.Status)
+		assert.False(t, d1.ResponseCode.Valid)
+		assert.True(t, d1.Error.Valid)
+	})
+
+	t.Run("DeliveredAfterRetry", func(t *testing.T) {
+		status = http.StatusServiceUnavailable
+
+		d1 := s.attempt(context.Background(), d)
+
+		status = http.StatusNoContent
+
+		d1 = s.attempt(context.Background(), d1)
+
+		assert.Equal(t, Succeeded, d1.Status)
+		assert.Equal(t, 2, d1.Attempts)
+		assert.False(t, d1.Error.Valid)
+	})
+}
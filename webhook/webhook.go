@@ -0,0 +1,274 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"gopkg.in/guregu/null.v4"
+)
+
+//go:generate mockgen -destination mocks/mock_webhook.go -package mock_webhook github.com/ystv/web-auth/webhook Repo
+
+type (
+	Repo interface {
+		GetWebhooks(context.Context) ([]Webhook, error)
+		GetWebhook(context.Context, Webhook) (Webhook, error)
+		AddWebhook(context.Context, Webhook) (Webhook, error)
+		EditWebhook(context.Context, Webhook) (Webhook, error)
+		DeleteWebhook(context.Context, Webhook) error
+		GetDeliveries(context.Context, Webhook, int) ([]Delivery, error)
+		GetDelivery(context.Context, Delivery) (Delivery, error)
+		GetDeliveriesForUser(context.Context, int) ([]Delivery, error)
+		Redeliver(context.Context, Delivery) (Delivery, error)
+		Emit(context.Context, sqlx.ExecerContext, Event, interface{}) error
+		DeliverPending(context.Context) error
+	}
+
+	// Store stores the dependencies
+	Store struct {
+		db     *sqlx.DB
+		client *http.Client
+	}
+
+	// Webhook is a subscription of an external URL to a set of events
+	Webhook struct {
+		WebhookID int            `db:"webhook_id" json:"webhookID"`
+		Name      string         `db:"name" json:"name"`
+		URL       string         `db:"url" json:"url"`
+		Secret    string         `db:"secret" json:"-"`
+		Events    pq.StringArray `db:"events" json:"events"`
+		Active    bool           `db:"active" json:"active"`
+		CreatedAt null.Time      `db:"created_at" json:"createdAt"`
+		CreatedBy null.Int       `db:"created_by" json:"createdBy"`
+	}
+
+	// Delivery is a single payload queued or sent to a Webhook
+	Delivery struct {
+		DeliveryID    int            `db:"delivery_id" json:"deliveryID"`
+		WebhookID     int            `db:"webhook_id" json:"webhookID"`
+		Event         Event          `db:"event" json:"event"`
+		Payload       string         `db:"payload" json:"payload"`
+		Status        DeliveryStatus `db:"status" json:"status"`
+		Attempts      int            `db:"attempts" json:"attempts"`
+		NextAttemptAt time.Time      `db:"next_attempt_at" json:"nextAttemptAt"`
+		LastAttemptAt null.Time      `db:"last_attempt_at" json:"lastAttemptAt"`
+		ResponseCode  null.Int       `db:"response_code" json:"responseCode"`
+		ResponseBody  null.String    `db:"response_body" json:"responseBody"`
+		Error         null.String    `db:"error" json:"error"`
+		CreatedAt     time.Time      `db:"created_at" json:"createdAt"`
+		URL           string         `db:"url" json:"-"`
+		Secret        string         `db:"secret" json:"-"`
+	}
+
+	// Payload is the body sent to the receiver of a webhook
+	Payload struct {
+		Event     Event       `json:"event"`
+		Timestamp time.Time   `json:"timestamp"`
+		Data      interface{} `json:"data"`
+	}
+
+	// Event is the name of something that happened which can be subscribed to
+	Event string
+
+	// DeliveryStatus is the state of a Delivery in the queue
+	DeliveryStatus string
+)
+
+const (
+	AllEvents Event = "*"
+
+	UserCreated Event = "user.created"
+	UserUpdated Event = "user.updated"
+	// UserDisabled is emitted along with UserUpdated when a user is disabled
+	UserDisabled Event = "user.disabled"
+	UserDeleted  Event = "user.deleted"
+
+	RoleCreated           Event = "role.created"
+	RoleUpdated           Event = "role.updated"
+	RoleDeleted           Event = "role.deleted"
+	RoleUserAdded         Event = "role.user_added"
+	RoleUserRemoved       Event = "role.user_removed"
+	RolePermissionAdded   Event = "role.permission_added"
+	RolePermissionRemoved Event = "role.permission_removed"
+	RoleInclusionAdded    Event = "role.inclusion_added"
+	RoleInclusionRemoved  Event = "role.inclusion_removed"
+
+	OfficershipCreated       Event = "officership.created"
+	OfficershipUpdated       Event = "officership.updated"
+	OfficershipDeleted       Event = "officership.deleted"
+	OfficershipMemberAdded   Event = "officership.member_added"
+	OfficershipMemberUpdated Event = "officership.member_updated"
+	OfficershipMemberRemoved Event = "officership.member_removed"
+)
+
+const (
+	Pending   DeliveryStatus = "pending"
+	Succeeded DeliveryStatus = "succeeded"
+	Failed    DeliveryStatus = "failed"
+)
+
+const (
+	// SignatureHeader contains the hex encoded HMAC-SHA256 of the body using the webhook secret
+	SignatureHeader = "X-Webhook-Signature"
+	// EventHeader contains the Event of the payload
+	EventHeader = "X-Webhook-Event"
+	// DeliveryHeader contains the delivery id, this is the same for every attempt of a delivery
+	DeliveryHeader = "X-Webhook-Delivery"
+
+	// maxAttempts is the number of attempts before a delivery is marked as failed
+	maxAttempts = 10
+	// maxResponseBody is the number of bytes of the receiver's response stored in the log
+	maxResponseBody = 4096
+	// batchSize is the number of deliveries claimed by each run of DeliverPending
+	batchSize = 50
+	// claimFor is how long a claimed delivery is left alone by other workers, long enough for every request in a
+	// batch to time out
+	claimFor = 10 * time.Minute
+)
+
+// Events is the list of events that can be subscribed to, used for the admin UI
+//
+//nolint:gochecknoglobals
+var Events = []Event{
+	UserCreated,
+	UserUpdated,
+	UserDisabled,
+	UserDeleted,
+	RoleCreated,
+	RoleUpdated,
+	RoleDeleted,
+	RoleUserAdded,
+	RoleUserRemoved,
+	RolePermissionAdded,
+	RolePermissionRemoved,
+	RoleInclusionAdded,
+	RoleInclusionRemoved,
+	OfficershipCreated,
+	OfficershipUpdated,
+	OfficershipDeleted,
+	OfficershipMemberAdded,
+	OfficershipMemberUpdated,
+	OfficershipMemberRemoved,
+}
+
+var _ Repo = &Store{}
+
+// NewWebhookRepo stores our dependency
+func NewWebhookRepo(db *sqlx.DB) *Store {
+	return &Store{
+		db: db,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// String returns the string equivalent of Event
+func (e Event) String() string {
+	return string(e)
+}
+
+// Subscribed returns if the webhook should receive the event
+func (w Webhook) Subscribed(e Event) bool {
+	for _, event := range w.Events {
+		if event == e.String() || event == AllEvents.String() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetWebhooks returns all webhooks
+func (s *Store) GetWebhooks(ctx context.Context) ([]Webhook, error) {
+	return s.getWebhooks(ctx)
+}
+
+// GetWebhook returns a webhook
+func (s *Store) GetWebhook(ctx context.Context, w Webhook) (Webhook, error) {
+	return s.getWebhook(ctx, w)
+}
+
+// AddWebhook adds a webhook
+func (s *Store) AddWebhook(ctx context.Context, w Webhook) (Webhook, error) {
+	return s.addWebhook(ctx, w)
+}
+
+// EditWebhook edits a webhook
+func (s *Store) EditWebhook(ctx context.Context, w Webhook) (Webhook, error) {
+	return s.editWebhook(ctx, w)
+}
+
+// DeleteWebhook deletes a webhook and its delivery log
+func (s *Store) DeleteWebhook(ctx context.Context, w Webhook) error {
+	return s.deleteWebhook(ctx, w)
+}
+
+// GetDeliveries returns the most recent deliveries of a webhook
+func (s *Store) GetDeliveries(ctx context.Context, w Webhook, limit int) ([]Delivery, error) {
+	return s.getDeliveries(ctx, w, limit)
+}
+
+// GetDelivery returns a delivery
+func (s *Store) GetDelivery(ctx context.Context, d Delivery) (Delivery, error) {
+	return s.getDelivery(ctx, d)
+}
+
+// GetDeliveriesForUser returns every delivery whose payload is about a user, newest first
+func (s *Store) GetDeliveriesForUser(ctx context.Context, userID int) ([]Delivery, error) {
+	return s.getDeliveriesForUser(ctx, userID)
+}
+
+// Redeliver queues a new delivery with the same payload as an existing delivery
+func (s *Store) Redeliver(ctx context.Context, d Delivery) (Delivery, error) {
+	delivery, err := s.getDelivery(ctx, d)
+	if err != nil {
+		return Delivery{}, fmt.Errorf("failed to get delivery for redeliver: %w", err)
+	}
+
+	return s.addDelivery(ctx, delivery)
+}
+
+// Emit queues a delivery of the event for every active webhook subscribed to it, it is given the transaction making
+// the change so the event is only sent if the change is committed and the change is rolled back if it can't be queued
+func (s *Store) Emit(ctx context.Context, tx sqlx.ExecerContext, e Event, data interface{}) error {
+	payload, err := json.Marshal(Payload{
+		Event:     e,
+		Timestamp: time.Now(),
+		Data:      data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload for emit: %w", err)
+	}
+
+	return s.queueDeliveries(ctx, tx, e, string(payload))
+}
+
+// Sign returns the signature of the body for the secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// backOff returns the time to wait before the next attempt, doubling each attempt starting at a minute
+func backOff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	if attempts > maxAttempts {
+		attempts = maxAttempts
+	}
+
+	return time.Duration(1<<(attempts-1)) * time.Minute
+}
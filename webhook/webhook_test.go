@@ -0,0 +1,41 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	// known value from: echo -n '{"event":"user.created"}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "sha256=e851f51160ef29a5847ccec510a3d5b801d448e9f8d769e8484a75fb4b9f6947",
+		Sign("secret", []byte(`{"event":"user.created"}`)))
+}
+
+func TestSubscribed(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []string
+		event  Event
+		want   bool
+	}{
+		{name: "Subscribed", events: []string{"user.created", "role.created"}, event: RoleCreated, want: true},
+		{name: "NotSubscribed", events: []string{"user.created"}, event: RoleCreated, want: false},
+		{name: "AllEvents", events: []string{"*"}, event: OfficershipMemberAdded, want: true},
+		{name: "NoEvents", events: nil, event: UserDeleted, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Webhook{Events: tt.events}.Subscribed(tt.event))
+		})
+	}
+}
+
+func TestBackOff(t *testing.T) {
+	assert.Equal(t, time.Minute, backOff(1))
+	assert.Equal(t, 2*time.Minute, backOff(2))
+	assert.Equal(t, 16*time.Minute, backOff(5))
+	assert.Equal(t, backOff(maxAttempts), backOff(maxAttempts+5))
+}